@@ -13,6 +13,7 @@ require (
 	github.com/ethereum/go-ethereum v1.10.3-0.20210419125455-653b7e959d57
 	github.com/fatih/structtag v1.2.0
 	github.com/go-kit/kit v0.10.0
+	github.com/go-redis/redis/v8 v8.11.4
 	github.com/google/go-github/v35 v35.3.1-0.20210613000602-77dd0eb64ad2
 	github.com/joho/godotenv v1.3.0
 	github.com/json-iterator/go v1.1.11
@@ -26,8 +27,18 @@ require (
 	github.com/rjeczalik/notify v0.9.2 // indirect
 	github.com/status-im/keycard-go v0.0.0-20190424133014-d95853db0f48 // indirect
 	github.com/tyler-smith/go-bip39 v1.0.2 // indirect
+	github.com/xitongsys/parquet-go v1.6.2
+	github.com/xitongsys/parquet-go-source v0.0.0-20200817004010-026bad9b25d0 // indirect
 	github.com/yalp/jsonpath v0.0.0-20180802001716-5cc68e5049a0
+	go.opentelemetry.io/otel v1.7.0
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp v1.7.0
+	go.opentelemetry.io/otel/sdk v1.7.0
+	go.opentelemetry.io/otel/trace v1.7.0
 	go.uber.org/goleak v1.1.10
 	golang.org/x/crypto v0.0.0-20210322153248-0c34fe9e7dc2
+	golang.org/x/sys v0.0.0-20210510120138-977fb7262007
+	golang.org/x/time v0.0.0-20210220033141-f8bda1e9f3ba
 	golang.org/x/tools v0.1.1-0.20210317201901-4599a76b0b9a // indirect
+	google.golang.org/grpc v1.46.0
+	google.golang.org/protobuf v1.28.0
 )
@@ -15,8 +15,13 @@ import (
 
 	"github.com/go-kit/kit/log"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/timestamp"
 	"github.com/prometheus/prometheus/promql"
 	"github.com/prometheus/prometheus/storage"
+	"github.com/tellor-io/telliot/pkg/calendar"
 	"github.com/tellor-io/telliot/pkg/format"
 	"github.com/tellor-io/telliot/pkg/logging"
 	"github.com/tellor-io/telliot/pkg/tracker/index"
@@ -31,6 +36,19 @@ type IAggregator interface {
 type Config struct {
 	LogLevel       string
 	ManualDataFile string
+	// DerivedFile maps symbols to expressions of other symbols like `[TRB/ETH] * [ETH/USD]`
+	// which are aggregated like the symbols recorded by the index tracker.
+	DerivedFile string
+	// RemoteURL is the instance running in the aggregator role
+	// which the submitter role gets the aggregated values from.
+	RemoteURL string
+	// CalendarFile sets the markets of the symbols tied to the traditional markets,
+	// their policy outside the trading hours and the holidays of the markets.
+	CalendarFile string
+	// HoldConfidence and InterpolateConfidence scale the confidence of the values
+	// aggregated outside the trading hours with these policies.
+	HoldConfidence        float64
+	InterpolateConfidence float64
 }
 
 type Aggregator struct {
@@ -39,6 +57,10 @@ type Aggregator struct {
 	tsDB         storage.SampleAndChunkQueryable
 	promqlEngine *promql.Engine
 	cfg          Config
+	derived      map[string]expression
+	calendar     *calendar.Calendar
+	samples      *prometheus.GaugeVec
+	closedMarket *prometheus.CounterVec
 }
 
 func New(
@@ -63,12 +85,40 @@ func New(
 	}
 	engine := promql.NewEngine(opts)
 
+	derived, err := loadDerived(cfg.DerivedFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "load derived symbols")
+	}
+	cal, err := calendar.Load(cfg.CalendarFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "load calendar")
+	}
+	if cfg.HoldConfidence < 0 || cfg.HoldConfidence > 1 || cfg.InterpolateConfidence < 0 || cfg.InterpolateConfidence > 1 {
+		return nil, errors.New("the confidence of the values outside the trading hours should be between 0 and 1")
+	}
+
 	return &Aggregator{
 		logger:       log.With(logger, "component", ComponentName),
 		ctx:          ctx,
 		tsDB:         tsDB,
 		promqlEngine: engine,
 		cfg:          cfg,
+		derived:      derived,
+		calendar:     cal,
+		samples: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "samples",
+			Help:      "The number of source samples used in the last median or mean aggregation",
+		},
+			[]string{"symbol"},
+		),
+		closedMarket: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "market_closed_total",
+			Help:      "The total number of aggregations outside the trading hours of the market of the symbol by the policy",
+		}, []string{"symbol", "policy"}),
 	}, nil
 }
 
@@ -101,6 +151,12 @@ func (self *Aggregator) ManualValue(oracleName string, reqID int64, ts time.Time
 }
 
 func (self *Aggregator) MedianAt(symbol string, at time.Time) (float64, float64, error) {
+	if val, conf, ok, err := self.outsideHours(symbol, at, func(at time.Time) (float64, float64, error) { return self.MedianAt(symbol, at) }); ok {
+		return val, conf, err
+	}
+	if expr, ok := self.derived[symbol]; ok {
+		return expr.eval(func(symbol string) (float64, float64, error) { return self.MedianAt(symbol, at) })
+	}
 	values, confidence, err := self.valuesAtWithConfidence(symbol, at)
 	if err != nil {
 		return 0, 0, err
@@ -123,6 +179,12 @@ func (self *Aggregator) MedianAtEOD(symbol string, at time.Time) (float64, float
 }
 
 func (self *Aggregator) MeanAt(symbol string, at time.Time) (float64, float64, error) {
+	if val, conf, ok, err := self.outsideHours(symbol, at, func(at time.Time) (float64, float64, error) { return self.MeanAt(symbol, at) }); ok {
+		return val, conf, err
+	}
+	if expr, ok := self.derived[symbol]; ok {
+		return expr.eval(func(symbol string) (float64, float64, error) { return self.MeanAt(symbol, at) })
+	}
 	values, confidence, err := self.valuesAtWithConfidence(symbol, at)
 	if err != nil {
 		return 0, 0, err
@@ -164,6 +226,12 @@ func (self *Aggregator) TimeWeightedAvg(
 	start time.Time,
 	lookBack time.Duration,
 ) (float64, float64, error) {
+	if val, conf, ok, err := self.outsideHours(symbol, start, func(at time.Time) (float64, float64, error) { return self.TimeWeightedAvg(symbol, at, lookBack) }); ok {
+		return val, conf, err
+	}
+	if expr, ok := self.derived[symbol]; ok {
+		return expr.eval(func(symbol string) (float64, float64, error) { return self.TimeWeightedAvg(symbol, start, lookBack) })
+	}
 	resolution, err := self.resolution(symbol, start)
 	if err != nil {
 		return 0, 0, err
@@ -239,6 +307,11 @@ func (self *Aggregator) VolumWeightedAvg(
 	end time.Time,
 	aggrWindow time.Duration,
 ) (float64, float64, error) {
+	if expr, ok := self.derived[symbol]; ok {
+		return expr.eval(func(symbol string) (float64, float64, error) {
+			return self.VolumWeightedAvg(symbol, start, end, aggrWindow)
+		})
+	}
 	_timeWindow := end.Sub(start).Round(time.Minute).Seconds()
 	timeWindow := strconv.Itoa(int(_timeWindow)) + "s"
 
@@ -376,6 +449,7 @@ func (self *Aggregator) valuesAtWithConfidence(symbol string, at time.Time) ([]f
 	for _, price := range pricesVector {
 		prices = append(prices, price.V)
 	}
+	self.samples.With(prometheus.Labels{"symbol": format.SanitizeMetricName(symbol)}).Set(float64(len(prices)))
 
 	// Confidence level.
 	query, err := self.promqlEngine.NewInstantQuery(
@@ -402,6 +476,117 @@ func (self *Aggregator) valuesAtWithConfidence(symbol string, at time.Time) ([]f
 	return prices, confidence.Value.(promql.Vector)[0].V * 100, nil
 }
 
+// LastSample returns the time of the newest sample of a symbol from all sources
+// within the look back before the given time.
+// Returns a zero time when there are no samples.
+// For a derived symbol it is the oldest of the last samples of its symbols
+// and for a symbol whose market is closed it is the last sample before the close.
+func (self *Aggregator) LastSample(symbol string, at time.Time, lookBack time.Duration) (time.Time, error) {
+	if expr, ok := self.derived[symbol]; ok {
+		var oldest time.Time
+		for _, s := range expr.symbols() {
+			last, err := self.LastSample(s, at, lookBack)
+			if err != nil || last.IsZero() {
+				return time.Time{}, err
+			}
+			if oldest.IsZero() || last.Before(oldest) {
+				oldest = last
+			}
+		}
+		return oldest, nil
+	}
+	at, err := self.marketAt(symbol, at)
+	if err != nil {
+		return time.Time{}, err
+	}
+	q, err := self.tsDB.Querier(self.ctx, timestamp.FromTime(at.Add(-lookBack)), timestamp.FromTime(at))
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "create querier")
+	}
+	defer q.Close()
+
+	set := q.Select(false, nil,
+		labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, index.ValueMetricName),
+		labels.MustNewMatcher(labels.MatchEqual, "symbol", format.SanitizeMetricName(symbol)),
+	)
+	var last int64
+	for set.Next() {
+		it := set.At().Iterator()
+		for it.Next() {
+			if t, _ := it.At(); t > last {
+				last = t
+			}
+		}
+		if err := it.Err(); err != nil {
+			return time.Time{}, errors.Wrap(err, "iterate samples")
+		}
+	}
+	if err := set.Err(); err != nil {
+		return time.Time{}, errors.Wrap(err, "select series")
+	}
+	if last == 0 {
+		return time.Time{}, nil
+	}
+	return timestamp.Time(last), nil
+}
+
+// Sample is a single value of a symbol collected from a source.
+type Sample struct {
+	Source string
+	Domain string
+	Time   time.Time
+	Value  float64
+}
+
+// Samples returns the values of a symbol from all sources between the given times ordered by time.
+// For a derived symbol these are the samples of all its symbols.
+func (self *Aggregator) Samples(symbol string, from, to time.Time) ([]Sample, error) {
+	if expr, ok := self.derived[symbol]; ok {
+		var samples []Sample
+		for _, s := range expr.symbols() {
+			ss, err := self.Samples(s, from, to)
+			if err != nil {
+				return nil, err
+			}
+			samples = append(samples, ss...)
+		}
+		sort.Slice(samples, func(i, j int) bool { return samples[i].Time.Before(samples[j].Time) })
+		return samples, nil
+	}
+	q, err := self.tsDB.Querier(self.ctx, timestamp.FromTime(from), timestamp.FromTime(to))
+	if err != nil {
+		return nil, errors.Wrap(err, "create querier")
+	}
+	defer q.Close()
+
+	set := q.Select(false, nil,
+		labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, index.ValueMetricName),
+		labels.MustNewMatcher(labels.MatchEqual, "symbol", format.SanitizeMetricName(symbol)),
+	)
+	var samples []Sample
+	for set.Next() {
+		lbls := set.At().Labels()
+		it := set.At().Iterator()
+		for it.Next() {
+			t, v := it.At()
+			samples = append(samples, Sample{
+				Source: lbls.Get("source"),
+				Domain: lbls.Get("domain"),
+				Time:   timestamp.Time(t),
+				Value:  v,
+			})
+		}
+		if err := it.Err(); err != nil {
+			return nil, errors.Wrap(err, "iterate samples")
+		}
+	}
+	if err := set.Err(); err != nil {
+		return nil, errors.Wrap(err, "select series")
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].Time.Before(samples[j].Time) })
+	return samples, nil
+}
+
 // valuesAt returns all values from all indexes at a given time.
 func (self *Aggregator) valuesAt(symbol string, at time.Time, lookBack time.Duration) (promql.Vector, error) {
 	query, err := self.promqlEngine.NewInstantQuery(
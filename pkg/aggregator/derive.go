@@ -0,0 +1,255 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package aggregator
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// lookup returns the value and confidence of a symbol using the same aggregation as the derived symbol.
+type lookup func(symbol string) (float64, float64, error)
+
+// expression is a parsed derivation like `[TRB/ETH] * [ETH/USD]`.
+// The symbols are in square brackets as they can contain a slash.
+// Supported are the + - * / operators, parentheses and numbers
+// so that baskets can be written as weighted sums like `0.6 * [AAVE/USD] + 0.4 * [COMP/USD]`.
+type expression interface {
+	// eval returns the value and the lowest confidence of the symbols in the expression.
+	eval(lookup) (float64, float64, error)
+	symbols() []string
+}
+
+type number float64
+
+func (self number) eval(lookup) (float64, float64, error) {
+	return float64(self), math.Inf(1), nil
+}
+
+func (self number) symbols() []string { return nil }
+
+type symbol string
+
+func (self symbol) eval(fn lookup) (float64, float64, error) {
+	val, conf, err := fn(string(self))
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "symbol:%v", string(self))
+	}
+	return val, conf, nil
+}
+
+func (self symbol) symbols() []string { return []string{string(self)} }
+
+type binary struct {
+	op          byte
+	left, right expression
+}
+
+func (self *binary) eval(fn lookup) (float64, float64, error) {
+	l, lConf, err := self.left.eval(fn)
+	if err != nil {
+		return 0, 0, err
+	}
+	r, rConf, err := self.right.eval(fn)
+	if err != nil {
+		return 0, 0, err
+	}
+	conf := math.Min(lConf, rConf)
+	switch self.op {
+	case '+':
+		return l + r, conf, nil
+	case '-':
+		return l - r, conf, nil
+	case '*':
+		return l * r, conf, nil
+	case '/':
+		if r == 0 {
+			return 0, 0, errors.New("division by zero")
+		}
+		return l / r, conf, nil
+	}
+	return 0, 0, errors.Errorf("unknown operator:%v", string(self.op))
+}
+
+func (self *binary) symbols() []string {
+	return append(self.left.symbols(), self.right.symbols()...)
+}
+
+// parseExpression parses a derivation with the usual operator precedence.
+func parseExpression(input string) (expression, error) {
+	p := &parser{input: input}
+	expr, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return nil, errors.Errorf("unexpected:%q at position:%v", p.input[p.pos:], p.pos)
+	}
+	if len(expr.symbols()) == 0 {
+		return nil, errors.New("expression without any symbols")
+	}
+	return expr, nil
+}
+
+type parser struct {
+	input string
+	pos   int
+}
+
+func (self *parser) skipSpace() {
+	for self.pos < len(self.input) && unicode.IsSpace(rune(self.input[self.pos])) {
+		self.pos++
+	}
+}
+
+// peek returns the next non space character or 0 at the end.
+func (self *parser) peek() byte {
+	self.skipSpace()
+	if self.pos >= len(self.input) {
+		return 0
+	}
+	return self.input[self.pos]
+}
+
+func (self *parser) sum() (expression, error) {
+	left, err := self.product()
+	if err != nil {
+		return nil, err
+	}
+	for op := self.peek(); op == '+' || op == '-'; op = self.peek() {
+		self.pos++
+		right, err := self.product()
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (self *parser) product() (expression, error) {
+	left, err := self.operand()
+	if err != nil {
+		return nil, err
+	}
+	for op := self.peek(); op == '*' || op == '/'; op = self.peek() {
+		self.pos++
+		right, err := self.operand()
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (self *parser) operand() (expression, error) {
+	switch c := self.peek(); {
+	case c == '(':
+		self.pos++
+		expr, err := self.sum()
+		if err != nil {
+			return nil, err
+		}
+		if self.peek() != ')' {
+			return nil, errors.Errorf("missing closing parenthesis at position:%v", self.pos)
+		}
+		self.pos++
+		return expr, nil
+	case c == '[':
+		end := strings.IndexByte(self.input[self.pos:], ']')
+		if end < 0 {
+			return nil, errors.Errorf("missing closing bracket at position:%v", self.pos)
+		}
+		name := strings.TrimSpace(self.input[self.pos+1 : self.pos+end])
+		if name == "" {
+			return nil, errors.Errorf("empty symbol at position:%v", self.pos)
+		}
+		self.pos += end + 1
+		return symbol(name), nil
+	case c == '-':
+		// Unary minus.
+		self.pos++
+		expr, err := self.operand()
+		if err != nil {
+			return nil, err
+		}
+		return &binary{op: '-', left: number(0), right: expr}, nil
+	case c == '.' || (c >= '0' && c <= '9'):
+		start := self.pos
+		for self.pos < len(self.input) && (self.input[self.pos] == '.' || (self.input[self.pos] >= '0' && self.input[self.pos] <= '9')) {
+			self.pos++
+		}
+		val, err := strconv.ParseFloat(self.input[start:self.pos], 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse number at position:%v", start)
+		}
+		return number(val), nil
+	case c == 0:
+		return nil, errors.New("unexpected end of expression")
+	default:
+		return nil, errors.Errorf("unexpected:%q at position:%v", string(c), self.pos)
+	}
+}
+
+// loadDerived parses the derived symbols file which maps a symbol to its expression.
+// A missing file means no derived symbols.
+func loadDerived(file string) (map[string]expression, error) {
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) || file == "" {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "read derived symbols file path:%s", file)
+	}
+	return parseDerived(data)
+}
+
+func parseDerived(data []byte) (map[string]expression, error) {
+	var raw map[string]string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, errors.Wrap(err, "unmarshal derived symbols")
+	}
+	derived := make(map[string]expression)
+	for symbol, s := range raw {
+		expr, err := parseExpression(s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse derivation for symbol:%v", symbol)
+		}
+		derived[symbol] = expr
+	}
+	for symbol := range derived {
+		if err := checkCycle(derived, symbol, nil); err != nil {
+			return nil, err
+		}
+	}
+	return derived, nil
+}
+
+// checkCycle returns an error when a derived symbol depends on itself.
+func checkCycle(derived map[string]expression, symbol string, path []string) error {
+	for _, p := range path {
+		if p == symbol {
+			return errors.Errorf("derived symbol depends on itself:%v", strings.Join(append(path, symbol), " -> "))
+		}
+	}
+	expr, ok := derived[symbol]
+	if !ok {
+		return nil
+	}
+	for _, s := range expr.symbols() {
+		if err := checkCycle(derived, s, append(path, symbol)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
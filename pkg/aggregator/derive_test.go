@@ -0,0 +1,68 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package aggregator
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+func TestExpression(t *testing.T) {
+	values := map[string][2]float64{
+		"TRB/ETH":  {0.02, 90},
+		"ETH/USD":  {2000, 100},
+		"AAVE/USD": {300, 80},
+		"COMP/USD": {400, 95},
+	}
+	fn := func(symbol string) (float64, float64, error) {
+		v, ok := values[symbol]
+		if !ok {
+			return 0, 0, errors.New("no values")
+		}
+		return v[0], v[1], nil
+	}
+
+	for input, exp := range map[string][2]float64{
+		"[TRB/ETH] * [ETH/USD]":                 {40, 90},
+		"0.5 * [AAVE/USD] + 0.5 * [COMP/USD]":   {350, 80},
+		"([AAVE/USD] + [COMP/USD]) / 2":         {350, 80},
+		"[ETH/USD] - [AAVE/USD] * 2":            {1400, 80},
+		"-[TRB/ETH] + 1":                        {0.98, 90},
+		"[ETH/USD] / ([AAVE/USD] - [COMP/USD])": {-20, 80},
+		" [ ETH/USD ]":                          {2000, 100},
+	} {
+		expr, err := parseExpression(input)
+		testutil.Ok(t, err, input)
+		val, conf, err := expr.eval(fn)
+		testutil.Ok(t, err, input)
+		testutil.Equals(t, exp[0], val, input)
+		testutil.Equals(t, exp[1], conf, input)
+	}
+
+	for _, input := range []string{"", "1 + 2", "[TRB/ETH] *", "([TRB/ETH]", "[TRB/ETH", "[]", "[TRB/ETH] [ETH/USD]", "[TRB/ETH] % 2"} {
+		_, err := parseExpression(input)
+		testutil.NotOk(t, err, input)
+	}
+
+	expr, err := parseExpression("[ETH/USD] / ([TRB/ETH] - [TRB/ETH])")
+	testutil.Ok(t, err)
+	_, _, err = expr.eval(fn)
+	testutil.NotOk(t, err)
+
+	expr, err = parseExpression("[ETH/USD] * [MISSING]")
+	testutil.Ok(t, err)
+	_, _, err = expr.eval(fn)
+	testutil.NotOk(t, err)
+}
+
+func TestParseDerived(t *testing.T) {
+	derived, err := parseDerived([]byte(`{"TRB/USD": "[TRB/ETH] * [ETH/USD]", "TRB/EUR": "[TRB/USD] / [EUR/USD]"}`))
+	testutil.Ok(t, err)
+	testutil.Equals(t, 2, len(derived))
+
+	_, err = parseDerived([]byte(`{"A": "[B] * 2", "B": "[C] + [A]"}`))
+	testutil.NotOk(t, err)
+}
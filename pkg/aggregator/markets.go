@@ -0,0 +1,74 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package aggregator
+
+import (
+	"math"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tellor-io/telliot/pkg/calendar"
+	"github.com/tellor-io/telliot/pkg/format"
+)
+
+// interpolateAfterOpen is how long after the next open the value for the interpolation is taken
+// so that all sources have recorded a value since the open.
+const interpolateAfterOpen = 5 * time.Minute
+
+// outsideHours aggregates a symbol tied to a closed market by the policy of the symbol
+// and returns false when its market is open or it isn't tied to a market.
+// The aggregation is called with times when the market was open.
+func (self *Aggregator) outsideHours(symbol string, at time.Time, aggr func(time.Time) (float64, float64, error)) (float64, float64, bool, error) {
+	s, ok := self.calendar.Symbol(symbol)
+	if !ok || self.calendar.Open(s.Market, at) {
+		return 0, 0, false, nil
+	}
+	self.closedMarket.With(prometheus.Labels{"symbol": format.SanitizeMetricName(symbol), "policy": s.Closed}).Inc()
+	if s.Closed == calendar.Refuse {
+		return 0, 0, true, errors.Wrapf(calendar.ErrMarketClosed, "symbol:%v market:%v", symbol, s.Market)
+	}
+
+	close, err := self.calendar.LastClose(s.Market, at)
+	if err != nil {
+		return 0, 0, true, err
+	}
+	// The last second before the close is still in the trading hours.
+	val, conf, err := aggr(close.Add(-time.Second))
+	if err != nil {
+		return 0, 0, true, errors.Wrapf(err, "aggregating the last close:%v", close)
+	}
+	if s.Closed == calendar.Interpolate {
+		open, err := self.calendar.NextOpen(s.Market, at)
+		if err != nil {
+			return 0, 0, true, err
+		}
+		after := open.Add(interpolateAfterOpen)
+		// The market hasn't opened again yet for the live values.
+		if !time.Now().Before(after) {
+			valOpen, confOpen, err := aggr(after)
+			if err == nil {
+				w := float64(at.Sub(close)) / float64(after.Sub(close))
+				return val + (valOpen-val)*w, math.Min(conf, confOpen) * self.cfg.InterpolateConfidence, true, nil
+			}
+			level.Warn(self.logger).Log("msg", "aggregating the next open, holding the last close", "symbol", symbol, "err", err)
+		}
+	}
+	return val, conf * self.cfg.HoldConfidence, true, nil
+}
+
+// marketAt returns the time of the samples of a symbol whose market is closed at the given time
+// which is the last close unless its policy refuses the values outside the trading hours.
+func (self *Aggregator) marketAt(symbol string, at time.Time) (time.Time, error) {
+	s, ok := self.calendar.Symbol(symbol)
+	if !ok || s.Closed == calendar.Refuse || self.calendar.Open(s.Market, at) {
+		return at, nil
+	}
+	close, err := self.calendar.LastClose(s.Market, at)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return close.Add(-time.Second), nil
+}
@@ -0,0 +1,83 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package aggregator
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/tellor-io/telliot/pkg/calendar"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/testutil"
+	"github.com/tellor-io/telliot/pkg/tracker/index"
+)
+
+func TestOutsideHours(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aggregator")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+	tsDB, err := tsdb.Open(filepath.Join(dir, "db"), nil, nil, tsdb.DefaultOptions())
+	testutil.Ok(t, err)
+	defer tsDB.Close()
+
+	// The daily break of the metals is from 21:00 to 22:00 UTC in the summer.
+	closed := time.Date(2021, 6, 8, 21, 0, 0, 0, time.UTC)
+	app := tsDB.Appender(context.Background())
+	for _, symbol := range []string{"XAU/USD", "XAG/USD"} {
+		lbls := func(name string) labels.Labels {
+			return labels.FromStrings(labels.MetricName, name, "symbol", format.SanitizeMetricName(symbol), "domain", "api.metals", "source", "https://api.metals/"+symbol)
+		}
+		add := func(at time.Time, v float64) {
+			_, err := app.Append(0, lbls(index.ValueMetricName), timestamp.FromTime(at), v)
+			testutil.Ok(t, err)
+			_, err = app.Append(0, lbls(index.IntervalMetricName), timestamp.FromTime(at), float64(time.Minute))
+			testutil.Ok(t, err)
+		}
+		for at := closed.Add(-20 * time.Minute); at.Before(closed); at = at.Add(time.Minute) {
+			add(at, 1800)
+		}
+		for at := closed.Add(time.Hour); at.Before(closed.Add(70 * time.Minute)); at = at.Add(time.Minute) {
+			add(at, 1830)
+		}
+	}
+	testutil.Ok(t, app.Commit())
+
+	calendarFile := filepath.Join(dir, "calendar.json")
+	testutil.Ok(t, ioutil.WriteFile(calendarFile, []byte(`{"Symbols": {
+		"XAU/USD": {"Market": "metals", "Closed": "hold"},
+		"XAG/USD": {"Market": "metals", "Closed": "interpolate"},
+		"EUR/USD": {"Market": "fx", "Closed": "refuse"}
+	}}`), 0666))
+	cfg := Config{LogLevel: "info", CalendarFile: calendarFile, HoldConfidence: 0.8, InterpolateConfidence: 0.9}
+	aggr, err := New(log.NewNopLogger(), context.Background(), cfg, tsDB)
+	testutil.Ok(t, err)
+
+	at := closed.Add(30 * time.Minute)
+	val, conf, err := aggr.MedianAt("XAU/USD", at)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 1800.0, val)
+	testutil.Assert(t, conf > 75 && conf <= 80, "unexpected confidence:%v", conf)
+
+	// Halfway between the close and 5 minutes after the next open.
+	val, conf, err = aggr.MedianAt("XAG/USD", closed.Add(32*time.Minute+30*time.Second))
+	testutil.Ok(t, err)
+	testutil.Equals(t, 1815.0, val)
+	testutil.Assert(t, conf > 85 && conf <= 90, "unexpected confidence:%v", conf)
+
+	last, err := aggr.LastSample("XAU/USD", at, 5*time.Minute)
+	testutil.Ok(t, err)
+	testutil.Equals(t, closed.Add(-time.Minute), last)
+
+	_, _, err = aggr.MedianAt("EUR/USD", time.Date(2021, 6, 5, 12, 0, 0, 0, time.UTC))
+	testutil.Assert(t, errors.Is(err, calendar.ErrMarketClosed), "unexpected error:%v", err)
+}
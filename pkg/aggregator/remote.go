@@ -0,0 +1,69 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package aggregator
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/web"
+)
+
+// TimeWeightedAvgResponse is the response of ServeTimeWeightedAvg.
+type TimeWeightedAvgResponse struct {
+	Value      float64 `json:"value"`
+	Confidence float64 `json:"confidence"`
+}
+
+// ServeTimeWeightedAvg serves the time weighted average to the instances running in the submitter role.
+// For example: curl 'localhost:9090/api/v1/aggregator/twa?symbol=TRB/ETH&ts=1620000000&lookBack=1h'
+func (self *Aggregator) ServeTimeWeightedAvg(w http.ResponseWriter, r *http.Request) {
+	var resp TimeWeightedAvgResponse
+	code, err := func() (int, error) {
+		ts, err := strconv.ParseInt(r.URL.Query().Get("ts"), 10, 64)
+		if err != nil {
+			return http.StatusBadRequest, errors.Wrap(err, "parsing the ts")
+		}
+		lookBack, err := time.ParseDuration(r.URL.Query().Get("lookBack"))
+		if err != nil {
+			return http.StatusBadRequest, errors.Wrap(err, "parsing the lookBack")
+		}
+		resp.Value, resp.Confidence, err = self.TimeWeightedAvg(r.URL.Query().Get("symbol"), time.Unix(ts, 0), lookBack)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		return http.StatusOK, nil
+	}()
+	if err := web.WriteJSON(w, code, resp, err); err != nil {
+		level.Error(self.logger).Log("msg", "encoding time weighted avg response", "err", err)
+	}
+}
+
+// Remote gets the aggregated values from an instance running in the aggregator role.
+type Remote struct {
+	url    string
+	client *http.Client
+}
+
+func NewRemote(url string, timeout time.Duration) *Remote {
+	return &Remote{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (self *Remote) TimeWeightedAvg(symbol string, start time.Time, lookBack time.Duration) (float64, float64, error) {
+	var resp TimeWeightedAvgResponse
+	u := fmt.Sprintf("%v/api/v1/aggregator/twa?symbol=%v&ts=%v&lookBack=%v", self.url, url.QueryEscape(symbol), start.Unix(), lookBack)
+	if err := web.GetJSON(context.Background(), self.client, u, &resp); err != nil {
+		return 0, 0, errors.Wrapf(err, "getting remote time weighted avg for symbol:%v", symbol)
+	}
+	return resp.Value, resp.Confidence, nil
+}
@@ -0,0 +1,457 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package approval
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/fsutil"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/notify"
+	"github.com/tellor-io/telliot/pkg/transactor"
+	"github.com/tellor-io/telliot/pkg/web"
+)
+
+const ComponentName = "approval"
+
+// The states of an approval request.
+const (
+	StatePending  = "pending"
+	StateApproved = "approved"
+	StateSent     = "sent"
+	StateRejected = "rejected"
+	StateExpired  = "expired"
+)
+
+type Config struct {
+	Enabled  bool
+	LogLevel string
+	// Path of the file that keeps the queue of the requests.
+	Path string
+	// Approvers are the addresses whose signatures approve a request.
+	Approvers []string
+	// Threshold is the number of approvers that need to sign a request before it is sent.
+	Threshold int
+	// Functions are the contract functions that need an approval e.g. withdrawStake.
+	Functions []string
+	// MinTransfer is the TRB amount from which a transfer needs an approval.
+	MinTransfer float64
+	// Expiry is how long a request can be approved and sent.
+	Expiry format.Duration
+}
+
+// Approval is the signature of an approver.
+type Approval struct {
+	Approver  common.Address
+	Signature hexutil.Bytes
+	Time      time.Time
+}
+
+// Request is an unsigned transaction that waits for the approvers.
+// The nonce and the gas price are set only when it is sent
+// so that an approval stays valid while other transactions are mined.
+type Request struct {
+	ID          string
+	Function    string
+	Description string
+	ChainID     *big.Int
+	From        common.Address
+	To          common.Address
+	Value       *big.Int
+	Data        hexutil.Bytes
+	Gas         uint64
+	State       string
+	Approvals   []Approval
+	Created     time.Time
+	Expires     time.Time
+	TxHash      *common.Hash `json:",omitempty"`
+	Err         string       `json:",omitempty"`
+}
+
+// NewRequest creates the request for the unsigned transaction.
+func NewRequest(function, description string, chainID *big.Int, from common.Address, tx *types.Transaction) *Request {
+	r := &Request{
+		Function:    function,
+		Description: description,
+		ChainID:     chainID,
+		From:        from,
+		Value:       tx.Value(),
+		Data:        tx.Data(),
+		Gas:         tx.Gas(),
+		State:       StatePending,
+	}
+	if tx.To() != nil {
+		r.To = *tx.To()
+	}
+	return r
+}
+
+// Message is the text that the approvers sign with personal_sign
+// so that a hardware wallet shows what it approves.
+func (self *Request) Message() string {
+	return fmt.Sprintf("telliot approval %s\nfunction: %s\nchain: %s\nfrom: %s\nto: %s\nvalue: %s\ngas: %d\ndata: %s",
+		self.ID,
+		self.Function,
+		self.ChainID.String(),
+		self.From.Hex(),
+		self.To.Hex(),
+		self.Value.String(),
+		self.Gas,
+		self.Data.String(),
+	)
+}
+
+// Recover returns the address that signed the message of the request.
+func (self *Request) Recover(sig []byte) (common.Address, error) {
+	if len(sig) != crypto.SignatureLength {
+		return common.Address{}, errors.Errorf("invalid signature length:%v", len(sig))
+	}
+	sig = common.CopyBytes(sig)
+	// Wallets return the recovery id as 27 or 28.
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+	pub, err := crypto.SigToPub(accounts.TextHash([]byte(self.Message())), sig)
+	if err != nil {
+		return common.Address{}, errors.Wrap(err, "recovering the signer")
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// Transaction returns the unsigned transaction of the request.
+func (self *Request) Transaction(nonce uint64, gasPrice *big.Int) *types.Transaction {
+	return types.NewTransaction(nonce, self.To, self.Value, self.Gas, gasPrice, self.Data)
+}
+
+func (self *Request) approvedBy(addr common.Address) bool {
+	for _, a := range self.Approvals {
+		if a.Approver == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// Queue keeps the approval requests in a file
+// so that the CLI and a running instance share them.
+// It is safe for concurrent use.
+type Queue struct {
+	logger      log.Logger
+	cfg         Config
+	approvers   map[common.Address]bool
+	minTransfer *big.Int
+	notifier    notify.Notifier
+	mtx         sync.Mutex
+}
+
+func New(logger log.Logger, cfg Config, notifier notify.Notifier) (*Queue, error) {
+	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	logger = log.With(logger, "component", ComponentName)
+
+	if cfg.Path == "" {
+		return nil, errors.New("the approval queue needs a path")
+	}
+	approvers := make(map[common.Address]bool)
+	for _, a := range cfg.Approvers {
+		a = strings.TrimSpace(a)
+		if !common.IsHexAddress(a) {
+			return nil, errors.Errorf("invalid approver address:%v", a)
+		}
+		approvers[common.HexToAddress(a)] = true
+	}
+	if cfg.Threshold < 1 || cfg.Threshold > len(approvers) {
+		return nil, errors.Errorf("the threshold:%v should be between 1 and the number of approvers:%v", cfg.Threshold, len(approvers))
+	}
+	minTransfer, _ := new(big.Float).Mul(big.NewFloat(cfg.MinTransfer), big.NewFloat(params.Ether)).Int(nil)
+
+	return &Queue{
+		logger:      logger,
+		cfg:         cfg,
+		approvers:   approvers,
+		minTransfer: minTransfer,
+		notifier:    notifier,
+	}, nil
+}
+
+// Required returns whether the function needs an approval.
+// The amount is only compared for the transfers.
+// A nil queue doesn't require any approvals.
+func (self *Queue) Required(function string, amount *big.Int) bool {
+	if self == nil {
+		return false
+	}
+	for _, f := range self.cfg.Functions {
+		if f != function {
+			continue
+		}
+		if function == transactor.FunctionTransfer && amount != nil {
+			return amount.Cmp(self.minTransfer) >= 0
+		}
+		return true
+	}
+	return false
+}
+
+// Add queues the request, sets its ID and expiry and notifies the approvers.
+func (self *Queue) Add(ctx context.Context, r *Request, now time.Time) error {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	requests, err := self.load()
+	if err != nil {
+		return err
+	}
+	r.Created = now
+	r.Expires = now.Add(self.cfg.Expiry.Duration)
+	r.State = StatePending
+	r.ID = requestID(r, len(requests))
+	requests = append(requests, r)
+	if err := self.save(requests); err != nil {
+		return err
+	}
+	level.Info(self.logger).Log("msg", "approval requested", "id", r.ID, "function", r.Function, "from", r.From.Hex())
+	self.notify(ctx, r, notify.EventApprovalRequested, "Approval requested", r.Description+"\n\nSign the message below and run `telliot approval approve`.\n\n"+r.Message())
+	return nil
+}
+
+// Requests returns all requests with the expired ones marked.
+func (self *Queue) Requests(now time.Time) ([]*Request, error) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	requests, err := self.load()
+	if err != nil {
+		return nil, err
+	}
+	if self.expire(requests, now) {
+		if err := self.save(requests); err != nil {
+			return nil, err
+		}
+	}
+	return requests, nil
+}
+
+// Get returns the request of the ID.
+func (self *Queue) Get(id string, now time.Time) (*Request, error) {
+	requests, err := self.Requests(now)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range requests {
+		if r.ID == id {
+			return r, nil
+		}
+	}
+	return nil, errors.Errorf("approval request not found id:%v", id)
+}
+
+// Approve adds the signature of an approver to the request.
+// The request is approved once it has the signatures of the threshold.
+func (self *Queue) Approve(ctx context.Context, id string, sig []byte, now time.Time) (*Request, error) {
+	r, err := self.update(id, now, func(r *Request) error {
+		if r.State != StatePending {
+			return errors.Errorf("can't approve a request in state:%v", r.State)
+		}
+		approver, err := r.Recover(sig)
+		if err != nil {
+			return err
+		}
+		if !self.approvers[approver] {
+			return errors.Errorf("the signer isn't an approver:%v", approver.Hex())
+		}
+		if r.approvedBy(approver) {
+			return errors.Errorf("already approved by:%v", approver.Hex())
+		}
+		r.Approvals = append(r.Approvals, Approval{Approver: approver, Signature: sig, Time: now})
+		if len(r.Approvals) >= self.cfg.Threshold {
+			r.State = StateApproved
+		}
+		level.Info(self.logger).Log("msg", "request approved", "id", r.ID, "approver", approver.Hex(), "approvals", len(r.Approvals), "state", r.State)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if r.State == StateApproved {
+		self.notify(ctx, r, notify.EventApprovalApproved, "Approval complete", r.Description+"\n\nSend it with `telliot approval send "+r.ID+"`.")
+	}
+	return r, nil
+}
+
+// Reject closes a request that isn't sent yet.
+func (self *Queue) Reject(id, reason string, now time.Time) (*Request, error) {
+	return self.update(id, now, func(r *Request) error {
+		if r.State != StatePending && r.State != StateApproved {
+			return errors.Errorf("can't reject a request in state:%v", r.State)
+		}
+		r.State = StateRejected
+		r.Err = reason
+		level.Info(self.logger).Log("msg", "request rejected", "id", r.ID, "reason", reason)
+		return nil
+	})
+}
+
+// Sent records the transaction of an approved request.
+func (self *Queue) Sent(id string, hash common.Hash, now time.Time) (*Request, error) {
+	return self.update(id, now, func(r *Request) error {
+		if r.State != StateApproved {
+			return errors.Errorf("can't send a request in state:%v", r.State)
+		}
+		r.State = StateSent
+		r.TxHash = &hash
+		return nil
+	})
+}
+
+func (self *Queue) update(id string, now time.Time, fn func(*Request) error) (*Request, error) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	requests, err := self.load()
+	if err != nil {
+		return nil, err
+	}
+	self.expire(requests, now)
+	for _, r := range requests {
+		if r.ID != id {
+			continue
+		}
+		if err := fn(r); err != nil {
+			return nil, err
+		}
+		return r, self.save(requests)
+	}
+	return nil, errors.Errorf("approval request not found id:%v", id)
+}
+
+func (self *Queue) notify(ctx context.Context, r *Request, event, title, body string) {
+	if self.notifier == nil {
+		return
+	}
+	if err := self.notifier.Notify(ctx, notify.Message{
+		Event:    event,
+		Severity: notify.SeverityWarning,
+		Title:    title,
+		Body:     body,
+		Data: map[string]string{
+			"id":       r.ID,
+			"function": r.Function,
+			"from":     r.From.Hex(),
+		},
+	}); err != nil {
+		level.Error(self.logger).Log("msg", "sending notification", "event", event, "err", err)
+	}
+}
+
+func (self *Queue) expire(requests []*Request, now time.Time) bool {
+	var changed bool
+	for _, r := range requests {
+		if (r.State == StatePending || r.State == StateApproved) && now.After(r.Expires) {
+			r.State = StateExpired
+			changed = true
+		}
+	}
+	return changed
+}
+
+// load reads the file on every operation
+// so that the changes of another process are not overwritten.
+func (self *Queue) load() ([]*Request, error) {
+	data, err := ioutil.ReadFile(self.cfg.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "read approval file path:%s", self.cfg.Path)
+	}
+	var requests []*Request
+	if err := json.Unmarshal(data, &requests); err != nil {
+		return nil, errors.Wrap(err, "unmarshal approval file")
+	}
+	return requests, nil
+}
+
+func (self *Queue) save(requests []*Request) error {
+	data, err := json.MarshalIndent(requests, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshal approval requests")
+	}
+	return errors.Wrap(fsutil.WriteAtomic(self.cfg.Path, data, 0666), "write approval file")
+}
+
+// ApproveRequest is the body of the approve endpoint.
+type ApproveRequest struct {
+	ID        string
+	Signature hexutil.Bytes
+}
+
+func (self *Queue) ServeList(w http.ResponseWriter, r *http.Request) {
+	requests, err := self.Requests(time.Now())
+	code := http.StatusOK
+	if err != nil {
+		code = http.StatusInternalServerError
+	}
+	if err := web.WriteJSON(w, code, requests, err); err != nil {
+		level.Error(self.logger).Log("msg", "encoding approval requests response", "err", err)
+	}
+}
+
+func (self *Queue) ServeApprove(w http.ResponseWriter, r *http.Request) {
+	var resp *Request
+	code, err := func() (int, error) {
+		var req ApproveRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return http.StatusBadRequest, errors.Wrap(err, "decoding the request")
+		}
+		var err error
+		resp, err = self.Approve(r.Context(), req.ID, req.Signature, time.Now())
+		if err != nil {
+			return http.StatusBadRequest, err
+		}
+		return http.StatusOK, nil
+	}()
+	if err := web.WriteJSON(w, code, resp, err); err != nil {
+		level.Error(self.logger).Log("msg", "encoding approve response", "err", err)
+	}
+}
+
+// requestID is a short hash of the transaction, its creation time
+// and its position in the queue so that the same transaction gets a new ID.
+func requestID(r *Request, seq int) string {
+	created := make([]byte, 16)
+	binary.BigEndian.PutUint64(created, uint64(r.Created.UnixNano()))
+	binary.BigEndian.PutUint64(created[8:], uint64(seq))
+	hash := crypto.Keccak256(
+		r.ChainID.Bytes(),
+		r.From.Bytes(),
+		r.To.Bytes(),
+		r.Value.Bytes(),
+		r.Data,
+		created,
+	)
+	return hexutil.Encode(hash[:8])
+}
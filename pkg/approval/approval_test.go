@@ -0,0 +1,119 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package approval
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/testutil"
+	"github.com/tellor-io/telliot/pkg/transactor"
+)
+
+func TestQueue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "approval")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	approver1, err := crypto.GenerateKey()
+	testutil.Ok(t, err)
+	approver2, err := crypto.GenerateKey()
+	testutil.Ok(t, err)
+	other, err := crypto.GenerateKey()
+	testutil.Ok(t, err)
+
+	cfg := Config{
+		LogLevel:    "info",
+		Path:        filepath.Join(dir, "approvals.json"),
+		Approvers:   []string{crypto.PubkeyToAddress(approver1.PublicKey).Hex(), crypto.PubkeyToAddress(approver2.PublicKey).Hex()},
+		Threshold:   2,
+		Functions:   []string{transactor.FunctionWithdrawStake, transactor.FunctionTransfer},
+		MinTransfer: 100,
+		Expiry:      format.Duration{Duration: time.Hour},
+	}
+	queue, err := New(logging.NewLogger(), cfg, nil)
+	testutil.Ok(t, err)
+
+	testutil.Assert(t, queue.Required(transactor.FunctionWithdrawStake, nil), "withdraw without approval")
+	testutil.Assert(t, !queue.Required(transactor.FunctionBeginDispute, nil), "dispute with approval")
+	testutil.Assert(t, !queue.Required(transactor.FunctionTransfer, big.NewInt(params.Ether)), "small transfer with approval")
+	testutil.Assert(t, queue.Required(transactor.FunctionTransfer, new(big.Int).Mul(big.NewInt(100), big.NewInt(params.Ether))), "big transfer without approval")
+	var nilQueue *Queue
+	testutil.Assert(t, !nilQueue.Required(transactor.FunctionWithdrawStake, nil), "nil queue with approval")
+
+	now := time.Now().Round(0)
+	to := common.HexToAddress("0x88dF592F8eb5D7Bd38bFeF7dEb0fBc02cf3778a0")
+	tx := types.NewTransaction(0, to, big.NewInt(0), 100000, nil, []byte{1, 2, 3})
+	r := NewRequest(transactor.FunctionWithdrawStake, "withdraw", big.NewInt(1), common.HexToAddress("0x1"), tx)
+	testutil.Ok(t, queue.Add(context.Background(), r, now))
+
+	sign := func(r *Request, key *ecdsa.PrivateKey) []byte {
+		sig, err := crypto.Sign(accounts.TextHash([]byte(r.Message())), key)
+		testutil.Ok(t, err)
+		// As returned by the wallets.
+		sig[crypto.RecoveryIDOffset] += 27
+		return sig
+	}
+
+	_, err = queue.Approve(context.Background(), r.ID, sign(r, other), now)
+	testutil.NotOk(t, err, "approved by a non approver")
+	_, err = queue.Sent(r.ID, common.Hash{}, now)
+	testutil.NotOk(t, err, "sent without approvals")
+
+	got, err := queue.Approve(context.Background(), r.ID, sign(r, approver1), now)
+	testutil.Ok(t, err)
+	testutil.Equals(t, StatePending, got.State)
+	_, err = queue.Approve(context.Background(), r.ID, sign(r, approver1), now)
+	testutil.NotOk(t, err, "approved twice by the same approver")
+
+	// A new queue reads the approvals from the file.
+	queue, err = New(logging.NewLogger(), cfg, nil)
+	testutil.Ok(t, err)
+	got, err = queue.Approve(context.Background(), r.ID, sign(r, approver2), now)
+	testutil.Ok(t, err)
+	testutil.Equals(t, StateApproved, got.State)
+	testutil.Equals(t, 2, len(got.Approvals))
+
+	got, err = queue.Sent(r.ID, common.HexToHash("0xaa"), now)
+	testutil.Ok(t, err)
+	testutil.Equals(t, StateSent, got.State)
+	testutil.Equals(t, tx.Data(), got.Transaction(5, big.NewInt(1)).Data())
+	testutil.Equals(t, uint64(5), got.Transaction(5, big.NewInt(1)).Nonce())
+
+	// Expired before it is approved.
+	expiring := NewRequest(transactor.FunctionWithdrawStake, "withdraw", big.NewInt(1), common.HexToAddress("0x1"), tx)
+	testutil.Ok(t, queue.Add(context.Background(), expiring, now))
+	_, err = queue.Approve(context.Background(), expiring.ID, sign(expiring, approver1), now.Add(2*time.Hour))
+	testutil.NotOk(t, err, "approved after the expiry")
+	got, err = queue.Get(expiring.ID, now.Add(2*time.Hour))
+	testutil.Ok(t, err)
+	testutil.Equals(t, StateExpired, got.State)
+
+	rejected := NewRequest(transactor.FunctionWithdrawStake, "withdraw", big.NewInt(1), common.HexToAddress("0x1"), tx)
+	testutil.Ok(t, queue.Add(context.Background(), rejected, now.Add(time.Second)))
+	got, err = queue.Reject(rejected.ID, "not planned", now)
+	testutil.Ok(t, err)
+	testutil.Equals(t, StateRejected, got.State)
+
+	requests, err := queue.Requests(now)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 3, len(requests))
+
+	cfg.Threshold = 3
+	_, err = New(logging.NewLogger(), cfg, nil)
+	testutil.NotOk(t, err, "threshold above the approvers")
+}
@@ -0,0 +1,351 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+// Package attestation signs the values of the PSR together with how they were calculated
+// so that the data consumers and the dispute voters can verify that a reporter
+// followed its declared methodology. It is experimental.
+//
+// The statement of a value has the aggregations, their input samples and a digest of the PSR config.
+// It is signed with a dedicated key or by a remote attestation service, e.g. one running
+// in an enclave which recalculates the value from the inputs before signing it.
+package attestation
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/aggregator"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/psr"
+	"github.com/tellor-io/telliot/pkg/secret"
+	"github.com/tellor-io/telliot/pkg/web"
+)
+
+const ComponentName = "attestation"
+
+// PrivateKeyEnvName is the key that signs the statements when there is no remote service.
+// It should not be the key of a reporter.
+const PrivateKeyEnvName = "ATTESTATION_PRIVATE_KEY"
+
+var (
+	attestations = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "telliot",
+		Subsystem: ComponentName,
+		Name:      "attestations_total",
+		Help:      "The total number of signed values",
+	})
+	attestationFails = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "telliot",
+		Subsystem: ComponentName,
+		Name:      "fails_total",
+		Help:      "The total number of values that couldn't be signed",
+	})
+)
+
+type Config struct {
+	Enabled  bool
+	LogLevel string
+	// RemoteURL is the attestation service that signs the statements.
+	// The statements are signed with the key in ATTESTATION_PRIVATE_KEY when empty.
+	RemoteURL string
+	Timeout   format.Duration
+	// Window is how long before a value its input samples are included in the statement.
+	Window format.Duration
+	// Path is the file that keeps the attestations.
+	Path string
+	// MaxAttestations is how many of the latest attestations are served by the API.
+	MaxAttestations int
+}
+
+// Statement is what is signed for a value.
+type Statement struct {
+	RequestID int64
+	Timestamp time.Time
+	// Value is the encoded value as submitted.
+	Value string
+	// Methodology is the sha256 of the declared PSR config.
+	Methodology  string
+	Aggregations []psr.Aggregation
+	// Inputs are the samples of the symbols of the aggregations.
+	Inputs map[string][]aggregator.Sample
+}
+
+// Attestation is a signed statement.
+// The signature is the secp256k1 signature of the keccak256 of the statement JSON
+// so that it can be verified with ecrecover.
+type Attestation struct {
+	Statement json.RawMessage
+	Signer    string
+	Signature string
+	// Quote is the proof of the remote service that it signed in an enclave, if any.
+	// Its format depends on the service.
+	Quote string `json:",omitempty"`
+}
+
+// Methodology returns the digest of a config that declares how the values are calculated.
+func Methodology(cfg interface{}) (string, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return "", errors.Wrap(err, "marshal methodology")
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Verify checks that the attestation is signed by its signer
+// and returns its statement.
+func Verify(a Attestation) (Statement, error) {
+	var s Statement
+	sig, err := hexutil.Decode(a.Signature)
+	if err != nil {
+		return s, errors.Wrap(err, "decoding the signature")
+	}
+	pub, err := crypto.SigToPub(crypto.Keccak256(a.Statement), sig)
+	if err != nil {
+		return s, errors.Wrap(err, "recovering the signer")
+	}
+	if signer := crypto.PubkeyToAddress(*pub).Hex(); !strings.EqualFold(signer, a.Signer) {
+		return s, errors.Errorf("signed by:%v instead of:%v", signer, a.Signer)
+	}
+	if err := json.Unmarshal(a.Statement, &s); err != nil {
+		return s, errors.Wrap(err, "decoding the statement")
+	}
+	return s, nil
+}
+
+// Signer signs a statement.
+type Signer interface {
+	Sign(ctx context.Context, statement []byte) (Attestation, error)
+}
+
+// KeySigner signs with a local key.
+type KeySigner struct {
+	key *ecdsa.PrivateKey
+}
+
+// NewKeySigner creates a signer with the hex key.
+func NewKeySigner(key string) (*KeySigner, error) {
+	k, err := crypto.HexToECDSA(strings.TrimPrefix(strings.TrimSpace(key), "0x"))
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding the key")
+	}
+	return &KeySigner{key: k}, nil
+}
+
+func (self *KeySigner) Sign(_ context.Context, statement []byte) (Attestation, error) {
+	sig, err := crypto.Sign(crypto.Keccak256(statement), self.key)
+	if err != nil {
+		return Attestation{}, errors.Wrap(err, "signing")
+	}
+	return Attestation{
+		Statement: statement,
+		Signer:    crypto.PubkeyToAddress(self.key.PublicKey).Hex(),
+		Signature: hexutil.Encode(sig),
+	}, nil
+}
+
+// RemoteSigner sends the statements to an attestation service.
+// The service receives the statement JSON and responds in the format of the telliot API
+// with the Signer, Signature and Quote of an attestation.
+type RemoteSigner struct {
+	url    string
+	client *http.Client
+}
+
+func NewRemoteSigner(url string, timeout time.Duration) *RemoteSigner {
+	return &RemoteSigner{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (self *RemoteSigner) Sign(ctx context.Context, statement []byte) (Attestation, error) {
+	var a Attestation
+	if err := web.PostJSON(ctx, self.client, self.url, json.RawMessage(statement), &a); err != nil {
+		return a, errors.Wrap(err, "remote signing")
+	}
+	// The signature is checked against the sent statement, not the returned one.
+	a.Statement = statement
+	if _, err := Verify(a); err != nil {
+		return a, errors.Wrap(err, "verifying the remote signature")
+	}
+	return a, nil
+}
+
+// Attestor wraps a PSR and signs its values in the background
+// so that the submissions are not delayed by the signing.
+// A value that can't be signed is still returned.
+type Attestor struct {
+	logger      log.Logger
+	cfg         Config
+	ctx         context.Context
+	close       context.CancelFunc
+	psr         psr.Observable
+	inputs      func(symbol string, from, to time.Time) ([]aggregator.Sample, error)
+	methodology string
+	signer      Signer
+	store       *store
+	wg          sync.WaitGroup
+
+	// mtx is held while calculating a value as the aggregations
+	// are collected from a single observer.
+	mtx          sync.Mutex
+	aggregations []psr.Aggregation
+}
+
+// New creates an attestor or returns nil when it is disabled.
+// The inputs are the samples of the symbols e.g. aggregator.Samples.
+func New(
+	logger log.Logger,
+	ctx context.Context,
+	cfg Config,
+	p psr.Observable,
+	inputs func(symbol string, from, to time.Time) ([]aggregator.Sample, error),
+	methodology string,
+) (*Attestor, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	logger = log.With(logger, "component", ComponentName)
+
+	var signer Signer
+	if cfg.RemoteURL != "" {
+		signer = NewRemoteSigner(cfg.RemoteURL, cfg.Timeout.Duration)
+	} else {
+		key := secret.Get(PrivateKeyEnvName)
+		if key == "" {
+			return nil, errors.Errorf("no remote service and no key in:%v", PrivateKeyEnvName)
+		}
+		signer, err = NewKeySigner(key)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating key signer")
+		}
+	}
+	store, err := openStore(cfg.Path, cfg.MaxAttestations)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening attestations store")
+	}
+	level.Warn(logger).Log("msg", "value attestation is experimental", "remote", cfg.RemoteURL != "", "methodology", methodology)
+
+	ctx, close := context.WithCancel(ctx)
+	self := &Attestor{
+		logger:      logger,
+		cfg:         cfg,
+		ctx:         ctx,
+		close:       close,
+		psr:         p,
+		inputs:      inputs,
+		methodology: methodology,
+		signer:      signer,
+		store:       store,
+	}
+	p.Observe(self.observe)
+	return self, nil
+}
+
+func (self *Attestor) observe(a psr.Aggregation) {
+	self.aggregations = append(self.aggregations, a)
+}
+
+func (self *Attestor) GetValue(reqID int64, ts time.Time) (*big.Int, error) {
+	self.mtx.Lock()
+	self.aggregations = nil
+	value, err := self.psr.GetValue(reqID, ts)
+	aggregations := self.aggregations
+	self.mtx.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	self.wg.Add(1)
+	go func() {
+		defer self.wg.Done()
+		if err := self.attest(reqID, ts, value, aggregations); err != nil {
+			attestationFails.Inc()
+			level.Error(self.logger).Log("msg", "attesting value", "reqID", reqID, "err", err)
+			return
+		}
+		attestations.Inc()
+	}()
+	return value, nil
+}
+
+func (self *Attestor) attest(reqID int64, ts time.Time, value *big.Int, aggregations []psr.Aggregation) error {
+	s := Statement{
+		RequestID:    reqID,
+		Timestamp:    ts,
+		Value:        value.String(),
+		Methodology:  self.methodology,
+		Aggregations: aggregations,
+		Inputs:       make(map[string][]aggregator.Sample),
+	}
+	for _, a := range aggregations {
+		if _, ok := s.Inputs[a.Symbol]; ok {
+			continue
+		}
+		samples, err := self.inputs(a.Symbol, ts.Add(-self.cfg.Window.Duration), ts)
+		if err != nil {
+			return errors.Wrapf(err, "getting the samples for symbol:%v", a.Symbol)
+		}
+		s.Inputs[a.Symbol] = samples
+	}
+	statement, err := json.Marshal(s)
+	if err != nil {
+		return errors.Wrap(err, "marshal statement")
+	}
+	ctx, cncl := context.WithTimeout(self.ctx, self.cfg.Timeout.Duration)
+	defer cncl()
+	a, err := self.signer.Sign(ctx, statement)
+	if err != nil {
+		return err
+	}
+	return self.store.add(a, reqID)
+}
+
+// ServeHTTP lists the latest attestations, newest first.
+// For example: curl 'localhost:9090/api/v1/attestations?id=1'.
+func (self *Attestor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var (
+		id  int64
+		err error
+	)
+	code := http.StatusOK
+	if _id := r.URL.Query().Get("id"); _id != "" {
+		id, err = strconv.ParseInt(_id, 10, 64)
+		if err != nil {
+			code, err = http.StatusBadRequest, errors.Wrap(err, "parsing the id")
+		}
+	}
+	var list []Attestation
+	if err == nil {
+		list = self.store.list(id)
+	}
+	if err := web.WriteJSON(w, code, list, err); err != nil {
+		level.Error(self.logger).Log("msg", "encoding attestations response", "err", err)
+	}
+}
+
+// Close waits for the values that are being signed.
+func (self *Attestor) Close() error {
+	self.wg.Wait()
+	self.close()
+	return self.store.close()
+}
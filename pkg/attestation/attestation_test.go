@@ -0,0 +1,138 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package attestation
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/go-kit/kit/log"
+	"github.com/tellor-io/telliot/pkg/aggregator"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/psr"
+	"github.com/tellor-io/telliot/pkg/testutil"
+	"github.com/tellor-io/telliot/pkg/web"
+)
+
+type psrMock struct {
+	observe func(psr.Aggregation)
+}
+
+func (self *psrMock) Observe(fn func(psr.Aggregation)) { self.observe = fn }
+
+func (self *psrMock) GetValue(reqID int64, ts time.Time) (*big.Int, error) {
+	self.observe(psr.Aggregation{Method: "median", Symbol: "ETH/USD", Value: 2000, Confidence: 1})
+	return big.NewInt(2000e6), nil
+}
+
+func inputs(symbol string, from, to time.Time) ([]aggregator.Sample, error) {
+	return []aggregator.Sample{{Source: "a", Time: to, Value: 2000}}, nil
+}
+
+const testKey = "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+
+func TestSigners(t *testing.T) {
+	ctx := context.Background()
+	statement := []byte(`{"RequestID":1}`)
+
+	signer, err := NewKeySigner("0x" + testKey)
+	testutil.Ok(t, err)
+	a, err := signer.Sign(ctx, statement)
+	testutil.Ok(t, err)
+	s, err := Verify(a)
+	testutil.Ok(t, err)
+	testutil.Equals(t, int64(1), s.RequestID)
+
+	tampered := a
+	tampered.Statement = []byte(`{"RequestID":2}`)
+	_, err = Verify(tampered)
+	testutil.NotOk(t, err, "a changed statement should not verify")
+
+	// The remote service signs with its own key.
+	remoteKey, err := crypto.GenerateKey()
+	testutil.Ok(t, err)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		testutil.Ok(t, err)
+		sig, err := crypto.Sign(crypto.Keccak256(body), remoteKey)
+		testutil.Ok(t, err)
+		_ = web.WriteJSON(w, http.StatusOK, Attestation{
+			Signer:    crypto.PubkeyToAddress(remoteKey.PublicKey).Hex(),
+			Signature: hexutil.Encode(sig),
+			Quote:     "quote",
+		}, nil)
+	}))
+	defer srv.Close()
+	a, err = NewRemoteSigner(srv.URL, time.Second).Sign(ctx, statement)
+	testutil.Ok(t, err)
+	testutil.Equals(t, "quote", a.Quote)
+	_, err = Verify(a)
+	testutil.Ok(t, err)
+}
+
+func TestAttestor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "attestation")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+	testutil.Ok(t, os.Setenv(PrivateKeyEnvName, testKey))
+	defer os.Unsetenv(PrivateKeyEnvName)
+
+	cfg := Config{
+		Enabled:         true,
+		LogLevel:        "info",
+		Timeout:         format.Duration{Duration: time.Second},
+		Window:          format.Duration{Duration: time.Minute},
+		Path:            filepath.Join(dir, "attestations.jsonl"),
+		MaxAttestations: 2,
+	}
+	attestor, err := New(log.NewNopLogger(), context.Background(), cfg, &psrMock{}, inputs, "methodology")
+	testutil.Ok(t, err)
+
+	ts := time.Unix(1600000000, 0).UTC()
+	for i := int64(1); i <= 3; i++ {
+		val, err := attestor.GetValue(i, ts)
+		testutil.Ok(t, err)
+		testutil.Equals(t, big.NewInt(2000e6), val)
+		attestor.wg.Wait()
+	}
+	testutil.Ok(t, attestor.Close())
+
+	// Reopen to check that the latest are loaded from the file.
+	attestor, err = New(log.NewNopLogger(), context.Background(), cfg, &psrMock{}, inputs, "methodology")
+	testutil.Ok(t, err)
+	defer attestor.Close()
+
+	rec := httptest.NewRecorder()
+	attestor.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/attestations", nil))
+	testutil.Equals(t, http.StatusOK, rec.Code)
+	var resp struct {
+		Data []Attestation
+	}
+	testutil.Ok(t, json.NewDecoder(rec.Body).Decode(&resp))
+	testutil.Equals(t, 2, len(resp.Data))
+
+	s, err := Verify(resp.Data[0])
+	testutil.Ok(t, err)
+	testutil.Equals(t, int64(3), s.RequestID)
+	testutil.Equals(t, ts, s.Timestamp)
+	testutil.Equals(t, "2000000000", s.Value)
+	testutil.Equals(t, "methodology", s.Methodology)
+	testutil.Equals(t, 1, len(s.Aggregations))
+	testutil.Equals(t, 1, len(s.Inputs["ETH/USD"]))
+
+	rec = httptest.NewRecorder()
+	attestor.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/attestations?id=2", nil))
+	testutil.Ok(t, json.NewDecoder(rec.Body).Decode(&resp))
+	testutil.Equals(t, 1, len(resp.Data))
+}
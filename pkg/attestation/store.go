@@ -0,0 +1,114 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package attestation
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+type entry struct {
+	requestID   int64
+	attestation Attestation
+}
+
+// store appends the attestations to a file with one JSON per line
+// and keeps the latest in memory for the API.
+type store struct {
+	mtx     sync.Mutex
+	file    *os.File
+	max     int
+	entries []entry
+}
+
+func openStore(path string, max int) (*store, error) {
+	if max <= 0 {
+		return nil, errors.Errorf("max attestations should be more than 0:%v", max)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return nil, errors.Wrap(err, "creating attestations folder")
+	}
+	self := &store{max: max}
+	if err := self.load(path); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening attestations file")
+	}
+	self.file = f
+	return self, nil
+}
+
+func (self *store) load(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "opening attestations file")
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	// The statements include the input samples so the lines can be long.
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var a Attestation
+		// A partial last line after a crash is skipped.
+		if err := json.Unmarshal(scanner.Bytes(), &a); err != nil {
+			continue
+		}
+		var s Statement
+		if err := json.Unmarshal(a.Statement, &s); err != nil {
+			continue
+		}
+		self.keep(entry{requestID: s.RequestID, attestation: a})
+	}
+	return errors.Wrap(scanner.Err(), "reading attestations file")
+}
+
+func (self *store) keep(e entry) {
+	self.entries = append(self.entries, e)
+	if len(self.entries) > self.max {
+		self.entries = append(self.entries[:0], self.entries[len(self.entries)-self.max:]...)
+	}
+}
+
+func (self *store) add(a Attestation, requestID int64) error {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return errors.Wrap(err, "marshal attestation")
+	}
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	if _, err := self.file.Write(append(data, '\n')); err != nil {
+		return errors.Wrap(err, "writing attestation")
+	}
+	self.keep(entry{requestID: requestID, attestation: a})
+	return nil
+}
+
+// list returns the attestations of the request ID, all for 0, newest first.
+func (self *store) list(requestID int64) []Attestation {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	result := []Attestation{}
+	for i := len(self.entries) - 1; i >= 0; i-- {
+		if requestID == 0 || self.entries[i].requestID == requestID {
+			result = append(result, self.entries[i].attestation)
+		}
+	}
+	return result
+}
+
+func (self *store) close() error {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	return self.file.Close()
+}
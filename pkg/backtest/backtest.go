@@ -0,0 +1,224 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package backtest
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/tellor-io/telliot/pkg/aggregator"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/tracker/index"
+)
+
+// Aggregation strategies.
+const (
+	StrategyMedian = "median"
+	StrategyMean   = "mean"
+	// StrategyTWAP takes the look back after a colon e.g. twap:1h.
+	StrategyTWAP = "twap"
+)
+
+// Strategy computes a value and its confidence for a symbol at a time.
+type Strategy struct {
+	Name      string
+	Aggregate func(symbol string, ts time.Time) (float64, float64, error)
+}
+
+// Strategies creates the strategies from their names.
+func Strategies(aggr *aggregator.Aggregator, names []string) ([]Strategy, error) {
+	var strategies []Strategy
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		switch {
+		case name == StrategyMedian:
+			strategies = append(strategies, Strategy{Name: name, Aggregate: aggr.MedianAt})
+		case name == StrategyMean:
+			strategies = append(strategies, Strategy{Name: name, Aggregate: aggr.MeanAt})
+		case strings.HasPrefix(name, StrategyTWAP+":"):
+			lookBack, err := time.ParseDuration(strings.TrimPrefix(name, StrategyTWAP+":"))
+			if err != nil {
+				return nil, errors.Wrapf(err, "parsing the look back of strategy:%v", name)
+			}
+			strategies = append(strategies, Strategy{
+				Name: name,
+				Aggregate: func(symbol string, ts time.Time) (float64, float64, error) {
+					return aggr.TimeWeightedAvg(symbol, ts, lookBack)
+				},
+			})
+		default:
+			return nil, errors.Errorf("unknown strategy:%v", name)
+		}
+	}
+	return strategies, nil
+}
+
+// Accepted is a value accepted on-chain.
+type Accepted struct {
+	Time  time.Time
+	Value float64
+}
+
+// AcceptedValues reads the values accepted on-chain for a request ID within the time range.
+// The values are divided by the granularity.
+func AcceptedValues(ctx context.Context, reader contracts.ValueReader, requestID int64, granularity float64, from, to time.Time) ([]Accepted, error) {
+	queryID := contracts.LegacyQueryID(requestID)
+	count, err := reader.ValueCount(ctx, queryID)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting the value count")
+	}
+	var accepted []Accepted
+	// Walk back from the latest value until before the range.
+	for i := count - 1; i >= 0; i-- {
+		ts, err := reader.TimestampByIndex(ctx, queryID, i)
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting the timestamp index:%v", i)
+		}
+		if ts.Before(from) {
+			break
+		}
+		if ts.After(to) {
+			continue
+		}
+		val, err := reader.Value(ctx, queryID, ts)
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting the value timestamp:%v", ts)
+		}
+		v, _ := new(big.Float).SetInt(val).Float64()
+		accepted = append(accepted, Accepted{Time: ts, Value: v / granularity})
+	}
+	sort.Slice(accepted, func(i, j int) bool { return accepted[i].Time.Before(accepted[j].Time) })
+	return accepted, nil
+}
+
+// Result is how a strategy with a min confidence compares to the accepted values.
+type Result struct {
+	Strategy      string
+	MinConfidence float64
+	// Compared is the number of values that were computed with enough confidence.
+	Compared int
+	// Skipped is the number of values with a confidence below the min.
+	Skipped int
+	Errors  int
+	// MeanDeviation and MaxDeviation are in percent from the accepted values.
+	MeanDeviation float64
+	MaxDeviation  float64
+	// OverThreshold is the number of values that deviate more than the dispute threshold.
+	OverThreshold int
+}
+
+// Run replays the accepted values through each strategy and min confidence.
+func Run(strategies []Strategy, minConfidences []float64, symbol string, accepted []Accepted, threshold float64) []Result {
+	var results []Result
+	for _, strategy := range strategies {
+		type computed struct {
+			deviation  float64
+			confidence float64
+		}
+		var values []computed
+		var errs int
+		for _, a := range accepted {
+			val, conf, err := strategy.Aggregate(symbol, a.Time)
+			if err != nil || a.Value == 0 {
+				errs++
+				continue
+			}
+			values = append(values, computed{deviation: math.Abs(val-a.Value) / a.Value * 100, confidence: conf})
+		}
+
+		for _, minConfidence := range minConfidences {
+			r := Result{Strategy: strategy.Name, MinConfidence: minConfidence, Errors: errs}
+			var sum float64
+			for _, v := range values {
+				if v.confidence < minConfidence {
+					r.Skipped++
+					continue
+				}
+				r.Compared++
+				sum += v.deviation
+				if v.deviation > r.MaxDeviation {
+					r.MaxDeviation = v.deviation
+				}
+				if v.deviation > threshold {
+					r.OverThreshold++
+				}
+			}
+			if r.Compared > 0 {
+				r.MeanDeviation = sum / float64(r.Compared)
+			}
+			results = append(results, r)
+		}
+	}
+	return results
+}
+
+// ImportCSV appends the index values from a CSV with the columns:
+// timestamp(unix seconds or RFC3339),symbol,source,value.
+// A header line is skipped.
+// The interval is how often the values were collected which the TWAP needs for the confidence.
+func ImportCSV(ctx context.Context, db *tsdb.DB, r io.Reader, interval time.Duration) (int, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	appender := db.Appender(ctx)
+	var count int
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			_ = appender.Rollback()
+			return 0, errors.Wrapf(err, "reading line:%v", line)
+		}
+		ts, err := parseTime(record[0])
+		if err != nil {
+			if line == 1 { // Header.
+				continue
+			}
+			_ = appender.Rollback()
+			return 0, errors.Wrapf(err, "parsing timestamp line:%v", line)
+		}
+		value, err := strconv.ParseFloat(record[3], 64)
+		if err != nil {
+			_ = appender.Rollback()
+			return 0, errors.Wrapf(err, "parsing value line:%v", line)
+		}
+		for name, v := range map[string]float64{
+			index.ValueMetricName:    value,
+			index.IntervalMetricName: float64(interval),
+		} {
+			lbls := labels.Labels{
+				labels.Label{Name: "__name__", Value: name},
+				labels.Label{Name: "source", Value: record[2]},
+				labels.Label{Name: "symbol", Value: format.SanitizeMetricName(record[1])},
+			}
+			sort.Sort(lbls)
+			if _, err := appender.Append(0, lbls, timestamp.FromTime(ts), v); err != nil {
+				_ = appender.Rollback()
+				return 0, errors.Wrapf(err, "append line:%v", line)
+			}
+		}
+		count++
+	}
+	return count, errors.Wrap(appender.Commit(), "commit")
+}
+
+func parseTime(s string) (time.Time, error) {
+	if unix, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(unix, 0), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
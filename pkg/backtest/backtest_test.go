@@ -0,0 +1,44 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package backtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+func TestRun(t *testing.T) {
+	now := time.Now()
+	accepted := []Accepted{
+		{Time: now, Value: 100},
+		{Time: now.Add(time.Minute), Value: 100},
+		{Time: now.Add(2 * time.Minute), Value: 100},
+	}
+	computed := map[time.Time][2]float64{
+		accepted[0].Time: {101, 90},
+		accepted[1].Time: {110, 50},
+		accepted[2].Time: {98, 95},
+	}
+	strategy := Strategy{
+		Name: StrategyMedian,
+		Aggregate: func(symbol string, ts time.Time) (float64, float64, error) {
+			return computed[ts][0], computed[ts][1], nil
+		},
+	}
+
+	results := Run([]Strategy{strategy}, []float64{0, 90}, "ETH/USD", accepted, 5)
+	testutil.Equals(t, 2, len(results))
+
+	testutil.Equals(t, 3, results[0].Compared)
+	testutil.Equals(t, 1, results[0].OverThreshold)
+	testutil.Equals(t, 10.0, results[0].MaxDeviation)
+	testutil.Equals(t, 13.0/3, results[0].MeanDeviation)
+
+	testutil.Equals(t, 2, results[1].Compared)
+	testutil.Equals(t, 1, results[1].Skipped)
+	testutil.Equals(t, 0, results[1].OverThreshold)
+	testutil.Equals(t, 2.0, results[1].MaxDeviation)
+}
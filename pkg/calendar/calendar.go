@@ -0,0 +1,204 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+// Package calendar has the trading hours and holidays of the traditional markets
+// and the policies for the symbols tied to them outside the trading hours.
+package calendar
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	// The trading hours are in the New York time which isn't on all systems.
+	_ "time/tzdata"
+)
+
+// Market sets the trading hours of a symbol.
+type Market string
+
+const (
+	// MarketCrypto is open all the time.
+	MarketCrypto Market = ""
+	// MarketFX is open from Sunday 17:00 to Friday 17:00 New York time.
+	MarketFX Market = "fx"
+	// MarketMetals is open from Sunday 18:00 to Friday 17:00 New York time
+	// with a daily break from 17:00 to 18:00.
+	MarketMetals Market = "metals"
+)
+
+// The policies for the values of a symbol while its market is closed.
+const (
+	// Hold uses the value at the last close.
+	Hold = "hold"
+	// Interpolate uses the value between the last close and the next open
+	// when the market has opened again, the value at the last close otherwise.
+	Interpolate = "interpolate"
+	// Refuse returns ErrMarketClosed.
+	Refuse = "refuse"
+)
+
+// maxClosed is the longest closure that is searched for the last close and the next open.
+const maxClosed = 14 * 24 * time.Hour
+
+var ErrMarketClosed = errors.New("market closed")
+
+var newYork = func() *time.Location {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}()
+
+func (self Market) Validate() error {
+	switch self {
+	case MarketCrypto, MarketFX, MarketMetals:
+		return nil
+	}
+	return errors.Errorf("unknown market:%v, should be fx or metals", self)
+}
+
+// Open returns whether the market is open at the given time by its trading hours.
+// The holidays are only known to a Calendar.
+func (self Market) Open(t time.Time) bool {
+	if self == MarketCrypto {
+		return true
+	}
+	t = t.In(newYork)
+	hour := t.Hour()
+	switch t.Weekday() {
+	case time.Saturday:
+		return false
+	case time.Sunday:
+		if self == MarketMetals {
+			return hour >= 18
+		}
+		return hour >= 17
+	case time.Friday:
+		return hour < 17
+	}
+	if self == MarketMetals && hour == 17 {
+		return false
+	}
+	return true
+}
+
+// Symbol is the market of a symbol and its policy outside the trading hours.
+type Symbol struct {
+	Market Market
+	// Closed is the policy while the market is closed, hold by default.
+	Closed string
+}
+
+// Calendar has the markets of the symbols and the holidays of the markets.
+// It is safe for concurrent use.
+type Calendar struct {
+	symbols map[string]Symbol
+	// holidays are the trade dates when a market is closed.
+	holidays map[Market]map[string]bool
+}
+
+type file struct {
+	Symbols  map[string]Symbol
+	Holidays map[Market][]string
+}
+
+// Load reads the calendar file.
+// A missing file is an empty calendar with all symbols open all the time.
+func Load(path string) (*Calendar, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) || path == "" {
+		return Parse([]byte("{}"))
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "read calendar file path:%s", path)
+	}
+	return Parse(data)
+}
+
+func Parse(data []byte) (*Calendar, error) {
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, errors.Wrap(err, "unmarshal calendar")
+	}
+	self := &Calendar{
+		symbols:  make(map[string]Symbol),
+		holidays: make(map[Market]map[string]bool),
+	}
+	for symbol, s := range f.Symbols {
+		if err := s.Market.Validate(); err != nil {
+			return nil, errors.Wrapf(err, "symbol:%v", symbol)
+		}
+		switch s.Closed {
+		case "":
+			s.Closed = Hold
+		case Hold, Interpolate, Refuse:
+		default:
+			return nil, errors.Errorf("unknown closed market policy:%v for symbol:%v, should be hold, interpolate or refuse", s.Closed, symbol)
+		}
+		self.symbols[symbol] = s
+	}
+	for market, dates := range f.Holidays {
+		if err := market.Validate(); err != nil {
+			return nil, err
+		}
+		self.holidays[market] = make(map[string]bool)
+		for _, d := range dates {
+			if _, err := time.Parse("2006-01-02", d); err != nil {
+				return nil, errors.Wrapf(err, "holiday of market:%v", market)
+			}
+			self.holidays[market][d] = true
+		}
+	}
+	return self, nil
+}
+
+// Symbol returns the market of the symbol and false for the symbols which aren't tied to a market.
+func (self *Calendar) Symbol(symbol string) (Symbol, bool) {
+	s, ok := self.symbols[symbol]
+	return s, ok && s.Market != MarketCrypto
+}
+
+// Open returns whether the market is open at the given time by its trading hours and holidays.
+func (self *Calendar) Open(market Market, t time.Time) bool {
+	if !market.Open(t) {
+		return false
+	}
+	return !self.holidays[market][tradeDate(t)]
+}
+
+// tradeDate returns the date of the trading session at the given time.
+// The sessions start at 17:00 New York time for the next day.
+func tradeDate(t time.Time) string {
+	return t.In(newYork).Add(7 * time.Hour).Format("2006-01-02")
+}
+
+// LastClose returns the time when the market closed before the given time
+// at which it should be closed. The trading hours start and end on the hour.
+func (self *Calendar) LastClose(market Market, t time.Time) (time.Time, error) {
+	c := t.Truncate(time.Hour)
+	for !self.Open(market, c.Add(-time.Minute)) {
+		c = c.Add(-time.Hour)
+		if t.Sub(c) > maxClosed {
+			return time.Time{}, errors.Errorf("market:%v closed for more than:%v", market, maxClosed)
+		}
+	}
+	return c, nil
+}
+
+// NextOpen returns the time when the market opens after the given time
+// at which it should be closed.
+func (self *Calendar) NextOpen(market Market, t time.Time) (time.Time, error) {
+	o := t.Truncate(time.Hour).Add(time.Hour)
+	for !self.Open(market, o) {
+		o = o.Add(time.Hour)
+		if o.Sub(t) > maxClosed {
+			return time.Time{}, errors.Errorf("market:%v closed for more than:%v", market, maxClosed)
+		}
+	}
+	return o, nil
+}
@@ -0,0 +1,66 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+func TestMarketHours(t *testing.T) {
+	for _, c := range []struct {
+		market Market
+		at     string
+		open   bool
+	}{
+		{MarketCrypto, "2021-06-05T12:00:00Z", true},
+		// Friday 16:59 and 17:00 in New York.
+		{MarketFX, "2021-06-04T20:59:00Z", true},
+		{MarketFX, "2021-06-04T21:00:00Z", false},
+		{MarketFX, "2021-06-05T12:00:00Z", false},
+		// Sunday 17:00 in New York.
+		{MarketFX, "2021-06-06T21:00:00Z", true},
+		{MarketMetals, "2021-06-06T21:00:00Z", false},
+		{MarketMetals, "2021-06-06T22:00:00Z", true},
+		// The daily break of the metals.
+		{MarketFX, "2021-06-08T21:30:00Z", true},
+		{MarketMetals, "2021-06-08T21:30:00Z", false},
+		// Standard time in the winter.
+		{MarketFX, "2021-12-03T21:30:00Z", true},
+		{MarketFX, "2021-12-03T22:00:00Z", false},
+	} {
+		at, err := time.Parse(time.RFC3339, c.at)
+		testutil.Ok(t, err)
+		testutil.Equals(t, c.open, c.market.Open(at), "market:%v at:%v", c.market, c.at)
+	}
+}
+
+func TestCalendar(t *testing.T) {
+	cal, err := Parse([]byte(`{
+		"Symbols": {"XAU/USD": {"Market": "metals"}, "EUR/USD": {"Market": "fx", "Closed": "refuse"}},
+		"Holidays": {"metals": ["2021-06-07"]}
+	}`))
+	testutil.Ok(t, err)
+
+	s, ok := cal.Symbol("XAU/USD")
+	testutil.Assert(t, ok, "no market for XAU/USD")
+	testutil.Equals(t, Symbol{Market: MarketMetals, Closed: Hold}, s)
+	_, ok = cal.Symbol("ETH/USD")
+	testutil.Assert(t, !ok, "unexpected market for ETH/USD")
+
+	// The holiday on Monday extends the weekend until the session of Tuesday.
+	saturday := time.Date(2021, 6, 5, 12, 0, 0, 0, time.UTC)
+	close, err := cal.LastClose(MarketMetals, saturday)
+	testutil.Ok(t, err)
+	testutil.Equals(t, time.Date(2021, 6, 4, 21, 0, 0, 0, time.UTC), close)
+	open, err := cal.NextOpen(MarketMetals, saturday)
+	testutil.Ok(t, err)
+	testutil.Equals(t, time.Date(2021, 6, 7, 22, 0, 0, 0, time.UTC), open)
+	testutil.Assert(t, cal.Open(MarketFX, time.Date(2021, 6, 7, 12, 0, 0, 0, time.UTC)), "fx closed on a metals holiday")
+
+	_, err = Parse([]byte(`{"Symbols": {"XAU/USD": {"Market": "metals", "Closed": "wait"}}}`))
+	testutil.NotOk(t, err)
+}
@@ -0,0 +1,189 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+// Package chaos injects random faults into the RPC client and the data sources
+// so that the operators can check that their deployment and its alerts
+// handle a slow node, dropped subscriptions and failing APIs.
+// It should never be enabled for a production deployment.
+package chaos
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/errclass"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+)
+
+const ComponentName = "chaos"
+
+// The injected faults.
+const (
+	faultRPCDelay         = "rpc_delay"
+	faultSubscriptionDrop = "subscription_drop"
+	faultSourceError      = "source_error"
+)
+
+// ErrInjected is returned by the injected faults.
+var ErrInjected = errclass.New(errclass.Retryable, "injected fault")
+
+var faultsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "telliot",
+	Subsystem: ComponentName,
+	Name:      "faults_total",
+	Help:      "The total number of injected faults",
+}, []string{"fault"})
+
+// Config sets the probabilities of the faults between 0 and 1, 0 disables the fault.
+type Config struct {
+	Enabled  bool
+	LogLevel string
+	// Seed makes the faults the same on every run, 0 uses the start time.
+	Seed int64
+	// RPCDelay is the probability that an RPC call is delayed
+	// by a random duration up to RPCMaxDelay.
+	RPCDelay    float64
+	RPCMaxDelay format.Duration
+	// SubscriptionDrop is the probability that a subscription is dropped with an error
+	// in every SubscriptionInterval like when the websocket connection of the node is lost.
+	SubscriptionDrop     float64
+	SubscriptionInterval format.Duration
+	// SourceError is the probability that getting a value of an index tracker source fails.
+	SourceError float64
+}
+
+func (self Config) Validate() error {
+	for name, p := range map[string]float64{
+		"RPCDelay":         self.RPCDelay,
+		"SubscriptionDrop": self.SubscriptionDrop,
+		"SourceError":      self.SourceError,
+	} {
+		if p < 0 || p > 1 {
+			return errors.Errorf("%v probability should be between 0 and 1:%v", name, p)
+		}
+	}
+	if self.RPCDelay > 0 && self.RPCMaxDelay.Duration <= 0 {
+		return errors.New("RPCMaxDelay should be more than 0 when RPCDelay is enabled")
+	}
+	if self.SubscriptionDrop > 0 && self.SubscriptionInterval.Duration <= 0 {
+		return errors.New("SubscriptionInterval should be more than 0 when SubscriptionDrop is enabled")
+	}
+	return nil
+}
+
+// Injector decides randomly which calls get a fault.
+type Injector struct {
+	logger log.Logger
+	cfg    Config
+	mtx    sync.Mutex
+	rand   *rand.Rand
+}
+
+// New creates an injector or returns nil when it is disabled.
+func New(logger log.Logger, cfg Config) (*Injector, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, errors.Wrap(err, "validate config")
+	}
+	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	logger = log.With(logger, "component", ComponentName)
+
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	level.Warn(logger).Log("msg", "fault injection enabled, do not use in production", "seed", seed)
+
+	return &Injector{
+		logger: logger,
+		cfg:    cfg,
+		rand:   rand.New(rand.NewSource(seed)),
+	}, nil
+}
+
+// roll returns true with the probability.
+func (self *Injector) roll(p float64) bool {
+	if p <= 0 {
+		return false
+	}
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	return self.rand.Float64() < p
+}
+
+func (self *Injector) duration(max time.Duration) time.Duration {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	return time.Duration(self.rand.Int63n(int64(max)) + 1)
+}
+
+func (self *Injector) inject(fault string, keyvals ...interface{}) {
+	faultsTotal.With(prometheus.Labels{"fault": fault}).Inc()
+	level.Debug(self.logger).Log(append([]interface{}{"msg", "injected fault", "fault", fault}, keyvals...)...)
+}
+
+// Delay waits a random duration before some RPC calls.
+// It returns the error of the context when it is done before the end of the delay.
+func (self *Injector) Delay(ctx context.Context, method string) error {
+	if !self.roll(self.cfg.RPCDelay) {
+		return nil
+	}
+	d := self.duration(self.cfg.RPCMaxDelay.Duration)
+	self.inject(faultRPCDelay, "method", method, "delay", d)
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Subscription wraps a subscription so that it can be dropped with ErrInjected.
+// The subscription is unsubscribed from the node when it is dropped.
+func (self *Injector) Subscription(sub ethereum.Subscription) ethereum.Subscription {
+	if self.cfg.SubscriptionDrop <= 0 {
+		return sub
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		ticker := time.NewTicker(self.cfg.SubscriptionInterval.Duration)
+		defer ticker.Stop()
+		for {
+			select {
+			case err := <-sub.Err():
+				return err
+			case <-ticker.C:
+				if self.roll(self.cfg.SubscriptionDrop) {
+					self.inject(faultSubscriptionDrop)
+					return ErrInjected
+				}
+			case <-quit:
+				return nil
+			}
+		}
+	})
+}
+
+// SourceError returns ErrInjected for some of the values of a data source.
+func (self *Injector) SourceError(source string) error {
+	if !self.roll(self.cfg.SourceError) {
+		return nil
+	}
+	self.inject(faultSourceError, "source", source)
+	return errors.Wrapf(ErrInjected, "source:%v", source)
+}
@@ -0,0 +1,68 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package chaos
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+func TestDisabled(t *testing.T) {
+	faults, err := New(log.NewNopLogger(), Config{SourceError: 1})
+	testutil.Ok(t, err)
+	testutil.Assert(t, faults == nil, "a disabled injector should be nil")
+
+	_, err = New(log.NewNopLogger(), Config{Enabled: true, SourceError: 2})
+	testutil.NotOk(t, err)
+	_, err = New(log.NewNopLogger(), Config{Enabled: true, RPCDelay: 0.5})
+	testutil.NotOk(t, err)
+}
+
+func TestFaults(t *testing.T) {
+	faults, err := New(log.NewNopLogger(), Config{
+		Enabled:              true,
+		LogLevel:             "info",
+		Seed:                 1,
+		RPCDelay:             1,
+		RPCMaxDelay:          format.Duration{Duration: time.Hour},
+		SubscriptionDrop:     1,
+		SubscriptionInterval: format.Duration{Duration: time.Millisecond},
+		SourceError:          1,
+	})
+	testutil.Ok(t, err)
+
+	err = faults.SourceError("api")
+	testutil.Assert(t, errors.Is(err, ErrInjected), "the source error should be injected:%v", err)
+
+	// The delay ends with the context.
+	ctx, cncl := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cncl()
+	testutil.Equals(t, context.DeadlineExceeded, faults.Delay(ctx, "eth_call"))
+
+	unsubscribed := make(chan struct{})
+	inner := event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		close(unsubscribed)
+		return nil
+	})
+	sub := faults.Subscription(inner)
+	select {
+	case err := <-sub.Err():
+		testutil.Equals(t, ErrInjected, err)
+	case <-time.After(time.Second):
+		t.Fatal("the subscription wasn't dropped")
+	}
+	select {
+	case <-unsubscribed:
+	case <-time.After(time.Second):
+		t.Fatal("the dropped subscription wasn't unsubscribed")
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package chaos
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/tellor-io/telliot/pkg/contracts"
+)
+
+// client delays the RPC calls and drops the subscriptions of the next client.
+type client struct {
+	next   contracts.ETHClient
+	faults *Injector
+}
+
+// NewClient wraps the client to inject the RPC faults.
+func NewClient(faults *Injector, next contracts.ETHClient) contracts.ETHClient {
+	return &client{next: next, faults: faults}
+}
+
+func (self *client) Close() {
+	self.next.Close()
+}
+
+func (self *client) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	if err := self.faults.Delay(ctx, "eth_getCode"); err != nil {
+		return nil, err
+	}
+	return self.next.CodeAt(ctx, contract, blockNumber)
+}
+
+func (self *client) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	if err := self.faults.Delay(ctx, "eth_getTransactionReceipt"); err != nil {
+		return nil, err
+	}
+	return self.next.TransactionReceipt(ctx, txHash)
+}
+
+func (self *client) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if err := self.faults.Delay(ctx, "eth_call"); err != nil {
+		return nil, err
+	}
+	return self.next.CallContract(ctx, call, blockNumber)
+}
+
+func (self *client) BatchCallContract(ctx context.Context, calls []ethereum.CallMsg, blockNumber *big.Int) ([][]byte, []error, error) {
+	if err := self.faults.Delay(ctx, "eth_call_batch"); err != nil {
+		return nil, nil, err
+	}
+	batcher, ok := self.next.(contracts.BatchCaller)
+	if !ok {
+		var (
+			res  [][]byte
+			errs []error
+		)
+		for _, call := range calls {
+			r, err := self.next.CallContract(ctx, call, blockNumber)
+			res = append(res, r)
+			errs = append(errs, err)
+		}
+		return res, errs, nil
+	}
+	return batcher.BatchCallContract(ctx, calls, blockNumber)
+}
+
+func (self *client) NonceAt(ctx context.Context, address common.Address) (uint64, error) {
+	if err := self.faults.Delay(ctx, "eth_getTransactionCount"); err != nil {
+		return 0, err
+	}
+	return self.next.NonceAt(ctx, address)
+}
+
+func (self *client) PendingCallContract(ctx context.Context, call ethereum.CallMsg) ([]byte, error) {
+	if err := self.faults.Delay(ctx, "eth_call"); err != nil {
+		return nil, err
+	}
+	return self.next.PendingCallContract(ctx, call)
+}
+
+func (self *client) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
+	if err := self.faults.Delay(ctx, "eth_getCode"); err != nil {
+		return nil, err
+	}
+	return self.next.PendingCodeAt(ctx, account)
+}
+
+func (self *client) PendingNonceAt(ctx context.Context, address common.Address) (uint64, error) {
+	if err := self.faults.Delay(ctx, "eth_getTransactionCount"); err != nil {
+		return 0, err
+	}
+	return self.next.PendingNonceAt(ctx, address)
+}
+
+func (self *client) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	if err := self.faults.Delay(ctx, "eth_estimateGas"); err != nil {
+		return 0, err
+	}
+	return self.next.EstimateGas(ctx, call)
+}
+
+func (self *client) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	if err := self.faults.Delay(ctx, "eth_gasPrice"); err != nil {
+		return nil, err
+	}
+	return self.next.SuggestGasPrice(ctx)
+}
+
+func (self *client) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	if err := self.faults.Delay(ctx, "eth_getLogs"); err != nil {
+		return nil, err
+	}
+	return self.next.FilterLogs(ctx, query)
+}
+
+func (self *client) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	sub, err := self.next.SubscribeFilterLogs(ctx, query, ch)
+	if err != nil {
+		return nil, err
+	}
+	return self.faults.Subscription(sub), nil
+}
+
+func (self *client) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	sub, err := self.next.SubscribeNewHead(ctx, ch)
+	if err != nil {
+		return nil, err
+	}
+	return self.faults.Subscription(sub), nil
+}
+
+func (self *client) BalanceAt(ctx context.Context, address common.Address, block *big.Int) (*big.Int, error) {
+	if err := self.faults.Delay(ctx, "eth_getBalance"); err != nil {
+		return nil, err
+	}
+	return self.next.BalanceAt(ctx, address, block)
+}
+
+func (self *client) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := self.faults.Delay(ctx, "eth_sendRawTransaction"); err != nil {
+		return err
+	}
+	return self.next.SendTransaction(ctx, tx)
+}
+
+func (self *client) IsSyncing(ctx context.Context) (bool, error) {
+	if err := self.faults.Delay(ctx, "eth_syncing"); err != nil {
+		return false, err
+	}
+	return self.next.IsSyncing(ctx)
+}
+
+func (self *client) NetworkID(ctx context.Context) (*big.Int, error) {
+	if err := self.faults.Delay(ctx, "net_version"); err != nil {
+		return nil, err
+	}
+	return self.next.NetworkID(ctx)
+}
+
+func (self *client) HeaderByNumber(ctx context.Context, num *big.Int) (*types.Header, error) {
+	if err := self.faults.Delay(ctx, "eth_getBlockByNumber"); err != nil {
+		return nil, err
+	}
+	return self.next.HeaderByNumber(ctx, num)
+}
+
+func (self *client) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	if err := self.faults.Delay(ctx, "eth_getTransactionByHash"); err != nil {
+		return nil, false, err
+	}
+	return self.next.TransactionByHash(ctx, hash)
+}
+
+func (self *client) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	if err := self.faults.Delay(ctx, "eth_getBlockByHash"); err != nil {
+		return nil, err
+	}
+	return self.next.BlockByHash(ctx, hash)
+}
+
+func (self *client) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	if err := self.faults.Delay(ctx, "eth_getBlockByNumber"); err != nil {
+		return nil, err
+	}
+	return self.next.BlockByNumber(ctx, number)
+}
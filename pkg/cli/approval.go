@@ -0,0 +1,267 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/approval"
+	"github.com/tellor-io/telliot/pkg/config"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/notify"
+)
+
+// newApprovals returns a nil queue when the approvals are disabled
+// so that the operations are sent without waiting for the approvers.
+func newApprovals(logger log.Logger, cfg *config.Config) (*approval.Queue, error) {
+	if !cfg.Approval.Enabled {
+		return nil, nil
+	}
+	notifier, err := notify.New(logger, cfg.Notify)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating notifier")
+	}
+	queue, err := approval.New(logger, cfg.Approval, notifier)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating approval queue")
+	}
+	return queue, nil
+}
+
+// requestApproval queues the transaction for the approvers instead of sending it.
+func requestApproval(
+	ctx context.Context,
+	logger log.Logger,
+	client contracts.ETHClient,
+	approvals *approval.Queue,
+	auth *bind.TransactOpts,
+	account *ethereum.Account,
+	function string,
+	description string,
+	build func(*bind.TransactOpts) (*types.Transaction, error),
+) error {
+	auth.NoSend = true
+	tx, err := build(auth)
+	if err != nil {
+		return errors.Wrap(err, "creating the transaction")
+	}
+	chainID, err := client.NetworkID(ctx)
+	if err != nil {
+		return errors.Wrap(err, "getting network id")
+	}
+	r := approval.NewRequest(function, description, chainID, account.Address, tx)
+	if err := approvals.Add(ctx, r, time.Now()); err != nil {
+		return errors.Wrap(err, "queueing the approval request")
+	}
+	level.Info(logger).Log("msg", "waiting for the approvers", "id", r.ID, "function", function, "expires", r.Expires.Format(time.RFC3339))
+	return nil
+}
+
+type approvalListCmd struct {
+	Config configPath `type:"existingfile" help:"path to config file"`
+	All    bool       `help:"include the sent, rejected and expired requests"`
+}
+
+func (self approvalListCmd) Run() error {
+	logger := logging.NewLogger()
+	cfg, err := config.ParseConfig(logger, string(self.Config))
+	if err != nil {
+		return errors.Wrap(err, "creating config")
+	}
+	queue, err := approval.New(logger, cfg.Approval, nil)
+	if err != nil {
+		return errors.Wrap(err, "creating approval queue")
+	}
+	requests, err := queue.Requests(time.Now())
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "ID\tSTATE\tFUNCTION\tFROM\tAPPROVALS\tEXPIRES\tDESCRIPTION\n")
+	for _, r := range requests {
+		if !self.All && r.State != approval.StatePending && r.State != approval.StateApproved {
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d/%d\t%s\t%s\n",
+			r.ID,
+			r.State,
+			r.Function,
+			r.From.Hex(),
+			len(r.Approvals),
+			cfg.Approval.Threshold,
+			r.Expires.Format(time.RFC3339),
+			r.Description,
+		)
+	}
+	return tw.Flush()
+}
+
+type approvalApproveCmd struct {
+	Config    configPath `type:"existingfile" help:"path to config file"`
+	ID        string     `arg:"" help:"the ID of the approval request"`
+	Account   int        `default:"-1" help:"sign with the key of this account from the env file"`
+	Signature string     `help:"the personal_sign signature of the request message e.g. from a hardware wallet"`
+}
+
+func (self approvalApproveCmd) Run() error {
+	logger := logging.NewLogger()
+	cfg, err := config.ParseConfig(logger, string(self.Config))
+	if err != nil {
+		return errors.Wrap(err, "creating config")
+	}
+	queue, err := newApprovals(logger, cfg)
+	if err != nil {
+		return err
+	}
+	if queue == nil {
+		return errors.New("the approvals are disabled")
+	}
+	r, err := queue.Get(self.ID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var sig []byte
+	switch {
+	case self.Signature != "":
+		sig, err = hexutil.Decode(self.Signature)
+		if err != nil {
+			return errors.Wrap(err, "decoding the signature")
+		}
+	case self.Account >= 0:
+		accs, err := ethereum.GetAccounts()
+		if err != nil {
+			return errors.Wrap(err, "creating accounts")
+		}
+		account, err := getAccountFor(accs, self.Account)
+		if err != nil {
+			return err
+		}
+		if account.ReadOnly() {
+			return errors.Wrapf(ethereum.ErrReadOnly, "can't sign with:%v", account.Address.Hex())
+		}
+		sig, err = crypto.Sign(accounts.TextHash([]byte(r.Message())), account.GetPrivateKey())
+		if err != nil {
+			return errors.Wrap(err, "signing the request")
+		}
+	default:
+		// Printed for signing with a hardware wallet.
+		fmt.Println(r.Message())
+		return nil
+	}
+
+	r, err = queue.Approve(context.Background(), self.ID, sig, time.Now())
+	return auditCommand(logger, cfg.Db, "approval_approve", self.ID, err, "state", stateOf(r))
+}
+
+type approvalRejectCmd struct {
+	Config configPath `type:"existingfile" help:"path to config file"`
+	ID     string     `arg:"" help:"the ID of the approval request"`
+	Reason string     `help:"why the request is rejected"`
+}
+
+func (self approvalRejectCmd) Run() error {
+	logger := logging.NewLogger()
+	cfg, err := config.ParseConfig(logger, string(self.Config))
+	if err != nil {
+		return errors.Wrap(err, "creating config")
+	}
+	queue, err := approval.New(logger, cfg.Approval, nil)
+	if err != nil {
+		return errors.Wrap(err, "creating approval queue")
+	}
+	_, err = queue.Reject(self.ID, self.Reason, time.Now())
+	return auditCommand(logger, cfg.Db, "approval_reject", self.ID, err, "reason", self.Reason)
+}
+
+type approvalSendCmd struct {
+	Config configPath `type:"existingfile" help:"path to config file"`
+	ID     string     `arg:"" help:"the ID of the approval request"`
+}
+
+func (self approvalSendCmd) Run() error {
+	logger := logging.NewLogger()
+	cfg, err := config.ParseConfig(logger, string(self.Config))
+	if err != nil {
+		return errors.Wrap(err, "creating config")
+	}
+	queue, err := approval.New(logger, cfg.Approval, nil)
+	if err != nil {
+		return errors.Wrap(err, "creating approval queue")
+	}
+	r, err := queue.Get(self.ID, time.Now())
+	if err != nil {
+		return err
+	}
+	if r.State != approval.StateApproved {
+		return errors.Errorf("can't send a request in state:%v", r.State)
+	}
+
+	ctx := context.Background()
+	client, accs, err := createTellorVariables(ctx, logger, cfg.Ethereum)
+	if err != nil {
+		return errors.Wrap(err, "creating tellor variables")
+	}
+	var account *ethereum.Account
+	for _, acc := range accs {
+		if acc.Address == r.From {
+			account = acc
+		}
+	}
+	if account == nil {
+		return errors.Errorf("no key for the account of the request:%v", r.From.Hex())
+	}
+
+	err = sendApproved(ctx, logger, client, queue, account, r)
+	return auditCommand(logger, cfg.Db, "approval_send", self.ID, err, "function", r.Function, "account", r.From.Hex())
+}
+
+// sendApproved signs the transaction of the request with the current nonce and gas price and sends it.
+func sendApproved(
+	ctx context.Context,
+	logger log.Logger,
+	client contracts.ETHClient,
+	queue *approval.Queue,
+	account *ethereum.Account,
+	r *approval.Request,
+) error {
+	auth, err := ethereum.PrepareEthTransaction(ctx, client, account)
+	if err != nil {
+		return errors.Wrap(err, "prepare ethereum transaction")
+	}
+	tx, err := auth.Signer(account.Address, r.Transaction(auth.Nonce.Uint64(), auth.GasPrice))
+	if err != nil {
+		return errors.Wrap(err, "signing the transaction")
+	}
+	if err := client.SendTransaction(ctx, tx); err != nil {
+		return errors.Wrap(err, "sending the transaction")
+	}
+	if _, err := queue.Sent(r.ID, tx.Hash(), time.Now()); err != nil {
+		return errors.Wrap(err, "recording the sent transaction")
+	}
+	level.Info(logger).Log("msg", "approved transaction sent", "id", r.ID, "function", r.Function, "txHash", tx.Hash().Hex())
+	return nil
+}
+
+func stateOf(r *approval.Request) string {
+	if r == nil {
+		return ""
+	}
+	return r.State
+}
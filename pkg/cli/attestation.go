@@ -0,0 +1,97 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/attestation"
+	"github.com/tellor-io/telliot/pkg/client"
+	"github.com/tellor-io/telliot/pkg/config"
+	"github.com/tellor-io/telliot/pkg/logging"
+)
+
+type attestationVerifyCmd struct {
+	URL    string     `help:"address of a running instance e.g. http://localhost:9090"`
+	File   string     `type:"existingfile" help:"a JSON file with a list of attestations as served by the API"`
+	ID     int64      `help:"only the values of this request ID"`
+	Signer string     `help:"the expected signer address"`
+	Config configPath `type:"existingfile" help:"a config file to check that the values were calculated with its PSR config"`
+}
+
+// Run checks the signature of every attestation and optionally the signer and the methodology.
+func (self attestationVerifyCmd) Run() error {
+	var (
+		list []attestation.Attestation
+		err  error
+	)
+	switch {
+	case self.URL != "":
+		ctx, cncl := context.WithTimeout(context.Background(), statusTimeout)
+		defer cncl()
+		list, err = client.New(self.URL, 0).Attestations(ctx, self.ID)
+		if err != nil {
+			return err
+		}
+	case self.File != "":
+		data, err := ioutil.ReadFile(self.File)
+		if err != nil {
+			return errors.Wrap(err, "reading attestations file")
+		}
+		if err := json.Unmarshal(data, &list); err != nil {
+			return errors.Wrap(err, "decoding attestations file")
+		}
+	default:
+		return errors.New("either the url or the file is required")
+	}
+
+	var methodology string
+	if self.Config != "" {
+		cfg, err := config.ParseConfig(logging.NewLogger(), string(self.Config))
+		if err != nil {
+			return errors.Wrap(err, "creating config")
+		}
+		methodology, err = attestation.Methodology(cfg.PsrTellor)
+		if err != nil {
+			return err
+		}
+	}
+
+	var failed int
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "REQUEST ID\tTIMESTAMP\tVALUE\tSIGNER\tRESULT\n")
+	for _, a := range list {
+		s, err := attestation.Verify(a)
+		switch {
+		case err != nil:
+		case self.ID != 0 && s.RequestID != self.ID:
+			continue
+		case self.Signer != "" && !strings.EqualFold(self.Signer, a.Signer):
+			err = errors.New("unexpected signer")
+		case methodology != "" && methodology != s.Methodology:
+			err = errors.Errorf("different methodology:%v", s.Methodology)
+		}
+		result := "ok"
+		if err != nil {
+			failed++
+			result = err.Error()
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", s.RequestID, s.Timestamp.Format(time.RFC3339), s.Value, a.Signer, result)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return errors.Errorf("%v attestations failed the verification", failed)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/config"
+	"github.com/tellor-io/telliot/pkg/db"
+	"github.com/tellor-io/telliot/pkg/logging"
+)
+
+// openAudit opens the audit log or returns nil when it is disabled.
+func openAudit(cfg db.Config) (*db.Audit, error) {
+	if cfg.AuditPath == "" {
+		return nil, nil
+	}
+	return db.OpenAudit(cfg.AuditPath)
+}
+
+// auditCommand records a command that changes state with the OS user as the actor
+// and returns the error of the command.
+// The details are key value pairs.
+func auditCommand(logger log.Logger, cfg db.Config, action, target string, err error, details ...string) error {
+	audit, aErr := openAudit(cfg)
+	if aErr != nil {
+		level.Error(logger).Log("msg", "opening audit log", "err", aErr)
+		return err
+	}
+	defer audit.Close()
+
+	actor := "cli"
+	if u, uErr := user.Current(); uErr == nil {
+		actor += ":" + u.Username
+	}
+	entry := db.AuditEntry{
+		Actor:   actor,
+		Action:  action,
+		Target:  target,
+		Details: make(map[string]string),
+	}
+	for i := 0; i+1 < len(details); i += 2 {
+		entry.Details[details[i]] = details[i+1]
+	}
+	if err != nil {
+		entry.Err = err.Error()
+	}
+	if aErr := audit.Record(entry); aErr != nil {
+		level.Error(logger).Log("msg", "recording audit entry", "action", action, "err", aErr)
+	}
+	return err
+}
+
+type auditExportCmd struct {
+	Config configPath    `type:"existingfile" help:"path to config file"`
+	Since  time.Duration `help:"export only the entries of this last period e.g. 24h, by default all entries"`
+	Format string        `enum:"csv,json" default:"csv" help:"output format(csv,json)"`
+	Output string        `type:"path" help:"the file to write the entries to, by default stdout"`
+}
+
+// Run writes the entries of the audit log for post-incident review.
+func (self auditExportCmd) Run() error {
+	logger := logging.NewLogger()
+
+	cfg, err := config.ParseConfig(logger, string(self.Config))
+	if err != nil {
+		return errors.Wrap(err, "creating config")
+	}
+	if cfg.Db.AuditPath == "" {
+		return errors.New("the audit log is disabled")
+	}
+
+	var since time.Time
+	if self.Since > 0 {
+		since = time.Now().Add(-self.Since)
+	}
+	entries, err := db.ReadAudit(cfg.Db.AuditPath, since, time.Time{})
+	if err != nil {
+		return errors.Wrap(err, "reading the audit log")
+	}
+
+	var w io.Writer = os.Stdout
+	if self.Output != "" {
+		f, err := os.Create(self.Output)
+		if err != nil {
+			return errors.Wrap(err, "creating the output file")
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if self.Format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+	return WriteAuditCSV(w, entries)
+}
+
+// WriteAuditCSV writes the audit entries as CSV with the details as sorted key=value pairs.
+func WriteAuditCSV(w io.Writer, entries []db.AuditEntry) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"time", "actor", "action", "target", "details", "error"}); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		var details []string
+		for k, v := range e.Details {
+			details = append(details, k+"="+v)
+		}
+		sort.Strings(details)
+		if err := cw.Write([]string{
+			e.Time.UTC().Format(time.RFC3339),
+			e.Actor,
+			e.Action,
+			e.Target,
+			strings.Join(details, " "),
+			e.Err,
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// auditConfig records the config that an instance started with and its checksum
+// so that config changes between restarts can be traced.
+func auditConfig(audit *db.Audit, path, role string) error {
+	if audit == nil {
+		return nil
+	}
+	details := map[string]string{"role": role}
+	if path != "" {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return errors.Wrap(err, "reading config file")
+		}
+		details["sha256"] = fmt.Sprintf("%x", sha256.Sum256(b))
+	}
+	return audit.Record(db.AuditEntry{
+		Actor:   "mine",
+		Action:  "config_loaded",
+		Target:  path,
+		Details: details,
+	})
+}
@@ -0,0 +1,139 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/tellor-io/telliot/pkg/aggregator"
+	"github.com/tellor-io/telliot/pkg/backtest"
+	"github.com/tellor-io/telliot/pkg/config"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/db"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/registry"
+)
+
+type backtestCmd struct {
+	Config         configPath    `type:"existingfile" help:"path to config file"`
+	RequestID      int64         `required:"" help:"the request ID of the accepted values to compare with"`
+	Symbol         string        `required:"" help:"the symbol of the index values e.g. ETH/USD"`
+	Since          time.Duration `default:"24h" help:"how far back to replay"`
+	Strategies     []string      `default:"median,mean,twap:1h,twap:24h" help:"aggregation strategies to compare(median,mean,twap:<look back>)"`
+	MinConfidences []float64     `name:"min-confidence" default:"0,50,70,90" help:"min confidence levels to compare"`
+	Threshold      float64       `default:"5" help:"dispute threshold as a percent deviation from the accepted value"`
+	Granularity    float64       `help:"granularity of the on-chain values, defaults to the granularity in the request ID registry"`
+	CSV            string        `name:"csv" type:"existingfile" help:"replay the index values from a CSV(timestamp,symbol,source,value) instead of the DB"`
+	CSVInterval    time.Duration `name:"csv-interval" default:"30s" help:"how often the values in the CSV were collected"`
+}
+
+func (self backtestCmd) Run() error {
+	logger := logging.NewLogger()
+
+	cfg, err := config.ParseConfig(logger, string(self.Config))
+	if err != nil {
+		return errors.Wrap(err, "creating config")
+	}
+
+	ctx := context.Background()
+	client, _, err := createTellorVariables(ctx, logger, cfg.Ethereum)
+	if err != nil {
+		return errors.Wrap(err, "creating tellor variables")
+	}
+	contract, err := newTellorContract(client, cfg.Contracts)
+	if err != nil {
+		return errors.Wrap(err, "create tellor contract instance")
+	}
+	caps, err := contracts.NewCapabilities(ctx, client, contract.Address)
+	if err != nil {
+		return errors.Wrap(err, "getting the contract capabilities")
+	}
+	reader, err := caps.Reader()
+	if err != nil {
+		return err
+	}
+
+	reg, err := registry.New(logger, cfg.Registry)
+	if err != nil {
+		return errors.Wrap(err, "creating request ID registry")
+	}
+	granularity := self.Granularity
+	if granularity == 0 {
+		granularity = reg.Spec(registry.OracleTellor, self.RequestID).Granularity
+	}
+
+	to := time.Now()
+	accepted, err := backtest.AcceptedValues(ctx, reader, self.RequestID, granularity, to.Add(-self.Since), to)
+	if err != nil {
+		return errors.Wrap(err, "getting the accepted values")
+	}
+	level.Info(logger).Log("msg", "accepted values", "count", len(accepted))
+
+	var tsDB storage.SampleAndChunkQueryable
+	switch {
+	case self.CSV != "":
+		dir, err := ioutil.TempDir("", "telliot-backtest")
+		if err != nil {
+			return errors.Wrap(err, "creating the temp db dir")
+		}
+		defer os.RemoveAll(dir)
+		db, err := tsdb.Open(dir, nil, nil, tsdb.DefaultOptions())
+		if err != nil {
+			return errors.Wrap(err, "opening the temp db")
+		}
+		defer db.Close()
+		f, err := os.Open(self.CSV)
+		if err != nil {
+			return errors.Wrap(err, "opening the csv")
+		}
+		defer f.Close()
+		count, err := backtest.ImportCSV(ctx, db, f, self.CSVInterval)
+		if err != nil {
+			return errors.Wrap(err, "importing the csv")
+		}
+		level.Info(logger).Log("msg", "imported the csv", "values", count)
+		tsDB = db
+	case cfg.Db.RemoteHost != "":
+		tsDB, err = remoteDB(cfg.Db)
+		if err != nil {
+			return errors.Wrap(err, "opening remote tsdb DB")
+		}
+	default:
+		// Read only so that it can run next to a running instance.
+		_tsDB, err := db.OpenReadOnly(logger, cfg.Db)
+		if err != nil {
+			return errors.Wrap(err, "opening local tsdb DB")
+		}
+		defer _tsDB.Close()
+		tsDB = _tsDB
+	}
+
+	aggr, err := aggregator.New(logger, ctx, cfg.Aggregator, tsDB)
+	if err != nil {
+		return errors.Wrap(err, "creating aggregator")
+	}
+	strategies, err := backtest.Strategies(aggr, self.Strategies)
+	if err != nil {
+		return err
+	}
+
+	results := backtest.Run(strategies, self.MinConfidences, self.Symbol, accepted, self.Threshold)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "STRATEGY\tMIN CONFIDENCE\tCOMPARED\tSKIPPED\tERRORS\tMEAN DEV %%\tMAX DEV %%\tOVER %v%%\n", self.Threshold)
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%v\t%d\t%d\t%d\t%.3f\t%.3f\t%d\n", r.Strategy, r.MinConfidence, r.Compared, r.Skipped, r.Errors, r.MeanDeviation, r.MaxDeviation, r.OverThreshold)
+	}
+	fmt.Fprintf(tw, "\nThe current PSR min confidence is %v and the granularity %v.\n", cfg.PsrTellor.MinConfidence, granularity)
+	return tw.Flush()
+}
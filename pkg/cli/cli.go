@@ -5,6 +5,8 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
 	"net/url"
 	"os"
 	"strconv"
@@ -24,26 +26,72 @@ import (
 	"github.com/prometheus/prometheus/storage/remote"
 	"github.com/prometheus/prometheus/tsdb"
 	"github.com/tellor-io/telliot/pkg/aggregator"
+	"github.com/tellor-io/telliot/pkg/approval"
+	"github.com/tellor-io/telliot/pkg/attestation"
+	"github.com/tellor-io/telliot/pkg/chaos"
 	"github.com/tellor-io/telliot/pkg/config"
 	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/coordination"
+	"github.com/tellor-io/telliot/pkg/crosscheck"
+	"github.com/tellor-io/telliot/pkg/dashboards"
 	"github.com/tellor-io/telliot/pkg/db"
 	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/evidence"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/gateway"
+	"github.com/tellor-io/telliot/pkg/health"
+	"github.com/tellor-io/telliot/pkg/indexer"
 	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/mempool"
 	"github.com/tellor-io/telliot/pkg/mining"
+	"github.com/tellor-io/telliot/pkg/notify"
+	"github.com/tellor-io/telliot/pkg/plugin"
+	"github.com/tellor-io/telliot/pkg/psr"
 	psrTellor "github.com/tellor-io/telliot/pkg/psr/tellor"
 	psrTellorAccess "github.com/tellor-io/telliot/pkg/psr/tellorAccess"
+	"github.com/tellor-io/telliot/pkg/registry"
+	"github.com/tellor-io/telliot/pkg/reputation"
 	"github.com/tellor-io/telliot/pkg/reward"
+	"github.com/tellor-io/telliot/pkg/submitter"
 	"github.com/tellor-io/telliot/pkg/submitter/tellor"
 	"github.com/tellor-io/telliot/pkg/submitter/tellorAccess"
+	"github.com/tellor-io/telliot/pkg/supervisor"
 	"github.com/tellor-io/telliot/pkg/tasker"
+	"github.com/tellor-io/telliot/pkg/tipper"
+	"github.com/tellor-io/telliot/pkg/tracing"
+	"github.com/tellor-io/telliot/pkg/tracker/balance"
+	"github.com/tellor-io/telliot/pkg/tracker/clock"
 	"github.com/tellor-io/telliot/pkg/tracker/dispute"
+	"github.com/tellor-io/telliot/pkg/tracker/disputeHistory"
 	"github.com/tellor-io/telliot/pkg/tracker/gasPrice"
 	"github.com/tellor-io/telliot/pkg/tracker/index"
+	"github.com/tellor-io/telliot/pkg/tracker/node"
 	"github.com/tellor-io/telliot/pkg/tracker/profit"
+	"github.com/tellor-io/telliot/pkg/tracker/race"
+	"github.com/tellor-io/telliot/pkg/tracker/slot"
+	"github.com/tellor-io/telliot/pkg/tracker/stake"
+	"github.com/tellor-io/telliot/pkg/tracker/vote"
 	"github.com/tellor-io/telliot/pkg/transactor"
+	"github.com/tellor-io/telliot/pkg/treasury"
+	"github.com/tellor-io/telliot/pkg/upgrade"
+	"github.com/tellor-io/telliot/pkg/watchdog"
 	"github.com/tellor-io/telliot/pkg/web"
 )
 
+// Max age of the last success before a component is reported as degraded.
+const (
+	// healthIndexMaxAge is multiplied by the index tracker interval.
+	healthIndexMaxAge  = 5
+	healthEventMaxAge  = time.Hour
+	healthSubmitMaxAge = 2 * time.Hour
+)
+
+// The PSR values are cached for the timestamps rounded to the resolution.
+const (
+	psrCacheResolution = 10 * time.Second
+	psrCacheTTL        = time.Minute
+)
+
 const VersionMessage = `
     The official Tellor cli tool %s (%s)
     -----------------------------------------
@@ -52,10 +100,14 @@ const VersionMessage = `
 `
 
 var CLI struct {
+	LogFormat logFormat `default:"logfmt" enum:"logfmt,json" help:"log output format (logfmt or json)"`
+
 	Transfer transferCmd `cmd:"" help:"Transfer tokens"`
 	Approve  approveCmd  `cmd:"" help:"Approve tokens"`
 	Accounts accountsCmd `cmd:"" help:"Show accounts"`
 	Balance  balanceCmd  `cmd:"" help:"Check the balance of an address"`
+	Status   snapshotCmd `cmd:"" help:"Show the status of the accounts and optionally of a running instance"`
+	Selftest selftestCmd `cmd:"" help:"Check the node, contracts, accounts, index sources, PSR and a simulated submission"`
 	Stake    struct {
 		Deposit  depositCmd  `cmd:"" help:"deposit a stake"`
 		Request  requestCmd  `cmd:"" help:"request to withdraw stake"`
@@ -63,12 +115,55 @@ var CLI struct {
 		Status   statusCmd   `cmd:"" help:"show stake status"`
 	} `cmd:"" help:"Perform one of the stake operations"`
 	Dispute struct {
-		New  newDisputeCmd `cmd:"" help:"start a new dispute"`
-		Vote voteCmd       `cmd:"" help:"vote on a open dispute"`
-		List listCmd       `cmd:"" help:"list open disputes"`
+		New      newDisputeCmd   `cmd:"" help:"start a new dispute"`
+		Vote     voteCmd         `cmd:"" help:"vote on a open dispute"`
+		List     listCmd         `cmd:"" help:"list open disputes"`
+		Evidence evidenceCmd     `cmd:"" help:"write the evidence for a disputed submission to a zip"`
+		Stats    disputeStatsCmd `cmd:"" help:"summarize the dispute history of a running instance per disputer, miner and request ID"`
 	} `cmd:"" help:"Perform commands related to disputes"`
+	Testnet struct {
+		Setup testnetSetupCmd `cmd:"" help:"get test TRB from the faucet, stake it and write a sandbox config"`
+	} `cmd:"" help:"Set up a testnet sandbox"`
+	Tx struct {
+		List   txListCmd   `cmd:"" help:"list the pending transactions of a running instance"`
+		Bump   txBumpCmd   `cmd:"" help:"replace a pending transaction with a higher gas price"`
+		Cancel txCancelCmd `cmd:"" help:"replace a pending transaction with an empty transfer"`
+	} `cmd:"" help:"Manage the pending transactions of a running instance"`
+	Submitter struct {
+		Pause  submitterPauseCmd  `cmd:"" help:"pause the submissions of a request ID or of all for a time window"`
+		Resume submitterResumeCmd `cmd:"" help:"remove the pauses of a request ID or the global ones"`
+		Pauses submitterPausesCmd `cmd:"" help:"list the current and scheduled pauses"`
+	} `cmd:"" help:"Pause the submissions of a running instance e.g. during an outage of the sources"`
+	Approval struct {
+		List    approvalListCmd    `cmd:"" help:"list the operations waiting for the approvers"`
+		Approve approvalApproveCmd `cmd:"" help:"sign an operation with an account key or add the signature of a hardware wallet"`
+		Reject  approvalRejectCmd  `cmd:"" help:"reject an operation so that it is never sent"`
+		Send    approvalSendCmd    `cmd:"" help:"sign and send an approved operation"`
+	} `cmd:"" help:"Approve the high-impact operations e.g. stake withdrawals, disputes and big transfers"`
+	Key struct {
+		Rotate   keyRotateCmd   `cmd:"" help:"stake a new key, replace the old key in the env file and request the withdrawal of its stake"`
+		Withdraw keyWithdrawCmd `cmd:"" help:"withdraw the unlocked stake of the retired keys and move it to an account"`
+	} `cmd:"" help:"Rotate the account keys"`
+	Attestation struct {
+		Verify attestationVerifyCmd `cmd:"" help:"verify the signatures of the PSR values attested by a running instance or saved to a file"`
+	} `cmd:"" help:"Verify the attested PSR values (experimental)"`
+	Audit struct {
+		Export auditExportCmd `cmd:"" help:"export the audit log of all state-changing operations to CSV or JSON"`
+	} `cmd:"" help:"Review the state-changing operations"`
+	Db struct {
+		Export dbExportCmd `cmd:"" help:"export series of the DB to OpenMetrics text or TSDB blocks"`
+		Import dbImportCmd `cmd:"" help:"import OpenMetrics text or TSDB blocks exported by another node to the DB"`
+	} `cmd:"" help:"Hand off the DB data between nodes"`
+	Grafana struct {
+		Provision grafanaProvisionCmd `cmd:"" help:"push the telliot dashboards to a Grafana instance"`
+	} `cmd:"" help:"Set up the Grafana dashboards"`
 	Dataserver dataserverCmd `cmd:"" help:"launch only a dataserver instance"`
 	Mine       mineCmd       `cmd:"" help:"Submit data to oracle contracts"`
+	Simulate   simulateCmd   `cmd:"" help:"Run the mining pipeline against a local fork of the chain"`
+	Backtest   backtestCmd   `cmd:"" help:"Compare aggregation strategies against the accepted on-chain values"`
+	Replay     replayCmd     `cmd:"" help:"Run the submission decisions of a replay log again to debug them or try another config"`
+	Export     exportCmd     `cmd:"" help:"Export the submit, dispute and reward events of a block range to CSV or Parquet"`
+	Gas        gasCmd        `cmd:"" help:"Show the average gas cost per contract function of a running instance"`
 	Version    VersionCmd    `cmd:"" help:"Show the CLI version information"`
 }
 
@@ -80,6 +175,13 @@ func (cmd *VersionCmd) Run() error {
 	return nil
 }
 
+type logFormat string
+
+// AfterApply sets the format before any of the commands creates its logger.
+func (self logFormat) AfterApply() error {
+	return logging.SetFormat(string(self))
+}
+
 type configPath string
 type tokenCmd struct {
 	Config  configPath `type:"existingfile" help:"path to config file"`
@@ -119,12 +221,17 @@ func (c *transferCmd) Run() error {
 		return err
 	}
 
-	contract, err := contracts.NewITellor(client)
+	contract, err := newTellorContract(client, cfg.Contracts)
 	if err != nil {
 		return errors.Wrap(err, "create tellor contract instance")
 	}
 
-	return Transfer(ctx, logger, client, contract, account, address.addr, amount.Int)
+	approvals, err := newApprovals(logger, cfg)
+	if err != nil {
+		return err
+	}
+	err = Transfer(ctx, logger, client, contract, account, address.addr, amount.Int, approvals)
+	return auditCommand(logger, cfg.Db, "transfer", address.addr.Hex(), err, "account", account.Address.Hex(), "amount", amount.Int.String())
 
 }
 
@@ -159,12 +266,13 @@ func (c *approveCmd) Run() error {
 		return err
 	}
 
-	contract, err := contracts.NewITellor(client)
+	contract, err := newTellorContract(client, cfg.Contracts)
 	if err != nil {
 		return errors.Wrap(err, "create tellor contract instance")
 	}
 
-	return Approve(ctx, logger, client, contract, account, address.addr, amount.Int)
+	err = Approve(ctx, logger, client, contract, account, address.addr, amount.Int)
+	return auditCommand(logger, cfg.Db, "approve", address.addr.Hex(), err, "account", account.Address.Hex(), "amount", amount.Int.String())
 }
 
 type accountsCmd struct {
@@ -211,7 +319,7 @@ func (b *balanceCmd) Run() error {
 		return errors.Wrap(err, "creating tellor variables")
 	}
 
-	contract, err := contracts.NewITellor(client)
+	contract, err := newTellorContract(client, cfg.Contracts)
 	if err != nil {
 		return errors.Wrap(err, "create tellor contract instance")
 	}
@@ -254,11 +362,16 @@ func (d depositCmd) Run() error {
 	if err != nil {
 		return err
 	}
-	contract, err := contracts.NewITellor(client)
+	contract, err := newTellorContract(client, cfg.Contracts)
 	if err != nil {
 		return errors.Wrap(err, "create tellor contract instance")
 	}
-	return Deposit(ctx, logger, client, contract, account)
+	staker, err := newStaker(ctx, client, contract)
+	if err != nil {
+		return err
+	}
+	err = Deposit(ctx, logger, client, contract, staker, account, cfg.Transactor.Confirmations[transactor.FunctionDepositStake])
+	return auditCommand(logger, cfg.Db, "stake_deposit", account.Address.Hex(), err)
 
 }
 
@@ -291,11 +404,20 @@ func (w withdrawCmd) Run() error {
 	if err != nil {
 		return err
 	}
-	contract, err := contracts.NewITellor(client)
+	contract, err := newTellorContract(client, cfg.Contracts)
 	if err != nil {
 		return errors.Wrap(err, "create tellor contract instance")
 	}
-	return WithdrawStake(ctx, logger, client, contract, account)
+	staker, err := newStaker(ctx, client, contract)
+	if err != nil {
+		return err
+	}
+	approvals, err := newApprovals(logger, cfg)
+	if err != nil {
+		return err
+	}
+	err = WithdrawStake(ctx, logger, client, staker, account, cfg.Transactor.Confirmations[transactor.FunctionWithdrawStake], approvals)
+	return auditCommand(logger, cfg.Db, "stake_withdraw", account.Address.Hex(), err)
 
 }
 
@@ -321,11 +443,20 @@ func (r requestCmd) Run() error {
 	if err != nil {
 		return err
 	}
-	contract, err := contracts.NewITellor(client)
+	contract, err := newTellorContract(client, cfg.Contracts)
 	if err != nil {
 		return errors.Wrap(err, "create tellor contract instance")
 	}
-	return RequestStakingWithdraw(ctx, logger, client, contract, account)
+	staker, err := newStaker(ctx, client, contract)
+	if err != nil {
+		return err
+	}
+	approvals, err := newApprovals(logger, cfg)
+	if err != nil {
+		return err
+	}
+	err = RequestStakingWithdraw(ctx, logger, client, staker, account, cfg.Transactor.Confirmations[transactor.FunctionRequestWithdraw], approvals)
+	return auditCommand(logger, cfg.Db, "stake_request_withdraw", account.Address.Hex(), err)
 }
 
 type statusCmd struct {
@@ -350,11 +481,52 @@ func (s statusCmd) Run() error {
 	if err != nil {
 		return err
 	}
-	contract, err := contracts.NewITellor(client)
+	contract, err := newTellorContract(client, cfg.Contracts)
 	if err != nil {
 		return errors.Wrap(err, "create tellor contract instance")
 	}
-	return ShowStatus(ctx, logger, client, contract, account)
+	staker, err := newStaker(ctx, client, contract)
+	if err != nil {
+		return err
+	}
+	return ShowStatus(ctx, logger, client, staker, account)
+}
+
+type snapshotCmd struct {
+	Config configPath `type:"existingfile" help:"path to config file"`
+	URL    string     `help:"address of a running instance to include the health of its components e.g. http://localhost:9090"`
+	JSON   bool       `name:"json" help:"print as JSON"`
+}
+
+func (s snapshotCmd) Run() error {
+	logger := logging.NewLogger()
+
+	cfg, err := config.ParseConfig(logger, string(s.Config))
+	if err != nil {
+		return errors.Wrap(err, "creating config")
+	}
+
+	ctx, cncl := context.WithTimeout(context.Background(), statusTimeout)
+	defer cncl()
+	client, accounts, err := createTellorVariables(ctx, logger, cfg.Ethereum)
+	if err != nil {
+		return errors.Wrap(err, "creating tellor variables")
+	}
+	contract, err := newTellorContract(client, cfg.Contracts)
+	if err != nil {
+		return errors.Wrap(err, "create tellor contract instance")
+	}
+
+	snapshot, err := GetSnapshot(ctx, client, contract, accounts, s.URL)
+	if err != nil {
+		return errors.Wrap(err, "getting the status")
+	}
+	if s.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(snapshot)
+	}
+	return PrintSnapshot(os.Stdout, snapshot)
 }
 
 type newDisputeCmd struct {
@@ -363,6 +535,7 @@ type newDisputeCmd struct {
 	timestamp  string     `arg:""  help:"the submitted timestamp to dispute"`
 	minerIndex string     `arg:""  help:"the miner index to dispute"`
 	Account    int        `arg:"" optional:""`
+	GasURL     string     `name:"gas-url" help:"address of a running instance to record the gas of the transaction in e.g. http://localhost:9090"`
 }
 
 func (n newDisputeCmd) Run() error {
@@ -398,18 +571,24 @@ func (n newDisputeCmd) Run() error {
 	if err != nil {
 		return err
 	}
-	contract, err := contracts.NewITellor(client)
+	contract, err := newTellorContract(client, cfg.Contracts)
 	if err != nil {
 		return errors.Wrap(err, "create tellor contract instance")
 	}
-	return Dispute(ctx, logger, client, contract, account, requestID.Int, timestamp.Int, minerIndex.Int)
+	approvals, err := newApprovals(logger, cfg)
+	if err != nil {
+		return err
+	}
+	err = Dispute(ctx, logger, client, contract, account, requestID.Int, timestamp.Int, minerIndex.Int, n.GasURL, cfg.Transactor.Budget, approvals, cfg.Transactor.Confirmations[transactor.FunctionBeginDispute])
+	return auditCommand(logger, cfg.Db, "dispute_new", requestID.Int.String(), err, "account", account.Address.Hex(), "timestamp", timestamp.Int.String(), "minerIndex", minerIndex.Int.String())
 }
 
 type voteCmd struct {
 	Config    configPath `type:"existingfile" help:"path to config file"`
-	disputeId string     `arg:""  help:"the dispute id"`
-	support   bool       `arg:""  help:"true or false"`
+	DisputeID string     `arg:""  help:"the dispute id"`
+	Support   string     `arg:""  help:"true or false"`
 	Account   int        `arg:"" optional:""`
+	GasURL    string     `name:"gas-url" help:"address of a running instance to record the gas of the transaction in e.g. http://localhost:9090"`
 }
 
 func (v voteCmd) Run() error {
@@ -427,19 +606,24 @@ func (v voteCmd) Run() error {
 	}
 
 	disputeID := EthereumInt{}
-	err = disputeID.Set(v.disputeId)
+	err = disputeID.Set(v.DisputeID)
 	if err != nil {
 		return errors.Wrap(err, "parsing argument")
 	}
+	support, err := strconv.ParseBool(v.Support)
+	if err != nil {
+		return errors.Wrap(err, "parsing support argument")
+	}
 	account, err := getAccountFor(accounts, v.Account)
 	if err != nil {
 		return err
 	}
-	contract, err := contracts.NewITellor(client)
+	contract, err := newTellorContract(client, cfg.Contracts)
 	if err != nil {
 		return errors.Wrap(err, "create tellor contract instance")
 	}
-	return Vote(ctx, logger, client, contract, account, disputeID.Int, v.support)
+	err = Vote(ctx, logger, client, contract, account, disputeID.Int, support, v.GasURL, cfg.Transactor.Budget, cfg.Transactor.Confirmations[transactor.FunctionVote])
+	return auditCommand(logger, cfg.Db, "vote", disputeID.Int.String(), err, "account", account.Address.Hex(), "support", strconv.FormatBool(support))
 }
 
 type listCmd struct {
@@ -472,6 +656,11 @@ func (s listCmd) Run() error {
 		if err != nil {
 			return errors.Wrap(err, "opening remote tsdb DB")
 		}
+	} else if cfg.Db.Lightweight.Enabled {
+		querable, err = db.OpenReadOnly(logger, cfg.Db)
+		if err != nil {
+			return errors.Wrap(err, "opening lightweight DB")
+		}
 	} else {
 		if err := os.MkdirAll(cfg.Db.Path, 0777); err != nil {
 			return errors.Wrap(err, "creating tsdb DB folder")
@@ -488,9 +677,13 @@ func (s listCmd) Run() error {
 	if err != nil {
 		return errors.Wrap(err, "creating aggregator")
 	}
+	registry, err := registry.New(logger, cfg.Registry)
+	if err != nil {
+		return errors.Wrap(err, "creating request ID registry")
+	}
 
-	psr := psrTellor.New(logger, cfg.PsrTellor, aggregator)
-	contract, err := contracts.NewITellor(client)
+	psr := psrTellor.New(logger, cfg.PsrTellor, aggregator, registry)
+	contract, err := newTellorContract(client, cfg.Contracts)
 	if err != nil {
 		return errors.Wrap(err, "create tellor contract instance")
 	}
@@ -519,24 +712,24 @@ func (self dataserverCmd) Run() error {
 		// Handle interupts.
 		g.Add(run.SignalHandler(context.Background(), syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM))
 
-		// Open the TSDB database.
-		tsdbOptions := tsdb.DefaultOptions()
-		// 48h are enough as the aggregator needs data only 24 hours in the past.
-		tsdbOptions.RetentionDuration = int64(2 * 24 * time.Hour / time.Millisecond)
-		if err := os.MkdirAll(cfg.Db.Path, 0777); err != nil {
-			return errors.Wrap(err, "creating tsdb DB folder")
-		}
-		tsDB, err := tsdb.Open(cfg.Db.Path, nil, nil, tsdbOptions)
+		// All components are started through the supervisor
+		// which restarts them when they crash and keeps track of their health.
+		supervisor, err := supervisor.New(logger, cfg.Supervisor)
 		if err != nil {
-			return errors.Wrap(err, "creating tsdb DB")
+			return errors.Wrap(err, "creating supervisor")
 		}
-		level.Info(logger).Log("msg", "opened local db", "path", cfg.Db.Path)
 
+		// Open the TSDB database.
+		tsDB, err := openLocalDB(ctx, logger, cfg.Db, &g, supervisor)
+		if err != nil {
+			return err
+		}
 		defer func() {
 			if err := tsDB.Close(); err != nil {
 				level.Error(logger).Log("msg", "closing the tsdb", "err", err)
 			}
 		}()
+		level.Info(logger).Log("msg", "opened local db", "path", cfg.Db.Path, "lightweight", cfg.Db.Lightweight.Enabled)
 
 		// Index tracker.
 
@@ -546,31 +739,65 @@ func (self dataserverCmd) Run() error {
 		if err != nil {
 			return errors.Wrap(err, "create rpc client instance")
 		}
+		faults, err := chaos.New(logger, cfg.Chaos)
+		if err != nil {
+			return errors.Wrap(err, "creating fault injector")
+		}
+		if faults != nil {
+			client = chaos.NewClient(faults, client)
+		}
+
+		plugins, err := plugin.Load(logger, cfg.Plugins)
+		if err != nil {
+			return errors.Wrap(err, "loading plugins")
+		}
+		defer plugins.Close()
+
+		watchdog, err := watchdog.New(logger, cfg.Watchdog)
+		if err != nil {
+			return errors.Wrap(err, "creating watchdog")
+		}
 
-		index, err := index.New(logger, ctx, cfg.IndexTracker, tsDB, client)
+		// Clock tracker.
+		// The values are stored with the chain time when the clock correction is enabled.
+		clockTracker, err := clock.New(logger, ctx, cfg.ClockTracker, client, watchdog)
+		if err != nil {
+			return errors.Wrap(err, "creating clock tracker")
+		}
+		g.Add(supervisor.Actor("clockTracker", false, clockTracker))
+
+		index, err := index.New(logger, ctx, cfg.IndexTracker, tsDB, client, plugins, clockTracker, faults, watchdog)
 		if err != nil {
 			return errors.Wrap(err, "creating index tracker")
 		}
 
-		g.Add(func() error {
-			err := index.Run()
-			level.Info(logger).Log("msg", "index shutdown complete")
-			return err
-		}, func(error) {
-			index.Stop()
-		})
+		g.Add(supervisor.Actor("indexTracker", true, index))
 
 		// Aggregator.
 		aggregator, err := aggregator.New(logger, ctx, cfg.Aggregator, tsDB)
 		if err != nil {
 			return errors.Wrap(err, "creating aggregator")
 		}
+		registry, err := registry.New(logger, cfg.Registry)
+		if err != nil {
+			return errors.Wrap(err, "creating request ID registry")
+		}
 
-		contractTellor, err := contracts.NewITellor(client)
+		psr := psrTellor.New(logger, cfg.PsrTellor, aggregator, registry)
+		if err := psr.SetPlugins(plugins); err != nil {
+			return errors.Wrap(err, "creating the plugin aggregations")
+		}
+
+		contractTellor, err := newTellorContract(client, cfg.Contracts)
 		if err != nil {
 			return errors.Wrap(err, "create tellor contract instance")
 		}
 
+		verifier, err := createVerifier(logger, cfg.Ethereum)
+		if err != nil {
+			return errors.Wrap(err, "creating event verifier")
+		}
+
 		disputeTracker, err := dispute.New(
 			logger,
 			ctx,
@@ -578,18 +805,15 @@ func (self dataserverCmd) Run() error {
 			tsDB,
 			client,
 			contractTellor,
-			psrTellor.New(logger, cfg.PsrTellor, aggregator),
+			psr,
+			registry,
+			verifier,
+			clockTracker,
 		)
 		if err != nil {
 			return errors.Wrap(err, "creating profit tracker")
 		}
-		g.Add(func() error {
-			disputeTracker.Start()
-			level.Info(logger).Log("msg", "dispute tracker shutdown complete")
-			return nil
-		}, func(error) {
-			disputeTracker.Stop()
-		})
+		g.Add(supervisor.Actor("disputeTracker", false, disputeTracker))
 
 		// Web/Api server.
 		{
@@ -597,13 +821,12 @@ func (self dataserverCmd) Run() error {
 			if err != nil {
 				return errors.Wrap(err, "create web server")
 			}
-			g.Add(func() error {
-				err := srv.Start()
-				level.Info(logger).Log("msg", "web server shutdown complete")
-				return err
-			}, func(error) {
-				srv.Stop()
-			})
+			srv.AddHealth(supervisor)
+			srv.AddHealth(ethClientHealth(client))
+			srv.AddHealth(clockTracker)
+			srv.AddHealth(health.Freshness("indexTracker:lastPoll", true, index.LastPoll, healthIndexMaxAge*cfg.IndexTracker.Interval.Duration))
+			srv.AddHealth(health.Freshness("disputeTracker:lastEvent", false, disputeTracker.LastEvent, healthEventMaxAge))
+			g.Add(supervisor.Actor("web", false, srv))
 		}
 	}
 
@@ -616,8 +839,38 @@ func (self dataserverCmd) Run() error {
 	return nil
 }
 
+// The roles allow running the components in separate instances.
+const (
+	roleAll        = "all"
+	roleTracker    = "tracker"
+	roleAggregator = "aggregator"
+	roleSubmitter  = "submitter"
+	roleMiner      = "miner"
+	// roleMonitor runs only the trackers and the API without loading any keys
+	// to watch the health and the data quality of the oracle.
+	roleMonitor = "monitor"
+)
+
 type mineCmd struct {
 	Config configPath `type:"existingfile" help:"path to config file"`
+	Role   string     `enum:"all,tracker,aggregator,submitter,miner,monitor" default:"all" help:"run only the components of this role(all,tracker,aggregator,submitter,miner,monitor)"`
+	// chainTime makes the components use the chain time instead of the local time.
+	// Set by the simulation which moves the chain time forward.
+	chainTime bool
+}
+
+// runs reports whether the components of any of the roles should run.
+// The monitor role runs the components of the tracker role.
+func (self mineCmd) runs(roles ...string) bool {
+	for _, role := range roles {
+		if self.Role == roleAll || self.Role == role {
+			return true
+		}
+		if self.Role == roleMonitor && role == roleTracker {
+			return true
+		}
+	}
+	return false
 }
 
 func (self mineCmd) Run() error {
@@ -627,13 +880,115 @@ func (self mineCmd) Run() error {
 	if err != nil {
 		return errors.Wrap(err, "creating config")
 	}
+	if self.chainTime {
+		// Any offset from the block timestamps is corrected.
+		cfg.ClockTracker.Correct = true
+		cfg.ClockTracker.MaxSkew = format.Duration{}
+	}
 
 	// Defining a global context for starting and stopping of components.
 	ctx := context.Background()
 
-	client, accounts, err := createTellorVariables(ctx, logger, cfg.Ethereum)
+	audit, err := openAudit(cfg.Db)
 	if err != nil {
-		return errors.Wrap(err, "creating tellor variables")
+		return errors.Wrap(err, "opening audit log")
+	}
+	defer func() {
+		if err := audit.Close(); err != nil {
+			level.Error(logger).Log("msg", "closing the audit log", "err", err)
+		}
+	}()
+	if err := auditConfig(audit, string(self.Config), self.Role); err != nil {
+		return errors.Wrap(err, "recording the loaded config")
+	}
+
+	var (
+		client   contracts.ETHClient
+		accounts []*ethereum.Account
+	)
+	if self.Role == roleMonitor {
+		// No keys are loaded so the signer can't sign for any account
+		// and no transactor can be created.
+		client, err = createClient(ctx, logger, cfg.Ethereum)
+		if err != nil {
+			return errors.Wrap(err, "creating client")
+		}
+		if os.Getenv(ethereum.PrivateKeysEnvName) != "" {
+			level.Warn(logger).Log("msg", "the monitor role ignores the private keys", "env", ethereum.PrivateKeysEnvName)
+		}
+		if cfg.Treasury.Enabled {
+			level.Warn(logger).Log("msg", "the monitor role doesn't run the treasury", "env", treasury.KeyEnvName)
+		}
+		accounts, err = ethereum.GetObserverAccounts()
+		if err != nil {
+			return errors.Wrap(err, "creating observer accounts")
+		}
+	} else {
+		client, accounts, err = createTellorVariables(ctx, logger, cfg.Ethereum)
+		if err != nil {
+			return errors.Wrap(err, "creating tellor variables")
+		}
+	}
+	faults, err := chaos.New(logger, cfg.Chaos)
+	if err != nil {
+		return errors.Wrap(err, "creating fault injector")
+	}
+	if faults != nil {
+		client = chaos.NewClient(faults, client)
+	}
+	// Only the transactors sign through the signer which takes the keys out of the accounts
+	// so that the trackers and the API never have access to them.
+	// The read-only accounts are only tracked.
+	var signers []*ethereum.Account
+	for _, account := range accounts {
+		if !account.ReadOnly() {
+			signers = append(signers, account)
+		}
+	}
+	signer := ethereum.NewKeySigner(logger, accounts)
+	if len(signers) == 0 && self.Role != roleMonitor {
+		level.Info(logger).Log("msg", "no private keys, running with read-only accounts without sending transactions")
+	}
+	// Shared by all components so that they share the rate limit.
+	logFetcher, err := ethereum.NewLogFetcher(logger, client, cfg.Ethereum.Logs)
+	if err != nil {
+		return errors.Wrap(err, "creating log fetcher")
+	}
+	verifier, err := createVerifier(logger, cfg.Ethereum)
+	if err != nil {
+		return errors.Wrap(err, "creating event verifier")
+	}
+
+	if cfg.Tracing.Enabled {
+		tracing, err := tracing.New(ctx, logger, cfg.Tracing)
+		if err != nil {
+			return errors.Wrap(err, "creating tracing")
+		}
+		defer func() {
+			ctx, cncl := context.WithTimeout(ctx, 5*time.Second)
+			defer cncl()
+			if err := tracing.Shutdown(ctx); err != nil {
+				level.Error(logger).Log("msg", "flushing the traces", "err", err)
+			}
+		}()
+	}
+
+	plugins, err := plugin.Load(logger, cfg.Plugins)
+	if err != nil {
+		return errors.Wrap(err, "loading plugins")
+	}
+	defer plugins.Close()
+
+	// After an upgrade the old process is stopped only now that the client is connected
+	// so that the submissions pause only while the DB and the journal change hands.
+	upgrader, err := upgrade.New(logger, ctx, cfg.Upgrade)
+	if err != nil {
+		return errors.Wrap(err, "creating upgrader")
+	}
+	if upgrader != nil {
+		if err := upgrader.Handover(); err != nil {
+			return errors.Wrap(err, "taking over from the old process")
+		}
 	}
 
 	// We define our run groups here.
@@ -643,22 +998,38 @@ func (self mineCmd) Run() error {
 		// Handle interupts.
 		g.Add(run.SignalHandler(context.Background(), syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM))
 
+		// Handle upgrades.
+		if upgrader != nil {
+			g.Add(upgrader.Start, func(error) {
+				upgrader.Stop()
+			})
+		}
+
+		// All components are started through the supervisor
+		// which restarts them when they crash and keeps track of their health.
+		supervisor, err := supervisor.New(logger, cfg.Supervisor)
+		if err != nil {
+			return errors.Wrap(err, "creating supervisor")
+		}
+
 		// Open a local or remote instance of the TSDB database.
+		// The submitter and miner roles don't use it.
 		var tsDB storage.SampleAndChunkQueryable
-		if cfg.Db.RemoteHost != "" {
+		if (self.Role == roleTracker || self.Role == roleMonitor) && cfg.Db.RemoteHost != "" {
+			return errors.Errorf("the %v role needs a local db", self.Role)
+		}
+		switch {
+		case !self.runs(roleTracker, roleAggregator):
+		case cfg.Db.RemoteHost != "":
 			tsDB, err = remoteDB(cfg.Db)
 			if err != nil {
 				return errors.Wrap(err, "opening remote tsdb DB")
 			}
 			level.Info(logger).Log("msg", "connected to remote db", "host", cfg.Db.RemoteHost, "port", cfg.Db.RemotePort)
-		} else {
-			// Open the TSDB database.
-			tsdbOptions := tsdb.DefaultOptions()
-			// 2 days are enough as the aggregator needs data only 24 hours in the past.
-			tsdbOptions.RetentionDuration = int64(2 * 24 * time.Hour)
-			_tsDB, err := tsdb.Open(cfg.Db.Path, nil, nil, tsdbOptions)
+		default:
+			_tsDB, err := openLocalDB(ctx, logger, cfg.Db, &g, supervisor)
 			if err != nil {
-				return errors.Wrap(err, "opening local tsdb DB")
+				return err
 			}
 			defer func() {
 				if err := _tsDB.Close(); err != nil {
@@ -666,70 +1037,218 @@ func (self mineCmd) Run() error {
 				}
 			}()
 			tsDB = _tsDB
-			level.Info(logger).Log("msg", "opened local db", "path", cfg.Db.Path)
+			level.Info(logger).Log("msg", "opened local db", "path", cfg.Db.Path, "lightweight", cfg.Db.Lightweight.Enabled)
 		}
 
 		// Web/Api server.
-		{
-			srv, err := web.New(logger, ctx, tsDB, cfg.Web)
+		srv, err := web.New(logger, ctx, tsDB, cfg.Web)
+		if err != nil {
+			return errors.Wrap(err, "create web server")
+		}
+		srv.AddHealth(supervisor)
+		srv.AddHealth(ethClientHealth(client))
+		if audit != nil {
+			srv.SetAudit(audit)
+		}
+		// The listener is handed over to the new process on an upgrade.
+		if upgrader != nil {
+			listener, err := upgrader.Listen(srv.Addr())
 			if err != nil {
-				return errors.Wrap(err, "create web server")
+				return errors.Wrap(err, "creating web listener")
+			}
+			srv.SetListener(listener)
+		}
+		dashboardHandler := dashboards.NewHandler(logger)
+		srv.Handle("/api/v1/dashboards", http.HandlerFunc(dashboardHandler.ServeList), opDashboards)
+		srv.Handle("/api/v1/dashboards/get", http.HandlerFunc(dashboardHandler.ServeDashboard), opDashboard)
+		g.Add(supervisor.Actor("web", false, srv))
+
+		// The gas of the sent transactions is only recorded in a local db.
+		var gasRecorder *transactor.GasRecorder
+		if db, ok := tsDB.(transactor.GasDB); ok {
+			gasRecorder = transactor.NewGasRecorder(logger, db)
+			srv.Handle("/api/v1/transactor/gas", http.HandlerFunc(gasRecorder.ServeSummary), opGasSummary)
+			if srv.AuthEnabled() {
+				srv.HandlePost("/api/v1/transactor/gas", http.HandlerFunc(gasRecorder.ServeRecord), opGasRecord)
 			}
-			g.Add(func() error {
-				err := srv.Start()
-				level.Info(logger).Log("msg", "web server shutdown complete")
-				return err
-			}, func(error) {
-				srv.Stop()
-			})
 		}
 
-		// Aggregator.
-		aggregator, err := aggregator.New(logger, ctx, cfg.Aggregator, tsDB)
+		// Shared by all transactors to bump or cancel their stuck transactions.
+		pool := transactor.NewPool(logger, audit)
+		srv.Handle("/api/v1/transactor/pending", http.HandlerFunc(pool.ServeList), opPending)
+		// The replacements are signed with the account keys so aren't served without the auth.
+		if srv.AuthEnabled() {
+			srv.HandlePost("/api/v1/transactor/pending/bump", http.HandlerFunc(pool.ServeBump), opBump)
+			srv.HandlePost("/api/v1/transactor/pending/cancel", http.HandlerFunc(pool.ServeCancel), opCancel)
+		} else {
+			level.Warn(logger).Log("msg", "bumping and canceling the pending transactions through the API is disabled because the API has no auth")
+		}
+
+		reg, err := registry.New(logger, cfg.Registry)
 		if err != nil {
-			return errors.Wrap(err, "creating aggregator")
+			return errors.Wrap(err, "creating request ID registry")
+		}
+
+		// Aggregator.
+		// The submitter role gets the aggregated values from an instance running in the aggregator role.
+		var (
+			aggr               aggregator.IAggregator
+			psrTellorCache     *psr.Cache
+			newPsrTellor       func(log.Logger) psr.Getter
+			newPsrTellorAccess func(log.Logger) psr.Getter
+			publisher          *evidence.Publisher
+			// Without the local DB the guard can't check the sources of a fast move.
+			guardSources submitter.Sources
+		)
+		if tsDB != nil {
+			_aggr, err := aggregator.New(logger, ctx, cfg.Aggregator, tsDB)
+			if err != nil {
+				return errors.Wrap(err, "creating aggregator")
+			}
+			aggr = _aggr
+			_psrTellor := psrTellor.New(logger, cfg.PsrTellor, _aggr, reg)
+			if err := _psrTellor.SetPlugins(plugins); err != nil {
+				return errors.Wrap(err, "creating the plugin aggregations")
+			}
+			// The values are signed before the cache so that every evaluated value is signed once.
+			var psrTellorGetter psr.Getter = _psrTellor
+			methodology, err := attestation.Methodology(cfg.PsrTellor)
+			if err != nil {
+				return errors.Wrap(err, "creating methodology digest")
+			}
+			attestor, err := attestation.New(logger, ctx, cfg.Attestation, _psrTellor, _aggr.Samples, methodology)
+			if err != nil {
+				return errors.Wrap(err, "creating attestor")
+			}
+			if attestor != nil {
+				defer func() {
+					if err := attestor.Close(); err != nil {
+						level.Error(logger).Log("msg", "closing the attestor", "err", err)
+					}
+				}()
+				psrTellorGetter = attestor
+				srv.Handle("/api/v1/attestations", attestor, opAttestations)
+			}
+			// A separate PSR as the publisher collects the aggregations with its own observer.
+			publisherPsr := psrTellor.New(logger, cfg.PsrTellor, _aggr, reg)
+			if err := publisherPsr.SetPlugins(plugins); err != nil {
+				return errors.Wrap(err, "creating the plugin aggregations")
+			}
+			publisher, err = evidence.NewPublisher(logger, ctx, cfg.Evidence, publisherPsr, _aggr.Samples, cfg.PsrTellor)
+			if err != nil {
+				return errors.Wrap(err, "creating evidence publisher")
+			}
+			if publisher != nil {
+				defer publisher.Close()
+			}
+			if cfg.SubmitterTellor.Guard.Volatility.Enabled {
+				// A separate PSR as the sources are counted with its own observer.
+				sourcesPsr := psrTellor.New(logger, cfg.PsrTellor, _aggr, reg)
+				if err := sourcesPsr.SetPlugins(plugins); err != nil {
+					return errors.Wrap(err, "creating the plugin aggregations")
+				}
+				guardSources = psr.NewSources(sourcesPsr, _aggr.Samples)
+			}
+			// Shared by the dispute tracker and the submitters so that the same value is evaluated once.
+			psrTellorCache = psr.NewCache(psrTellorGetter, psrCacheResolution, psrCacheTTL)
+			newPsrTellor = func(log.Logger) psr.Getter { return psrTellorCache }
+			newPsrTellorAccess = func(logger log.Logger) psr.Getter {
+				return psrTellorAccess.New(logger, cfg.PsrTellorAccess, _aggr, reg)
+			}
+
+			if self.Role == roleAggregator || self.Role == roleMonitor {
+				srv.Handle("/api/v1/aggregator/twa", http.HandlerFunc(_aggr.ServeTimeWeightedAvg), opTimeWeightedAvg)
+				srv.Handle("/api/v1/psr/tellor", psr.NewHandler(logger, newPsrTellor(logger)), opPsrTellor)
+				srv.Handle("/api/v1/psr/tellorAccess", psr.NewHandler(logger, newPsrTellorAccess(logger)), opPsrTellorAccess)
+			}
+		} else if self.runs(roleSubmitter) {
+			if cfg.Aggregator.RemoteURL == "" {
+				return errors.New("the submitter role needs the url of an aggregator instance")
+			}
+			if cfg.Evidence.Enabled {
+				return errors.New("the evidence publication needs the local DB of the aggregator role")
+			}
+			aggr = aggregator.NewRemote(cfg.Aggregator.RemoteURL, cfg.Db.RemoteTimeout.Duration)
+			newPsrTellor = func(log.Logger) psr.Getter {
+				return psr.NewRemote(cfg.Aggregator.RemoteURL+"/api/v1/psr/tellor", cfg.Db.RemoteTimeout.Duration)
+			}
+			newPsrTellorAccess = func(log.Logger) psr.Getter {
+				return psr.NewRemote(cfg.Aggregator.RemoteURL+"/api/v1/psr/tellorAccess", cfg.Db.RemoteTimeout.Duration)
+			}
+			level.Info(logger).Log("msg", "using remote aggregator", "url", cfg.Aggregator.RemoteURL)
 		}
 
-		contractTellor, err := contracts.NewITellor(client)
+		contractTellor, err := newTellorContract(client, cfg.Contracts)
 		if err != nil {
 			return errors.Wrap(err, "create tellor contract instance")
 		}
 
+		// Watchdog.
+		// Logs and counts the tracker loops and the external calls running past their deadlines.
+		watchdog, err := watchdog.New(logger, cfg.Watchdog)
+		if err != nil {
+			return errors.Wrap(err, "creating watchdog")
+		}
+
+		// Clock tracker.
+		// Shared by all components that compare the local time with the chain time.
+		clockTracker, err := clock.New(logger, ctx, cfg.ClockTracker, client, watchdog)
+		if err != nil {
+			return errors.Wrap(err, "creating clock tracker")
+		}
+		g.Add(supervisor.Actor("clockTracker", false, clockTracker))
+		srv.AddHealth(clockTracker)
+
+		// Current on-chain values.
+		// Served in every role so that any node can be used as a data gateway.
+		if cfg.Gateway.Enabled {
+			caps, err := contracts.NewCapabilities(ctx, client, contractTellor.Address)
+			if err != nil {
+				return errors.Wrap(err, "getting the contract capabilities")
+			}
+			reader, err := caps.Reader()
+			if err != nil {
+				return errors.Wrap(err, "creating gateway")
+			}
+			// Not all contract versions have the reporters.
+			reporters, _ := caps.Reporters()
+			gw, err := gateway.New(logger, cfg.Gateway, reader, reporters)
+			if err != nil {
+				return errors.Wrap(err, "creating gateway")
+			}
+			srv.Handle("/api/v1/oracle/tellor", gw, opOracleTellor)
+		}
+
 		// Index tracker.
 		// Run only when not using remote DB as it needs to write to the local db.
-		if cfg.Db.RemoteHost == "" {
-			_tsDB, ok := tsDB.(*tsdb.DB)
+		var indexTracker *index.IndexTracker
+		if self.runs(roleTracker) && cfg.Db.RemoteHost == "" {
+			_tsDB, ok := tsDB.(*db.DB)
 			if !ok {
 				return errors.New("tsdb is not a writable DB instance")
 			}
 
 			// Index Tracker.
-			index, err := index.New(logger, ctx, cfg.IndexTracker, _tsDB, client)
+			indexTracker, err = index.New(logger, ctx, cfg.IndexTracker, _tsDB, client, plugins, clockTracker, faults, watchdog)
 			if err != nil {
 				return errors.Wrapf(err, "creating index tracker")
 			}
 
-			g.Add(func() error {
-				err := index.Run()
-				level.Info(logger).Log("msg", "index shutdown complete")
-				return err
-			}, func(error) {
-				index.Stop()
-			})
+			g.Add(supervisor.Actor("indexTracker", true, indexTracker))
+			srv.AddHealth(health.Freshness("indexTracker:lastPoll", true, indexTracker.LastPoll, healthIndexMaxAge*cfg.IndexTracker.Interval.Duration))
+			if psrTellorCache != nil {
+				srv.AddHealth(psr.NewReadiness("psrTellor", psrTellorCache, cfg.IndexTracker.Warmup.RequestIDs, clockTracker.Now, indexTracker.WarmedUp()))
+			}
 		}
 
 		// Dispute tracker.
-		{
+		if self.runs(roleTracker) {
 			// When running with a remote db need to create a new instance of a local db.
 			// Otherwise use the already opened DB.
 			if cfg.Db.RemoteHost != "" {
-				// Open the TSDB database.
-				tsdbOptions := tsdb.DefaultOptions()
-				// 2 days are enough as the aggregator needs data only 24 hours in the past.
-				tsdbOptions.RetentionDuration = int64(2 * 24 * time.Hour)
-				_tsDB, err := tsdb.Open(cfg.Db.Path, nil, nil, tsdbOptions)
+				_tsDB, err := openLocalDB(ctx, logger, cfg.Db, &g, supervisor)
 				if err != nil {
-					return errors.Wrap(err, "opening local tsdb DB")
+					return err
 				}
 				defer func() {
 					if err := _tsDB.Close(); err != nil {
@@ -740,7 +1259,7 @@ func (self mineCmd) Run() error {
 				level.Info(logger).Log("msg", "opened local db for recording disputer tracker values", "path", cfg.Db.Path)
 			}
 
-			_tsDB, ok := tsDB.(*tsdb.DB)
+			_tsDB, ok := tsDB.(*db.DB)
 			if !ok {
 				return errors.New("tsdb is not a writable DB instance")
 			}
@@ -751,141 +1270,586 @@ func (self mineCmd) Run() error {
 				_tsDB,
 				client,
 				contractTellor,
-				psrTellor.New(logger, cfg.PsrTellor, aggregator),
+				psrTellorCache,
+				reg,
+				verifier,
+				clockTracker,
 			)
 			if err != nil {
 				return errors.Wrap(err, "creating profit tracker")
 			}
-			g.Add(func() error {
-				disputeTracker.Start()
-				level.Info(logger).Log("msg", "dispute tracker shutdown complete")
-				return nil
-			}, func(error) {
-				disputeTracker.Stop()
-			})
+			g.Add(supervisor.Actor("disputeTracker", false, disputeTracker))
+			srv.AddHealth(health.Freshness("disputeTracker:lastEvent", false, disputeTracker.LastEvent, healthEventMaxAge))
 		}
 
-		gasPriceTracker := gasPrice.New(logger, client)
-
-		if cfg.SubmitterTellor.Enabled {
-			// Profit tracker.
+		// The profits of the observed accounts and the races against the competitors
+		// without sending any transactions.
+		if self.Role == roleMonitor {
 			var accountAddrs []common.Address
 			for _, acc := range accounts {
 				accountAddrs = append(accountAddrs, acc.Address)
 			}
-			profitTracker, err := profit.NewProfitTracker(logger, ctx, cfg.ProfitTracker, client, contractTellor, accountAddrs)
+			monitorDB, ok := tsDB.(*db.DB)
+			if !ok {
+				return errors.New("tsdb is not a writable DB instance")
+			}
+			if len(accountAddrs) > 0 {
+				profitTracker, err := profit.NewProfitTracker(logger, ctx, cfg.ProfitTracker, monitorDB, client, logFetcher, contractTellor, accountAddrs)
+				if err != nil {
+					return errors.Wrap(err, "creating profit tracker")
+				}
+				g.Add(supervisor.Actor("profitTracker", false, profitTracker))
+			}
+
+			var taskerUpgrades, slotUpgrades <-chan contracts.Addresses
+			if cfg.Contracts.Enabled {
+				discovery, err := contracts.NewDiscovery(logger, ctx, cfg.Contracts, client, contractTellor.Address)
+				if err != nil {
+					return errors.Wrap(err, "creating contract discovery")
+				}
+				taskerUpgrades = discovery.Subscribe()
+				slotUpgrades = discovery.Subscribe()
+				g.Add(supervisor.Actor("contractDiscovery", false, discovery))
+			}
+
+			raceTracker, err := race.New(logger, cfg.RaceTracker, client, monitorDB, accountAddrs)
 			if err != nil {
-				return errors.Wrap(err, "creating profit tracker")
+				return errors.Wrap(err, "creating race tracker")
 			}
-			g.Add(func() error {
-				err := profitTracker.Start()
-				level.Info(logger).Log("msg", "profit shutdown complete")
-				return err
-			}, func(error) {
-				profitTracker.Stop()
-			})
+			srv.Handle("/api/v1/races", raceTracker, opRaces)
 
-			// Event tasker.
-			tasker, taskerChs, err := tasker.New(ctx, logger, cfg.Tasker, client, contractTellor, accounts)
+			// Without accounts the tasker only reports the new challenges to the race tracker.
+			tasker, _, err := tasker.New(ctx, logger, cfg.Tasker, client, contractTellor, nil, taskerUpgrades, raceTracker, nil)
 			if err != nil {
 				return errors.Wrap(err, "creating tasker")
 			}
-			g.Add(func() error {
-				err := tasker.Start()
-				level.Info(logger).Log("msg", "tasker shutdown complete")
-				return err
-			}, func(error) {
-				tasker.Stop()
-			})
+			g.Add(supervisor.Actor("tasker", true, tasker))
+			srv.AddHealth(health.Freshness("tasker:lastEvent", false, tasker.LastEvent, healthEventMaxAge))
 
-			// Create a submitter for each account.
-			for _, account := range accounts {
-				loggerWithAddr := log.With(logger, "addr", account.Address.String()[:6])
+			slotTracker, err := slot.New(logger, ctx, cfg.SlotTracker, client, contractTellor, slotUpgrades, raceTracker)
+			if err != nil {
+				return errors.Wrap(err, "creating slot tracker")
+			}
+			g.Add(supervisor.Actor("slotTracker", false, slotTracker))
+		}
 
-				transactor, err := transactor.New(loggerWithAddr, cfg.Transactor, gasPriceTracker, client, account)
-				if err != nil {
-					return errors.Wrap(err, "creating transactor")
+		// Everything that sends transactions.
+		if self.runs(roleSubmitter) {
+			// Submissions journal.
+			journal, err := db.OpenJournal(cfg.Db.JournalPath)
+			if err != nil {
+				return errors.Wrap(err, "opening submissions journal")
+			}
+			defer func() {
+				if err := journal.Close(); err != nil {
+					level.Error(logger).Log("msg", "closing the submissions journal", "err", err)
 				}
+			}()
+			level.Info(logger).Log("msg", "opened submissions journal", "path", cfg.Db.JournalPath)
+			srv.Handle("/api/v1/submissions", journal, opSubmissions)
 
-				psr := psrTellor.New(loggerWithAddr, cfg.PsrTellor, aggregator)
-
-				// Get a channel on which it listens for new data to submit.
-				submitter, submitterCh, err := tellor.New(
-					ctx,
-					loggerWithAddr,
-					cfg.SubmitterTellor,
-					client,
-					contractTellor,
-					account,
-					reward.New(loggerWithAddr, aggregator, contractTellor),
-					transactor,
-					gasPriceTracker,
-					psr,
-				)
+			gasPriceTracker := gasPrice.New(logger, client)
+
+			// Shared by all transactors so that the caps are across all accounts and operations.
+			var budget *transactor.Budget
+			if cfg.Transactor.Budget.Enabled {
+				budget, err = transactor.NewBudget(logger, cfg.Transactor.Budget)
 				if err != nil {
-					return errors.Wrap(err, "creating tellor submitter")
+					return errors.Wrap(err, "creating transaction budget")
 				}
-				g.Add(func() error {
-					err := submitter.Start()
-					level.Info(loggerWithAddr).Log("msg", "tellor submitter shutdown complete")
-					return err
-				}, func(error) {
-					submitter.Stop()
-				})
+			}
 
-				// Will be used to cancel pending submissions.
-				tasker.AddSubmitCanceler(submitter)
+			// The transactions of each account are serialized between the instances that share the same accounts
+			// so that a leader that steps down never shares a nonce with the new leader.
+			var coordinator *coordination.Coordinator
+			if cfg.Coordination.Enabled {
+				coordinator, err = coordination.New(logger, cfg.Coordination)
+				if err != nil {
+					return errors.Wrap(err, "creating coordinator")
+				}
+			}
+			coordinate := func(account *ethereum.Account, tr transactor.Transactor) transactor.Transactor {
+				if coordinator == nil {
+					return tr
+				}
+				return coordinator.Wrap(account.Address.String(), tr)
+			}
 
-				// The Miner component.
-				miner, err := mining.NewMiningManager(loggerWithAddr, ctx, cfg.Mining, contractTellor, taskerChs[account.Address.String()], submitterCh, client)
+			// The accounts with a smart account send ERC-4337 user operations instead of transactions.
+			newTransactor := func(logger log.Logger, account *ethereum.Account) (transactor.Transactor, error) {
+				if _, ok := cfg.Transactor.UserOp.SmartAccount(account.Address); ok && cfg.Transactor.UserOp.Enabled {
+					tr, err := transactor.NewUserOp(logger, cfg.Transactor, client, account, signer)
+					if err != nil {
+						return nil, err
+					}
+					return coordinate(account, tr), nil
+				}
+				tr, err := transactor.New(logger, cfg.Transactor, gasPriceTracker, client, account, signer, budget, gasRecorder, pool, nil)
 				if err != nil {
-					return errors.Wrap(err, "creating miner")
+					return nil, err
 				}
-				g.Add(func() error {
-					err := miner.Start()
-					level.Info(loggerWithAddr).Log("msg", "miner shutdown complete")
-					return err
-				}, func(error) {
-					miner.Stop()
-				})
+				return coordinate(account, tr), nil
 			}
-		}
 
-		if cfg.SubmitterTellorAccess.Enabled {
-			contract, err := contracts.NewITellorAccess(client)
+			notifier, err := notify.New(logger, cfg.Notify)
 			if err != nil {
-				return errors.Wrap(err, "create tellor contract instance")
+				return errors.Wrap(err, "creating notifier")
 			}
 
-			// Create a submitter for each account.
+			// The approvers sign the queued operations through the API of the running instance.
+			if cfg.Approval.Enabled {
+				approvals, err := approval.New(logger, cfg.Approval, notifier)
+				if err != nil {
+					return errors.Wrap(err, "creating approval queue")
+				}
+				srv.Handle("/api/v1/approvals", http.HandlerFunc(approvals.ServeList), opApprovals)
+				// The approvals release the held spends so they need a key with the admin scope.
+				if srv.AuthEnabled() {
+					srv.HandlePost("/api/v1/approvals/approve", http.HandlerFunc(approvals.ServeApprove), opApprove)
+				} else {
+					level.Warn(logger).Log("msg", "approving the operations through the API is disabled because the API has no auth")
+				}
+			}
+
+			var accountAddrs []common.Address
+			for _, acc := range accounts {
+				accountAddrs = append(accountAddrs, acc.Address)
+			}
+
+			// The watchdogs use the gates to pause the submissions of an account.
+			gates := make(map[string]*submitter.Gate)
 			for _, account := range accounts {
+				gate := submitter.NewGate(account.Address.String())
+				gates[account.Address.String()] = gate
+				srv.AddHealth(gate)
+			}
+
+			// The submissions wait for the values of the index tracker warmup
+			// instead of failing in the first interval.
+			if indexTracker != nil {
+				for _, gate := range gates {
+					gate.Pause(index.WarmupName, "waiting for the index tracker warmup")
+				}
+				go func() {
+					select {
+					case <-indexTracker.WarmedUp():
+						for _, gate := range gates {
+							gate.Resume(index.WarmupName)
+						}
+					case <-ctx.Done():
+					}
+				}()
+			}
+
+			// The breakers pause the submissions of an account after repeated failures.
+			breakers := make(submitter.Breakers)
+			if cfg.Breaker.Enabled {
+				for _, account := range accounts {
+					breaker, err := submitter.NewBreaker(logger, cfg.Breaker, account.Address.String(), gates[account.Address.String()], notifier)
+					if err != nil {
+						return errors.Wrap(err, "creating circuit breaker")
+					}
+					breakers[account.Address.String()] = breaker
+				}
+				srv.Handle("/api/v1/submitter/breaker", breakers, opBreakers)
+				if srv.AuthEnabled() {
+					srv.HandlePost("/api/v1/submitter/breaker/reset", http.HandlerFunc(breakers.ServeReset), opBreakerReset)
+				} else {
+					level.Warn(logger).Log("msg", "resetting the circuit breakers through the API is disabled because the API has no auth, a tripped breaker resumes only after its timeout or a restart")
+				}
+			}
+
+			// Leader election between the instances that share the same accounts.
+			// Only the leader mines, submits and tips through the gates of its accounts.
+			if coordinator != nil {
+				g.Add(supervisor.Actor("coordination", true, coordinator.NewElector(ctx, signers, gates)))
+			}
+
+			// Balance tracker.
+			if cfg.BalanceTracker.Enabled {
+				balanceTracker, err := balance.New(logger, ctx, cfg.BalanceTracker, client, contractTellor, gasPriceTracker, accounts, gates, notifier, watchdog)
+				if err != nil {
+					return errors.Wrap(err, "creating balance tracker")
+				}
+				g.Add(supervisor.Actor("balanceTracker", false, balanceTracker))
+			}
+
+			// Tops up the ETH of the accounts from a treasury account.
+			if cfg.Treasury.Enabled {
+				account, err := treasury.Account()
+				if err != nil {
+					return errors.Wrap(err, "creating treasury account")
+				}
+				// A separate signer so that the transactors can't sign for the treasury.
+				treasurySigner := ethereum.NewKeySigner(logger, []*ethereum.Account{account})
+				treasury, err := treasury.New(logger, ctx, cfg.Treasury, client, gasPriceTracker, account.Address, treasurySigner, notifier)
+				if err != nil {
+					return errors.Wrap(err, "creating treasury")
+				}
+				g.Add(supervisor.Actor("treasury", false, treasury))
+			}
+
+			// Stake tracker.
+			if cfg.StakeTracker.Enabled {
+				stakeTracker, err := stake.New(logger, ctx, cfg.StakeTracker, client, contractTellor, accounts, gates, notifier, watchdog)
+				if err != nil {
+					return errors.Wrap(err, "creating stake tracker")
+				}
+				g.Add(supervisor.Actor("stakeTracker", false, stakeTracker))
+			}
+
+			// Node tracker.
+			if cfg.NodeTracker.Enabled {
+				nodeTracker, err := node.New(logger, ctx, cfg.NodeTracker, client, os.Getenv(ethereum.NodeURLEnvName), notifier, watchdog)
+				if err != nil {
+					return errors.Wrap(err, "creating node tracker")
+				}
+				g.Add(supervisor.Actor("nodeTracker", false, nodeTracker))
+			}
+
+			// Vote tracker.
+			if cfg.VoteTracker.Enabled {
+				voteTracker, err := vote.New(logger, ctx, cfg.VoteTracker, client, logFetcher, verifier, contractTellor, accountAddrs, notifier, watchdog, clockTracker)
+				if err != nil {
+					return errors.Wrap(err, "creating vote tracker")
+				}
+				g.Add(supervisor.Actor("voteTracker", false, voteTracker))
+			}
+
+			// Dispute history.
+			var disputes reputation.Disputes
+			if cfg.DisputeHistory.Enabled {
+				disputeStore, err := disputeHistory.OpenStore(cfg.DisputeHistory.Path)
+				if err != nil {
+					return errors.Wrap(err, "opening dispute history")
+				}
+				defer func() {
+					if err := disputeStore.Close(); err != nil {
+						level.Error(logger).Log("msg", "closing the dispute history", "err", err)
+					}
+				}()
+				historyTracker, err := disputeHistory.New(logger, ctx, cfg.DisputeHistory, client, logFetcher, contractTellor, disputeStore, accountAddrs, watchdog)
+				if err != nil {
+					return errors.Wrap(err, "creating dispute history tracker")
+				}
+				srv.Handle("/api/v1/disputes", historyTracker, opDisputes)
+				srv.Handle("/api/v1/disputes/stats", http.HandlerFunc(historyTracker.ServeStats), opDisputeStats)
+				g.Add(supervisor.Actor("disputeHistory", false, historyTracker))
+				disputes = disputeStore
+			}
+
+			// Event indexer.
+			if cfg.Indexer.Enabled {
+				eventStore, err := indexer.OpenStore(cfg.Indexer.Path)
+				if err != nil {
+					return errors.Wrap(err, "opening the indexed events")
+				}
+				defer func() {
+					if err := eventStore.Close(); err != nil {
+						level.Error(logger).Log("msg", "closing the indexed events", "err", err)
+					}
+				}()
+				eventIndexer, err := indexer.New(logger, ctx, cfg.Indexer, client, logFetcher, contractTellor.Address, eventStore, watchdog)
+				if err != nil {
+					return errors.Wrap(err, "creating event indexer")
+				}
+				srv.Handle("/api/v1/events", eventIndexer, opEvents)
+				g.Add(supervisor.Actor("indexer", false, eventIndexer))
+			}
+
+			// Miner reputation.
+			if cfg.Reputation.Enabled {
+				if tsDB == nil {
+					return errors.Errorf("the reputation scoring needs the db which the %v role doesn't open", self.Role)
+				}
+				scorer, err := reputation.New(logger, ctx, cfg.Reputation, tsDB, disputes)
+				if err != nil {
+					return errors.Wrap(err, "creating reputation scorer")
+				}
+				srv.Handle("/api/v1/reputation", scorer, opReputation)
+				g.Add(supervisor.Actor("reputation", false, scorer))
+			}
+
+			if cfg.SubmitterTellor.Enabled {
+				// Profit tracker.
+				// The transaction amounts are recorded only in a local DB.
+				profitDB, _ := tsDB.(*db.DB)
+				profitTracker, err := profit.NewProfitTracker(logger, ctx, cfg.ProfitTracker, profitDB, client, logFetcher, contractTellor, accountAddrs)
+				if err != nil {
+					return errors.Wrap(err, "creating profit tracker")
+				}
+				g.Add(supervisor.Actor("profitTracker", false, profitTracker))
+
+				// Contract upgrades detection.
+				var taskerUpgrades, slotUpgrades <-chan contracts.Addresses
+				if cfg.Contracts.Enabled {
+					discovery, err := contracts.NewDiscovery(logger, ctx, cfg.Contracts, client, contractTellor.Address)
+					if err != nil {
+						return errors.Wrap(err, "creating contract discovery")
+					}
+					taskerUpgrades = discovery.Subscribe()
+					slotUpgrades = discovery.Subscribe()
+					g.Add(supervisor.Actor("contractDiscovery", false, discovery))
+				}
+
+				// The submission races of all accounts.
+				// The races are recorded only in a local DB.
+				var raceDB storage.Appendable
+				if _db, ok := tsDB.(*db.DB); ok {
+					raceDB = _db
+				}
+				raceTracker, err := race.New(logger, cfg.RaceTracker, client, raceDB, accountAddrs)
+				if err != nil {
+					return errors.Wrap(err, "creating race tracker")
+				}
+				srv.Handle("/api/v1/races", raceTracker, opRaces)
+
+				// The exclusions are checked by the tasker before mining.
+				requests, err := tellor.NewRequests(logger, cfg.SubmitterTellor, reg)
+				if err != nil {
+					return errors.Wrap(err, "creating request overrides")
+				}
+				srv.Handle("/api/v1/submitter/requests", requests, opRequests)
+				// A loopback API without auth would accept these from any page open in the browser of the operator.
+				if srv.AuthEnabled() {
+					srv.HandlePost("/api/v1/submitter/pause", http.HandlerFunc(requests.ServePause), opPause)
+					srv.HandlePost("/api/v1/submitter/resume", http.HandlerFunc(requests.ServeResume), opResume)
+				} else {
+					level.Warn(logger).Log("msg", "pausing and resuming the submissions through the API is disabled because the API has no auth")
+				}
+
+				// Event tasker.
+				tasker, taskerChs, err := tasker.New(ctx, logger, cfg.Tasker, client, contractTellor, signers, taskerUpgrades, raceTracker, requests)
+				if err != nil {
+					return errors.Wrap(err, "creating tasker")
+				}
+				g.Add(supervisor.Actor("tasker", true, tasker))
+				srv.AddHealth(health.Freshness("tasker:lastEvent", false, tasker.LastEvent, healthEventMaxAge))
+
+				// The slot tracker is shared by all submitters.
+				slotTracker, err := slot.New(logger, ctx, cfg.SlotTracker, client, contractTellor, slotUpgrades, raceTracker)
+				if err != nil {
+					return errors.Wrap(err, "creating slot tracker")
+				}
+				g.Add(supervisor.Actor("slotTracker", false, slotTracker))
+
+				// The mempool watcher is shared by all submitters.
+				var mempoolWatcher *mempool.Watcher
+				if cfg.Mempool.Enabled {
+					mempoolWatcher, err = mempool.New(logger, ctx, cfg.Mempool, contractTellor.Address, os.Getenv(ethereum.NodeURLEnvName))
+					if err != nil {
+						return errors.Wrap(err, "creating mempool watcher")
+					}
+					g.Add(supervisor.Actor("mempool", false, mempoolWatcher))
+				}
+
+				// The cross check compares the values with other oracles in the value guard.
+				var crossChecker submitter.CrossChecker
+				checker, err := crosscheck.New(logger, cfg.CrossCheck, client)
+				if err != nil {
+					return errors.Wrap(err, "creating cross checker")
+				}
+				if checker != nil {
+					if !cfg.SubmitterTellor.Guard.Enabled {
+						return errors.New("the cross check needs the value guard to be enabled")
+					}
+					crossChecker = checker
+					srv.Handle("/api/v1/crosscheck", checker, opCrossCheck)
+					if srv.AuthEnabled() {
+						srv.HandlePost("/api/v1/crosscheck/allow", http.HandlerFunc(checker.ServeAllow), opCrossCheckAllow)
+					} else {
+						level.Warn(logger).Log("msg", "allowing the values that disagree with the other oracles through the API is disabled because the API has no auth")
+					}
+				}
+
+				// Shared by all accounts so that the on-chain medians are cached once.
+				var guard *submitter.Guard
+				if cfg.SubmitterTellor.Guard.Enabled {
+					// The submissions don't depend on the guard so a contract it can't read
+					// only disables it.
+					caps, err := contracts.NewCapabilities(ctx, client, contractTellor.Address)
+					if err == nil {
+						var reader contracts.ValueReader
+						if reader, err = caps.Reader(); err == nil {
+							guard = submitter.NewGuard(logger, cfg.SubmitterTellor.Guard, registry.OracleTellor, reader, reg, guardSources, crossChecker)
+						}
+					}
+					if err != nil {
+						level.Warn(logger).Log("msg", "the value guard is disabled as the contract capabilities can't be detected", "err", err)
+						guardErr := errors.Wrap(err, "value guard disabled")
+						srv.AddHealth(health.NewCheck("valueGuard", false, func(context.Context) error { return guardErr }))
+					}
+				}
+
+				var replay *tellor.ReplayLog
+				if cfg.SubmitterTellor.ReplayLog != "" {
+					replay, err = tellor.OpenReplayLog(cfg.SubmitterTellor.ReplayLog)
+					if err != nil {
+						return errors.Wrap(err, "opening the replay log")
+					}
+					defer func() {
+						if err := replay.Close(); err != nil {
+							level.Error(logger).Log("msg", "closing the replay log", "err", err)
+						}
+					}()
+				}
+
+				// Create a submitter for each account.
+				submitterChs := make(map[string]chan *mining.Result)
+				maxGasPrice := transactor.MaxGasPrice(cfg.Transactor)
+				for _, account := range signers {
+					loggerWithAddr := log.With(logger, "addr", account.Address.String()[:6])
+
+					tr, err := transactor.New(loggerWithAddr, cfg.Transactor, gasPriceTracker, client, account, signer, budget, gasRecorder, pool, raceTracker)
+					if err != nil {
+						return errors.Wrap(err, "creating transactor")
+					}
+					transactor := coordinate(account, tr)
+
+					// Get a channel on which it listens for new data to submit.
+					submitter, submitterCh, err := tellor.New(
+						ctx,
+						loggerWithAddr,
+						cfg.SubmitterTellor,
+						client,
+						contractTellor,
+						account,
+						reward.New(loggerWithAddr, aggr, contractTellor),
+						transactor,
+						maxGasPrice,
+						gasPriceTracker,
+						newPsrTellor(loggerWithAddr),
+						requests,
+						replay,
+						journal,
+						gates[account.Address.String()],
+						guard,
+						breakers[account.Address.String()],
+						notifier,
+						mempoolWatcher,
+						slotTracker,
+						clockTracker,
+						raceTracker,
+						publisher,
+						plugins,
+					)
+					if err != nil {
+						return errors.Wrap(err, "creating tellor submitter")
+					}
+					g.Add(supervisor.Actor("submitterTellor:"+account.Address.String(), true, submitter))
+					srv.AddHealth(health.Freshness("submitterTellor:"+account.Address.String()+":lastSubmit", false, submitter.LastSubmitted, healthSubmitMaxAge))
+
+					// Will be used to cancel pending submissions.
+					tasker.AddSubmitCanceler(submitter)
+					submitterChs[account.Address.String()] = submitterCh
+
+					// The miner role runs in another instance.
+					if self.Role != roleAll {
+						continue
+					}
+
+					// The Miner component.
+					miner, err := mining.NewMiningManager(loggerWithAddr, ctx, cfg.Mining, contractTellor, taskerChs[account.Address.String()], submitterCh, client, account, gates[account.Address.String()])
+					if err != nil {
+						return errors.Wrap(err, "creating miner")
+					}
+					g.Add(supervisor.Actor("miner:"+account.Address.String(), true, miner))
+				}
+
+				// Serve the work to the miner role.
+				if self.Role == roleSubmitter {
+					workServer := mining.NewWorkServer(logger, ctx, taskerChs, submitterChs)
+					srv.Handle("/api/v1/mining/work", http.HandlerFunc(workServer.ServeWork), opWork)
+					if srv.Protected() {
+						srv.HandlePost("/api/v1/mining/solution", http.HandlerFunc(workServer.ServeSolution), opSolution)
+					} else {
+						level.Error(logger).Log("msg", "the remote miners can't send solutions because the API has no auth and doesn't listen on a loopback address")
+					}
+					g.Add(supervisor.Actor("workServer", true, workServer))
+				}
+			}
+
+			// Tipper.
+			if cfg.Tipper.Enabled {
+				if len(signers) == 0 {
+					return errors.New("no accounts to send the tips from")
+				}
+				// All tips are sent from the first account.
+				account := signers[0]
 				loggerWithAddr := log.With(logger, "addr", account.Address.String()[:6])
-				psr := psrTellorAccess.New(loggerWithAddr, cfg.PsrTellorAccess, aggregator)
-				transactor, err := transactor.New(loggerWithAddr, cfg.Transactor, gasPriceTracker, client, account)
+
+				transactor, err := newTransactor(loggerWithAddr, account)
 				if err != nil {
 					return errors.Wrap(err, "creating transactor")
 				}
+				tipper, err := tipper.New(ctx, loggerWithAddr, cfg.Tipper, client, contractTellor, account, transactor, gates[account.Address.String()])
+				if err != nil {
+					return errors.Wrap(err, "creating tipper")
+				}
+				g.Add(supervisor.Actor("tipper", false, tipper))
+			}
 
-				submitter, err := tellorAccess.New(
-					ctx,
-					loggerWithAddr,
-					cfg.SubmitterTellorAccess,
-					client,
-					contract,
-					account,
-					transactor,
-					psr,
-				)
+			if cfg.SubmitterTellorAccess.Enabled {
+				contract, err := contracts.NewITellorAccess(client)
 				if err != nil {
-					return errors.Wrap(err, "creating tellor access submitter")
+					return errors.Wrap(err, "create tellor contract instance")
 				}
-				g.Add(func() error {
-					err := submitter.Start()
-					level.Info(loggerWithAddr).Log("msg", "tellor access submitter shutdown complete")
-					return err
-				}, func(error) {
-					submitter.Stop()
-				})
+
+				// Shared by all accounts so that the on-chain medians are cached once.
+				var guard *submitter.Guard
+				if cfg.SubmitterTellorAccess.Guard.Enabled {
+					guard = submitter.NewGuard(logger, cfg.SubmitterTellorAccess.Guard, registry.OracleTellorAccess, contract.Reader(), reg, nil, nil)
+				}
+
+				// Create a submitter for each account.
+				for _, account := range signers {
+					loggerWithAddr := log.With(logger, "addr", account.Address.String()[:6])
+					transactor, err := newTransactor(loggerWithAddr, account)
+					if err != nil {
+						return errors.Wrap(err, "creating transactor")
+					}
+
+					submitter, err := tellorAccess.New(
+						ctx,
+						loggerWithAddr,
+						cfg.SubmitterTellorAccess,
+						client,
+						contract,
+						account,
+						transactor,
+						gasPriceTracker,
+						newPsrTellorAccess(loggerWithAddr),
+						journal,
+						gates[account.Address.String()],
+						guard,
+						breakers[account.Address.String()],
+						clockTracker,
+					)
+					if err != nil {
+						return errors.Wrap(err, "creating tellor access submitter")
+					}
+					g.Add(supervisor.Actor("submitterTellorAccess:"+account.Address.String(), true, submitter))
+				}
+			}
+		}
+
+		// Miners that get the work from an instance running in the submitter role.
+		if self.Role == roleMiner {
+			if cfg.Mining.RemoteURL == "" {
+				return errors.New("the miner role needs the url of a submitter instance")
+			}
+			// The miners only need the addresses so they can run with read-only accounts.
+			for _, account := range accounts {
+				loggerWithAddr := log.With(logger, "addr", account.Address.String()[:6])
+
+				remoteWork, taskerCh, submitterCh := mining.NewRemoteWork(loggerWithAddr, ctx, cfg.Mining, account.Address.String())
+				g.Add(supervisor.Actor("remoteWork:"+account.Address.String(), true, remoteWork))
+
+				miner, err := mining.NewMiningManager(loggerWithAddr, ctx, cfg.Mining, contractTellor, taskerCh, submitterCh, client, account, nil)
+				if err != nil {
+					return errors.Wrap(err, "creating miner")
+				}
+				g.Add(supervisor.Actor("miner:"+account.Address.String(), true, miner))
 			}
 		}
 
@@ -900,18 +1864,58 @@ func (self mineCmd) Run() error {
 	return nil
 }
 
+func ethClientHealth(client contracts.ETHClient) *health.Check {
+	return health.NewCheck("ethClient", true, func(ctx context.Context) error {
+		_, err := client.HeaderByNumber(ctx, nil)
+		return err
+	})
+}
+
+// openLocalDB opens the local TSDB database of the mine command
+// and adds its downsampler to the group when enabled.
+// The caller closes the DB.
+func openLocalDB(ctx context.Context, logger log.Logger, cfg db.Config, g *run.Group, supervisor *supervisor.Supervisor) (*db.DB, error) {
+	tsdbOptions := tsdb.DefaultOptions()
+	// 48h are enough as the aggregator needs data only 24 hours in the past.
+	tsdbOptions.RetentionDuration = int64(2 * 24 * time.Hour / time.Millisecond)
+	tsdbOptions.MaxExemplars = cfg.MaxExemplars
+	if err := os.MkdirAll(cfg.Path, 0777); err != nil {
+		return nil, errors.Wrap(err, "creating tsdb DB folder")
+	}
+	tsDB, err := db.Open(logger, cfg, tsdbOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening local tsdb DB")
+	}
+	if cfg.Downsample.Enabled {
+		downsampler, err := db.NewDownsampler(logger, ctx, cfg.Downsample, tsDB)
+		if err != nil {
+			if err := tsDB.Close(); err != nil {
+				level.Error(logger).Log("msg", "closing the tsdb", "err", err)
+			}
+			return nil, errors.Wrap(err, "creating downsampler")
+		}
+		g.Add(supervisor.Actor("downsampler", false, downsampler))
+	}
+	return tsDB, nil
+}
+
 func remoteDB(cfg db.Config) (storage.SampleAndChunkQueryable, error) {
 
 	url, err := url.Parse("http://" + cfg.RemoteHost + ":" + strconv.Itoa(int(cfg.RemotePort)) + "/api/v1/read")
 	if err != nil {
 		return nil, err
 	}
+	httpConfig := promConfig.HTTPClientConfig{
+		FollowRedirects: true,
+	}
+	// The remote instance needs an API key when it runs with the auth.
+	if key := os.Getenv(web.APIKeyEnvName); key != "" {
+		httpConfig.Authorization = &promConfig.Authorization{Type: "Bearer", Credentials: promConfig.Secret(key)}
+	}
 	client, err := remote.NewReadClient("", &remote.ClientConfig{
-		URL:     &promConfig.URL{URL: url},
-		Timeout: model.Duration(cfg.RemoteTimeout.Duration),
-		HTTPClientConfig: promConfig.HTTPClientConfig{
-			FollowRedirects: true,
-		},
+		URL:              &promConfig.URL{URL: url},
+		Timeout:          model.Duration(cfg.RemoteTimeout.Duration),
+		HTTPClientConfig: httpConfig,
 	})
 	if err != nil {
 		return nil, err
@@ -932,35 +1936,53 @@ func getAccountFor(accounts []*ethereum.Account, accountNo int) (*ethereum.Accou
 	return accounts[accountNo], nil
 }
 
+// createVerifier returns a nil verifier when the verification is disabled
+// so that the events are not verified.
+func createVerifier(logger log.Logger, cfg ethereum.Config) (*ethereum.Verifier, error) {
+	if !cfg.Verify.Enabled {
+		return nil, nil
+	}
+	return ethereum.NewVerifier(logger, cfg.Verify, os.Getenv(ethereum.NodeURLEnvName))
+}
+
 func createTellorVariables(ctx context.Context, logger log.Logger, cfg ethereum.Config) (contracts.ETHClient, []*ethereum.Account, error) {
-	nodeURL := os.Getenv(ethereum.NodeURLEnvName)
-	client, err := ethereum.NewClient(logger, cfg, nodeURL)
+	client, err := createClient(ctx, logger, cfg)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "create rpc client instance")
+		return nil, nil, err
 	}
 
 	accounts, err := ethereum.GetAccounts()
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "creating accounts")
 	}
+	return client, accounts, nil
+}
+
+// createClient connects to the node and checks that it is synced.
+func createClient(ctx context.Context, logger log.Logger, cfg ethereum.Config) (contracts.ETHClient, error) {
+	nodeURL := os.Getenv(ethereum.NodeURLEnvName)
+	client, err := ethereum.NewClient(logger, cfg, nodeURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "create rpc client instance")
+	}
 
 	if !strings.Contains(strings.ToLower(nodeURL), "arbitrum") { // Arbitrum nodes doesn't support sync checking.
 		// Issue #55, halt if client is still syncing with Ethereum network
 		s, err := client.IsSyncing(ctx)
 		if err != nil {
-			return nil, nil, errors.Wrap(err, "determining if Ethereum client is syncing")
+			return nil, errors.Wrap(err, "determining if Ethereum client is syncing")
 		}
 		if s {
-			return nil, nil, errors.New("ethereum node is still syncing with the network")
+			return nil, errors.New("ethereum node is still syncing with the network")
 		}
 	}
 
 	id, err := client.NetworkID(ctx)
 	if err != nil {
-		return nil, nil, level.Error(logger).Log("msg", "get nerwork ID", "err", err)
+		return nil, errors.Wrap(err, "get network ID")
 	}
 
 	level.Info(logger).Log("msg", "client created", "netID", id.String())
 
-	return client, accounts, nil
+	return client, nil
 }
@@ -0,0 +1,26 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/kong"
+	"github.com/tellor-io/telliot/pkg/testutil"
+	"github.com/tellor-io/telliot/pkg/tracker/vote"
+)
+
+// TestVoteCommand ensures that the vote notifications
+// tell the operators to run a registered command.
+func TestVoteCommand(t *testing.T) {
+	parser, err := kong.New(&CLI, kong.Name("telliot"), kong.Exit(func(int) {}))
+	testutil.Ok(t, err)
+
+	args := strings.Fields(strings.Replace(vote.VoteCommand(5), "<true|false>", "true", 1))
+	testutil.Equals(t, "telliot", args[0])
+	ctx, err := parser.Parse(args[1:])
+	testutil.Ok(t, err)
+	testutil.Equals(t, "dispute vote <dispute-id> <support>", ctx.Command())
+}
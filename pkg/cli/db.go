@@ -0,0 +1,139 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package cli
+
+import (
+	"context"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/tellor-io/telliot/pkg/config"
+	"github.com/tellor-io/telliot/pkg/db"
+	"github.com/tellor-io/telliot/pkg/logging"
+)
+
+type dbExportCmd struct {
+	Config configPath `type:"existingfile" help:"path to config file"`
+	Match  []string   `help:"export only the series matching any of these selectors e.g. '{__name__=~\"indexTracker_.*\",symbol=\"ETH/USD\"}', by default all series"`
+	From   time.Time  `help:"export only the samples after this time(RFC3339), by default from the oldest sample"`
+	To     time.Time  `help:"export only the samples before this time(RFC3339), by default until the latest sample"`
+	Format string     `enum:"openmetrics,blocks" default:"openmetrics" help:"output format(openmetrics,blocks)"`
+	Output string     `type:"path" help:"the file to write the OpenMetrics text to, by default stdout, or the dir to write the blocks to"`
+}
+
+// Run writes the selected series and time range of the local or the remote DB
+// so that they can be imported by another node.
+func (self dbExportCmd) Run() error {
+	logger := logging.NewLogger()
+
+	cfg, err := config.ParseConfig(logger, string(self.Config))
+	if err != nil {
+		return errors.Wrap(err, "creating config")
+	}
+	if self.Format == "blocks" && self.Output == "" {
+		return errors.New("the blocks format needs an output dir")
+	}
+	mint, maxt := int64(math.MinInt64), int64(math.MaxInt64)
+	if !self.From.IsZero() {
+		mint = timestamp.FromTime(self.From)
+	}
+	if !self.To.IsZero() {
+		maxt = timestamp.FromTime(self.To)
+	}
+	if mint > maxt {
+		return errors.Errorf("from:%v is after to:%v", self.From, self.To)
+	}
+
+	var tsDB storage.Queryable
+	if cfg.Db.RemoteHost != "" {
+		tsDB, err = remoteDB(cfg.Db)
+		if err != nil {
+			return errors.Wrap(err, "opening remote tsdb DB")
+		}
+	} else {
+		// Read only so that it can run next to a running instance.
+		_tsDB, err := db.OpenReadOnly(logger, cfg.Db)
+		if err != nil {
+			return errors.Wrap(err, "opening local tsdb DB")
+		}
+		defer _tsDB.Close()
+		tsDB = _tsDB
+	}
+
+	ctx := context.Background()
+	var count int
+	if self.Format == "blocks" {
+		count, err = db.ExportBlocks(ctx, logger, self.Output, tsDB, mint, maxt, self.Match)
+	} else {
+		var w io.Writer = os.Stdout
+		if self.Output != "" {
+			f, err := os.Create(self.Output)
+			if err != nil {
+				return errors.Wrap(err, "creating the output file")
+			}
+			defer f.Close()
+			w = f
+		}
+		count, err = db.ExportOpenMetrics(ctx, w, tsDB, mint, maxt, self.Match)
+	}
+	if err != nil {
+		return errors.Wrap(err, "exporting")
+	}
+	level.Info(logger).Log("msg", "exported", "samples", count, "format", self.Format)
+	return nil
+}
+
+type dbImportCmd struct {
+	Config configPath `type:"existingfile" help:"path to config file"`
+	Format string     `enum:"openmetrics,blocks" default:"openmetrics" help:"input format(openmetrics,blocks)"`
+	Input  string     `arg:"" type:"path" help:"the OpenMetrics file or the dir of the blocks to import, - for the OpenMetrics text from stdin"`
+}
+
+// Run writes the exported data of another node to the local DB.
+// The data is written as new blocks so that it can run next to a running instance,
+// which loads them within a minute.
+func (self dbImportCmd) Run() error {
+	logger := logging.NewLogger()
+
+	cfg, err := config.ParseConfig(logger, string(self.Config))
+	if err != nil {
+		return errors.Wrap(err, "creating config")
+	}
+	if cfg.Db.RemoteHost != "" {
+		return errors.New("importing to a remote DB isn't supported")
+	}
+
+	var count int
+	if self.Format == "blocks" {
+		count, err = db.ImportBlocks(logger, self.Input, cfg.Db.Path)
+		if err == nil {
+			level.Info(logger).Log("msg", "imported", "blocks", count)
+		}
+	} else {
+		var r io.Reader = os.Stdin
+		if self.Input != "-" {
+			f, err := os.Open(self.Input)
+			if err != nil {
+				return errors.Wrap(err, "opening the input file")
+			}
+			defer f.Close()
+			r = f
+		}
+		count, err = db.ImportOpenMetrics(context.Background(), logger, r, cfg.Db.Path)
+		if err == nil {
+			level.Info(logger).Log("msg", "imported", "samples", count)
+		}
+	}
+	if err != nil {
+		err = errors.Wrap(err, "importing")
+	}
+	return auditCommand(logger, cfg.Db, "db_import", self.Input, err, "format", self.Format, "count", strconv.Itoa(count))
+}
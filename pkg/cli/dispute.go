@@ -5,16 +5,27 @@ package cli
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"math/big"
+	"os"
+	"text/tabwriter"
+	"time"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/approval"
+	"github.com/tellor-io/telliot/pkg/client"
 	"github.com/tellor-io/telliot/pkg/contracts"
 	tEthereum "github.com/tellor-io/telliot/pkg/ethereum"
 	"github.com/tellor-io/telliot/pkg/format"
 	psr "github.com/tellor-io/telliot/pkg/psr/tellor"
+	"github.com/tellor-io/telliot/pkg/tracker/disputeHistory"
+	"github.com/tellor-io/telliot/pkg/transactor"
 )
 
 func Dispute(
@@ -26,6 +37,10 @@ func Dispute(
 	requestId *big.Int,
 	timestamp *big.Int,
 	minerIndex *big.Int,
+	gasURL string,
+	budget transactor.BudgetConfig,
+	approvals *approval.Queue,
+	confirmations uint64,
 ) error {
 
 	if !minerIndex.IsUint64() || minerIndex.Uint64() > 4 {
@@ -54,12 +69,29 @@ func Dispute(
 		return errors.Wrapf(err, "prepare ethereum transaction")
 	}
 
+	if approvals.Required(transactor.FunctionBeginDispute, nil) {
+		description := fmt.Sprintf("dispute the value of request ID %v at %v by miner index %v", requestId, timestamp, minerIndex)
+		return requestApproval(ctx, logger, client, approvals, auth, account, transactor.FunctionBeginDispute, description, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+			return contract.BeginDispute(auth, requestId, timestamp, minerIndex)
+		})
+	}
+
+	release, err := reserveBudget(logger, budget, auth.GasPrice)
+	if err != nil {
+		return err
+	}
 	tx, err := contract.BeginDispute(auth, requestId, timestamp, minerIndex)
 	if err != nil {
+		if release != nil {
+			release(nil)
+		}
 		return errors.Wrap(err, "send dispute txn")
 	}
 	level.Info(logger).Log("msg", "dispute started", "txn", tx.Hash().Hex())
-	return nil
+	if err := recordGas(ctx, logger, client, tx, release, transactor.FunctionBeginDispute, account.Address.String(), gasURL); err != nil {
+		return err
+	}
+	return waitFinalized(ctx, logger, client, tx, confirmations)
 }
 
 func Vote(
@@ -70,9 +102,12 @@ func Vote(
 	account *tEthereum.Account,
 	disputeId *big.Int,
 	supportsDispute bool,
+	gasURL string,
+	budget transactor.BudgetConfig,
+	confirmations uint64,
 ) error {
 
-	voted, err := contract.DidVote(nil, disputeId, contract.Address)
+	voted, err := contract.DidVote(&bind.CallOpts{Context: ctx}, disputeId, account.Address)
 	if err != nil {
 		return errors.Wrapf(err, "check if you've already voted")
 	}
@@ -85,13 +120,23 @@ func Vote(
 	if err != nil {
 		return errors.Wrapf(err, "prepare ethereum transaction")
 	}
+	release, err := reserveBudget(logger, budget, auth.GasPrice)
+	if err != nil {
+		return err
+	}
 	tx, err := contract.Vote(auth, disputeId, supportsDispute)
 	if err != nil {
+		if release != nil {
+			release(nil)
+		}
 		return errors.Wrapf(err, "submit vote transaction")
 	}
 
 	level.Info(logger).Log("msg", "vote submitted with transaction", "tx", tx.Hash().Hex())
-	return nil
+	if err := recordGas(ctx, logger, client, tx, release, transactor.FunctionVote, account.Address.String(), gasURL); err != nil {
+		return err
+	}
+	return waitFinalized(ctx, logger, client, tx, confirmations)
 }
 
 func List(
@@ -281,3 +326,52 @@ func List(
 
 	return nil
 }
+
+type disputeStatsCmd struct {
+	URL    string        `required:"" help:"address of a running instance with the dispute history enabled e.g. http://localhost:9090"`
+	Window time.Duration `help:"only the disputes opened within this period, all when not set"`
+	Top    int           `default:"10" help:"the number of disputers, miners and request IDs with the most disputes"`
+}
+
+func (self disputeStatsCmd) Run() error {
+	ctx, cncl := context.WithTimeout(context.Background(), statusTimeout)
+	defer cncl()
+	stats, err := client.New(self.URL, 0).DisputeStats(ctx, self.Window)
+	if err != nil {
+		return err
+	}
+	return PrintDisputeStats(os.Stdout, stats, self.Top)
+}
+
+func PrintDisputeStats(w io.Writer, stats disputeHistory.Stats, top int) error {
+	fmt.Fprintf(w, "Disputes:%v open:%v passed:%v failed:%v\n", stats.Disputes, stats.Open, stats.Passed, stats.Failed)
+	for _, table := range []struct {
+		name   string
+		counts []disputeHistory.Count
+	}{
+		{"DISPUTER", stats.ByDisputer},
+		{"MINER", stats.ByMiner},
+		{"REQUEST ID", stats.ByRequestID},
+	} {
+		fmt.Fprintln(w)
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "%s\tDISPUTES\tOPEN\tPASSED\tFAILED\tPASS RATE\n", table.name)
+		for i, c := range table.counts {
+			if i == top {
+				break
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%.0f%%\n", c.Key, c.Disputes, c.Open, c.Passed, c.Failed, c.PassRate*100)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "WEEK\tAGAINST ME\tOPEN\tLOST\tWON\n")
+	for _, e := range stats.Exposure {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\n", e.Week.Format("2006-01-02"), e.Disputes, e.Open, e.Passed, e.Failed)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,177 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/tellor-io/telliot/pkg/aggregator"
+	"github.com/tellor-io/telliot/pkg/config"
+	"github.com/tellor-io/telliot/pkg/db"
+	"github.com/tellor-io/telliot/pkg/evidence"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/psr"
+	psrTellor "github.com/tellor-io/telliot/pkg/psr/tellor"
+	"github.com/tellor-io/telliot/pkg/registry"
+	"github.com/tellor-io/telliot/pkg/tracker/index"
+)
+
+// The indexes of the dispute uint vars.
+const (
+	disputeVarRequestID = 0
+	disputeVarTimestamp = 1
+	disputeVarValue     = 2
+)
+
+type evidenceCmd struct {
+	Config configPath    `type:"existingfile" help:"path to config file"`
+	ID     int64         `required:"" help:"the dispute ID"`
+	Window time.Duration `default:"10m" help:"include the samples this long before and after the submission"`
+	Output string        `type:"path" help:"the zip file to write, defaults to dispute-<id>.zip"`
+}
+
+// Run assembles the on-chain submission, the local samples, the PSR aggregations
+// and the captured API responses at the time of a disputed submission into a zip to share during the vote.
+func (self evidenceCmd) Run() error {
+	logger := logging.NewLogger()
+
+	cfg, err := config.ParseConfig(logger, string(self.Config))
+	if err != nil {
+		return errors.Wrap(err, "creating config")
+	}
+
+	ctx := context.Background()
+	client, _, err := createTellorVariables(ctx, logger, cfg.Ethereum)
+	if err != nil {
+		return errors.Wrap(err, "creating tellor variables")
+	}
+	contract, err := newTellorContract(client, cfg.Contracts)
+	if err != nil {
+		return errors.Wrap(err, "create tellor contract instance")
+	}
+	reg, err := registry.New(logger, cfg.Registry)
+	if err != nil {
+		return errors.Wrap(err, "creating request ID registry")
+	}
+
+	opts := &bind.CallOpts{Context: ctx}
+	_, _, _, _, reportedMiner, _, _, uintVars, _, err := contract.GetAllDisputeVars(opts, big.NewInt(self.ID))
+	if err != nil {
+		return errors.Wrap(err, "get dispute details")
+	}
+	if uintVars[disputeVarTimestamp].Sign() == 0 {
+		return errors.Errorf("dispute ID:%v doesn't exist", self.ID)
+	}
+	requestID := uintVars[disputeVarRequestID].Int64()
+	submission := evidence.Submission{
+		DisputeID:     self.ID,
+		RequestID:     requestID,
+		Timestamp:     time.Unix(uintVars[disputeVarTimestamp].Int64(), 0),
+		ReportedMiner: reportedMiner.String(),
+		Value:         reg.Decode(registry.OracleTellor, requestID, uintVars[disputeVarValue]),
+	}
+	miners, err := contract.GetMinersByRequestIdAndTimestamp(opts, uintVars[disputeVarRequestID], uintVars[disputeVarTimestamp])
+	if err != nil {
+		return errors.Wrap(err, "get the miners of the submission")
+	}
+	values, err := contract.GetSubmissionsByTimestamp(opts, uintVars[disputeVarRequestID], uintVars[disputeVarTimestamp])
+	if err != nil {
+		return errors.Wrap(err, "get the values of the submission")
+	}
+	for i, m := range miners {
+		submission.Miners = append(submission.Miners, m.String())
+		submission.Values = append(submission.Values, reg.Decode(registry.OracleTellor, requestID, values[i]))
+	}
+
+	var tsDB storage.SampleAndChunkQueryable
+	if cfg.Db.RemoteHost != "" {
+		tsDB, err = remoteDB(cfg.Db)
+		if err != nil {
+			return errors.Wrap(err, "opening remote tsdb DB")
+		}
+	} else {
+		// Read only so that it can run next to a running instance.
+		_tsDB, err := db.OpenReadOnly(logger, cfg.Db)
+		if err != nil {
+			return errors.Wrap(err, "opening local tsdb DB")
+		}
+		defer _tsDB.Close()
+		tsDB = _tsDB
+	}
+	aggr, err := aggregator.New(logger, ctx, cfg.Aggregator, tsDB)
+	if err != nil {
+		return errors.Wrap(err, "creating aggregator")
+	}
+
+	report := &evidence.Report{
+		Generated:  time.Now(),
+		Submission: submission,
+		Window:     self.Window,
+		Samples:    make(map[string][]aggregator.Sample),
+	}
+	p := psrTellor.New(logger, cfg.PsrTellor, aggr, reg)
+	p.Observe(func(a psr.Aggregation) {
+		report.Aggregations = append(report.Aggregations, a)
+	})
+	val, err := p.GetValue(requestID, submission.Timestamp)
+	if err != nil {
+		report.PSRErr = err.Error()
+	} else {
+		report.PSRValue = reg.Decode(registry.OracleTellor, requestID, val)
+	}
+
+	for _, a := range report.Aggregations {
+		if _, ok := report.Samples[a.Symbol]; ok {
+			continue
+		}
+		samples, err := aggr.Samples(a.Symbol, submission.Timestamp.Add(-self.Window), submission.Timestamp.Add(self.Window))
+		if err != nil {
+			return errors.Wrapf(err, "getting the samples for symbol:%v", a.Symbol)
+		}
+		report.Samples[a.Symbol] = samples
+	}
+
+	if cfg.IndexTracker.Capture.Enabled {
+		capture, err := index.NewCapture(logger, cfg.IndexTracker.Capture)
+		if err != nil {
+			return errors.Wrap(err, "opening the response capture")
+		}
+		for symbol := range report.Samples {
+			captured, err := capture.Find(symbol, submission.Timestamp.Add(-self.Window), submission.Timestamp.Add(self.Window))
+			if err != nil {
+				return errors.Wrapf(err, "finding the captured responses for symbol:%v", symbol)
+			}
+			for _, c := range captured {
+				body, err := c.Read()
+				if err != nil {
+					return err
+				}
+				report.Responses = append(report.Responses, evidence.Response{Symbol: c.Symbol, Domain: c.Domain, Time: c.Time, Body: body})
+			}
+		}
+	}
+
+	output := self.Output
+	if output == "" {
+		output = fmt.Sprintf("dispute-%d.zip", self.ID)
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return errors.Wrap(err, "creating the output file")
+	}
+	defer f.Close()
+	if err := evidence.Write(f, report); err != nil {
+		return err
+	}
+	level.Info(logger).Log("msg", "evidence written", "output", output, "psrValue", report.PSRValue, "disputedValue", submission.Value)
+	return nil
+}
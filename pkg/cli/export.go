@@ -0,0 +1,98 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package cli
+
+import (
+	"context"
+	"os"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/config"
+	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/history"
+	"github.com/tellor-io/telliot/pkg/logging"
+)
+
+type exportCmd struct {
+	Config    configPath `type:"existingfile" help:"path to config file"`
+	FromBlock uint64     `required:"" help:"the first block to scan"`
+	ToBlock   uint64     `help:"the last block to scan, defaults to the latest block"`
+	Format    string     `enum:"csv,parquet" default:"csv" help:"output format(csv,parquet)"`
+	Output    string     `required:"" type:"path" help:"the file to write the events to"`
+	Chunk     uint64     `help:"max number of blocks requested at once, defaults to Ethereum.Logs.ChunkSize"`
+	RateLimit float64    `help:"max number of requests per second, defaults to Ethereum.Logs.RateLimit"`
+}
+
+// Run scans the block range for the submits, disputes and rewards
+// and writes them to a file for offline analysis.
+func (self exportCmd) Run() error {
+	logger := logging.NewLogger()
+
+	cfg, err := config.ParseConfig(logger, string(self.Config))
+	if err != nil {
+		return errors.Wrap(err, "creating config")
+	}
+
+	ctx := context.Background()
+	client, _, err := createTellorVariables(ctx, logger, cfg.Ethereum)
+	if err != nil {
+		return errors.Wrap(err, "creating tellor variables")
+	}
+	contract, err := newTellorContract(client, cfg.Contracts)
+	if err != nil {
+		return errors.Wrap(err, "create tellor contract instance")
+	}
+
+	to := self.ToBlock
+	if to == 0 {
+		header, err := client.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return errors.Wrap(err, "get latest eth block header")
+		}
+		to = header.Number.Uint64()
+	}
+	if self.FromBlock > to {
+		return errors.Errorf("from block:%v is after the to block:%v", self.FromBlock, to)
+	}
+
+	fetcherCfg := cfg.Ethereum.Logs
+	if self.Chunk > 0 {
+		fetcherCfg.ChunkSize = self.Chunk
+	}
+	if self.RateLimit > 0 {
+		fetcherCfg.RateLimit = self.RateLimit
+	}
+	fetcher, err := ethereum.NewLogFetcher(logger, client, fetcherCfg)
+	if err != nil {
+		return errors.Wrap(err, "creating log fetcher")
+	}
+	exporter, err := history.NewExporter(client, fetcher, contract.Address)
+	if err != nil {
+		return errors.Wrap(err, "creating exporter")
+	}
+
+	f, err := os.Create(self.Output)
+	if err != nil {
+		return errors.Wrap(err, "creating the output file")
+	}
+	defer f.Close()
+	w, err := history.NewWriter(f, self.Format)
+	if err != nil {
+		return err
+	}
+
+	level.Info(logger).Log("msg", "exporting", "from", self.FromBlock, "to", to, "format", self.Format)
+	count, err := exporter.Export(ctx, w, self.FromBlock, to, func(block uint64, count int) {
+		level.Info(logger).Log("msg", "exported", "block", block, "events", count)
+	})
+	if err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return errors.Wrap(err, "flushing the output")
+	}
+	level.Info(logger).Log("msg", "export completed", "events", count, "output", self.Output)
+	return nil
+}
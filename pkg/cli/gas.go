@@ -0,0 +1,106 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/client"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/transactor"
+)
+
+type gasCmd struct {
+	URL    string        `required:"" help:"address of a running instance e.g. http://localhost:9090"`
+	Window time.Duration `default:"24h" help:"the period of the averages, up to the retention of the db"`
+	JSON   bool          `name:"json" help:"print as JSON"`
+}
+
+func (s gasCmd) Run() error {
+	logger := logging.NewLogger()
+
+	ctx, cncl := context.WithTimeout(context.Background(), statusTimeout)
+	defer cncl()
+
+	summary, err := client.New(s.URL, 0).GasSummary(ctx, s.Window)
+	if err != nil {
+		return err
+	}
+	if s.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(summary)
+	}
+	if len(summary) == 0 {
+		level.Info(logger).Log("msg", "no transactions within the window", "window", s.Window)
+		return nil
+	}
+	return PrintGas(os.Stdout, summary)
+}
+
+func PrintGas(w io.Writer, summary []transactor.GasStats) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "FUNCTION\tTXS\tAVG GAS USED\tAVG GAS PRICE (GWEI)\tAVG COST (ETH)\n")
+	for _, s := range summary {
+		fmt.Fprintf(tw, "%s\t%d\t%.0f\t%.2f\t%.6f\n", s.Function, s.Count, s.GasUsed, s.GasPrice, s.Cost)
+	}
+	return tw.Flush()
+}
+
+// recordGas waits for a transaction sent by a CLI command to be mined,
+// settles its reservation in the transaction budget with the actual cost
+// and records its gas in the instance running at the url
+// as the db can't be opened while that instance is running.
+// Without a mined receipt the reservation keeps the max cost.
+func recordGas(ctx context.Context, logger log.Logger, ethClient contracts.ETHClient, tx *types.Transaction, release func(*big.Int), fn, account, url string) error {
+	if release == nil && url == "" {
+		return nil
+	}
+	level.Info(logger).Log("msg", "waiting for the transaction to be mined", "tx", tx.Hash().Hex())
+	receipt, err := bind.WaitMined(ctx, ethClient, tx)
+	if err != nil {
+		return errors.Wrapf(err, "transaction result tx:%v", tx.Hash())
+	}
+	if release != nil {
+		release(new(big.Int).Mul(tx.GasPrice(), new(big.Int).SetUint64(receipt.GasUsed)))
+	}
+	if url == "" {
+		return nil
+	}
+	rec := transactor.GasRecord{
+		Function: fn,
+		Account:  account,
+		GasUsed:  receipt.GasUsed,
+		GasPrice: tx.GasPrice(),
+		Time:     time.Now(),
+	}
+	return client.New(url, 0).RecordGas(ctx, rec)
+}
+
+// reserveBudget reserves the max cost of a dispute or vote transaction
+// in the transaction budget shared with the running instance through the budget file.
+// It returns a nil function when the budget is disabled.
+func reserveBudget(logger log.Logger, cfg transactor.BudgetConfig, gasPrice *big.Int) (func(*big.Int), error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	budget, err := transactor.NewBudget(logger, cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating the transaction budget")
+	}
+	return budget.Reserve(transactor.PriorityDispute, transactor.MaxCost(gasPrice), time.Now())
+}
@@ -0,0 +1,40 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package cli
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/dashboards"
+	"github.com/tellor-io/telliot/pkg/logging"
+)
+
+type grafanaProvisionCmd struct {
+	URL          string        `required:"" help:"address of the Grafana instance e.g. http://localhost:3000, the API key is read from the GRAFANA_API_KEY env var"`
+	Datasource   string        `default:"prometheus" help:"the Prometheus datasource that scrapes the telliot metrics"`
+	DbDatasource string        `name:"db-datasource" help:"the datasource of an instance with a local DB for the series only in the DB, by default the same as the datasource"`
+	Folder       string        `default:"Telliot" help:"the folder of the dashboards, created when missing, empty for the General folder"`
+	Timeout      time.Duration `default:"30s" help:"timeout of the Grafana requests"`
+}
+
+func (self grafanaProvisionCmd) Run() error {
+	logger := logging.NewLogger()
+
+	ctx, cncl := context.WithTimeout(context.Background(), self.Timeout)
+	defer cncl()
+
+	grafana := dashboards.NewGrafana(self.URL, os.Getenv(dashboards.GrafanaKeyEnvName), self.Timeout)
+	urls, err := grafana.Provision(ctx, self.Folder, self.Datasource, self.DbDatasource)
+	if err != nil {
+		return errors.Wrap(err, "provisioning the dashboards")
+	}
+	for _, u := range urls {
+		level.Info(logger).Log("msg", "provisioned dashboard", "url", u)
+	}
+	return nil
+}
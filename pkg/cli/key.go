@@ -0,0 +1,392 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/config"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/fsutil"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/secret"
+	"github.com/tellor-io/telliot/pkg/transactor"
+)
+
+// RetiredKeysEnvName holds the keys replaced by a rotation until their stake is withdrawn.
+const RetiredKeysEnvName = ethereum.RetiredKeysEnvName
+
+type keyRotateCmd struct {
+	Config    configPath `type:"existingfile" help:"path to config file"`
+	NewKeyEnv string     `default:"NEW_ETH_PRIVATE_KEY" help:"name of the env variable with the new private key, it can be set in the env file"`
+	Account   int        `arg:"" optional:"" help:"the account to retire"`
+}
+
+func (self keyRotateCmd) Run() error {
+	logger := logging.NewLogger()
+
+	cfg, err := config.ParseConfig(logger, string(self.Config))
+	if err != nil {
+		return errors.Wrap(err, "creating config")
+	}
+
+	ctx := context.Background()
+	client, accounts, err := createTellorVariables(ctx, logger, cfg.Ethereum)
+	if err != nil {
+		return errors.Wrap(err, "creating tellor variables")
+	}
+	account, err := getAccountFor(accounts, self.Account)
+	if err != nil {
+		return err
+	}
+	if account.ReadOnly() {
+		return errors.Wrapf(ethereum.ErrReadOnly, "can't rotate:%v", account.Address.Hex())
+	}
+	if err := secret.Take(self.NewKeyEnv); err != nil {
+		return err
+	}
+	newKey := secret.Get(self.NewKeyEnv)
+	if newKey == "" {
+		return errors.Errorf("missing new private key env variable:%v", self.NewKeyEnv)
+	}
+	newAccount, err := ethereum.NewAccount(newKey)
+	if err != nil {
+		return errors.Wrap(err, "parsing the new private key")
+	}
+	for _, a := range accounts {
+		if a.Address == newAccount.Address {
+			return errors.Errorf("the new account:%v is already in use", newAccount.Address.Hex())
+		}
+	}
+	contract, err := newTellorContract(client, cfg.Contracts)
+	if err != nil {
+		return errors.Wrap(err, "create tellor contract instance")
+	}
+	staker, err := newStaker(ctx, client, contract)
+	if err != nil {
+		return err
+	}
+	err = KeyRotate(ctx, logger, client, contract, staker, account, newAccount, cfg.EnvFile, cfg.Transactor.Confirmations)
+	return auditCommand(logger, cfg.Db, "key_rotate", account.Address.Hex(), err, "new", newAccount.Address.Hex())
+}
+
+// rotationStep is a single step of the key rotation
+// with an optional undo to roll it back when a later step fails.
+type rotationStep struct {
+	name string
+	do   func() error
+	undo func() error
+}
+
+// KeyRotate moves the stake of an account to a new account.
+// It funds and stakes the new account, replaces the old key in the env file
+// and requests the withdrawal of the old stake.
+// The old key is kept in the env file as a retired key until `key withdraw`
+// withdraws its stake to the new account once the withdrawal is unlocked.
+// When a step fails the completed steps are rolled back in reverse order
+// until a step that can't be rolled back like the stake of the new account,
+// and the steps left in place are returned with the error.
+func KeyRotate(
+	ctx context.Context,
+	logger log.Logger,
+	client contracts.ETHClient,
+	contract token,
+	staker contracts.Staker,
+	oldAccount *ethereum.Account,
+	newAccount *ethereum.Account,
+	envFile string,
+	confirmations map[string]uint64,
+) error {
+	logger = log.With(logger, "old", oldAccount.Address.Hex(), "new", newAccount.Address.Hex())
+
+	oldStatus, _, err := staker.StakerInfo(ctx, oldAccount.Address)
+	if err != nil {
+		return errors.Wrap(err, "get old stake status")
+	}
+	newStatus, _, err := staker.StakerInfo(ctx, newAccount.Address)
+	if err != nil {
+		return errors.Wrap(err, "get new stake status")
+	}
+	stakeAmt, err := staker.StakeAmount(ctx)
+	if err != nil {
+		return errors.Wrap(err, "fetching stake amount")
+	}
+	ethBalance, err := client.BalanceAt(ctx, newAccount.Address, nil)
+	if err != nil {
+		return errors.Wrap(err, "get ETH balance of the new account")
+	}
+	if ethBalance.Sign() == 0 {
+		return errors.New("the new account needs ETH for the transaction fees")
+	}
+
+	var steps []rotationStep
+
+	// Fund the new account from the free balance of the old account.
+	if newStatus != 1 {
+		newBalance, err := contract.BalanceOf(&bind.CallOpts{Context: ctx}, newAccount.Address)
+		if err != nil {
+			return errors.Wrap(err, "get TRB balance of the new account")
+		}
+		if missing := new(big.Int).Sub(stakeAmt, newBalance); missing.Sign() > 0 {
+			oldBalance, err := contract.BalanceOf(&bind.CallOpts{Context: ctx}, oldAccount.Address)
+			if err != nil {
+				return errors.Wrap(err, "get TRB balance of the old account")
+			}
+			free := new(big.Int).Set(oldBalance)
+			if oldStatus != 0 {
+				free.Sub(free, stakeAmt)
+			}
+			if free.Cmp(missing) < 0 {
+				return errors.Errorf("the new account needs %v TRB more to stake and the old account has only %v free TRB", format.ERC20Balance(missing), format.ERC20Balance(free))
+			}
+			steps = append(steps, rotationStep{
+				name: "fund the new account",
+				do: func() error {
+					return transactAndWait(ctx, logger, client, oldAccount, "transfer", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+						return contract.Transfer(auth, newAccount.Address, missing)
+					})
+				},
+				undo: func() error {
+					return transactAndWait(ctx, logger, client, newAccount, "transfer back", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+						return contract.Transfer(auth, oldAccount.Address, missing)
+					})
+				},
+			})
+		}
+		// The stake is locked once deposited so neither it nor the funding can be rolled back.
+		steps = append(steps, rotationStep{
+			name: "stake the new account",
+			do: func() error {
+				return Deposit(ctx, logger, client, contract, staker, newAccount, confirmations[transactor.FunctionDepositStake])
+			},
+		})
+	}
+
+	var restoreEnv func() error
+	steps = append(steps, rotationStep{
+		name: "replace the key in the env file",
+		do: func() error {
+			var err error
+			restoreEnv, err = retireKey(envFile, oldAccount, newAccount)
+			return err
+		},
+		undo: func() error { return restoreEnv() },
+	})
+
+	// A pending withdrawal of the old account is kept and
+	// is completed with the retired key.
+	if oldStatus == 1 {
+		steps = append(steps, rotationStep{
+			name: "request the withdrawal of the old stake",
+			do: func() error {
+				// The rotation moves the stake only between the own keys so it doesn't wait for the approvers.
+				return RequestStakingWithdraw(ctx, logger, client, staker, oldAccount, confirmations[transactor.FunctionRequestWithdraw], nil)
+			},
+		})
+	}
+
+	for i, step := range steps {
+		level.Info(logger).Log("msg", "key rotation", "step", step.name)
+		if err := step.do(); err != nil {
+			if left := rollback(logger, steps[:i]); len(left) > 0 {
+				return errors.Wrapf(err, "key rotation step:%v, the steps left in place:%v", step.name, strings.Join(left, ", "))
+			}
+			return errors.Wrapf(err, "key rotation step:%v", step.name)
+		}
+	}
+	level.Info(logger).Log("msg", "key rotated, run `telliot key withdraw` once the old stake is unlocked to move it to the new account")
+	return nil
+}
+
+// rollback undoes the steps in reverse order and returns the names of the steps left in place.
+// It stops at the first step that can't be undone as the steps before it depend on it.
+func rollback(logger log.Logger, steps []rotationStep) []string {
+	for i := len(steps) - 1; i >= 0; i-- {
+		if steps[i].undo == nil {
+			level.Error(logger).Log("msg", "step can't be rolled back", "step", steps[i].name)
+			return stepNames(steps[:i+1])
+		}
+		if err := steps[i].undo(); err != nil {
+			level.Error(logger).Log("msg", "rolling back", "step", steps[i].name, "err", err)
+			return stepNames(steps[:i+1])
+		}
+		level.Info(logger).Log("msg", "rolled back", "step", steps[i].name)
+	}
+	return nil
+}
+
+func stepNames(steps []rotationStep) []string {
+	var names []string
+	for _, step := range steps {
+		names = append(names, step.name)
+	}
+	return names
+}
+
+// retireKey replaces the old key with the new one in the env file
+// and moves the old key to the retired keys.
+// It returns a function to restore the original env file.
+func retireKey(envFile string, oldAccount, newAccount *ethereum.Account) (func() error, error) {
+	orig, err := ioutil.ReadFile(envFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading the env file")
+	}
+	oldKey := fmt.Sprintf("%x", crypto.FromECDSA(oldAccount.PrivateKey))
+	newKey := fmt.Sprintf("%x", crypto.FromECDSA(newAccount.PrivateKey))
+
+	var keys []string
+	for _, k := range strings.Split(secret.Get(ethereum.PrivateKeysEnvName), ",") {
+		account, err := ethereum.NewAccount(k)
+		if err != nil {
+			return nil, err
+		}
+		if account.Address == oldAccount.Address {
+			k = newKey
+		}
+		keys = append(keys, strings.TrimSpace(k))
+	}
+	retired := []string{oldKey}
+	if r := secret.Get(RetiredKeysEnvName); r != "" {
+		retired = append(strings.Split(r, ","), oldKey)
+	}
+
+	content := setEnvVar(string(orig), ethereum.PrivateKeysEnvName, strings.Join(keys, ","))
+	content = setEnvVar(content, RetiredKeysEnvName, strings.Join(retired, ","))
+	if err := fsutil.WriteAtomic(envFile, []byte(content), 0600); err != nil {
+		return nil, err
+	}
+	return func() error { return fsutil.WriteAtomic(envFile, orig, 0600) }, nil
+}
+
+// setEnvVar sets the value of a variable in the content of an env file
+// and keeps the rest of the line like a comment.
+// A missing variable is appended.
+func setEnvVar(content, name, value string) string {
+	re := regexp.MustCompile(`(?m)^([ \t]*(?:export[ \t]+)?` + regexp.QuoteMeta(name) + `[ \t]*=[ \t]*)("[^"]*"|'[^']*'|[^\s#]*)`)
+	if re.MatchString(content) {
+		return re.ReplaceAllStringFunc(content, func(m string) string {
+			return re.FindStringSubmatch(m)[1] + `"` + value + `"`
+		})
+	}
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content + name + `="` + value + `"` + "\n"
+}
+
+type keyWithdrawCmd struct {
+	Config  configPath `type:"existingfile" help:"path to config file"`
+	Account int        `arg:"" optional:"" help:"the account to move the withdrawn stake to"`
+}
+
+func (self keyWithdrawCmd) Run() error {
+	logger := logging.NewLogger()
+
+	cfg, err := config.ParseConfig(logger, string(self.Config))
+	if err != nil {
+		return errors.Wrap(err, "creating config")
+	}
+
+	ctx := context.Background()
+	client, accounts, err := createTellorVariables(ctx, logger, cfg.Ethereum)
+	if err != nil {
+		return errors.Wrap(err, "creating tellor variables")
+	}
+	account, err := getAccountFor(accounts, self.Account)
+	if err != nil {
+		return err
+	}
+	if secret.Get(RetiredKeysEnvName) == "" {
+		level.Info(logger).Log("msg", "no retired keys")
+		return nil
+	}
+	retired, err := ethereum.ParseAccounts(secret.Get(RetiredKeysEnvName))
+	if err != nil {
+		return errors.Wrap(err, "parsing the retired keys")
+	}
+	contract, err := newTellorContract(client, cfg.Contracts)
+	if err != nil {
+		return errors.Wrap(err, "create tellor contract instance")
+	}
+	staker, err := newStaker(ctx, client, contract)
+	if err != nil {
+		return err
+	}
+	for _, r := range retired {
+		err := KeyWithdraw(ctx, logger, client, contract, staker, r, account.Address, cfg.Transactor.Confirmations)
+		if err := auditCommand(logger, cfg.Db, "key_withdraw", r.Address.Hex(), err, "to", account.Address.Hex()); err != nil {
+			level.Error(logger).Log("msg", "withdrawing retired key", "addr", r.Address.Hex(), "err", err)
+		}
+	}
+	return nil
+}
+
+// KeyWithdraw withdraws the unlocked stake of a retired account
+// and transfers all its TRB to the given address.
+// The stake only moves between the own keys so it doesn't wait for the approvers.
+func KeyWithdraw(
+	ctx context.Context,
+	logger log.Logger,
+	client contracts.ETHClient,
+	contract token,
+	staker contracts.Staker,
+	retired *ethereum.Account,
+	to common.Address,
+	confirmations map[string]uint64,
+) error {
+	logger = log.With(logger, "retired", retired.Address.Hex())
+	status, startTime, err := staker.StakerInfo(ctx, retired.Address)
+	if err != nil {
+		return errors.Wrap(err, "get stake status")
+	}
+	switch status {
+	case 0:
+	case 1:
+		if err := RequestStakingWithdraw(ctx, logger, client, staker, retired, confirmations[transactor.FunctionRequestWithdraw], nil); err != nil {
+			return err
+		}
+		return nil
+	case 2:
+		if withdrawDelta(startTime) <= 0 {
+			printStakeStatus(logger, status, startTime)
+			return nil
+		}
+		if err := WithdrawStake(ctx, logger, client, staker, retired, confirmations[transactor.FunctionWithdrawStake], nil); err != nil {
+			return err
+		}
+	default:
+		printStakeStatus(logger, status, startTime)
+		return nil
+	}
+
+	balance, err := contract.BalanceOf(&bind.CallOpts{Context: ctx}, retired.Address)
+	if err != nil {
+		return errors.Wrap(err, "get TRB balance")
+	}
+	if balance.Sign() == 0 {
+		level.Info(logger).Log("msg", "nothing left to move, the key can be removed from the retired keys")
+		return nil
+	}
+	if err := transactAndWait(ctx, logger, client, retired, "transfer", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return contract.Transfer(auth, to, balance)
+	}); err != nil {
+		return err
+	}
+	level.Info(logger).Log("msg", "moved the stake of the retired key, it can be removed from the retired keys", "amount", format.ERC20Balance(balance), "to", to.Hex())
+	return nil
+}
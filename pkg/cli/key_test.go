@@ -0,0 +1,224 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/testutil"
+	"github.com/tellor-io/telliot/pkg/testutil/chain"
+)
+
+var testStake = big.NewInt(100)
+
+// fakeTellor keeps the TRB balances and the stakes of the legacy contract
+// which isn't deployed on the simulated chain.
+// Every call sends a transaction to the chain so that
+// the commands wait for their receipts like with the real contract.
+type fakeTellor struct {
+	chain *chain.Chain
+
+	mtx      sync.Mutex
+	balances map[common.Address]*big.Int
+	status   map[common.Address]int64
+	fail     map[string]bool
+}
+
+func newFakeTellor(c *chain.Chain) *fakeTellor {
+	return &fakeTellor{
+		chain:    c,
+		balances: make(map[common.Address]*big.Int),
+		status:   make(map[common.Address]int64),
+		fail:     make(map[string]bool),
+	}
+}
+
+func (self *fakeTellor) balance(addr common.Address) *big.Int {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	if b, ok := self.balances[addr]; ok {
+		return new(big.Int).Set(b)
+	}
+	return big.NewInt(0)
+}
+
+// send mines a transaction signed with the options in place of the contract call.
+func (self *fakeTellor) send(name string, opts *bind.TransactOpts) (*types.Transaction, error) {
+	if self.fail[name] {
+		return nil, errors.Errorf("%v reverted", name)
+	}
+	tx := types.NewTransaction(opts.Nonce.Uint64(), opts.From, big.NewInt(0), 21000, opts.GasPrice, nil)
+	signed, err := opts.Signer(opts.From, tx)
+	if err != nil {
+		return nil, err
+	}
+	return signed, self.chain.SendTransaction(context.Background(), signed)
+}
+
+func (self *fakeTellor) BalanceOf(_ *bind.CallOpts, addr common.Address) (*big.Int, error) {
+	return self.balance(addr), nil
+}
+
+func (self *fakeTellor) Transfer(opts *bind.TransactOpts, to common.Address, amount *big.Int) (*types.Transaction, error) {
+	self.mtx.Lock()
+	free := new(big.Int).Set(self.balances[opts.From])
+	if self.status[opts.From] != 0 {
+		free.Sub(free, testStake)
+	}
+	if free.Cmp(amount) < 0 {
+		self.mtx.Unlock()
+		return nil, errors.New("transfer reverted, not enough free balance")
+	}
+	self.balances[opts.From] = free.Sub(self.balances[opts.From], amount)
+	if self.balances[to] == nil {
+		self.balances[to] = big.NewInt(0)
+	}
+	self.balances[to] = new(big.Int).Add(self.balances[to], amount)
+	self.mtx.Unlock()
+	return self.send("transfer", opts)
+}
+
+func (self *fakeTellor) StakerInfo(_ context.Context, addr common.Address) (int64, time.Time, error) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	return self.status[addr], time.Time{}, nil
+}
+
+func (self *fakeTellor) StakeAmount(context.Context) (*big.Int, error) {
+	return testStake, nil
+}
+
+func (self *fakeTellor) setStatus(name string, opts *bind.TransactOpts, status int64) (*types.Transaction, error) {
+	tx, err := self.send(name, opts)
+	if err != nil {
+		return nil, err
+	}
+	self.mtx.Lock()
+	self.status[opts.From] = status
+	self.mtx.Unlock()
+	return tx, nil
+}
+
+func (self *fakeTellor) DepositStake(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return self.setStatus("deposit", opts, 1)
+}
+
+func (self *fakeTellor) RequestStakingWithdraw(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return self.setStatus("request", opts, 2)
+}
+
+func (self *fakeTellor) WithdrawStake(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return self.setStatus("withdraw", opts, 0)
+}
+
+func hexKey(account *ethereum.Account) string {
+	return fmt.Sprintf("%x", crypto.FromECDSA(account.PrivateKey))
+}
+
+// newRotation returns a chain with a staked old account and an unstaked new account
+// and an env file with the key of the old account.
+func newRotation(t *testing.T) (*chain.Chain, *fakeTellor, string, func()) {
+	c, err := chain.New(2)
+	testutil.Ok(t, err)
+	tellor := newFakeTellor(c)
+	tellor.balances[c.Accounts[0].Address] = big.NewInt(250)
+	tellor.status[c.Accounts[0].Address] = 1
+
+	dir, err := ioutil.TempDir("", "rotate")
+	testutil.Ok(t, err)
+	envFile := filepath.Join(dir, ".env")
+	testutil.Ok(t, ioutil.WriteFile(envFile, []byte(`ETH_PRIVATE_KEYS="`+hexKey(c.Accounts[0])+`" # the miner`+"\n"), 0600))
+	testutil.Ok(t, os.Setenv(ethereum.PrivateKeysEnvName, hexKey(c.Accounts[0])))
+	testutil.Ok(t, os.Unsetenv(RetiredKeysEnvName))
+
+	return c, tellor, envFile, func() {
+		c.Close()
+		os.RemoveAll(dir)
+		os.Unsetenv(ethereum.PrivateKeysEnvName)
+	}
+}
+
+// TestKeyRotate ensures that the new account is funded and staked,
+// the old key is retired and the old stake is unlocking.
+func TestKeyRotate(t *testing.T) {
+	c, tellor, envFile, cleanup := newRotation(t)
+	defer cleanup()
+	oldAccount, newAccount := c.Accounts[0], c.Accounts[1]
+
+	testutil.Ok(t, KeyRotate(context.Background(), log.NewNopLogger(), c, tellor, tellor, oldAccount, newAccount, envFile, nil))
+
+	testutil.Equals(t, big.NewInt(150), tellor.balance(oldAccount.Address))
+	testutil.Equals(t, big.NewInt(100), tellor.balance(newAccount.Address))
+	testutil.Equals(t, int64(2), tellor.status[oldAccount.Address])
+	testutil.Equals(t, int64(1), tellor.status[newAccount.Address])
+
+	env, err := ioutil.ReadFile(envFile)
+	testutil.Ok(t, err)
+	testutil.Equals(t,
+		`ETH_PRIVATE_KEYS="`+hexKey(newAccount)+`" # the miner`+"\n"+RetiredKeysEnvName+`="`+hexKey(oldAccount)+`"`+"\n",
+		string(env),
+	)
+}
+
+// TestKeyRotateRollback ensures that the steps before a failed step are rolled back
+// and that the steps which can't be rolled back are reported.
+func TestKeyRotateRollback(t *testing.T) {
+	t.Run("stake", func(t *testing.T) {
+		c, tellor, envFile, cleanup := newRotation(t)
+		defer cleanup()
+		oldAccount, newAccount := c.Accounts[0], c.Accounts[1]
+		origEnv, err := ioutil.ReadFile(envFile)
+		testutil.Ok(t, err)
+
+		tellor.fail["deposit"] = true
+		err = KeyRotate(context.Background(), log.NewNopLogger(), c, tellor, tellor, oldAccount, newAccount, envFile, nil)
+		testutil.NotOk(t, err)
+		testutil.Assert(t, !strings.Contains(err.Error(), "left in place"), "all steps should be rolled back:%v", err)
+
+		// The funding is transferred back.
+		testutil.Equals(t, big.NewInt(250), tellor.balance(oldAccount.Address))
+		testutil.Equals(t, big.NewInt(0), tellor.balance(newAccount.Address))
+		testutil.Equals(t, int64(1), tellor.status[oldAccount.Address])
+		env, err := ioutil.ReadFile(envFile)
+		testutil.Ok(t, err)
+		testutil.Equals(t, string(origEnv), string(env))
+	})
+
+	t.Run("withdraw request", func(t *testing.T) {
+		c, tellor, envFile, cleanup := newRotation(t)
+		defer cleanup()
+		oldAccount, newAccount := c.Accounts[0], c.Accounts[1]
+		origEnv, err := ioutil.ReadFile(envFile)
+		testutil.Ok(t, err)
+
+		tellor.fail["request"] = true
+		err = KeyRotate(context.Background(), log.NewNopLogger(), c, tellor, tellor, oldAccount, newAccount, envFile, nil)
+		testutil.NotOk(t, err)
+		testutil.Assert(t, strings.Contains(err.Error(), "the steps left in place:fund the new account, stake the new account"), "the stake should be reported:%v", err)
+
+		// The env file is restored while the new stake stays locked.
+		env, err := ioutil.ReadFile(envFile)
+		testutil.Ok(t, err)
+		testutil.Equals(t, string(origEnv), string(env))
+		testutil.Equals(t, big.NewInt(100), tellor.balance(newAccount.Address))
+		testutil.Equals(t, int64(1), tellor.status[newAccount.Address])
+		testutil.Equals(t, int64(1), tellor.status[oldAccount.Address])
+	})
+}
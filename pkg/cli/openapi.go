@@ -0,0 +1,205 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package cli
+
+import (
+	"github.com/tellor-io/telliot/pkg/aggregator"
+	"github.com/tellor-io/telliot/pkg/approval"
+	"github.com/tellor-io/telliot/pkg/attestation"
+	"github.com/tellor-io/telliot/pkg/crosscheck"
+	"github.com/tellor-io/telliot/pkg/db"
+	"github.com/tellor-io/telliot/pkg/gateway"
+	"github.com/tellor-io/telliot/pkg/indexer"
+	"github.com/tellor-io/telliot/pkg/mining"
+	"github.com/tellor-io/telliot/pkg/psr"
+	"github.com/tellor-io/telliot/pkg/reputation"
+	"github.com/tellor-io/telliot/pkg/submitter"
+	"github.com/tellor-io/telliot/pkg/submitter/tellor"
+	"github.com/tellor-io/telliot/pkg/tracker/disputeHistory"
+	"github.com/tellor-io/telliot/pkg/tracker/race"
+	"github.com/tellor-io/telliot/pkg/transactor"
+	"github.com/tellor-io/telliot/pkg/web"
+)
+
+// The operations of the handlers registered by the roles for the OpenAPI document.
+// These live here as the web package can't import the packages of the handlers.
+var (
+	opDashboards = web.Operation{
+		Summary:  "List the Grafana dashboards.",
+		Response: []string{},
+	}
+	opDashboard = web.Operation{
+		Summary: "Get a Grafana dashboard as it is imported.",
+		Params: []web.Param{
+			{Name: "name", Description: "The dashboard name.", Required: true},
+			{Name: "datasource", Description: "The Prometheus datasource that scrapes the metrics."},
+			{Name: "db", Description: "The datasource of an instance with a local DB, defaults to the datasource."},
+		},
+		Response: map[string]interface{}{},
+		Raw:      true,
+	}
+	opGasSummary = web.Operation{
+		Summary:  "Summarize the gas spent by the transactions of each account.",
+		Params:   []web.Param{{Name: "window", Description: "The summary duration e.g. 24h.", Required: true}},
+		Response: []transactor.GasStats{},
+	}
+	opGasRecord = web.Operation{
+		Summary: "Record the gas of a transaction sent by a CLI command.",
+		Request: transactor.GasRecord{},
+	}
+	opPending = web.Operation{
+		Summary:  "List the in-flight transactions.",
+		Response: []transactor.Pending{},
+	}
+	opBump = web.Operation{
+		Summary:  "Replace a stuck transaction with a higher gas price.",
+		Request:  transactor.ReplaceRequest{},
+		Response: transactor.Pending{},
+	}
+	opCancel = web.Operation{
+		Summary:  "Cancel a stuck transaction with an empty transaction at the same nonce.",
+		Request:  transactor.ReplaceRequest{},
+		Response: transactor.Pending{},
+	}
+	opApprovals = web.Operation{
+		Summary:  "List the operations waiting for the approvers.",
+		Response: []approval.Request{},
+	}
+	opApprove = web.Operation{
+		Summary:  "Add the signature of an approver to an operation.",
+		Request:  approval.ApproveRequest{},
+		Response: approval.Request{},
+	}
+	opOracleTellor = web.Operation{
+		Summary: "Get the current on-chain values of the Tellor oracle.",
+		Params: []web.Param{
+			{Name: "id", Description: "The request IDs, repeated or separated by commas, the configured ones when empty."},
+		},
+		Response: []gateway.Value{},
+	}
+	opTimeWeightedAvg = web.Operation{
+		Summary: "Get the time weighted average of a symbol.",
+		Params: []web.Param{
+			{Name: "symbol", Description: "The symbol e.g. TRB/ETH.", Required: true},
+			{Name: "ts", Description: "The end unix timestamp.", Required: true},
+			{Name: "lookBack", Description: "The averaged duration e.g. 1h.", Required: true},
+		},
+		Response: aggregator.TimeWeightedAvgResponse{},
+	}
+	opPsrTellor = web.Operation{
+		Summary:  "Get the value of a data ID for the Tellor contract.",
+		Params:   psrParams,
+		Response: psr.Response{},
+	}
+	opPsrTellorAccess = web.Operation{
+		Summary:  "Get the value of a data ID for the TellorAccess contract.",
+		Params:   psrParams,
+		Response: psr.Response{},
+	}
+	psrParams = []web.Param{
+		{Name: "id", Description: "The data ID.", Required: true},
+		{Name: "ts", Description: "The unix timestamp, defaults to now."},
+	}
+	opRaces = web.Operation{
+		Summary: "List the tracked submission races.",
+		Params: []web.Param{
+			{Name: "account", Description: "Only the races of this account."},
+			{Name: "result", Description: "Only the races with this result: landed, lost or skipped."},
+		},
+		Response: []race.Race{},
+	}
+	opCrossCheck = web.Operation{
+		Summary:  "List the last comparison of each request ID with the other oracles.",
+		Response: []crosscheck.Status{},
+	}
+	opCrossCheckAllow = web.Operation{
+		Summary: "Allow the values of a request ID that disagree with the other oracles for a duration.",
+		Request: crosscheck.AllowRequest{},
+	}
+	opAttestations = web.Operation{
+		Summary:  "List the latest signed PSR values with their statements, newest first.",
+		Params:   []web.Param{{Name: "id", Description: "Only the values of this request ID."}},
+		Response: []attestation.Attestation{},
+	}
+	opDisputes = web.Operation{
+		Summary: "List the history of the disputes with their votes and outcomes.",
+		Params: []web.Param{
+			{Name: "miner", Description: "Only the disputes against this miner."},
+			{Name: "disputer", Description: "Only the disputes opened by this address."},
+			{Name: "id", Description: "Only the disputes of this request ID."},
+			{Name: "outcome", Description: "Only the disputes with this outcome, one of open, passed, failed."},
+		},
+		Response: []disputeHistory.Dispute{},
+	}
+	opDisputeStats = web.Operation{
+		Summary:  "Summarize the disputes per disputer, miner and request ID and the weekly disputes against the accounts.",
+		Params:   []web.Param{{Name: "window", Description: "Only the disputes opened within this duration e.g. 720h, all when empty."}},
+		Response: disputeHistory.Stats{},
+	}
+	opEvents = web.Operation{
+		Summary: "Query the indexed submit, dispute and reward events in block order.",
+		Params: []web.Param{
+			{Name: "event", Description: "The event name e.g. NonceSubmitted, NewDispute or Transfer."},
+			{Name: "address", Description: "The miner of the submits and disputes or the receiver of the rewards."},
+			{Name: "id", Description: "The request ID."},
+			{Name: "fromBlock", Description: "The first block."},
+			{Name: "toBlock", Description: "The last block."},
+			{Name: "from", Description: "The first unix timestamp."},
+			{Name: "to", Description: "The last unix timestamp."},
+			{Name: "offset", Description: "The number of the matching events to skip."},
+			{Name: "limit", Description: "The max number of events, Indexer.MaxResults when empty."},
+		},
+		Response: indexer.QueryResponse{},
+	}
+	opReputation = web.Operation{
+		Summary:  "List the reputation scores of the miners with their dispute thresholds, the worst first.",
+		Params:   []web.Param{{Name: "miner", Description: "Only the score of this miner."}},
+		Response: []reputation.Score{},
+	}
+	opSubmissions = web.Operation{
+		Summary: "List the submissions in the journal.",
+		Params: []web.Param{
+			{Name: "account", Description: "Only the submissions of this account."},
+			{Name: "state", Description: "Only the submissions in this state."},
+		},
+		Response: []db.Submission{},
+	}
+	opBreakers = web.Operation{
+		Summary:  "List the circuit breaker status of each account.",
+		Response: []submitter.BreakerStatus{},
+	}
+	opBreakerReset = web.Operation{
+		Summary:  "Reset a tripped circuit breaker and resume the submissions.",
+		Params:   []web.Param{{Name: "account", Description: "The account of the breaker.", Required: true}},
+		Response: submitter.BreakerStatus{},
+	}
+	opRequests = web.Operation{
+		Summary:  "List the excluded, overridden and paused requests in effect.",
+		Response: tellor.RequestsStatus{},
+	}
+	opPause = web.Operation{
+		Summary: "Pause the submissions of a request ID or of all for a time window.",
+		Params: []web.Param{
+			{Name: "id", Description: "The request ID, 0 or none for all."},
+			{Name: "for", Description: "The duration of the pause e.g. 2h.", Required: true},
+			{Name: "from", Description: "The RFC3339 start time, defaults to now."},
+			{Name: "reason", Description: "Why the submissions are paused."},
+		},
+		Response: tellor.RequestsStatus{},
+	}
+	opResume = web.Operation{
+		Summary:  "Remove the pauses of a request ID, 0 or none for the global ones.",
+		Params:   []web.Param{{Name: "id", Description: "The request ID."}},
+		Response: tellor.RequestsStatus{},
+	}
+	opWork = web.Operation{
+		Summary:  "Get the current mining work of an account.",
+		Params:   []web.Param{{Name: "account", Description: "The account address.", Required: true}},
+		Response: mining.Work{},
+	}
+	opSolution = web.Operation{
+		Summary: "Send a mining solution to the submitter of the account.",
+		Request: mining.Result{},
+	}
+)
@@ -0,0 +1,84 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/config"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/submitter/tellor"
+)
+
+type replayCmd struct {
+	Log                string     `arg:"" type:"existingfile" help:"the replay log of the submitter"`
+	Config             configPath `type:"existingfile" help:"replay with the decision config of the config file instead of the recorded one"`
+	Account            string     `help:"only the decisions of the account"`
+	Challenge          string     `help:"only the decisions of the challenge"`
+	ProfitThreshold    int64      `default:"-1" help:"replay with this profit threshold instead of the recorded one"`
+	MinSlotProbability float64    `default:"-1" help:"replay with this min slot probability instead of the recorded one"`
+	Diff               bool       `help:"only print the decisions that changed"`
+}
+
+func (self replayCmd) Run() error {
+	records, err := tellor.ReadReplayLog(self.Log)
+	if err != nil {
+		return err
+	}
+
+	var fromConfig *tellor.DecisionConfig
+	if self.Config != "" {
+		cfg, err := config.ParseConfig(logging.NewLogger(), string(self.Config))
+		if err != nil {
+			return errors.Wrap(err, "creating config")
+		}
+		fromConfig = &tellor.DecisionConfig{
+			ProfitThreshold:    cfg.SubmitterTellor.ProfitThreshold,
+			MinSlotProbability: cfg.SubmitterTellor.MinSlotProbability,
+		}
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "TIME\tACCOUNT\tCHALLENGE\tRECORDED\tREPLAYED\tREASON\n")
+	var count, changed int
+	for _, r := range records {
+		if self.Account != "" && !strings.EqualFold(self.Account, r.Account) {
+			continue
+		}
+		if self.Challenge != "" && !strings.HasPrefix(r.Challenge, strings.TrimPrefix(self.Challenge, "0x")) {
+			continue
+		}
+		cfg := r.Config
+		if fromConfig != nil {
+			cfg = *fromConfig
+		}
+		if self.ProfitThreshold >= 0 {
+			cfg.ProfitThreshold = uint64(self.ProfitThreshold)
+		}
+		if self.MinSlotProbability >= 0 {
+			cfg.MinSlotProbability = self.MinSlotProbability
+		}
+
+		d := r.Replay(cfg)
+		count++
+		if d != r.Decision {
+			changed++
+		} else if self.Diff {
+			continue
+		}
+		challenge := r.Challenge
+		if len(challenge) > 16 {
+			challenge = challenge[:16]
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", r.Time.Format(time.RFC3339), r.Account, challenge, r.Decision.Action, d.Action, d.Reason)
+	}
+	fmt.Fprintln(tw)
+	fmt.Fprintf(tw, "replayed %d decisions, %d changed\n", count, changed)
+	return tw.Flush()
+}
@@ -0,0 +1,396 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/tellor-io/telliot/pkg/aggregator"
+	"github.com/tellor-io/telliot/pkg/config"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/db"
+	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/plugin"
+	psrTellor "github.com/tellor-io/telliot/pkg/psr/tellor"
+	"github.com/tellor-io/telliot/pkg/registry"
+	"github.com/tellor-io/telliot/pkg/submitter"
+	"github.com/tellor-io/telliot/pkg/tracker/index"
+)
+
+// selftestTimeout limits how long a single check can take.
+const selftestTimeout = time.Minute
+
+// The results of a self-test check.
+const (
+	selftestPass = "pass"
+	selftestFail = "fail"
+	// selftestSkip is for the checks that can't run because an earlier check failed
+	// or that don't apply to the setup.
+	selftestSkip = "skip"
+)
+
+type selftestCmd struct {
+	Config configPath `type:"existingfile" help:"path to config file"`
+	IDs    []int64    `name:"id" help:"request IDs to check the PSR values of, defaults to the IDs of the current challenge"`
+	JSON   bool       `name:"json" help:"print as JSON"`
+}
+
+// SelftestCheck is the result of a single self-test check.
+type SelftestCheck struct {
+	Name   string
+	Result string
+	Detail string `json:",omitempty"`
+}
+
+type selftest struct {
+	checks []SelftestCheck
+}
+
+func (self *selftest) add(name string, err error, detail string) bool {
+	check := SelftestCheck{Name: name, Result: selftestPass, Detail: detail}
+	if err != nil {
+		check.Result = selftestFail
+		check.Detail = err.Error()
+	}
+	self.checks = append(self.checks, check)
+	return err == nil
+}
+
+func (self *selftest) skip(name, reason string) {
+	self.checks = append(self.checks, SelftestCheck{Name: name, Result: selftestSkip, Detail: reason})
+}
+
+func (self *selftest) failed() int {
+	var failed int
+	for _, c := range self.checks {
+		if c.Result == selftestFail {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Run checks the full pipeline from the node connection to a simulated submission
+// without sending any transaction and prints a pass/fail report.
+// It returns an error when any of the checks failed.
+func (self selftestCmd) Run() error {
+	logger := logging.NewLogger()
+
+	cfg, err := config.ParseConfig(logger, string(self.Config))
+	if err != nil {
+		return errors.Wrap(err, "creating config")
+	}
+
+	report := &selftest{}
+	self.run(logger, cfg, report)
+
+	if self.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report.checks); err != nil {
+			return err
+		}
+	} else if err := printSelftest(os.Stdout, report); err != nil {
+		return err
+	}
+	if failed := report.failed(); failed > 0 {
+		return errors.Errorf("%v of %v checks failed", failed, len(report.checks))
+	}
+	return nil
+}
+
+func (self selftestCmd) run(logger log.Logger, cfg *config.Config, report *selftest) {
+	ctx, cncl := context.WithTimeout(context.Background(), selftestTimeout)
+	defer cncl()
+
+	// RPC connectivity and chain ID.
+	client, err := createClient(ctx, logger, cfg.Ethereum)
+	var netID *big.Int
+	if err == nil {
+		netID, err = client.NetworkID(ctx)
+	}
+	var head *types.Header
+	if err == nil {
+		head, err = client.HeaderByNumber(ctx, nil)
+	}
+	if !report.add("rpc", err, fmt.Sprintf("chain ID:%v, block:%v", netID, blockNumber(head))) {
+		for _, name := range []string{"contracts", "accounts", "stake", "index", "psr", "submit"} {
+			report.skip(name, "no connection to the node")
+		}
+		return
+	}
+	defer client.Close()
+
+	// Contract addresses.
+	contract, err := newTellorContract(client, cfg.Contracts)
+	var caps *contracts.Capabilities
+	if err == nil {
+		caps, err = contracts.NewCapabilities(ctx, client, contract.Address)
+	}
+	contractsOk := report.add("contracts", err, contractsDetail(ctx, client, caps))
+
+	// Account keys.
+	accounts, err := ethereum.GetAccounts()
+	if err == nil {
+		err = checkAccounts(accounts, netID)
+	}
+	accountsOk := report.add("accounts", err, fmt.Sprintf("%v accounts", len(accounts)))
+
+	// Stake status.
+	switch {
+	case !contractsOk || !accountsOk:
+		report.skip("stake", "the contracts or the accounts check failed")
+	default:
+		report.add("stake", checkStake(ctx, caps, accounts), "all accounts are staked")
+	}
+
+	// Index sources.
+	plugins, err := plugin.Load(logger, cfg.Plugins)
+	if err != nil {
+		report.add("index", errors.Wrap(err, "loading the plugins"), "")
+	} else {
+		self.checkIndex(ctx, cfg, client, plugins, report)
+	}
+
+	// PSR values.
+	ids := self.IDs
+	if len(ids) == 0 && contractsOk && caps.Version == contracts.VersionLegacy {
+		vars, err := contract.GetNewCurrentVariables(&bind.CallOpts{Context: ctx})
+		if err != nil {
+			report.add("psr", errors.Wrap(err, "getting the request IDs of the current challenge"), "")
+			report.skip("submit", "no request IDs")
+			return
+		}
+		for _, id := range vars.RequestIds {
+			ids = append(ids, id.Int64())
+		}
+	}
+	if len(ids) == 0 {
+		report.skip("psr", "no request IDs, set them with --id")
+		report.skip("submit", "no request IDs")
+		return
+	}
+	values, ok := self.checkPsr(ctx, logger, cfg, plugins, ids, report)
+
+	// Simulated submission.
+	switch {
+	case !contractsOk || !accountsOk:
+		report.skip("submit", "the contracts or the accounts check failed")
+	case !ok:
+		report.skip("submit", "not all request IDs have a PSR value")
+	default:
+		checkSubmit(ctx, client, caps, accounts, ids, values, report)
+	}
+}
+
+func blockNumber(head *types.Header) *big.Int {
+	if head == nil {
+		return nil
+	}
+	return head.Number
+}
+
+// contractsDetail lists the resolved contract addresses.
+func contractsDetail(ctx context.Context, client contracts.ETHClient, caps *contracts.Capabilities) string {
+	if caps == nil {
+		return ""
+	}
+	detail := fmt.Sprintf("version:%v master:%v", caps.Version, caps.Address.Hex())
+	if caps.Version == contracts.VersionTellorFlex {
+		return detail
+	}
+	if addrs, err := contracts.Discover(ctx, client, caps.Address); err == nil {
+		detail += fmt.Sprintf(" implementation:%v", addrs.Implementation.Hex())
+	}
+	return detail
+}
+
+// checkAccounts signs a transaction with the key of every account
+// and checks that it recovers to the account address.
+func checkAccounts(accounts []*ethereum.Account, chainID *big.Int) error {
+	for _, account := range accounts {
+		if account.ReadOnly() {
+			continue
+		}
+		tx := types.NewTransaction(0, account.Address, big.NewInt(0), 21000, big.NewInt(0), nil)
+		signed, err := types.SignTx(tx, types.NewEIP155Signer(chainID), account.PrivateKey)
+		if err != nil {
+			return errors.Wrapf(err, "signing with account:%v", account.Address.Hex())
+		}
+		sender, err := types.Sender(types.NewEIP155Signer(chainID), signed)
+		if err != nil {
+			return errors.Wrapf(err, "recovering the signer of account:%v", account.Address.Hex())
+		}
+		if sender != account.Address {
+			return errors.Errorf("the key of account:%v signs as:%v", account.Address.Hex(), sender.Hex())
+		}
+	}
+	return nil
+}
+
+func checkStake(ctx context.Context, caps *contracts.Capabilities, accounts []*ethereum.Account) error {
+	staker, err := caps.Staker()
+	if err != nil {
+		return err
+	}
+	var notStaked []string
+	for _, account := range accounts {
+		status, _, err := staker.StakerInfo(ctx, account.Address)
+		if err != nil {
+			return errors.Wrapf(err, "getting the stake status of account:%v", account.Address.Hex())
+		}
+		if status != 1 {
+			notStaked = append(notStaked, account.Address.Hex()+":"+contracts.StakerStatusName(status))
+		}
+	}
+	if len(notStaked) > 0 {
+		return errors.Errorf("not staked %v", strings.Join(notStaked, ", "))
+	}
+	return nil
+}
+
+// checkIndex adds a check for every index source.
+func (self selftestCmd) checkIndex(ctx context.Context, cfg *config.Config, client contracts.ETHClient, plugins *plugin.Plugins, report *selftest) {
+	results, err := index.Probe(ctx, cfg.IndexTracker, client, plugins)
+	if err != nil {
+		report.add("index", err, "")
+		return
+	}
+	for _, r := range results {
+		report.add("index:"+r.Symbol+":"+r.Source, r.Err, fmt.Sprintf("value:%v", r.Value))
+	}
+}
+
+// checkPsr adds a check for the PSR value of every request ID
+// from the local DB or the remote DB when configured.
+// It returns the values and whether all request IDs have a value.
+func (self selftestCmd) checkPsr(ctx context.Context, logger log.Logger, cfg *config.Config, plugins *plugin.Plugins, ids []int64, report *selftest) ([]*big.Int, bool) {
+	if plugins == nil {
+		report.skip("psr", "the plugins failed to load")
+		return nil, false
+	}
+
+	var querable storage.SampleAndChunkQueryable
+	if cfg.Db.RemoteHost != "" {
+		remote, err := remoteDB(cfg.Db)
+		if err != nil {
+			report.add("psr", errors.Wrap(err, "opening remote tsdb DB"), "")
+			return nil, false
+		}
+		querable = remote
+	} else {
+		// Read only so that it can run next to a running instance.
+		_tsDB, err := db.OpenReadOnly(logger, cfg.Db)
+		if err != nil {
+			report.add("psr", errors.Wrap(err, "opening local tsdb DB"), "")
+			return nil, false
+		}
+		defer _tsDB.Close()
+		querable = _tsDB
+	}
+	aggr, err := aggregator.New(logger, ctx, cfg.Aggregator, querable)
+	if err != nil {
+		report.add("psr", errors.Wrap(err, "creating aggregator"), "")
+		return nil, false
+	}
+	reg, err := registry.New(logger, cfg.Registry)
+	if err != nil {
+		report.add("psr", errors.Wrap(err, "creating request ID registry"), "")
+		return nil, false
+	}
+	psr := psrTellor.New(logger, cfg.PsrTellor, aggr, reg)
+	if err := psr.SetPlugins(plugins); err != nil {
+		report.add("psr", errors.Wrap(err, "creating the plugin aggregations"), "")
+		return nil, false
+	}
+
+	ok := true
+	values := make([]*big.Int, len(ids))
+	now := time.Now()
+	for i, id := range ids {
+		val, err := psr.GetValue(id, now)
+		values[i] = val
+		if !report.add(fmt.Sprintf("psr:%v", id), err, fmt.Sprintf("value:%v", val)) {
+			ok = false
+		}
+	}
+	return values, ok
+}
+
+// checkSubmit simulates a submission of the values from every account with a key.
+// The proof of work is not solved so a call rejected only because of the nonce is skipped.
+func checkSubmit(ctx context.Context, client contracts.ETHClient, caps *contracts.Capabilities, accounts []*ethereum.Account, ids []int64, values []*big.Int, report *selftest) {
+	miningSubmitter, err := caps.MiningSubmitter()
+	if err != nil {
+		report.skip("submit", "only the mining submissions are simulated: "+err.Error())
+		return
+	}
+	if len(ids) != 5 {
+		report.skip("submit", fmt.Sprintf("a mining submission needs 5 request IDs, got:%v", len(ids)))
+		return
+	}
+	var reqIDs, reqVals [5]*big.Int
+	for i := range ids {
+		reqIDs[i] = big.NewInt(ids[i])
+		reqVals[i] = values[i]
+	}
+
+	for _, account := range accounts {
+		name := "submit:" + account.Address.Hex()
+		if account.ReadOnly() {
+			report.skip(name, "read-only account")
+			continue
+		}
+		auth := &bind.TransactOpts{
+			From:     account.Address,
+			Context:  ctx,
+			GasPrice: big.NewInt(0),
+			GasLimit: 3000000,
+			NoSend:   true,
+			Signer: func(_ common.Address, tx *types.Transaction) (*types.Transaction, error) {
+				return tx, nil
+			},
+		}
+		tx, err := miningSubmitter.SubmitMiningSolution(auth, "selftest", reqIDs, reqVals)
+		if err == nil {
+			err = submitter.Simulate(ctx, client, account.Address, tx)
+		}
+		if err != nil {
+			reason := submitter.DecodeRevert(err)
+			if strings.Contains(strings.ToLower(reason), "nonce") {
+				report.skip(name, "the call reached the proof of work check: "+reason)
+				continue
+			}
+			report.add(name, errors.New(reason), "")
+			continue
+		}
+		report.add(name, nil, "the submission succeeds against the latest state")
+	}
+}
+
+// printSelftest writes the report as a human readable table.
+func printSelftest(w io.Writer, report *selftest) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "CHECK\tRESULT\tDETAIL\n")
+	for _, c := range report.checks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", c.Name, strings.ToUpper(c.Result), c.Detail)
+	}
+	fmt.Fprintf(tw, "\n%v checks, %v failed\n", len(report.checks), report.failed())
+	return tw.Flush()
+}
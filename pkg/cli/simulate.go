@@ -0,0 +1,81 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package cli
+
+import (
+	"context"
+	"math/big"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/config"
+	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/simulation"
+)
+
+type simulateCmd struct {
+	Config configPath `type:"existingfile" help:"path to config file"`
+	Role   string     `enum:"all,tracker,aggregator,submitter,miner" default:"all" help:"run only the components of this role(all,tracker,aggregator,submitter,miner)"`
+}
+
+// Run starts or attaches to a fork, points the node URL to it
+// and runs the full mining pipeline against it.
+func (self simulateCmd) Run() error {
+	logger := logging.NewLogger()
+
+	cfg, err := config.ParseConfig(logger, string(self.Config))
+	if err != nil {
+		return errors.Wrap(err, "creating config")
+	}
+
+	ctx, cncl := context.WithCancel(context.Background())
+	defer cncl()
+	fork, err := simulation.New(logger, ctx, cfg.Simulation)
+	if err != nil {
+		return errors.Wrap(err, "creating fork")
+	}
+	if err := fork.Launch(); err != nil {
+		fork.Stop()
+		return err
+	}
+	defer fork.Stop()
+
+	// The env file doesn't override already set env vars
+	// so all components connect to the fork.
+	if err := os.Setenv(ethereum.NodeURLEnvName, fork.URL()); err != nil {
+		return errors.Wrap(err, "setting the node url")
+	}
+
+	_, accounts, err := createTellorVariables(ctx, logger, cfg.Ethereum)
+	if err != nil {
+		return errors.Wrap(err, "creating tellor variables")
+	}
+	if cfg.Simulation.Balance > 0 {
+		balance := new(big.Int).Mul(big.NewInt(int64(cfg.Simulation.Balance)), big.NewInt(1e18))
+		for _, account := range accounts {
+			if err := fork.SetBalance(ctx, account.Address, balance); err != nil {
+				return errors.Wrapf(err, "funding account:%v", account.Address.String())
+			}
+		}
+	}
+
+	seed := cfg.Simulation.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	rand.Seed(seed)
+
+	go func() {
+		if err := fork.Start(); err != nil {
+			level.Error(logger).Log("msg", "fast forwarding", "err", err)
+		}
+	}()
+
+	level.Info(logger).Log("msg", "running the pipeline against the fork", "url", fork.URL(), "seed", seed)
+	return mineCmd{Config: self.Config, Role: self.Role, chainTime: true}.Run()
+}
@@ -8,30 +8,54 @@ import (
 	"math/big"
 	"time"
 
-	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/approval"
 	"github.com/tellor-io/telliot/pkg/contracts"
 	"github.com/tellor-io/telliot/pkg/ethereum"
 	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/transactor"
 )
 
-func printStakeStatus(logger log.Logger, bigStatus *big.Int, started *big.Int) {
+// token is the part of the TRB token used to move and stake the TRB of the accounts.
+type token interface {
+	BalanceOf(opts *bind.CallOpts, account common.Address) (*big.Int, error)
+	Transfer(opts *bind.TransactOpts, to common.Address, amount *big.Int) (*types.Transaction, error)
+}
+
+// newTellorContract creates the contract instance for the configured master address.
+func newTellorContract(client contracts.ETHClient, cfg contracts.Config) (*contracts.ITellor, error) {
+	if cfg.Address == "" {
+		return contracts.NewITellor(client)
+	}
+	if !common.IsHexAddress(cfg.Address) {
+		return nil, errors.Errorf("invalid contract address:%v", cfg.Address)
+	}
+	return contracts.NewITellorAt(client, common.HexToAddress(cfg.Address))
+}
+
+// newStaker returns the staking implementation for the deployed contract version.
+func newStaker(ctx context.Context, client contracts.ETHClient, contract *contracts.ITellor) (contracts.Staker, error) {
+	caps, err := contracts.NewCapabilities(ctx, client, contract.Address)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting the contract capabilities")
+	}
+	return caps.Staker()
+}
+
+func printStakeStatus(logger log.Logger, status int64, stakeTime time.Time) {
 	// 0-not Staked, 1=Staked, 2=LockedForWithdraw 3= OnDispute
-	status := bigStatus.Uint64()
-	stakeTime := time.Unix(started.Int64(), 0)
 	switch status {
 	case 0:
 		level.Info(logger).Log("msg", "not currently staked")
 	case 1:
 		level.Info(logger).Log("msg", "staked in good standing since", "UTC", stakeTime.UTC())
 	case 2:
-		startedRound := started.Int64()
-		startedRound = ((startedRound + 86399) / 86400) * 86400
-		target := time.Unix(startedRound, 0)
-		timePassed := time.Since(target)
-		delta := timePassed - (time.Hour * 24 * 7)
+		delta := withdrawDelta(stakeTime)
 		if delta > 0 {
 			level.Info(logger).Log("msg", "stake has been eligbile to withdraw for", "delta", delta)
 		} else {
@@ -42,12 +66,24 @@ func printStakeStatus(logger log.Logger, bigStatus *big.Int, started *big.Int) {
 	}
 }
 
+// withdrawDelta returns how long the stake has been eligible to withdraw
+// or how long until it is eligible when negative.
+func withdrawDelta(stakeTime time.Time) time.Duration {
+	startedRound := stakeTime.Unix()
+	startedRound = ((startedRound + 86399) / 86400) * 86400
+	target := time.Unix(startedRound, 0)
+	timePassed := time.Since(target)
+	return timePassed - (time.Hour * 24 * 7)
+}
+
 func Deposit(
 	ctx context.Context,
 	logger log.Logger,
 	client contracts.ETHClient,
-	contract *contracts.ITellor,
+	contract token,
+	staker contracts.Staker,
 	account *ethereum.Account,
+	confirmations uint64,
 ) error {
 
 	balance, err := contract.BalanceOf(nil, account.Address)
@@ -55,20 +91,17 @@ func Deposit(
 		return errors.Wrap(err, "get TRB balance")
 	}
 
-	status, startTime, err := contract.GetStakerInfo(nil, account.Address)
+	status, startTime, err := staker.StakerInfo(ctx, account.Address)
 	if err != nil {
 		return errors.Wrap(err, "get stake status")
 	}
 
-	if status.Uint64() != 0 && status.Uint64() != 2 {
+	if status != 0 && status != 2 {
 		printStakeStatus(logger, status, startTime)
 		return nil
 	}
 
-	dat := crypto.Keccak256([]byte("_STAKE_AMOUNT"))
-	var dat32 [32]byte
-	copy(dat32[:], dat)
-	stakeAmt, err := contract.GetUintVar(nil, dat32)
+	stakeAmt, err := staker.StakeAmount(ctx)
 	if err != nil {
 		return errors.Wrap(err, "fetching stake amount")
 	}
@@ -84,22 +117,22 @@ func Deposit(
 		return errors.Wrap(err, "prepare ethereum transaction")
 	}
 
-	tx, err := contract.DepositStake(auth)
+	tx, err := staker.DepositStake(auth)
 	if err != nil {
 		return errors.Wrap(err, "contract failed")
 	}
 	level.Info(logger).Log("msg", "stake depositied", "txHash", tx.Hash().Hex())
-	return nil
+	return waitFinalized(ctx, logger, client, tx, confirmations)
 }
 
 func ShowStatus(
 	ctx context.Context,
 	logger log.Logger,
 	client contracts.ETHClient,
-	contract *contracts.ITellor,
+	staker contracts.Staker,
 	account *ethereum.Account,
 ) error {
-	status, startTime, err := contract.GetStakerInfo(nil, account.Address)
+	status, startTime, err := staker.StakerInfo(ctx, account.Address)
 	if err != nil {
 		return errors.Wrap(err, "get stake status")
 	}
@@ -112,15 +145,17 @@ func RequestStakingWithdraw(
 	ctx context.Context,
 	logger log.Logger,
 	client contracts.ETHClient,
-	contract *contracts.ITellor,
+	staker contracts.Staker,
 	account *ethereum.Account,
+	confirmations uint64,
+	approvals *approval.Queue,
 ) error {
 
-	status, startTime, err := contract.GetStakerInfo(nil, account.Address)
+	status, startTime, err := staker.StakerInfo(ctx, account.Address)
 	if err != nil {
 		return errors.Wrap(err, "get stake status")
 	}
-	if status.Uint64() != 1 {
+	if status != 1 {
 		printStakeStatus(logger, status, startTime)
 		return nil
 	}
@@ -130,27 +165,34 @@ func RequestStakingWithdraw(
 		return errors.Wrap(err, "prepare ethereum transaction")
 	}
 
-	tx, err := contract.RequestStakingWithdraw(auth)
+	if approvals.Required(transactor.FunctionRequestWithdraw, nil) {
+		description := "request the withdrawal of the stake of " + account.Address.Hex()
+		return requestApproval(ctx, logger, client, approvals, auth, account, transactor.FunctionRequestWithdraw, description, staker.RequestStakingWithdraw)
+	}
+
+	tx, err := staker.RequestStakingWithdraw(auth)
 	if err != nil {
 		return errors.Wrap(err, "contract")
 	}
 
 	level.Info(logger).Log("msg", "withdrawal request sent", "txHash", tx.Hash().Hex())
-	return nil
+	return waitFinalized(ctx, logger, client, tx, confirmations)
 }
 
 func WithdrawStake(
 	ctx context.Context,
 	logger log.Logger,
 	client contracts.ETHClient,
-	contract *contracts.ITellor,
+	staker contracts.Staker,
 	account *ethereum.Account,
+	confirmations uint64,
+	approvals *approval.Queue,
 ) error {
-	status, startTime, err := contract.GetStakerInfo(nil, account.Address)
+	status, startTime, err := staker.StakerInfo(ctx, account.Address)
 	if err != nil {
 		return errors.Wrap(err, "get stake status")
 	}
-	if status.Uint64() != 2 {
+	if status != 2 {
 		level.Info(logger).Log("msg", "can't withdraw")
 		printStakeStatus(logger, status, startTime)
 		return nil
@@ -161,10 +203,33 @@ func WithdrawStake(
 		return errors.Wrap(err, "prepare ethereum transaction")
 	}
 
-	tx, err := contract.WithdrawStake(auth)
+	if approvals.Required(transactor.FunctionWithdrawStake, nil) {
+		description := "withdraw the stake of " + account.Address.Hex()
+		return requestApproval(ctx, logger, client, approvals, auth, account, transactor.FunctionWithdrawStake, description, staker.WithdrawStake)
+	}
+
+	tx, err := staker.WithdrawStake(auth)
 	if err != nil {
 		return errors.Wrap(err, "contract")
 	}
 	level.Info(logger).Log("msg", "withdrew stake", "txHash", tx.Hash().Hex())
+	return waitFinalized(ctx, logger, client, tx, confirmations)
+}
+
+// waitFinalized waits for the configured confirmations of a stake transaction
+// so that the command only returns once it is safe to act on it.
+func waitFinalized(ctx context.Context, logger log.Logger, client contracts.ETHClient, tx *types.Transaction, confirmations uint64) error {
+	if confirmations == 0 {
+		return nil
+	}
+	level.Info(logger).Log("msg", "waiting for confirmations", "txHash", tx.Hash().Hex(), "confirmations", confirmations)
+	receipt, err := transactor.WaitFinalized(ctx, client, tx.Hash(), confirmations)
+	if err != nil {
+		return errors.Wrap(err, "waiting for confirmations")
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return errors.Errorf("transaction failed txHash:%v", tx.Hash().Hex())
+	}
+	level.Info(logger).Log("msg", "transaction finalized", "txHash", tx.Hash().Hex(), "block", receipt.BlockNumber)
 	return nil
 }
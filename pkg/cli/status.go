@@ -0,0 +1,247 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package cli
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/health"
+	"github.com/tellor-io/telliot/pkg/submitter/tellor"
+	"github.com/tellor-io/telliot/pkg/web"
+)
+
+// statusTimeout limits how long collecting the snapshot can take.
+const statusTimeout = 30 * time.Second
+
+// Snapshot is a one-shot view of the accounts, the current challenge
+// and optionally the health of a running instance.
+type Snapshot struct {
+	Time       time.Time
+	Challenge  ChallengeStatus
+	Accounts   []AccountStatus
+	Components []health.Status `json:",omitempty"`
+	// ComponentsError is set when the running instance couldn't be reached.
+	ComponentsError string `json:",omitempty"`
+	// Requests are the excluded and overridden request IDs of the running instance.
+	Requests *tellor.RequestsStatus `json:",omitempty"`
+}
+
+type ChallengeStatus struct {
+	Hash       string
+	RequestIDs []int64
+	Difficulty string
+	Tip        string
+}
+
+type AccountStatus struct {
+	Address     common.Address
+	Stake       string
+	StakedSince time.Time
+	// LastSubmit is zero when the account has never submitted.
+	LastSubmit time.Time
+	PendingTxs uint64
+	ETH        string
+	TRB        string
+}
+
+// GetSnapshot collects the snapshot from the chain.
+// When url is not empty it also includes the component health of the instance running at that address.
+func GetSnapshot(ctx context.Context, client contracts.ETHClient, contract *contracts.ITellor, accounts []*ethereum.Account, url string) (*Snapshot, error) {
+	snapshot := &Snapshot{Time: time.Now()}
+
+	vars, err := contract.GetNewCurrentVariables(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return nil, errors.Wrap(err, "getting the current challenge")
+	}
+	snapshot.Challenge = ChallengeStatus{
+		Hash:       hex.EncodeToString(vars.Challenge[:]),
+		Difficulty: vars.Difficutly.String(),
+		Tip:        format.ERC20Balance(vars.Tip),
+	}
+	for _, id := range vars.RequestIds {
+		snapshot.Challenge.RequestIDs = append(snapshot.Challenge.RequestIDs, id.Int64())
+	}
+
+	for _, account := range accounts {
+		status, err := accountStatus(ctx, client, contract, account.Address)
+		if err != nil {
+			return nil, errors.Wrapf(err, "account:%v", account.Address.String())
+		}
+		snapshot.Accounts = append(snapshot.Accounts, *status)
+	}
+
+	if url != "" {
+		components, err := instanceHealth(ctx, url)
+		if err != nil {
+			snapshot.ComponentsError = err.Error()
+		}
+		snapshot.Components = components
+		if err == nil {
+			// Not all instances run a submitter so the requests are optional.
+			snapshot.Requests, _ = instanceRequests(ctx, url)
+		}
+	}
+	return snapshot, nil
+}
+
+func accountStatus(ctx context.Context, client contracts.ETHClient, contract *contracts.ITellor, addr common.Address) (*AccountStatus, error) {
+	opts := &bind.CallOpts{Context: ctx}
+	status, started, err := contract.GetStakerInfo(opts, addr)
+	if err != nil {
+		return nil, errors.Wrap(err, "get stake status")
+	}
+	// The contract stores the last submit time under the hash of the miner address.
+	last, err := contract.GetUintVar(opts, ethereum.Keccak256(common.LeftPadBytes(addr.Bytes(), 32)))
+	if err != nil {
+		return nil, errors.Wrap(err, "get last submit time")
+	}
+	nonce, err := client.NonceAt(ctx, addr)
+	if err != nil {
+		return nil, errors.Wrap(err, "get nonce")
+	}
+	pendingNonce, err := client.PendingNonceAt(ctx, addr)
+	if err != nil {
+		return nil, errors.Wrap(err, "get pending nonce")
+	}
+	ethBalance, err := client.BalanceAt(ctx, addr, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "get eth balance")
+	}
+	trbBalance, err := contract.BalanceOf(opts, addr)
+	if err != nil {
+		return nil, errors.Wrap(err, "get trb balance")
+	}
+
+	s := &AccountStatus{
+		Address: addr,
+		Stake:   contracts.StakerStatusName(status.Int64()),
+		ETH:     format.ERC20Balance(ethBalance),
+		TRB:     format.ERC20Balance(trbBalance),
+	}
+	if started.Int64() > 0 {
+		s.StakedSince = time.Unix(started.Int64(), 0)
+	}
+	if last.Int64() > 0 {
+		s.LastSubmit = time.Unix(last.Int64(), 0)
+	}
+	if pendingNonce > nonce {
+		s.PendingTxs = pendingNonce - nonce
+	}
+	return s, nil
+}
+
+// instanceHealth gets the component health from the liveness endpoint of a running instance.
+func instanceHealth(ctx context.Context, url string) ([]health.Status, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(url, "/")+"/healthz", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "create request")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "send request")
+	}
+	defer resp.Body.Close()
+
+	// The endpoint responds with 503 when degraded so the status code is not checked.
+	var r struct {
+		Components []health.Status `json:"components"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, errors.Wrapf(err, "decode response code:%v", resp.StatusCode)
+	}
+	return r.Components, nil
+}
+
+func instanceRequests(ctx context.Context, url string) (*tellor.RequestsStatus, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(url, "/")+"/api/v1/submitter/requests", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "create request")
+	}
+	web.SetAPIKey(req)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "send request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("response code:%v", resp.StatusCode)
+	}
+
+	var r struct {
+		Data tellor.RequestsStatus `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, errors.Wrap(err, "decode response")
+	}
+	return &r.Data, nil
+}
+
+// PrintSnapshot writes the snapshot as human readable tables.
+func PrintSnapshot(w io.Writer, s *Snapshot) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "CHALLENGE\tREQUEST IDS\tDIFFICULTY\tTIP\n")
+	fmt.Fprintf(tw, "%s\t%v\t%s\t%s\n", s.Challenge.Hash[:16], s.Challenge.RequestIDs, s.Challenge.Difficulty, s.Challenge.Tip)
+	fmt.Fprintln(tw)
+
+	fmt.Fprintf(tw, "ACCOUNT\tSTAKE\tLAST SUBMIT\tPENDING TXS\tETH\tTRB\n")
+	for _, a := range s.Accounts {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n", a.Address.String(), a.Stake, since(s.Time, a.LastSubmit), a.PendingTxs, a.ETH, a.TRB)
+	}
+
+	if len(s.Components) > 0 || s.ComponentsError != "" {
+		fmt.Fprintln(tw)
+		fmt.Fprintf(tw, "COMPONENT\tCRITICAL\tHEALTHY\tREADY\tMESSAGE\n")
+		for _, c := range s.Components {
+			fmt.Fprintf(tw, "%s\t%v\t%v\t%v\t%s\n", c.Name, c.Critical, c.Healthy, c.Ready, c.Message)
+		}
+		if s.ComponentsError != "" {
+			fmt.Fprintf(tw, "instance unreachable: %s\n", s.ComponentsError)
+		}
+	}
+
+	if s.Requests != nil && (len(s.Requests.Excluded) > 0 || len(s.Requests.Overrides) > 0) {
+		fmt.Fprintln(tw)
+		fmt.Fprintf(tw, "REQUEST ID\tEXCLUDED\tOVERRIDE\tEXPIRY\tACTIVE\n")
+		for _, id := range s.Requests.Excluded {
+			fmt.Fprintf(tw, "%d\t%v\t\t\t\n", id, true)
+		}
+		for _, o := range s.Requests.Overrides {
+			fmt.Fprintf(tw, "%d\t%v\t%v\t%s\t%v\n", o.RequestID, false, o.Value, o.Expiry.Format(time.RFC3339), o.Active)
+		}
+	}
+	if s.Requests != nil && len(s.Requests.Pauses) > 0 {
+		fmt.Fprintln(tw)
+		fmt.Fprintf(tw, "PAUSED ID\tFROM\tUNTIL\tACTIVE\tREASON\n")
+		for _, p := range s.Requests.Pauses {
+			id := fmt.Sprint(p.RequestID)
+			if p.RequestID == 0 {
+				id = "all"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%v\t%s\n", id, p.From.Format(time.RFC3339), p.Until.Format(time.RFC3339), p.Active, p.Reason)
+		}
+	}
+	return tw.Flush()
+}
+
+func since(now, t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return now.Sub(t).Truncate(time.Second).String() + " ago"
+}
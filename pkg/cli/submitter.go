@@ -0,0 +1,76 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tellor-io/telliot/pkg/client"
+	"github.com/tellor-io/telliot/pkg/submitter/tellor"
+)
+
+type submitterPauseCmd struct {
+	URL    string        `required:"" help:"address of a running instance e.g. http://localhost:9090"`
+	ID     int64         `help:"the request ID, all when not set"`
+	For    time.Duration `required:"" help:"how long to pause e.g. 2h"`
+	From   time.Time     `help:"the start of the pause in RFC3339 e.g. 2021-06-01T10:00:00Z, defaults to now"`
+	Reason string        `help:"why the submissions are paused, kept with the pause"`
+}
+
+func (self submitterPauseCmd) Run() error {
+	ctx, cncl := context.WithTimeout(context.Background(), statusTimeout)
+	defer cncl()
+	status, err := client.New(self.URL, 0).Pause(ctx, self.ID, self.From, self.For, self.Reason)
+	if err != nil {
+		return err
+	}
+	return PrintPauses(os.Stdout, status.Pauses)
+}
+
+type submitterResumeCmd struct {
+	URL string `required:"" help:"address of a running instance e.g. http://localhost:9090"`
+	ID  int64  `help:"the request ID, the global pauses when not set"`
+}
+
+func (self submitterResumeCmd) Run() error {
+	ctx, cncl := context.WithTimeout(context.Background(), statusTimeout)
+	defer cncl()
+	status, err := client.New(self.URL, 0).Resume(ctx, self.ID)
+	if err != nil {
+		return err
+	}
+	return PrintPauses(os.Stdout, status.Pauses)
+}
+
+type submitterPausesCmd struct {
+	URL string `required:"" help:"address of a running instance e.g. http://localhost:9090"`
+}
+
+func (self submitterPausesCmd) Run() error {
+	ctx, cncl := context.WithTimeout(context.Background(), statusTimeout)
+	defer cncl()
+	status, err := client.New(self.URL, 0).Requests(ctx)
+	if err != nil {
+		return err
+	}
+	return PrintPauses(os.Stdout, status.Pauses)
+}
+
+func PrintPauses(w io.Writer, pauses []tellor.PauseStatus) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "REQUEST ID\tFROM\tUNTIL\tACTIVE\tREASON\tBY\n")
+	for _, p := range pauses {
+		id := fmt.Sprint(p.RequestID)
+		if p.RequestID == 0 {
+			id = "all"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%v\t%s\t%s\n", id, p.From.Format(time.RFC3339), p.Until.Format(time.RFC3339), p.Active, p.Reason, p.By)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,207 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/config"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+)
+
+// testnets are the networks where the setup is allowed so that it never touches mainnet funds.
+var testnets = map[int64]string{
+	5:        "goerli",
+	11155111: "sepolia",
+}
+
+// faucetABI is the minimal ABI of the playground token with a faucet.
+const faucetABI = `[
+{"inputs":[{"name":"_user","type":"address"}],"name":"faucet","outputs":[],"stateMutability":"nonpayable","type":"function"},
+{"inputs":[{"name":"_spender","type":"address"},{"name":"_amount","type":"uint256"}],"name":"approve","outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable","type":"function"},
+{"inputs":[{"name":"_user","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"}
+]`
+
+type testnetSetupCmd struct {
+	Config   configPath `type:"existingfile" help:"path to config file"`
+	Faucet   string     `required:"" help:"address of the test TRB token contract with the faucet"`
+	Contract string     `required:"" help:"address of the Tellor contract to stake in"`
+	Profile  string     `default:"configs/sandbox.json" help:"path to write the sandbox config to"`
+	Account  int        `arg:"" optional:""`
+}
+
+func (self testnetSetupCmd) Run() error {
+	logger := logging.NewLogger()
+
+	cfg, err := config.ParseConfig(logger, string(self.Config))
+	if err != nil {
+		return errors.Wrap(err, "creating config")
+	}
+
+	ctx := context.Background()
+	client, accounts, err := createTellorVariables(ctx, logger, cfg.Ethereum)
+	if err != nil {
+		return errors.Wrap(err, "creating tellor variables")
+	}
+	account, err := getAccountFor(accounts, self.Account)
+	if err != nil {
+		return err
+	}
+
+	netID, err := client.NetworkID(ctx)
+	if err != nil {
+		return errors.Wrap(err, "getting network id")
+	}
+	network, ok := testnets[netID.Int64()]
+	if !ok {
+		return errors.Errorf("network id:%v is not a supported testnet", netID)
+	}
+	level.Info(logger).Log("msg", "setting up testnet", "network", network, "account", account.Address.String())
+
+	faucetAddr, contractAddr := ETHAddress{}, ETHAddress{}
+	if err := faucetAddr.Set(self.Faucet); err != nil {
+		return errors.Wrap(err, "parsing faucet address")
+	}
+	if err := contractAddr.Set(self.Contract); err != nil {
+		return errors.Wrap(err, "parsing contract address")
+	}
+
+	if err := TestnetSetup(ctx, logger, client, account, faucetAddr.addr, contractAddr.addr); err != nil {
+		return err
+	}
+	return writeSandboxProfile(logger, self.Profile, cfg, contractAddr.addr)
+}
+
+// TestnetSetup requests test TRB from the faucet and stakes it.
+func TestnetSetup(
+	ctx context.Context,
+	logger log.Logger,
+	client contracts.ETHClient,
+	account *ethereum.Account,
+	faucetAddr common.Address,
+	contractAddr common.Address,
+) error {
+	parsed, err := abi.JSON(strings.NewReader(faucetABI))
+	if err != nil {
+		return errors.Wrap(err, "parse faucet abi")
+	}
+	faucet := bind.NewBoundContract(faucetAddr, parsed, client, client, client)
+
+	caps, err := contracts.NewCapabilities(ctx, client, contractAddr)
+	if err != nil {
+		return errors.Wrap(err, "getting the contract capabilities")
+	}
+	staker, err := caps.Staker()
+	if err != nil {
+		return err
+	}
+	status, startTime, err := staker.StakerInfo(ctx, account.Address)
+	if err != nil {
+		return errors.Wrap(err, "get stake status")
+	}
+	if status == 1 {
+		printStakeStatus(logger, status, startTime)
+		return nil
+	}
+	stakeAmt, err := staker.StakeAmount(ctx)
+	if err != nil {
+		return errors.Wrap(err, "fetching stake amount")
+	}
+
+	var balance []interface{}
+	if err := faucet.Call(&bind.CallOpts{Context: ctx}, &balance, "balanceOf", account.Address); err != nil {
+		return errors.Wrap(err, "get TRB balance")
+	}
+	if abi.ConvertType(balance[0], new(big.Int)).(*big.Int).Cmp(stakeAmt) < 0 {
+		if err := transactAndWait(ctx, logger, client, account, "faucet", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+			return faucet.Transact(auth, "faucet", account.Address)
+		}); err != nil {
+			return err
+		}
+	}
+
+	// TellorFlex pulls the stake with transferFrom so it needs an allowance.
+	if caps.Version == contracts.VersionTellorFlex {
+		if err := transactAndWait(ctx, logger, client, account, "approve", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+			return faucet.Transact(auth, "approve", contractAddr, stakeAmt)
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := transactAndWait(ctx, logger, client, account, "deposit stake", staker.DepositStake); err != nil {
+		return err
+	}
+	level.Info(logger).Log("msg", "staked", "amount", format.ERC20Balance(stakeAmt), "contract", contractAddr.Hex(), "version", caps.Version)
+	return nil
+}
+
+func transactAndWait(
+	ctx context.Context,
+	logger log.Logger,
+	client contracts.ETHClient,
+	account *ethereum.Account,
+	name string,
+	f func(*bind.TransactOpts) (*types.Transaction, error),
+) error {
+	auth, err := ethereum.PrepareEthTransaction(ctx, client, account)
+	if err != nil {
+		return errors.Wrap(err, "prepare ethereum transaction")
+	}
+	tx, err := f(auth)
+	if err != nil {
+		return errors.Wrap(err, name)
+	}
+	level.Info(logger).Log("msg", "waiting for the transaction", "name", name, "txHash", tx.Hash().Hex())
+	receipt, err := bind.WaitMined(ctx, client, tx)
+	if err != nil {
+		return errors.Wrapf(err, "waiting for %v", name)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return errors.Errorf("%v transaction reverted txHash:%v", name, tx.Hash().Hex())
+	}
+	return nil
+}
+
+// writeSandboxProfile writes a config that points all commands to the testnet contract.
+// The components that need mainnet contracts are disabled.
+func writeSandboxProfile(logger log.Logger, path string, cfg *config.Config, contractAddr common.Address) error {
+	profile := map[string]interface{}{
+		"EnvFile": cfg.EnvFile,
+		"Contracts": map[string]interface{}{
+			"Enabled": false,
+			"Address": contractAddr.Hex(),
+		},
+		"Tipper":  map[string]interface{}{"Enabled": false},
+		"Mempool": map[string]interface{}{"Enabled": false},
+	}
+	b, err := json.MarshalIndent(profile, "", "    ")
+	if err != nil {
+		return errors.Wrap(err, "marshal sandbox profile")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrap(err, "creating the profile dir")
+	}
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		return errors.Wrap(err, "writing the sandbox profile")
+	}
+	level.Info(logger).Log("msg", "sandbox profile written, use it with --config", "path", path)
+	return nil
+}
@@ -10,12 +10,15 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/approval"
 	"github.com/tellor-io/telliot/pkg/contracts"
 	"github.com/tellor-io/telliot/pkg/ethereum"
 	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/transactor"
 )
 
 func prepareTransfer(
@@ -51,12 +54,20 @@ func Transfer(
 	account *ethereum.Account,
 	toAddress common.Address,
 	amt *big.Int,
+	approvals *approval.Queue,
 ) error {
 	auth, err := prepareTransfer(ctx, logger, client, tellor, account, amt)
 	if err != nil {
 		return errors.Wrap(err, "preparing transfer")
 	}
 
+	if approvals.Required(transactor.FunctionTransfer, amt) {
+		description := fmt.Sprintf("transfer %v TRB to %v", format.ERC20Balance(amt), toAddress.Hex())
+		return requestApproval(ctx, logger, client, approvals, auth, account, transactor.FunctionTransfer, description, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+			return tellor.Transfer(auth, toAddress, amt)
+		})
+	}
+
 	tx, err := tellor.Transfer(auth, toAddress, amt)
 	if err != nil {
 		return errors.Wrap(err, "calling transfer")
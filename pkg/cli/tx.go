@@ -0,0 +1,91 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/tellor-io/telliot/pkg/client"
+	"github.com/tellor-io/telliot/pkg/transactor"
+)
+
+type txListCmd struct {
+	URL  string `required:"" help:"address of a running instance e.g. http://localhost:9090"`
+	JSON bool   `name:"json" help:"print as JSON"`
+}
+
+func (s txListCmd) Run() error {
+	ctx, cncl := context.WithTimeout(context.Background(), statusTimeout)
+	defer cncl()
+
+	pending, err := client.New(s.URL, 0).Pending(ctx)
+	if err != nil {
+		return err
+	}
+	if s.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(pending)
+	}
+	return PrintPending(os.Stdout, pending)
+}
+
+func PrintPending(w io.Writer, pending []transactor.Pending) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "ACCOUNT\tNONCE\tHASH\tFUNCTION\tGAS PRICE (GWEI)\tAGE\tREPLACEMENTS\tCANCELED\n")
+	for _, p := range pending {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t%d\t%v\n", p.Account, p.Nonce, p.Hash, p.Function, gwei(p.GasPrice), p.Age, p.Replacements, p.Canceled)
+	}
+	return tw.Flush()
+}
+
+func gwei(wei *big.Int) string {
+	if wei == nil {
+		return ""
+	}
+	return new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(params.GWei)).Text('f', 2)
+}
+
+type txBumpCmd struct {
+	URL      string  `required:"" help:"address of a running instance e.g. http://localhost:9090"`
+	Hash     string  `arg:"" help:"the hash of the pending transaction or any of its replacements"`
+	GasPrice float64 `help:"the new gas price in gwei, by default 20% higher than the current"`
+}
+
+func (s txBumpCmd) Run() error {
+	return replaceTx(s.URL, "bump", s.Hash, s.GasPrice)
+}
+
+type txCancelCmd struct {
+	URL      string  `required:"" help:"address of a running instance e.g. http://localhost:9090"`
+	Hash     string  `arg:"" help:"the hash of the pending transaction or any of its replacements"`
+	GasPrice float64 `help:"the gas price of the cancel transaction in gwei, by default 20% higher than the current"`
+}
+
+func (s txCancelCmd) Run() error {
+	return replaceTx(s.URL, "cancel", s.Hash, s.GasPrice)
+}
+
+func replaceTx(url, action, hash string, gasPrice float64) error {
+	ctx, cncl := context.WithTimeout(context.Background(), statusTimeout)
+	defer cncl()
+
+	c := client.New(url, 0)
+	replace := c.Bump
+	if action == "cancel" {
+		replace = c.Cancel
+	}
+	pending, err := replace(ctx, hash, gasPrice)
+	if err != nil {
+		return err
+	}
+	return PrintPending(os.Stdout, []transactor.Pending{pending})
+}
@@ -0,0 +1,291 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+// Package client calls the web API of a running instance.
+// The endpoints are described in the OpenAPI document served at /openapi.json.
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/attestation"
+	"github.com/tellor-io/telliot/pkg/crosscheck"
+	"github.com/tellor-io/telliot/pkg/db"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/reputation"
+	"github.com/tellor-io/telliot/pkg/submitter"
+	"github.com/tellor-io/telliot/pkg/submitter/tellor"
+	"github.com/tellor-io/telliot/pkg/tracker/disputeHistory"
+	"github.com/tellor-io/telliot/pkg/tracker/race"
+	"github.com/tellor-io/telliot/pkg/transactor"
+	"github.com/tellor-io/telliot/pkg/web"
+)
+
+// Client calls the web API of the instance at the url e.g. http://localhost:9090.
+// The requests are sent with the API key in the TELLIOT_API_KEY env var when set.
+type Client struct {
+	url    string
+	client *http.Client
+}
+
+// New creates a client, a zero timeout leaves it to the context of each call.
+func New(url string, timeout time.Duration) *Client {
+	return &Client{
+		url:    strings.TrimSuffix(url, "/"),
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Races lists the tracked submission races, the empty filters match all.
+func (self *Client) Races(ctx context.Context, account, result string) ([]race.Race, error) {
+	var races []race.Race
+	if err := web.GetJSON(ctx, self.client, self.path("/api/v1/races", "account", account, "result", result), &races); err != nil {
+		return nil, errors.Wrap(err, "getting the races")
+	}
+	return races, nil
+}
+
+// Submissions lists the submissions in the journal, the empty filters match all.
+func (self *Client) Submissions(ctx context.Context, account string, state db.SubmissionState) ([]db.Submission, error) {
+	var subs []db.Submission
+	if err := web.GetJSON(ctx, self.client, self.path("/api/v1/submissions", "account", account, "state", string(state)), &subs); err != nil {
+		return nil, errors.Wrap(err, "getting the submissions")
+	}
+	return subs, nil
+}
+
+// Attestations lists the latest signed PSR values, all request IDs for 0.
+func (self *Client) Attestations(ctx context.Context, requestID int64) ([]attestation.Attestation, error) {
+	id := ""
+	if requestID != 0 {
+		id = strconv.FormatInt(requestID, 10)
+	}
+	var list []attestation.Attestation
+	if err := web.GetJSON(ctx, self.client, self.path("/api/v1/attestations", "id", id), &list); err != nil {
+		return nil, errors.Wrap(err, "getting the attestations")
+	}
+	return list, nil
+}
+
+// Pending lists the in-flight transactions.
+func (self *Client) Pending(ctx context.Context) ([]transactor.Pending, error) {
+	var pending []transactor.Pending
+	if err := web.GetJSON(ctx, self.client, self.path("/api/v1/transactor/pending"), &pending); err != nil {
+		return nil, errors.Wrap(err, "getting the pending transactions")
+	}
+	return pending, nil
+}
+
+// Bump replaces a stuck transaction with a higher gas price in gwei,
+// 0 increases the current gas price by 20%.
+func (self *Client) Bump(ctx context.Context, hash string, gasPrice float64) (transactor.Pending, error) {
+	return self.replace(ctx, "bump", hash, gasPrice)
+}
+
+// Cancel replaces a stuck transaction with an empty transaction at the same nonce.
+func (self *Client) Cancel(ctx context.Context, hash string, gasPrice float64) (transactor.Pending, error) {
+	return self.replace(ctx, "cancel", hash, gasPrice)
+}
+
+func (self *Client) replace(ctx context.Context, action, hash string, gasPrice float64) (transactor.Pending, error) {
+	var pending transactor.Pending
+	req := transactor.ReplaceRequest{Hash: hash, GasPrice: gasPrice}
+	if err := web.PostJSON(ctx, self.client, self.path("/api/v1/transactor/pending/"+action), req, &pending); err != nil {
+		return pending, errors.Wrapf(err, "%v transaction", action)
+	}
+	return pending, nil
+}
+
+// GasSummary summarizes the gas spent within the window.
+func (self *Client) GasSummary(ctx context.Context, window time.Duration) ([]transactor.GasStats, error) {
+	var summary []transactor.GasStats
+	if err := web.GetJSON(ctx, self.client, self.path("/api/v1/transactor/gas", "window", window.String()), &summary); err != nil {
+		return nil, errors.Wrap(err, "getting the gas summary")
+	}
+	return summary, nil
+}
+
+// RecordGas records the gas of a transaction sent outside of the instance.
+func (self *Client) RecordGas(ctx context.Context, rec transactor.GasRecord) error {
+	if err := web.PostJSON(ctx, self.client, self.path("/api/v1/transactor/gas"), rec, nil); err != nil {
+		return errors.Wrap(err, "recording the transaction gas")
+	}
+	return nil
+}
+
+// Breakers lists the circuit breaker status of each account.
+func (self *Client) Breakers(ctx context.Context) ([]submitter.BreakerStatus, error) {
+	var statuses []submitter.BreakerStatus
+	if err := web.GetJSON(ctx, self.client, self.path("/api/v1/submitter/breaker"), &statuses); err != nil {
+		return nil, errors.Wrap(err, "getting the circuit breakers")
+	}
+	return statuses, nil
+}
+
+// ResetBreaker resets the tripped circuit breaker of the account.
+func (self *Client) ResetBreaker(ctx context.Context, account string) (submitter.BreakerStatus, error) {
+	var status submitter.BreakerStatus
+	if err := web.PostJSON(ctx, self.client, self.path("/api/v1/submitter/breaker/reset", "account", account), nil, &status); err != nil {
+		return status, errors.Wrapf(err, "resetting the circuit breaker of account:%v", account)
+	}
+	return status, nil
+}
+
+// CrossCheck lists the last comparison of each request ID with the other oracles.
+func (self *Client) CrossCheck(ctx context.Context) ([]crosscheck.Status, error) {
+	var list []crosscheck.Status
+	if err := web.GetJSON(ctx, self.client, self.path("/api/v1/crosscheck"), &list); err != nil {
+		return nil, errors.Wrap(err, "getting the cross checks")
+	}
+	return list, nil
+}
+
+// AllowCrossCheck allows the values of a request ID that disagree with the other oracles for a duration.
+func (self *Client) AllowCrossCheck(ctx context.Context, req crosscheck.AllowRequest) error {
+	if err := web.PostJSON(ctx, self.client, self.path("/api/v1/crosscheck/allow"), req, nil); err != nil {
+		return errors.Wrapf(err, "allowing request ID:%v", req.RequestID)
+	}
+	return nil
+}
+
+// Disputes lists the history of the disputes against a miner, all when empty.
+func (self *Client) Disputes(ctx context.Context, miner string) ([]disputeHistory.Dispute, error) {
+	var list []disputeHistory.Dispute
+	if err := web.GetJSON(ctx, self.client, self.path("/api/v1/disputes", "miner", miner), &list); err != nil {
+		return nil, errors.Wrap(err, "getting the disputes")
+	}
+	return list, nil
+}
+
+// DisputeStats summarizes the disputes opened within the window, all when 0.
+func (self *Client) DisputeStats(ctx context.Context, window time.Duration) (disputeHistory.Stats, error) {
+	var _window string
+	if window > 0 {
+		_window = window.String()
+	}
+	var stats disputeHistory.Stats
+	if err := web.GetJSON(ctx, self.client, self.path("/api/v1/disputes/stats", "window", _window), &stats); err != nil {
+		return stats, errors.Wrap(err, "getting the dispute stats")
+	}
+	return stats, nil
+}
+
+// Reputation lists the reputation scores of the miners, only of one when not empty.
+func (self *Client) Reputation(ctx context.Context, miner string) ([]reputation.Score, error) {
+	var list []reputation.Score
+	if err := web.GetJSON(ctx, self.client, self.path("/api/v1/reputation", "miner", miner), &list); err != nil {
+		return nil, errors.Wrap(err, "getting the reputation scores")
+	}
+	return list, nil
+}
+
+// Requests lists the excluded and overridden requests in effect.
+func (self *Client) Requests(ctx context.Context) (tellor.RequestsStatus, error) {
+	var status tellor.RequestsStatus
+	if err := web.GetJSON(ctx, self.client, self.path("/api/v1/submitter/requests"), &status); err != nil {
+		return status, errors.Wrap(err, "getting the requests")
+	}
+	return status, nil
+}
+
+// Pause pauses the submissions of a request ID, 0 for all, for a duration from the given time or now when zero.
+func (self *Client) Pause(ctx context.Context, requestID int64, from time.Time, d time.Duration, reason string) (tellor.RequestsStatus, error) {
+	var _from string
+	if !from.IsZero() {
+		_from = from.Format(time.RFC3339)
+	}
+	var status tellor.RequestsStatus
+	p := self.path("/api/v1/submitter/pause", "id", strconv.FormatInt(requestID, 10), "for", d.String(), "from", _from, "reason", reason)
+	if err := web.PostJSON(ctx, self.client, p, nil, &status); err != nil {
+		return status, errors.Wrapf(err, "pausing request ID:%v", requestID)
+	}
+	return status, nil
+}
+
+// Resume removes the pauses of a request ID, 0 for the global ones.
+func (self *Client) Resume(ctx context.Context, requestID int64) (tellor.RequestsStatus, error) {
+	var status tellor.RequestsStatus
+	if err := web.PostJSON(ctx, self.client, self.path("/api/v1/submitter/resume", "id", strconv.FormatInt(requestID, 10)), nil, &status); err != nil {
+		return status, errors.Wrapf(err, "resuming request ID:%v", requestID)
+	}
+	return status, nil
+}
+
+// LogLevels lists the log level of all components.
+func (self *Client) LogLevels(ctx context.Context) ([]logging.ComponentLevel, error) {
+	var levels []logging.ComponentLevel
+	if err := web.GetJSON(ctx, self.client, self.path("/api/v1/loglevel"), &levels); err != nil {
+		return nil, errors.Wrap(err, "getting the log levels")
+	}
+	return levels, nil
+}
+
+// Runtime shows the resource usage and the runtime settings.
+func (self *Client) Runtime(ctx context.Context) (web.RuntimeStatus, error) {
+	var status web.RuntimeStatus
+	if err := web.GetJSON(ctx, self.client, self.path("/api/v1/runtime"), &status); err != nil {
+		return status, errors.Wrap(err, "getting the runtime settings")
+	}
+	return status, nil
+}
+
+// Audit lists the audit log entries within the duration, 0 for all.
+func (self *Client) Audit(ctx context.Context, since time.Duration) ([]db.AuditEntry, error) {
+	var entries []db.AuditEntry
+	s := ""
+	if since > 0 {
+		s = since.String()
+	}
+	if err := web.GetJSON(ctx, self.client, self.path("/api/v1/audit", "since", s), &entries); err != nil {
+		return nil, errors.Wrap(err, "getting the audit log")
+	}
+	return entries, nil
+}
+
+// Keys lists the API keys without their secrets.
+func (self *Client) Keys(ctx context.Context) ([]web.APIKey, error) {
+	var keys []web.APIKey
+	if err := web.GetJSON(ctx, self.client, self.path("/api/v1/keys"), &keys); err != nil {
+		return nil, errors.Wrap(err, "getting the API keys")
+	}
+	return keys, nil
+}
+
+// IssueKey issues an API key. The key is only in the response.
+func (self *Client) IssueKey(ctx context.Context, req web.IssueRequest) (web.IssueResponse, error) {
+	var resp web.IssueResponse
+	if err := web.PostJSON(ctx, self.client, self.path("/api/v1/keys"), req, &resp); err != nil {
+		return resp, errors.Wrap(err, "issuing an API key")
+	}
+	return resp, nil
+}
+
+// RevokeKey revokes an API key.
+func (self *Client) RevokeKey(ctx context.Context, id string) (web.APIKey, error) {
+	var key web.APIKey
+	if err := web.PostJSON(ctx, self.client, self.path("/api/v1/keys/revoke", "id", id), nil, &key); err != nil {
+		return key, errors.Wrapf(err, "revoking the API key:%v", id)
+	}
+	return key, nil
+}
+
+// path returns the url of the endpoint with the non empty query params as name, value pairs.
+func (self *Client) path(p string, params ...string) string {
+	q := url.Values{}
+	for i := 0; i+1 < len(params); i += 2 {
+		if params[i+1] != "" {
+			q.Set(params[i], params[i+1])
+		}
+	}
+	if len(q) == 0 {
+		return self.url + p
+	}
+	return fmt.Sprintf("%v%v?%v", self.url, p, q.Encode())
+}
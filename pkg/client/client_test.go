@@ -0,0 +1,60 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/testutil"
+	"github.com/tellor-io/telliot/pkg/tracker/race"
+	"github.com/tellor-io/telliot/pkg/transactor"
+	"github.com/tellor-io/telliot/pkg/web"
+)
+
+// TestClient ensures that the filters are sent as query params
+// and that the data and the errors of the responses are decoded.
+func TestClient(t *testing.T) {
+	var reqURL string
+	var body transactor.ReplaceRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqURL = r.URL.String()
+		switch r.URL.Path {
+		case "/api/v1/races":
+			_ = web.WriteJSON(w, http.StatusOK, []race.Race{{Account: "0x1", Result: race.ResultLost}}, nil)
+		case "/api/v1/transactor/pending/bump":
+			testutil.Ok(t, json.NewDecoder(r.Body).Decode(&body))
+			_ = web.WriteJSON(w, http.StatusOK, transactor.Pending{Hash: body.Hash}, nil)
+		default:
+			_ = web.WriteJSON(w, http.StatusNotFound, nil, errors.New("not found"))
+		}
+	}))
+	defer srv.Close()
+	c := New(srv.URL+"/", 0)
+	ctx := context.Background()
+
+	races, err := c.Races(ctx, "0x1", race.ResultLost)
+	testutil.Ok(t, err)
+	testutil.Equals(t, "/api/v1/races?account=0x1&result=lost", reqURL)
+	testutil.Equals(t, 1, len(races))
+	testutil.Equals(t, race.ResultLost, races[0].Result)
+
+	_, err = c.Races(ctx, "", "")
+	testutil.Ok(t, err)
+	testutil.Equals(t, "/api/v1/races", reqURL)
+
+	pending, err := c.Bump(ctx, "0xabc", 30)
+	testutil.Ok(t, err)
+	testutil.Equals(t, transactor.ReplaceRequest{Hash: "0xabc", GasPrice: 30}, body)
+	testutil.Equals(t, "0xabc", pending.Hash)
+
+	_, err = c.Breakers(ctx)
+	var statusErr *web.StatusError
+	testutil.Assert(t, errors.As(err, &statusErr), "expected a status error")
+	testutil.Equals(t, http.StatusNotFound, statusErr.Code)
+}
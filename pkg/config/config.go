@@ -8,6 +8,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/go-kit/kit/log"
@@ -15,19 +16,51 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/pkg/errors"
 	"github.com/tellor-io/telliot/pkg/aggregator"
+	"github.com/tellor-io/telliot/pkg/approval"
+	"github.com/tellor-io/telliot/pkg/attestation"
+	"github.com/tellor-io/telliot/pkg/chaos"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/coordination"
+	"github.com/tellor-io/telliot/pkg/crosscheck"
 	"github.com/tellor-io/telliot/pkg/db"
+	"github.com/tellor-io/telliot/pkg/errclass"
 	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/evidence"
 	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/gateway"
+	"github.com/tellor-io/telliot/pkg/indexer"
+	"github.com/tellor-io/telliot/pkg/mempool"
 	"github.com/tellor-io/telliot/pkg/mining"
+	"github.com/tellor-io/telliot/pkg/notify"
+	"github.com/tellor-io/telliot/pkg/plugin"
 	psrTellor "github.com/tellor-io/telliot/pkg/psr/tellor"
 	psrTellorAccess "github.com/tellor-io/telliot/pkg/psr/tellorAccess"
+	"github.com/tellor-io/telliot/pkg/registry"
+	"github.com/tellor-io/telliot/pkg/reputation"
+	"github.com/tellor-io/telliot/pkg/secret"
+	"github.com/tellor-io/telliot/pkg/simulation"
+	"github.com/tellor-io/telliot/pkg/submitter"
 	"github.com/tellor-io/telliot/pkg/submitter/tellor"
 	"github.com/tellor-io/telliot/pkg/submitter/tellorAccess"
+	"github.com/tellor-io/telliot/pkg/supervisor"
 	"github.com/tellor-io/telliot/pkg/tasker"
+	"github.com/tellor-io/telliot/pkg/tipper"
+	"github.com/tellor-io/telliot/pkg/tracing"
+	"github.com/tellor-io/telliot/pkg/tracker/balance"
+	"github.com/tellor-io/telliot/pkg/tracker/clock"
 	"github.com/tellor-io/telliot/pkg/tracker/dispute"
+	"github.com/tellor-io/telliot/pkg/tracker/disputeHistory"
 	"github.com/tellor-io/telliot/pkg/tracker/index"
+	"github.com/tellor-io/telliot/pkg/tracker/node"
 	"github.com/tellor-io/telliot/pkg/tracker/profit"
+	"github.com/tellor-io/telliot/pkg/tracker/race"
+	"github.com/tellor-io/telliot/pkg/tracker/slot"
+	"github.com/tellor-io/telliot/pkg/tracker/stake"
+	"github.com/tellor-io/telliot/pkg/tracker/vote"
 	"github.com/tellor-io/telliot/pkg/transactor"
+	"github.com/tellor-io/telliot/pkg/treasury"
+	"github.com/tellor-io/telliot/pkg/upgrade"
+	"github.com/tellor-io/telliot/pkg/watchdog"
 	"github.com/tellor-io/telliot/pkg/web"
 )
 
@@ -39,14 +72,44 @@ type Config struct {
 	SubmitterTellorAccess tellorAccess.Config
 	ProfitTracker         profit.Config
 	Tasker                tasker.Config
+	Tipper                tipper.Config
 	Transactor            transactor.Config
 	IndexTracker          index.Config
 	DisputeTracker        dispute.Config
+	VoteTracker           vote.Config
+	DisputeHistory        disputeHistory.Config
+	Reputation            reputation.Config
+	BalanceTracker        balance.Config
+	StakeTracker          stake.Config
+	NodeTracker           node.Config
+	SlotTracker           slot.Config
+	ClockTracker          clock.Config
+	RaceTracker           race.Config
+	Mempool               mempool.Config
+	Coordination          coordination.Config
+	Contracts             contracts.Config
+	Notify                notify.Config
+	Breaker               submitter.BreakerConfig
 	Ethereum              ethereum.Config
 	Aggregator            aggregator.Config
 	PsrTellor             psrTellor.Config
 	PsrTellorAccess       psrTellorAccess.Config
+	Registry              registry.Config
 	Db                    db.Config
+	Supervisor            supervisor.Config
+	Plugins               plugin.Config
+	Simulation            simulation.Config
+	Tracing               tracing.Config
+	Chaos                 chaos.Config
+	Upgrade               upgrade.Config
+	Attestation           attestation.Config
+	Evidence              evidence.Config
+	CrossCheck            crosscheck.Config
+	Treasury              treasury.Config
+	Approval              approval.Config
+	Gateway               gateway.Config
+	Indexer               indexer.Config
+	Watchdog              watchdog.Config
 	// EnvFile location that include all private details like private key etc.
 	EnvFile string `json:"envFile"`
 }
@@ -55,16 +118,54 @@ var DefaultConfig = Config{
 	Mining: mining.Config{
 		LogLevel:  "info",
 		Heartbeat: time.Minute,
+		Workers:   mining.NumProcessors,
+		Hash:      mining.HashAuto,
 	},
 	Web: web.Config{
-		LogLevel:   "info",
-		ListenHost: "", // Listen on all addresses.
+		LogLevel: "info",
+		// Only local clients without auth, set to 0.0.0.0 or the address of an interface together with the auth.
+		ListenHost: "127.0.0.1",
 		ListenPort: 9090,
+		Gzip:       true,
+		Query: web.QueryConfig{
+			Timeout:       format.Duration{Duration: 10 * time.Second},
+			MaxSamples:    5000000,
+			LookbackDelta: format.Duration{Duration: 5 * time.Minute},
+		},
+		Auth: web.AuthConfig{
+			KeysPath:  "db/apikeys.json",
+			RateLimit: 10,
+			Burst:     20,
+		},
 	},
 	Db: db.Config{
 		LogLevel:      "info",
 		Path:          "db",
+		JournalPath:   "db/submissions.journal",
+		AuditPath:     "db/audit.log",
 		RemoteTimeout: format.Duration{Duration: 5 * time.Second},
+		MaxExemplars:  10000,
+		Downsample: db.DownsampleConfig{
+			After:    format.Duration{Duration: 7 * 24 * time.Hour},
+			Interval: format.Duration{Duration: time.Hour},
+			Resolutions: []format.Duration{
+				{Duration: 5 * time.Minute},
+				{Duration: time.Hour},
+			},
+			Metrics:   []string{index.ValueMetricName, index.IntervalMetricName},
+			Retention: format.Duration{Duration: 365 * 24 * time.Hour},
+		},
+		Batch: db.BatchConfig{
+			Interval:   format.Duration{Duration: time.Second},
+			MaxSamples: 1000,
+			QueueSize:  10000,
+		},
+		Lightweight: db.LightweightConfig{
+			// The aggregations look back up to 24 hours.
+			Retention:        format.Duration{Duration: 25 * time.Hour},
+			MaxSamples:       5000,
+			SnapshotInterval: format.Duration{Duration: 15 * time.Minute},
+		},
 	},
 	Tasker: tasker.Config{
 		LogLevel: "info",
@@ -72,40 +173,361 @@ var DefaultConfig = Config{
 	ProfitTracker: profit.Config{
 		LogLevel: "info",
 	},
+	Tipper: tipper.Config{
+		LogLevel:    "info",
+		RequestIDs:  []int64{1},
+		Amount:      1,
+		Interval:    format.Duration{Duration: 30 * time.Minute},
+		MinInterval: format.Duration{Duration: 4 * time.Hour},
+		DailyCap:    10,
+		File:        "db/tipper.json",
+	},
 	DisputeTracker: dispute.Config{
+		LogLevel:    "info",
+		PendingPath: "db/dispute.pending",
+		Storm: dispute.StormConfig{
+			Removals:      10,
+			Window:        format.Duration{Duration: time.Minute},
+			Confirmations: 12,
+		},
+	},
+	VoteTracker: vote.Config{
+		LogLevel:       "info",
+		Interval:       format.Duration{Duration: 10 * time.Minute},
+		ReminderBefore: format.Duration{Duration: 24 * time.Hour},
+		LookBack:       format.Duration{Duration: 8 * 24 * time.Hour},
+	},
+	DisputeHistory: disputeHistory.Config{
+		LogLevel:      "info",
+		Path:          "db/disputes.jsonl",
+		Interval:      format.Duration{Duration: 10 * time.Minute},
+		LookBack:      format.Duration{Duration: 30 * 24 * time.Hour},
+		Confirmations: 12,
+	},
+	Reputation: reputation.Config{
+		LogLevel:           "info",
+		Interval:           format.Duration{Duration: 10 * time.Minute},
+		Window:             format.Duration{Duration: 7 * 24 * time.Hour},
+		MaxDeviation:       5,
+		MaxDisputeLosses:   2,
+		DeviationWeight:    2,
+		DisputeWeight:      2,
+		RegularityWeight:   1,
+		DisputeThreshold:   5,
+		MinThresholdFactor: 0.4,
+	},
+	BalanceTracker: balance.Config{
+		LogLevel:         "info",
+		Interval:         format.Duration{Duration: 5 * time.Minute},
+		MinSubmissions:   10,
+		GasPerSubmission: 200000,
+	},
+	StakeTracker: stake.Config{
 		LogLevel: "info",
+		Interval: format.Duration{Duration: 10 * time.Minute},
+	},
+	NodeTracker: node.Config{
+		LogLevel:         "info",
+		Interval:         format.Duration{Duration: 30 * time.Second},
+		UnreachableAfter: format.Duration{Duration: 5 * time.Minute},
+	},
+	SlotTracker: slot.Config{
+		LogLevel: "info",
+	},
+	ClockTracker: clock.Config{
+		LogLevel: "info",
+		Interval: format.Duration{Duration: 15 * time.Second},
+		Samples:  20,
+		MaxSkew:  format.Duration{Duration: 30 * time.Second},
+	},
+	RaceTracker: race.Config{
+		LogLevel: "info",
+		History:  1000,
+	},
+	Coordination: coordination.Config{
+		LogLevel: "info",
+		Backend:  coordination.BackendFile,
+		Dir:      "locks",
+		TTL:      format.Duration{Duration: 30 * time.Second},
+	},
+	Contracts: contracts.Config{
+		LogLevel: "info",
+		Interval: format.Duration{Duration: time.Minute},
+	},
+	Mempool: mempool.Config{
+		LogLevel:       "info",
+		Interval:       format.Duration{Duration: 2 * time.Second},
+		GasBumpPercent: 10,
+		MaxGasPrice:    100,
+	},
+	Notify: notify.Config{
+		LogLevel: "info",
+		Timeout:  format.Duration{Duration: 10 * time.Second},
+		Email: notify.EmailConfig{
+			Port: 587,
+		},
+		Incidents: notify.IncidentConfig{
+			ResolveDelay: format.Duration{Duration: 5 * time.Minute},
+		},
+	},
+	Breaker: submitter.BreakerConfig{
+		Enabled:   true,
+		Threshold: 3,
+		Timeout:   format.Duration{Duration: time.Hour},
 	},
 	Ethereum: ethereum.Config{
 		LogLevel: "info",
 		Timeout:  3000,
+		Logs: ethereum.LogFetcherConfig{
+			ChunkSize:    5000,
+			MinChunkSize: 10,
+			RateLimit:    10,
+		},
+		Middleware: ethereum.MiddlewareConfig{
+			Timeouts: map[string]format.Duration{
+				"eth_call":    {Duration: 30 * time.Second},
+				"eth_getLogs": {Duration: 2 * time.Minute},
+			},
+			DefaultTimeout: format.Duration{Duration: time.Minute},
+			SlowCall:       format.Duration{Duration: 5 * time.Second},
+		},
+		Blocks: ethereum.BlocksConfig{
+			Source:       ethereum.BlocksAuto,
+			PollInterval: format.Duration{Duration: 5 * time.Second},
+		},
 	},
 	Transactor: transactor.Config{
 		LogLevel:      "info",
 		GasMax:        10,
 		GasMultiplier: 1,
+		GasStrategy:   transactor.GasStrategyMultiplier,
+		Adaptive: transactor.AdaptiveGasConfig{
+			Percentile: 80,
+			Window:     format.Duration{Duration: 2 * time.Hour},
+			MinSamples: 5,
+		},
+		Budget: transactor.BudgetConfig{
+			Daily:           0.5,
+			Weekly:          2,
+			SubmissionShare: 0.8,
+			TipShare:        0.5,
+			File:            "db/budget.json",
+		},
+		Confirmations: map[string]uint64{
+			transactor.FunctionDepositStake:    12,
+			transactor.FunctionRequestWithdraw: 12,
+			transactor.FunctionWithdrawStake:   12,
+		},
+		UserOp: transactor.UserOpConfig{
+			EntryPoint: "0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789",
+			Timeout:    format.Duration{Duration: 2 * time.Minute},
+		},
 	},
 	SubmitterTellor: tellor.Config{
 		Enabled:  true,
 		LogLevel: "info",
 		// MinSubmitPeriod is the time limit between each submit for a staked miner.
-		// With a 1 second delay here as a workaround to prevent a race condition in the oracle contract check.
-		MinSubmitPeriod: format.Duration{Duration: 15*time.Minute + 1*time.Second},
+		MinSubmitPeriod:    format.Duration{Duration: 15 * time.Minute},
+		SubmitMargin:       format.Duration{Duration: time.Second},
+		Timing:             tellor.TimingImmediate,
+		TimingWindow:       format.Duration{Duration: time.Minute},
+		TimingLastN:        format.Duration{Duration: 10 * time.Second},
+		MinSlotProbability: 0.5,
+		Guard: submitter.GuardConfig{
+			Enabled:               true,
+			MaxDeviation:          20,
+			Recent:                10,
+			MedianTTL:             format.Duration{Duration: 5 * time.Minute},
+			MaxReferenceDeviation: 5,
+			ReferenceTimeout:      format.Duration{Duration: 10 * time.Second},
+			Volatility: submitter.VolatilityConfig{
+				MaxChange:  10,
+				Period:     format.Duration{Duration: time.Hour},
+				MinSources: 2,
+			},
+		},
 	},
 	SubmitterTellorAccess: tellorAccess.Config{
 		LogLevel: "info",
+		Guard: submitter.GuardConfig{
+			Enabled:               true,
+			MaxDeviation:          20,
+			Recent:                10,
+			MedianTTL:             format.Duration{Duration: 5 * time.Minute},
+			MaxReferenceDeviation: 5,
+			ReferenceTimeout:      format.Duration{Duration: 10 * time.Second},
+			Volatility: submitter.VolatilityConfig{
+				MaxChange:  10,
+				Period:     format.Duration{Duration: time.Hour},
+				MinSources: 2,
+			},
+		},
 	},
 	PsrTellor: psrTellor.Config{
 		MinConfidence: 70,
+		MaxAge:        format.Duration{Duration: 10 * time.Minute},
+		// Twice the interval of the sources in the index file.
+		MaxAges: map[string]format.Duration{
+			"DEFITVL":  {Duration: 2 * time.Hour},
+			"DEFIMCAP": {Duration: time.Hour},
+		},
+		AMPL: psrTellor.VWAPConfig{
+			Symbol:     "AMPL/USD",
+			Window:     format.Duration{Duration: 24 * time.Hour},
+			AggrWindow: format.Duration{Duration: 10 * time.Minute},
+			// The daily AMPL rebase.
+			Cutoff: "02:00",
+		},
+		USPCE: psrTellor.MonthlyConfig{
+			File:   "configs/uspce.json",
+			Months: 3,
+		},
+	},
+	PsrTellorAccess: psrTellorAccess.Config{
+		MaxAge: format.Duration{Duration: 10 * time.Minute},
+	},
+	Registry: registry.Config{
+		File: "configs/registry.json",
 	},
 	Aggregator: aggregator.Config{
-		LogLevel:       "info",
-		ManualDataFile: "configs/manualData.json",
+		LogLevel:              "info",
+		ManualDataFile:        "configs/manualData.json",
+		DerivedFile:           "configs/derived.json",
+		CalendarFile:          "configs/calendar.json",
+		HoldConfidence:        0.9,
+		InterpolateConfidence: 0.95,
 	},
 
 	IndexTracker: index.Config{
 		LogLevel:  "info",
 		Interval:  format.Duration{Duration: 30 * time.Second},
 		IndexFile: "configs/index.json",
+		Capture: index.CaptureConfig{
+			Dir:       "db/captures",
+			Responses: 100,
+			MaxBytes:  10 * 1024 * 1024,
+		},
+		Retry: web.RetryConfig{
+			MaxAttempts:      5,
+			BackoffMin:       format.Duration{Duration: time.Second},
+			BackoffMax:       format.Duration{Duration: 30 * time.Second},
+			Jitter:           0.2,
+			BreakerThreshold: 5,
+			BreakerCooldown:  format.Duration{Duration: 5 * time.Minute},
+		},
+		Cache: true,
+		Warmup: index.WarmupConfig{
+			Enabled:    true,
+			Timeout:    format.Duration{Duration: 30 * time.Second},
+			RequestIDs: []int64{1, 2},
+		},
+	},
+	Supervisor: supervisor.Config{
+		LogLevel:   "info",
+		BackoffMin: format.Duration{Duration: time.Second},
+		BackoffMax: format.Duration{Duration: 5 * time.Minute},
+	},
+	Plugins: plugin.Config{
+		LogLevel:     "info",
+		StartTimeout: format.Duration{Duration: 10 * time.Second},
+	},
+	Simulation: simulation.Config{
+		LogLevel:    "info",
+		Backend:     simulation.BackendAnvil,
+		Port:        8545,
+		Interval:    format.Duration{Duration: 10 * time.Second},
+		FastForward: format.Duration{Duration: time.Minute},
+		Balance:     100,
+	},
+	Tracing: tracing.Config{
+		LogLevel:    "info",
+		Endpoint:    "localhost:4318",
+		Insecure:    true,
+		SampleRatio: 1,
+	},
+	Chaos: chaos.Config{
+		LogLevel:             "info",
+		RPCDelay:             0.1,
+		RPCMaxDelay:          format.Duration{Duration: 10 * time.Second},
+		SubscriptionDrop:     0.05,
+		SubscriptionInterval: format.Duration{Duration: time.Minute},
+		SourceError:          0.1,
+	},
+	Upgrade: upgrade.Config{
+		LogLevel: "info",
+		Timeout:  format.Duration{Duration: 2 * time.Minute},
+	},
+	Attestation: attestation.Config{
+		LogLevel:        "info",
+		Timeout:         format.Duration{Duration: 10 * time.Second},
+		Window:          format.Duration{Duration: 10 * time.Minute},
+		Path:            "db/attestations.jsonl",
+		MaxAttestations: 1000,
+	},
+	Evidence: evidence.Config{
+		LogLevel: "info",
+		IPFSURL:  "http://localhost:5001",
+		Timeout:  format.Duration{Duration: time.Minute},
+		Window:   format.Duration{Duration: 10 * time.Minute},
+	},
+	CrossCheck: crosscheck.Config{
+		LogLevel:      "info",
+		Tolerance:     3,
+		MinReferences: 1,
+		Timeout:       format.Duration{Duration: 10 * time.Second},
+		CacheTTL:      format.Duration{Duration: 30 * time.Second},
+		MaxAge:        format.Duration{Duration: 2 * time.Hour},
+		BandURL:       "https://laozi1.bandchain.org/api",
+		DIAURL:        "https://api.diadata.org",
+		// The Chainlink contracts are on mainnet.
+		Feeds: []crosscheck.Feed{
+			{RequestID: 1, Chainlink: "0x5f4eC3Df9cbd43714FE2740f5E3616155c5b8419", Band: "ETH/USD", DIA: "ETH/USD"},
+			{RequestID: 2, Chainlink: "0xF4030086522a5bEEa4988F8cA5B36dbC97BeE88c", Band: "BTC/USD", DIA: "BTC/USD"},
+		},
+	},
+	Treasury: treasury.Config{
+		LogLevel:  "info",
+		Interval:  format.Duration{Duration: 5 * time.Minute},
+		Threshold: 0.1,
+		TopUp:     0.2,
+		DailyCap:  0.6,
+		File:      "db/treasury.json",
+	},
+	Approval: approval.Config{
+		LogLevel:  "info",
+		Path:      "db/approvals.json",
+		Threshold: 1,
+		Functions: []string{
+			transactor.FunctionWithdrawStake,
+			transactor.FunctionRequestWithdraw,
+			transactor.FunctionBeginDispute,
+			transactor.FunctionTransfer,
+		},
+		MinTransfer: 100,
+		Expiry:      format.Duration{Duration: 24 * time.Hour},
+	},
+	Gateway: gateway.Config{
+		LogLevel:      "info",
+		CacheTTL:      format.Duration{Duration: 15 * time.Second},
+		RequestIDs:    []int64{1, 2},
+		MaxRequestIDs: 50,
+	},
+	Indexer: indexer.Config{
+		LogLevel:      "info",
+		Path:          "db/events.jsonl",
+		Interval:      format.Duration{Duration: time.Minute},
+		Confirmations: 12,
+		MaxResults:    1000,
+	},
+	Watchdog: watchdog.Config{
+		LogLevel: "info",
+		Deadlines: map[string]format.Duration{
+			"indexTracker.fetch": {Duration: 2 * time.Minute},
+			"db.commit":          {Duration: 30 * time.Second},
+			"disputeHistory":     {Duration: 10 * time.Minute},
+			"indexer":            {Duration: 10 * time.Minute},
+		},
+		Default: format.Duration{Duration: 5 * time.Minute},
 	},
 	EnvFile: "configs/.env",
 }
@@ -146,15 +568,72 @@ func ParseConfig(logger log.Logger, path string) (*Config, error) {
 			if err := dec.Decode(cfg); err == io.EOF {
 				break
 			} else if err != nil {
-				return nil, errors.Wrap(err, "parse config")
+				return nil, errclass.Wrap(errclass.Config, errors.Wrap(err, "parse config"))
 			}
 
 		}
 	}
 
+	deriveDbPaths(cfg)
+
+	if err := cfg.Ethereum.Verify.Validate(); err != nil {
+		return nil, errclass.Wrap(errclass.Config, errors.Wrap(err, "validating Ethereum.Verify"))
+	}
+
+	if err := validateSmartAccounts(cfg); err != nil {
+		return nil, errclass.Wrap(errclass.Config, err)
+	}
+
 	if err := godotenv.Load(cfg.EnvFile); err != nil && !os.IsNotExist(err) {
-		return nil, errors.Wrap(err, "loading env vars from env file")
+		return nil, errclass.Wrap(errclass.Config, errors.Wrap(err, "loading env vars from env file"))
+	}
+	// The keys are only read through the secret package from now on
+	// so that they aren't inherited by the child processes like the hooks and the plugins.
+	if err := secret.Take(ethereum.PrivateKeysEnvName, ethereum.RetiredKeysEnvName, attestation.PrivateKeyEnvName, treasury.KeyEnvName); err != nil {
+		return nil, errors.Wrap(err, "removing the keys from the env")
 	}
 
 	return cfg, nil
 }
+
+// validateSmartAccounts rejects the smart accounts with the mining submitter
+// which submits from all accounts while its proof of work is tied to the address of the miner,
+// so its solutions sent through a smart account would always revert.
+func validateSmartAccounts(cfg *Config) error {
+	if cfg.SubmitterTellor.Enabled && cfg.Transactor.UserOp.Enabled && len(cfg.Transactor.UserOp.Accounts) > 0 {
+		return errors.New("the smart accounts of Transactor.UserOp can't be used with SubmitterTellor which submits the mining solutions with transactions, disable one of them")
+	}
+	return nil
+}
+
+// dbFiles are the files kept in the DB dir by default.
+func dbFiles(cfg *Config) []*string {
+	return []*string{
+		&cfg.Db.JournalPath,
+		&cfg.Db.AuditPath,
+		&cfg.DisputeTracker.PendingPath,
+		&cfg.Tipper.File,
+		&cfg.IndexTracker.Capture.Dir,
+		&cfg.Transactor.Budget.File,
+		&cfg.Treasury.File,
+		&cfg.Approval.Path,
+		&cfg.Web.Auth.KeysPath,
+		&cfg.DisputeHistory.Path,
+		&cfg.Indexer.Path,
+		&cfg.Attestation.Path,
+	}
+}
+
+// deriveDbPaths moves the files that are still at their default location in the default DB dir
+// to the configured DB dir so that changing Db.Path moves all of them.
+func deriveDbPaths(cfg *Config) {
+	if cfg.Db.Path == DefaultConfig.Db.Path {
+		return
+	}
+	defaults := dbFiles(&DefaultConfig)
+	for i, path := range dbFiles(cfg) {
+		if *path == *defaults[i] {
+			*path = filepath.Join(cfg.Db.Path, strings.TrimPrefix(*path, DefaultConfig.Db.Path+"/"))
+		}
+	}
+}
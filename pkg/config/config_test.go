@@ -5,6 +5,8 @@ package config
 
 import (
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/tellor-io/telliot/pkg/testutil"
@@ -39,3 +41,69 @@ func TestConfig(t *testing.T) {
 	testutil.Assert(t, cfg.Transactor.GasMultiplier > 0, "GasMultiplier should have value")
 
 }
+
+// TestDeriveDbPaths ensures that the files at their default location follow the DB dir
+// while the files set explicitly stay where they are.
+func TestDeriveDbPaths(t *testing.T) {
+	cfg := DefaultConfig
+	cfg.Db.Path = "/data/telliot"
+	deriveDbPaths(&cfg)
+	testutil.Equals(t, "/data/telliot/submissions.journal", cfg.Db.JournalPath)
+	testutil.Equals(t, "/data/telliot/audit.log", cfg.Db.AuditPath)
+	testutil.Equals(t, "/data/telliot/dispute.pending", cfg.DisputeTracker.PendingPath)
+	testutil.Equals(t, "/data/telliot/tipper.json", cfg.Tipper.File)
+	testutil.Equals(t, "/data/telliot/captures", cfg.IndexTracker.Capture.Dir)
+	testutil.Equals(t, "/data/telliot/budget.json", cfg.Transactor.Budget.File)
+	testutil.Equals(t, "/data/telliot/treasury.json", cfg.Treasury.File)
+	testutil.Equals(t, "/data/telliot/approvals.json", cfg.Approval.Path)
+	testutil.Equals(t, "/data/telliot/apikeys.json", cfg.Web.Auth.KeysPath)
+	testutil.Equals(t, "/data/telliot/disputes.jsonl", cfg.DisputeHistory.Path)
+	testutil.Equals(t, "/data/telliot/events.jsonl", cfg.Indexer.Path)
+	testutil.Equals(t, "/data/telliot/attestations.jsonl", cfg.Attestation.Path)
+
+	cfg = DefaultConfig
+	cfg.Db.Path = "/data/telliot"
+	cfg.Db.AuditPath = "/var/log/telliot/audit.log"
+	deriveDbPaths(&cfg)
+	testutil.Equals(t, "/var/log/telliot/audit.log", cfg.Db.AuditPath)
+
+	cfg = DefaultConfig
+	deriveDbPaths(&cfg)
+	testutil.Equals(t, DefaultConfig.Db.AuditPath, cfg.Db.AuditPath)
+
+	// Every default in the DB dir follows it.
+	cfg = DefaultConfig
+	derived := make(map[*string]bool)
+	for _, path := range dbFiles(&cfg) {
+		derived[path] = true
+	}
+	var walk func(v reflect.Value, name string)
+	walk = func(v reflect.Value, name string) {
+		switch v.Kind() {
+		case reflect.Struct:
+			for i := 0; i < v.NumField(); i++ {
+				if v.Type().Field(i).PkgPath == "" {
+					walk(v.Field(i), name+"."+v.Type().Field(i).Name)
+				}
+			}
+		case reflect.String:
+			if strings.HasPrefix(v.String(), DefaultConfig.Db.Path+"/") {
+				testutil.Assert(t, derived[v.Addr().Interface().(*string)], "%v isn't in the DB files", name)
+			}
+		}
+	}
+	walk(reflect.ValueOf(&cfg).Elem(), "Config")
+}
+
+// TestValidateSmartAccounts ensures that the smart accounts are rejected for the mining submitter.
+func TestValidateSmartAccounts(t *testing.T) {
+	cfg := DefaultConfig
+	testutil.Ok(t, validateSmartAccounts(&cfg))
+
+	cfg.Transactor.UserOp.Enabled = true
+	cfg.Transactor.UserOp.Accounts = map[string]string{"0x01": "0x02"}
+	testutil.NotOk(t, validateSmartAccounts(&cfg), "the mining submitter is enabled")
+
+	cfg.SubmitterTellor.Enabled = false
+	testutil.Ok(t, validateSmartAccounts(&cfg))
+}
@@ -26,6 +26,9 @@ func OpenTestConfig(dir string) (*Config, error) {
 	cfg.IndexTracker.IndexFile = filepath.Join(rootDir, cfg.IndexTracker.IndexFile)
 	cfg.EnvFile = filepath.Join(rootDir, cfg.EnvFile+".example")
 	cfg.Aggregator.ManualDataFile = filepath.Join(rootDir, cfg.Aggregator.ManualDataFile)
+	cfg.Aggregator.DerivedFile = filepath.Join(rootDir, cfg.Aggregator.DerivedFile)
+	cfg.Registry.File = filepath.Join(rootDir, cfg.Registry.File)
+	cfg.PsrTellor.USPCE.File = filepath.Join(rootDir, cfg.PsrTellor.USPCE.File)
 
 	return &cfg, nil
 
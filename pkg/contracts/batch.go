@@ -0,0 +1,97 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package contracts
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// maxBatchSize is the max number of calls in a single JSON-RPC batch
+// as most providers reject larger batches.
+const maxBatchSize = 100
+
+// BatchCaller is implemented by the clients that can send many calls in a single JSON-RPC batch request.
+type BatchCaller interface {
+	// BatchCallContract returns the result or the error of every call in the order of the calls.
+	// The returned error is set only when the whole batch failed.
+	BatchCallContract(ctx context.Context, calls []ethereum.CallMsg, blockNumber *big.Int) ([][]byte, []error, error)
+}
+
+// BatchCall is a contract call in a batch.
+type BatchCall struct {
+	Contract common.Address
+	ABI      *abi.ABI
+	Method   string
+	Args     []interface{}
+
+	// Result is set to the unpacked outputs of the method or Err when the call failed.
+	Result []interface{}
+	Err    error
+}
+
+// ParseABI parses one of the contract ABIs for the batch calls.
+func ParseABI(raw string) (*abi.ABI, error) {
+	parsed, err := abi.JSON(strings.NewReader(raw))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse abi")
+	}
+	return &parsed, nil
+}
+
+// CallBatch reads many contract values with as few requests as possible
+// so that the trackers reading a value per account or request ID each cycle
+// don't exhaust the rate limits of the providers.
+// The clients that don't support batching make a request per call.
+// The error of a single call is set in its Err and the returned error only when the whole batch failed.
+func CallBatch(ctx context.Context, client ETHClient, blockNumber *big.Int, calls []*BatchCall) error {
+	msgs := make([]ethereum.CallMsg, len(calls))
+	for i, call := range calls {
+		data, err := call.ABI.Pack(call.Method, call.Args...)
+		if err != nil {
+			return errors.Wrapf(err, "pack method:%v", call.Method)
+		}
+		to := call.Contract
+		msgs[i] = ethereum.CallMsg{To: &to, Data: data}
+	}
+
+	batcher, ok := client.(BatchCaller)
+	for start := 0; start < len(calls); start += maxBatchSize {
+		end := start + maxBatchSize
+		if end > len(calls) {
+			end = len(calls)
+		}
+		var (
+			results [][]byte
+			errs    []error
+		)
+		if ok {
+			var err error
+			results, errs, err = batcher.BatchCallContract(ctx, msgs[start:end], blockNumber)
+			if err != nil {
+				return errors.Wrap(err, "batch call")
+			}
+		} else {
+			for _, msg := range msgs[start:end] {
+				r, err := client.CallContract(ctx, msg, blockNumber)
+				results = append(results, r)
+				errs = append(errs, err)
+			}
+		}
+		for i, call := range calls[start:end] {
+			if errs[i] != nil {
+				call.Err = errs[i]
+				continue
+			}
+			call.Result, call.Err = call.ABI.Unpack(call.Method, results[i])
+		}
+	}
+	return nil
+}
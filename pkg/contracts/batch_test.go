@@ -0,0 +1,93 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package contracts
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+const balanceABI = `[{"inputs":[{"name":"_user","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"}]`
+
+// balanceClient returns the last byte of the account as its balance
+// and an error for the zero address.
+type balanceClient struct {
+	ETHClient
+	abi   *abi.ABI
+	calls int
+}
+
+func (self *balanceClient) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	self.calls++
+	addr := common.BytesToAddress(call.Data[len(call.Data)-common.AddressLength:])
+	if addr == (common.Address{}) {
+		return nil, errors.New("execution reverted")
+	}
+	return self.abi.Methods["balanceOf"].Outputs.Pack(big.NewInt(int64(addr[common.AddressLength-1])))
+}
+
+// batchClient sends the calls in batches.
+type batchClient struct {
+	*balanceClient
+	batches []int
+}
+
+func (self *batchClient) BatchCallContract(ctx context.Context, calls []ethereum.CallMsg, blockNumber *big.Int) ([][]byte, []error, error) {
+	self.batches = append(self.batches, len(calls))
+	var (
+		results [][]byte
+		errs    []error
+	)
+	for _, call := range calls {
+		r, err := self.balanceClient.CallContract(ctx, call, blockNumber)
+		results = append(results, r)
+		errs = append(errs, err)
+	}
+	return results, errs, nil
+}
+
+func TestCallBatch(t *testing.T) {
+	parsed, err := ParseABI(balanceABI)
+	testutil.Ok(t, err)
+
+	newCalls := func(n int) []*BatchCall {
+		calls := make([]*BatchCall, n)
+		for i := range calls {
+			calls[i] = &BatchCall{
+				ABI:    parsed,
+				Method: "balanceOf",
+				Args:   []interface{}{common.BigToAddress(big.NewInt(int64(i)))},
+			}
+		}
+		return calls
+	}
+	check := func(calls []*BatchCall) {
+		testutil.NotOk(t, calls[0].Err)
+		for i, call := range calls[1:] {
+			testutil.Ok(t, call.Err)
+			testutil.Equals(t, int64((i+1)%256), call.Result[0].(*big.Int).Int64())
+		}
+	}
+
+	// Fallback to a request per call.
+	client := &balanceClient{abi: parsed}
+	calls := newCalls(5)
+	testutil.Ok(t, CallBatch(context.Background(), client, nil, calls))
+	testutil.Equals(t, 5, client.calls)
+	check(calls)
+
+	// Batches of the max size.
+	batcher := &batchClient{balanceClient: &balanceClient{abi: parsed}}
+	calls = newCalls(maxBatchSize*2 + 1)
+	testutil.Ok(t, CallBatch(context.Background(), batcher, nil, calls))
+	testutil.Equals(t, []int{maxBatchSize, maxBatchSize, 1}, batcher.batches)
+	check(calls)
+}
@@ -0,0 +1,493 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package contracts
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/contracts/tellor"
+	"github.com/tellor-io/telliot/pkg/contracts/tellorAccess"
+	"github.com/tellor-io/telliot/pkg/errclass"
+)
+
+// Version is the generation of the Tellor contracts.
+type Version string
+
+const (
+	// VersionLegacy is the proof of work Tellor behind the master proxy.
+	VersionLegacy Version = "legacy"
+	// VersionTellorX is the Tellor X master with a separate oracle contract.
+	VersionTellorX Version = "tellorX"
+	// VersionTellorFlex is the standalone TellorFlex oracle.
+	VersionTellorFlex Version = "tellorFlex"
+)
+
+// ErrUnsupported is returned when the contract version doesn't have a capability.
+var ErrUnsupported = errclass.New(errclass.Config, "not supported by the contract version")
+
+var oracleContractKey = crypto.Keccak256Hash([]byte("_ORACLE_CONTRACT"))
+
+// Staker manages the stake of a reporter.
+type Staker interface {
+	// StakerInfo returns the legacy staker status(see StakerStatusName) and the time it was staked.
+	StakerInfo(ctx context.Context, staker common.Address) (int64, time.Time, error)
+	StakeAmount(ctx context.Context) (*big.Int, error)
+	DepositStake(opts *bind.TransactOpts) (*types.Transaction, error)
+	RequestStakingWithdraw(opts *bind.TransactOpts) (*types.Transaction, error)
+	WithdrawStake(opts *bind.TransactOpts) (*types.Transaction, error)
+}
+
+// ValueReader reads the submitted values.
+// The legacy request IDs are converted with LegacyQueryID.
+type ValueReader interface {
+	ValueCount(ctx context.Context, queryID [32]byte) (int64, error)
+	TimestampByIndex(ctx context.Context, queryID [32]byte, index int64) (time.Time, error)
+	Value(ctx context.Context, queryID [32]byte, ts time.Time) (*big.Int, error)
+}
+
+// ReporterReader reads the reporters of the submitted values.
+// The reporter of a legacy value is the miner of the median, which is the official value.
+type ReporterReader interface {
+	Reporter(ctx context.Context, queryID [32]byte, ts time.Time) (common.Address, error)
+}
+
+// MiningSubmitter submits proof of work solutions.
+type MiningSubmitter interface {
+	SubmitMiningSolution(opts *bind.TransactOpts, nonce string, requestIDs [5]*big.Int, values [5]*big.Int) (*types.Transaction, error)
+}
+
+// ValueSubmitter submits single values without proof of work.
+type ValueSubmitter interface {
+	SubmitValue(opts *bind.TransactOpts, queryID [32]byte, value []byte, nonce *big.Int, queryData []byte) (*types.Transaction, error)
+}
+
+// Disputer opens and votes on disputes.
+type Disputer interface {
+	BeginDispute(opts *bind.TransactOpts, queryID [32]byte, ts time.Time, minerIndex int64) (*types.Transaction, error)
+	Vote(opts *bind.TransactOpts, disputeID *big.Int, supports bool) (*types.Transaction, error)
+}
+
+// Capabilities are the contract operations available for the detected version.
+// Use the getters which return ErrUnsupported when the version doesn't have the capability.
+type Capabilities struct {
+	Version Version
+	Address common.Address
+
+	staker          Staker
+	reader          ValueReader
+	reporters       ReporterReader
+	miningSubmitter MiningSubmitter
+	valueSubmitter  ValueSubmitter
+	disputer        Disputer
+}
+
+func (self *Capabilities) Staker() (Staker, error) {
+	if self.staker == nil {
+		return nil, errors.Wrapf(ErrUnsupported, "staking version:%v", self.Version)
+	}
+	return self.staker, nil
+}
+
+func (self *Capabilities) Reader() (ValueReader, error) {
+	if self.reader == nil {
+		return nil, errors.Wrapf(ErrUnsupported, "reading values version:%v", self.Version)
+	}
+	return self.reader, nil
+}
+
+func (self *Capabilities) Reporters() (ReporterReader, error) {
+	if self.reporters == nil {
+		return nil, errors.Wrapf(ErrUnsupported, "reading reporters version:%v", self.Version)
+	}
+	return self.reporters, nil
+}
+
+func (self *Capabilities) MiningSubmitter() (MiningSubmitter, error) {
+	if self.miningSubmitter == nil {
+		return nil, errors.Wrapf(ErrUnsupported, "submitting mining solutions version:%v", self.Version)
+	}
+	return self.miningSubmitter, nil
+}
+
+func (self *Capabilities) ValueSubmitter() (ValueSubmitter, error) {
+	if self.valueSubmitter == nil {
+		return nil, errors.Wrapf(ErrUnsupported, "submitting values version:%v", self.Version)
+	}
+	return self.valueSubmitter, nil
+}
+
+func (self *Capabilities) Disputer() (Disputer, error) {
+	if self.disputer == nil {
+		return nil, errors.Wrapf(ErrUnsupported, "disputes version:%v", self.Version)
+	}
+	return self.disputer, nil
+}
+
+// LegacyQueryID converts a legacy request ID to a query ID.
+func LegacyQueryID(requestID int64) [32]byte {
+	return common.BigToHash(big.NewInt(requestID))
+}
+
+// DetectVersion checks the deployed code for the selectors of each contract version.
+func DetectVersion(ctx context.Context, client ETHClient, addr common.Address) (Version, error) {
+	code, err := client.CodeAt(ctx, addr, nil)
+	if err != nil {
+		return "", errors.Wrap(err, "getting the contract code")
+	}
+	if len(code) == 0 {
+		return "", errors.Errorf("no contract at:%v", addr.Hex())
+	}
+	// TellorFlex is not behind a proxy so the selectors are in its own code.
+	if hasSelector(code, "submitValue(bytes32,bytes,uint256,bytes)") {
+		return VersionTellorFlex, nil
+	}
+
+	addrs, err := Discover(ctx, client, addr)
+	if err != nil {
+		return "", err
+	}
+	code, err = client.CodeAt(ctx, addrs.Implementation, nil)
+	if err != nil {
+		return "", errors.Wrap(err, "getting the implementation code")
+	}
+	if hasSelector(code, "submitMiningSolution(string,uint256[5],uint256[5])") {
+		return VersionLegacy, nil
+	}
+	oracle, err := oracleAddress(ctx, client, addr)
+	if err != nil {
+		return "", err
+	}
+	if oracle != (common.Address{}) {
+		return VersionTellorX, nil
+	}
+	return "", errors.Errorf("unknown contract version at:%v", addr.Hex())
+}
+
+// hasSelector reports whether the code pushes the function selector
+// which is how the solidity dispatcher matches the called function.
+func hasSelector(code []byte, signature string) bool {
+	return pushes(code, crypto.Keccak256([]byte(signature))[:4])
+}
+
+const (
+	opPush0  = 0x5f
+	opPush1  = 0x60
+	opPush32 = 0x7f
+)
+
+// pushes reports whether any push instruction of the code pushes the value.
+// The optimizer pushes a selector with leading zero bytes with a shorter push
+// and the push data is skipped so that it isn't mistaken for instructions.
+func pushes(code []byte, value []byte) bool {
+	value = bytes.TrimLeft(value, "\x00")
+	for i := 0; i < len(code); i++ {
+		op := code[i]
+		if op == opPush0 {
+			if len(value) == 0 {
+				return true
+			}
+			continue
+		}
+		if op < opPush1 || op > opPush32 {
+			continue
+		}
+		end := i + 1 + int(op-opPush1) + 1
+		if end > len(code) {
+			return false
+		}
+		if bytes.Equal(bytes.TrimLeft(code[i+1:end], "\x00"), value) {
+			return true
+		}
+		i = end - 1
+	}
+	return false
+}
+
+func oracleAddress(ctx context.Context, client ETHClient, master common.Address) (common.Address, error) {
+	caller, err := tellor.NewITellorCaller(master, client)
+	if err != nil {
+		return common.Address{}, errors.Wrap(err, "creating master instance")
+	}
+	oracle, err := caller.GetAddressVars(&bind.CallOpts{Context: ctx}, oracleContractKey)
+	if err != nil {
+		return common.Address{}, errors.Wrap(err, "getting the oracle address")
+	}
+	return oracle, nil
+}
+
+// NewCapabilities detects the contract version and creates the implementations for its capabilities.
+func NewCapabilities(ctx context.Context, client ETHClient, addr common.Address) (*Capabilities, error) {
+	version, err := DetectVersion(ctx, client, addr)
+	if err != nil {
+		return nil, errors.Wrap(err, "detecting the contract version")
+	}
+	caps := &Capabilities{Version: version, Address: addr}
+
+	switch version {
+	case VersionLegacy:
+		instance, err := tellor.NewITellor(addr, client)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating tellor instance")
+		}
+		l := &legacy{ITellor: instance}
+		caps.staker, caps.reader, caps.reporters, caps.miningSubmitter, caps.disputer = l, l, l, l, l
+	case VersionTellorX:
+		instance, err := tellor.NewITellor(addr, client)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating tellor instance")
+		}
+		// The master kept the legacy staking functions.
+		caps.staker = &legacy{ITellor: instance}
+		oracleAddr, err := oracleAddress(ctx, client, addr)
+		if err != nil {
+			return nil, err
+		}
+		oracle, err := newBound(oracleAddr, tellorXOracleABI, client)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating oracle instance")
+		}
+		o := &valueOracle{
+			BoundContract: oracle,
+			countMethod:   "getTimestampCountById",
+			indexMethod:   "getReportTimestampByIndex",
+			valueMethod:   "getValueByTimestamp",
+		}
+		caps.reader, caps.reporters, caps.valueSubmitter = o, o, o
+	case VersionTellorFlex:
+		contract, err := newBound(addr, tellorFlexABI, client)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating flex instance")
+		}
+		o := &valueOracle{
+			BoundContract: contract,
+			countMethod:   "getNewValueCountbyQueryId",
+			indexMethod:   "getTimestampbyQueryIdandIndex",
+			valueMethod:   "retrieveData",
+		}
+		caps.reader, caps.reporters, caps.valueSubmitter = o, o, o
+		caps.staker = &flexStaker{BoundContract: contract}
+	}
+	return caps, nil
+}
+
+func newBound(addr common.Address, abiJSON string, client ETHClient) (*bind.BoundContract, error) {
+	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse abi")
+	}
+	return bind.NewBoundContract(addr, parsed, client, client, client), nil
+}
+
+// legacy implements all capabilities with the generated bindings.
+type legacy struct {
+	*tellor.ITellor
+}
+
+func (self *legacy) StakerInfo(ctx context.Context, staker common.Address) (int64, time.Time, error) {
+	status, started, err := self.GetStakerInfo(&bind.CallOpts{Context: ctx}, staker)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return status.Int64(), time.Unix(started.Int64(), 0), nil
+}
+
+func (self *legacy) StakeAmount(ctx context.Context) (*big.Int, error) {
+	return self.GetUintVar(&bind.CallOpts{Context: ctx}, crypto.Keccak256Hash([]byte("_STAKE_AMOUNT")))
+}
+
+func (self *legacy) ValueCount(ctx context.Context, queryID [32]byte) (int64, error) {
+	count, err := self.GetNewValueCountbyRequestId(&bind.CallOpts{Context: ctx}, new(big.Int).SetBytes(queryID[:]))
+	if err != nil {
+		return 0, err
+	}
+	return count.Int64(), nil
+}
+
+func (self *legacy) TimestampByIndex(ctx context.Context, queryID [32]byte, index int64) (time.Time, error) {
+	ts, err := self.GetTimestampbyRequestIDandIndex(&bind.CallOpts{Context: ctx}, new(big.Int).SetBytes(queryID[:]), big.NewInt(index))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(ts.Int64(), 0), nil
+}
+
+func (self *legacy) Value(ctx context.Context, queryID [32]byte, ts time.Time) (*big.Int, error) {
+	return self.RetrieveData(&bind.CallOpts{Context: ctx}, new(big.Int).SetBytes(queryID[:]), big.NewInt(ts.Unix()))
+}
+
+// Reporter returns the miner of the median value which is at the middle index.
+func (self *legacy) Reporter(ctx context.Context, queryID [32]byte, ts time.Time) (common.Address, error) {
+	miners, err := self.GetMinersByRequestIdAndTimestamp(&bind.CallOpts{Context: ctx}, new(big.Int).SetBytes(queryID[:]), big.NewInt(ts.Unix()))
+	if err != nil {
+		return common.Address{}, err
+	}
+	return miners[2], nil
+}
+
+func (self *legacy) BeginDispute(opts *bind.TransactOpts, queryID [32]byte, ts time.Time, minerIndex int64) (*types.Transaction, error) {
+	return self.ITellor.BeginDispute(opts, new(big.Int).SetBytes(queryID[:]), big.NewInt(ts.Unix()), big.NewInt(minerIndex))
+}
+
+// valueOracle implements reading and submitting values for the contracts
+// that store the values as bytes under query IDs.
+type valueOracle struct {
+	*bind.BoundContract
+	countMethod string
+	indexMethod string
+	valueMethod string
+}
+
+func (self *valueOracle) ValueCount(ctx context.Context, queryID [32]byte) (int64, error) {
+	var out []interface{}
+	if err := self.Call(&bind.CallOpts{Context: ctx}, &out, self.countMethod, queryID); err != nil {
+		return 0, err
+	}
+	return abi.ConvertType(out[0], new(big.Int)).(*big.Int).Int64(), nil
+}
+
+func (self *valueOracle) TimestampByIndex(ctx context.Context, queryID [32]byte, index int64) (time.Time, error) {
+	var out []interface{}
+	if err := self.Call(&bind.CallOpts{Context: ctx}, &out, self.indexMethod, queryID, big.NewInt(index)); err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(abi.ConvertType(out[0], new(big.Int)).(*big.Int).Int64(), 0), nil
+}
+
+// Value decodes the value as an uint256 which is how the legacy request IDs are reported.
+func (self *valueOracle) Value(ctx context.Context, queryID [32]byte, ts time.Time) (*big.Int, error) {
+	var out []interface{}
+	if err := self.Call(&bind.CallOpts{Context: ctx}, &out, self.valueMethod, queryID, big.NewInt(ts.Unix())); err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(*abi.ConvertType(out[0], new([]byte)).(*[]byte)), nil
+}
+
+// Reporter uses the same function name in both contract versions.
+func (self *valueOracle) Reporter(ctx context.Context, queryID [32]byte, ts time.Time) (common.Address, error) {
+	var out []interface{}
+	if err := self.Call(&bind.CallOpts{Context: ctx}, &out, "getReporterByTimestamp", queryID, big.NewInt(ts.Unix())); err != nil {
+		return common.Address{}, err
+	}
+	return *abi.ConvertType(out[0], new(common.Address)).(*common.Address), nil
+}
+
+func (self *valueOracle) SubmitValue(opts *bind.TransactOpts, queryID [32]byte, value []byte, nonce *big.Int, queryData []byte) (*types.Transaction, error) {
+	return self.Transact(opts, "submitValue", queryID, value, nonce, queryData)
+}
+
+// flexStaker maps the TellorFlex staked and locked balances to the legacy staker status.
+type flexStaker struct {
+	*bind.BoundContract
+}
+
+func (self *flexStaker) StakerInfo(ctx context.Context, staker common.Address) (int64, time.Time, error) {
+	var out []interface{}
+	if err := self.Call(&bind.CallOpts{Context: ctx}, &out, "getStakerInfo", staker); err != nil {
+		return 0, time.Time{}, err
+	}
+	started := abi.ConvertType(out[0], new(big.Int)).(*big.Int)
+	staked := abi.ConvertType(out[1], new(big.Int)).(*big.Int)
+	locked := abi.ConvertType(out[2], new(big.Int)).(*big.Int)
+	switch {
+	case locked.Sign() > 0:
+		return 2, time.Unix(started.Int64(), 0), nil
+	case staked.Sign() > 0:
+		return 1, time.Unix(started.Int64(), 0), nil
+	default:
+		return 0, time.Time{}, nil
+	}
+}
+
+func (self *flexStaker) StakeAmount(ctx context.Context) (*big.Int, error) {
+	var out []interface{}
+	if err := self.Call(&bind.CallOpts{Context: ctx}, &out, "stakeAmount"); err != nil {
+		return nil, err
+	}
+	return abi.ConvertType(out[0], new(big.Int)).(*big.Int), nil
+}
+
+func (self *flexStaker) DepositStake(opts *bind.TransactOpts) (*types.Transaction, error) {
+	amount, err := self.StakeAmount(opts.Context)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting the stake amount")
+	}
+	return self.Transact(opts, "depositStake", amount)
+}
+
+// RequestStakingWithdraw requests to withdraw the whole staked balance.
+func (self *flexStaker) RequestStakingWithdraw(opts *bind.TransactOpts) (*types.Transaction, error) {
+	var out []interface{}
+	if err := self.Call(&bind.CallOpts{Context: opts.Context}, &out, "getStakerInfo", opts.From); err != nil {
+		return nil, errors.Wrap(err, "getting the staked balance")
+	}
+	return self.Transact(opts, "requestStakingWithdraw", abi.ConvertType(out[1], new(big.Int)).(*big.Int))
+}
+
+func (self *flexStaker) WithdrawStake(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return self.Transact(opts, "withdrawStake")
+}
+
+// The minimal ABIs of the functions used from the newer contracts.
+// getStakerInfo declares only the outputs that are the same in all TellorFlex releases.
+const (
+	tellorXOracleABI = `[
+{"inputs":[{"name":"_queryId","type":"bytes32"}],"name":"getTimestampCountById","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+{"inputs":[{"name":"_queryId","type":"bytes32"},{"name":"_index","type":"uint256"}],"name":"getReportTimestampByIndex","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+{"inputs":[{"name":"_queryId","type":"bytes32"},{"name":"_timestamp","type":"uint256"}],"name":"getValueByTimestamp","outputs":[{"name":"","type":"bytes"}],"stateMutability":"view","type":"function"},
+{"inputs":[{"name":"_queryId","type":"bytes32"},{"name":"_timestamp","type":"uint256"}],"name":"getReporterByTimestamp","outputs":[{"name":"","type":"address"}],"stateMutability":"view","type":"function"},
+{"inputs":[{"name":"_queryId","type":"bytes32"},{"name":"_value","type":"bytes"},{"name":"_nonce","type":"uint256"},{"name":"_queryData","type":"bytes"}],"name":"submitValue","outputs":[],"stateMutability":"nonpayable","type":"function"}
+]`
+	tellorFlexABI = `[
+{"inputs":[{"name":"_queryId","type":"bytes32"}],"name":"getNewValueCountbyQueryId","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+{"inputs":[{"name":"_queryId","type":"bytes32"},{"name":"_index","type":"uint256"}],"name":"getTimestampbyQueryIdandIndex","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+{"inputs":[{"name":"_queryId","type":"bytes32"},{"name":"_timestamp","type":"uint256"}],"name":"retrieveData","outputs":[{"name":"","type":"bytes"}],"stateMutability":"view","type":"function"},
+{"inputs":[{"name":"_queryId","type":"bytes32"},{"name":"_timestamp","type":"uint256"}],"name":"getReporterByTimestamp","outputs":[{"name":"","type":"address"}],"stateMutability":"view","type":"function"},
+{"inputs":[{"name":"_queryId","type":"bytes32"},{"name":"_value","type":"bytes"},{"name":"_nonce","type":"uint256"},{"name":"_queryData","type":"bytes"}],"name":"submitValue","outputs":[],"stateMutability":"nonpayable","type":"function"},
+{"inputs":[{"name":"_staker","type":"address"}],"name":"getStakerInfo","outputs":[{"name":"","type":"uint256"},{"name":"","type":"uint256"},{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+{"inputs":[],"name":"stakeAmount","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+{"inputs":[{"name":"_amount","type":"uint256"}],"name":"depositStake","outputs":[],"stateMutability":"nonpayable","type":"function"},
+{"inputs":[{"name":"_amount","type":"uint256"}],"name":"requestStakingWithdraw","outputs":[],"stateMutability":"nonpayable","type":"function"},
+{"inputs":[],"name":"withdrawStake","outputs":[],"stateMutability":"nonpayable","type":"function"}
+]`
+)
+
+// Reader returns the values of the TellorAccess contract which is keyed by the legacy request IDs.
+func (self *ITellorAccess) Reader() ValueReader {
+	return &accessReader{TellorAccess: self.TellorAccess}
+}
+
+// accessReader reads the values of the TellorAccess contract.
+type accessReader struct {
+	*tellorAccess.TellorAccess
+}
+
+func (self *accessReader) ValueCount(ctx context.Context, queryID [32]byte) (int64, error) {
+	count, err := self.GetNewValueCountbyRequestId(&bind.CallOpts{Context: ctx}, new(big.Int).SetBytes(queryID[:]))
+	if err != nil {
+		return 0, err
+	}
+	return count.Int64(), nil
+}
+
+func (self *accessReader) TimestampByIndex(ctx context.Context, queryID [32]byte, index int64) (time.Time, error) {
+	ts, err := self.GetTimestampbyRequestIDandIndex(&bind.CallOpts{Context: ctx}, new(big.Int).SetBytes(queryID[:]), big.NewInt(index))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(ts.Int64(), 0), nil
+}
+
+func (self *accessReader) Value(ctx context.Context, queryID [32]byte, ts time.Time) (*big.Int, error) {
+	return self.RetrieveData(&bind.CallOpts{Context: ctx}, new(big.Int).SetBytes(queryID[:]), big.NewInt(ts.Unix()))
+}
@@ -0,0 +1,43 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package contracts
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tellor-io/telliot/pkg/contracts/tellorAccess"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+func TestHasSelector(t *testing.T) {
+	code := common.FromHex(tellorAccess.TellorAccessBin)
+	testutil.Assert(t, hasSelector(code, "submitValue(uint256,uint256)"), "the selector of a function of the contract not found")
+	testutil.Assert(t, !hasSelector(code, "submitMiningSolution(string,uint256[5],uint256[5])"), "the selector of a function of another contract found")
+}
+
+func TestPushes(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		code     string
+		value    string
+		expected bool
+	}{
+		{name: "push4", code: "0x8063a3183701146103f0", value: "0xa3183701", expected: true},
+		// The optimizer drops the leading zero bytes.
+		{name: "push3", code: "0x806262f55114", value: "0x0062f551", expected: true},
+		{name: "push2", code: "0x80610f1e14", value: "0x00000f1e", expected: true},
+		{name: "push1", code: "0x80601e14", value: "0x0000001e", expected: true},
+		{name: "push0", code: "0x805f14", value: "0x00000000", expected: true},
+		{name: "padded push", code: "0x806700000000a318370114", value: "0xa3183701", expected: true},
+		{name: "another value", code: "0x8063a3183702146103f0", value: "0xa3183701", expected: false},
+		// A value within the data of another push isn't an instruction.
+		{name: "push data", code: "0x6563a31837011400", value: "0xa3183701", expected: false},
+		{name: "cut push", code: "0x8063a31837", value: "0xa3183701", expected: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			testutil.Equals(t, tc.expected, pushes(common.FromHex(tc.code), common.FromHex(tc.value)))
+		})
+	}
+}
@@ -35,6 +35,7 @@ const (
 	IERC20ABI         = uniswap.IERC20ABI
 	IUniswapV2PairABI = uniswap.IUniswapV2PairABI
 	ITellorABI        = tellor.ITellorABI
+	TellorAccessABI   = tellorAccess.TellorAccessABI
 )
 
 type ITellorAccess struct {
@@ -89,6 +90,9 @@ type ETHClient interface {
 	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
 }
 
+// errNoLens is returned when the lens is not deployed on the current network.
+var errNoLens = errors.New("lens not deployed on the current network")
+
 func getLensAddress(client ETHClient) (common.Address, error) {
 	networkID, err := client.NetworkID(context.Background())
 	if err != nil {
@@ -100,26 +104,43 @@ func getLensAddress(client ETHClient) (common.Address, error) {
 	case 4:
 		return common.HexToAddress(LensAddressRinkeby), nil
 	default:
-		return common.Address{}, errors.Errorf("contract address for current network id not found:%v", netID)
+		return common.Address{}, errors.Wrapf(errNoLens, "network id:%v", netID)
 	}
 }
 
 func NewITellor(client ETHClient) (*ITellor, error) {
-	tellorInstance, err := tellor.NewITellor(common.HexToAddress(TellorAddress), client)
+	contract, err := NewITellorAt(client, common.HexToAddress(TellorAddress))
+	if err != nil {
+		return nil, err
+	}
+	if contract.Main == nil {
+		return nil, errors.Wrap(errNoLens, "creating lens address")
+	}
+	return contract, nil
+}
+
+// NewITellorAt creates the contract instance for a master contract at a custom address.
+// The lens instance is nil when the lens is not deployed on the current network.
+func NewITellorAt(client ETHClient, addr common.Address) (*ITellor, error) {
+	tellorInstance, err := tellor.NewITellor(addr, client)
 	if err != nil {
 		return nil, errors.Wrap(err, "creating telllor interface")
 	}
-	contractAddr, err := getLensAddress(client)
+	contract := &ITellor{Address: addr, ITellor: tellorInstance}
+
+	lensAddr, err := getLensAddress(client)
+	if errors.Cause(err) == errNoLens {
+		return contract, nil
+	}
 	if err != nil {
 		return nil, errors.Wrap(err, "creating lens address")
 	}
-
-	lensInstance, err := lens.NewMain(contractAddr, client)
+	lensInstance, err := lens.NewMain(lensAddr, client)
 	if err != nil {
 		return nil, errors.Wrap(err, "creating telllor interface")
 	}
-
-	return &ITellor{Address: common.HexToAddress(TellorAddress), ITellor: tellorInstance, Main: lensInstance}, nil
+	contract.Main = lensInstance
+	return contract, nil
 }
 
 func NewITellorAccess(client ETHClient) (*ITellorAccess, error) {
@@ -132,7 +153,7 @@ func NewITellorAccess(client ETHClient) (*ITellorAccess, error) {
 		return nil, errors.Wrap(err, "creating telllor interface")
 	}
 
-	return &ITellorAccess{Address: common.HexToAddress(TellorAccessAddress), TellorAccess: tellorInstance}, nil
+	return &ITellorAccess{Address: conractAddr, TellorAccess: tellorInstance}, nil
 }
 
 func getTellorAccessAddress(client ETHClient) (common.Address, error) {
@@ -149,3 +170,24 @@ func getTellorAccessAddress(client ETHClient) (common.Address, error) {
 		return common.Address{}, errors.Errorf("contract address for current network id not found:%v", netID)
 	}
 }
+
+// StakerStatusName returns the name of a staker status as returned by getStakerInfo.
+func StakerStatusName(statusID int64) string {
+	// From https://github.com/tellor-io/tellor3/blob/7c2f38a0e3f96631fb0f96e0d0a9f73e7b355766/contracts/TellorStorage.sol#L41
+	switch statusID {
+	case 0:
+		return "Not staked"
+	case 1:
+		return "Staked"
+	case 2:
+		return "LockedForWithdraw"
+	case 3:
+		return "OnDispute"
+	case 4:
+		return "ReadyForUnlocking"
+	case 5:
+		return "Unlocked"
+	default:
+		return "Unknown"
+	}
+}
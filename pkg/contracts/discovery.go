@@ -0,0 +1,208 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package contracts
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/contracts/tellor"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+)
+
+const ComponentName = "contractDiscovery"
+
+// Keys of the address variables in the master contract storage.
+var (
+	tellorContractKey = crypto.Keccak256Hash([]byte("_TELLOR_CONTRACT"))
+	extensionKey      = crypto.Keccak256Hash([]byte("_EXTENSION"))
+)
+
+type Config struct {
+	Enabled  bool
+	LogLevel string
+	// Address of the master contract.
+	// When empty the mainnet Tellor master is used.
+	Address string
+	// Interval is how often to check the master contract for upgrades.
+	Interval format.Duration
+}
+
+// Addresses are the contracts behind the master proxy.
+// All calls and events go through the master which delegates
+// to the current implementation and extension.
+type Addresses struct {
+	Master         common.Address
+	Implementation common.Address
+	Extension      common.Address
+}
+
+// Discover resolves the current implementation and extension addresses through the master proxy.
+func Discover(ctx context.Context, client ETHClient, master common.Address) (Addresses, error) {
+	caller, err := tellor.NewITellorCaller(master, client)
+	if err != nil {
+		return Addresses{}, errors.Wrap(err, "creating master instance")
+	}
+	opts := &bind.CallOpts{Context: ctx}
+	impl, err := caller.GetAddressVars(opts, tellorContractKey)
+	if err != nil {
+		return Addresses{}, errors.Wrap(err, "getting the implementation address")
+	}
+	if impl == (common.Address{}) {
+		return Addresses{}, errors.Errorf("master:%v has no implementation address", master.Hex())
+	}
+	ext, err := caller.GetAddressVars(opts, extensionKey)
+	if err != nil {
+		return Addresses{}, errors.Wrap(err, "getting the extension address")
+	}
+	return Addresses{Master: master, Implementation: impl, Extension: ext}, nil
+}
+
+// Discovery watches the master proxy for contract upgrades
+// and notifies the subscribers so that they can resubscribe to the contract events.
+type Discovery struct {
+	ctx    context.Context
+	close  context.CancelFunc
+	logger log.Logger
+	cfg    Config
+	client ETHClient
+
+	master  common.Address
+	mtx     sync.Mutex
+	current Addresses
+	subs    []chan Addresses
+
+	upgrades prometheus.Counter
+}
+
+func NewDiscovery(logger log.Logger, ctx context.Context, cfg Config, client ETHClient, master common.Address) (*Discovery, error) {
+	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	logger = log.With(logger, "component", ComponentName)
+
+	// The components use the master address so they don't need the discovered addresses to start
+	// and the addresses are discovered again at every check.
+	current, err := Discover(ctx, client, master)
+	if err != nil {
+		level.Warn(logger).Log("msg", "discovering the contract addresses, retrying at the next check", "err", err)
+	} else {
+		logDiscovered(logger, current)
+	}
+
+	ctx, close := context.WithCancel(ctx)
+	return &Discovery{
+		ctx:     ctx,
+		close:   close,
+		logger:  logger,
+		cfg:     cfg,
+		client:  client,
+		master:  master,
+		current: current,
+		upgrades: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "upgrades_total",
+			Help:      "The total number of detected contract upgrades",
+		}),
+	}, nil
+}
+
+func (self *Discovery) Start() error {
+	level.Info(self.logger).Log("msg", "starting", "interval", self.cfg.Interval)
+	ticker := time.NewTicker(self.cfg.Interval.Duration)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-self.ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		if err := self.check(); err != nil {
+			level.Error(self.logger).Log("msg", "checking for contract upgrades", "err", err)
+		}
+	}
+}
+
+func (self *Discovery) Stop() {
+	self.close()
+}
+
+func (self *Discovery) check() error {
+	ctx, cncl := context.WithTimeout(self.ctx, self.cfg.Interval.Duration)
+	defer cncl()
+
+	addrs, err := Discover(ctx, self.client, self.master)
+	if err != nil {
+		return err
+	}
+
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	if addrs == self.current {
+		return nil
+	}
+	// Not an upgrade when the addresses couldn't be discovered at startup.
+	if self.current == (Addresses{}) {
+		logDiscovered(self.logger, addrs)
+		self.current = addrs
+		return nil
+	}
+	level.Warn(self.logger).Log(
+		"msg", "contract upgrade detected",
+		"implementation", self.current.Implementation.Hex(),
+		"newImplementation", addrs.Implementation.Hex(),
+		"extension", self.current.Extension.Hex(),
+		"newExtension", addrs.Extension.Hex(),
+	)
+	self.current = addrs
+	self.upgrades.Inc()
+	for _, sub := range self.subs {
+		// Drop a stale notification that wasn't consumed yet.
+		select {
+		case <-sub:
+		default:
+		}
+		sub <- addrs
+	}
+	return nil
+}
+
+func logDiscovered(logger log.Logger, addrs Addresses) {
+	level.Info(logger).Log(
+		"msg", "discovered contract addresses",
+		"master", addrs.Master.Hex(),
+		"implementation", addrs.Implementation.Hex(),
+		"extension", addrs.Extension.Hex(),
+	)
+}
+
+// Addresses returns the currently known contract addresses
+// which are empty until they are discovered.
+func (self *Discovery) Addresses() Addresses {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	return self.current
+}
+
+// Subscribe returns a channel that receives the new addresses after each upgrade.
+// It should be called before starting the discovery.
+func (self *Discovery) Subscribe() <-chan Addresses {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	sub := make(chan Addresses, 1)
+	self.subs = append(self.subs, sub)
+	return sub
+}
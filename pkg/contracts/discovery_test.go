@@ -0,0 +1,96 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package contracts
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/tellor-io/telliot/pkg/contracts/tellor"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// masterClient returns the address variables of the master contract
+// and an error while it is down.
+type masterClient struct {
+	ETHClient
+	abi  abi.ABI
+	vars map[common.Hash]common.Address
+	down bool
+}
+
+func newMasterClient(t *testing.T, vars map[common.Hash]common.Address) *masterClient {
+	parsed, err := abi.JSON(strings.NewReader(tellor.ITellorABI))
+	testutil.Ok(t, err)
+	return &masterClient{abi: parsed, vars: vars}
+}
+
+func (self *masterClient) CodeAt(context.Context, common.Address, *big.Int) ([]byte, error) {
+	return []byte{0x1}, nil
+}
+
+func (self *masterClient) CallContract(_ context.Context, call ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	if self.down {
+		return nil, errors.New("connection refused")
+	}
+	method := self.abi.Methods["getAddressVars"]
+	args, err := method.Inputs.Unpack(call.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+	key := common.Hash(args[0].([32]byte))
+	return method.Outputs.Pack(self.vars[key])
+}
+
+func TestDiscover(t *testing.T) {
+	master := common.HexToAddress("0x1")
+	impl, ext := common.HexToAddress("0x2"), common.HexToAddress("0x3")
+	client := newMasterClient(t, map[common.Hash]common.Address{tellorContractKey: impl, extensionKey: ext})
+
+	addrs, err := Discover(context.Background(), client, master)
+	testutil.Ok(t, err)
+	testutil.Equals(t, Addresses{Master: master, Implementation: impl, Extension: ext}, addrs)
+
+	_, err = Discover(context.Background(), newMasterClient(t, nil), master)
+	testutil.NotOk(t, err)
+}
+
+// TestDiscovery ensures that the discovery starts while the master can't be read
+// and that only a change of the discovered addresses is an upgrade.
+func TestDiscovery(t *testing.T) {
+	master := common.HexToAddress("0x1")
+	impl, ext := common.HexToAddress("0x2"), common.HexToAddress("0x3")
+	client := newMasterClient(t, map[common.Hash]common.Address{tellorContractKey: impl, extensionKey: ext})
+	client.down = true
+
+	cfg := Config{LogLevel: "info", Interval: format.Duration{Duration: time.Minute}}
+	discovery, err := NewDiscovery(logging.NewLogger(), context.Background(), cfg, client, master)
+	testutil.Ok(t, err)
+	testutil.Equals(t, Addresses{}, discovery.Addresses())
+	upgrades := discovery.Subscribe()
+
+	testutil.NotOk(t, discovery.check())
+
+	client.down = false
+	testutil.Ok(t, discovery.check())
+	testutil.Equals(t, Addresses{Master: master, Implementation: impl, Extension: ext}, discovery.Addresses())
+	testutil.Equals(t, 0, len(upgrades))
+	testutil.Equals(t, float64(0), promtestutil.ToFloat64(discovery.upgrades))
+
+	newImpl := common.HexToAddress("0x4")
+	client.vars[tellorContractKey] = newImpl
+	testutil.Ok(t, discovery.check())
+	testutil.Equals(t, Addresses{Master: master, Implementation: newImpl, Extension: ext}, <-upgrades)
+	testutil.Equals(t, float64(1), promtestutil.ToFloat64(discovery.upgrades))
+}
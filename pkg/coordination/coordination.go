@@ -0,0 +1,387 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package coordination
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/submitter"
+	"github.com/tellor-io/telliot/pkg/transactor"
+)
+
+const ComponentName = "coordination"
+
+// Lock backends.
+const (
+	BackendFile  = "file"
+	BackendRedis = "redis"
+)
+
+// nonceKeySuffix separates the lock of the transactions from the lock of the leader election of the same account.
+const nonceKeySuffix = "-nonce"
+
+type Config struct {
+	Enabled  bool
+	LogLevel string
+	// Backend is file or redis.
+	// The file backend works only for instances on the same host.
+	Backend string
+	// Dir is where the file backend creates the lock files.
+	Dir string
+	// RedisURL is the address of the redis backend in the format
+	// redis://<user>:<password>@<host>:<port>/<db_number>
+	RedisURL string
+	// TTL is how long the lock is held without a renewal.
+	// A crashed leader is replaced after at most this duration.
+	TTL format.Duration
+}
+
+// Locker is a lock shared between the instances.
+type Locker interface {
+	// TryLock acquires or renews the lock without blocking.
+	// Returns true when this instance holds the lock.
+	TryLock(ctx context.Context, key string) (bool, error)
+	Unlock(ctx context.Context, key string) error
+}
+
+// Coordinator serializes the transactions of each account among the instances that share it.
+// The lock is held only from picking the nonce of a transaction until it is mined,
+// so the transactions still in flight of a leader that steps down
+// never share a nonce with the transactions of the new leader.
+type Coordinator struct {
+	logger log.Logger
+	cfg    Config
+	locker Locker
+	// retry is how often a held lock is tried again.
+	retry time.Duration
+
+	mtx sync.Mutex
+	// held serializes the transactions within the instance
+	// because a locker returns true to the instance that already holds its lock.
+	held map[string]chan struct{}
+
+	locked *prometheus.GaugeVec
+	wait   *prometheus.HistogramVec
+}
+
+func New(logger log.Logger, cfg Config) (*Coordinator, error) {
+	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	logger = log.With(logger, "component", ComponentName)
+
+	if cfg.TTL.Duration <= 0 {
+		return nil, errors.Errorf("invalid TTL:%v", cfg.TTL)
+	}
+
+	var locker Locker
+	switch cfg.Backend {
+	case BackendFile:
+		if err := os.MkdirAll(cfg.Dir, 0755); err != nil {
+			return nil, errors.Wrap(err, "creating the lock dir")
+		}
+		locker = NewFileLocker(cfg.Dir)
+	case BackendRedis:
+		locker, err = NewRedisLocker(cfg.RedisURL, cfg.TTL.Duration)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating redis locker")
+		}
+	default:
+		return nil, errors.Errorf("unknown coordination backend:%v", cfg.Backend)
+	}
+	return newCoordinator(logger, cfg, locker), nil
+}
+
+func newCoordinator(logger log.Logger, cfg Config, locker Locker) *Coordinator {
+	return &Coordinator{
+		logger: logger,
+		cfg:    cfg,
+		locker: locker,
+		retry:  time.Second,
+		held:   make(map[string]chan struct{}),
+		locked: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "locked",
+			Help:      "Whether this instance holds the lock(1) of the account",
+		},
+			[]string{"addr"},
+		),
+		wait: promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "lock_wait_seconds",
+			Help:      "The time waiting for the lock of the account",
+			Buckets:   prometheus.ExponentialBuckets(0.1, 2, 10),
+		},
+			[]string{"addr"},
+		),
+	}
+}
+
+// Lock blocks until this instance holds the transactions lock of the key or the context is canceled.
+// The lock is renewed until the returned unlock is called and
+// the returned context is canceled when a renewal fails
+// because another instance might already hold the lock.
+func (self *Coordinator) Lock(ctx context.Context, key string) (context.Context, func(), error) {
+	start := time.Now()
+	lockKey := key + nonceKeySuffix
+
+	self.mtx.Lock()
+	held, ok := self.held[key]
+	if !ok {
+		held = make(chan struct{}, 1)
+		self.held[key] = held
+	}
+	self.mtx.Unlock()
+
+	select {
+	case held <- struct{}{}:
+	case <-ctx.Done():
+		return nil, nil, errors.Wrap(ctx.Err(), "waiting for the lock within the instance")
+	}
+
+	for {
+		ok, err := self.tryLock(ctx, lockKey)
+		if err != nil {
+			level.Error(self.logger).Log("msg", "acquiring the lock", "addr", key, "err", err)
+		}
+		if ok {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			<-held
+			return nil, nil, errors.Wrap(ctx.Err(), "waiting for the lock held by another instance")
+		case <-time.After(self.retry):
+		}
+	}
+	self.locked.With(prometheus.Labels{"addr": key}).Set(1)
+	self.wait.With(prometheus.Labels{"addr": key}).Observe(time.Since(start).Seconds())
+
+	// Renew well before the lock expires in case the transaction is slow to be mined.
+	lockCtx, cncl := context.WithCancel(ctx)
+	done := make(chan struct{})
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		ticker := time.NewTicker(self.cfg.TTL.Duration / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			if ok, err := self.tryLock(context.Background(), lockKey); err != nil || !ok {
+				level.Error(self.logger).Log("msg", "renewing the lock, aborting the transaction", "addr", key, "err", err)
+				cncl()
+				return
+			}
+		}
+	}()
+
+	return lockCtx, func() {
+		close(done)
+		<-renewed
+		cncl()
+		ctx, cncl := context.WithTimeout(context.Background(), self.cfg.TTL.Duration/3)
+		defer cncl()
+		if err := self.locker.Unlock(ctx, lockKey); err != nil {
+			level.Error(self.logger).Log("msg", "releasing the lock", "addr", key, "err", err)
+		}
+		self.locked.With(prometheus.Labels{"addr": key}).Set(0)
+		<-held
+	}, nil
+}
+
+func (self *Coordinator) tryLock(ctx context.Context, key string) (bool, error) {
+	ctx, cncl := context.WithTimeout(ctx, self.cfg.TTL.Duration/3)
+	defer cncl()
+	return self.locker.TryLock(ctx, key)
+}
+
+// Wrap returns a transactor which holds the lock of the key for each of its transactions.
+// The batching of the transactor is preserved.
+func (self *Coordinator) Wrap(key string, tr transactor.Transactor) transactor.Transactor {
+	locked := &lockedTransactor{Transactor: tr, coordinator: self, key: key}
+	if batcher, ok := tr.(transactor.Batcher); ok {
+		return &lockedBatcher{lockedTransactor: locked, batcher: batcher}
+	}
+	return locked
+}
+
+// lockedTransactor holds the lock from the nonce of the transaction until it is mined.
+// Send is called within Transact so it isn't locked again.
+// The transaction is sent with the context of the lock so that it is aborted when the lock is lost.
+type lockedTransactor struct {
+	transactor.Transactor
+	coordinator *Coordinator
+	key         string
+}
+
+func (self *lockedTransactor) Transact(ctx context.Context, fn func(*bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, *types.Receipt, error) {
+	ctx, unlock, err := self.coordinator.Lock(ctx, self.key)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer unlock()
+	return self.Transactor.Transact(ctx, fn)
+}
+
+type lockedBatcher struct {
+	*lockedTransactor
+	batcher transactor.Batcher
+}
+
+func (self *lockedBatcher) TransactBatch(ctx context.Context, fns ...func(*bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, *types.Receipt, error) {
+	ctx, unlock, err := self.coordinator.Lock(ctx, self.key)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer unlock()
+	return self.batcher.TransactBatch(ctx, fns...)
+}
+
+// Elector elects a single leader for each account among the instances that share it.
+// Only the leader mines, submits and tips through the submit gate of the account
+// so the instances never double submit.
+// The followers keep running all other components and
+// take over when the leader stops renewing its lock.
+type Elector struct {
+	ctx      context.Context
+	close    context.CancelFunc
+	logger   log.Logger
+	cfg      Config
+	locker   Locker
+	accounts []*ethereum.Account
+	gates    map[string]*submitter.Gate
+	leaders  map[string]bool
+
+	leader *prometheus.GaugeVec
+}
+
+// NewElector returns an elector which shares the lock backend of the coordinator.
+// The gates of the accounts are paused until the first election.
+func (self *Coordinator) NewElector(
+	ctx context.Context,
+	accounts []*ethereum.Account,
+	gates map[string]*submitter.Gate,
+) *Elector {
+	ctx, close := context.WithCancel(ctx)
+	elector := &Elector{
+		ctx:      ctx,
+		close:    close,
+		logger:   self.logger,
+		cfg:      self.cfg,
+		locker:   self.locker,
+		accounts: accounts,
+		gates:    gates,
+		leaders:  make(map[string]bool),
+		leader: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "leader",
+			Help:      "Whether this instance is the leader(1) or a follower(0) for the account",
+		},
+			[]string{"addr"},
+		),
+	}
+
+	// Start as a follower so that nothing is submitted before the first election.
+	for _, account := range accounts {
+		if gate := gates[account.Address.String()]; gate != nil {
+			gate.Pause(ComponentName, "waiting for the leader election")
+		}
+	}
+	return elector
+}
+
+func (self *Elector) Start() error {
+	level.Info(self.logger).Log("msg", "starting", "backend", self.cfg.Backend, "ttl", self.cfg.TTL)
+
+	// Renew well before the lock expires.
+	ticker := time.NewTicker(self.cfg.TTL.Duration / 3)
+	defer ticker.Stop()
+	for {
+		for _, account := range self.accounts {
+			self.elect(account.Address.String())
+		}
+		select {
+		case <-self.ctx.Done():
+			self.release()
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (self *Elector) Stop() {
+	self.close()
+}
+
+func (self *Elector) elect(addr string) {
+	logger := log.With(self.logger, "addr", addr)
+	gate := self.gates[addr]
+
+	ctx, cncl := context.WithTimeout(self.ctx, self.cfg.TTL.Duration/3)
+	defer cncl()
+	leader, err := self.locker.TryLock(ctx, addr)
+	if err != nil {
+		// Can't be sure that the lock is still held so step down.
+		level.Error(logger).Log("msg", "acquiring the lock", "err", err)
+		leader = false
+	}
+
+	if leader {
+		self.leader.With(prometheus.Labels{"addr": addr}).Set(1)
+	} else {
+		self.leader.With(prometheus.Labels{"addr": addr}).Set(0)
+	}
+
+	if prev, ok := self.leaders[addr]; ok && prev == leader {
+		return
+	}
+	self.leaders[addr] = leader
+
+	if leader {
+		level.Info(logger).Log("msg", "elected as the leader")
+		if gate != nil {
+			gate.Resume(ComponentName)
+		}
+		return
+	}
+	level.Info(logger).Log("msg", "running as a follower")
+	if gate != nil {
+		gate.Pause(ComponentName, "another instance is the leader")
+	}
+}
+
+// release unlocks all held locks so that a follower can take over without waiting for the TTL.
+func (self *Elector) release() {
+	ctx, cncl := context.WithTimeout(context.Background(), self.cfg.TTL.Duration/3)
+	defer cncl()
+	for addr, leader := range self.leaders {
+		if !leader {
+			continue
+		}
+		if err := self.locker.Unlock(ctx, addr); err != nil {
+			level.Error(self.logger).Log("msg", "releasing the lock", "addr", addr, "err", err)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+//go:build !windows
+// +build !windows
+
+package coordination
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/submitter"
+	"github.com/tellor-io/telliot/pkg/testutil"
+	"github.com/tellor-io/telliot/pkg/transactor"
+)
+
+type fakeTransactor struct {
+	transact func()
+}
+
+func (self *fakeTransactor) Transact(context.Context, func(*bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, *types.Receipt, error) {
+	self.transact()
+	return nil, nil, nil
+}
+
+func (self *fakeTransactor) Send(_ context.Context, tx *types.Transaction) (*types.Transaction, error) {
+	return tx, nil
+}
+
+type fakeBatcher struct {
+	fakeTransactor
+}
+
+func (self *fakeBatcher) TransactBatch(context.Context, ...func(*bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, *types.Receipt, error) {
+	self.transact()
+	return nil, nil, nil
+}
+
+var testCoordinator = newCoordinator(logging.NewLogger(), Config{TTL: format.Duration{Duration: time.Minute}}, nil)
+
+// The instances share the lock dir and the metrics which can be registered only once.
+func newTestCoordinators(t *testing.T) (*Coordinator, *Coordinator, func()) {
+	dir, err := ioutil.TempDir("", "locks")
+	testutil.Ok(t, err)
+	newInstance := func() *Coordinator {
+		return &Coordinator{
+			logger: testCoordinator.logger,
+			cfg:    testCoordinator.cfg,
+			locker: NewFileLocker(dir),
+			retry:  10 * time.Millisecond,
+			held:   make(map[string]chan struct{}),
+			locked: testCoordinator.locked,
+			wait:   testCoordinator.wait,
+		}
+	}
+	return newInstance(), newInstance(), func() { os.RemoveAll(dir) }
+}
+
+// renewFailLocker grants the lock and then fails all renewals.
+type renewFailLocker struct {
+	locked bool
+}
+
+func (self *renewFailLocker) TryLock(context.Context, string) (bool, error) {
+	if self.locked {
+		return false, nil
+	}
+	self.locked = true
+	return true, nil
+}
+
+func (self *renewFailLocker) Unlock(context.Context, string) error {
+	return nil
+}
+
+// TestLock ensures that the lock is held only until it is unlocked
+// and that another instance waits for it.
+func TestLock(t *testing.T) {
+	first, second, cleanup := newTestCoordinators(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	_, unlock, err := first.Lock(ctx, "0x01")
+	testutil.Ok(t, err)
+
+	locked := make(chan func())
+	go func() {
+		_, unlock, err := second.Lock(ctx, "0x01")
+		testutil.Ok(t, err)
+		locked <- unlock
+	}()
+	select {
+	case <-locked:
+		t.Fatal("the lock is held by the first instance")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Different accounts use different locks.
+	_, unlockOther, err := second.Lock(ctx, "0x02")
+	testutil.Ok(t, err)
+	unlockOther()
+
+	unlock()
+	select {
+	case unlock = <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("the second instance didn't get the lock after the release")
+	}
+
+	timeout, cncl := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cncl()
+	_, _, err = first.Lock(timeout, "0x01")
+	testutil.NotOk(t, err, "the lock is held by the second instance")
+	unlock()
+}
+
+// TestWrap ensures that the transactions of the same account within the instance
+// are serialized and that the batching is preserved.
+func TestWrap(t *testing.T) {
+	first, _, cleanup := newTestCoordinators(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	_, ok := first.Wrap("0x01", &fakeTransactor{}).(transactor.Batcher)
+	testutil.Assert(t, !ok, "a transactor without batching")
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	batcher := &fakeBatcher{fakeTransactor{transact: func() {
+		started <- struct{}{}
+		<-release
+	}}}
+	wrapped, ok := first.Wrap("0x01", batcher).(transactor.Batcher)
+	testutil.Assert(t, ok, "the batching isn't preserved")
+
+	done := make(chan struct{}, 2)
+	go func() {
+		_, _, _ = wrapped.(transactor.Transactor).Transact(ctx, nil)
+		done <- struct{}{}
+	}()
+	<-started
+	go func() {
+		_, _, _ = wrapped.TransactBatch(ctx)
+		done <- struct{}{}
+	}()
+	select {
+	case <-started:
+		t.Fatal("the second transaction started while the first holds the lock")
+	case <-time.After(100 * time.Millisecond):
+	}
+	release <- struct{}{}
+	<-started
+	release <- struct{}{}
+	<-done
+	<-done
+}
+
+// TestLockRenewalFailure ensures that the context of the lock is canceled
+// so that the transaction is aborted when the lock can't be renewed.
+func TestLockRenewalFailure(t *testing.T) {
+	coordinator := &Coordinator{
+		logger: testCoordinator.logger,
+		cfg:    Config{TTL: format.Duration{Duration: 30 * time.Millisecond}},
+		locker: &renewFailLocker{},
+		retry:  10 * time.Millisecond,
+		held:   make(map[string]chan struct{}),
+		locked: testCoordinator.locked,
+		wait:   testCoordinator.wait,
+	}
+	ctx, unlock, err := coordinator.Lock(context.Background(), "0x01")
+	testutil.Ok(t, err)
+	defer unlock()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("the context isn't canceled after the failed renewal")
+	}
+}
+
+// TestElector ensures that only one instance is the leader of an account
+// and that a follower takes over when the leader stops.
+func TestElector(t *testing.T) {
+	first, second, cleanup := newTestCoordinators(t)
+	defer cleanup()
+
+	accounts := []*ethereum.Account{{Address: common.HexToAddress("0x01")}}
+	newGates := func() map[string]*submitter.Gate {
+		return map[string]*submitter.Gate{accounts[0].Address.String(): submitter.NewGate(accounts[0].Address.String())}
+	}
+	firstGates, secondGates := newGates(), newGates()
+
+	leader := first.NewElector(context.Background(), accounts, firstGates)
+	follower := &Elector{
+		logger:   second.logger,
+		cfg:      second.cfg,
+		locker:   second.locker,
+		accounts: accounts,
+		gates:    secondGates,
+		leaders:  make(map[string]bool),
+		leader:   leader.leader,
+	}
+	follower.ctx, follower.close = context.WithCancel(context.Background())
+	testutil.NotOk(t, firstGates[accounts[0].Address.String()].Err(), "the gate is paused until the first election")
+
+	leader.elect(accounts[0].Address.String())
+	follower.elect(accounts[0].Address.String())
+	testutil.Ok(t, firstGates[accounts[0].Address.String()].Err())
+	testutil.NotOk(t, secondGates[accounts[0].Address.String()].Err(), "another instance is the leader")
+
+	// The transactions lock is separate from the leader lock.
+	_, unlock, err := second.Lock(context.Background(), accounts[0].Address.String())
+	testutil.Ok(t, err)
+	unlock()
+
+	leader.release()
+	follower.elect(accounts[0].Address.String())
+	testutil.Ok(t, secondGates[accounts[0].Address.String()].Err())
+}
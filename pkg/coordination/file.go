@@ -0,0 +1,69 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+//go:build !windows
+// +build !windows
+
+package coordination
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// FileLocker uses flock on a file per key.
+// The kernel releases the lock when the process exits so it never needs to expire.
+type FileLocker struct {
+	dir   string
+	mtx   sync.Mutex
+	files map[string]*os.File
+}
+
+func NewFileLocker(dir string) *FileLocker {
+	return &FileLocker{
+		dir:   dir,
+		files: make(map[string]*os.File),
+	}
+}
+
+func (self *FileLocker) TryLock(_ context.Context, key string) (bool, error) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	if _, ok := self.files[key]; ok {
+		return true, nil
+	}
+
+	f, err := os.OpenFile(filepath.Join(self.dir, key+".lock"), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return false, errors.Wrap(err, "opening the lock file")
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if err == syscall.EWOULDBLOCK {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "locking the file")
+	}
+	self.files[key] = f
+	return true, nil
+}
+
+func (self *FileLocker) Unlock(_ context.Context, key string) error {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	f, ok := self.files[key]
+	if !ok {
+		return nil
+	}
+	delete(self.files, key)
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_UN); err != nil {
+		f.Close()
+		return errors.Wrap(err, "unlocking the file")
+	}
+	return f.Close()
+}
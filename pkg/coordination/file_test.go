@@ -0,0 +1,51 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+//go:build !windows
+// +build !windows
+
+package coordination
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// TestFileLocker ensures that only one instance holds the lock
+// and that the other takes over after it is released.
+func TestFileLocker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "locks")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+	ctx := context.Background()
+
+	leader := NewFileLocker(dir)
+	follower := NewFileLocker(dir)
+
+	ok, err := leader.TryLock(ctx, "0x01")
+	testutil.Ok(t, err)
+	testutil.Assert(t, ok, "the first instance should get the lock")
+
+	ok, err = follower.TryLock(ctx, "0x01")
+	testutil.Ok(t, err)
+	testutil.Assert(t, !ok, "the lock is held by the first instance")
+
+	// Renewing by the holder.
+	ok, err = leader.TryLock(ctx, "0x01")
+	testutil.Ok(t, err)
+	testutil.Assert(t, ok, "the holder should keep the lock")
+
+	// Different accounts use different locks.
+	ok, err = follower.TryLock(ctx, "0x02")
+	testutil.Ok(t, err)
+	testutil.Assert(t, ok, "the lock for another account should be free")
+
+	testutil.Ok(t, leader.Unlock(ctx, "0x01"))
+	ok, err = follower.TryLock(ctx, "0x01")
+	testutil.Ok(t, err)
+	testutil.Assert(t, ok, "the follower should take over after the release")
+}
@@ -0,0 +1,66 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package coordination
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/windows"
+)
+
+// FileLocker uses LockFileEx on a file per key.
+// The system releases the lock when the process exits so it never needs to expire.
+type FileLocker struct {
+	dir   string
+	mtx   sync.Mutex
+	files map[string]*os.File
+}
+
+func NewFileLocker(dir string) *FileLocker {
+	return &FileLocker{
+		dir:   dir,
+		files: make(map[string]*os.File),
+	}
+}
+
+func (self *FileLocker) TryLock(_ context.Context, key string) (bool, error) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	if _, ok := self.files[key]; ok {
+		return true, nil
+	}
+	f, err := os.OpenFile(filepath.Join(self.dir, key+".lock"), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return false, errors.Wrap(err, "opening the lock file")
+	}
+	// Locks the first byte which is enough as all instances lock the same range.
+	if err := windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, &windows.Overlapped{}); err != nil {
+		f.Close()
+		if err == windows.ERROR_LOCK_VIOLATION {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "locking the file")
+	}
+	self.files[key] = f
+	return true, nil
+}
+
+func (self *FileLocker) Unlock(_ context.Context, key string) error {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	f, ok := self.files[key]
+	if !ok {
+		return nil
+	}
+	delete(self.files, key)
+	if err := windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, &windows.Overlapped{}); err != nil {
+		f.Close()
+		return errors.Wrap(err, "unlocking the file")
+	}
+	return f.Close()
+}
@@ -0,0 +1,78 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package coordination
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+const redisKeyPrefix = "telliot:lock:"
+
+// renewScript extends the lock only when it is still held by the same instance.
+var renewScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("pexpire", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// unlockScript deletes the lock only when it is still held by the same instance.
+var unlockScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0
+`)
+
+// RedisLocker is a lock with a TTL that is renewed by the holder.
+type RedisLocker struct {
+	client *redis.Client
+	id     string
+	ttl    time.Duration
+}
+
+func NewRedisLocker(url string, ttl time.Duration) (*RedisLocker, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing the redis url")
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting the hostname")
+	}
+	return &RedisLocker{
+		client: redis.NewClient(opts),
+		id:     fmt.Sprintf("%v:%v:%v", hostname, os.Getpid(), time.Now().UnixNano()),
+		ttl:    ttl,
+	}, nil
+}
+
+func (self *RedisLocker) TryLock(ctx context.Context, key string) (bool, error) {
+	key = redisKeyPrefix + key
+	ok, err := self.client.SetNX(ctx, key, self.id, self.ttl).Result()
+	if err != nil {
+		return false, errors.Wrap(err, "setting the lock")
+	}
+	if ok {
+		return true, nil
+	}
+	renewed, err := renewScript.Run(ctx, self.client, []string{key}, self.id, self.ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, errors.Wrap(err, "renewing the lock")
+	}
+	return renewed == 1, nil
+}
+
+func (self *RedisLocker) Unlock(ctx context.Context, key string) error {
+	if err := unlockScript.Run(ctx, self.client, []string{redisKeyPrefix + key}, self.id).Err(); err != nil {
+		return errors.Wrap(err, "deleting the lock")
+	}
+	return nil
+}
@@ -0,0 +1,313 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+// Package crosscheck compares the values with the prices of other oracles before submitting them
+// so that a value that disagrees with the rest of the market isn't submitted.
+package crosscheck
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/errclass"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/web"
+)
+
+const ComponentName = "crossCheck"
+
+// ErrDisagree is returned when a value is too far from the prices of the other oracles.
+var ErrDisagree = errclass.New(errclass.DataQuality, "value disagrees with the other oracles")
+
+var (
+	deviationGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "telliot",
+		Subsystem: ComponentName,
+		Name:      "deviation_percent",
+		Help:      "The percent the last checked value deviates from the price of another oracle",
+	}, []string{"requestID", "oracle"})
+	disagreements = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "telliot",
+		Subsystem: ComponentName,
+		Name:      "disagreements_total",
+		Help:      "The total number of values refused as too far from the other oracles",
+	}, []string{"requestID"})
+	referenceErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "telliot",
+		Subsystem: ComponentName,
+		Name:      "reference_errors_total",
+		Help:      "The total number of failed price requests to another oracle",
+	}, []string{"oracle"})
+)
+
+type Config struct {
+	Enabled  bool
+	LogLevel string
+	// Tolerance is the max percent a value can deviate from the median of the other oracles.
+	Tolerance float64
+	// MinReferences is the least number of oracles with a price for the check.
+	// The check is skipped when fewer oracles respond.
+	MinReferences int
+	Timeout       format.Duration
+	// CacheTTL is how long the prices of the other oracles are reused
+	// as a refused submission is retried every second.
+	CacheTTL format.Duration
+	// MaxAge of the latest Chainlink round.
+	MaxAge  format.Duration
+	BandURL string
+	DIAURL  string
+	Feeds   []Feed
+}
+
+// Feed are the pairs of a request ID in the other oracles.
+// The oracles with an empty pair are not checked.
+type Feed struct {
+	RequestID int64
+	// Chainlink is the address of the price feed contract on the chain of the node.
+	Chainlink string
+	// Band and DIA are pairs like ETH/USD.
+	Band string
+	DIA  string
+	// Tolerance overrides the default when not 0.
+	Tolerance float64
+}
+
+// Status is the last check of a request ID.
+type Status struct {
+	RequestID    int64
+	Time         time.Time
+	Value        float64
+	References   map[string]float64
+	Errors       map[string]string `json:",omitempty"`
+	Deviation    float64
+	AllowedUntil *time.Time `json:",omitempty"`
+}
+
+// AllowRequest allows the values of a request ID that disagree with the other oracles for a duration.
+type AllowRequest struct {
+	RequestID int64
+	Duration  format.Duration
+}
+
+type cachedPrice struct {
+	price   float64
+	ok      bool
+	err     error
+	expires time.Time
+}
+
+// Checker compares the values with the prices of Chainlink, Band and DIA.
+// It is safe for concurrent use.
+type Checker struct {
+	logger     log.Logger
+	cfg        Config
+	feeds      map[int64]Feed
+	references []reference
+
+	mtx     sync.Mutex
+	cache   map[string]cachedPrice
+	allowed map[int64]time.Time
+	status  map[int64]Status
+}
+
+// New creates a checker or returns nil when it is disabled.
+// The client reads the Chainlink contracts.
+func New(logger log.Logger, cfg Config, client bind.ContractCaller) (*Checker, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	logger = log.With(logger, "component", ComponentName)
+
+	cl, err := newChainlink(client, cfg.MaxAge.Duration)
+	if err != nil {
+		return nil, err
+	}
+	self := &Checker{
+		logger:     logger,
+		cfg:        cfg,
+		feeds:      make(map[int64]Feed),
+		references: []reference{cl, &band{url: cfg.BandURL}, &dia{url: cfg.DIAURL}},
+		cache:      make(map[string]cachedPrice),
+		allowed:    make(map[int64]time.Time),
+		status:     make(map[int64]Status),
+	}
+	for _, f := range cfg.Feeds {
+		self.feeds[f.RequestID] = f
+	}
+	return self, nil
+}
+
+// Check returns an error wrapping ErrDisagree when the decoded value of a request ID
+// deviates from the median of the other oracles more than the tolerance.
+// The request IDs without a feed and the allowed ones are not checked.
+func (self *Checker) Check(ctx context.Context, requestID int64, value float64) error {
+	feed, ok := self.feeds[requestID]
+	if !ok {
+		return nil
+	}
+	ctx, cncl := context.WithTimeout(ctx, self.cfg.Timeout.Duration)
+	defer cncl()
+
+	s := Status{
+		RequestID:  requestID,
+		Time:       time.Now(),
+		Value:      value,
+		References: make(map[string]float64),
+		Errors:     make(map[string]string),
+	}
+	var prices []float64
+	for _, r := range self.references {
+		price, ok, err := self.price(ctx, r, feed)
+		if !ok {
+			continue
+		}
+		if err != nil {
+			s.Errors[r.name()] = err.Error()
+			level.Warn(self.logger).Log("msg", "getting the price of another oracle", "oracle", r.name(), "requestID", requestID, "err", err)
+			continue
+		}
+		s.References[r.name()] = price
+		deviationGauge.With(prometheus.Labels{"requestID": strconv.FormatInt(requestID, 10), "oracle": r.name()}).Set(deviation(value, price))
+		prices = append(prices, price)
+	}
+	minReferences := self.cfg.MinReferences
+	if minReferences < 1 {
+		minReferences = 1
+	}
+	if len(prices) < minReferences {
+		self.setStatus(s)
+		level.Warn(self.logger).Log("msg", "not enough prices of other oracles, skipping the check", "requestID", requestID, "prices", len(prices), "min", minReferences)
+		return nil
+	}
+
+	s.Deviation = deviation(value, median(prices))
+	tolerance := self.cfg.Tolerance
+	if feed.Tolerance != 0 {
+		tolerance = feed.Tolerance
+	}
+	allowedUntil := self.setStatus(s)
+	if math.Abs(s.Deviation) <= tolerance {
+		return nil
+	}
+	if allowedUntil != nil {
+		level.Warn(self.logger).Log("msg", "value disagrees with the other oracles but is allowed", "requestID", requestID, "deviation", s.Deviation, "until", allowedUntil)
+		return nil
+	}
+	disagreements.With(prometheus.Labels{"requestID": strconv.FormatInt(requestID, 10)}).Inc()
+	return errors.Wrapf(ErrDisagree, "request ID:%v value:%v is %.2f%% from the median of the other oracles:%v", requestID, value, s.Deviation, s.References)
+}
+
+// price returns the cached price of an oracle.
+func (self *Checker) price(ctx context.Context, r reference, feed Feed) (float64, bool, error) {
+	key := r.name() + ":" + strconv.FormatInt(feed.RequestID, 10)
+	self.mtx.Lock()
+	c, ok := self.cache[key]
+	self.mtx.Unlock()
+	if ok && time.Now().Before(c.expires) {
+		return c.price, c.ok, c.err
+	}
+	price, ok, err := r.price(ctx, feed)
+	if err != nil {
+		referenceErrors.With(prometheus.Labels{"oracle": r.name()}).Inc()
+	}
+	self.mtx.Lock()
+	self.cache[key] = cachedPrice{price: price, ok: ok, err: err, expires: time.Now().Add(self.cfg.CacheTTL.Duration)}
+	self.mtx.Unlock()
+	return price, ok, err
+}
+
+// setStatus records the last check and returns until when the request ID is allowed.
+func (self *Checker) setStatus(s Status) *time.Time {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	if until, ok := self.allowed[s.RequestID]; ok {
+		if time.Now().Before(until) {
+			s.AllowedUntil = &until
+		} else {
+			delete(self.allowed, s.RequestID)
+		}
+	}
+	self.status[s.RequestID] = s
+	return s.AllowedUntil
+}
+
+// Allow skips the disagreement of the values of a request ID until the given time
+// e.g. when another oracle is known to be wrong.
+func (self *Checker) Allow(requestID int64, until time.Time) error {
+	if _, ok := self.feeds[requestID]; !ok {
+		return errors.Errorf("no feed for request ID:%v", requestID)
+	}
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	self.allowed[requestID] = until
+	return nil
+}
+
+// ServeHTTP lists the last check of every request ID.
+// For example: curl 'localhost:9090/api/v1/crosscheck'.
+func (self *Checker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	self.mtx.Lock()
+	list := make([]Status, 0, len(self.status))
+	for _, s := range self.status {
+		list = append(list, s)
+	}
+	self.mtx.Unlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].RequestID < list[j].RequestID })
+	if err := web.WriteJSON(w, http.StatusOK, list, nil); err != nil {
+		level.Error(self.logger).Log("msg", "encoding cross check response", "err", err)
+	}
+}
+
+// ServeAllow allows the values of a request ID for a duration.
+// For example: curl -X POST 'localhost:9090/api/v1/crosscheck/allow' -d '{"RequestID":1,"Duration":"1h"}'.
+func (self *Checker) ServeAllow(w http.ResponseWriter, r *http.Request) {
+	code, err := func() (int, error) {
+		var req AllowRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return http.StatusBadRequest, errors.Wrap(err, "decoding the request")
+		}
+		if req.Duration.Duration <= 0 {
+			return http.StatusBadRequest, errors.New("the duration should be more than 0")
+		}
+		until := time.Now().Add(req.Duration.Duration)
+		if err := self.Allow(req.RequestID, until); err != nil {
+			return http.StatusBadRequest, err
+		}
+		level.Info(self.logger).Log("msg", "disagreement allowed", "requestID", req.RequestID, "until", until)
+		return http.StatusOK, nil
+	}()
+	if err := web.WriteJSON(w, code, nil, err); err != nil {
+		level.Error(self.logger).Log("msg", "encoding allow response", "err", err)
+	}
+}
+
+func median(values []float64) float64 {
+	sort.Float64s(values)
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2
+	}
+	return values[mid]
+}
+
+// deviation in percent of a value from the expected.
+func deviation(value, expected float64) float64 {
+	return (value - expected) / expected * 100
+}
@@ -0,0 +1,118 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package crosscheck
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	eth "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// aggregatorV3 is a Chainlink feed with 8 decimals.
+type aggregatorV3 struct {
+	abi     abi.ABI
+	answer  int64
+	updated time.Time
+}
+
+func (self *aggregatorV3) CodeAt(context.Context, common.Address, *big.Int) ([]byte, error) {
+	return []byte{1}, nil
+}
+
+func (self *aggregatorV3) CallContract(_ context.Context, call eth.CallMsg, _ *big.Int) ([]byte, error) {
+	method, err := self.abi.MethodById(call.Data)
+	if err != nil {
+		return nil, err
+	}
+	switch method.Name {
+	case "decimals":
+		return method.Outputs.Pack(uint8(8))
+	case "latestRoundData":
+		return method.Outputs.Pack(big.NewInt(1), big.NewInt(self.answer*1e8), big.NewInt(self.updated.Unix()), big.NewInt(self.updated.Unix()), big.NewInt(1))
+	}
+	return nil, errors.Errorf("unexpected method:%v", method.Name)
+}
+
+func TestCheck(t *testing.T) {
+	ctx := context.Background()
+	band := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		testutil.Equals(t, "/oracle/v1/request_prices", r.URL.Path)
+		testutil.Equals(t, []string{"ETH"}, r.URL.Query()["symbols"])
+		_, _ = w.Write([]byte(`{"price_results":[{"symbol":"ETH","multiplier":"1000000000","px":"2010000000000"}]}`))
+	}))
+	defer band.Close()
+	dia := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		testutil.Equals(t, "/v1/quotation/ETH", r.URL.Path)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"Symbol": "ETH", "Price": 2020})
+	}))
+	defer dia.Close()
+
+	parsed, err := abi.JSON(strings.NewReader(chainlinkABI))
+	testutil.Ok(t, err)
+	cfg := Config{
+		Enabled:       true,
+		LogLevel:      "info",
+		Tolerance:     3,
+		MinReferences: 2,
+		Timeout:       format.Duration{Duration: time.Second},
+		MaxAge:        format.Duration{Duration: time.Hour},
+		BandURL:       band.URL,
+		DIAURL:        dia.URL,
+		Feeds: []Feed{
+			{RequestID: 1, Chainlink: "0x5f4eC3Df9cbd43714FE2740f5E3616155c5b8419", Band: "ETH/USD", DIA: "ETH/USD"},
+			{RequestID: 2, DIA: "ETH/USD", Tolerance: 50},
+		},
+	}
+	checker, err := New(log.NewNopLogger(), cfg, &aggregatorV3{abi: parsed, answer: 2000, updated: time.Now()})
+	testutil.Ok(t, err)
+
+	// The median of the other oracles is 2010.
+	testutil.Ok(t, checker.Check(ctx, 1, 2030))
+	err = checker.Check(ctx, 1, 2200)
+	testutil.Assert(t, errors.Is(err, ErrDisagree), "a value too far from the other oracles not refused:%v", err)
+
+	testutil.Ok(t, checker.Allow(1, time.Now().Add(time.Minute)))
+	testutil.Ok(t, checker.Check(ctx, 1, 2200))
+	testutil.NotOk(t, checker.Allow(3, time.Now().Add(time.Minute)), "allowing a request ID without a feed")
+
+	// Not enough oracles for request ID 2 and no feed for 3.
+	testutil.Ok(t, checker.Check(ctx, 2, 3000))
+	testutil.Ok(t, checker.Check(ctx, 3, 3000))
+
+	// A stale Chainlink round is not used.
+	checker, err = New(log.NewNopLogger(), cfg, &aggregatorV3{abi: parsed, answer: 2000, updated: time.Now().Add(-2 * time.Hour)})
+	testutil.Ok(t, err)
+	testutil.Ok(t, checker.Check(ctx, 1, 2030))
+	rec := httptest.NewRecorder()
+	checker.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/crosscheck", nil))
+	var resp struct {
+		Data []Status
+	}
+	testutil.Ok(t, json.NewDecoder(rec.Body).Decode(&resp))
+	testutil.Equals(t, 1, len(resp.Data))
+	testutil.Equals(t, 2, len(resp.Data[0].References))
+	testutil.Equals(t, 1, len(resp.Data[0].Errors))
+}
+
+func TestQuoted(t *testing.T) {
+	price, ok, err := quoted(map[string]float64{"ETH": 2000, "BTC": 40000}, "ETH", "BTC")
+	testutil.Ok(t, err)
+	testutil.Assert(t, ok, "")
+	testutil.Equals(t, 0.05, price)
+	_, _, err = quoted(map[string]float64{"ETH": 2000}, "ETH", "BTC")
+	testutil.NotOk(t, err)
+}
@@ -0,0 +1,193 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package crosscheck
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/web"
+)
+
+const (
+	OracleChainlink = "chainlink"
+	OracleBand      = "band"
+	OracleDIA       = "dia"
+)
+
+// reference is the price of a feed from another oracle.
+// It returns false when the feed has no pair for the oracle.
+type reference interface {
+	name() string
+	price(ctx context.Context, feed Feed) (float64, bool, error)
+}
+
+// chainlinkABI is the minimal ABI of the AggregatorV3Interface.
+const chainlinkABI = `[
+{"inputs":[],"name":"decimals","outputs":[{"name":"","type":"uint8"}],"stateMutability":"view","type":"function"},
+{"inputs":[],"name":"latestRoundData","outputs":[{"name":"roundId","type":"uint80"},{"name":"answer","type":"int256"},{"name":"startedAt","type":"uint256"},{"name":"updatedAt","type":"uint256"},{"name":"answeredInRound","type":"uint80"}],"stateMutability":"view","type":"function"}
+]`
+
+// chainlink reads the price feed contracts on the chain of the client.
+type chainlink struct {
+	client bind.ContractCaller
+	abi    abi.ABI
+	maxAge time.Duration
+}
+
+func newChainlink(client bind.ContractCaller, maxAge time.Duration) (*chainlink, error) {
+	parsed, err := abi.JSON(strings.NewReader(chainlinkABI))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse chainlink abi")
+	}
+	return &chainlink{client: client, abi: parsed, maxAge: maxAge}, nil
+}
+
+func (self *chainlink) name() string { return OracleChainlink }
+
+func (self *chainlink) price(ctx context.Context, feed Feed) (float64, bool, error) {
+	if feed.Chainlink == "" {
+		return 0, false, nil
+	}
+	if !common.IsHexAddress(feed.Chainlink) {
+		return 0, true, errors.Errorf("invalid chainlink address:%v", feed.Chainlink)
+	}
+	contract := bind.NewBoundContract(common.HexToAddress(feed.Chainlink), self.abi, self.client, nil, nil)
+	opts := &bind.CallOpts{Context: ctx}
+
+	var decimals []interface{}
+	if err := contract.Call(opts, &decimals, "decimals"); err != nil {
+		return 0, true, errors.Wrap(err, "get decimals")
+	}
+	var round []interface{}
+	if err := contract.Call(opts, &round, "latestRoundData"); err != nil {
+		return 0, true, errors.Wrap(err, "get latest round")
+	}
+	answer := abi.ConvertType(round[1], new(big.Int)).(*big.Int)
+	updated := time.Unix(abi.ConvertType(round[3], new(big.Int)).(*big.Int).Int64(), 0)
+	if self.maxAge > 0 && time.Since(updated) > self.maxAge {
+		return 0, true, errors.Errorf("latest round is too old:%v", updated)
+	}
+	price, _ := new(big.Float).SetInt(answer).Float64()
+	return price / math.Pow10(int(decimals[0].(uint8))), true, nil
+}
+
+// band gets the prices of the standard dataset from a BandChain REST endpoint.
+type band struct {
+	url string
+}
+
+func (self *band) name() string { return OracleBand }
+
+func (self *band) price(ctx context.Context, feed Feed) (float64, bool, error) {
+	if feed.Band == "" {
+		return 0, false, nil
+	}
+	base, quote, err := pair(feed.Band)
+	if err != nil {
+		return 0, true, err
+	}
+	symbols := url.Values{"symbols": []string{base}}
+	if quote != "USD" {
+		symbols.Add("symbols", quote)
+	}
+	data, err := web.Fetch(ctx, self.url+"/oracle/v1/request_prices?"+symbols.Encode())
+	if err != nil {
+		return 0, true, err
+	}
+	var resp struct {
+		PriceResults []struct {
+			Symbol     string `json:"symbol"`
+			Multiplier string `json:"multiplier"`
+			Px         string `json:"px"`
+		} `json:"price_results"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return 0, true, errors.Wrap(err, "decode response")
+	}
+	prices := make(map[string]float64)
+	for _, r := range resp.PriceResults {
+		px, err := strconv.ParseFloat(r.Px, 64)
+		if err != nil {
+			return 0, true, errors.Wrapf(err, "parse price of:%v", r.Symbol)
+		}
+		multiplier, err := strconv.ParseFloat(r.Multiplier, 64)
+		if err != nil || multiplier == 0 {
+			return 0, true, errors.Errorf("invalid multiplier of:%v", r.Symbol)
+		}
+		prices[r.Symbol] = px / multiplier
+	}
+	return quoted(prices, base, quote)
+}
+
+// dia gets the USD quotations of the DIA API.
+type dia struct {
+	url string
+}
+
+func (self *dia) name() string { return OracleDIA }
+
+func (self *dia) price(ctx context.Context, feed Feed) (float64, bool, error) {
+	if feed.DIA == "" {
+		return 0, false, nil
+	}
+	base, quote, err := pair(feed.DIA)
+	if err != nil {
+		return 0, true, err
+	}
+	prices := make(map[string]float64)
+	symbols := []string{base}
+	if quote != "USD" {
+		symbols = append(symbols, quote)
+	}
+	for _, symbol := range symbols {
+		data, err := web.Fetch(ctx, self.url+"/v1/quotation/"+url.PathEscape(symbol))
+		if err != nil {
+			return 0, true, err
+		}
+		var resp struct {
+			Price float64
+		}
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return 0, true, errors.Wrap(err, "decode response")
+		}
+		prices[symbol] = resp.Price
+	}
+	return quoted(prices, base, quote)
+}
+
+// pair splits a pair like ETH/USD.
+func pair(p string) (string, string, error) {
+	parts := strings.Split(p, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", errors.Errorf("invalid pair:%v", p)
+	}
+	return parts[0], parts[1], nil
+}
+
+// quoted returns the price of the base in the quote from their USD prices.
+func quoted(usd map[string]float64, base, quote string) (float64, bool, error) {
+	b, ok := usd[base]
+	if !ok || b == 0 {
+		return 0, true, errors.Errorf("no price for:%v", base)
+	}
+	if quote == "USD" {
+		return b, true, nil
+	}
+	q, ok := usd[quote]
+	if !ok || q == 0 {
+		return 0, true, errors.Errorf("no price for:%v", quote)
+	}
+	return b / q, true, nil
+}
@@ -0,0 +1,222 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+// Package dashboards has the Grafana dashboards of the telliot metrics and DB series.
+package dashboards
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/web"
+)
+
+// GrafanaKeyEnvName is the env var of the Grafana API key used for the provisioning.
+const GrafanaKeyEnvName = "GRAFANA_API_KEY"
+
+// The datasource variables of the dashboards.
+// The metrics one is a Prometheus which scrapes the instances
+// and the db one is an instance with a local DB for the series that are only in the DB.
+const (
+	datasourceVar   = "datasource"
+	dbDatasourceVar = "db"
+)
+
+//go:embed *.json
+var files embed.FS
+
+// Names lists the dashboards.
+func Names() []string {
+	entries, _ := files.ReadDir(".")
+	var names []string
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".json"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the dashboard with its datasource variables set to the datasources
+// so that it works without selecting them. An empty db datasource is the same as the datasource.
+func Get(name, datasource, dbDatasource string) (map[string]interface{}, error) {
+	data, err := files.ReadFile(name + ".json")
+	if err != nil {
+		return nil, errors.Errorf("unknown dashboard:%v", name)
+	}
+	var dashboard map[string]interface{}
+	if err := json.Unmarshal(data, &dashboard); err != nil {
+		return nil, errors.Wrapf(err, "decoding dashboard:%v", name)
+	}
+	if dbDatasource == "" {
+		dbDatasource = datasource
+	}
+	templating, _ := dashboard["templating"].(map[string]interface{})
+	vars, _ := templating["list"].([]interface{})
+	for _, v := range vars {
+		v, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		current := ""
+		switch v["name"] {
+		case datasourceVar:
+			current = datasource
+		case dbDatasourceVar:
+			current = dbDatasource
+		}
+		if current != "" {
+			v["current"] = map[string]interface{}{"text": current, "value": current}
+		}
+	}
+	return dashboard, nil
+}
+
+// Handler serves the dashboards so that they can be imported in Grafana.
+type Handler struct {
+	logger log.Logger
+}
+
+func NewHandler(logger log.Logger) *Handler {
+	return &Handler{logger: logger}
+}
+
+// ServeList serves the names of the dashboards.
+func (self *Handler) ServeList(w http.ResponseWriter, r *http.Request) {
+	if err := web.WriteJSON(w, http.StatusOK, Names(), nil); err != nil {
+		level.Error(self.logger).Log("msg", "encoding dashboards response", "err", err)
+	}
+}
+
+// ServeDashboard serves the JSON of a single dashboard as Grafana imports it.
+// For example: curl 'localhost:9090/api/v1/dashboards/get?name=mining&datasource=prometheus&db=telliot'
+func (self *Handler) ServeDashboard(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	dashboard, err := Get(q.Get("name"), q.Get("datasource"), q.Get("db"))
+	if err != nil {
+		_ = web.WriteJSON(w, http.StatusNotFound, nil, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(dashboard); err != nil {
+		level.Error(self.logger).Log("msg", "encoding dashboard response", "err", err)
+	}
+}
+
+// Grafana pushes the dashboards to a Grafana instance through its HTTP API.
+type Grafana struct {
+	url    string
+	key    string
+	client *http.Client
+}
+
+func NewGrafana(url, key string, timeout time.Duration) *Grafana {
+	return &Grafana{
+		url:    strings.TrimSuffix(url, "/"),
+		key:    key,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Provision creates or overwrites all dashboards in the folder, which is created when missing,
+// and returns their URLs.
+func (self *Grafana) Provision(ctx context.Context, folder, datasource, dbDatasource string) ([]string, error) {
+	folderID, err := self.folder(ctx, folder)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting the folder:%v", folder)
+	}
+
+	var urls []string
+	for _, name := range Names() {
+		dashboard, err := Get(name, datasource, dbDatasource)
+		if err != nil {
+			return nil, err
+		}
+		var resp struct {
+			URL string `json:"url"`
+		}
+		req := map[string]interface{}{
+			"dashboard": dashboard,
+			"folderId":  folderID,
+			"overwrite": true,
+			"message":   "Provisioned by telliot",
+		}
+		if err := self.do(ctx, http.MethodPost, "/api/dashboards/db", req, &resp); err != nil {
+			return nil, errors.Wrapf(err, "pushing the dashboard:%v", name)
+		}
+		urls = append(urls, self.url+resp.URL)
+	}
+	return urls, nil
+}
+
+// folder returns the ID of the folder with the title, 0 for the General folder.
+func (self *Grafana) folder(ctx context.Context, title string) (int64, error) {
+	if title == "" {
+		return 0, nil
+	}
+	var folders []struct {
+		ID    int64  `json:"id"`
+		Title string `json:"title"`
+	}
+	if err := self.do(ctx, http.MethodGet, "/api/folders", nil, &folders); err != nil {
+		return 0, err
+	}
+	for _, f := range folders {
+		if f.Title == title {
+			return f.ID, nil
+		}
+	}
+	var created struct {
+		ID int64 `json:"id"`
+	}
+	if err := self.do(ctx, http.MethodPost, "/api/folders", map[string]string{"title": title}, &created); err != nil {
+		return 0, errors.Wrap(err, "creating the folder")
+	}
+	return created.ID, nil
+}
+
+func (self *Grafana) do(ctx context.Context, method, path string, body, v interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return errors.Wrap(err, "marshal request body")
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, self.url+path, reqBody)
+	if err != nil {
+		return errors.Wrap(err, "create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if self.key != "" {
+		req.Header.Set("Authorization", "Bearer "+self.key)
+	}
+	resp, err := self.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "send request")
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "read response")
+	}
+	if resp.StatusCode/100 != 2 {
+		var msg struct {
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(data, &msg)
+		return errors.Errorf("response code:%v message:%v", resp.StatusCode, msg.Message)
+	}
+	return errors.Wrap(json.Unmarshal(data, v), "decode response")
+}
@@ -0,0 +1,94 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package dashboards
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// TestGet ensures that the panels of all dashboards use their datasource variables
+// and that the variables default to the operator datasources.
+func TestGet(t *testing.T) {
+	testutil.Equals(t, []string{"data-quality", "disputes", "mining", "profit"}, Names())
+
+	for _, name := range Names() {
+		dashboard, err := Get(name, "prom", "")
+		testutil.Ok(t, err)
+
+		vars := make(map[string]string)
+		for _, v := range dashboard["templating"].(map[string]interface{})["list"].([]interface{}) {
+			v := v.(map[string]interface{})
+			vars["${"+v["name"].(string)+"}"] = v["current"].(map[string]interface{})["value"].(string)
+		}
+		for _, p := range dashboard["panels"].([]interface{}) {
+			ds := p.(map[string]interface{})["datasource"].(string)
+			current, ok := vars[ds]
+			testutil.Assert(t, ok, "dashboard:%v panel datasource:%v isn't a variable", name, ds)
+			// The db datasource defaults to the datasource.
+			testutil.Equals(t, "prom", current)
+		}
+	}
+
+	dashboard, err := Get("disputes", "prom", "telliot")
+	testutil.Ok(t, err)
+	db := dashboard["templating"].(map[string]interface{})["list"].([]interface{})[1].(map[string]interface{})
+	testutil.Equals(t, "telliot", db["current"].(map[string]interface{})["value"])
+
+	_, err = Get("unknown", "", "")
+	testutil.NotOk(t, err)
+}
+
+// TestProvision ensures that the folder is created once and that all dashboards are pushed into it.
+func TestProvision(t *testing.T) {
+	folders := []map[string]interface{}{{"id": 1, "title": "General"}}
+	pushed := make(map[string]float64)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		testutil.Equals(t, "Bearer secret", r.Header.Get("Authorization"))
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/folders":
+			testutil.Ok(t, json.NewEncoder(w).Encode(folders))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/folders":
+			folders = append(folders, map[string]interface{}{"id": 7, "title": "Telliot"})
+			testutil.Ok(t, json.NewEncoder(w).Encode(folders[len(folders)-1]))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/dashboards/db":
+			var req struct {
+				Dashboard map[string]interface{} `json:"dashboard"`
+				FolderID  float64                `json:"folderId"`
+				Overwrite bool                   `json:"overwrite"`
+			}
+			testutil.Ok(t, json.NewDecoder(r.Body).Decode(&req))
+			testutil.Assert(t, req.Overwrite, "the dashboards should be overwritten")
+			uid := req.Dashboard["uid"].(string)
+			pushed[uid] = req.FolderID
+			testutil.Ok(t, json.NewEncoder(w).Encode(map[string]string{"url": "/d/" + uid}))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			testutil.Ok(t, json.NewEncoder(w).Encode(map[string]string{"message": "not found"}))
+		}
+	}))
+	defer srv.Close()
+
+	grafana := NewGrafana(srv.URL, "secret", time.Second)
+	for i := 0; i < 2; i++ {
+		urls, err := grafana.Provision(context.Background(), "Telliot", "prometheus", "telliot")
+		testutil.Ok(t, err)
+		testutil.Equals(t, len(Names()), len(urls))
+		testutil.Equals(t, srv.URL+"/d/telliot-data-quality", urls[0])
+	}
+	testutil.Equals(t, 2, len(folders))
+	testutil.Equals(t, len(Names()), len(pushed))
+	for _, folderID := range pushed {
+		testutil.Equals(t, float64(7), folderID)
+	}
+
+	_, err := NewGrafana(srv.URL+"/missing", "secret", time.Second).Provision(context.Background(), "", "prometheus", "")
+	testutil.NotOk(t, err)
+}
@@ -0,0 +1,173 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package db
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// AuditEntry is a single state-changing operation.
+type AuditEntry struct {
+	Time time.Time
+	// Actor is who triggered the operation e.g. a component, the remote address of an API request or the CLI user.
+	Actor  string
+	Action string
+	// Target is what the operation changed e.g. a transaction hash or an API path.
+	Target  string            `json:",omitempty"`
+	Details map[string]string `json:",omitempty"`
+	Err     string            `json:",omitempty"`
+}
+
+// Audit is an append only log of all state-changing operations for post-incident review.
+// The CLI commands and a running instance can append to the same file.
+// A nil Audit doesn't record anything.
+type Audit struct {
+	mtx  sync.Mutex
+	path string
+	file *os.File
+}
+
+// OpenAudit opens or creates the audit log at the given path.
+func OpenAudit(path string) (*Audit, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return nil, errors.Wrap(err, "creating audit log folder")
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "open audit log file")
+	}
+	// Terminate a partial last line left by a crash so that it doesn't corrupt the next entry.
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, errors.Wrap(err, "stat audit log file")
+	}
+	if stat.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, stat.Size()-1); err != nil {
+			f.Close()
+			return nil, errors.Wrap(err, "read audit log file")
+		}
+		if last[0] != '\n' {
+			if _, err := f.Write([]byte{'\n'}); err != nil {
+				f.Close()
+				return nil, errors.Wrap(err, "write audit log file")
+			}
+		}
+	}
+	return &Audit{path: path, file: f}, nil
+}
+
+// Record appends an entry.
+func (self *Audit) Record(e AuditEntry) error {
+	if self == nil {
+		return nil
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return errors.Wrap(err, "marshal audit entry")
+	}
+
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	// A single write so that the entries of the processes appending to the same file don't interleave.
+	if _, err := self.file.Write(append(b, '\n')); err != nil {
+		return errors.Wrap(err, "write audit entry")
+	}
+	if err := self.file.Sync(); err != nil {
+		return errors.Wrap(err, "sync audit log file")
+	}
+	return nil
+}
+
+// Entries returns the entries recorded in the given time range, oldest first.
+// A zero time doesn't limit the range.
+func (self *Audit) Entries(since, until time.Time) ([]AuditEntry, error) {
+	return ReadAudit(self.path, since, until)
+}
+
+// maxAuditLine is the max size of an entry in the audit log file.
+const maxAuditLine = 10 * 1024 * 1024
+
+// ReadAudit reads the entries of an audit log file in the given time range, oldest first.
+// A zero time doesn't limit the range.
+func ReadAudit(path string, since, until time.Time) ([]AuditEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "open audit log file")
+	}
+	defer f.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	// The details of an entry can be longer than the default max line.
+	scanner.Buffer(make([]byte, 64*1024), maxAuditLine)
+	for scanner.Scan() {
+		var e AuditEntry
+		// A partial last line is expected when crashing in the middle of a write.
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		if !since.IsZero() && e.Time.Before(since) {
+			continue
+		}
+		if !until.IsZero() && e.Time.After(until) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+func (self *Audit) Close() error {
+	if self == nil {
+		return nil
+	}
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	return self.file.Close()
+}
+
+// ServeHTTP returns the entries, the since query param limits them to the given duration e.g. 24h.
+func (self *Audit) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var since time.Time
+	if s := r.URL.Query().Get("since"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			http.Error(w, errors.Wrap(err, "parsing since").Error(), http.StatusBadRequest)
+			return
+		}
+		since = time.Now().Add(-d)
+	}
+	entries, err := self.Entries(since, time.Time{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if entries == nil {
+		entries = []AuditEntry{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(struct {
+		Status string       `json:"status"`
+		Data   []AuditEntry `json:"data"`
+	}{
+		Status: "success",
+		Data:   entries,
+	}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package db
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// TestAuditAppend ensures that the entries survive a reopen and a crash in the middle of a write.
+func TestAuditAppend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	start := time.Now()
+	a, err := OpenAudit(path)
+	testutil.Ok(t, err)
+	testutil.Ok(t, a.Record(AuditEntry{Time: start.Add(-time.Hour), Actor: "cli:user", Action: "transfer", Target: "0x01"}))
+	testutil.Ok(t, a.Record(AuditEntry{Actor: "0x02", Action: "tx_broadcast", Target: "0xaa", Details: map[string]string{"nonce": "1"}}))
+	testutil.Ok(t, a.Close())
+
+	// Simulate a crash in the middle of a write.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0666)
+	testutil.Ok(t, err)
+	_, err = f.WriteString(`{"Actor":"0x02","Act`)
+	testutil.Ok(t, err)
+	testutil.Ok(t, f.Close())
+
+	a, err = OpenAudit(path)
+	testutil.Ok(t, err)
+	defer a.Close()
+	testutil.Ok(t, a.Record(AuditEntry{Actor: "api:127.0.0.1", Action: "api_put", Target: "/api/v1/loglevel"}))
+
+	entries, err := a.Entries(time.Time{}, time.Time{})
+	testutil.Ok(t, err)
+	testutil.Equals(t, 3, len(entries))
+	testutil.Equals(t, "transfer", entries[0].Action)
+	testutil.Equals(t, "1", entries[1].Details["nonce"])
+	testutil.Equals(t, "api_put", entries[2].Action)
+
+	entries, err = a.Entries(start, time.Time{})
+	testutil.Ok(t, err)
+	testutil.Equals(t, 2, len(entries))
+
+	// The entries longer than the default max line of the scanner.
+	testutil.Ok(t, a.Record(AuditEntry{Actor: "cli:user", Action: "config", Details: map[string]string{"value": strings.Repeat("a", 100*1024)}}))
+	entries, err = a.Entries(time.Time{}, time.Time{})
+	testutil.Ok(t, err)
+	testutil.Equals(t, 4, len(entries))
+	testutil.Equals(t, 100*1024, len(entries[3].Details["value"]))
+
+	var nilAudit *Audit
+	testutil.Ok(t, nilAudit.Record(AuditEntry{Action: "ignored"}))
+}
@@ -0,0 +1,270 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package db
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/tellor-io/telliot/pkg/format"
+)
+
+// BatchConfig sets how the samples of the trackers are batched before they are committed.
+// A zero interval disables the batching so that every append is committed on its own.
+type BatchConfig struct {
+	// Interval is how often the queued samples are committed.
+	Interval format.Duration
+	// MaxSamples commits the queued samples before the interval when reached.
+	MaxSamples int
+	// QueueSize limits the samples waiting for a commit
+	// and the writers wait while the queue is full.
+	QueueSize int
+}
+
+// Sample is a value to append to the DB.
+type Sample struct {
+	Labels labels.Labels
+	T      int64
+	V      float64
+	// Event adds an exemplar of the transaction that produced the value when set.
+	Event *types.Log
+	// Fails counts the sample when it can't be appended, when set.
+	Fails prometheus.Counter
+}
+
+// errClosed is returned for the samples appended after the DB was closed.
+var errClosed = errors.New("db closed")
+
+// The metrics are shared by all DBs as some commands open more than one.
+var (
+	batchQueue = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "telliot",
+		Subsystem: ComponentName,
+		Name:      "batch_queue_samples",
+		Help:      "The number of samples waiting for a commit",
+	})
+	batchSamples = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "telliot",
+		Subsystem: ComponentName,
+		Name:      "batch_samples",
+		Help:      "The number of samples in a single commit",
+		Buckets:   prometheus.ExponentialBuckets(1, 4, 8),
+	})
+	batchWaits = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "telliot",
+		Subsystem: ComponentName,
+		Name:      "batch_queue_full_total",
+		Help:      "The total number of appends that waited for a full queue",
+	})
+	batchFails = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "telliot",
+		Subsystem: ComponentName,
+		Name:      "batch_append_fails_total",
+		Help:      "The total number of samples that couldn't be appended",
+	})
+)
+
+// batcher commits the samples of all writers with a single appender
+// to avoid an appender and a commit per event.
+type batcher struct {
+	logger     log.Logger
+	cfg        BatchConfig
+	appendable storage.Appendable
+	queue      chan Sample
+	batch      []Sample
+
+	// mtx is held by the writers while they queue
+	// so that closing can wait for them.
+	mtx    sync.RWMutex
+	closed bool
+	quit   chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+func newBatcher(logger log.Logger, cfg BatchConfig, appendable storage.Appendable) (*batcher, error) {
+	if cfg.MaxSamples <= 0 {
+		return nil, errors.Errorf("batch max samples should be more than 0:%v", cfg.MaxSamples)
+	}
+	if cfg.QueueSize < cfg.MaxSamples {
+		return nil, errors.Errorf("batch queue size:%v lower than the max samples:%v", cfg.QueueSize, cfg.MaxSamples)
+	}
+	self := &batcher{
+		logger:     logger,
+		cfg:        cfg,
+		appendable: appendable,
+		queue:      make(chan Sample, cfg.QueueSize),
+		batch:      make([]Sample, 0, cfg.MaxSamples),
+		quit:       make(chan struct{}),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	go self.run()
+	return self, nil
+}
+
+// add queues the samples and waits while the queue is full.
+func (self *batcher) add(ctx context.Context, samples []Sample) error {
+	self.mtx.RLock()
+	defer self.mtx.RUnlock()
+	if self.closed {
+		return errClosed
+	}
+	for _, s := range samples {
+		select {
+		case self.queue <- s:
+			continue
+		default:
+		}
+		batchWaits.Inc()
+		select {
+		case self.queue <- s:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-self.quit:
+			return errClosed
+		}
+	}
+	return nil
+}
+
+func (self *batcher) run() {
+	defer close(self.done)
+	ticker := time.NewTicker(self.cfg.Interval.Duration)
+	defer ticker.Stop()
+	for {
+		select {
+		case s := <-self.queue:
+			self.batch = append(self.batch, s)
+			if len(self.batch) >= self.cfg.MaxSamples {
+				self.flush()
+			}
+		case <-ticker.C:
+			self.flush()
+		case <-self.stop:
+			for {
+				select {
+				case s := <-self.queue:
+					self.batch = append(self.batch, s)
+					if len(self.batch) >= self.cfg.MaxSamples {
+						self.flush()
+					}
+				default:
+					self.flush()
+					return
+				}
+			}
+		}
+		batchQueue.Set(float64(len(self.queue)))
+	}
+}
+
+func (self *batcher) flush() {
+	if len(self.batch) == 0 {
+		return
+	}
+	batchSamples.Observe(float64(len(self.batch)))
+
+	appender := self.appendable.Appender(context.Background())
+	var (
+		failed   []Sample
+		firstErr error
+	)
+	for _, s := range self.batch {
+		var err error
+		if s.Event != nil {
+			_, err = AppendWithTx(appender, s.Labels, s.T, s.V, *s.Event)
+		} else {
+			_, err = appender.Append(0, s.Labels, s.T, s.V)
+		}
+		// A sample that can't be appended e.g. out of order doesn't fail the others.
+		if err != nil {
+			failed = append(failed, s)
+			if firstErr == nil {
+				firstErr = errors.Wrapf(err, "series:%v", s.Labels)
+			}
+		}
+	}
+	if err := appender.Commit(); err != nil {
+		failed = self.batch
+		firstErr = errors.Wrap(err, "db append commit failed")
+	}
+	if len(failed) > 0 {
+		level.Error(self.logger).Log("msg", "appending batch", "failed", len(failed), "samples", len(self.batch), "err", firstErr)
+		for _, s := range failed {
+			batchFails.Inc()
+			if s.Fails != nil {
+				s.Fails.Inc()
+			}
+		}
+	}
+
+	// Clear the samples so that their labels can be garbage collected
+	// and keep the capacity to not allocate it again for the next batch.
+	for i := range self.batch {
+		self.batch[i] = Sample{}
+	}
+	self.batch = self.batch[:0]
+}
+
+// close commits the queued samples and waits for the commit.
+func (self *batcher) close() {
+	close(self.quit)
+	self.mtx.Lock()
+	self.closed = true
+	self.mtx.Unlock()
+	close(self.stop)
+	<-self.done
+}
+
+// Append adds the samples to the DB.
+// With the batching enabled the samples are queued and committed together with the samples
+// of the other writers, and it waits while the queue is full until the context is done.
+// Otherwise the samples are committed before it returns.
+func (self *DB) Append(ctx context.Context, samples ...Sample) (err error) {
+	if self.batcher != nil {
+		return self.batcher.add(ctx, samples)
+	}
+
+	appender := self.Appender(ctx)
+	defer func() { // An appender always needs to be committed or rolled back.
+		if err != nil {
+			if err := appender.Rollback(); err != nil {
+				level.Error(self.logger).Log("msg", "db rollback failed", "err", err)
+			}
+			return
+		}
+		if errC := appender.Commit(); errC != nil {
+			for _, s := range samples {
+				if s.Fails != nil {
+					s.Fails.Inc()
+				}
+			}
+			err = errors.Wrap(errC, "db append commit failed")
+		}
+	}()
+	for _, s := range samples {
+		if s.Event != nil {
+			_, err = AppendWithTx(appender, s.Labels, s.T, s.V, *s.Event)
+		} else {
+			_, err = appender.Append(0, s.Labels, s.T, s.V)
+		}
+		if err != nil {
+			if s.Fails != nil {
+				s.Fails.Inc()
+			}
+			return errors.Wrap(err, "append values to the DB")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package db
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// TestBatch ensures that the queued samples are committed when the batch is full
+// and when the DB is closed and that a failed sample doesn't fail the others.
+func TestBatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "batch")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	cfg := Config{
+		LogLevel: "info",
+		Path:     dir,
+		Batch: BatchConfig{
+			Interval:   format.Duration{Duration: time.Hour},
+			MaxSamples: 2,
+			QueueSize:  2,
+		},
+	}
+	tsDB, err := Open(logging.NewLogger(), cfg, tsdb.DefaultOptions())
+	testutil.Ok(t, err)
+
+	ctx := context.Background()
+	fails := prometheus.NewCounter(prometheus.CounterOpts{Name: "fails"})
+	sample := func(symbol string, ts int64) Sample {
+		return Sample{Labels: labels.FromStrings("__name__", "index", "symbol", symbol), T: ts, V: 1, Fails: fails}
+	}
+
+	testutil.Ok(t, tsDB.Append(ctx, sample("ETH/USD", 1000), sample("BTC/USD", 1000)))
+	// The full batch is committed without waiting for the interval.
+	for i := 0; count(t, tsDB.DB) < 2; i++ {
+		testutil.Assert(t, i < 100, "the full batch wasn't committed")
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Out of order.
+	testutil.Ok(t, tsDB.Append(ctx, sample("ETH/USD", 500), sample("ETH/USD", 2000)))
+	testutil.Ok(t, tsDB.Append(ctx, sample("TRB/USD", 2000)))
+	testutil.Ok(t, tsDB.Close())
+	testutil.Equals(t, float64(1), promtest.ToFloat64(fails))
+	testutil.Equals(t, errClosed, tsDB.Append(ctx, sample("TRB/USD", 3000)))
+
+	cfg.Batch = BatchConfig{}
+	tsDB, err = Open(logging.NewLogger(), cfg, tsdb.DefaultOptions())
+	testutil.Ok(t, err)
+	defer tsDB.Close()
+	testutil.Equals(t, 4, count(t, tsDB.DB))
+
+	// Without batching the samples are committed before the append returns.
+	testutil.Ok(t, tsDB.Append(ctx, sample("TRB/USD", 3000)))
+	testutil.Equals(t, 5, count(t, tsDB.DB))
+}
+
+func count(t *testing.T, tsDB *tsdb.DB) int {
+	q, err := tsDB.Querier(context.Background(), 0, 5000)
+	testutil.Ok(t, err)
+	defer q.Close()
+	set := q.Select(false, nil, labels.MustNewMatcher(labels.MatchEqual, "__name__", "index"))
+	var n int
+	for set.Next() {
+		it := set.At().Iterator()
+		for it.Next() {
+			n++
+		}
+	}
+	testutil.Ok(t, set.Err())
+	return n
+}
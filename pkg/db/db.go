@@ -12,8 +12,34 @@ const ComponentName = "db"
 type Config struct {
 	LogLevel string
 	Path     string
+	// JournalPath is the file that records the state of all submissions.
+	JournalPath string
+	// AuditPath is the file that records all state-changing operations, empty disables it.
+	// The files at their default location in the DB dir move with Path.
+	AuditPath string
 	// Connect to this remote DB.
 	RemoteHost    string
 	RemotePort    uint
 	RemoteTimeout format.Duration
+	// MaxExemplars is the number of the latest exemplars kept in memory
+	// e.g. the transaction hashes of the tracked on-chain values.
+	MaxExemplars int
+	// ExternalLabels are added to all samples written to the local DB and sent to the remote write
+	// e.g. instance, region and network so that the series of many instances can be aggregated centrally.
+	ExternalLabels map[string]string
+	// RemoteWrite sends the samples written to the local DB to Prometheus compatible remote storages.
+	RemoteWrite []RemoteWriteConfig
+	// Downsample replaces the old raw samples with rollups to keep a long history.
+	Downsample DownsampleConfig
+	// Batch commits the samples of the trackers together to avoid a commit per event.
+	Batch BatchConfig
+	// Lightweight keeps only the recent samples in memory instead of the TSDB.
+	Lightweight LightweightConfig
+}
+
+type RemoteWriteConfig struct {
+	URL string
+	// Headers are added to the requests e.g. an Authorization header.
+	Headers map[string]string
+	Timeout format.Duration
 }
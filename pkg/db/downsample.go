@@ -0,0 +1,270 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package db
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/tellor-io/telliot/pkg/format"
+)
+
+// downsampleChunk is the period downsampled at once to limit the memory usage.
+const downsampleChunk = 24 * time.Hour
+
+type DownsampleConfig struct {
+	Enabled bool
+	// After is the age of the raw samples which are replaced with the rollups.
+	After format.Duration
+	// Interval is how often to downsample.
+	Interval format.Duration
+	// Resolutions are the periods of the rollups. Each should divide an hour.
+	Resolutions []format.Duration
+	// Metrics are the names of the raw series to downsample.
+	Metrics []string
+	// Retention of the DB when downsampling so that the rollups are kept longer than the raw samples.
+	Retention format.Duration
+}
+
+// RollupName returns the name of the rollup series of a metric e.g. indexTracker_value_5m.
+func RollupName(metric string, resolution time.Duration) string {
+	return metric + "_" + model.Duration(resolution).String()
+}
+
+// Downsampler replaces the raw samples older than DownsampleConfig.After
+// with the average of each period of the resolutions.
+// The rollups are written as new blocks, which the DB loads within a minute,
+// so the DB needs to allow overlapping blocks and then the raw samples are deleted.
+type Downsampler struct {
+	ctx    context.Context
+	close  context.CancelFunc
+	logger log.Logger
+	cfg    DownsampleConfig
+	db     *DB
+
+	rollupSamples prometheus.Counter
+}
+
+func NewDownsampler(logger log.Logger, ctx context.Context, cfg DownsampleConfig, db *DB) (*Downsampler, error) {
+	if db.memory != nil {
+		return nil, errors.New("the downsampling doesn't work in the lightweight mode")
+	}
+	if cfg.After.Duration < 24*time.Hour {
+		return nil, errors.Errorf("downsampling after:%v, should be at least a day so that the raw samples are no longer in the head", cfg.After)
+	}
+	if cfg.Interval.Duration <= 0 {
+		return nil, errors.Errorf("invalid downsampling interval:%v", cfg.Interval)
+	}
+	if cfg.Retention.Duration <= cfg.After.Duration {
+		return nil, errors.Errorf("retention:%v should be longer than the downsampling after:%v", cfg.Retention, cfg.After)
+	}
+	for _, res := range cfg.Resolutions {
+		if res.Duration <= 0 || time.Hour%res.Duration != 0 {
+			return nil, errors.Errorf("invalid resolution:%v, should divide an hour", res)
+		}
+	}
+	if len(cfg.Resolutions) == 0 || len(cfg.Metrics) == 0 {
+		return nil, errors.New("downsampling needs at least one resolution and metric")
+	}
+	ctx, close := context.WithCancel(ctx)
+	return &Downsampler{
+		ctx:    ctx,
+		close:  close,
+		logger: log.With(logger, "component", "downsampler"),
+		cfg:    cfg,
+		db:     db,
+		rollupSamples: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "rollup_samples_total",
+			Help:      "The total number of samples written by the downsampler",
+		}),
+	}, nil
+}
+
+func (self *Downsampler) Start() error {
+	level.Info(self.logger).Log("msg", "starting", "after", self.cfg.After, "retention", self.cfg.Retention)
+
+	ticker := time.NewTicker(self.cfg.Interval.Duration)
+	defer ticker.Stop()
+	for {
+		if err := self.run(); err != nil {
+			level.Error(self.logger).Log("msg", "downsampling", "err", err)
+		}
+		select {
+		case <-self.ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (self *Downsampler) Stop() {
+	self.close()
+}
+
+func (self *Downsampler) matcher() *labels.Matcher {
+	return labels.MustNewMatcher(labels.MatchRegexp, labels.MetricName, strings.Join(self.cfg.Metrics, "|"))
+}
+
+// run downsamples all raw samples older than the configured age a day at a time.
+func (self *Downsampler) run() error {
+	end := timestamp.FromTime(time.Now().Add(-self.cfg.After.Duration).Truncate(time.Hour))
+	first, err := self.first(end)
+	if err != nil {
+		return errors.Wrap(err, "getting the oldest raw sample")
+	}
+	if first >= end {
+		return nil
+	}
+	chunk := downsampleChunk.Milliseconds()
+	hour := time.Hour.Milliseconds()
+	for from := first / hour * hour; from < end; from += chunk {
+		select {
+		case <-self.ctx.Done():
+			return nil
+		default:
+		}
+		to := from + chunk
+		if to > end {
+			to = end
+		}
+		count, err := self.downsample(from, to)
+		if err != nil {
+			return errors.Wrapf(err, "downsampling from:%v to:%v", timestamp.Time(from), timestamp.Time(to))
+		}
+		// The intervals of the blocks are closed so exclude the end.
+		if err := self.db.Delete(from, to-1, self.matcher()); err != nil {
+			return errors.Wrap(err, "deleting the raw samples")
+		}
+		self.rollupSamples.Add(float64(count))
+		level.Info(self.logger).Log("msg", "downsampled", "from", timestamp.Time(from), "to", timestamp.Time(to), "rollupSamples", count)
+	}
+	// Free the disk space of the deleted samples.
+	if err := self.db.CleanTombstones(); err != nil {
+		return errors.Wrap(err, "cleaning the deleted samples")
+	}
+	return nil
+}
+
+// first returns the timestamp of the oldest raw sample before the end.
+func (self *Downsampler) first(end int64) (int64, error) {
+	q, err := self.db.Querier(self.ctx, math.MinInt64, end-1)
+	if err != nil {
+		return 0, err
+	}
+	defer q.Close()
+	first := end
+	set := q.Select(false, nil, self.matcher())
+	for set.Next() {
+		it := set.At().Iterator()
+		if it.Next() {
+			if t, _ := it.At(); t < first {
+				first = t
+			}
+		}
+		if err := it.Err(); err != nil {
+			return 0, err
+		}
+	}
+	return first, set.Err()
+}
+
+type bucket struct {
+	sum   float64
+	count int
+}
+
+// downsample writes the rollups of the raw samples in [from, to) to a new block
+// and returns the number of the written samples.
+// Each rollup sample is the average of its period and has the timestamp of the period end.
+func (self *Downsampler) downsample(from, to int64) (count int, err error) {
+	q, err := self.db.Querier(self.ctx, from, to-1)
+	if err != nil {
+		return 0, err
+	}
+	defer q.Close()
+
+	// The writer logs every step of the flush.
+	w, err := tsdb.NewBlockWriter(level.NewFilter(self.logger, level.AllowWarn()), self.db.Dir(), downsampleChunk.Milliseconds())
+	if err != nil {
+		return 0, errors.Wrap(err, "creating the block writer")
+	}
+	defer func() {
+		if errC := w.Close(); errC != nil && err == nil {
+			err = errors.Wrap(errC, "closing the block writer")
+		}
+	}()
+	appender := w.Appender(self.ctx)
+
+	set := q.Select(false, nil, self.matcher())
+	for set.Next() {
+		series := set.At()
+		buckets := make([]map[int64]*bucket, len(self.cfg.Resolutions))
+		for i := range buckets {
+			buckets[i] = make(map[int64]*bucket)
+		}
+		it := series.Iterator()
+		for it.Next() {
+			t, v := it.At()
+			for i, res := range self.cfg.Resolutions {
+				start := t / res.Milliseconds() * res.Milliseconds()
+				b, ok := buckets[i][start]
+				if !ok {
+					b = &bucket{}
+					buckets[i][start] = b
+				}
+				b.sum += v
+				b.count++
+			}
+		}
+		if err := it.Err(); err != nil {
+			return 0, errors.Wrap(err, "reading the raw samples")
+		}
+		for i, res := range self.cfg.Resolutions {
+			lbls := labels.NewBuilder(series.Labels()).
+				Set(labels.MetricName, RollupName(series.Labels().Get(labels.MetricName), res.Duration)).
+				Labels()
+			starts := make([]int64, 0, len(buckets[i]))
+			for start := range buckets[i] {
+				starts = append(starts, start)
+			}
+			sort.Slice(starts, func(a, b int) bool { return starts[a] < starts[b] })
+			var ref uint64
+			for _, start := range starts {
+				b := buckets[i][start]
+				ref, err = appender.Append(ref, lbls, start+res.Milliseconds(), b.sum/float64(b.count))
+				if err != nil {
+					return 0, errors.Wrap(err, "appending a rollup sample")
+				}
+				count++
+			}
+		}
+	}
+	if err := set.Err(); err != nil {
+		return 0, errors.Wrap(err, "reading the raw series")
+	}
+	if err := appender.Commit(); err != nil {
+		return 0, errors.Wrap(err, "committing the rollups")
+	}
+	if count == 0 {
+		return 0, nil
+	}
+	if _, err := w.Flush(self.ctx); err != nil {
+		return 0, errors.Wrap(err, "writing the rollup block")
+	}
+	return count, nil
+}
@@ -0,0 +1,94 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package db
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// TestDownsample ensures that the old raw samples are replaced with the averages of each period.
+func TestDownsample(t *testing.T) {
+	dir, err := ioutil.TempDir("", "downsample")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	cfg := Config{
+		LogLevel: "info",
+		Path:     dir,
+		Downsample: DownsampleConfig{
+			Enabled:     true,
+			After:       format.Duration{Duration: 7 * 24 * time.Hour},
+			Interval:    format.Duration{Duration: time.Hour},
+			Resolutions: []format.Duration{{Duration: 5 * time.Minute}, {Duration: time.Hour}},
+			Metrics:     []string{"index_value"},
+			Retention:   format.Duration{Duration: 365 * 24 * time.Hour},
+		},
+	}
+	tsDB, err := Open(logging.NewLogger(), cfg, tsdb.DefaultOptions())
+	testutil.Ok(t, err)
+	defer func() { tsDB.Close() }()
+
+	// A sample every minute for 2 hours 10 days ago.
+	start := time.Now().Add(-10 * 24 * time.Hour).Truncate(time.Hour)
+	raw := labels.FromStrings("__name__", "index_value", "symbol", "ETH_USD")
+	appender := tsDB.Appender(context.Background())
+	for i := 0; i < 120; i++ {
+		_, err := appender.Append(0, raw, timestamp.FromTime(start.Add(time.Duration(i)*time.Minute)), float64(i))
+		testutil.Ok(t, err)
+	}
+	testutil.Ok(t, appender.Commit())
+
+	downsampler, err := NewDownsampler(logging.NewLogger(), context.Background(), cfg.Downsample, tsDB)
+	testutil.Ok(t, err)
+	testutil.Ok(t, downsampler.run())
+	// Reopen to load the block of the rollups.
+	testutil.Ok(t, tsDB.Close())
+	tsDB, err = Open(logging.NewLogger(), cfg, tsdb.DefaultOptions())
+	testutil.Ok(t, err)
+	downsampler.db = tsDB
+
+	samples := func(name string) map[time.Time]float64 {
+		q, err := tsDB.Querier(context.Background(), timestamp.FromTime(start), timestamp.FromTime(start.Add(3*time.Hour)))
+		testutil.Ok(t, err)
+		defer q.Close()
+		result := make(map[time.Time]float64)
+		set := q.Select(false, nil, labels.MustNewMatcher(labels.MatchEqual, "__name__", name))
+		for set.Next() {
+			testutil.Equals(t, "ETH_USD", set.At().Labels().Get("symbol"))
+			it := set.At().Iterator()
+			for it.Next() {
+				ts, v := it.At()
+				result[timestamp.Time(ts)] = v
+			}
+			testutil.Ok(t, it.Err())
+		}
+		testutil.Ok(t, set.Err())
+		return result
+	}
+
+	testutil.Equals(t, 0, len(samples("index_value")))
+	hourly := samples("index_value_1h")
+	testutil.Equals(t, map[time.Time]float64{
+		start.Add(time.Hour).UTC():     29.5,
+		start.Add(2 * time.Hour).UTC(): 89.5,
+	}, hourly)
+	fiveMin := samples("index_value_5m")
+	testutil.Equals(t, 24, len(fiveMin))
+	testutil.Equals(t, 2.0, fiveMin[start.Add(5*time.Minute).UTC()])
+
+	// Nothing left to downsample.
+	testutil.Ok(t, downsampler.run())
+	testutil.Equals(t, hourly, samples("index_value_1h"))
+}
@@ -0,0 +1,38 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package db
+
+import (
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/pkg/exemplar"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/storage"
+)
+
+// AppendWithTx appends a sample together with an exemplar
+// of the transaction hash and block number of the event that produced it
+// so that a sample on a dashboard can be traced back to the on-chain transaction.
+// The exemplars are dropped when the DB is opened without MaxExemplars.
+func AppendWithTx(appender storage.Appender, lbls labels.Labels, ts int64, value float64, event types.Log) (uint64, error) {
+	ref, err := appender.Append(0, lbls, ts, value)
+	if err != nil {
+		return 0, errors.Wrap(err, "append values to the DB")
+	}
+	_, err = appender.AppendExemplar(ref, lbls, exemplar.Exemplar{
+		Labels: labels.FromStrings(
+			"tx_hash", event.TxHash.String(),
+			"block", strconv.FormatUint(event.BlockNumber, 10),
+		),
+		Value: value,
+		Ts:    ts,
+		HasTs: true,
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "append exemplar to the DB")
+	}
+	return ref, nil
+}
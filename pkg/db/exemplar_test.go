@@ -0,0 +1,47 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package db
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// TestAppendWithTx ensures that the transaction of a sample can be queried back.
+func TestAppendWithTx(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exemplar")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	opts := tsdb.DefaultOptions()
+	opts.MaxExemplars = 10
+	tsDB, err := tsdb.Open(dir, nil, nil, opts)
+	testutil.Ok(t, err)
+	defer tsDB.Close()
+
+	hash := common.HexToHash("0x7a2f")
+	lbls := labels.FromStrings("__name__", "oracle_value", "id", "1")
+	appender := tsDB.Appender(context.Background())
+	_, err = AppendWithTx(appender, lbls, 1000, 10, types.Log{TxHash: hash, BlockNumber: 12})
+	testutil.Ok(t, err)
+	testutil.Ok(t, appender.Commit())
+
+	q, err := tsDB.ExemplarQuerier(context.Background())
+	testutil.Ok(t, err)
+	res, err := q.Select(0, 2000, []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "__name__", "oracle_value")})
+	testutil.Ok(t, err)
+	testutil.Equals(t, 1, len(res))
+	testutil.Equals(t, 1, len(res[0].Exemplars))
+	testutil.Equals(t, hash.String(), res[0].Exemplars[0].Labels.Get("tx_hash"))
+	testutil.Equals(t, "12", res[0].Exemplars[0].Labels.Get("block"))
+	testutil.Equals(t, float64(10), res[0].Exemplars[0].Value)
+}
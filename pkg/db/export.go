@@ -0,0 +1,308 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package db
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/textparse"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/fileutil"
+)
+
+// DefaultSelector selects all series.
+const DefaultSelector = `{__name__=~".+"}`
+
+// importBatch is the number of samples written to a block at once to limit the memory usage.
+const importBatch = 5000000
+
+var lvalEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+// selectSeries returns the sorted series of the querier matching any of the selectors.
+func selectSeries(q storage.Querier, selectors []string) (storage.SeriesSet, error) {
+	if len(selectors) == 0 {
+		selectors = []string{DefaultSelector}
+	}
+	var sets []storage.SeriesSet
+	for _, s := range selectors {
+		matchers, err := parser.ParseMetricSelector(s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing selector:%v", s)
+		}
+		sets = append(sets, q.Select(true, nil, matchers...))
+	}
+	return storage.NewMergeSeriesSet(sets, storage.ChainedSeriesMerge), nil
+}
+
+// ExportOpenMetrics writes the samples of the series matching any of the selectors in [mint, maxt]
+// in the OpenMetrics text format and returns the number of the written samples.
+// The type of all metrics is unknown as the DB doesn't keep the metadata.
+func ExportOpenMetrics(ctx context.Context, w io.Writer, db storage.Queryable, mint, maxt int64, selectors []string) (count int, err error) {
+	q, err := db.Querier(ctx, mint, maxt)
+	if err != nil {
+		return 0, errors.Wrap(err, "creating querier")
+	}
+	defer q.Close()
+	set, err := selectSeries(q, selectors)
+	if err != nil {
+		return 0, err
+	}
+
+	bw := bufio.NewWriter(w)
+	var lastName string
+	for set.Next() {
+		series := set.At()
+		name := series.Labels().Get(labels.MetricName)
+		if name != lastName {
+			if _, err := fmt.Fprintf(bw, "# TYPE %s unknown\n", name); err != nil {
+				return 0, err
+			}
+			lastName = name
+		}
+		var lbls []string
+		for _, l := range series.Labels() {
+			if l.Name == labels.MetricName {
+				continue
+			}
+			lbls = append(lbls, l.Name+`="`+lvalEscaper.Replace(l.Value)+`"`)
+		}
+		prefix := name
+		if len(lbls) > 0 {
+			prefix += "{" + strings.Join(lbls, ",") + "}"
+		}
+		it := series.Iterator()
+		for it.Next() {
+			t, v := it.At()
+			if _, err := fmt.Fprintf(bw, "%s %s %s\n",
+				prefix,
+				strconv.FormatFloat(v, 'g', -1, 64),
+				strconv.FormatFloat(float64(t)/1000, 'f', -1, 64),
+			); err != nil {
+				return 0, err
+			}
+			count++
+		}
+		if err := it.Err(); err != nil {
+			return 0, errors.Wrapf(err, "reading the samples of series:%v", series.Labels())
+		}
+	}
+	if err := set.Err(); err != nil {
+		return 0, errors.Wrap(err, "reading the series")
+	}
+	if _, err := bw.WriteString("# EOF\n"); err != nil {
+		return 0, err
+	}
+	return count, bw.Flush()
+}
+
+// ExportBlocks writes the samples of the series matching any of the selectors in [mint, maxt]
+// as TSDB blocks to the dir and returns the number of the written samples.
+func ExportBlocks(ctx context.Context, logger log.Logger, dir string, db storage.Queryable, mint, maxt int64, selectors []string) (int, error) {
+	q, err := db.Querier(ctx, mint, maxt)
+	if err != nil {
+		return 0, errors.Wrap(err, "creating querier")
+	}
+	defer q.Close()
+	set, err := selectSeries(q, selectors)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, errors.Wrap(err, "creating the output dir")
+	}
+	w := newBlockWriter(ctx, logger, dir)
+	for set.Next() {
+		series := set.At()
+		it := series.Iterator()
+		var ref uint64
+		for it.Next() {
+			t, v := it.At()
+			if ref, err = w.append(ref, series.Labels(), t, v); err != nil {
+				w.discard()
+				return 0, err
+			}
+		}
+		if err := it.Err(); err != nil {
+			w.discard()
+			return 0, errors.Wrapf(err, "reading the samples of series:%v", series.Labels())
+		}
+	}
+	if err := set.Err(); err != nil {
+		w.discard()
+		return 0, errors.Wrap(err, "reading the series")
+	}
+	return w.count, w.close()
+}
+
+// ImportOpenMetrics writes the samples of the OpenMetrics text to new blocks in the dir of a DB
+// and returns the number of the written samples.
+// All samples need a timestamp and the samples of a series need to be in order.
+// A running DB loads the new blocks within a minute.
+func ImportOpenMetrics(ctx context.Context, logger log.Logger, r io.Reader, dir string) (int, error) {
+	input, err := ioutil.ReadAll(r)
+	if err != nil {
+		return 0, errors.Wrap(err, "reading the input")
+	}
+	w := newBlockWriter(ctx, logger, dir)
+	p := textparse.NewOpenMetricsParser(input)
+	for {
+		entry, err := p.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			w.discard()
+			return 0, errors.Wrap(err, "parsing the input")
+		}
+		if entry != textparse.EntrySeries {
+			continue
+		}
+		_, ts, v := p.Series()
+		var lbls labels.Labels
+		p.Metric(&lbls)
+		if ts == nil {
+			w.discard()
+			return 0, errors.Errorf("no timestamp for a sample of series:%v", lbls)
+		}
+		if _, err := w.append(0, lbls, *ts, v); err != nil {
+			w.discard()
+			return 0, err
+		}
+	}
+	return w.count, w.close()
+}
+
+// ImportBlocks copies the TSDB blocks in the src dir to the dir of a DB
+// and returns the number of the copied blocks.
+// A running DB loads the new blocks within a minute.
+func ImportBlocks(logger log.Logger, src, dir string) (int, error) {
+	entries, err := ioutil.ReadDir(src)
+	if err != nil {
+		return 0, errors.Wrap(err, "reading the input dir")
+	}
+	var blocks []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(src, e.Name(), "meta.json")); err != nil {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, e.Name())); err == nil {
+			return 0, errors.Errorf("block:%v already exists in the DB", e.Name())
+		}
+		blocks = append(blocks, e.Name())
+	}
+	if len(blocks) == 0 {
+		return 0, errors.Errorf("no blocks in:%v", src)
+	}
+	for _, b := range blocks {
+		// Copy to a tmp dir first so that the DB doesn't load a partial block.
+		tmp := filepath.Join(dir, b+".tmp-for-creation")
+		if err := fileutil.CopyDirs(filepath.Join(src, b), tmp); err != nil {
+			os.RemoveAll(tmp)
+			return 0, errors.Wrapf(err, "copying block:%v", b)
+		}
+		if err := fileutil.Replace(tmp, filepath.Join(dir, b)); err != nil {
+			os.RemoveAll(tmp)
+			return 0, errors.Wrapf(err, "moving block:%v", b)
+		}
+		level.Info(logger).Log("msg", "imported block", "block", b)
+	}
+	return len(blocks), nil
+}
+
+// blockWriter writes the samples to new blocks in batches.
+// The samples of a batch can be in any time range so the blocks can overlap.
+type blockWriter struct {
+	ctx    context.Context
+	logger log.Logger
+	dir    string
+
+	w        *tsdb.BlockWriter
+	appender storage.Appender
+	batch    int
+	count    int
+}
+
+func newBlockWriter(ctx context.Context, logger log.Logger, dir string) *blockWriter {
+	return &blockWriter{
+		ctx: ctx,
+		// The writer logs every step of the flush.
+		logger: level.NewFilter(logger, level.AllowWarn()),
+		dir:    dir,
+	}
+}
+
+func (self *blockWriter) append(ref uint64, lbls labels.Labels, t int64, v float64) (uint64, error) {
+	if self.w == nil {
+		w, err := tsdb.NewBlockWriter(self.logger, self.dir, tsdb.DefaultBlockDuration)
+		if err != nil {
+			return 0, errors.Wrap(err, "creating the block writer")
+		}
+		self.w = w
+		self.appender = w.Appender(self.ctx)
+		ref = 0
+	}
+	ref, err := self.appender.Append(ref, lbls, t, v)
+	if err != nil {
+		return 0, errors.Wrapf(err, "appending a sample of series:%v", lbls)
+	}
+	self.count++
+	self.batch++
+	if self.batch >= importBatch {
+		if err := self.flush(); err != nil {
+			return 0, err
+		}
+	}
+	return ref, nil
+}
+
+// flush writes the current batch to a new block.
+func (self *blockWriter) flush() error {
+	if self.w == nil {
+		return nil
+	}
+	w := self.w
+	self.w = nil
+	defer w.Close()
+	if err := self.appender.Commit(); err != nil {
+		return errors.Wrap(err, "committing the samples")
+	}
+	if self.batch == 0 {
+		return nil
+	}
+	self.batch = 0
+	if _, err := w.Flush(self.ctx); err != nil {
+		return errors.Wrap(err, "writing the block")
+	}
+	return nil
+}
+
+// close flushes the last batch.
+func (self *blockWriter) close() error {
+	return self.flush()
+}
+
+// discard drops the current batch after an error.
+func (self *blockWriter) discard() {
+	if self.w != nil {
+		self.w.Close()
+		self.w = nil
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package db
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// TestExportImport ensures that the exported series are the same after
+// importing them to another DB in both formats.
+func TestExportImport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "export")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+	logger := logging.NewLogger()
+	ctx := context.Background()
+
+	src, err := Open(logger, Config{LogLevel: "info", Path: filepath.Join(dir, "src")}, tsdb.DefaultOptions())
+	testutil.Ok(t, err)
+	defer src.Close()
+	appender := src.Appender(ctx)
+	for i := int64(0); i < 10; i++ {
+		_, err = appender.Append(0, labels.FromStrings("__name__", "index", "symbol", "ETH/USD", "source", `a "quoted"\source`), i*1500, float64(i))
+		testutil.Ok(t, err)
+		_, err = appender.Append(0, labels.FromStrings("__name__", "value", "symbol", "ETH/USD"), i*1500, float64(i)/3)
+		testutil.Ok(t, err)
+		_, err = appender.Append(0, labels.FromStrings("__name__", "other"), i*1500, 1)
+		testutil.Ok(t, err)
+	}
+	testutil.Ok(t, appender.Commit())
+
+	selectors := []string{`{__name__="index"}`, `value{symbol="ETH/USD"}`}
+	var om bytes.Buffer
+	count, err := ExportOpenMetrics(ctx, &om, src, 1500, 12000, selectors)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 16, count)
+	testutil.Assert(t, strings.HasSuffix(om.String(), "# EOF\n"), "missing EOF")
+
+	blocksDir := filepath.Join(dir, "blocks")
+	count, err = ExportBlocks(ctx, logger, blocksDir, src, 1500, 12000, selectors)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 16, count)
+
+	expected := series(t, src, 1500, 12000, selectors)
+	testutil.Equals(t, 2, len(expected))
+
+	omDir := filepath.Join(dir, "openmetrics")
+	count, err = ImportOpenMetrics(ctx, logger, bytes.NewReader(om.Bytes()), omDir)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 16, count)
+
+	blocksDst := filepath.Join(dir, "dst")
+	testutil.Ok(t, os.MkdirAll(blocksDst, 0755))
+	n, err := ImportBlocks(logger, blocksDir, blocksDst)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 1, n)
+	_, err = ImportBlocks(logger, blocksDir, blocksDst)
+	testutil.NotOk(t, err)
+
+	for _, d := range []string{omDir, blocksDst} {
+		dst, err := Open(logger, Config{LogLevel: "info", Path: d}, tsdb.DefaultOptions())
+		testutil.Ok(t, err)
+		testutil.Equals(t, expected, series(t, dst, math.MinInt64, math.MaxInt64, nil))
+		testutil.Ok(t, dst.Close())
+	}
+
+	_, err = ImportOpenMetrics(ctx, logger, strings.NewReader("index 1\n# EOF\n"), omDir)
+	testutil.NotOk(t, err)
+}
+
+type sample struct {
+	t int64
+	v float64
+}
+
+func series(t *testing.T, db *DB, mint, maxt int64, selectors []string) map[string][]sample {
+	q, err := db.Querier(context.Background(), mint, maxt)
+	testutil.Ok(t, err)
+	defer q.Close()
+	set, err := selectSeries(q, selectors)
+	testutil.Ok(t, err)
+	result := make(map[string][]sample)
+	for set.Next() {
+		it := set.At().Iterator()
+		for it.Next() {
+			ts, v := it.At()
+			result[set.At().Labels().String()] = append(result[set.At().Labels().String()], sample{t: ts, v: v})
+		}
+		testutil.Ok(t, it.Err())
+	}
+	testutil.Ok(t, set.Err())
+	return result
+}
@@ -0,0 +1,293 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package db
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/fsutil"
+)
+
+// SubmissionState is a step in the lifecycle of a single submission:
+// mined -> simulated -> broadcast -> confirmed.
+// Any of the non final states can move to failed,
+// and a submission only simulated in dry-run mode ends in dry_run.
+type SubmissionState string
+
+const (
+	StateMined     SubmissionState = "mined"
+	StateSimulated SubmissionState = "simulated"
+	StateBroadcast SubmissionState = "broadcast"
+	StateConfirmed SubmissionState = "confirmed"
+	StateFailed    SubmissionState = "failed"
+	StateDryRun    SubmissionState = "dry_run"
+)
+
+// Final reports whether no more state changes are expected.
+func (self SubmissionState) Final() bool {
+	return self == StateConfirmed || self == StateFailed || self == StateDryRun
+}
+
+// maxJournalSubmissions is how many submissions are kept when compacting the journal.
+const maxJournalSubmissions = 1000
+
+// journalCompactEvery is how many records are appended before compacting the journal again
+// so that it doesn't grow without a restart.
+const journalCompactEvery = 5 * maxJournalSubmissions
+
+// Submission is a single journal record.
+// Every state change appends a new record with the same ID.
+type Submission struct {
+	ID      string
+	Account string
+	// Function is the contract function of the submission, empty for the mining solutions
+	// recorded before it was added.
+	Function   string `json:",omitempty"`
+	State      SubmissionState
+	Challenge  string   `json:",omitempty"`
+	RequestIDs []string `json:",omitempty"`
+	Values     []string `json:",omitempty"`
+	TxHash     string   `json:",omitempty"`
+	Nonce      uint64   `json:",omitempty"`
+	GasUsed    uint64   `json:",omitempty"`
+	Err        string   `json:",omitempty"`
+	Time       time.Time
+}
+
+// Journal is an append only log of all submission state changes.
+// It is replayed on startup so that in-flight transactions are not forgotten after a restart.
+type Journal struct {
+	mtx         sync.Mutex
+	path        string
+	file        *os.File
+	submissions map[string]*Submission
+	order       []string
+	// appended is the number of records since the last compaction.
+	appended     int
+	compactEvery int
+}
+
+// OpenJournal opens or creates the journal at the given path and replays its content.
+func OpenJournal(path string) (*Journal, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return nil, errors.Wrap(err, "creating journal folder")
+	}
+	self := &Journal{
+		path:         path,
+		submissions:  make(map[string]*Submission),
+		compactEvery: journalCompactEvery,
+	}
+	if err := self.replay(); err != nil {
+		return nil, errors.Wrap(err, "replay journal")
+	}
+	if err := self.compact(); err != nil {
+		return nil, errors.Wrap(err, "compact journal")
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+	if err != nil {
+		return nil, errors.Wrap(err, "open journal file")
+	}
+	self.file = f
+	return self, nil
+}
+
+func (self *Journal) replay() error {
+	f, err := os.Open(self.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "open journal file")
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		s := &Submission{}
+		// A partial last line is expected when crashing in the middle of a write.
+		if err := json.Unmarshal(scanner.Bytes(), s); err != nil {
+			continue
+		}
+		self.apply(s)
+	}
+	return scanner.Err()
+}
+
+func (self *Journal) apply(s *Submission) {
+	prev, ok := self.submissions[s.ID]
+	if !ok {
+		self.order = append(self.order, s.ID)
+		self.submissions[s.ID] = s
+		return
+	}
+	// Keep details recorded in earlier states.
+	if s.Function == "" {
+		s.Function = prev.Function
+	}
+	if s.Challenge == "" {
+		s.Challenge = prev.Challenge
+	}
+	if len(s.RequestIDs) == 0 {
+		s.RequestIDs = prev.RequestIDs
+	}
+	if len(s.Values) == 0 {
+		s.Values = prev.Values
+	}
+	if s.TxHash == "" {
+		s.TxHash = prev.TxHash
+		s.Nonce = prev.Nonce
+	}
+	if s.GasUsed == 0 {
+		s.GasUsed = prev.GasUsed
+	}
+	self.submissions[s.ID] = s
+}
+
+// compact rewrites the journal atomically with only the latest state of the most recent submissions.
+// Submissions that haven't reached a final state are always kept so that they can be resumed.
+func (self *Journal) compact() error {
+	if excess := len(self.order) - maxJournalSubmissions; excess > 0 {
+		order := self.order[:0]
+		for _, id := range self.order {
+			if excess > 0 && self.submissions[id].State.Final() {
+				delete(self.submissions, id)
+				excess--
+				continue
+			}
+			order = append(order, id)
+		}
+		self.order = order
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, id := range self.order {
+		if err := enc.Encode(self.submissions[id]); err != nil {
+			return errors.Wrap(err, "write journal record")
+		}
+	}
+	return errors.Wrap(fsutil.WriteAtomic(self.path, buf.Bytes(), 0600), "replace journal file")
+}
+
+// Record appends a new state for a submission.
+func (self *Journal) Record(s Submission) error {
+	if s.Time.IsZero() {
+		s.Time = time.Now()
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		return errors.Wrap(err, "marshal journal record")
+	}
+
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	if _, err := self.file.Write(append(b, '\n')); err != nil {
+		return errors.Wrap(err, "write journal record")
+	}
+	if err := self.file.Sync(); err != nil {
+		return errors.Wrap(err, "sync journal file")
+	}
+	self.apply(&s)
+
+	self.appended++
+	if self.appended >= self.compactEvery {
+		return self.rotate()
+	}
+	return nil
+}
+
+// rotate compacts the journal and appends to the compacted file.
+func (self *Journal) rotate() error {
+	if err := self.file.Close(); err != nil {
+		return errors.Wrap(err, "close journal file")
+	}
+	// The file is opened again even when the compaction fails so that the records are still appended.
+	cerr := self.compact()
+	f, err := os.OpenFile(self.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+	if err != nil {
+		return errors.Wrap(err, "open journal file")
+	}
+	self.file = f
+	self.appended = 0
+	return errors.Wrap(cerr, "compact journal")
+}
+
+// Submissions returns the latest state of all submissions, newest first.
+func (self *Journal) Submissions() []Submission {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	subs := make([]Submission, 0, len(self.order))
+	for i := len(self.order) - 1; i >= 0; i-- {
+		subs = append(subs, *self.submissions[self.order[i]])
+	}
+	return subs
+}
+
+// Pending returns all submissions of the contract function for the account that haven't reached a final state.
+// The submissions without a function are returned for all functions.
+func (self *Journal) Pending(account, function string) []Submission {
+	var pending []Submission
+	for _, s := range self.Submissions() {
+		if s.Account == account && (s.Function == "" || s.Function == function) && !s.State.Final() {
+			pending = append(pending, s)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Time.Before(pending[j].Time) })
+	return pending
+}
+
+// LastConfirmed returns the newest confirmed submission of the contract function for the account.
+func (self *Journal) LastConfirmed(account, function string) (Submission, bool) {
+	for _, s := range self.Submissions() {
+		if s.Account == account && s.Function == function && s.State == StateConfirmed {
+			return s, true
+		}
+	}
+	return Submission{}, false
+}
+
+func (self *Journal) Close() error {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	return self.file.Close()
+}
+
+// ServeHTTP returns the latest state of all submissions.
+// The results can be filtered by the account and state query params.
+func (self *Journal) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	account := r.URL.Query().Get("account")
+	state := r.URL.Query().Get("state")
+
+	subs := []Submission{}
+	for _, s := range self.Submissions() {
+		if account != "" && s.Account != account {
+			continue
+		}
+		if state != "" && string(s.State) != state {
+			continue
+		}
+		subs = append(subs, s)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(struct {
+		Status string       `json:"status"`
+		Data   []Submission `json:"data"`
+	}{
+		Status: "success",
+		Data:   subs,
+	}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package db
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// TestJournalReplay ensures that the latest state of each submission
+// and the details from earlier states survive a restart.
+func TestJournalReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "submissions.journal")
+
+	j, err := OpenJournal(path)
+	testutil.Ok(t, err)
+
+	testutil.Ok(t, j.Record(Submission{ID: "1", Account: "a", State: StateMined, Challenge: "aa"}))
+	testutil.Ok(t, j.Record(Submission{ID: "1", Account: "a", State: StateSimulated, Values: []string{"10"}}))
+	testutil.Ok(t, j.Record(Submission{ID: "1", Account: "a", State: StateBroadcast, TxHash: "0x01", Nonce: 5}))
+	testutil.Ok(t, j.Record(Submission{ID: "2", Account: "a", State: StateMined}))
+	testutil.Ok(t, j.Record(Submission{ID: "2", Account: "a", State: StateFailed, Err: "canceled"}))
+	testutil.Ok(t, j.Record(Submission{ID: "3", Account: "b", State: StateBroadcast}))
+	testutil.Ok(t, j.Close())
+
+	// Simulate a crash in the middle of a write.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0666)
+	testutil.Ok(t, err)
+	_, err = f.WriteString(`{"ID":"1","Sta`)
+	testutil.Ok(t, err)
+	testutil.Ok(t, f.Close())
+
+	j, err = OpenJournal(path)
+	testutil.Ok(t, err)
+	defer j.Close()
+
+	testutil.Equals(t, 3, len(j.Submissions()))
+
+	pending := j.Pending("a", "submitMiningSolution")
+	testutil.Equals(t, 1, len(pending))
+	testutil.Equals(t, StateBroadcast, pending[0].State)
+	testutil.Equals(t, "aa", pending[0].Challenge)
+	testutil.Equals(t, []string{"10"}, pending[0].Values)
+	testutil.Equals(t, "0x01", pending[0].TxHash)
+	testutil.Equals(t, uint64(5), pending[0].Nonce)
+}
+
+// TestJournalFunction ensures that the submissions of different contract functions
+// of the same account are kept apart.
+func TestJournalFunction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	j, err := OpenJournal(filepath.Join(dir, "submissions.journal"))
+	testutil.Ok(t, err)
+	defer j.Close()
+
+	testutil.Ok(t, j.Record(Submission{ID: "1", Account: "a", Function: "submitValue", State: StateSimulated, RequestIDs: []string{"1", "2"}}))
+	testutil.Ok(t, j.Record(Submission{ID: "1", Account: "a", State: StateConfirmed, GasUsed: 100000}))
+	testutil.Ok(t, j.Record(Submission{ID: "2", Account: "a", Function: "submitValue", State: StateSimulated}))
+	testutil.Ok(t, j.Record(Submission{ID: "2", Account: "a", State: StateDryRun}))
+	testutil.Ok(t, j.Record(Submission{ID: "3", Account: "a", Function: "submitMiningSolution", State: StateBroadcast}))
+	testutil.Ok(t, j.Record(Submission{ID: "4", Account: "a", Function: "submitMiningSolution", State: StateConfirmed, GasUsed: 500000}))
+
+	testutil.Equals(t, 0, len(j.Pending("a", "submitValue")))
+	testutil.Equals(t, 1, len(j.Pending("a", "submitMiningSolution")))
+
+	last, ok := j.LastConfirmed("a", "submitValue")
+	testutil.Assert(t, ok, "the confirmed submission not found")
+	testutil.Equals(t, "1", last.ID)
+	testutil.Equals(t, uint64(100000), last.GasUsed)
+	testutil.Equals(t, []string{"1", "2"}, last.RequestIDs)
+
+	_, ok = j.LastConfirmed("b", "submitValue")
+	testutil.Assert(t, !ok, "a confirmed submission of another account")
+}
+
+// TestJournalCompact ensures that a long running journal is compacted
+// without losing the latest states.
+func TestJournalCompact(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "submissions.journal")
+
+	j, err := OpenJournal(path)
+	testutil.Ok(t, err)
+	j.compactEvery = 4
+
+	testutil.Ok(t, j.Record(Submission{ID: "1", Account: "a", State: StateMined, Challenge: "aa"}))
+	testutil.Ok(t, j.Record(Submission{ID: "1", Account: "a", State: StateSimulated}))
+	testutil.Ok(t, j.Record(Submission{ID: "1", Account: "a", State: StateBroadcast, TxHash: "0x01"}))
+	testutil.Ok(t, j.Record(Submission{ID: "1", Account: "a", State: StateConfirmed}))
+	testutil.Ok(t, j.Record(Submission{ID: "2", Account: "a", State: StateMined}))
+
+	data, err := ioutil.ReadFile(path)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 2, strings.Count(string(data), "\n"))
+	testutil.Ok(t, j.Close())
+
+	j, err = OpenJournal(path)
+	testutil.Ok(t, err)
+	defer j.Close()
+	subs := j.Submissions()
+	testutil.Equals(t, 2, len(subs))
+	testutil.Equals(t, StateConfirmed, subs[1].State)
+	testutil.Equals(t, "aa", subs[1].Challenge)
+	testutil.Equals(t, "0x01", subs[1].TxHash)
+}
+
+// TestJournalCompactPending ensures that compacting drops only the oldest final submissions
+// and keeps the in-flight ones together with the details of their earlier states.
+func TestJournalCompactPending(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	j, err := OpenJournal(filepath.Join(dir, "submissions.journal"))
+	testutil.Ok(t, err)
+	defer j.Close()
+
+	j.apply(&Submission{ID: "pending", Account: "a", State: StateBroadcast, TxHash: "0x01", GasUsed: 100000})
+	j.apply(&Submission{ID: "pending", Account: "a", State: StateBroadcast, TxHash: "0x02"})
+	for i := 0; i < maxJournalSubmissions; i++ {
+		j.apply(&Submission{ID: strconv.Itoa(i), Account: "a", State: StateConfirmed})
+	}
+	testutil.Ok(t, j.compact())
+
+	subs := j.Submissions()
+	testutil.Equals(t, maxJournalSubmissions, len(subs))
+	pending := j.Pending("a", "submitMiningSolution")
+	testutil.Equals(t, 1, len(pending))
+	testutil.Equals(t, "0x02", pending[0].TxHash)
+	testutil.Equals(t, uint64(100000), pending[0].GasUsed)
+	_, ok := j.submissions["0"]
+	testutil.Assert(t, !ok, "the oldest final submission wasn't dropped")
+}
@@ -0,0 +1,507 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package db
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/gob"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/pkg/exemplar"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/tsdbutil"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/fsutil"
+)
+
+// snapshotFile is the file in the DB path with the samples of the lightweight storage.
+const snapshotFile = "snapshot.gob.gz"
+
+// LightweightConfig keeps only the recent samples needed by the aggregations in memory
+// instead of the TSDB, for small devices e.g. a Raspberry Pi where the TSDB wears out the SD card.
+// The samples are written to a snapshot file in the DB path which is loaded on the next start.
+// It doesn't support the downsampling, the remote write and the exemplars.
+type LightweightConfig struct {
+	Enabled bool
+	// Retention is how long the samples are kept and should cover the look back of the aggregations.
+	Retention format.Duration
+	// MaxSamples is the size of the ring buffer of each series
+	// and the oldest samples are dropped when it is full.
+	MaxSamples int
+	// SnapshotInterval is how often the snapshot is written, zero writes it only on exit.
+	SnapshotInterval format.Duration
+}
+
+// Memory is a storage that keeps the samples of each series in a ring buffer.
+type Memory struct {
+	logger log.Logger
+	cfg    LightweightConfig
+	path   string
+
+	mtx    sync.RWMutex
+	series map[string]*memSeries
+
+	// snapshotMtx makes sure that only one snapshot is written at a time.
+	snapshotMtx sync.Mutex
+	readOnly    bool
+	stop        chan struct{}
+	done        chan struct{}
+}
+
+// OpenMemory loads the snapshot in the dir when it exists
+// and starts writing the snapshots at the interval of the config.
+func OpenMemory(logger log.Logger, cfg LightweightConfig, dir string) (*Memory, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrapf(err, "creating the db dir:%v", dir)
+	}
+	self, err := newMemory(logger, cfg, dir)
+	if err != nil {
+		return nil, err
+	}
+	go self.run()
+	return self, nil
+}
+
+// ReadMemory loads the snapshot in the dir without ever writing it
+// so that it can run next to a running instance.
+func ReadMemory(logger log.Logger, cfg LightweightConfig, dir string) (*Memory, error) {
+	self, err := newMemory(logger, cfg, dir)
+	if err != nil {
+		return nil, err
+	}
+	self.readOnly = true
+	return self, nil
+}
+
+func newMemory(logger log.Logger, cfg LightweightConfig, dir string) (*Memory, error) {
+	if cfg.MaxSamples <= 0 {
+		return nil, errors.Errorf("lightweight max samples should be more than 0:%v", cfg.MaxSamples)
+	}
+	if cfg.Retention.Duration <= 0 {
+		return nil, errors.Errorf("invalid lightweight retention:%v", cfg.Retention)
+	}
+	self := &Memory{
+		logger: logger,
+		cfg:    cfg,
+		path:   filepath.Join(dir, snapshotFile),
+		series: make(map[string]*memSeries),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	if err := self.load(); err != nil {
+		return nil, err
+	}
+	return self, nil
+}
+
+func (self *Memory) run() {
+	defer close(self.done)
+	if self.cfg.SnapshotInterval.Duration <= 0 {
+		<-self.stop
+		return
+	}
+	ticker := time.NewTicker(self.cfg.SnapshotInterval.Duration)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := self.Snapshot(); err != nil {
+				level.Error(self.logger).Log("msg", "writing the db snapshot", "err", err)
+			}
+		case <-self.stop:
+			return
+		}
+	}
+}
+
+// Close stops the snapshots and writes the last one.
+func (self *Memory) Close() error {
+	if self.readOnly {
+		return nil
+	}
+	close(self.stop)
+	<-self.done
+	return self.Snapshot()
+}
+
+// snapshotSeries is a series in the snapshot file.
+type snapshotSeries struct {
+	Labels labels.Labels
+	T      []int64
+	V      []float64
+}
+
+// Snapshot drops the samples older than the retention and writes the rest to the snapshot file.
+// It is written atomically so that a crash never leaves a partial snapshot.
+func (self *Memory) Snapshot() error {
+	self.snapshotMtx.Lock()
+	defer self.snapshotMtx.Unlock()
+
+	mint := time.Now().Add(-self.cfg.Retention.Duration).UnixNano() / int64(time.Millisecond)
+	var snapshot []snapshotSeries
+	self.mtx.Lock()
+	for key, s := range self.series {
+		s.truncate(mint)
+		if s.n == 0 {
+			delete(self.series, key)
+			continue
+		}
+		ss := snapshotSeries{Labels: s.labels}
+		s.each(math.MinInt64, math.MaxInt64, func(t int64, v float64) {
+			ss.T = append(ss.T, t)
+			ss.V = append(ss.V, v)
+		})
+		snapshot = append(snapshot, ss)
+	}
+	self.mtx.Unlock()
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if err := gob.NewEncoder(w).Encode(snapshot); err != nil {
+		return errors.Wrap(err, "encoding the snapshot")
+	}
+	if err := w.Close(); err != nil {
+		return errors.Wrap(err, "compressing the snapshot")
+	}
+	return errors.Wrap(fsutil.WriteAtomic(self.path, buf.Bytes(), 0600), "writing the snapshot file")
+}
+
+func (self *Memory) load() error {
+	f, err := os.Open(self.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "opening the snapshot file")
+	}
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		return errors.Wrap(err, "decompressing the snapshot")
+	}
+	var snapshot []snapshotSeries
+	if err := gob.NewDecoder(r).Decode(&snapshot); err != nil {
+		return errors.Wrap(err, "decoding the snapshot")
+	}
+	var samples int
+	for _, ss := range snapshot {
+		s := self.getOrCreate(ss.Labels)
+		for i := range ss.T {
+			s.append(ss.T[i], ss.V[i], self.cfg.Retention.Milliseconds())
+		}
+		samples += len(ss.T)
+	}
+	level.Info(self.logger).Log("msg", "loaded the db snapshot", "series", len(snapshot), "samples", samples)
+	return nil
+}
+
+func (self *Memory) getOrCreate(lbls labels.Labels) *memSeries {
+	key := lbls.String()
+	s, ok := self.series[key]
+	if !ok {
+		s = &memSeries{labels: lbls, max: self.cfg.MaxSamples}
+		self.series[key] = s
+	}
+	return s
+}
+
+// StartTime returns the time of the oldest sample.
+func (self *Memory) StartTime() (int64, error) {
+	self.mtx.RLock()
+	defer self.mtx.RUnlock()
+	var start int64 = math.MaxInt64
+	for _, s := range self.series {
+		if s.n > 0 && s.at(0).t < start {
+			start = s.at(0).t
+		}
+	}
+	return start, nil
+}
+
+func (self *Memory) Appender(_ context.Context) storage.Appender {
+	return &memAppender{memory: self}
+}
+
+func (self *Memory) Querier(_ context.Context, mint, maxt int64) (storage.Querier, error) {
+	return &memQuerier{memory: self, mint: mint, maxt: maxt}, nil
+}
+
+func (self *Memory) ChunkQuerier(_ context.Context, mint, maxt int64) (storage.ChunkQuerier, error) {
+	return &memChunkQuerier{memQuerier{memory: self, mint: mint, maxt: maxt}}, nil
+}
+
+// ExemplarQuerier returns no exemplars as they are not kept.
+func (self *Memory) ExemplarQuerier(_ context.Context) (storage.ExemplarQuerier, error) {
+	return memExemplarQuerier{}, nil
+}
+
+type memSample struct {
+	t int64
+	v float64
+}
+
+func (self memSample) T() int64   { return self.t }
+func (self memSample) V() float64 { return self.v }
+
+// memSeries is a ring buffer of the samples of a series in time order
+// which grows up to the max samples.
+type memSeries struct {
+	labels labels.Labels
+	buf    []memSample
+	max    int
+	start  int
+	n      int
+}
+
+func (self *memSeries) at(i int) memSample {
+	return self.buf[(self.start+i)%len(self.buf)]
+}
+
+func (self *memSeries) appendable(t int64, v float64) error {
+	if self.n == 0 {
+		return nil
+	}
+	last := self.at(self.n - 1)
+	if t < last.t {
+		return storage.ErrOutOfOrderSample
+	}
+	if t == last.t && v != last.v {
+		return storage.ErrDuplicateSampleForTimestamp
+	}
+	return nil
+}
+
+// append adds the sample and drops the oldest samples
+// when the buffer is full or they are older than the retention.
+func (self *memSeries) append(t int64, v float64, retention int64) {
+	if self.n > 0 && self.at(self.n-1).t == t {
+		return
+	}
+	if self.n == len(self.buf) && len(self.buf) < self.max {
+		size := 2*len(self.buf) + 1
+		if size > self.max {
+			size = self.max
+		}
+		buf := make([]memSample, size)
+		for i := 0; i < self.n; i++ {
+			buf[i] = self.at(i)
+		}
+		self.buf, self.start = buf, 0
+	}
+	if self.n == len(self.buf) {
+		self.start = (self.start + 1) % len(self.buf)
+		self.n--
+	}
+	self.buf[(self.start+self.n)%len(self.buf)] = memSample{t: t, v: v}
+	self.n++
+	self.truncate(t - retention)
+}
+
+// truncate drops the samples older than mint.
+func (self *memSeries) truncate(mint int64) {
+	for self.n > 0 && self.at(0).t < mint {
+		self.start = (self.start + 1) % len(self.buf)
+		self.n--
+	}
+}
+
+func (self *memSeries) each(mint, maxt int64, f func(t int64, v float64)) {
+	for i := 0; i < self.n; i++ {
+		s := self.at(i)
+		if s.t >= mint && s.t <= maxt {
+			f(s.t, s.v)
+		}
+	}
+}
+
+// memAppender keeps the samples until the commit.
+type memAppender struct {
+	memory  *Memory
+	samples []memAppend
+}
+
+type memAppend struct {
+	labels labels.Labels
+	t      int64
+	v      float64
+}
+
+// Append returns the out of order samples as errors like the TSDB
+// and the samples are added to the series on commit.
+func (self *memAppender) Append(_ uint64, lbls labels.Labels, t int64, v float64) (uint64, error) {
+	for i := len(self.samples) - 1; i >= 0; i-- {
+		if labels.Equal(self.samples[i].labels, lbls) {
+			if t < self.samples[i].t {
+				return 0, storage.ErrOutOfOrderSample
+			}
+			break
+		}
+	}
+	self.memory.mtx.RLock()
+	s, ok := self.memory.series[lbls.String()]
+	var err error
+	if ok {
+		err = s.appendable(t, v)
+	}
+	self.memory.mtx.RUnlock()
+	if err != nil {
+		return 0, err
+	}
+	self.samples = append(self.samples, memAppend{labels: lbls.Copy(), t: t, v: v})
+	return 0, nil
+}
+
+// AppendExemplar drops the exemplars.
+func (self *memAppender) AppendExemplar(ref uint64, _ labels.Labels, _ exemplar.Exemplar) (uint64, error) {
+	return ref, nil
+}
+
+func (self *memAppender) Commit() error {
+	self.memory.mtx.Lock()
+	defer self.memory.mtx.Unlock()
+	for _, s := range self.samples {
+		series := self.memory.getOrCreate(s.labels)
+		// Another appender could have added a later sample since the append.
+		if series.appendable(s.t, s.v) != nil {
+			continue
+		}
+		series.append(s.t, s.v, self.memory.cfg.Retention.Milliseconds())
+	}
+	self.samples = nil
+	return nil
+}
+
+func (self *memAppender) Rollback() error {
+	self.samples = nil
+	return nil
+}
+
+type memQuerier struct {
+	memory     *Memory
+	mint, maxt int64
+}
+
+// series returns the series that match the matchers
+// with their samples in the time range of the querier sorted by the labels.
+func (self *memQuerier) series(hints *storage.SelectHints, matchers ...*labels.Matcher) []storage.Series {
+	mint, maxt := self.mint, self.maxt
+	if hints != nil {
+		if hints.Start > mint {
+			mint = hints.Start
+		}
+		if hints.End < maxt {
+			maxt = hints.End
+		}
+	}
+
+	self.memory.mtx.RLock()
+	defer self.memory.mtx.RUnlock()
+	var result []storage.Series
+	for _, s := range self.memory.series {
+		if !matches(s.labels, matchers) {
+			continue
+		}
+		var samples []tsdbutil.Sample
+		s.each(mint, maxt, func(t int64, v float64) {
+			samples = append(samples, memSample{t: t, v: v})
+		})
+		if len(samples) == 0 {
+			continue
+		}
+		result = append(result, storage.NewListSeries(s.labels, samples))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return labels.Compare(result[i].Labels(), result[j].Labels()) < 0
+	})
+	return result
+}
+
+// Select returns the series sorted even when not required
+// as there are only a few series.
+func (self *memQuerier) Select(_ bool, hints *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	return &memSeriesSet{series: self.series(hints, matchers...), i: -1}
+}
+
+func (self *memQuerier) LabelValues(name string, matchers ...*labels.Matcher) ([]string, storage.Warnings, error) {
+	self.memory.mtx.RLock()
+	defer self.memory.mtx.RUnlock()
+	values := make(map[string]struct{})
+	for _, s := range self.memory.series {
+		if v := s.labels.Get(name); v != "" && matches(s.labels, matchers) {
+			values[v] = struct{}{}
+		}
+	}
+	return sortedKeys(values), nil, nil
+}
+
+func (self *memQuerier) LabelNames() ([]string, storage.Warnings, error) {
+	self.memory.mtx.RLock()
+	defer self.memory.mtx.RUnlock()
+	names := make(map[string]struct{})
+	for _, s := range self.memory.series {
+		for _, l := range s.labels {
+			names[l.Name] = struct{}{}
+		}
+	}
+	return sortedKeys(names), nil, nil
+}
+
+func (self *memQuerier) Close() error { return nil }
+
+type memChunkQuerier struct {
+	memQuerier
+}
+
+func (self *memChunkQuerier) Select(sortSeries bool, hints *storage.SelectHints, matchers ...*labels.Matcher) storage.ChunkSeriesSet {
+	return storage.NewSeriesSetToChunkSet(self.memQuerier.Select(sortSeries, hints, matchers...))
+}
+
+type memSeriesSet struct {
+	series []storage.Series
+	i      int
+}
+
+func (self *memSeriesSet) Next() bool {
+	self.i++
+	return self.i < len(self.series)
+}
+
+func (self *memSeriesSet) At() storage.Series         { return self.series[self.i] }
+func (self *memSeriesSet) Err() error                 { return nil }
+func (self *memSeriesSet) Warnings() storage.Warnings { return nil }
+
+type memExemplarQuerier struct{}
+
+func (memExemplarQuerier) Select(_, _ int64, _ ...[]*labels.Matcher) ([]exemplar.QueryResult, error) {
+	return nil, nil
+}
+
+func matches(lbls labels.Labels, matchers []*labels.Matcher) bool {
+	for _, m := range matchers {
+		if !m.Matches(lbls.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,103 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package db
+
+import (
+	"context"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// TestLightweight ensures that the lightweight storage keeps only the latest samples of each series
+// and that they survive a restart through the snapshot.
+func TestLightweight(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lightweight")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	cfg := Config{
+		LogLevel:       "info",
+		Path:           dir,
+		ExternalLabels: map[string]string{"instance": "pi"},
+		Lightweight: LightweightConfig{
+			Enabled:    true,
+			Retention:  format.Duration{Duration: time.Hour},
+			MaxSamples: 3,
+		},
+	}
+	tsDB, err := Open(logging.NewLogger(), cfg, tsdb.DefaultOptions())
+	testutil.Ok(t, err)
+
+	now := timestamp.FromTime(time.Now())
+	eth := labels.FromStrings("__name__", "index", "symbol", "ETH/USD")
+	btc := labels.FromStrings("__name__", "index", "symbol", "BTC/USD")
+	for i := int64(0); i < 5; i++ {
+		testutil.Ok(t, tsDB.Append(context.Background(), Sample{Labels: eth, T: now + i*1000, V: float64(i)}))
+	}
+	// Older than the retention.
+	testutil.Ok(t, tsDB.Append(context.Background(), Sample{Labels: btc, T: now - 2*time.Hour.Milliseconds(), V: 1}))
+	testutil.Ok(t, tsDB.Append(context.Background(), Sample{Labels: btc, T: now, V: 2}))
+
+	appender := tsDB.Appender(context.Background())
+	_, err = appender.Append(0, eth, now, 10)
+	testutil.Equals(t, storage.ErrOutOfOrderSample, err)
+	testutil.Ok(t, appender.Rollback())
+
+	expected := map[string][]float64{
+		`{__name__="index", instance="pi", symbol="BTC/USD"}`: {2},
+		`{__name__="index", instance="pi", symbol="ETH/USD"}`: {2, 3, 4},
+	}
+	testutil.Equals(t, expected, selectAll(t, tsDB))
+	testutil.Ok(t, tsDB.Close())
+
+	// Reopened from the snapshot.
+	tsDB, err = Open(logging.NewLogger(), cfg, tsdb.DefaultOptions())
+	testutil.Ok(t, err)
+	testutil.Equals(t, expected, selectAll(t, tsDB))
+	testutil.Ok(t, tsDB.Append(context.Background(), Sample{Labels: eth, T: now + 5000, V: 5}))
+
+	// The read only DB sees the last snapshot and doesn't write it.
+	readOnly, err := OpenReadOnly(logging.NewLogger(), cfg)
+	testutil.Ok(t, err)
+	testutil.Equals(t, expected, selectAll(t, readOnly))
+	testutil.Ok(t, readOnly.Close())
+	testutil.Ok(t, tsDB.Close())
+	_, err = os.Stat(filepath.Join(dir, snapshotFile+".tmp"))
+	testutil.Assert(t, os.IsNotExist(err), "temp snapshot file left behind")
+
+	cfg.Downsample.Enabled = true
+	_, err = Open(logging.NewLogger(), cfg, tsdb.DefaultOptions())
+	testutil.NotOk(t, err)
+}
+
+// selectAll returns the values of all series by their labels.
+func selectAll(t *testing.T, q storage.Queryable) map[string][]float64 {
+	querier, err := q.Querier(context.Background(), math.MinInt64, math.MaxInt64)
+	testutil.Ok(t, err)
+	defer querier.Close()
+	set := querier.Select(true, nil, labels.MustNewMatcher(labels.MatchRegexp, "symbol", ".+"))
+	result := make(map[string][]float64)
+	for set.Next() {
+		it := set.At().Iterator()
+		for it.Next() {
+			_, v := it.At()
+			result[set.At().Labels().String()] = append(result[set.At().Labels().String()], v)
+		}
+		testutil.Ok(t, it.Err())
+	}
+	testutil.Ok(t, set.Err())
+	return result
+}
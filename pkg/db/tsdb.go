@@ -0,0 +1,253 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package db
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	config_util "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/config"
+	"github.com/prometheus/prometheus/pkg/exemplar"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/storage/remote"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/tellor-io/telliot/pkg/logging"
+)
+
+// remoteFlushDeadline is how long to try sending the pending samples when closing the DB.
+const remoteFlushDeadline = time.Minute
+
+// DB is the local TSDB which adds the external labels to all appended samples
+// and sends them to the remote write storages.
+// In the lightweight mode the samples are kept in memory instead and the TSDB is nil.
+type DB struct {
+	*tsdb.DB
+	memory      *Memory
+	logger      log.Logger
+	labels      labels.Labels
+	remoteWrite *remote.WriteStorage
+	batcher     *batcher
+}
+
+// Open opens the local TSDB at the path of the config.
+// The rollups and the imported data are written as separate blocks so the DB allows overlapping blocks.
+// With downsampling the retention of the options is replaced with the retention of the rollups.
+// The remote write reads the samples from the WAL of the DB
+// so the samples already have the external labels.
+func Open(logger log.Logger, cfg Config, opts *tsdb.Options) (*DB, error) {
+	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	logger = log.With(logger, "component", ComponentName)
+
+	lbls, err := externalLabels(cfg.ExternalLabels)
+	if err != nil {
+		return nil, err
+	}
+	remoteCfgs, err := remoteWriteConfigs(cfg.RemoteWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Lightweight.Enabled {
+		return openLightweight(logger, cfg, lbls, remoteCfgs)
+	}
+
+	opts.AllowOverlappingBlocks = true
+	if cfg.Downsample.Enabled {
+		opts.RetentionDuration = cfg.Downsample.Retention.Milliseconds()
+	}
+	tsDB, err := tsdb.Open(cfg.Path, nil, nil, opts)
+	if err != nil {
+		return nil, err
+	}
+	self := &DB{
+		DB:     tsDB,
+		logger: logger,
+		labels: lbls,
+	}
+	if cfg.Batch.Interval.Duration > 0 {
+		self.batcher, err = newBatcher(logger, cfg.Batch, self)
+		if err != nil {
+			tsDB.Close()
+			return nil, err
+		}
+	}
+	if len(remoteCfgs) > 0 {
+		self.remoteWrite = remote.NewWriteStorage(logger, prometheus.DefaultRegisterer, cfg.Path, remoteFlushDeadline, nil)
+		if err := self.remoteWrite.ApplyConfig(&config.Config{RemoteWriteConfigs: remoteCfgs}); err != nil {
+			self.Close()
+			return nil, errors.Wrap(err, "applying the remote write config")
+		}
+		for _, c := range cfg.RemoteWrite {
+			level.Info(logger).Log("msg", "sending the samples to remote write", "url", c.URL)
+		}
+	}
+	return self, nil
+}
+
+// ReadOnly is a local DB opened without writing to it.
+type ReadOnly interface {
+	storage.SampleAndChunkQueryable
+	Close() error
+}
+
+// OpenReadOnly opens the local DB without writing to it so that it can run next to a running instance.
+// In the lightweight mode it reads the samples of the last snapshot.
+func OpenReadOnly(logger log.Logger, cfg Config) (ReadOnly, error) {
+	if cfg.Lightweight.Enabled {
+		return ReadMemory(logger, cfg.Lightweight, cfg.Path)
+	}
+	return tsdb.OpenDBReadOnly(cfg.Path, nil)
+}
+
+func openLightweight(logger log.Logger, cfg Config, lbls labels.Labels, remoteCfgs []*config.RemoteWriteConfig) (*DB, error) {
+	if len(remoteCfgs) > 0 {
+		return nil, errors.New("the remote write reads the TSDB WAL so it doesn't work in the lightweight mode")
+	}
+	if cfg.Downsample.Enabled {
+		return nil, errors.New("the downsampling doesn't work in the lightweight mode")
+	}
+	memory, err := OpenMemory(logger, cfg.Lightweight, cfg.Path)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening the lightweight storage")
+	}
+	self := &DB{
+		memory: memory,
+		logger: logger,
+		labels: lbls,
+	}
+	if cfg.Batch.Interval.Duration > 0 {
+		self.batcher, err = newBatcher(logger, cfg.Batch, self)
+		if err != nil {
+			memory.Close()
+			return nil, err
+		}
+	}
+	return self, nil
+}
+
+func externalLabels(cfg map[string]string) (labels.Labels, error) {
+	lbls := make(labels.Labels, 0, len(cfg))
+	for name, value := range cfg {
+		if !model.LabelName(name).IsValid() || strings.HasPrefix(name, "__") {
+			return nil, errors.Errorf("invalid external label name:%v", name)
+		}
+		if value == "" {
+			return nil, errors.Errorf("empty value of external label:%v", name)
+		}
+		lbls = append(lbls, labels.Label{Name: name, Value: value})
+	}
+	sort.Sort(lbls)
+	return lbls, nil
+}
+
+func remoteWriteConfigs(cfgs []RemoteWriteConfig) ([]*config.RemoteWriteConfig, error) {
+	var remoteCfgs []*config.RemoteWriteConfig
+	for _, c := range cfgs {
+		u, err := url.Parse(c.URL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, errors.Errorf("invalid remote write url:%v", c.URL)
+		}
+		remoteCfg := config.DefaultRemoteWriteConfig
+		remoteCfg.URL = &config_util.URL{URL: u}
+		remoteCfg.Headers = c.Headers
+		if c.Timeout.Duration > 0 {
+			remoteCfg.RemoteTimeout = model.Duration(c.Timeout.Duration)
+		}
+		// There is no scrape manager to get the metadata from.
+		remoteCfg.MetadataConfig.Send = false
+		remoteCfgs = append(remoteCfgs, &remoteCfg)
+	}
+	return remoteCfgs, nil
+}
+
+func (self *DB) Appender(ctx context.Context) storage.Appender {
+	if self.memory != nil {
+		return &appender{Appender: self.memory.Appender(ctx), labels: self.labels}
+	}
+	return &appender{Appender: self.DB.Appender(ctx), labels: self.labels}
+}
+
+func (self *DB) Querier(ctx context.Context, mint, maxt int64) (storage.Querier, error) {
+	if self.memory != nil {
+		return self.memory.Querier(ctx, mint, maxt)
+	}
+	return self.DB.Querier(ctx, mint, maxt)
+}
+
+func (self *DB) ChunkQuerier(ctx context.Context, mint, maxt int64) (storage.ChunkQuerier, error) {
+	if self.memory != nil {
+		return self.memory.ChunkQuerier(ctx, mint, maxt)
+	}
+	return self.DB.ChunkQuerier(ctx, mint, maxt)
+}
+
+func (self *DB) ExemplarQuerier(ctx context.Context) (storage.ExemplarQuerier, error) {
+	if self.memory != nil {
+		return self.memory.ExemplarQuerier(ctx)
+	}
+	return self.DB.ExemplarQuerier(ctx)
+}
+
+func (self *DB) StartTime() (int64, error) {
+	if self.memory != nil {
+		return self.memory.StartTime()
+	}
+	return self.DB.StartTime()
+}
+
+// Close commits the queued samples, sends the pending samples to the remote write and closes the DB.
+func (self *DB) Close() error {
+	if self.batcher != nil {
+		self.batcher.close()
+	}
+	if self.remoteWrite != nil {
+		if err := self.remoteWrite.Close(); err != nil {
+			level.Error(self.logger).Log("msg", "closing the remote write", "err", err)
+		}
+	}
+	if self.memory != nil {
+		return self.memory.Close()
+	}
+	return self.DB.Close()
+}
+
+// appender adds the external labels which the series doesn't have already.
+type appender struct {
+	storage.Appender
+	labels labels.Labels
+}
+
+func (self *appender) with(lbls labels.Labels) labels.Labels {
+	if len(self.labels) == 0 {
+		return lbls
+	}
+	b := labels.NewBuilder(lbls)
+	for _, l := range self.labels {
+		if lbls.Get(l.Name) == "" {
+			b.Set(l.Name, l.Value)
+		}
+	}
+	return b.Labels()
+}
+
+func (self *appender) Append(ref uint64, lbls labels.Labels, t int64, v float64) (uint64, error) {
+	return self.Appender.Append(ref, self.with(lbls), t, v)
+}
+
+func (self *appender) AppendExemplar(ref uint64, lbls labels.Labels, e exemplar.Exemplar) (uint64, error) {
+	return self.Appender.AppendExemplar(ref, self.with(lbls), e)
+}
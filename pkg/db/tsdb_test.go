@@ -0,0 +1,57 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package db
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// TestExternalLabels ensures that the external labels are added
+// without overriding the labels of the series.
+func TestExternalLabels(t *testing.T) {
+	dir, err := ioutil.TempDir("", "labels")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	cfg := Config{
+		LogLevel:       "info",
+		Path:           dir,
+		ExternalLabels: map[string]string{"instance": "eu-1", "network": "mainnet"},
+	}
+	tsDB, err := Open(logging.NewLogger(), cfg, tsdb.DefaultOptions())
+	testutil.Ok(t, err)
+	defer tsDB.Close()
+
+	appender := tsDB.Appender(context.Background())
+	_, err = appender.Append(0, labels.FromStrings("__name__", "index", "symbol", "ETH/USD"), 1000, 10)
+	testutil.Ok(t, err)
+	_, err = appender.Append(0, labels.FromStrings("__name__", "index", "symbol", "BTC/USD", "network", "rinkeby"), 1000, 20)
+	testutil.Ok(t, err)
+	testutil.Ok(t, appender.Commit())
+
+	q, err := tsDB.Querier(context.Background(), 0, 2000)
+	testutil.Ok(t, err)
+	defer q.Close()
+	set := q.Select(true, nil, labels.MustNewMatcher(labels.MatchEqual, "__name__", "index"))
+	var series []labels.Labels
+	for set.Next() {
+		series = append(series, set.At().Labels())
+	}
+	testutil.Ok(t, set.Err())
+	testutil.Equals(t, []labels.Labels{
+		labels.FromStrings("__name__", "index", "instance", "eu-1", "network", "mainnet", "symbol", "ETH/USD"),
+		labels.FromStrings("__name__", "index", "instance", "eu-1", "network", "rinkeby", "symbol", "BTC/USD"),
+	}, series)
+
+	_, err = Open(logging.NewLogger(), Config{LogLevel: "info", Path: dir, ExternalLabels: map[string]string{"__name__": "x"}}, tsdb.DefaultOptions())
+	testutil.NotOk(t, err)
+}
@@ -0,0 +1,120 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+// Package errclass is the taxonomy of the errors shared by all components
+// so that the retries, metrics and alerts can branch on the class of an error
+// instead of matching the messages of the wrapped errors.
+package errclass
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+type Class string
+
+const (
+	Unknown Class = "unknown"
+	// Retryable are the transient failures of a node or a data source
+	// e.g. timeouts, dropped connections and rate limits, which can succeed on a retry.
+	Retryable Class = "retryable"
+	// Config are the permanent failures caused by the config e.g. a wrong URL, key or request ID,
+	// which fail again until the config is changed.
+	Config Class = "config"
+	// DataQuality are the values that are missing, stale, out of bounds or disagree with the other sources.
+	DataQuality Class = "data_quality"
+	// Revert are the calls and transactions reverted by a contract.
+	Revert Class = "revert"
+	// Nonce are the transactions rejected by the node because their nonce is already used.
+	Nonce Class = "nonce"
+	// Underpriced are the replacement transactions rejected by the node because their gas price is too low.
+	Underpriced Class = "underpriced"
+)
+
+var errorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "telliot",
+	Name:      "errors_total",
+	Help:      "The total number of errors by component and class",
+}, []string{"component", "class"})
+
+// Error is an error with a class.
+type Error struct {
+	class Class
+	err   error
+}
+
+func (self *Error) Error() string { return self.err.Error() }
+
+func (self *Error) Unwrap() error { return self.err }
+
+// Cause keeps errors.Cause working through the class.
+func (self *Error) Cause() error { return self.err }
+
+func (self *Error) Class() Class { return self.class }
+
+// New returns an error with a class e.g. for the sentinel errors of a package.
+func New(class Class, msg string) error {
+	return &Error{class: class, err: errors.New(msg)}
+}
+
+// Wrap sets the class of an error without changing its message.
+// It returns nil for a nil error and the error unchanged for the unknown class.
+func Wrap(class Class, err error) error {
+	if err == nil || class == Unknown {
+		return err
+	}
+	return &Error{class: class, err: err}
+}
+
+// Of returns the class of an error, the outermost one when it was wrapped with several classes.
+// The errors without a class are classified by their type and the messages of the ethereum nodes.
+func Of(err error) Class {
+	if err == nil {
+		return Unknown
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e.class
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return Retryable
+	}
+	// The standard code of a reverted call, older nodes only have it in the message.
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == 3 {
+		return Revert
+	}
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "execution reverted"):
+		return Revert
+	case strings.Contains(msg, "nonce too low"):
+		return Nonce
+	case strings.Contains(msg, "replacement transaction underpriced"):
+		return Underpriced
+	case strings.Contains(msg, "connection refused"), strings.Contains(msg, "connection reset"), strings.Contains(msg, "eof"):
+		return Retryable
+	}
+	return Unknown
+}
+
+// Is returns true when the error has the class.
+func Is(err error, class Class) bool {
+	return err != nil && Of(err) == class
+}
+
+// Observe counts the error of a component by its class and returns the class.
+func Observe(component string, err error) Class {
+	class := Of(err)
+	if err != nil {
+		errorsTotal.With(prometheus.Labels{"component": component, "class": string(class)}).Inc()
+	}
+	return class
+}
@@ -0,0 +1,57 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package errclass
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+type rpcError struct {
+	code int
+}
+
+func (self rpcError) Error() string  { return "rpc error" }
+func (self rpcError) ErrorCode() int { return self.code }
+
+func TestOf(t *testing.T) {
+	errStale := New(DataQuality, "stale")
+	wrapped := errors.Wrap(errStale, "getting value")
+
+	testutil.Equals(t, DataQuality, Of(wrapped))
+	testutil.Assert(t, errors.Is(wrapped, errStale), "the sentinel error isn't found through the wrapping")
+	testutil.Equals(t, "getting value: stale", wrapped.Error())
+
+	// The outermost class wins and the inner errors are still found.
+	reclassified := Wrap(Config, errors.Wrap(context.DeadlineExceeded, "call"))
+	testutil.Equals(t, Config, Of(reclassified))
+	testutil.Assert(t, errors.Is(reclassified, context.DeadlineExceeded), "the inner error isn't found through the class")
+	testutil.Equals(t, context.DeadlineExceeded, errors.Cause(reclassified))
+
+	testutil.Assert(t, Wrap(Retryable, nil) == nil, "a wrapped nil error isn't nil")
+	plain := errors.New("plain")
+	testutil.Equals(t, plain, Wrap(Unknown, plain))
+
+	cases := []struct {
+		err error
+		exp Class
+	}{
+		{err: nil, exp: Unknown},
+		{err: errors.New("something else"), exp: Unknown},
+		{err: errors.Wrap(context.DeadlineExceeded, "call"), exp: Retryable},
+		{err: errors.Wrap(rpcError{code: 3}, "call"), exp: Revert},
+		{err: rpcError{code: -32000}, exp: Unknown},
+		{err: errors.New("execution reverted: not staked"), exp: Revert},
+		{err: errors.New("Nonce too low"), exp: Nonce},
+		{err: errors.New("replacement transaction underpriced"), exp: Underpriced},
+		{err: errors.New("dial tcp: connection refused"), exp: Retryable},
+	}
+	for _, c := range cases {
+		testutil.Equals(t, c.exp, Of(c.err), c.err)
+		testutil.Equals(t, c.err != nil && c.exp == Retryable, Is(c.err, Retryable), c.err)
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package ethereum
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/format"
+)
+
+// The sources of the new block headers.
+const (
+	// BlocksAuto subscribes and polls when the node doesn't support subscriptions e.g. on HTTP.
+	BlocksAuto      = "auto"
+	BlocksSubscribe = "subscribe"
+	BlocksPoll      = "poll"
+)
+
+// maxPollGap is the max number of blocks between two polls which are all sent,
+// only the latest is sent after a longer gap e.g. when the node was syncing.
+const maxPollGap = 128
+
+type BlocksConfig struct {
+	// Source is subscribe for eth_subscribe(newHeads), poll or auto.
+	Source string
+	// PollInterval is how often the latest block is requested when polling.
+	PollInterval format.Duration
+}
+
+func (self BlocksConfig) validate() error {
+	switch self.Source {
+	case BlocksAuto, BlocksSubscribe:
+		return nil
+	case BlocksPoll:
+		if self.PollInterval.Duration <= 0 {
+			return errors.New("the block poll interval should be more than 0")
+		}
+		return nil
+	default:
+		return errors.Errorf("invalid block source:%v", self.Source)
+	}
+}
+
+type headerReader interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// PollNewHeads sends the new block headers like eth_subscribe(newHeads) by polling the latest block.
+// The blocks skipped between two polls are sent in order,
+// the head of a reorg is sent once it is the latest block and
+// the head at the time of subscribing is not sent.
+// A failed request ends the subscription with its error
+// so that the subscribers resubscribe like after a dropped connection.
+func PollNewHeads(client headerReader, interval time.Duration, ch chan<- *types.Header) ethereum.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			<-quit
+			cancel()
+		}()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		var last *types.Header
+		for {
+			head, err := client.HeaderByNumber(ctx, nil)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return errors.Wrap(err, "getting the latest block header")
+			}
+			headers, err := newHeaders(ctx, client, last, head)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return err
+			}
+			for _, h := range headers {
+				select {
+				case ch <- h:
+				case <-quit:
+					return nil
+				}
+			}
+			last = head
+
+			select {
+			case <-quit:
+				return nil
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// newHeaders returns the headers after the last sent one up to the head.
+func newHeaders(ctx context.Context, client headerReader, last, head *types.Header) ([]*types.Header, error) {
+	if last == nil || head.Hash() == last.Hash() {
+		return nil, nil
+	}
+	// A reorg to a chain which isn't longer.
+	if head.Number.Cmp(last.Number) <= 0 {
+		return []*types.Header{head}, nil
+	}
+	gap := new(big.Int).Sub(head.Number, last.Number).Uint64()
+	if gap > maxPollGap {
+		return []*types.Header{head}, nil
+	}
+	headers := make([]*types.Header, 0, gap)
+	for n := last.Number.Uint64() + 1; n < head.Number.Uint64(); n++ {
+		h, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(n))
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting the header of block:%v", n)
+		}
+		headers = append(headers, h)
+	}
+	return append(headers, head), nil
+}
@@ -0,0 +1,80 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package ethereum
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// fakeChain returns the next scripted head on every request of the latest block.
+type fakeChain struct {
+	mtx    sync.Mutex
+	blocks map[uint64]*types.Header
+	heads  []*types.Header
+}
+
+func header(number uint64, extra string) *types.Header {
+	return &types.Header{Number: new(big.Int).SetUint64(number), Extra: []byte(extra)}
+}
+
+func (self *fakeChain) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	if number != nil {
+		return self.blocks[number.Uint64()], nil
+	}
+	if len(self.heads) == 0 {
+		return nil, errors.New("node down")
+	}
+	head := self.heads[0]
+	self.heads = self.heads[1:]
+	self.blocks[head.Number.Uint64()] = head
+	return head, nil
+}
+
+func TestPollNewHeads(t *testing.T) {
+	chain := &fakeChain{blocks: make(map[uint64]*types.Header)}
+	for n := uint64(1); n <= 4; n++ {
+		chain.blocks[n] = header(n, "")
+	}
+	chain.heads = []*types.Header{
+		header(1, ""),
+		header(1, ""),
+		header(4, ""),
+		header(4, "reorg"),
+	}
+
+	ch := make(chan *types.Header)
+	sub := PollNewHeads(chain, time.Millisecond, ch)
+	defer sub.Unsubscribe()
+
+	var received []*types.Header
+	for {
+		select {
+		case h := <-ch:
+			received = append(received, h)
+			continue
+		case err := <-sub.Err():
+			testutil.NotOk(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the subscription didn't end")
+		}
+		break
+	}
+
+	// The first head isn't sent, the skipped blocks are sent in order and then the reorged head.
+	exp := []*types.Header{header(2, ""), header(3, ""), header(4, ""), header(4, "reorg")}
+	testutil.Equals(t, len(exp), len(received))
+	for i := range exp {
+		testutil.Equals(t, exp[i].Hash(), received[i].Hash(), i)
+	}
+}
@@ -6,7 +6,6 @@ package ethereum
 import (
 	"context"
 	"math/big"
-	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -15,10 +14,12 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/pkg/errors"
 	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/errclass"
 	"github.com/tellor-io/telliot/pkg/logging"
 )
 
@@ -27,13 +28,23 @@ const ComponentName = "ethereumClient"
 type Config struct {
 	LogLevel string
 	Timeout  uint
+	// Logs sets how large block ranges are fetched.
+	Logs LogFetcherConfig
+	// Middleware sets the timeouts and the logging of the slow calls per RPC method.
+	Middleware MiddlewareConfig
+	// Verify sets the verification of the events against the block headers.
+	Verify VerifyConfig
+	// Blocks sets how the new blocks are received.
+	Blocks BlocksConfig
 }
 
 // clientInstance is the concrete implementation of the ETHClient.
 type clientInstance struct {
 	ethClient *ethclient.Client
+	rpcClient *rpc.Client
 	timeout   time.Duration
 	logger    log.Logger
+	blocks    BlocksConfig
 }
 
 var (
@@ -47,7 +58,10 @@ var (
 // NewClient creates a new client instance.
 func NewClient(logger log.Logger, cfg Config, url string) (contracts.ETHClient, error) {
 	timeout := time.Duration(cfg.Timeout) * time.Second
-	client, err := ethclient.Dial(url)
+	if err := cfg.Blocks.validate(); err != nil {
+		return nil, err
+	}
+	rpcClient, err := rpc.Dial(url)
 	if err != nil {
 		return nil, err
 	}
@@ -55,11 +69,14 @@ func NewClient(logger log.Logger, cfg Config, url string) (contracts.ETHClient,
 	if err != nil {
 		return nil, errors.Wrap(err, "apply filter logger")
 	}
-	return &clientInstance{
-		ethClient: client,
+	logger = log.With(logger, "component", ComponentName)
+	return newMiddleware(logger, cfg.Middleware, &clientInstance{
+		ethClient: ethclient.NewClient(rpcClient),
+		rpcClient: rpcClient,
 		timeout:   timeout,
-		logger:    log.With(logger, "component", ComponentName),
-	}, nil
+		logger:    logger,
+		blocks:    cfg.Blocks,
+	}), nil
 }
 
 func (c *clientInstance) withTimeout(ctx context.Context, fn func(*context.Context) error) error {
@@ -78,10 +95,13 @@ func (c *clientInstance) withTimeout(ctx context.Context, fn func(*context.Conte
 		if err == nil {
 			return nil
 		}
-		if strings.Contains(strings.ToLower(err.Error()), "nonce too low") {
+		// The same request fails again on a retry.
+		switch errclass.Of(err) {
+		case errclass.Nonce, errclass.Underpriced, errclass.Revert, errclass.Config:
 			return err
 		}
-		if strings.Contains(strings.ToLower(err.Error()), "replacement transaction underpriced") {
+		// The same request will fail again so let the caller reduce the range.
+		if IsLogRangeError(err) {
 			return err
 		}
 		level.Error(c.logger).Log("msg", "calling eth client", "err", err)
@@ -162,7 +182,18 @@ func (c *clientInstance) SubscribeFilterLogs(ctx context.Context, query ethereum
 }
 
 func (c *clientInstance) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
-	return c.ethClient.SubscribeNewHead(ctx, ch)
+	switch c.blocks.Source {
+	case BlocksPoll:
+		return PollNewHeads(c, c.blocks.PollInterval.Duration, ch), nil
+	case BlocksSubscribe:
+		return c.ethClient.SubscribeNewHead(ctx, ch)
+	}
+	sub, err := c.ethClient.SubscribeNewHead(ctx, ch)
+	if errors.Is(err, rpc.ErrNotificationsUnsupported) && c.blocks.PollInterval.Duration > 0 {
+		level.Info(c.logger).Log("msg", "the node doesn't support subscriptions, polling the new blocks", "interval", c.blocks.PollInterval)
+		return PollNewHeads(c, c.blocks.PollInterval.Duration, ch), nil
+	}
+	return sub, err
 }
 
 func (c *clientInstance) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
@@ -212,6 +243,56 @@ func (c *clientInstance) CallContract(ctx context.Context, call ethereum.CallMsg
 	return res, _err
 }
 
+// BatchCallContract sends all calls in a single JSON-RPC batch request.
+func (c *clientInstance) BatchCallContract(ctx context.Context, calls []ethereum.CallMsg, blockNumber *big.Int) ([][]byte, []error, error) {
+	block := "latest"
+	if blockNumber != nil {
+		block = hexutil.EncodeBig(blockNumber)
+	}
+	results := make([]hexutil.Bytes, len(calls))
+	elems := make([]rpc.BatchElem, len(calls))
+	for i, call := range calls {
+		elems[i] = rpc.BatchElem{
+			Method: "eth_call",
+			Args:   []interface{}{toCallArg(call), block},
+			Result: &results[i],
+		}
+	}
+	err := c.withTimeout(ctx, func(_ctx *context.Context) error {
+		return c.rpcClient.BatchCallContext(*_ctx, elems)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	res := make([][]byte, len(calls))
+	errs := make([]error, len(calls))
+	for i, elem := range elems {
+		res[i], errs[i] = results[i], elem.Error
+	}
+	return res, errs, nil
+}
+
+// toCallArg is the same as in the ethclient which doesn't export it.
+func toCallArg(msg ethereum.CallMsg) interface{} {
+	arg := map[string]interface{}{
+		"from": msg.From,
+		"to":   msg.To,
+	}
+	if len(msg.Data) > 0 {
+		arg["data"] = hexutil.Bytes(msg.Data)
+	}
+	if msg.Value != nil {
+		arg["value"] = (*hexutil.Big)(msg.Value)
+	}
+	if msg.Gas != 0 {
+		arg["gas"] = hexutil.Uint64(msg.Gas)
+	}
+	if msg.GasPrice != nil {
+		arg["gasPrice"] = (*hexutil.Big)(msg.GasPrice)
+	}
+	return arg
+}
+
 func (c *clientInstance) PendingNonceAt(ctx context.Context, address common.Address) (uint64, error) {
 	var res uint64
 	_err := c.withTimeout(ctx, func(_ctx *context.Context) error {
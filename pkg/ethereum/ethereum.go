@@ -8,7 +8,6 @@ import (
 	"crypto/ecdsa"
 	"encoding/hex"
 	"math/big"
-	"os"
 	"regexp"
 	"strings"
 
@@ -17,9 +16,13 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/pkg/errors"
 	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/secret"
 )
 
 const PrivateKeysEnvName = "ETH_PRIVATE_KEYS"
+
+// RetiredKeysEnvName holds the keys replaced by a rotation until their stake is withdrawn.
+const RetiredKeysEnvName = "ETH_RETIRED_PRIVATE_KEYS"
 const NodeURLEnvName = "NODE_URL"
 
 var ethAddressRE *regexp.Regexp = regexp.MustCompile("^0x[0-9a-fA-F]{40}$")
@@ -105,6 +108,9 @@ func PrepareEthTransaction(
 		return nil, errors.Wrap(err, "getting network id")
 	}
 
+	if account.ReadOnly() {
+		return nil, errors.Wrapf(ErrReadOnly, "can't send transactions from:%v", account.Address.Hex())
+	}
 	auth, err := bind.NewKeyedTransactorWithChainID(account.GetPrivateKey(), netID)
 	if err != nil {
 		return nil, errors.Wrap(err, "creating transactor")
@@ -138,27 +144,53 @@ func (a *Account) GetPrivateKey() *ecdsa.PrivateKey {
 }
 
 // GetAccounts returns a slice of Account from private keys in
-// PrivateKeysEnvName environment variable.
+// PrivateKeysEnvName environment variable
+// followed by the read-only accounts in ObserverAddressesEnvName.
 func GetAccounts() ([]*Account, error) {
-	_privateKeys := os.Getenv(PrivateKeysEnvName)
-	privateKeys := strings.Split(_privateKeys, ",")
-
-	// Create an Account instance per private keys.
-	accounts := make([]*Account, len(privateKeys))
-	for i, pkey := range privateKeys {
-		privateKey, err := crypto.HexToECDSA(strings.TrimSpace(pkey))
+	var accounts []*Account
+	if keys := secret.Get(PrivateKeysEnvName); keys != "" {
+		_accounts, err := ParseAccounts(keys)
 		if err != nil {
-			return nil, errors.Wrap(err, "getting private key to ECDSA")
+			return nil, err
 		}
+		accounts = append(accounts, _accounts...)
+	}
+	observers, err := GetObserverAccounts()
+	if err != nil {
+		return nil, err
+	}
+	accounts = append(accounts, observers...)
+	if len(accounts) == 0 {
+		return nil, errors.Errorf("no accounts in %v or %v", PrivateKeysEnvName, ObserverAddressesEnvName)
+	}
+	return accounts, nil
+}
 
-		publicKey := privateKey.Public()
-		publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-		if !ok {
-			return nil, errors.New("casting public key to ECDSA")
+// ParseAccounts returns an Account per private key in a list separated by `,`.
+func ParseAccounts(privateKeys string) ([]*Account, error) {
+	var accounts []*Account
+	for _, pkey := range strings.Split(privateKeys, ",") {
+		account, err := NewAccount(pkey)
+		if err != nil {
+			return nil, err
 		}
-
-		publicAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
-		accounts[i] = &Account{Address: publicAddress, PrivateKey: privateKey}
+		accounts = append(accounts, account)
 	}
 	return accounts, nil
 }
+
+// NewAccount creates an Account from a hex encoded private key.
+func NewAccount(pkey string) (*Account, error) {
+	privateKey, err := crypto.HexToECDSA(strings.TrimSpace(pkey))
+	if err != nil {
+		return nil, errors.Wrap(err, "getting private key to ECDSA")
+	}
+
+	publicKey := privateKey.Public()
+	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("casting public key to ECDSA")
+	}
+
+	return &Account{Address: crypto.PubkeyToAddress(*publicKeyECDSA), PrivateKey: privateKey}, nil
+}
@@ -0,0 +1,171 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package ethereum
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+)
+
+// logRangeErrors are the errors returned by the providers
+// when a log request covers too many blocks or results.
+var logRangeErrors = []string{
+	"query returned more than",
+	"block range is too wide",
+	"block range too large",
+	"exceed maximum block range",
+	"response size exceeded",
+	"response is too big",
+	"too many results",
+	"is limited to",
+}
+
+// growAfter is the number of successful requests after which a reduced chunk is doubled.
+const growAfter = 5
+
+// IsLogRangeError returns true when the error is because the requested log range was too big.
+func IsLogRangeError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, e := range logRangeErrors {
+		if strings.Contains(msg, e) {
+			return true
+		}
+	}
+	return false
+}
+
+type LogFetcherConfig struct {
+	// ChunkSize is the max number of blocks requested at once.
+	ChunkSize uint64
+	// MinChunkSize is the size below which a range error is returned instead of retried.
+	MinChunkSize uint64
+	// RateLimit is the max number of requests per second, 0 means no limit.
+	RateLimit float64
+}
+
+// LogFetcher fetches the logs of large block ranges in chunks.
+// A chunk is halved when the provider rejects it as too big
+// and doubled again after a few successful requests.
+// It is safe to share between components so that they share the rate limit.
+type LogFetcher struct {
+	logger  log.Logger
+	client  ethereum.LogFilterer
+	cfg     LogFetcherConfig
+	limiter *rate.Limiter
+
+	mtx   sync.Mutex
+	chunk uint64
+	// successes since the chunk was last changed.
+	successes int
+}
+
+func NewLogFetcher(logger log.Logger, client ethereum.LogFilterer, cfg LogFetcherConfig) (*LogFetcher, error) {
+	if cfg.ChunkSize == 0 {
+		return nil, errors.New("chunk size should be more than 0")
+	}
+	if cfg.MinChunkSize == 0 {
+		cfg.MinChunkSize = 1
+	}
+	limit := rate.Inf
+	if cfg.RateLimit > 0 {
+		limit = rate.Limit(cfg.RateLimit)
+	}
+	return &LogFetcher{
+		logger:  log.With(logger, "component", ComponentName),
+		client:  client,
+		cfg:     cfg,
+		chunk:   cfg.ChunkSize,
+		limiter: rate.NewLimiter(limit, 1),
+	}, nil
+}
+
+// FilterLogs returns the logs of the query between the from and to blocks inclusive.
+// The FromBlock and ToBlock of the query are ignored.
+func (self *LogFetcher) FilterLogs(ctx context.Context, query ethereum.FilterQuery, from, to uint64) ([]types.Log, error) {
+	var logs []types.Log
+	err := self.Walk(ctx, query, from, to, func(_, _ uint64, l []types.Log) error {
+		logs = append(logs, l...)
+		return nil
+	})
+	return logs, err
+}
+
+// Walk calls fn with the logs of every chunk in block order
+// so that large ranges don't need to be kept in memory.
+func (self *LogFetcher) Walk(ctx context.Context, query ethereum.FilterQuery, from, to uint64, fn func(from, to uint64, logs []types.Log) error) error {
+	for start := from; start <= to; {
+		end := start + self.chunkSize() - 1
+		if end > to {
+			end = to
+		}
+		if err := self.limiter.Wait(ctx); err != nil {
+			return err
+		}
+		query.FromBlock = new(big.Int).SetUint64(start)
+		query.ToBlock = new(big.Int).SetUint64(end)
+		logs, err := self.client.FilterLogs(ctx, query)
+		if err != nil {
+			if !IsLogRangeError(err) || !self.shrink(end-start+1) {
+				return errors.Wrapf(err, "filter logs blocks:%v-%v", start, end)
+			}
+			level.Debug(self.logger).Log("msg", "log range too big, reducing the chunk size", "chunk", self.chunkSize(), "err", err)
+			continue
+		}
+		self.grow()
+		if err := fn(start, end, logs); err != nil {
+			return err
+		}
+		start = end + 1
+	}
+	return nil
+}
+
+func (self *LogFetcher) chunkSize() uint64 {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	return self.chunk
+}
+
+// shrink halves the chunk that failed and
+// returns false when it is already at the min size.
+func (self *LogFetcher) shrink(failed uint64) bool {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	if failed <= self.cfg.MinChunkSize {
+		return false
+	}
+	self.successes = 0
+	if failed/2 < self.chunk {
+		self.chunk = failed / 2
+	}
+	if self.chunk < self.cfg.MinChunkSize {
+		self.chunk = self.cfg.MinChunkSize
+	}
+	return true
+}
+
+func (self *LogFetcher) grow() {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	self.successes++
+	if self.chunk < self.cfg.ChunkSize && self.successes >= growAfter {
+		self.successes = 0
+		self.chunk *= 2
+		if self.chunk > self.cfg.ChunkSize {
+			self.chunk = self.cfg.ChunkSize
+		}
+	}
+}
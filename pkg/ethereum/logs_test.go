@@ -0,0 +1,58 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package ethereum
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// limitedFilterer has a log on every block and
+// rejects the requests for more than the max blocks.
+type limitedFilterer struct {
+	ethereum.LogFilterer
+	max      uint64
+	requests int
+}
+
+func (self *limitedFilterer) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	self.requests++
+	from, to := q.FromBlock.Uint64(), q.ToBlock.Uint64()
+	if to-from+1 > self.max {
+		return nil, errors.New("query returned more than 10000 results")
+	}
+	var logs []types.Log
+	for b := from; b <= to; b++ {
+		logs = append(logs, types.Log{BlockNumber: b})
+	}
+	return logs, nil
+}
+
+func TestLogFetcher(t *testing.T) {
+	client := &limitedFilterer{max: 30}
+	fetcher, err := NewLogFetcher(logging.NewLogger(), client, LogFetcherConfig{ChunkSize: 100})
+	testutil.Ok(t, err)
+
+	logs, err := fetcher.FilterLogs(context.Background(), ethereum.FilterQuery{}, 1, 1000)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 1000, len(logs))
+	for i, l := range logs {
+		testutil.Equals(t, uint64(i+1), l.BlockNumber)
+	}
+	// The chunk grows back periodically so some requests fail,
+	// but most should be within the limit.
+	testutil.Assert(t, client.requests < 100, "too many requests:%v", client.requests)
+
+	// The range error is returned when it can't be reduced below the min chunk size.
+	fetcher, err = NewLogFetcher(logging.NewLogger(), client, LogFetcherConfig{ChunkSize: 100, MinChunkSize: 50})
+	testutil.Ok(t, err)
+	_, err = fetcher.FilterLogs(context.Background(), ethereum.FilterQuery{}, 1, 1000)
+	testutil.NotOk(t, err)
+}
@@ -0,0 +1,314 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package ethereum
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/errclass"
+	"github.com/tellor-io/telliot/pkg/format"
+)
+
+// The metrics are shared by all clients as some commands create more than one.
+var (
+	rpcDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "telliot",
+		Subsystem: ComponentName,
+		Name:      "request_duration_seconds",
+		Help:      "The duration of the RPC calls including their retries",
+		Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"method"})
+	rpcRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "telliot",
+		Subsystem: ComponentName,
+		Name:      "requests_total",
+		Help:      "The total number of RPC calls by result",
+	}, []string{"method", "result"})
+)
+
+// The results of the RPC calls.
+const (
+	resultOK          = "ok"
+	resultError       = "error"
+	resultTimeout     = "timeout"
+	resultRateLimited = "rate_limited"
+)
+
+// MiddlewareConfig sets the timeouts and logging of the RPC calls per JSON-RPC method like eth_call.
+type MiddlewareConfig struct {
+	// Timeouts limits how long a call of the method can take including its retries.
+	Timeouts map[string]format.Duration
+	// DefaultTimeout is the timeout of the methods not in the timeouts so that a hung call
+	// can't block its caller, 0 disables it.
+	DefaultTimeout format.Duration
+	// SlowCall logs the calls that take longer, 0 disables it.
+	SlowCall format.Duration
+}
+
+// middleware wraps a client to record the latency and the results of every call per RPC method.
+type middleware struct {
+	next   contracts.ETHClient
+	cfg    MiddlewareConfig
+	logger log.Logger
+}
+
+func newMiddleware(logger log.Logger, cfg MiddlewareConfig, next contracts.ETHClient) *middleware {
+	return &middleware{
+		next:   next,
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+func (self *middleware) call(ctx context.Context, method string, fn func(context.Context) error) error {
+	timeout, ok := self.cfg.Timeouts[method]
+	if !ok {
+		timeout = self.cfg.DefaultTimeout
+	}
+	if timeout.Duration > 0 {
+		var cncl context.CancelFunc
+		ctx, cncl = context.WithTimeout(ctx, timeout.Duration)
+		defer cncl()
+	}
+	start := time.Now()
+	err := fn(ctx)
+	took := time.Since(start)
+
+	rpcDuration.With(prometheus.Labels{"method": method}).Observe(took.Seconds())
+	result := resultOK
+	switch {
+	// Not found is the normal response while waiting for a transaction.
+	case err == nil || errors.Is(err, ethereum.NotFound):
+	case IsRateLimitError(err):
+		result = resultRateLimited
+	case errors.Is(err, context.DeadlineExceeded) || ctx.Err() == context.DeadlineExceeded:
+		result = resultTimeout
+	default:
+		result = resultError
+	}
+	rpcRequests.With(prometheus.Labels{"method": method, "result": result}).Inc()
+
+	if self.cfg.SlowCall.Duration > 0 && took > self.cfg.SlowCall.Duration {
+		level.Warn(self.logger).Log("msg", "slow rpc call", "method", method, "took", took, "result", result)
+	}
+	// The rate limits and the timeouts of the provider are retryable for all callers.
+	if result == resultRateLimited || result == resultTimeout {
+		return errclass.Wrap(errclass.Retryable, err)
+	}
+	return err
+}
+
+// IsRateLimitError returns true when the provider rejected a request for exceeding its rate limit.
+func IsRateLimitError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{"429", "too many requests", "rate limit", "limit exceeded", "exceeded the quota"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (self *middleware) Close() {
+	self.next.Close()
+}
+
+func (self *middleware) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) (res []byte, err error) {
+	err = self.call(ctx, "eth_getCode", func(ctx context.Context) error {
+		res, err = self.next.CodeAt(ctx, contract, blockNumber)
+		return err
+	})
+	return res, err
+}
+
+func (self *middleware) TransactionReceipt(ctx context.Context, txHash common.Hash) (res *types.Receipt, err error) {
+	err = self.call(ctx, "eth_getTransactionReceipt", func(ctx context.Context) error {
+		res, err = self.next.TransactionReceipt(ctx, txHash)
+		return err
+	})
+	return res, err
+}
+
+func (self *middleware) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) (res []byte, err error) {
+	err = self.call(ctx, "eth_call", func(ctx context.Context) error {
+		res, err = self.next.CallContract(ctx, call, blockNumber)
+		return err
+	})
+	return res, err
+}
+
+func (self *middleware) BatchCallContract(ctx context.Context, calls []ethereum.CallMsg, blockNumber *big.Int) (res [][]byte, errs []error, err error) {
+	err = self.call(ctx, "eth_call_batch", func(ctx context.Context) error {
+		batcher, ok := self.next.(contracts.BatchCaller)
+		if !ok {
+			for _, call := range calls {
+				r, err := self.next.CallContract(ctx, call, blockNumber)
+				res = append(res, r)
+				errs = append(errs, err)
+			}
+			return nil
+		}
+		res, errs, err = batcher.BatchCallContract(ctx, calls, blockNumber)
+		return err
+	})
+	return res, errs, err
+}
+
+func (self *middleware) NonceAt(ctx context.Context, address common.Address) (res uint64, err error) {
+	err = self.call(ctx, "eth_getTransactionCount", func(ctx context.Context) error {
+		res, err = self.next.NonceAt(ctx, address)
+		return err
+	})
+	return res, err
+}
+
+func (self *middleware) PendingCallContract(ctx context.Context, call ethereum.CallMsg) (res []byte, err error) {
+	err = self.call(ctx, "eth_call", func(ctx context.Context) error {
+		res, err = self.next.PendingCallContract(ctx, call)
+		return err
+	})
+	return res, err
+}
+
+func (self *middleware) PendingCodeAt(ctx context.Context, account common.Address) (res []byte, err error) {
+	err = self.call(ctx, "eth_getCode", func(ctx context.Context) error {
+		res, err = self.next.PendingCodeAt(ctx, account)
+		return err
+	})
+	return res, err
+}
+
+func (self *middleware) PendingNonceAt(ctx context.Context, address common.Address) (res uint64, err error) {
+	err = self.call(ctx, "eth_getTransactionCount", func(ctx context.Context) error {
+		res, err = self.next.PendingNonceAt(ctx, address)
+		return err
+	})
+	return res, err
+}
+
+func (self *middleware) EstimateGas(ctx context.Context, call ethereum.CallMsg) (res uint64, err error) {
+	err = self.call(ctx, "eth_estimateGas", func(ctx context.Context) error {
+		res, err = self.next.EstimateGas(ctx, call)
+		return err
+	})
+	return res, err
+}
+
+func (self *middleware) SuggestGasPrice(ctx context.Context) (res *big.Int, err error) {
+	err = self.call(ctx, "eth_gasPrice", func(ctx context.Context) error {
+		res, err = self.next.SuggestGasPrice(ctx)
+		return err
+	})
+	return res, err
+}
+
+func (self *middleware) FilterLogs(ctx context.Context, query ethereum.FilterQuery) (res []types.Log, err error) {
+	err = self.call(ctx, "eth_getLogs", func(ctx context.Context) error {
+		res, err = self.next.FilterLogs(ctx, query)
+		return err
+	})
+	return res, err
+}
+
+// The subscriptions use the context for their lifetime so they don't get a timeout.
+
+func (self *middleware) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (res ethereum.Subscription, err error) {
+	start := time.Now()
+	res, err = self.next.SubscribeFilterLogs(ctx, query, ch)
+	self.observeSubscribe(start, err)
+	return res, err
+}
+
+func (self *middleware) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (res ethereum.Subscription, err error) {
+	start := time.Now()
+	res, err = self.next.SubscribeNewHead(ctx, ch)
+	self.observeSubscribe(start, err)
+	return res, err
+}
+
+func (self *middleware) observeSubscribe(start time.Time, err error) {
+	rpcDuration.With(prometheus.Labels{"method": "eth_subscribe"}).Observe(time.Since(start).Seconds())
+	result := resultOK
+	if err != nil {
+		result = resultError
+	}
+	rpcRequests.With(prometheus.Labels{"method": "eth_subscribe", "result": result}).Inc()
+}
+
+func (self *middleware) BalanceAt(ctx context.Context, address common.Address, block *big.Int) (res *big.Int, err error) {
+	err = self.call(ctx, "eth_getBalance", func(ctx context.Context) error {
+		res, err = self.next.BalanceAt(ctx, address, block)
+		return err
+	})
+	return res, err
+}
+
+func (self *middleware) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	return self.call(ctx, "eth_sendRawTransaction", func(ctx context.Context) error {
+		return self.next.SendTransaction(ctx, tx)
+	})
+}
+
+func (self *middleware) IsSyncing(ctx context.Context) (res bool, err error) {
+	err = self.call(ctx, "eth_syncing", func(ctx context.Context) error {
+		res, err = self.next.IsSyncing(ctx)
+		return err
+	})
+	return res, err
+}
+
+func (self *middleware) NetworkID(ctx context.Context) (res *big.Int, err error) {
+	err = self.call(ctx, "net_version", func(ctx context.Context) error {
+		res, err = self.next.NetworkID(ctx)
+		return err
+	})
+	return res, err
+}
+
+func (self *middleware) HeaderByNumber(ctx context.Context, num *big.Int) (res *types.Header, err error) {
+	err = self.call(ctx, "eth_getBlockByNumber", func(ctx context.Context) error {
+		res, err = self.next.HeaderByNumber(ctx, num)
+		return err
+	})
+	return res, err
+}
+
+func (self *middleware) TransactionByHash(ctx context.Context, hash common.Hash) (tx *types.Transaction, isPending bool, err error) {
+	err = self.call(ctx, "eth_getTransactionByHash", func(ctx context.Context) error {
+		tx, isPending, err = self.next.TransactionByHash(ctx, hash)
+		return err
+	})
+	return tx, isPending, err
+}
+
+func (self *middleware) BlockByHash(ctx context.Context, hash common.Hash) (res *types.Block, err error) {
+	err = self.call(ctx, "eth_getBlockByHash", func(ctx context.Context) error {
+		res, err = self.next.BlockByHash(ctx, hash)
+		return err
+	})
+	return res, err
+}
+
+func (self *middleware) BlockByNumber(ctx context.Context, number *big.Int) (res *types.Block, err error) {
+	err = self.call(ctx, "eth_getBlockByNumber", func(ctx context.Context) error {
+		res, err = self.next.BlockByNumber(ctx, number)
+		return err
+	})
+	return res, err
+}
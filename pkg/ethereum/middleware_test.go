@@ -0,0 +1,69 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package ethereum
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// networkIDClient only implements NetworkID and returns the next error on every call.
+type networkIDClient struct {
+	contracts.ETHClient
+	errs []error
+}
+
+func (self *networkIDClient) NetworkID(ctx context.Context) (*big.Int, error) {
+	err := self.errs[0]
+	self.errs = self.errs[1:]
+	if err == context.DeadlineExceeded {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return big.NewInt(1), err
+}
+
+func TestMiddleware(t *testing.T) {
+	client := &networkIDClient{errs: []error{
+		nil,
+		errors.New("429 Too Many Requests"),
+		errors.New("connection refused"),
+		context.DeadlineExceeded,
+	}}
+	m := newMiddleware(log.NewNopLogger(), MiddlewareConfig{
+		Timeouts: map[string]format.Duration{"net_version": {Duration: 10 * time.Millisecond}},
+	}, client)
+
+	count := func(result string) float64 {
+		return promtest.ToFloat64(rpcRequests.With(prometheus.Labels{"method": "net_version", "result": result}))
+	}
+	before := map[string]float64{}
+	for _, r := range []string{resultOK, resultRateLimited, resultError, resultTimeout} {
+		before[r] = count(r)
+	}
+
+	_, err := m.NetworkID(context.Background())
+	testutil.Ok(t, err)
+	_, err = m.NetworkID(context.Background())
+	testutil.NotOk(t, err)
+	_, err = m.NetworkID(context.Background())
+	testutil.NotOk(t, err)
+	// The call is canceled after the method timeout.
+	_, err = m.NetworkID(context.Background())
+	testutil.NotOk(t, err)
+
+	for _, r := range []string{resultOK, resultRateLimited, resultError, resultTimeout} {
+		testutil.Equals(t, before[r]+1, count(r), "result:%v", r)
+	}
+}
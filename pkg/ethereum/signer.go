@@ -0,0 +1,131 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package ethereum
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/errclass"
+)
+
+// ObserverAddressesEnvName is a list of addresses separated by `,`
+// for the accounts that are only tracked without a private key.
+const ObserverAddressesEnvName = "ETH_OBSERVER_ADDRESSES"
+
+// ErrReadOnly is returned when signing for an account without a private key.
+var ErrReadOnly = errclass.New(errclass.Config, "read-only account")
+
+// Signer signs the transactions of the accounts it has the keys for
+// so that the keys are never handed out to the components that send transactions.
+type Signer interface {
+	SignTx(from common.Address, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+	CanSign(addr common.Address) bool
+}
+
+// HashSigner signs hashes with the keys of the accounts,
+// e.g. for the messages that smart accounts verify.
+type HashSigner interface {
+	SignHash(from common.Address, hash []byte) ([]byte, error)
+}
+
+// KeySigner signs with the private keys of the accounts and logs every signing request.
+// It is safe for concurrent use.
+type KeySigner struct {
+	logger log.Logger
+	mtx    sync.Mutex
+	keys   map[common.Address]*ecdsa.PrivateKey
+	signed map[common.Address]uint64
+}
+
+// NewKeySigner takes the private keys of the accounts
+// and removes them from the accounts.
+// The read-only accounts are skipped.
+func NewKeySigner(logger log.Logger, accounts []*Account) *KeySigner {
+	self := &KeySigner{
+		logger: log.With(logger, "component", "signer"),
+		keys:   make(map[common.Address]*ecdsa.PrivateKey),
+		signed: make(map[common.Address]uint64),
+	}
+	for _, account := range accounts {
+		if account.ReadOnly() {
+			continue
+		}
+		self.keys[account.Address] = account.PrivateKey
+		account.PrivateKey = nil
+	}
+	return self
+}
+
+func (self *KeySigner) SignTx(from common.Address, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	key, ok := self.keys[from]
+	if !ok {
+		return nil, errors.Wrapf(ErrReadOnly, "no key for:%v", from.Hex())
+	}
+	signed, err := types.SignTx(tx, types.NewEIP155Signer(chainID), key)
+	if err != nil {
+		return nil, errors.Wrap(err, "signing transaction")
+	}
+	self.signed[from]++
+	level.Debug(self.logger).Log("msg", "signed transaction", "from", from.Hex(), "nonce", tx.Nonce(), "to", tx.To(), "hash", signed.Hash().Hex(), "total", self.signed[from])
+	return signed, nil
+}
+
+// SignHash returns the signature of the hash in the [R || S || V] format where V is 0 or 1.
+func (self *KeySigner) SignHash(from common.Address, hash []byte) ([]byte, error) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	key, ok := self.keys[from]
+	if !ok {
+		return nil, errors.Wrapf(ErrReadOnly, "no key for:%v", from.Hex())
+	}
+	sig, err := crypto.Sign(hash, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "signing hash")
+	}
+	self.signed[from]++
+	level.Debug(self.logger).Log("msg", "signed hash", "from", from.Hex(), "hash", common.Bytes2Hex(hash), "total", self.signed[from])
+	return sig, nil
+}
+
+// CanSign returns true when the signer has the key of the account.
+func (self *KeySigner) CanSign(addr common.Address) bool {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	_, ok := self.keys[addr]
+	return ok
+}
+
+// ReadOnly returns true for the accounts that are only tracked without a private key.
+func (a *Account) ReadOnly() bool {
+	return a.PrivateKey == nil
+}
+
+// GetObserverAccounts returns the read-only accounts from the addresses
+// in ObserverAddressesEnvName environment variable.
+func GetObserverAccounts() ([]*Account, error) {
+	var accounts []*Account
+	for _, addr := range strings.Split(os.Getenv(ObserverAddressesEnvName), ",") {
+		addr = strings.TrimSpace(addr)
+		if addr == "" {
+			continue
+		}
+		if !common.IsHexAddress(addr) {
+			return nil, errors.Errorf("invalid observer address:%v", addr)
+		}
+		accounts = append(accounts, &Account{Address: common.HexToAddress(addr)})
+	}
+	return accounts, nil
+}
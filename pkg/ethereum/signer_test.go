@@ -0,0 +1,60 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package ethereum
+
+import (
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+func TestKeySigner(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	testutil.Ok(t, err)
+	account := &Account{Address: crypto.PubkeyToAddress(key.PublicKey), PrivateKey: key}
+	observer := &Account{Address: common.HexToAddress("0x1")}
+
+	signer := NewKeySigner(log.NewNopLogger(), []*Account{account, observer})
+	testutil.Assert(t, account.ReadOnly(), "the key should be removed from the account")
+	testutil.Assert(t, signer.CanSign(account.Address), "should sign for the account")
+	testutil.Assert(t, !signer.CanSign(observer.Address), "shouldn't sign for the read-only account")
+
+	chainID := big.NewInt(1)
+	tx := types.NewTransaction(0, observer.Address, big.NewInt(0), 21000, big.NewInt(1), nil)
+	signed, err := signer.SignTx(account.Address, tx, chainID)
+	testutil.Ok(t, err)
+	from, err := types.Sender(types.NewEIP155Signer(chainID), signed)
+	testutil.Ok(t, err)
+	testutil.Equals(t, account.Address, from)
+
+	_, err = signer.SignTx(observer.Address, tx, chainID)
+	testutil.Assert(t, errors.Is(err, ErrReadOnly), "signing for a read-only account should fail")
+}
+
+func TestGetAccountsObservers(t *testing.T) {
+	defer os.Setenv(PrivateKeysEnvName, os.Getenv(PrivateKeysEnvName))
+	defer os.Setenv(ObserverAddressesEnvName, os.Getenv(ObserverAddressesEnvName))
+
+	testutil.Ok(t, os.Setenv(PrivateKeysEnvName, ""))
+	testutil.Ok(t, os.Setenv(ObserverAddressesEnvName, ""))
+	_, err := GetAccounts()
+	testutil.NotOk(t, err)
+
+	testutil.Ok(t, os.Setenv(ObserverAddressesEnvName, "0x0000000000000000000000000000000000000001, 0x0000000000000000000000000000000000000002"))
+	accounts, err := GetAccounts()
+	testutil.Ok(t, err)
+	testutil.Equals(t, 2, len(accounts))
+	testutil.Assert(t, accounts[0].ReadOnly() && accounts[1].ReadOnly(), "observer accounts should be read-only")
+
+	testutil.Ok(t, os.Setenv(ObserverAddressesEnvName, "0x1"))
+	_, err = GetAccounts()
+	testutil.NotOk(t, err)
+}
@@ -0,0 +1,357 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package ethereum
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/errclass"
+)
+
+// ErrVerificationFailed is returned when the data returned by the node doesn't match its proofs
+// or the block of the witnesses, which means that the node is compromised or malfunctioning.
+var ErrVerificationFailed = errclass.New(errclass.DataQuality, "verification failed")
+
+const (
+	// verifiedBlocks is how many blocks with verified receipts are kept
+	// so that the events in the same block don't fetch the receipts again.
+	verifiedBlocks = 32
+	// receiptsBatchSize is the max number of receipts requested in a single batch.
+	receiptsBatchSize = 100
+)
+
+type VerifyConfig struct {
+	// Enabled verifies the block header and the receipts proof of the events before acting on them.
+	Enabled bool
+	// Witnesses are the names of env variables with the URLs of independent nodes
+	// which should have the same block hash at the block number of the events.
+	Witnesses []string
+}
+
+// Verifier checks that the events returned by the node are included in the canonical chain
+// instead of trusting the node.
+// The block hash of an event is checked against the hash of the block header
+// and the header is checked against the witness nodes.
+// The event is checked to be in the receipts of the block by rebuilding the receipts trie
+// and comparing its root with the one in the header.
+// A nil Verifier doesn't verify anything.
+type Verifier struct {
+	logger    log.Logger
+	client    *rpc.Client
+	witnesses map[string]*rpc.Client
+
+	mtx      sync.Mutex
+	receipts map[common.Hash][]*types.Receipt
+	order    []common.Hash
+
+	verifications *prometheus.CounterVec
+}
+
+// Validate returns an error when the verification is enabled without witnesses
+// as the headers would only be checked against the same node that returned the events.
+func (self VerifyConfig) Validate() error {
+	if self.Enabled && len(self.Witnesses) == 0 {
+		return errors.New("the event verification needs at least one witness node")
+	}
+	return nil
+}
+
+func NewVerifier(logger log.Logger, cfg VerifyConfig, url string) (*Verifier, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	client, err := rpc.Dial(url)
+	if err != nil {
+		return nil, errors.Wrap(err, "dial node")
+	}
+	witnesses := make(map[string]*rpc.Client)
+	for _, name := range cfg.Witnesses {
+		witnessURL := os.Getenv(name)
+		if witnessURL == "" {
+			return nil, errors.Errorf("missing witness URL env variable:%v", name)
+		}
+		witness, err := rpc.Dial(witnessURL)
+		if err != nil {
+			return nil, errors.Wrapf(err, "dial witness:%v", name)
+		}
+		witnesses[name] = witness
+	}
+	return &Verifier{
+		logger:    log.With(logger, "component", "verifier"),
+		client:    client,
+		witnesses: witnesses,
+		receipts:  make(map[common.Hash][]*types.Receipt),
+		verifications: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "verifications_total",
+			Help:      "The total number of verified events by result(ok, failed, error)",
+		}, []string{"result"}),
+	}, nil
+}
+
+// VerifyLog returns an error wrapping ErrVerificationFailed
+// when the log is not included in the block it claims to be in.
+func (self *Verifier) VerifyLog(ctx context.Context, l types.Log) error {
+	if self == nil {
+		return nil
+	}
+	err := self.verifyLog(ctx, l)
+	switch {
+	case err == nil:
+		self.verifications.With(prometheus.Labels{"result": "ok"}).Inc()
+	case errors.Is(err, ErrVerificationFailed):
+		self.verifications.With(prometheus.Labels{"result": "failed"}).Inc()
+		level.Error(self.logger).Log("msg", "event verification failed", "block", l.BlockHash, "tx", l.TxHash, "err", err)
+	default:
+		self.verifications.With(prometheus.Labels{"result": "error"}).Inc()
+	}
+	return err
+}
+
+func (self *Verifier) verifyLog(ctx context.Context, l types.Log) error {
+	receipts, err := self.blockReceipts(ctx, l.BlockHash, l.BlockNumber)
+	if err != nil {
+		return err
+	}
+	if int(l.TxIndex) >= len(receipts) {
+		return errors.Wrapf(ErrVerificationFailed, "tx index:%v not in the block with %v transactions", l.TxIndex, len(receipts))
+	}
+	for _, rl := range receipts[l.TxIndex].Logs {
+		if rl.Address == l.Address && equalTopics(rl.Topics, l.Topics) && bytes.Equal(rl.Data, l.Data) {
+			return nil
+		}
+	}
+	return errors.Wrapf(ErrVerificationFailed, "log not in the receipt of tx index:%v", l.TxIndex)
+}
+
+func equalTopics(a, b []common.Hash) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// blockReceipts returns the receipts of the block after verifying them against the block header.
+func (self *Verifier) blockReceipts(ctx context.Context, hash common.Hash, number uint64) ([]*types.Receipt, error) {
+	self.mtx.Lock()
+	receipts, ok := self.receipts[hash]
+	self.mtx.Unlock()
+	if ok {
+		return receipts, nil
+	}
+
+	var block *rpcBlock
+	if err := self.client.CallContext(ctx, &block, "eth_getBlockByHash", hash, false); err != nil {
+		return nil, errors.Wrap(err, "get block")
+	}
+	if block == nil {
+		return nil, errors.Wrapf(ErrVerificationFailed, "block:%v not found", hash)
+	}
+	if h := block.hash(); h != hash {
+		return nil, errors.Wrapf(ErrVerificationFailed, "block header hash:%v doesn't match:%v", h, hash)
+	}
+	if block.Number.ToInt().Uint64() != number {
+		return nil, errors.Wrapf(ErrVerificationFailed, "block number:%v doesn't match:%v", block.Number.ToInt(), number)
+	}
+	if err := self.checkWitnesses(ctx, hash, number); err != nil {
+		return nil, err
+	}
+
+	receipts = make([]*types.Receipt, len(block.Transactions))
+	elems := make([]rpc.BatchElem, len(block.Transactions))
+	for i, tx := range block.Transactions {
+		elems[i] = rpc.BatchElem{
+			Method: "eth_getTransactionReceipt",
+			Args:   []interface{}{tx},
+			Result: &receipts[i],
+		}
+	}
+	for start := 0; start < len(elems); start += receiptsBatchSize {
+		end := start + receiptsBatchSize
+		if end > len(elems) {
+			end = len(elems)
+		}
+		if err := self.client.BatchCallContext(ctx, elems[start:end]); err != nil {
+			return nil, errors.Wrap(err, "get receipts")
+		}
+	}
+	for i, elem := range elems {
+		if elem.Error != nil {
+			return nil, errors.Wrapf(elem.Error, "get receipt:%v", block.Transactions[i])
+		}
+		if receipts[i] == nil {
+			return nil, errors.Wrapf(ErrVerificationFailed, "missing receipt:%v", block.Transactions[i])
+		}
+	}
+	if root := types.DeriveSha(receiptList(receipts), trie.NewStackTrie(nil)); root != block.ReceiptsRoot {
+		return nil, errors.Wrapf(ErrVerificationFailed, "receipts root:%v doesn't match the header:%v", root, block.ReceiptsRoot)
+	}
+
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	self.receipts[hash] = receipts
+	self.order = append(self.order, hash)
+	if len(self.order) > verifiedBlocks {
+		delete(self.receipts, self.order[0])
+		self.order = self.order[1:]
+	}
+	return receipts, nil
+}
+
+// checkWitnesses checks that all the witnesses that have the block at this number have the same block.
+func (self *Verifier) checkWitnesses(ctx context.Context, hash common.Hash, number uint64) error {
+	if len(self.witnesses) == 0 {
+		return nil
+	}
+	var confirmed int
+	for name, witness := range self.witnesses {
+		var header *struct {
+			Hash common.Hash `json:"hash"`
+		}
+		if err := witness.CallContext(ctx, &header, "eth_getBlockByNumber", hexutil.EncodeUint64(number), false); err != nil {
+			level.Warn(self.logger).Log("msg", "get block from witness", "witness", name, "err", err)
+			continue
+		}
+		// The witness might not have the block yet.
+		if header == nil {
+			continue
+		}
+		if header.Hash != hash {
+			return errors.Wrapf(ErrVerificationFailed, "witness:%v has block:%v at number:%v instead of:%v", name, header.Hash, number, hash)
+		}
+		confirmed++
+	}
+	if confirmed == 0 {
+		return errors.Errorf("none of the witnesses confirmed block:%v", hash)
+	}
+	return nil
+}
+
+// rpcBlock is the block header as returned by the node.
+// The header is decoded here instead of using types.Header
+// so that the hash includes the fields added by the later forks.
+type rpcBlock struct {
+	ParentHash            common.Hash      `json:"parentHash"`
+	UncleHash             common.Hash      `json:"sha3Uncles"`
+	Coinbase              common.Address   `json:"miner"`
+	Root                  common.Hash      `json:"stateRoot"`
+	TxHash                common.Hash      `json:"transactionsRoot"`
+	ReceiptsRoot          common.Hash      `json:"receiptsRoot"`
+	Bloom                 types.Bloom      `json:"logsBloom"`
+	Difficulty            *hexutil.Big     `json:"difficulty"`
+	Number                *hexutil.Big     `json:"number"`
+	GasLimit              hexutil.Uint64   `json:"gasLimit"`
+	GasUsed               hexutil.Uint64   `json:"gasUsed"`
+	Time                  hexutil.Uint64   `json:"timestamp"`
+	Extra                 hexutil.Bytes    `json:"extraData"`
+	MixDigest             common.Hash      `json:"mixHash"`
+	Nonce                 types.BlockNonce `json:"nonce"`
+	BaseFee               *hexutil.Big     `json:"baseFeePerGas"`
+	WithdrawalsRoot       *common.Hash     `json:"withdrawalsRoot"`
+	BlobGasUsed           *hexutil.Uint64  `json:"blobGasUsed"`
+	ExcessBlobGas         *hexutil.Uint64  `json:"excessBlobGas"`
+	ParentBeaconBlockRoot *common.Hash     `json:"parentBeaconBlockRoot"`
+	RequestsHash          *common.Hash     `json:"requestsHash"`
+
+	Transactions []common.Hash `json:"transactions"`
+}
+
+func (self *rpcBlock) UnmarshalJSON(input []byte) error {
+	type block rpcBlock
+	var b block
+	if err := json.Unmarshal(input, &b); err != nil {
+		return err
+	}
+	if b.Number == nil || b.Difficulty == nil {
+		return errors.New("missing block number or difficulty")
+	}
+	*self = rpcBlock(b)
+	return nil
+}
+
+// hash returns the keccak256 hash of the RLP encoded header
+// with the optional fields of the later forks appended in order.
+func (self *rpcBlock) hash() common.Hash {
+	fields := []interface{}{
+		self.ParentHash,
+		self.UncleHash,
+		self.Coinbase,
+		self.Root,
+		self.TxHash,
+		self.ReceiptsRoot,
+		self.Bloom,
+		self.Difficulty.ToInt(),
+		self.Number.ToInt(),
+		uint64(self.GasLimit),
+		uint64(self.GasUsed),
+		uint64(self.Time),
+		[]byte(self.Extra),
+		self.MixDigest,
+		self.Nonce,
+	}
+	if self.BaseFee != nil {
+		fields = append(fields, self.BaseFee.ToInt())
+	}
+	if self.WithdrawalsRoot != nil {
+		fields = append(fields, *self.WithdrawalsRoot)
+	}
+	if self.BlobGasUsed != nil {
+		fields = append(fields, uint64(*self.BlobGasUsed))
+	}
+	if self.ExcessBlobGas != nil {
+		fields = append(fields, uint64(*self.ExcessBlobGas))
+	}
+	if self.ParentBeaconBlockRoot != nil {
+		fields = append(fields, *self.ParentBeaconBlockRoot)
+	}
+	if self.RequestsHash != nil {
+		fields = append(fields, *self.RequestsHash)
+	}
+	enc, _ := rlp.EncodeToBytes(fields)
+	return crypto.Keccak256Hash(enc)
+}
+
+// receiptList encodes the receipts for the receipts trie.
+// It is the same as types.Receipts, which doesn't encode the receipts
+// of the transaction types added after the AccessList type.
+type receiptList []*types.Receipt
+
+func (self receiptList) Len() int { return len(self) }
+
+func (self receiptList) EncodeIndex(i int, w *bytes.Buffer) {
+	r := self[i]
+	status := r.PostState
+	if len(status) == 0 {
+		status = []byte{}
+		if r.Status == types.ReceiptStatusSuccessful {
+			status = []byte{0x01}
+		}
+	}
+	if r.Type != types.LegacyTxType {
+		w.WriteByte(r.Type)
+	}
+	_ = rlp.Encode(w, []interface{}{status, r.CumulativeGasUsed, r.Bloom, r.Logs})
+}
@@ -0,0 +1,119 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package ethereum
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// fakeNode serves a single block with its receipts.
+type fakeNode struct {
+	header   *types.Header
+	receipts map[common.Hash]*types.Receipt
+	txs      []common.Hash
+}
+
+func (self *fakeNode) GetBlockByHash(hash common.Hash, full bool) (map[string]interface{}, error) {
+	enc, err := json.Marshal(self.header)
+	if err != nil {
+		return nil, err
+	}
+	var block map[string]interface{}
+	if err := json.Unmarshal(enc, &block); err != nil {
+		return nil, err
+	}
+	block["transactions"] = self.txs
+	return block, nil
+}
+
+func (self *fakeNode) GetTransactionReceipt(hash common.Hash) (*types.Receipt, error) {
+	return self.receipts[hash], nil
+}
+
+func TestVerifyLog(t *testing.T) {
+	event := &types.Log{
+		Address: common.HexToAddress("0x1"),
+		Topics:  []common.Hash{common.HexToHash("0x2")},
+		Data:    []byte{3},
+	}
+	var receipts types.Receipts
+	node := &fakeNode{receipts: make(map[common.Hash]*types.Receipt)}
+	for i := 0; i < 3; i++ {
+		receipt := &types.Receipt{
+			Type:              types.AccessListTxType,
+			Status:            types.ReceiptStatusSuccessful,
+			CumulativeGasUsed: uint64(21000 * (i + 1)),
+			TxHash:            common.BigToHash(big.NewInt(int64(i + 1))),
+			Logs:              []*types.Log{},
+		}
+		if i == 1 {
+			receipt.Logs = []*types.Log{event}
+		}
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+		receipts = append(receipts, receipt)
+		node.receipts[receipt.TxHash] = receipt
+		node.txs = append(node.txs, receipt.TxHash)
+	}
+	node.header = &types.Header{
+		Number:      big.NewInt(10),
+		Difficulty:  big.NewInt(1),
+		GasLimit:    30000000,
+		ReceiptHash: types.DeriveSha(receipts, trie.NewStackTrie(nil)),
+		Extra:       []byte{},
+	}
+
+	server := rpc.NewServer()
+	testutil.Ok(t, server.RegisterName("eth", node))
+	defer server.Stop()
+	verifier := &Verifier{
+		logger:        log.NewNopLogger(),
+		client:        rpc.DialInProc(server),
+		receipts:      make(map[common.Hash][]*types.Receipt),
+		verifications: prometheus.NewCounterVec(prometheus.CounterOpts{}, []string{"result"}),
+	}
+
+	l := *event
+	l.BlockHash = node.header.Hash()
+	l.BlockNumber = 10
+	l.TxIndex = 1
+	testutil.Ok(t, verifier.VerifyLog(context.Background(), l))
+
+	// A log that is not in the receipt.
+	forged := l
+	forged.Data = []byte{4}
+	testutil.Assert(t, errors.Is(verifier.VerifyLog(context.Background(), forged), ErrVerificationFailed), "forged log should fail")
+
+	// A block hash that doesn't match the header.
+	forged = l
+	forged.BlockHash = common.HexToHash("0x5")
+	testutil.Assert(t, errors.Is(verifier.VerifyLog(context.Background(), forged), ErrVerificationFailed), "forged block hash should fail")
+
+	// A receipt that doesn't match the receipts root.
+	verifier.receipts = make(map[common.Hash][]*types.Receipt)
+	forged = l
+	forged.Data = []byte{4}
+	node.receipts[node.txs[1]].Logs = []*types.Log{&forged}
+	testutil.Assert(t, errors.Is(verifier.VerifyLog(context.Background(), l), ErrVerificationFailed), "forged receipt should fail")
+}
+
+// TestVerifyConfig ensures that the verification isn't enabled without witnesses.
+func TestVerifyConfig(t *testing.T) {
+	testutil.Ok(t, VerifyConfig{}.Validate())
+	testutil.NotOk(t, VerifyConfig{Enabled: true}.Validate())
+	testutil.Ok(t, VerifyConfig{Enabled: true, Witnesses: []string{"WITNESS_NODE_URL"}}.Validate())
+	_, err := NewVerifier(log.NewNopLogger(), VerifyConfig{Enabled: true}, "http://localhost:8545")
+	testutil.NotOk(t, err)
+}
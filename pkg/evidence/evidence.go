@@ -0,0 +1,175 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package evidence
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/aggregator"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/psr"
+)
+
+// Submission is a disputed submission and the other values submitted in the same block.
+type Submission struct {
+	DisputeID     int64
+	RequestID     int64
+	Timestamp     time.Time
+	ReportedMiner string
+	// Value is the disputed value in the PSR units.
+	Value  float64
+	Miners []string
+	Values []float64
+}
+
+// Report is the evidence for a disputed submission.
+type Report struct {
+	Generated  time.Time
+	Submission Submission
+	// PSRValue is the value the PSR calculates from the local samples
+	// at the time of the submission.
+	PSRValue     float64
+	PSRErr       string `json:",omitempty"`
+	Aggregations []psr.Aggregation
+	// Window is the time before and after the submission of the included samples.
+	Window  time.Duration
+	Samples map[string][]aggregator.Sample
+	// Responses are the raw API responses captured by the index tracker within the window.
+	Responses []Response
+}
+
+// Response is a raw API response of a source.
+type Response struct {
+	Symbol string
+	Domain string
+	Time   time.Time
+	Body   []byte `json:"-"`
+}
+
+func (self Response) name() string {
+	return path.Join("responses", format.SanitizeMetricName(self.Symbol), self.Domain+"-"+strconv.FormatInt(self.Time.UnixNano(), 10)+".json")
+}
+
+// Write packages the report as a zip
+// with the full report as JSON, the samples as CSV, a readable summary
+// and the raw API responses.
+func Write(w io.Writer, report *Report) error {
+	zw := zip.NewWriter(w)
+
+	f, err := create(zw, "report.json", report.Generated)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return errors.Wrap(err, "encoding the report")
+	}
+
+	f, err = create(zw, "samples.csv", report.Generated)
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(f)
+	if err := cw.Write([]string{"timestamp", "symbol", "source", "domain", "value"}); err != nil {
+		return errors.Wrap(err, "writing the csv header")
+	}
+	for symbol, samples := range report.Samples {
+		for _, s := range samples {
+			if err := cw.Write([]string{
+				s.Time.UTC().Format(time.RFC3339),
+				symbol,
+				s.Source,
+				s.Domain,
+				strconv.FormatFloat(s.Value, 'f', -1, 64),
+			}); err != nil {
+				return errors.Wrap(err, "writing the csv")
+			}
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return errors.Wrap(err, "writing the csv")
+	}
+
+	f, err = create(zw, "summary.txt", report.Generated)
+	if err != nil {
+		return err
+	}
+	if err := summary(f, report); err != nil {
+		return errors.Wrap(err, "writing the summary")
+	}
+
+	for _, r := range report.Responses {
+		f, err = create(zw, r.name(), r.Time)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(r.Body); err != nil {
+			return errors.Wrapf(err, "writing %v", r.name())
+		}
+	}
+
+	return zw.Close()
+}
+
+func create(zw *zip.Writer, name string, modified time.Time) (io.Writer, error) {
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating %v", name)
+	}
+	return f, nil
+}
+
+func summary(w io.Writer, report *Report) error {
+	s := report.Submission
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Dispute ID:\t%d\n", s.DisputeID)
+	fmt.Fprintf(tw, "Request ID:\t%d\n", s.RequestID)
+	fmt.Fprintf(tw, "Submitted at:\t%s\n", s.Timestamp.UTC().Format(time.RFC3339))
+	fmt.Fprintf(tw, "Reported miner:\t%s\n", s.ReportedMiner)
+	fmt.Fprintf(tw, "Disputed value:\t%v\n", s.Value)
+	if report.PSRErr != "" {
+		fmt.Fprintf(tw, "PSR value:\tnone(%s)\n", report.PSRErr)
+	} else {
+		fmt.Fprintf(tw, "PSR value:\t%v\n", report.PSRValue)
+		if report.PSRValue != 0 {
+			fmt.Fprintf(tw, "Deviation:\t%.2f%%\n", (s.Value-report.PSRValue)/report.PSRValue*100)
+		}
+	}
+	fmt.Fprintf(tw, "Generated at:\t%s\n", report.Generated.UTC().Format(time.RFC3339))
+
+	fmt.Fprintf(tw, "\nMINER\tVALUE\n")
+	for i, m := range s.Miners {
+		fmt.Fprintf(tw, "%s\t%v\n", m, s.Values[i])
+	}
+
+	fmt.Fprintf(tw, "\nAGGREGATION\tSYMBOL\tVALUE\tCONFIDENCE\tERROR\n")
+	for _, a := range report.Aggregations {
+		fmt.Fprintf(tw, "%s\t%s\t%v\t%.2f\t%s\n", a.Method, a.Symbol, a.Value, a.Confidence, a.Err)
+	}
+
+	fmt.Fprintf(tw, "\nSYMBOL\tSAMPLES ±%v\n", report.Window)
+	for symbol, samples := range report.Samples {
+		fmt.Fprintf(tw, "%s\t%d\n", symbol, len(samples))
+	}
+
+	fmt.Fprintf(tw, "\nRAW RESPONSES\n")
+	if len(report.Responses) == 0 {
+		fmt.Fprintf(tw, "none, enable IndexTracker.Capture to capture the raw API responses\n")
+	}
+	for _, r := range report.Responses {
+		fmt.Fprintf(tw, "%s\n", r.name())
+	}
+	return tw.Flush()
+}
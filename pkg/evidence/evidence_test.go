@@ -0,0 +1,67 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package evidence
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tellor-io/telliot/pkg/aggregator"
+	"github.com/tellor-io/telliot/pkg/psr"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+func TestWrite(t *testing.T) {
+	ts := time.Unix(1600000000, 0)
+	report := &Report{
+		Generated: ts.Add(time.Hour),
+		Submission: Submission{
+			DisputeID: 7,
+			RequestID: 1,
+			Timestamp: ts,
+			Value:     100,
+			Miners:    []string{"0xa", "0xb"},
+			Values:    []float64{90, 100},
+		},
+		PSRValue:     80,
+		Aggregations: []psr.Aggregation{{Method: "median", Symbol: "ETH/USD", Value: 80, Confidence: 100}},
+		Window:       time.Minute,
+		Samples: map[string][]aggregator.Sample{
+			"ETH/USD": {
+				{Source: "coinbase", Domain: "api.pro.coinbase.com", Time: ts, Value: 80},
+				{Source: "kraken", Domain: "api.kraken.com", Time: ts, Value: 81},
+			},
+		},
+		Responses: []Response{{Symbol: "ETH/USD", Domain: "api.kraken.com", Time: ts, Body: []byte(`{"price":81}`)}},
+	}
+
+	buf := &bytes.Buffer{}
+	testutil.Ok(t, Write(buf, report))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	testutil.Ok(t, err)
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		r, err := f.Open()
+		testutil.Ok(t, err)
+		b, err := ioutil.ReadAll(r)
+		testutil.Ok(t, err)
+		files[f.Name] = string(b)
+	}
+	testutil.Equals(t, 4, len(files))
+	testutil.Equals(t, `{"price":81}`, files["responses/ETH_USD/api.kraken.com-1600000000000000000.json"])
+
+	var got Report
+	testutil.Ok(t, json.Unmarshal([]byte(files["report.json"]), &got))
+	testutil.Equals(t, report.Submission.Value, got.Submission.Value)
+	testutil.Equals(t, 2, len(got.Samples["ETH/USD"]))
+
+	testutil.Equals(t, 3, strings.Count(files["samples.csv"], "\n"))
+	testutil.Assert(t, strings.Contains(files["summary.txt"], "25.00%"), "summary without the deviation:%v", files["summary.txt"])
+}
@@ -0,0 +1,80 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package evidence
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// IPFSAuthEnvName is the optional Authorization header of the IPFS API
+// e.g. `Basic <base64 of project:secret>` for a hosted pinning service.
+const IPFSAuthEnvName = "IPFS_API_AUTH"
+
+// ipfs adds and pins files with the HTTP RPC API of an IPFS node.
+type ipfs struct {
+	url    string
+	auth   string
+	client *http.Client
+}
+
+func newIPFS(url string) *ipfs {
+	return &ipfs{
+		url:    strings.TrimSuffix(url, "/"),
+		auth:   os.Getenv(IPFSAuthEnvName),
+		client: &http.Client{},
+	}
+}
+
+// add pins the data and returns its CID.
+func (self *ipfs) add(ctx context.Context, name string, data []byte) (string, error) {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	fw, err := mw.CreateFormFile("file", name)
+	if err != nil {
+		return "", errors.Wrap(err, "create form file")
+	}
+	if _, err := fw.Write(data); err != nil {
+		return "", errors.Wrap(err, "write form file")
+	}
+	if err := mw.Close(); err != nil {
+		return "", errors.Wrap(err, "close form")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, self.url+"/api/v0/add?pin=true&cid-version=1", body)
+	if err != nil {
+		return "", errors.Wrap(err, "create request")
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	if self.auth != "" {
+		req.Header.Set("Authorization", self.auth)
+	}
+	resp, err := self.client.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "send request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return "", errors.Errorf("response code:%v error:%s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	var added struct {
+		Hash string
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&added); err != nil {
+		return "", errors.Wrap(err, "decode response")
+	}
+	if added.Hash == "" {
+		return "", errors.New("no CID in the response")
+	}
+	return added.Hash, nil
+}
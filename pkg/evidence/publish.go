@@ -0,0 +1,271 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package evidence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/aggregator"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/psr"
+)
+
+const ComponentName = "evidence"
+
+var (
+	published = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "telliot",
+		Subsystem: ComponentName,
+		Name:      "published_total",
+		Help:      "The total number of submission evidence bundles pinned to IPFS",
+	})
+	publishFails = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "telliot",
+		Subsystem: ComponentName,
+		Name:      "publish_fails_total",
+		Help:      "The total number of submission evidence bundles that couldn't be pinned to IPFS",
+	})
+)
+
+// Config of the publication of the evidence of every submission to IPFS.
+type Config struct {
+	Enabled  bool
+	LogLevel string
+	// IPFSURL is the HTTP RPC API of the IPFS node that pins the bundles.
+	IPFSURL string
+	Timeout format.Duration
+	// Window is how long before a submission its samples are included.
+	Window format.Duration
+}
+
+// Bundle is the evidence of a submission that is pinned to IPFS.
+type Bundle struct {
+	Generated time.Time
+	Account   string
+	TxHash    string
+	Challenge string
+	// Timestamp is the time at which the values were calculated.
+	Timestamp time.Time
+	// MethodologyCID is the CID of the pinned Methodology
+	// so that all bundles of the same config link to it.
+	MethodologyCID string
+	Methodology    json.RawMessage
+	Window         time.Duration
+	Values         []Value
+}
+
+// Value is a submitted value with the aggregations and the samples it was calculated from.
+type Value struct {
+	RequestID int64
+	// Value is the encoded value as submitted.
+	Value string
+	// Override values are set manually so have no aggregations.
+	Override     bool   `json:",omitempty"`
+	PSRErr       string `json:",omitempty"`
+	Aggregations []psr.Aggregation
+	Samples      map[string][]aggregator.Sample
+}
+
+// Published is a confirmed submission to publish.
+type Published struct {
+	Account    string
+	TxHash     string
+	Challenge  string
+	Timestamp  time.Time
+	RequestIDs []int64
+	Values     []*big.Int
+	Overrides  map[int64]bool
+}
+
+// Publisher pins the evidence of the submissions to IPFS in the background
+// so that the reporters have a tamper-evident trail to defend against disputes.
+type Publisher struct {
+	logger      log.Logger
+	cfg         Config
+	ctx         context.Context
+	close       context.CancelFunc
+	ipfs        *ipfs
+	psr         psr.Observable
+	samples     func(symbol string, from, to time.Time) ([]aggregator.Sample, error)
+	methodology json.RawMessage
+	wg          sync.WaitGroup
+
+	// mtx is held while calculating a value as the aggregations
+	// are collected from a single observer.
+	mtx          sync.Mutex
+	aggregations []psr.Aggregation
+
+	cidMtx         sync.Mutex
+	methodologyCID string
+}
+
+// NewPublisher creates a publisher or returns nil when it is disabled.
+// The PSR should be a separate instance as its observer is replaced
+// and the methodology is its config.
+func NewPublisher(
+	logger log.Logger,
+	ctx context.Context,
+	cfg Config,
+	p psr.Observable,
+	samples func(symbol string, from, to time.Time) ([]aggregator.Sample, error),
+	methodology interface{},
+) (*Publisher, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	logger = log.With(logger, "component", ComponentName)
+	if cfg.IPFSURL == "" {
+		return nil, errors.New("the IPFS url is required")
+	}
+	m, err := json.Marshal(methodology)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal methodology")
+	}
+
+	ctx, close := context.WithCancel(ctx)
+	self := &Publisher{
+		logger:      logger,
+		cfg:         cfg,
+		ctx:         ctx,
+		close:       close,
+		ipfs:        newIPFS(cfg.IPFSURL),
+		psr:         p,
+		samples:     samples,
+		methodology: m,
+	}
+	p.Observe(self.observe)
+
+	// Published at startup so that it is available before the first dispute.
+	// It is retried with the first submission when the node is down.
+	if cid, err := self.methodologyID(); err != nil {
+		level.Warn(logger).Log("msg", "publishing the methodology, will retry with the first submission", "err", err)
+	} else {
+		level.Info(logger).Log("msg", "methodology published", "cid", cid)
+	}
+	return self, nil
+}
+
+func (self *Publisher) observe(a psr.Aggregation) {
+	self.aggregations = append(self.aggregations, a)
+}
+
+// methodologyID pins the methodology once and returns its CID.
+func (self *Publisher) methodologyID() (string, error) {
+	self.cidMtx.Lock()
+	defer self.cidMtx.Unlock()
+	if self.methodologyCID != "" {
+		return self.methodologyCID, nil
+	}
+	ctx, cncl := context.WithTimeout(self.ctx, self.cfg.Timeout.Duration)
+	defer cncl()
+	cid, err := self.ipfs.add(ctx, "methodology.json", self.methodology)
+	if err != nil {
+		return "", err
+	}
+	self.methodologyCID = cid
+	return cid, nil
+}
+
+// Publish pins the evidence of a submission in the background and logs its CID.
+func (self *Publisher) Publish(s Published) {
+	self.wg.Add(1)
+	go func() {
+		defer self.wg.Done()
+		cid, err := self.publish(s)
+		if err != nil {
+			publishFails.Inc()
+			level.Error(self.logger).Log("msg", "publishing submission evidence", "txHash", s.TxHash, "err", err)
+			return
+		}
+		published.Inc()
+		level.Info(self.logger).Log("msg", "submission evidence published", "txHash", s.TxHash, "cid", cid)
+	}()
+}
+
+func (self *Publisher) publish(s Published) (string, error) {
+	methodologyCID, err := self.methodologyID()
+	if err != nil {
+		return "", errors.Wrap(err, "publishing the methodology")
+	}
+	bundle := Bundle{
+		Generated:      time.Now(),
+		Account:        s.Account,
+		TxHash:         s.TxHash,
+		Challenge:      s.Challenge,
+		Timestamp:      s.Timestamp,
+		MethodologyCID: methodologyCID,
+		Methodology:    self.methodology,
+		Window:         self.cfg.Window.Duration,
+	}
+	for i, reqID := range s.RequestIDs {
+		v, err := self.value(reqID, s.Values[i], s.Timestamp, s.Overrides[reqID])
+		if err != nil {
+			return "", err
+		}
+		bundle.Values = append(bundle.Values, v)
+	}
+
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		return "", errors.Wrap(err, "marshal bundle")
+	}
+	ctx, cncl := context.WithTimeout(self.ctx, self.cfg.Timeout.Duration)
+	defer cncl()
+	return self.ipfs.add(ctx, fmt.Sprintf("submission-%s.json", s.TxHash), data)
+}
+
+// value recalculates the value to collect its aggregations and samples.
+func (self *Publisher) value(reqID int64, submitted *big.Int, ts time.Time, override bool) (Value, error) {
+	v := Value{
+		RequestID: reqID,
+		Value:     submitted.String(),
+		Override:  override,
+		Samples:   make(map[string][]aggregator.Sample),
+	}
+	if override {
+		return v, nil
+	}
+
+	self.mtx.Lock()
+	self.aggregations = nil
+	_, err := self.psr.GetValue(reqID, ts)
+	v.Aggregations = self.aggregations
+	self.mtx.Unlock()
+	if err != nil {
+		v.PSRErr = err.Error()
+	}
+
+	for _, a := range v.Aggregations {
+		if _, ok := v.Samples[a.Symbol]; ok {
+			continue
+		}
+		samples, err := self.samples(a.Symbol, ts.Add(-self.cfg.Window.Duration), ts)
+		if err != nil {
+			return v, errors.Wrapf(err, "getting the samples for symbol:%v", a.Symbol)
+		}
+		v.Samples[a.Symbol] = samples
+	}
+	return v, nil
+}
+
+// Close waits for the bundles that are being published.
+func (self *Publisher) Close() {
+	self.wg.Wait()
+	self.close()
+}
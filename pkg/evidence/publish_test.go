@@ -0,0 +1,115 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package evidence
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/tellor-io/telliot/pkg/aggregator"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/psr"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+type psrMock struct {
+	observe func(psr.Aggregation)
+}
+
+func (self *psrMock) Observe(fn func(psr.Aggregation)) { self.observe = fn }
+
+func (self *psrMock) GetValue(reqID int64, ts time.Time) (*big.Int, error) {
+	self.observe(psr.Aggregation{Method: "median", Symbol: "ETH/USD", Value: 2000, Confidence: 1})
+	return big.NewInt(2000e6), nil
+}
+
+// ipfsMock keeps the added files by a fake CID.
+type ipfsMock struct {
+	mtx   sync.Mutex
+	files map[string][]byte
+	auth  string
+}
+
+func (self *ipfsMock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f, _, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sum := sha256.Sum256(data)
+	cid := hex.EncodeToString(sum[:])
+	self.mtx.Lock()
+	self.files[cid] = data
+	self.auth = r.Header.Get("Authorization")
+	self.mtx.Unlock()
+	_ = json.NewEncoder(w).Encode(map[string]string{"Name": "file", "Hash": cid})
+}
+
+func TestPublisher(t *testing.T) {
+	node := &ipfsMock{files: make(map[string][]byte)}
+	srv := httptest.NewServer(node)
+	defer srv.Close()
+	testutil.Ok(t, os.Setenv(IPFSAuthEnvName, "Basic secret"))
+	defer os.Unsetenv(IPFSAuthEnvName)
+
+	cfg := Config{
+		Enabled:  true,
+		LogLevel: "info",
+		IPFSURL:  srv.URL,
+		Timeout:  format.Duration{Duration: time.Second},
+		Window:   format.Duration{Duration: time.Minute},
+	}
+	samples := func(symbol string, from, to time.Time) ([]aggregator.Sample, error) {
+		return []aggregator.Sample{{Source: "coinbase", Domain: "api.pro.coinbase.com", Time: to, Value: 2000}}, nil
+	}
+	publisher, err := NewPublisher(log.NewNopLogger(), context.Background(), cfg, &psrMock{}, samples, map[string]int{"MinConfidence": 1})
+	testutil.Ok(t, err)
+	testutil.Equals(t, 1, len(node.files))
+	testutil.Equals(t, "Basic secret", node.auth)
+
+	ts := time.Unix(1600000000, 0).UTC()
+	publisher.Publish(Published{
+		Account:    "0xa",
+		TxHash:     "0x1",
+		Timestamp:  ts,
+		RequestIDs: []int64{1, 2},
+		Values:     []*big.Int{big.NewInt(2000e6), big.NewInt(5)},
+		Overrides:  map[int64]bool{2: true},
+	})
+	publisher.Close()
+	testutil.Equals(t, 2, len(node.files))
+
+	var bundle Bundle
+	for cid, data := range node.files {
+		if cid == publisher.methodologyCID {
+			testutil.Equals(t, `{"MinConfidence":1}`, string(data))
+			continue
+		}
+		testutil.Ok(t, json.Unmarshal(data, &bundle))
+	}
+	testutil.Equals(t, publisher.methodologyCID, bundle.MethodologyCID)
+	testutil.Equals(t, ts, bundle.Timestamp)
+	testutil.Equals(t, 2, len(bundle.Values))
+	testutil.Equals(t, "2000000000", bundle.Values[0].Value)
+	testutil.Equals(t, 1, len(bundle.Values[0].Aggregations))
+	testutil.Equals(t, 1, len(bundle.Values[0].Samples["ETH/USD"]))
+	testutil.Assert(t, bundle.Values[1].Override, "the override value should be marked")
+	testutil.Equals(t, 0, len(bundle.Values[1].Aggregations))
+}
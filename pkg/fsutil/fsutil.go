@@ -0,0 +1,38 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+// Package fsutil has the file helpers shared by the components that keep their state in files.
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// WriteAtomic replaces the file with the data by writing and syncing a temp file
+// in the same dir and renaming it, so a crash leaves either the old or the new file.
+// The dir is created when it doesn't exist.
+func WriteAtomic(path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return errors.Wrap(err, "creating the dir")
+	}
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return errors.Wrap(err, "creating temp file")
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return errors.Wrap(err, "writing temp file")
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return errors.Wrap(err, "syncing temp file")
+	}
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "closing temp file")
+	}
+	return errors.Wrap(os.Rename(tmp, path), "replacing file")
+}
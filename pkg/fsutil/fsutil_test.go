@@ -0,0 +1,33 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package fsutil
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// TestWriteAtomic ensures that the file is created with its dir and replaced without a leftover temp file.
+func TestWriteAtomic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fsutil")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "sub", "state.json")
+	testutil.Ok(t, WriteAtomic(path, []byte("first"), 0600))
+	testutil.Ok(t, WriteAtomic(path, []byte("second"), 0600))
+
+	data, err := ioutil.ReadFile(path)
+	testutil.Ok(t, err)
+	testutil.Equals(t, "second", string(data))
+	info, err := os.Stat(path)
+	testutil.Ok(t, err)
+	testutil.Equals(t, os.FileMode(0600), info.Mode().Perm())
+	_, err = os.Stat(path + ".tmp")
+	testutil.Assert(t, os.IsNotExist(err), "the temp file should be renamed")
+}
@@ -0,0 +1,39 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+//go:build !windows
+// +build !windows
+
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// Lock blocks until the process holds the exclusive lock of the lock file,
+// so the processes sharing a state file can read, update and write it in turn.
+// The kernel releases the lock when the process exits.
+func Lock(path string) (func() error, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return nil, errors.Wrap(err, "creating the dir")
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening the lock file")
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, errors.Wrap(err, "locking the file")
+	}
+	return func() error {
+		if err := syscall.Flock(int(f.Fd()), syscall.LOCK_UN); err != nil {
+			f.Close()
+			return errors.Wrap(err, "unlocking the file")
+		}
+		return f.Close()
+	}, nil
+}
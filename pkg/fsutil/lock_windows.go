@@ -0,0 +1,37 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/windows"
+)
+
+// Lock blocks until the process holds the exclusive lock of the lock file,
+// so the processes sharing a state file can read, update and write it in turn.
+// The system releases the lock when the process exits.
+func Lock(path string) (func() error, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return nil, errors.Wrap(err, "creating the dir")
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening the lock file")
+	}
+	// Locks the first byte which is enough as all processes lock the same range.
+	if err := windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, &windows.Overlapped{}); err != nil {
+		f.Close()
+		return nil, errors.Wrap(err, "locking the file")
+	}
+	return func() error {
+		if err := windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, &windows.Overlapped{}); err != nil {
+			f.Close()
+			return errors.Wrap(err, "unlocking the file")
+		}
+		return f.Close()
+	}, nil
+}
@@ -0,0 +1,224 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package gateway
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/web"
+)
+
+const ComponentName = "gateway"
+
+type Config struct {
+	Enabled  bool
+	LogLevel string
+	// CacheTTL is how long a value is served before the contract is read again.
+	CacheTTL format.Duration
+	// RequestIDs are returned when the API request doesn't select any.
+	RequestIDs []int64
+	// MaxRequestIDs limits the request IDs of a single API request.
+	MaxRequestIDs int
+}
+
+// Value is the current on-chain value of a request ID.
+type Value struct {
+	RequestID int64
+	QueryID   string
+	// Count is the number of values of the request ID, the others are empty when 0.
+	Count     int64
+	Value     *big.Int        `json:",omitempty"`
+	Timestamp *time.Time      `json:",omitempty"`
+	Reporter  *common.Address `json:",omitempty"`
+	// Read is when the value was read from the contract.
+	Read time.Time
+	Err  string `json:",omitempty"`
+}
+
+type entry struct {
+	done  chan struct{}
+	value Value
+	err   error
+}
+
+// Gateway reads the current values of the oracle contract and caches them
+// so that many API clients cost a single contract read per request ID and TTL.
+// Concurrent requests for the same request ID wait for a single read and errors are not cached.
+// It is safe for concurrent use.
+type Gateway struct {
+	logger    log.Logger
+	cfg       Config
+	reader    contracts.ValueReader
+	reporters contracts.ReporterReader
+	mtx       sync.Mutex
+	entries   map[int64]*entry
+	reads     *prometheus.CounterVec
+}
+
+// New creates a gateway for the reader.
+// The reporters are optional as not all contract versions have them.
+func New(logger log.Logger, cfg Config, reader contracts.ValueReader, reporters contracts.ReporterReader) (*Gateway, error) {
+	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	logger = log.With(logger, "component", ComponentName)
+
+	return &Gateway{
+		logger:    logger,
+		cfg:       cfg,
+		reader:    reader,
+		reporters: reporters,
+		entries:   make(map[int64]*entry),
+		reads: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "reads_total",
+			Help:      "The total number of the current values served by whether they were cached",
+		},
+			[]string{"cached"},
+		),
+	}, nil
+}
+
+// Current returns the last value of the request ID from the cache
+// or reads it from the contract when it is older than the TTL.
+func (self *Gateway) Current(ctx context.Context, requestID int64, now time.Time) (Value, error) {
+	self.mtx.Lock()
+	e, ok := self.entries[requestID]
+	if ok {
+		select {
+		case <-e.done:
+			if now.Sub(e.value.Read) > self.cfg.CacheTTL.Duration {
+				ok = false
+			}
+		default:
+		}
+	}
+	if !ok {
+		e = &entry{done: make(chan struct{})}
+		self.entries[requestID] = e
+	}
+	self.mtx.Unlock()
+
+	if ok {
+		self.reads.With(prometheus.Labels{"cached": "true"}).Inc()
+		<-e.done
+	} else {
+		self.reads.With(prometheus.Labels{"cached": "false"}).Inc()
+		e.value, e.err = self.read(ctx, requestID, now)
+		close(e.done)
+		if e.err != nil {
+			self.mtx.Lock()
+			if self.entries[requestID] == e {
+				delete(self.entries, requestID)
+			}
+			self.mtx.Unlock()
+		}
+	}
+	return e.value, e.err
+}
+
+func (self *Gateway) read(ctx context.Context, requestID int64, now time.Time) (Value, error) {
+	queryID := contracts.LegacyQueryID(requestID)
+	v := Value{
+		RequestID: requestID,
+		QueryID:   hexutil.Encode(queryID[:]),
+		Read:      now,
+	}
+	count, err := self.reader.ValueCount(ctx, queryID)
+	if err != nil {
+		return v, errors.Wrap(err, "getting the value count")
+	}
+	v.Count = count
+	if count == 0 {
+		return v, nil
+	}
+	ts, err := self.reader.TimestampByIndex(ctx, queryID, count-1)
+	if err != nil {
+		return v, errors.Wrap(err, "getting the last timestamp")
+	}
+	v.Timestamp = &ts
+	v.Value, err = self.reader.Value(ctx, queryID, ts)
+	if err != nil {
+		return v, errors.Wrapf(err, "getting the value timestamp:%v", ts)
+	}
+	if self.reporters != nil {
+		reporter, err := self.reporters.Reporter(ctx, queryID, ts)
+		if err != nil {
+			return v, errors.Wrapf(err, "getting the reporter timestamp:%v", ts)
+		}
+		v.Reporter = &reporter
+	}
+	return v, nil
+}
+
+// ServeHTTP returns the current values of the request IDs of the id parameters
+// or of the configured ones without any.
+// A failed read is returned in the error of its value so that it doesn't fail the others.
+func (self *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var resp []Value
+	code, err := func() (int, error) {
+		ids, err := parseIDs(r.URL.Query()["id"])
+		if err != nil {
+			return http.StatusBadRequest, err
+		}
+		if len(ids) == 0 {
+			ids = self.cfg.RequestIDs
+		}
+		if len(ids) == 0 {
+			return http.StatusBadRequest, errors.New("no request IDs selected")
+		}
+		if len(ids) > self.cfg.MaxRequestIDs {
+			return http.StatusBadRequest, errors.Errorf("too many request IDs:%v max:%v", len(ids), self.cfg.MaxRequestIDs)
+		}
+		now := time.Now()
+		for _, id := range ids {
+			v, err := self.Current(r.Context(), id, now)
+			if err != nil {
+				level.Error(self.logger).Log("msg", "reading the current value", "id", id, "err", err)
+				v.Err = err.Error()
+			}
+			resp = append(resp, v)
+		}
+		return http.StatusOK, nil
+	}()
+	if err := web.WriteJSON(w, code, resp, err); err != nil {
+		level.Error(self.logger).Log("msg", "encoding current values response", "err", err)
+	}
+}
+
+// parseIDs accepts repeated and comma separated IDs e.g. id=1&id=2,3.
+func parseIDs(params []string) ([]int64, error) {
+	var ids []int64
+	for _, p := range params {
+		for _, s := range strings.Split(p, ",") {
+			if s == "" {
+				continue
+			}
+			id, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+			if err != nil || id <= 0 {
+				return nil, errors.Errorf("invalid request ID:%v", s)
+			}
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,109 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+type fakeReader struct {
+	mtx    sync.Mutex
+	reads  int
+	values map[[32]byte][]*big.Int
+	fail   bool
+}
+
+func (self *fakeReader) ValueCount(ctx context.Context, queryID [32]byte) (int64, error) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	self.reads++
+	if self.fail {
+		return 0, errors.New("node down")
+	}
+	return int64(len(self.values[queryID])), nil
+}
+
+func (self *fakeReader) TimestampByIndex(ctx context.Context, queryID [32]byte, index int64) (time.Time, error) {
+	return time.Unix(1600000000+index, 0), nil
+}
+
+func (self *fakeReader) Value(ctx context.Context, queryID [32]byte, ts time.Time) (*big.Int, error) {
+	return self.values[queryID][ts.Unix()-1600000000], nil
+}
+
+func (self *fakeReader) Reporter(ctx context.Context, queryID [32]byte, ts time.Time) (common.Address, error) {
+	return common.BigToAddress(big.NewInt(ts.Unix())), nil
+}
+
+func TestCurrent(t *testing.T) {
+	reader := &fakeReader{values: map[[32]byte][]*big.Int{
+		contracts.LegacyQueryID(1): {big.NewInt(10), big.NewInt(20)},
+	}}
+	cfg := Config{
+		LogLevel:      "info",
+		CacheTTL:      format.Duration{Duration: time.Minute},
+		RequestIDs:    []int64{1, 2},
+		MaxRequestIDs: 2,
+	}
+	gw, err := New(logging.NewLogger(), cfg, reader, reader)
+	testutil.Ok(t, err)
+
+	now := time.Now()
+	v, err := gw.Current(context.Background(), 1, now)
+	testutil.Ok(t, err)
+	testutil.Equals(t, int64(2), v.Count)
+	testutil.Equals(t, big.NewInt(20), v.Value)
+	testutil.Equals(t, time.Unix(1600000001, 0), *v.Timestamp)
+	testutil.Equals(t, common.BigToAddress(big.NewInt(1600000001)), *v.Reporter)
+
+	// Served from the cache within the TTL.
+	_, err = gw.Current(context.Background(), 1, now.Add(30*time.Second))
+	testutil.Ok(t, err)
+	testutil.Equals(t, 1, reader.reads)
+	_, err = gw.Current(context.Background(), 1, now.Add(2*time.Minute))
+	testutil.Ok(t, err)
+	testutil.Equals(t, 2, reader.reads)
+
+	// Errors are not cached.
+	reader.fail = true
+	_, err = gw.Current(context.Background(), 3, now)
+	testutil.NotOk(t, err)
+	reader.fail = false
+	v, err = gw.Current(context.Background(), 3, now)
+	testutil.Ok(t, err)
+	testutil.Equals(t, int64(0), v.Count)
+	testutil.Assert(t, v.Value == nil, "a value for a request ID without values")
+
+	// The configured request IDs without any in the request.
+	var resp struct {
+		Data []Value
+	}
+	rec := httptest.NewRecorder()
+	gw.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	testutil.Equals(t, http.StatusOK, rec.Code)
+	testutil.Ok(t, json.NewDecoder(rec.Body).Decode(&resp))
+	testutil.Equals(t, 2, len(resp.Data))
+	testutil.Equals(t, big.NewInt(20), resp.Data[0].Value)
+
+	rec = httptest.NewRecorder()
+	gw.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?id=1,2&id=3", nil))
+	testutil.Equals(t, http.StatusBadRequest, rec.Code)
+	rec = httptest.NewRecorder()
+	gw.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?id=x", nil))
+	testutil.Equals(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,86 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package health
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrStarting is returned by checks for components that
+// haven't completed their first successful run yet.
+// Such components are considered alive but not ready.
+var ErrStarting = errors.New("starting")
+
+// Status is the health of a single component.
+type Status struct {
+	Name     string
+	Critical bool
+	// Healthy is false when the component is degraded.
+	Healthy bool
+	// Ready is false when the component can't serve its purpose yet.
+	Ready   bool
+	Message string `json:",omitempty"`
+}
+
+// Reporter reports the health of one or more components.
+type Reporter interface {
+	Health(context.Context) []Status
+}
+
+// Check is a Reporter for a single component.
+type Check struct {
+	Name     string
+	Critical bool
+	Fn       func(context.Context) error
+}
+
+func NewCheck(name string, critical bool, fn func(context.Context) error) *Check {
+	return &Check{Name: name, Critical: critical, Fn: fn}
+}
+
+func (self *Check) Health(ctx context.Context) []Status {
+	status := Status{Name: self.Name, Critical: self.Critical, Healthy: true, Ready: true}
+	if err := self.Fn(ctx); err != nil {
+		status.Message = err.Error()
+		status.Ready = false
+		status.Healthy = errors.Is(err, ErrStarting)
+	}
+	return []Status{status}
+}
+
+// Freshness creates a check that fails when the last success is older than maxAge.
+func Freshness(name string, critical bool, last func() time.Time, maxAge time.Duration) *Check {
+	return NewCheck(name, critical, func(context.Context) error {
+		lastSuccess := last()
+		if lastSuccess.IsZero() {
+			return ErrStarting
+		}
+		if age := time.Since(lastSuccess); age > maxAge {
+			return errors.Errorf("last success %v ago, max allowed:%v", age.Round(time.Second), maxAge)
+		}
+		return nil
+	})
+}
+
+// Timestamp records the time of the last success.
+// It is safe for concurrent use.
+type Timestamp struct {
+	nanos int64
+}
+
+func (self *Timestamp) Set(t time.Time) {
+	atomic.StoreInt64(&self.nanos, t.UnixNano())
+}
+
+func (self *Timestamp) Get() time.Time {
+	nanos := atomic.LoadInt64(&self.nanos)
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
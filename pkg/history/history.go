@@ -0,0 +1,312 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package history
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	eth "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/contracts/tellor"
+	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// Event names as in the contract.
+const (
+	EventNonceSubmitted = "NonceSubmitted"
+	EventNewDispute     = "NewDispute"
+	EventReward         = "Transfer"
+)
+
+// Supported export formats.
+const (
+	FormatCSV     = "csv"
+	FormatParquet = "parquet"
+)
+
+// Record is a single event flattened so that all events fit in the same table.
+// The fields that don't apply to an event are left empty.
+type Record struct {
+	Block    int64  `parquet:"name=block, type=INT64"`
+	Time     int64  `parquet:"name=time, type=INT64"`
+	TxHash   string `parquet:"name=tx_hash, type=BYTE_ARRAY, convertedtype=UTF8"`
+	LogIndex int64  `parquet:"name=log_index, type=INT64"`
+	Event    string `parquet:"name=event, type=BYTE_ARRAY, convertedtype=UTF8"`
+	// Address is the miner for submits and disputes and the receiver for rewards.
+	Address string `parquet:"name=address, type=BYTE_ARRAY, convertedtype=UTF8"`
+	// RequestIDs and Values are space separated.
+	RequestIDs string `parquet:"name=request_ids, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Values     string `parquet:"name=values, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Slot       int64  `parquet:"name=slot, type=INT64"`
+	DisputeID  int64  `parquet:"name=dispute_id, type=INT64"`
+	// Amount is the reward in TRB wei.
+	Amount string `parquet:"name=amount, type=BYTE_ARRAY, convertedtype=UTF8"`
+}
+
+var csvHeader = []string{"block", "time", "tx_hash", "log_index", "event", "address", "request_ids", "values", "slot", "dispute_id", "amount"}
+
+func (r Record) csv() []string {
+	return []string{
+		strconv.FormatInt(r.Block, 10),
+		strconv.FormatInt(r.Time, 10),
+		r.TxHash,
+		strconv.FormatInt(r.LogIndex, 10),
+		r.Event,
+		r.Address,
+		r.RequestIDs,
+		r.Values,
+		strconv.FormatInt(r.Slot, 10),
+		strconv.FormatInt(r.DisputeID, 10),
+		r.Amount,
+	}
+}
+
+// Writer writes the records in one of the export formats.
+type Writer interface {
+	Write(Record) error
+	// Close flushes the buffered records.
+	Close() error
+}
+
+// NewWriter creates a writer for the given format.
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return nil, errors.Wrap(err, "writing the csv header")
+		}
+		return &csvWriter{w: cw}, nil
+	case FormatParquet:
+		pw, err := writer.NewParquetWriterFromWriter(w, new(Record), 1)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating the parquet writer")
+		}
+		pw.CompressionType = parquet.CompressionCodec_SNAPPY
+		return &parquetWriter{w: pw}, nil
+	default:
+		return nil, errors.Errorf("unknown format:%v", format)
+	}
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (self *csvWriter) Write(r Record) error {
+	return self.w.Write(r.csv())
+}
+
+func (self *csvWriter) Close() error {
+	self.w.Flush()
+	return self.w.Error()
+}
+
+type parquetWriter struct {
+	w *writer.ParquetWriter
+}
+
+func (self *parquetWriter) Write(r Record) error {
+	return self.w.Write(r)
+}
+
+func (self *parquetWriter) Close() error {
+	return self.w.WriteStop()
+}
+
+// Exporter scans a block range for the mining events and writes them as records.
+type Exporter struct {
+	client   contracts.ETHClient
+	fetcher  *ethereum.LogFetcher
+	filterer *tellor.TellorFilterer
+	contract common.Address
+	abi      abi.ABI
+	// times caches the block times of the current chunk.
+	times map[uint64]int64
+}
+
+func NewExporter(client contracts.ETHClient, fetcher *ethereum.LogFetcher, contract common.Address) (*Exporter, error) {
+	filterer, err := tellor.NewTellorFilterer(contract, client)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting instance")
+	}
+	abi, err := abi.JSON(strings.NewReader(tellor.TellorABI))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse abi")
+	}
+	return &Exporter{
+		client:   client,
+		fetcher:  fetcher,
+		filterer: filterer,
+		contract: contract,
+		abi:      abi,
+	}, nil
+}
+
+// Export writes all events between the from and to blocks inclusive.
+// The records of each chunk are written in block order.
+func (self *Exporter) Export(ctx context.Context, w Writer, from, to uint64, progress func(block uint64, count int)) (int, error) {
+	query := eth.FilterQuery{
+		Addresses: []common.Address{self.contract},
+		Topics: [][]common.Hash{{
+			self.abi.Events[EventNonceSubmitted].ID,
+			self.abi.Events[EventNewDispute].ID,
+		}},
+	}
+	// The rewards are minted so are transfers from the zero address.
+	rewardsQuery := eth.FilterQuery{
+		Addresses: []common.Address{self.contract},
+		Topics:    [][]common.Hash{{self.abi.Events[EventReward].ID}, {{}}},
+	}
+
+	var total int
+	err := self.fetcher.Walk(ctx, query, from, to, func(start, end uint64, logs []types.Log) error {
+		rewards, err := self.fetcher.FilterLogs(ctx, rewardsQuery, start, end)
+		if err != nil {
+			return errors.Wrap(err, "filter transfer events")
+		}
+		records, err := self.records(ctx, append(logs, rewards...))
+		if err != nil {
+			return errors.Wrapf(err, "exporting blocks:%v-%v", start, end)
+		}
+		for _, r := range records {
+			if err := w.Write(r); err != nil {
+				return errors.Wrap(err, "writing record")
+			}
+		}
+		total += len(records)
+		if progress != nil {
+			progress(end, total)
+		}
+		return nil
+	})
+	return total, err
+}
+
+func (self *Exporter) records(ctx context.Context, logs []types.Log) ([]Record, error) {
+	self.times = make(map[uint64]int64)
+	sort.Slice(logs, func(i, j int) bool {
+		if logs[i].BlockNumber != logs[j].BlockNumber {
+			return logs[i].BlockNumber < logs[j].BlockNumber
+		}
+		return logs[i].Index < logs[j].Index
+	})
+
+	records := make([]Record, 0, len(logs))
+	for _, l := range logs {
+		var r Record
+		var err error
+		switch l.Topics[0] {
+		case self.abi.Events[EventNonceSubmitted].ID:
+			r, err = self.nonceSubmitted(ctx, l)
+		case self.abi.Events[EventNewDispute].ID:
+			r, err = self.newDispute(ctx, l)
+		case self.abi.Events[EventReward].ID:
+			r, err = self.reward(ctx, l)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+func (self *Exporter) nonceSubmitted(ctx context.Context, l types.Log) (Record, error) {
+	e, err := self.filterer.ParseNonceSubmitted(l)
+	if err != nil {
+		return Record{}, errors.Wrap(err, "parse nonce submitted event")
+	}
+	r, err := self.record(ctx, l, EventNonceSubmitted)
+	if err != nil {
+		return Record{}, err
+	}
+	r.Address = e.Miner.Hex()
+	r.RequestIDs = join(e.RequestId[:])
+	r.Values = join(e.Value[:])
+	if e.Slot != nil {
+		r.Slot = e.Slot.Int64()
+	}
+	return r, nil
+}
+
+func (self *Exporter) newDispute(ctx context.Context, l types.Log) (Record, error) {
+	e, err := self.filterer.ParseNewDispute(l)
+	if err != nil {
+		return Record{}, errors.Wrap(err, "parse dispute event")
+	}
+	r, err := self.record(ctx, l, EventNewDispute)
+	if err != nil {
+		return Record{}, err
+	}
+	r.Address = e.Miner.Hex()
+	r.RequestIDs = e.RequestId.String()
+	r.DisputeID = e.DisputeId.Int64()
+	return r, nil
+}
+
+func (self *Exporter) reward(ctx context.Context, l types.Log) (Record, error) {
+	e, err := self.filterer.ParseTransferred(l)
+	if err != nil {
+		return Record{}, errors.Wrap(err, "parse transfer event")
+	}
+	r, err := self.record(ctx, l, EventReward)
+	if err != nil {
+		return Record{}, err
+	}
+	r.Address = e.To.Hex()
+	r.Amount = e.Value.String()
+	return r, nil
+}
+
+func (self *Exporter) record(ctx context.Context, log types.Log, event string) (Record, error) {
+	ts, err := self.blockTime(ctx, log.BlockNumber)
+	if err != nil {
+		return Record{}, err
+	}
+	return Record{
+		Block:    int64(log.BlockNumber),
+		Time:     ts,
+		TxHash:   log.TxHash.Hex(),
+		LogIndex: int64(log.Index),
+		Event:    event,
+	}, nil
+}
+
+// blockTime returns the time of a block, cached as all events of a block share it.
+func (self *Exporter) blockTime(ctx context.Context, block uint64) (int64, error) {
+	if ts, ok := self.times[block]; ok {
+		return ts, nil
+	}
+	header, err := self.client.HeaderByNumber(ctx, new(big.Int).SetUint64(block))
+	if err != nil {
+		return 0, errors.Wrapf(err, "getting header for block:%v", block)
+	}
+	self.times[block] = int64(header.Time)
+	return int64(header.Time), nil
+}
+
+func join(vals []*big.Int) string {
+	s := make([]string, len(vals))
+	for i, v := range vals {
+		if v != nil {
+			s[i] = v.String()
+		}
+	}
+	return strings.Join(s, " ")
+}
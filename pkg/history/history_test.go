@@ -0,0 +1,44 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package history
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+func TestWriter(t *testing.T) {
+	records := []Record{
+		{Block: 1, Time: 10, TxHash: "0x1", Event: EventNonceSubmitted, Address: "0xa", RequestIDs: "1 2 3 4 5", Values: "6 7 8 9 10", Slot: 2},
+		{Block: 2, Time: 20, TxHash: "0x2", LogIndex: 1, Event: EventReward, Address: "0xa", Amount: "1000"},
+	}
+
+	buf := new(bytes.Buffer)
+	w, err := NewWriter(buf, FormatCSV)
+	testutil.Ok(t, err)
+	for _, r := range records {
+		testutil.Ok(t, w.Write(r))
+	}
+	testutil.Ok(t, w.Close())
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	testutil.Equals(t, 3, len(lines))
+	testutil.Equals(t, strings.Join(csvHeader, ","), lines[0])
+	testutil.Equals(t, "1,10,0x1,0,NonceSubmitted,0xa,1 2 3 4 5,6 7 8 9 10,2,0,", lines[1])
+	testutil.Equals(t, "2,20,0x2,1,Transfer,0xa,,,0,0,1000", lines[2])
+
+	buf.Reset()
+	w, err = NewWriter(buf, FormatParquet)
+	testutil.Ok(t, err)
+	for _, r := range records {
+		testutil.Ok(t, w.Write(r))
+	}
+	testutil.Ok(t, w.Close())
+	testutil.Equals(t, "PAR1", buf.String()[:4])
+
+	_, err = NewWriter(buf, "json")
+	testutil.NotOk(t, err)
+}
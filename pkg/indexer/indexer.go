@@ -0,0 +1,244 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package indexer
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/history"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/watchdog"
+	"github.com/tellor-io/telliot/pkg/web"
+)
+
+const ComponentName = "indexer"
+
+type Config struct {
+	Enabled  bool
+	LogLevel string
+	// Path of the file with the indexed events.
+	Path     string
+	Interval format.Duration
+	// FromBlock is where an empty index starts, the latest block when 0.
+	FromBlock uint64
+	// Confirmations are the blocks after which an event is indexed
+	// so that the reorged events are never indexed.
+	Confirmations uint64
+	// MaxResults limits the events returned by a single query.
+	MaxResults int
+}
+
+var indexedBlock = promauto.NewGauge(prometheus.GaugeOpts{
+	Namespace: "telliot",
+	Subsystem: ComponentName,
+	Name:      "indexed_block",
+	Help:      "The last block up to which all events are indexed",
+})
+
+// QueryResponse is a page of the matching events.
+type QueryResponse struct {
+	// Indexed is the last block up to which all events are indexed.
+	Indexed uint64
+	Total   int
+	Events  []history.Record
+}
+
+// Indexer continuously records the submit, dispute and reward events of the contract
+// and serves them for queries.
+type Indexer struct {
+	ctx      context.Context
+	close    context.CancelFunc
+	logger   log.Logger
+	watchdog *watchdog.Watchdog
+	cfg      Config
+	client   contracts.ETHClient
+	exporter *history.Exporter
+	store    *Store
+}
+
+func New(
+	logger log.Logger,
+	ctx context.Context,
+	cfg Config,
+	client contracts.ETHClient,
+	fetcher *ethereum.LogFetcher,
+	contract common.Address,
+	store *Store,
+	watchdog *watchdog.Watchdog,
+) (*Indexer, error) {
+	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	logger = log.With(logger, "component", ComponentName)
+
+	exporter, err := history.NewExporter(client, fetcher, contract)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating exporter")
+	}
+	ctx, close := context.WithCancel(ctx)
+	return &Indexer{
+		ctx:      ctx,
+		close:    close,
+		logger:   logger,
+		watchdog: watchdog,
+		cfg:      cfg,
+		client:   client,
+		exporter: exporter,
+		store:    store,
+	}, nil
+}
+
+func (self *Indexer) Start() error {
+	level.Info(self.logger).Log("msg", "starting", "indexed", self.store.LastIndexed())
+
+	ticker := time.NewTicker(self.cfg.Interval.Duration)
+	defer ticker.Stop()
+	for {
+		stop := self.watchdog.Watch(ComponentName)
+		if err := self.index(); err != nil {
+			level.Error(self.logger).Log("msg", "indexing events", "err", err)
+		}
+		stop()
+		select {
+		case <-self.ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (self *Indexer) Stop() {
+	self.close()
+}
+
+// index records the events of the confirmed blocks since the last indexed block.
+func (self *Indexer) index() error {
+	header, err := self.client.HeaderByNumber(self.ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "get latest eth block header")
+	}
+	if header.Number.Uint64() < self.cfg.Confirmations {
+		return nil
+	}
+	to := header.Number.Uint64() - self.cfg.Confirmations
+
+	from := self.store.LastIndexed() + 1
+	if self.store.LastIndexed() == 0 {
+		from = self.cfg.FromBlock
+		if from == 0 {
+			from = to
+		}
+	}
+	if from > to {
+		return nil
+	}
+
+	w := &storeWriter{store: self.store}
+	count, err := self.exporter.Export(self.ctx, w, from, to, func(block uint64, count int) {
+		if err := self.store.Indexed(block); err != nil {
+			w.err = err
+			return
+		}
+		indexedBlock.Set(float64(block))
+	})
+	if err != nil {
+		return err
+	}
+	if w.err != nil {
+		return w.err
+	}
+	level.Debug(self.logger).Log("msg", "indexed", "from", from, "to", to, "events", count)
+	return nil
+}
+
+// storeWriter records the exported events in the store.
+type storeWriter struct {
+	store *Store
+	err   error
+}
+
+func (self *storeWriter) Write(r history.Record) error {
+	if self.err != nil {
+		return self.err
+	}
+	return self.store.Record(r)
+}
+
+func (self *storeWriter) Close() error {
+	return nil
+}
+
+// ServeHTTP returns the events that match the query parameters.
+func (self *Indexer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var resp QueryResponse
+	code, err := func() (int, error) {
+		q, err := parseQuery(r, self.cfg.MaxResults)
+		if err != nil {
+			return http.StatusBadRequest, err
+		}
+		resp.Events, resp.Total = self.store.Query(q)
+		resp.Indexed = self.store.LastIndexed()
+		return http.StatusOK, nil
+	}()
+	if err := web.WriteJSON(w, code, resp, err); err != nil {
+		level.Error(self.logger).Log("msg", "encoding events response", "err", err)
+	}
+}
+
+func parseQuery(r *http.Request, maxResults int) (Query, error) {
+	params := r.URL.Query()
+	q := Query{Event: params.Get("event"), Limit: maxResults}
+	if a := params.Get("address"); a != "" {
+		if !common.IsHexAddress(a) {
+			return q, errors.Errorf("invalid address:%v", a)
+		}
+		addr := common.HexToAddress(a)
+		q.Address = &addr
+	}
+
+	var n [7]int64
+	for i, name := range []string{"id", "fromBlock", "toBlock", "from", "to", "offset", "limit"} {
+		v := params.Get(name)
+		if v == "" {
+			continue
+		}
+		var err error
+		n[i], err = strconv.ParseInt(v, 10, 64)
+		if err != nil || n[i] < 0 {
+			return q, errors.Errorf("invalid %v:%v", name, v)
+		}
+	}
+	id, fromBlock, toBlock, from, to, offset, limit := n[0], n[1], n[2], n[3], n[4], n[5], n[6]
+
+	q.RequestID = id
+	q.FromBlock = uint64(fromBlock)
+	q.ToBlock = uint64(toBlock)
+	if from > 0 {
+		q.From = time.Unix(from, 0)
+	}
+	if to > 0 {
+		q.To = time.Unix(to, 0)
+	}
+	q.Offset = int(offset)
+	if limit > int64(maxResults) {
+		return q, errors.Errorf("limit:%v is more than the max results:%v", limit, maxResults)
+	}
+	if limit > 0 {
+		q.Limit = int(limit)
+	}
+	return q, nil
+}
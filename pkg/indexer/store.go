@@ -0,0 +1,264 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package indexer
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/history"
+)
+
+// line is a single line of the store file
+// which is either an event or the last indexed block.
+type line struct {
+	Record  *history.Record `json:",omitempty"`
+	Indexed uint64          `json:",omitempty"`
+}
+
+// Query selects the events, the zero fields match all events.
+type Query struct {
+	Event     string
+	Address   *common.Address
+	RequestID int64
+	FromBlock uint64
+	// ToBlock is inclusive.
+	ToBlock uint64
+	From    time.Time
+	// To is inclusive.
+	To     time.Time
+	Offset int
+	Limit  int
+}
+
+// Store keeps the indexed events in an append only JSON lines file
+// and in memory with indexes by address and request ID.
+// The events are appended in block order and
+// the events recorded again after a crash are skipped.
+// It is safe for concurrent use.
+type Store struct {
+	mtx         sync.Mutex
+	file        *os.File
+	records     []history.Record
+	seen        map[string]bool
+	byAddress   map[common.Address][]int
+	byRequestID map[int64][]int
+	indexed     uint64
+}
+
+func OpenStore(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return nil, errors.Wrap(err, "creating indexer folder")
+	}
+	self := &Store{
+		seen:        make(map[string]bool),
+		byAddress:   make(map[common.Address][]int),
+		byRequestID: make(map[int64][]int),
+	}
+	if err := self.replay(path); err != nil {
+		return nil, errors.Wrap(err, "replay indexer file")
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+	if err != nil {
+		return nil, errors.Wrap(err, "open indexer file")
+	}
+	self.file = f
+	return self, nil
+}
+
+func (self *Store) replay(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "open indexer file")
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		l := line{}
+		// A partial last line is expected when crashing in the middle of a write.
+		if err := json.Unmarshal(scanner.Bytes(), &l); err != nil {
+			continue
+		}
+		self.apply(l)
+	}
+	return scanner.Err()
+}
+
+func (self *Store) apply(l line) {
+	if l.Indexed > self.indexed {
+		self.indexed = l.Indexed
+	}
+	if l.Record == nil {
+		return
+	}
+	r := *l.Record
+	self.seen[key(r)] = true
+	pos := len(self.records)
+	self.records = append(self.records, r)
+	if r.Address != "" {
+		addr := common.HexToAddress(r.Address)
+		self.byAddress[addr] = append(self.byAddress[addr], pos)
+	}
+	for _, id := range requestIDs(r) {
+		self.byRequestID[id] = append(self.byRequestID[id], pos)
+	}
+}
+
+// Record appends the events that aren't recorded yet.
+func (self *Store) Record(records ...history.Record) error {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	for _, r := range records {
+		if self.seen[key(r)] {
+			continue
+		}
+		r := r
+		if err := self.write(line{Record: &r}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Indexed records that all events up to the block are recorded.
+func (self *Store) Indexed(block uint64) error {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	if err := self.write(line{Indexed: block}); err != nil {
+		return err
+	}
+	return errors.Wrap(self.file.Sync(), "sync indexer file")
+}
+
+func (self *Store) write(l line) error {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return errors.Wrap(err, "marshal indexer line")
+	}
+	if _, err := self.file.Write(append(b, '\n')); err != nil {
+		return errors.Wrap(err, "write indexer line")
+	}
+	self.apply(l)
+	return nil
+}
+
+// LastIndexed returns the last block up to which all events are recorded.
+func (self *Store) LastIndexed() uint64 {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	return self.indexed
+}
+
+// Query returns the page of the matching events in block order
+// and the total number of the matching events.
+func (self *Store) Query(q Query) ([]history.Record, int) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	// The positions of the smallest index that applies, all events without one.
+	var positions []int
+	indexed := false
+	if q.Address != nil {
+		positions, indexed = self.byAddress[*q.Address], true
+	}
+	if q.RequestID > 0 {
+		if byID := self.byRequestID[q.RequestID]; !indexed || len(byID) < len(positions) {
+			positions, indexed = byID, true
+		}
+	}
+	count := len(self.records)
+	if indexed {
+		count = len(positions)
+	}
+	// The events are in block order so the scan starts at the first block of the range.
+	start := sort.Search(count, func(i int) bool {
+		return self.at(positions, indexed, i).Block >= int64(q.FromBlock)
+	})
+
+	var page []history.Record
+	var total int
+	for i := start; i < count; i++ {
+		r := self.at(positions, indexed, i)
+		if q.ToBlock > 0 && r.Block > int64(q.ToBlock) {
+			break
+		}
+		if !q.matches(r) {
+			continue
+		}
+		if total >= q.Offset && (q.Limit <= 0 || len(page) < q.Limit) {
+			page = append(page, r)
+		}
+		total++
+	}
+	return page, total
+}
+
+func (self *Store) at(positions []int, indexed bool, i int) history.Record {
+	if indexed {
+		return self.records[positions[i]]
+	}
+	return self.records[i]
+}
+
+func (self *Store) Close() error {
+	return self.file.Close()
+}
+
+func (self Query) matches(r history.Record) bool {
+	if self.Event != "" && !strings.EqualFold(self.Event, r.Event) {
+		return false
+	}
+	if self.Address != nil && common.HexToAddress(r.Address) != *self.Address {
+		return false
+	}
+	if self.RequestID > 0 && !containsID(requestIDs(r), self.RequestID) {
+		return false
+	}
+	if !self.From.IsZero() && r.Time < self.From.Unix() {
+		return false
+	}
+	if !self.To.IsZero() && r.Time > self.To.Unix() {
+		return false
+	}
+	return true
+}
+
+func key(r history.Record) string {
+	return r.TxHash + ":" + strconv.FormatInt(r.LogIndex, 10)
+}
+
+// requestIDs parses the space separated request IDs of a record.
+func requestIDs(r history.Record) []int64 {
+	var ids []int64
+	for _, s := range strings.Fields(r.RequestIDs) {
+		if id, err := strconv.ParseInt(s, 10, 64); err == nil && !containsID(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func containsID(ids []int64, id int64) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
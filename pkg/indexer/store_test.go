@@ -0,0 +1,87 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package indexer
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tellor-io/telliot/pkg/history"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "indexer")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "events.jsonl")
+
+	miner1 := common.HexToAddress("0x1")
+	miner2 := common.HexToAddress("0x2")
+	records := []history.Record{
+		{Block: 10, Time: 1000, TxHash: "0xa", LogIndex: 0, Event: history.EventNonceSubmitted, Address: miner1.Hex(), RequestIDs: "1 2 3 4 5"},
+		{Block: 10, Time: 1000, TxHash: "0xa", LogIndex: 1, Event: history.EventReward, Address: miner1.Hex(), Amount: "100"},
+		{Block: 12, Time: 1024, TxHash: "0xb", LogIndex: 0, Event: history.EventNonceSubmitted, Address: miner2.Hex(), RequestIDs: "1 6 7 8 9"},
+		{Block: 15, Time: 1060, TxHash: "0xc", LogIndex: 3, Event: history.EventNewDispute, Address: miner2.Hex(), RequestIDs: "6", DisputeID: 1},
+	}
+
+	store, err := OpenStore(path)
+	testutil.Ok(t, err)
+	testutil.Ok(t, store.Record(records[:2]...))
+	testutil.Ok(t, store.Indexed(11))
+	testutil.Ok(t, store.Record(records[2:]...))
+	testutil.Ok(t, store.Close())
+
+	// The events recorded again after a crash are skipped.
+	store, err = OpenStore(path)
+	testutil.Ok(t, err)
+	defer store.Close()
+	testutil.Equals(t, uint64(11), store.LastIndexed())
+	testutil.Ok(t, store.Record(records[2:]...))
+	testutil.Ok(t, store.Indexed(15))
+	testutil.Equals(t, uint64(15), store.LastIndexed())
+
+	cases := []struct {
+		name  string
+		query Query
+		exp   []history.Record
+		total int
+	}{
+		{name: "all", exp: records, total: 4},
+		{name: "by miner", query: Query{Address: &miner2}, exp: records[2:], total: 2},
+		{name: "by request ID", query: Query{RequestID: 1}, exp: []history.Record{records[0], records[2]}, total: 2},
+		{name: "by miner and request ID", query: Query{Address: &miner2, RequestID: 6}, exp: records[2:], total: 2},
+		{name: "by event", query: Query{Event: "newdispute"}, exp: records[3:], total: 1},
+		{name: "by block range", query: Query{FromBlock: 11, ToBlock: 12}, exp: records[2:3], total: 1},
+		{name: "by time range", query: Query{From: time.Unix(1001, 0), To: time.Unix(1060, 0)}, exp: records[2:], total: 2},
+		{name: "page", query: Query{Offset: 1, Limit: 2}, exp: records[1:3], total: 4},
+		{name: "no match", query: Query{RequestID: 100}, total: 0},
+	}
+	for _, c := range cases {
+		page, total := store.Query(c.query)
+		testutil.Equals(t, c.exp, page, c.name)
+		testutil.Equals(t, c.total, total, c.name)
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	q, err := parseQuery(httptest.NewRequest("GET", "/?address=0x0000000000000000000000000000000000000001&id=2&fromBlock=10&to=1000&limit=5", nil), 10)
+	testutil.Ok(t, err)
+	addr := common.HexToAddress("0x1")
+	testutil.Equals(t, Query{Address: &addr, RequestID: 2, FromBlock: 10, To: time.Unix(1000, 0), Limit: 5}, q)
+
+	q, err = parseQuery(httptest.NewRequest("GET", "/", nil), 10)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 10, q.Limit)
+
+	for _, params := range []string{"limit=11", "id=x", "fromBlock=-1", "address=0x1"} {
+		_, err := parseQuery(httptest.NewRequest("GET", "/?"+params, nil), 10)
+		testutil.NotOk(t, err, params)
+	}
+}
@@ -5,6 +5,8 @@ package logging
 
 import (
 	"os"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/go-kit/kit/log"
@@ -12,27 +14,164 @@ import (
 	"github.com/pkg/errors"
 )
 
+const (
+	FormatLogfmt = "logfmt"
+	FormatJSON   = "json"
+)
+
+// ComponentKey is the key used by all components to add their name to the log lines.
+const ComponentKey = "component"
+
+var logFormat = FormatLogfmt
+
+// SetFormat sets the output format of all loggers created with NewLogger.
+func SetFormat(format string) error {
+	switch format {
+	case FormatLogfmt, FormatJSON:
+		logFormat = format
+		return nil
+	default:
+		return errors.Errorf("unexpected log format:%v", format)
+	}
+}
+
 // NewLogger create a new logger.
 func NewLogger() log.Logger {
+	if logFormat == FormatJSON {
+		logger := log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+		return log.With(logger, "ts", log.DefaultTimestampUTC, "caller", log.Caller(5))
+	}
 	logger := log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr))
 	return log.With(logger, "ts", log.TimestampFormat(func() time.Time { return time.Now().UTC() }, "jan 02 15:04:05.00"), "caller", log.Caller(5))
 }
 
+var levelRanks = map[string]int{
+	"debug": 0,
+	"info":  1,
+	"warn":  2,
+	"error": 3,
+}
+
 // ApplyFilter applies a filter to logger based on component name.
+// The configured level can be overridden at runtime with SetLevel.
 func ApplyFilter(configLevel string, logger log.Logger) (log.Logger, error) {
-	var lvl level.Option
-	switch configLevel {
-	case "error":
-		lvl = level.AllowError()
-	case "warn":
-		lvl = level.AllowWarn()
-	case "info":
-		lvl = level.AllowInfo()
-	case "debug":
-		lvl = level.AllowDebug()
-	default:
+	rank, ok := levelRanks[configLevel]
+	if !ok {
 		return nil, errors.Errorf("unexpected log level:%v", configLevel)
 	}
+	return &filter{next: logger, level: configLevel, rank: rank}, nil
+}
+
+type filter struct {
+	next  log.Logger
+	level string
+	rank  int
+}
+
+func (self *filter) Log(keyvals ...interface{}) error {
+	var (
+		component string
+		lvl       level.Value
+	)
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		switch keyvals[i] {
+		case level.Key():
+			if v, ok := keyvals[i+1].(level.Value); ok {
+				lvl = v
+			}
+		case ComponentKey:
+			// Nested components log with their own name so the last one wins.
+			if v, ok := keyvals[i+1].(string); ok {
+				component = v
+			}
+		}
+	}
+
+	rank := self.rank
+	if component != "" {
+		if override, ok := levels.seen(component, self.level); ok {
+			rank = levelRanks[override]
+		}
+	}
+	if lvl != nil && levelRanks[lvl.String()] < rank {
+		return nil
+	}
+	return self.next.Log(keyvals...)
+}
+
+var levels = &registry{
+	configured: make(map[string]string),
+	overrides:  make(map[string]string),
+}
+
+type registry struct {
+	mtx        sync.RWMutex
+	configured map[string]string
+	overrides  map[string]string
+}
+
+// seen records the configured level of the component and returns its runtime override if any.
+func (self *registry) seen(component, configured string) (string, bool) {
+	self.mtx.RLock()
+	override, ok := self.overrides[component]
+	_, known := self.configured[component]
+	self.mtx.RUnlock()
+	if !known {
+		self.mtx.Lock()
+		self.configured[component] = configured
+		self.mtx.Unlock()
+	}
+	return override, ok
+}
+
+// SetLevel overrides the configured log level of a component without a restart.
+// An empty level removes the override.
+func SetLevel(component, lvl string) error {
+	if component == "" {
+		return errors.New("missing component name")
+	}
+	levels.mtx.Lock()
+	defer levels.mtx.Unlock()
+	if lvl == "" {
+		delete(levels.overrides, component)
+		return nil
+	}
+	if _, ok := levelRanks[lvl]; !ok {
+		return errors.Errorf("unexpected log level:%v", lvl)
+	}
+	levels.overrides[component] = lvl
+	return nil
+}
+
+// ComponentLevel is the current log level of a component.
+type ComponentLevel struct {
+	Component  string `json:"component"`
+	Level      string `json:"level"`
+	Configured string `json:"configured,omitempty"`
+}
 
-	return level.NewFilter(logger, lvl), nil
+// Levels returns the current log level of all components that have logged
+// so far and of all components with an override, sorted by name.
+func Levels() []ComponentLevel {
+	levels.mtx.RLock()
+	defer levels.mtx.RUnlock()
+
+	names := make(map[string]struct{})
+	for name := range levels.configured {
+		names[name] = struct{}{}
+	}
+	for name := range levels.overrides {
+		names[name] = struct{}{}
+	}
+
+	var result []ComponentLevel
+	for name := range names {
+		l := ComponentLevel{Component: name, Configured: levels.configured[name], Level: levels.configured[name]}
+		if override, ok := levels.overrides[name]; ok {
+			l.Level = override
+		}
+		result = append(result, l)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Component < result[j].Component })
+	return result
 }
@@ -0,0 +1,47 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// TestSetLevel ensures that the log level of a single component can be changed at runtime.
+func TestSetLevel(t *testing.T) {
+	buf := &bytes.Buffer{}
+	base := log.NewLogfmtLogger(buf)
+
+	newLogger := func(component string) log.Logger {
+		logger, err := ApplyFilter("info", base)
+		testutil.Ok(t, err)
+		return log.With(logger, ComponentKey, component)
+	}
+	tracker := newLogger("tracker")
+	other := newLogger("other")
+
+	level.Debug(tracker).Log("msg", "hidden")
+	testutil.Equals(t, "", buf.String())
+
+	testutil.Ok(t, SetLevel("tracker", "debug"))
+	level.Debug(tracker).Log("msg", "visible")
+	level.Debug(other).Log("msg", "hidden")
+	testutil.Equals(t, 1, strings.Count(buf.String(), "\n"))
+	testutil.Assert(t, strings.Contains(buf.String(), "visible"), "the override should allow debug logs")
+
+	testutil.Equals(t, []ComponentLevel{
+		{Component: "other", Level: "info", Configured: "info"},
+		{Component: "tracker", Level: "debug", Configured: "info"},
+	}, Levels())
+
+	testutil.NotOk(t, SetLevel("tracker", "verbose"))
+	testutil.Ok(t, SetLevel("tracker", ""))
+	level.Debug(tracker).Log("msg", "hidden")
+	testutil.Equals(t, 1, strings.Count(buf.String(), "\n"))
+}
@@ -0,0 +1,282 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package mempool
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+)
+
+const ComponentName = "mempool"
+
+// NumSlots is how many submissions are accepted for each challenge.
+const NumSlots = 5
+
+// ErrSlotsClaimed is returned when the pending transactions
+// already claim all remaining slots for the challenge.
+var ErrSlotsClaimed = errors.New("all slots are claimed by pending transactions")
+
+type Config struct {
+	Enabled  bool
+	LogLevel string
+	Interval format.Duration
+	// NodeURL is a node that supports the txpool API.
+	// When empty the main node is used.
+	NodeURL string
+	// GasBumpPercent is how much to outbid the competing transaction
+	// with the lowest gas price that would still get a slot.
+	GasBumpPercent uint
+	// MaxGasPrice in gwei limits the outbidding.
+	// When the required gas price is higher the broadcast is aborted.
+	MaxGasPrice uint
+}
+
+// Submission is a pending submitMiningSolution transaction.
+type Submission struct {
+	From       common.Address
+	Hash       common.Hash
+	GasPrice   *big.Int
+	RequestIDs [5]*big.Int
+}
+
+// Watcher polls the txpool of a node for pending submitMiningSolution transactions
+// so that the submitters can outbid the competing transactions
+// or abort the broadcast when all slots are already claimed.
+type Watcher struct {
+	ctx      context.Context
+	close    context.CancelFunc
+	logger   log.Logger
+	cfg      Config
+	nodeURL  string
+	contract common.Address
+	method   abi.Method
+
+	mtx     sync.Mutex
+	pending []Submission
+
+	competing prometheus.Gauge
+	bumps     prometheus.Counter
+	aborts    prometheus.Counter
+}
+
+func New(logger log.Logger, ctx context.Context, cfg Config, contract common.Address, nodeURL string) (*Watcher, error) {
+	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	logger = log.With(logger, "component", ComponentName)
+
+	_abi, err := abi.JSON(strings.NewReader(contracts.ITellorABI))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse abi")
+	}
+	method, ok := _abi.Methods["submitMiningSolution"]
+	if !ok {
+		return nil, errors.New("submitMiningSolution not found in the contract abi")
+	}
+	if cfg.NodeURL != "" {
+		nodeURL = cfg.NodeURL
+	}
+
+	ctx, close := context.WithCancel(ctx)
+	return &Watcher{
+		ctx:      ctx,
+		close:    close,
+		logger:   logger,
+		cfg:      cfg,
+		nodeURL:  nodeURL,
+		contract: contract,
+		method:   method,
+		competing: promauto.NewGauge(prometheus.GaugeOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "pending_submissions",
+			Help:      "The number of pending submitMiningSolution transactions in the txpool",
+		}),
+		bumps: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "gas_bumps_total",
+			Help:      "The total number of times the gas price was increased to outbid pending submissions",
+		}),
+		aborts: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "aborts_total",
+			Help:      "The total number of broadcasts aborted because all slots were claimed by pending submissions",
+		}),
+	}, nil
+}
+
+func (self *Watcher) Start() error {
+	client, err := rpc.DialContext(self.ctx, self.nodeURL)
+	if err != nil {
+		return errors.Wrap(err, "connecting to the node")
+	}
+	defer client.Close()
+
+	level.Info(self.logger).Log("msg", "starting", "interval", self.cfg.Interval)
+
+	ticker := time.NewTicker(self.cfg.Interval.Duration)
+	defer ticker.Stop()
+	for {
+		if err := self.poll(client); err != nil {
+			level.Error(self.logger).Log("msg", "polling the txpool", "err", err)
+		}
+		select {
+		case <-self.ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (self *Watcher) Stop() {
+	self.close()
+}
+
+func (self *Watcher) poll(client *rpc.Client) error {
+	ctx, cncl := context.WithTimeout(self.ctx, self.cfg.Interval.Duration)
+	defer cncl()
+
+	var content map[string]map[common.Address]map[string]*types.Transaction
+	if err := client.CallContext(ctx, &content, "txpool_content"); err != nil {
+		return errors.Wrap(err, "txpool_content")
+	}
+
+	var pending []Submission
+	for from, txs := range content["pending"] {
+		for _, tx := range txs {
+			s, ok := self.decode(tx)
+			if !ok {
+				continue
+			}
+			s.From = from
+			pending = append(pending, s)
+		}
+	}
+
+	self.mtx.Lock()
+	self.pending = pending
+	self.mtx.Unlock()
+	self.competing.Set(float64(len(pending)))
+	level.Debug(self.logger).Log("msg", "polled the txpool", "pendingSubmissions", len(pending))
+	return nil
+}
+
+func (self *Watcher) decode(tx *types.Transaction) (Submission, bool) {
+	if tx.To() == nil || *tx.To() != self.contract {
+		return Submission{}, false
+	}
+	if len(tx.Data()) < 4 || !bytes.Equal(tx.Data()[:4], self.method.ID) {
+		return Submission{}, false
+	}
+	args, err := self.method.Inputs.Unpack(tx.Data()[4:])
+	if err != nil || len(args) != 3 {
+		level.Debug(self.logger).Log("msg", "decoding submitMiningSolution input", "hash", tx.Hash(), "err", err)
+		return Submission{}, false
+	}
+	requestIDs, ok := args[1].([5]*big.Int)
+	if !ok {
+		return Submission{}, false
+	}
+	return Submission{
+		Hash:       tx.Hash(),
+		GasPrice:   tx.GasPrice(),
+		RequestIDs: requestIDs,
+	}, true
+}
+
+// Competing returns the pending submissions from other accounts
+// for the same request IDs sorted by gas price in descending order.
+func (self *Watcher) Competing(requestIDs [5]*big.Int, own common.Address) []Submission {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	var competing []Submission
+	for _, s := range self.pending {
+		if s.From == own || !sameIDs(s.RequestIDs, requestIDs) {
+			continue
+		}
+		competing = append(competing, s)
+	}
+	sort.Slice(competing, func(i, j int) bool {
+		return competing[i].GasPrice.Cmp(competing[j].GasPrice) > 0
+	})
+	return competing
+}
+
+// GasPrice returns the gas price needed to claim one of the remaining slots
+// when competing with the pending submissions.
+// The price is limited by the lower of MaxGasPrice and the max of the caller e.g. the max of the transactor.
+// It returns ErrSlotsClaimed when even the max gas price isn't enough.
+func (self *Watcher) GasPrice(requestIDs [5]*big.Int, own common.Address, filledSlots int, gasPrice, max *big.Int) (*big.Int, error) {
+	maxGasPrice := new(big.Int).Mul(big.NewInt(int64(self.cfg.MaxGasPrice)), big.NewInt(1e9))
+	if max != nil && max.Sign() > 0 && (maxGasPrice.Sign() == 0 || max.Cmp(maxGasPrice) < 0) {
+		maxGasPrice = max
+	}
+	price, err := gasPriceForSlot(self.Competing(requestIDs, own), NumSlots-filledSlots, gasPrice, self.cfg.GasBumpPercent, maxGasPrice)
+	if err != nil {
+		self.aborts.Inc()
+		return nil, err
+	}
+	if price.Cmp(gasPrice) > 0 {
+		self.bumps.Inc()
+		level.Info(self.logger).Log("msg", "outbidding pending submissions", "gasPrice", gasPrice, "newGasPrice", price)
+	}
+	return price, nil
+}
+
+// gasPriceForSlot returns the gas price that is higher than all but
+// the highest paying competing transactions that fit in the remaining slots.
+// A zero max means no limit.
+func gasPriceForSlot(competing []Submission, slotsLeft int, gasPrice *big.Int, bumpPercent uint, max *big.Int) (*big.Int, error) {
+	if slotsLeft <= 0 {
+		return nil, errors.New("all slots are filled")
+	}
+	if len(competing) < slotsLeft {
+		return gasPrice, nil
+	}
+	// The competing tx that would get the last remaining slot.
+	lowest := competing[slotsLeft-1].GasPrice
+	if gasPrice.Cmp(lowest) > 0 {
+		return gasPrice, nil
+	}
+	price := new(big.Int).Mul(lowest, big.NewInt(int64(100+bumpPercent)))
+	price.Div(price, big.NewInt(100))
+	if price.Cmp(lowest) <= 0 {
+		price.Add(lowest, big.NewInt(1))
+	}
+	if max.Sign() > 0 && price.Cmp(max) > 0 {
+		return nil, errors.Wrapf(ErrSlotsClaimed, "required gas price:%v higher than the max:%v", price, max)
+	}
+	return price, nil
+}
+
+func sameIDs(a, b [5]*big.Int) bool {
+	for i := range a {
+		if a[i] == nil || b[i] == nil || a[i].Cmp(b[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package mempool
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// TestGasPriceForSlot ensures that the gas price outbids only the
+// competing transactions that would take the remaining slots.
+func TestGasPriceForSlot(t *testing.T) {
+	var competing []Submission
+	for _, price := range []int64{500, 400, 300, 200, 100} {
+		competing = append(competing, Submission{GasPrice: big.NewInt(price)})
+	}
+	max := big.NewInt(1000)
+
+	// Less competing transactions than slots.
+	price, err := gasPriceForSlot(competing[:2], 5, big.NewInt(50), 10, max)
+	testutil.Ok(t, err)
+	testutil.Equals(t, int64(50), price.Int64())
+
+	// Outbid the competing transaction that would get the last slot.
+	price, err = gasPriceForSlot(competing, 2, big.NewInt(50), 10, max)
+	testutil.Ok(t, err)
+	testutil.Equals(t, int64(440), price.Int64())
+
+	// Already paying enough.
+	price, err = gasPriceForSlot(competing, 2, big.NewInt(450), 10, max)
+	testutil.Ok(t, err)
+	testutil.Equals(t, int64(450), price.Int64())
+
+	// The required gas price is above the max.
+	_, err = gasPriceForSlot(competing, 1, big.NewInt(50), 10, big.NewInt(520))
+	testutil.Assert(t, errors.Is(err, ErrSlotsClaimed), "expected all slots to be claimed")
+}
+
+// TestGasPriceMax ensures that the gas price is limited by the lower of
+// MaxGasPrice and the max of the transactor.
+func TestGasPriceMax(t *testing.T) {
+	w, err := New(log.NewNopLogger(), context.Background(), Config{LogLevel: "info", GasBumpPercent: 10, MaxGasPrice: 100}, common.Address{}, "")
+	testutil.Ok(t, err)
+	ids := [5]*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3), big.NewInt(4), big.NewInt(5)}
+	gwei := func(v int64) *big.Int { return new(big.Int).Mul(big.NewInt(v), big.NewInt(1e9)) }
+	w.pending = []Submission{{From: common.HexToAddress("0x1"), GasPrice: gwei(50), RequestIDs: ids}}
+	own := common.HexToAddress("0x2")
+
+	price, err := w.GasPrice(ids, own, 4, gwei(10), nil)
+	testutil.Ok(t, err)
+	testutil.Equals(t, gwei(55), price)
+
+	// The transactor max is lower than MaxGasPrice.
+	_, err = w.GasPrice(ids, own, 4, gwei(10), gwei(54))
+	testutil.Assert(t, errors.Is(err, ErrSlotsClaimed), "expected the transactor max to limit the price")
+
+	// MaxGasPrice is lower than the transactor max.
+	w.pending[0].GasPrice = gwei(95)
+	_, err = w.GasPrice(ids, own, 4, gwei(10), gwei(200))
+	testutil.Assert(t, errors.Is(err, ErrSlotsClaimed), "expected MaxGasPrice to limit the price")
+}
@@ -5,22 +5,18 @@ package mining
 
 import (
 	"context"
-	"crypto/sha256"
 	"fmt"
 	"math/big"
 	"strconv"
-
-	"github.com/ethereum/go-ethereum/crypto"
-
-	// nolint:staticcheck
-	"golang.org/x/crypto/ripemd160"
 )
 
-type CpuMiner int64
+type CpuMiner struct {
+	id   int64
+	hash hashFunc
+}
 
-func NewCpuMiner(id int64) *CpuMiner {
-	x := CpuMiner(id)
-	return &x
+func NewCpuMiner(id int64, impl hashImpl) *CpuMiner {
+	return &CpuMiner{id: id, hash: impl.new()}
 }
 
 func (c *CpuMiner) StepSize() uint64 {
@@ -28,7 +24,7 @@ func (c *CpuMiner) StepSize() uint64 {
 }
 
 func (c *CpuMiner) Name() string {
-	return fmt.Sprintf("CPU %d", c)
+	return fmt.Sprintf("CPU %d", c.id)
 }
 
 func (c *CpuMiner) CheckRange(anySolution context.Context, hash *HashSettings, start uint64, n uint64) (string, uint64, error) {
@@ -36,6 +32,7 @@ func (c *CpuMiner) CheckRange(anySolution context.Context, hash *HashSettings, s
 	hashInput := make([]byte, len(hash.prefix))
 	copy(hashInput, hash.prefix)
 
+	numHash := new(big.Int)
 	x := new(big.Int)
 	compareZero := big.NewInt(0)
 	for i := start; i < (start + n); i++ {
@@ -47,10 +44,7 @@ func (c *CpuMiner) CheckRange(anySolution context.Context, hash *HashSettings, s
 		nn := strconv.FormatUint(i, 10)
 		hashInput = hashInput[:baseLen]
 		hashInput = append(hashInput, []byte(nn)...)
-		numHash, err := hashFn(hashInput)
-		if err != nil {
-			return "", 0, err
-		}
+		c.hash(hashInput, numHash)
 		x.Mod(numHash, hash.difficulty)
 		if x.Cmp(compareZero) == 0 {
 			return nn, (i - start) + 1, nil
@@ -58,17 +52,3 @@ func (c *CpuMiner) CheckRange(anySolution context.Context, hash *HashSettings, s
 	}
 	return "", n, nil
 }
-
-func hashFn(input []byte) (*big.Int, error) {
-	hash := crypto.Keccak256(input)
-	hasher := ripemd160.New()
-	if _, err := hasher.Write(hash); err != nil {
-		return nil, err
-	}
-	hash1 := hasher.Sum(nil)
-	n := sha256.Sum256(hash1)
-	result := new(big.Int)
-	result.SetBytes(n[:])
-
-	return result, nil
-}
@@ -0,0 +1,171 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package mining
+
+import (
+	"crypto/sha256"
+	"hash"
+	"math/big"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"golang.org/x/sys/cpu"
+
+	// nolint:staticcheck
+	"golang.org/x/crypto/ripemd160"
+)
+
+// HashAuto selects the fastest implementation of the hash on the CPU.
+const HashAuto = "auto"
+
+// hashFunc computes the PoW hash of the input: sha256(ripemd160(keccak256(input))).
+// A hashFunc isn't safe for concurrent use so every hasher creates its own.
+type hashFunc func(input []byte, result *big.Int)
+
+// hashImpl is an implementation of the PoW hash.
+type hashImpl struct {
+	name string
+	// supported reports whether the CPU has the features that the implementation needs.
+	supported func() bool
+	new       func() hashFunc
+}
+
+// hashImpls are all implementations of the hash.
+// The SIMD and the hardware SHA instructions are used by the hash functions of the standard library and x/crypto
+// when the CPU has them, and an implementation that needs a CPU feature has to check it in supported
+// so that every platform builds and falls back to the others at runtime.
+var hashImpls = []hashImpl{
+	{name: "generic", supported: always, new: newGenericHash},
+	{name: "buffered", supported: always, new: newBufferedHash},
+}
+
+func always() bool { return true }
+
+// newGenericHash allocates the hash states and the buffers for every hash.
+func newGenericHash() hashFunc {
+	return func(input []byte, result *big.Int) {
+		hasher := ripemd160.New()
+		_, _ = hasher.Write(crypto.Keccak256(input))
+		n := sha256.Sum256(hasher.Sum(nil))
+		result.SetBytes(n[:])
+	}
+}
+
+// newBufferedHash reuses the hash states and the buffers
+// which avoids the allocations on the CPUs where the GC is a large part of the hashing time.
+func newBufferedHash() hashFunc {
+	var (
+		keccak = crypto.NewKeccakState()
+		ripemd = ripemd160.New()
+		sha    = sha256.New()
+		buf    [32]byte
+		sum    []byte
+	)
+	write := func(h hash.Hash, b []byte) {
+		h.Reset()
+		_, _ = h.Write(b)
+	}
+	return func(input []byte, result *big.Int) {
+		write(keccak, input)
+		_, _ = keccak.Read(buf[:])
+		write(ripemd, buf[:])
+		sum = ripemd.Sum(sum[:0])
+		write(sha, sum)
+		sum = sha.Sum(sum[:0])
+		result.SetBytes(sum)
+	}
+}
+
+// hashBenchmark is how long every implementation hashes when selecting the fastest one.
+const hashBenchmark = 50 * time.Millisecond
+
+var (
+	autoHash     hashImpl
+	autoHashOnce sync.Once
+)
+
+// selectHash returns the implementation with the name
+// or the fastest of the supported implementations for HashAuto.
+// The auto selection is done once as all accounts share the CPU.
+func selectHash(name string) (hashImpl, error) {
+	if name == HashAuto || name == "" {
+		autoHashOnce.Do(func() {
+			autoHash = fastestHash(hashBenchmark)
+		})
+		return autoHash, nil
+	}
+	for _, impl := range hashImpls {
+		if impl.name != name {
+			continue
+		}
+		if !impl.supported() {
+			return hashImpl{}, errors.Errorf("hash implementation:%v not supported on this CPU:%v/%v features:%v", name, runtime.GOOS, runtime.GOARCH, CPUFeatures())
+		}
+		return impl, nil
+	}
+	return hashImpl{}, errors.Errorf("unknown hash implementation:%v", name)
+}
+
+func fastestHash(d time.Duration) hashImpl {
+	var (
+		fastest hashImpl
+		best    float64
+	)
+	for _, impl := range hashImpls {
+		if !impl.supported() {
+			continue
+		}
+		if rate := hashRate(impl, d); rate > best {
+			fastest, best = impl, rate
+		}
+	}
+	return fastest
+}
+
+// hashRate returns the hashes per second of the implementation.
+func hashRate(impl hashImpl, d time.Duration) float64 {
+	fn := impl.new()
+	input := make([]byte, 52, 72)
+	result := new(big.Int)
+	var n int
+	start := time.Now()
+	for time.Since(start) < d {
+		for i := 0; i < 100; i++ {
+			fn(append(input, byte(n), byte(i)), result)
+		}
+		n++
+	}
+	return float64(n*100) / time.Since(start).Seconds()
+}
+
+// CPUFeatures lists the CPU features that speed up the hash,
+// e.g. AVX2 and BMI2 for sha256 on amd64 and the SHA2 instructions on arm64.
+func CPUFeatures() string {
+	var features []string
+	add := func(has bool, name string) {
+		if has {
+			features = append(features, name)
+		}
+	}
+	switch runtime.GOARCH {
+	case "amd64", "386":
+		add(cpu.X86.HasAVX2, "avx2")
+		add(cpu.X86.HasBMI2, "bmi2")
+	case "arm64":
+		add(cpu.ARM64.HasASIMD, "neon")
+		add(cpu.ARM64.HasSHA2, "sha2")
+		add(cpu.ARM64.HasSHA3, "sha3")
+	case "arm":
+		add(cpu.ARM.HasNEON, "neon")
+		add(cpu.ARM.HasSHA2, "sha2")
+	}
+	if len(features) == 0 {
+		return "none"
+	}
+	return strings.Join(features, ",")
+}
@@ -0,0 +1,60 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package mining
+
+import (
+	"crypto/sha256"
+	"math/big"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tellor-io/telliot/pkg/testutil"
+
+	// nolint:staticcheck
+	"golang.org/x/crypto/ripemd160"
+)
+
+func TestHashImpls(t *testing.T) {
+	expected := func(input []byte) *big.Int {
+		hasher := ripemd160.New()
+		_, err := hasher.Write(crypto.Keccak256(input))
+		testutil.Ok(t, err)
+		n := sha256.Sum256(hasher.Sum(nil))
+		return new(big.Int).SetBytes(n[:])
+	}
+
+	prefix := make([]byte, 52)
+	for i := range prefix {
+		prefix[i] = byte(i)
+	}
+	for _, impl := range hashImpls {
+		if !impl.supported() {
+			t.Logf("hash:%v not supported on this CPU", impl.name)
+			continue
+		}
+		fn := impl.new()
+		result := new(big.Int)
+		// The same function is used for all inputs to catch any state kept between the hashes.
+		for i := 0; i < 1000; i++ {
+			input := append(prefix[:52:52], []byte(strconv.Itoa(i))...)
+			fn(input, result)
+			testutil.Equals(t, expected(input), result, "hash:%v input:%v", impl.name, i)
+		}
+	}
+}
+
+func TestSelectHash(t *testing.T) {
+	impl, err := selectHash("generic")
+	testutil.Ok(t, err)
+	testutil.Equals(t, "generic", impl.name)
+
+	_, err = selectHash("unknown")
+	testutil.NotOk(t, err)
+
+	impl = fastestHash(time.Millisecond)
+	testutil.Assert(t, impl.new != nil, "no hash selected")
+	testutil.Assert(t, impl.supported(), "unsupported hash selected:%v", impl.name)
+}
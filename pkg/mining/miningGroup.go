@@ -13,9 +13,13 @@ import (
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/tellor-io/telliot/pkg/contracts"
 	"github.com/tellor-io/telliot/pkg/ethereum"
 	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/tuning"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const ComponentName = "miner"
@@ -75,9 +79,13 @@ type MiningGroup struct {
 	LastPrinted      time.Time
 	logger           log.Logger
 	contractInstance *contracts.ITellor
+	hashes           prometheus.Counter
+	hashRate         prometheus.Gauge
+	// workers limits how many backends mine at the same time, all of them when nil.
+	workers *tuning.Int
 }
 
-func NewMiningGroup(logger log.Logger, cfg Config, hashers []Hasher, contractInstance *contracts.ITellor) (*MiningGroup, error) {
+func NewMiningGroup(logger log.Logger, cfg Config, hashers []Hasher, contractInstance *contracts.ITellor, account *ethereum.Account) (*MiningGroup, error) {
 	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
 	if err != nil {
 		return nil, errors.Wrap(err, "apply filter logger")
@@ -87,6 +95,20 @@ func NewMiningGroup(logger log.Logger, cfg Config, hashers []Hasher, contractIns
 		Backends:         make([]*Backend, len(hashers)),
 		logger:           log.With(logger, "component", ComponentName),
 		contractInstance: contractInstance,
+		hashes: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace:   "telliot",
+			Subsystem:   ComponentName,
+			Name:        "hashes_total",
+			Help:        "The total number of computed hashes",
+			ConstLabels: prometheus.Labels{"account": account.Address.String()},
+		}),
+		hashRate: promauto.NewGauge(prometheus.GaugeOpts{
+			Namespace:   "telliot",
+			Subsystem:   ComponentName,
+			Name:        "hashrate",
+			Help:        "The hash rate since the last heartbeat in hashes per second",
+			ConstLabels: prometheus.Labels{"account": account.Address.String()},
+		}),
 	}
 	for i, hasher := range hashers {
 		//start with a small estimate for hash rate, much faster to increase the gusses rather than decrease
@@ -163,6 +185,7 @@ func (g *MiningGroup) PrintHashRateSummary() {
 	now := time.Now()
 	delta := now.Sub(g.LastPrinted).Seconds()
 	totalHashrate := float64(totalHashes) / delta
+	g.hashRate.Set(totalHashrate)
 	level.Info(g.logger).Log("msg", "check total hashrate", "totalHashrate", formatHashRate(totalHashrate))
 	for _, b := range g.Backends {
 		hashRate := float64(b.HashSincePrint) / delta
@@ -182,6 +205,10 @@ type Work struct {
 	PublicAddr string
 	Start      uint64
 	N          uint64
+	// Trace is the span of the new challenge event.
+	// All spans for this work are created as its children.
+	// It isn't sent to the miners running in another instance.
+	Trace trace.SpanContext `json:"-"`
 }
 
 type Result struct {
@@ -221,6 +248,11 @@ func (g *MiningGroup) getTimeOfLastNewValue() *big.Int {
 	return timeOfLastNewValue
 }
 
+// canDispatch returns true when fewer than the allowed number of backends are mining.
+func (g *MiningGroup) canDispatch(idle int) bool {
+	return g.workers == nil || int64(len(g.Backends)-idle) < g.workers.Get()
+}
+
 func (g *MiningGroup) Mine(ctx context.Context, input chan *Work, output chan *Result) {
 	sent := uint64(0)
 	recv := uint64(0)
@@ -258,11 +290,15 @@ func (g *MiningGroup) Mine(ctx context.Context, input chan *Work, output chan *R
 		case <-ctx.Done():
 			return
 		// Read in a new work block.
+		// A nil work stops the mining until the next work block.
 		case work := <-input:
 			sent = 0
 			recv = 0
 			currWork = work
-			currHashSettings = NewHashSettings(work.Challenge, work.PublicAddr)
+			currHashSettings = nil
+			if work != nil {
+				currHashSettings = NewHashSettings(work.Challenge, work.PublicAddr)
+			}
 
 		// Read in a result from one of the miners.
 		case result := <-resultChannel:
@@ -275,6 +311,7 @@ func (g *MiningGroup) Mine(ctx context.Context, input chan *Work, output chan *R
 			// Update the backend statistics no matter what.
 			result.backend.TotalHashes += result.n
 			result.backend.HashSincePrint += result.n
+			g.hashes.Add(float64(result.n))
 
 			// Only update the hashRateEstimate if we didn't find a solution - otherwise the rate could be wrong
 			// due to returning early.
@@ -310,7 +347,7 @@ func (g *MiningGroup) Mine(ctx context.Context, input chan *Work, output chan *R
 			}
 		}
 		if currWork != nil {
-			for sent < currWork.N && len(idleWorkers) > 0 {
+			for sent < currWork.N && len(idleWorkers) > 0 && g.canDispatch(len(idleWorkers)) {
 				worker := <-idleWorkers
 				timeOfLastNewValue := g.getTimeOfLastNewValue()
 				sent += worker.dispatchWork(ctx, timeOfLastNewValue, currHashSettings, currWork.Start+sent, resultChannel)
@@ -6,19 +6,38 @@ package mining
 import (
 	"context"
 	"fmt"
+	"runtime"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/ethereum"
 	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/submitter"
+	"github.com/tellor-io/telliot/pkg/tracing"
+	"github.com/tellor-io/telliot/pkg/tuning"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Config struct {
 	LogLevel  string
 	Heartbeat time.Duration
+	// RemoteURL is the instance running in the submitter role
+	// which the miner role gets the work from and sends the solutions to.
+	RemoteURL string
+	// Workers is the number of CPU mining threads of each account.
+	// It can be changed without a restart up to the number of CPUs.
+	Workers int
+	// Hash is the implementation of the hash, auto selects the fastest on the CPU.
+	Hash string
 }
 
 type SolutionSink interface {
@@ -27,16 +46,43 @@ type SolutionSink interface {
 
 const NumProcessors = 1
 
-func SetupMiningGroup(logger log.Logger, cfg Config, contractInstance *contracts.ITellor) (*MiningGroup, error) {
+var (
+	workers     *tuning.Int
+	workersOnce sync.Once
+)
+
+// gateCheckInterval is how often the manager checks whether the submit gate was paused or resumed.
+const gateCheckInterval = 10 * time.Second
+
+func SetupMiningGroup(logger log.Logger, cfg Config, contractInstance *contracts.ITellor, account *ethereum.Account) (*MiningGroup, error) {
+	if cfg.Workers <= 0 {
+		cfg.Workers = NumProcessors
+	}
+	// A hasher is created for every CPU so that the workers can be increased
+	// without a restart, the idle hashers use no resources.
+	max := runtime.NumCPU()
+	if cfg.Workers > max {
+		max = cfg.Workers
+	}
+	workersOnce.Do(func() {
+		workers = tuning.NewInt("miner.workers", "The number of CPU mining threads of each account.", int64(cfg.Workers), 1, int64(max))
+	})
+
+	impl, err := selectHash(cfg.Hash)
+	if err != nil {
+		return nil, errors.Wrap(err, "selecting hash implementation")
+	}
+
 	var hashers []Hasher
-	level.Info(logger).Log("msg", "starting CPU mining", "threads", NumProcessors)
-	for i := 0; i < NumProcessors; i++ {
-		hashers = append(hashers, NewCpuMiner(int64(i)))
+	level.Info(logger).Log("msg", "starting CPU mining", "threads", workers.Get(), "hash", impl.name, "arch", runtime.GOARCH, "cpuFeatures", CPUFeatures())
+	for i := 0; i < max; i++ {
+		hashers = append(hashers, NewCpuMiner(int64(i), impl))
 	}
-	miningGrp, err := NewMiningGroup(logger, cfg, hashers, contractInstance)
+	miningGrp, err := NewMiningGroup(logger, cfg, hashers, contractInstance, account)
 	if err != nil {
 		return nil, errors.Wrap(err, "creating new mining group")
 	}
+	miningGrp.workers = workers
 	return miningGrp, nil
 }
 
@@ -46,6 +92,7 @@ func SetupMiningGroup(logger log.Logger, cfg Config, contractInstance *contracts
 // The profit is calculated the same way as in the Tellor contract.
 // Transaction cost for submitting in each slot might be different so because of this
 // the manager needs to complete few transaction to gather the tx cost for each slot.
+// While the submit gate is paused the manager doesn't mine as the solutions can't be submitted.
 type MiningMgr struct {
 	ctx              context.Context
 	close            context.CancelFunc
@@ -57,6 +104,12 @@ type MiningMgr struct {
 	contractInstance *contracts.ITellor
 	toMineInput      chan *Work
 	solutionOutput   chan *Result
+	mineSpan         trace.Span
+	solutions        prometheus.Counter
+	gate             *submitter.Gate
+	// work is the current work, kept so that the mining can continue when the gate is resumed.
+	work   *Work
+	paused bool
 }
 
 // NewMiningManager is the MiningMgr constructor.
@@ -68,6 +121,8 @@ func NewMiningManager(
 	taskerCh chan *Work,
 	submitterCh chan *Result,
 	client contracts.ETHClient,
+	account *ethereum.Account,
+	gate *submitter.Gate,
 ) (*MiningMgr, error) {
 
 	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
@@ -76,7 +131,7 @@ func NewMiningManager(
 	}
 	logger = log.With(logger, "component", ComponentName)
 
-	group, err := SetupMiningGroup(logger, cfg, contractInstance)
+	group, err := SetupMiningGroup(logger, cfg, contractInstance, account)
 	if err != nil {
 		return nil, errors.Wrap(err, "setup MiningGroup")
 	}
@@ -93,6 +148,14 @@ func NewMiningManager(
 		ethClient:        client,
 		toMineInput:      make(chan *Work),
 		solutionOutput:   make(chan *Result),
+		gate:             gate,
+		solutions: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace:   "telliot",
+			Subsystem:   ComponentName,
+			Name:        "solutions_total",
+			Help:        "The total number of found solutions",
+			ConstLabels: prometheus.Labels{"account": account.Address.String()},
+		}),
 	}
 	return mng, nil
 }
@@ -102,31 +165,102 @@ func (mgr *MiningMgr) Start() error {
 	// Start the mining group.
 	go mgr.group.Mine(mgr.ctx, mgr.toMineInput, mgr.solutionOutput)
 
+	ticker := time.NewTicker(gateCheckInterval)
+	defer ticker.Stop()
+
 	for {
 		select {
 
 		// Boss wants us to quit for the day.
 		case <-mgr.ctx.Done():
+			mgr.endMineSpan("stopped")
 			return mgr.ctx.Err()
 
 		// Found a solution.
 		case solution := <-mgr.solutionOutput:
+			mgr.endMineSpan("")
+			mgr.work = nil
+			mgr.solutions.Inc()
 			level.Info(mgr.logger).Log("msg", "sending the solution to the submitter")
 			mgr.submitterCh <- solution
 
 		// Listen for new work from the tasker and send for mining.
 		case work := <-mgr.taskerCh:
-			mgr.toMineInput <- work
-			level.Info(mgr.logger).Log("msg", "sent new challenge to the mining group",
-				"challenge", fmt.Sprintf("%x", work.Challenge.Challenge),
-				"difficulty", work.Challenge.Difficulty,
-				"requestIDs", fmt.Sprintf("%+v", work.Challenge.RequestIDs),
-			)
+			mgr.endMineSpan("replaced by a new challenge")
+			mgr.work = work
+			// A nil work is a challenge that can't be submitted.
+			if work == nil {
+				level.Info(mgr.logger).Log("msg", "stopping mining until the next challenge")
+				if !mgr.paused {
+					mgr.toMineInput <- nil
+				}
+				continue
+			}
+			if mgr.paused {
+				level.Info(mgr.logger).Log("msg", "submitting is paused, skipping new challenge",
+					"challenge", fmt.Sprintf("%x", work.Challenge.Challenge),
+				)
+				continue
+			}
+			mgr.mine(work)
+
+		case <-ticker.C:
+			mgr.checkGate()
 		}
 	}
 
 }
 
+func (mgr *MiningMgr) mine(work *Work) {
+	_, mgr.mineSpan = tracing.Start(
+		trace.ContextWithSpanContext(mgr.ctx, work.Trace),
+		"miner.mine",
+		attribute.String("account", work.PublicAddr),
+		attribute.Int64("difficulty", work.Challenge.Difficulty.Int64()),
+	)
+	mgr.toMineInput <- work
+	level.Info(mgr.logger).Log("msg", "sent new challenge to the mining group",
+		"challenge", fmt.Sprintf("%x", work.Challenge.Challenge),
+		"difficulty", work.Challenge.Difficulty,
+		"requestIDs", fmt.Sprintf("%+v", work.Challenge.RequestIDs),
+	)
+}
+
+// checkGate stops the mining group when the submit gate is paused and
+// continues with the current work when it is resumed.
+func (mgr *MiningMgr) checkGate() {
+	if mgr.gate == nil {
+		return
+	}
+	err := mgr.gate.Err()
+	switch {
+	case err != nil && !mgr.paused:
+		mgr.paused = true
+		level.Warn(mgr.logger).Log("msg", "stopping mining", "reason", err)
+		mgr.endMineSpan("paused")
+		mgr.toMineInput <- nil
+	case err == nil && mgr.paused:
+		mgr.paused = false
+		level.Info(mgr.logger).Log("msg", "resuming mining")
+		if mgr.work != nil {
+			mgr.mine(mgr.work)
+		}
+	}
+}
+
+// endMineSpan ends the span of the current work.
+// A non empty reason means that no solution was found.
+func (mgr *MiningMgr) endMineSpan(reason string) {
+	if mgr.mineSpan == nil {
+		return
+	}
+	if reason != "" {
+		mgr.mineSpan.SetStatus(codes.Error, reason)
+	}
+	mgr.mineSpan.End()
+	mgr.mineSpan = nil
+}
+
 // Stop will take care of stopping the miner component.
 func (mgr *MiningMgr) Stop() {
 	mgr.close()
@@ -0,0 +1,212 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package mining
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/web"
+)
+
+// remotePollInterval is how often the miner role checks for new work.
+const remotePollInterval = time.Second
+
+// WorkServer serves the work from the tasker to the miners running in other instances
+// and passes their solutions to the submitters.
+type WorkServer struct {
+	ctx          context.Context
+	close        context.CancelFunc
+	logger       log.Logger
+	taskerChs    map[string]chan *Work
+	submitterChs map[string]chan *Result
+
+	mtx  sync.Mutex
+	work map[string]*Work
+}
+
+func NewWorkServer(logger log.Logger, ctx context.Context, taskerChs map[string]chan *Work, submitterChs map[string]chan *Result) *WorkServer {
+	ctx, close := context.WithCancel(ctx)
+	return &WorkServer{
+		ctx:          ctx,
+		close:        close,
+		logger:       log.With(logger, "component", ComponentName),
+		taskerChs:    taskerChs,
+		submitterChs: submitterChs,
+		work:         make(map[string]*Work),
+	}
+}
+
+func (self *WorkServer) Start() error {
+	var wg sync.WaitGroup
+	for addr, ch := range self.taskerChs {
+		wg.Add(1)
+		go func(addr string, ch chan *Work) {
+			defer wg.Done()
+			for {
+				select {
+				case <-self.ctx.Done():
+					return
+				case work := <-ch:
+					self.mtx.Lock()
+					self.work[addr] = work
+					self.mtx.Unlock()
+				}
+			}
+		}(addr, ch)
+	}
+	wg.Wait()
+	return nil
+}
+
+func (self *WorkServer) Stop() {
+	self.close()
+}
+
+// ServeWork returns the current work for the account query param.
+func (self *WorkServer) ServeWork(w http.ResponseWriter, r *http.Request) {
+	account := r.URL.Query().Get("account")
+	self.mtx.Lock()
+	work, ok := self.work[account]
+	self.mtx.Unlock()
+
+	var err error
+	code := http.StatusOK
+	if !ok {
+		code = http.StatusNotFound
+		err = errors.Errorf("no work for account:%v", account)
+	}
+	if err := web.WriteJSON(w, code, work, err); err != nil {
+		level.Error(self.logger).Log("msg", "encoding work response", "err", err)
+	}
+}
+
+// ServeSolution passes a solution for the current work of the account to its submitter.
+// It leads to a transaction signed with the account key
+// so should only be served with the auth or on a loopback address.
+func (self *WorkServer) ServeSolution(w http.ResponseWriter, r *http.Request) {
+	code, err := func() (int, error) {
+		result := &Result{}
+		if err := json.NewDecoder(r.Body).Decode(result); err != nil {
+			return http.StatusBadRequest, errors.Wrap(err, "decoding the solution")
+		}
+		if result.Work == nil || result.Work.Challenge == nil {
+			return http.StatusBadRequest, errors.New("solution without work")
+		}
+		ch, ok := self.submitterChs[result.Work.PublicAddr]
+		if !ok {
+			return http.StatusNotFound, errors.Errorf("no submitter for account:%v", result.Work.PublicAddr)
+		}
+		self.mtx.Lock()
+		work := self.work[result.Work.PublicAddr]
+		self.mtx.Unlock()
+		if work == nil || !bytes.Equal(work.Challenge.Challenge, result.Work.Challenge.Challenge) {
+			return http.StatusConflict, errors.Errorf("solution for a challenge that isn't the current work of account:%v", result.Work.PublicAddr)
+		}
+		// Only the nonce comes from the miner, the request IDs and the difficulty are the served ones.
+		result = &Result{Work: work, Nonce: result.Nonce}
+		select {
+		case ch <- result:
+		case <-r.Context().Done():
+			return http.StatusServiceUnavailable, r.Context().Err()
+		}
+		level.Info(self.logger).Log("msg", "received a remote solution", "addr", result.Work.PublicAddr, "solution", result.Nonce)
+		return http.StatusOK, nil
+	}()
+	if err := web.WriteJSON(w, code, nil, err); err != nil {
+		level.Error(self.logger).Log("msg", "encoding solution response", "err", err)
+	}
+}
+
+// RemoteWork gets the work for a single account from an instance running in the submitter role
+// and sends back the solutions found by the miner.
+type RemoteWork struct {
+	ctx         context.Context
+	close       context.CancelFunc
+	logger      log.Logger
+	url         string
+	account     string
+	client      *http.Client
+	taskerCh    chan *Work
+	submitterCh chan *Result
+}
+
+// NewRemoteWork returns the remote work together with the channels
+// to use as the tasker and submitter channels for the miner.
+func NewRemoteWork(logger log.Logger, ctx context.Context, cfg Config, account string) (*RemoteWork, chan *Work, chan *Result) {
+	ctx, close := context.WithCancel(ctx)
+	self := &RemoteWork{
+		ctx:         ctx,
+		close:       close,
+		logger:      log.With(logger, "component", ComponentName),
+		url:         cfg.RemoteURL,
+		account:     account,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		taskerCh:    make(chan *Work),
+		submitterCh: make(chan *Result),
+	}
+	return self, self.taskerCh, self.submitterCh
+}
+
+func (self *RemoteWork) Start() error {
+	level.Info(self.logger).Log("msg", "starting", "url", self.url)
+
+	// Solutions are sent independently so that a new work never blocks the miner.
+	go func() {
+		for {
+			select {
+			case <-self.ctx.Done():
+				return
+			case result := <-self.submitterCh:
+				if err := web.PostJSON(self.ctx, self.client, self.url+"/api/v1/mining/solution", result, nil); err != nil {
+					level.Error(self.logger).Log("msg", "sending the solution", "err", err)
+				}
+			}
+		}
+	}()
+
+	var current *Work
+	ticker := time.NewTicker(remotePollInterval)
+	defer ticker.Stop()
+	for {
+		work := &Work{}
+		err := web.GetJSON(self.ctx, self.client, fmt.Sprintf("%v/api/v1/mining/work?account=%v", self.url, url.QueryEscape(self.account)), work)
+		if err != nil {
+			level.Debug(self.logger).Log("msg", "getting the work", "err", err)
+		} else if work.Challenge == nil && current != nil {
+			// The current challenge can't be submitted.
+			current = nil
+			select {
+			case self.taskerCh <- nil:
+			case <-self.ctx.Done():
+				return nil
+			}
+		} else if work.Challenge != nil && (current == nil || !bytes.Equal(current.Challenge.Challenge, work.Challenge.Challenge)) {
+			current = work
+			select {
+			case self.taskerCh <- work:
+			case <-self.ctx.Done():
+				return nil
+			}
+		}
+		select {
+		case <-self.ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (self *RemoteWork) Stop() {
+	self.close()
+}
@@ -0,0 +1,104 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package mining
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+const testAccount = "0x0000000000000000000000000000000000000001"
+
+func testWork(challenge byte) *Work {
+	return &Work{
+		Challenge: &MiningChallenge{
+			Challenge:  []byte{challenge},
+			Difficulty: big.NewInt(1000),
+			RequestIDs: [5]*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3), big.NewInt(4), big.NewInt(5)},
+		},
+		PublicAddr: testAccount,
+		N:          10,
+	}
+}
+
+func post(handler http.HandlerFunc, result interface{}) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(result)
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodPost, "/api/v1/mining/solution", bytes.NewReader(body)))
+	return rec
+}
+
+// TestWorkServer ensures that the served work reaches the miners
+// and that only the solutions of the current work reach the submitter.
+func TestWorkServer(t *testing.T) {
+	ctx, cncl := context.WithCancel(context.Background())
+	defer cncl()
+	taskerCh := make(chan *Work)
+	submitterCh := make(chan *Result, 1)
+	srv := NewWorkServer(logging.NewLogger(), ctx, map[string]chan *Work{testAccount: taskerCh}, map[string]chan *Result{testAccount: submitterCh})
+	go func() { testutil.Ok(t, srv.Start()) }()
+	defer srv.Stop()
+
+	rec := httptest.NewRecorder()
+	srv.ServeWork(rec, httptest.NewRequest(http.MethodGet, "/api/v1/mining/work?account="+testAccount, nil))
+	testutil.Equals(t, http.StatusNotFound, rec.Code)
+
+	taskerCh <- testWork(1)
+	// The work is stored by the tasker loop.
+	for i := 0; ; i++ {
+		rec = httptest.NewRecorder()
+		srv.ServeWork(rec, httptest.NewRequest(http.MethodGet, "/api/v1/mining/work?account="+testAccount, nil))
+		if rec.Code == http.StatusOK {
+			break
+		}
+		testutil.Assert(t, i < 100, "the work wasn't served")
+		time.Sleep(10 * time.Millisecond)
+	}
+	var resp struct {
+		Data Work `json:"data"`
+	}
+	testutil.Ok(t, json.NewDecoder(rec.Body).Decode(&resp))
+	testutil.Equals(t, []byte{1}, resp.Data.Challenge.Challenge)
+
+	testutil.Equals(t, http.StatusBadRequest, post(srv.ServeSolution, Result{Nonce: "1"}).Code)
+
+	other := testWork(1)
+	other.PublicAddr = "0x0000000000000000000000000000000000000002"
+	testutil.Equals(t, http.StatusNotFound, post(srv.ServeSolution, Result{Work: other, Nonce: "1"}).Code)
+
+	// A solution of an old or made up challenge.
+	testutil.Equals(t, http.StatusConflict, post(srv.ServeSolution, Result{Work: testWork(2), Nonce: "1"}).Code)
+
+	// The request IDs and the difficulty of the submitted solution are the served ones.
+	tampered := testWork(1)
+	tampered.Challenge.RequestIDs[0] = big.NewInt(100)
+	tampered.Challenge.Difficulty = big.NewInt(1)
+	testutil.Equals(t, http.StatusOK, post(srv.ServeSolution, Result{Work: tampered, Nonce: "42"}).Code)
+	result := <-submitterCh
+	testutil.Equals(t, "42", result.Nonce)
+	testutil.Equals(t, int64(1), result.Work.Challenge.RequestIDs[0].Int64())
+	testutil.Equals(t, int64(1000), result.Work.Challenge.Difficulty.Int64())
+
+	// A challenge with an excluded request ID isn't served
+	// and the solutions of the previous one are rejected.
+	taskerCh <- nil
+	for i := 0; ; i++ {
+		code := post(srv.ServeSolution, Result{Work: testWork(1), Nonce: "43"}).Code
+		if code == http.StatusConflict {
+			break
+		}
+		<-submitterCh
+		testutil.Assert(t, i < 100, "the solution of the previous challenge was accepted")
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,271 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const emailBackend = "email"
+
+// SMTPPasswordEnvName is the env variable with the password of the SMTP user.
+const SMTPPasswordEnvName = "SMTP_PASSWORD"
+
+const (
+	DefaultEmailSubject = `[telliot] {{.Severity}}: {{.Title}}`
+	DefaultEmailBody    = `{{.Body}}
+
+Event: {{.Event}}
+Severity: {{.Severity}}
+Time: {{.Time.Format "2006-01-02 15:04:05 MST"}}
+{{range $k, $v := .Data}}{{$k}}: {{$v}}
+{{end}}`
+)
+
+// EmailConfig sends the notifications by email through an SMTP server.
+type EmailConfig struct {
+	Enabled bool
+	Host    string
+	Port    uint
+	// Username for the SMTP auth with the password in the SMTP_PASSWORD env variable, no auth when empty.
+	Username string
+	From     string
+	// TLS connects with implicit TLS e.g. on port 465,
+	// otherwise STARTTLS is used when the server supports it.
+	TLS bool
+	// Subject and Body are text/template templates executed with the message,
+	// DefaultEmailSubject and DefaultEmailBody when empty.
+	Subject string
+	Body    string
+	// Routes send the messages to the recipients by severity
+	// e.g. the critical ones to an on-call pager address and all to a team list.
+	Routes []EmailRoute
+}
+
+// EmailRoute sends the messages with at least the severity to the recipients.
+type EmailRoute struct {
+	To []string
+	// MinSeverity is info, warning or critical, all messages when empty.
+	MinSeverity Severity
+	// Events limits the route to these events, all events when empty.
+	Events []string
+}
+
+func (self EmailRoute) matches(msg Message) bool {
+	if msg.Severity.rank() < self.MinSeverity.rank() {
+		return false
+	}
+	if len(self.Events) == 0 {
+		return true
+	}
+	for _, e := range self.Events {
+		if e == msg.Event {
+			return true
+		}
+	}
+	return false
+}
+
+func (self Severity) rank() int {
+	switch self {
+	case SeverityWarning:
+		return 1
+	case SeverityCritical:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// Email sends the notifications by email to the recipients of the matching routes.
+type Email struct {
+	cfg      EmailConfig
+	password string
+	timeout  time.Duration
+	subject  *template.Template
+	body     *template.Template
+}
+
+func NewEmail(cfg EmailConfig, password string, timeout time.Duration) (*Email, error) {
+	if cfg.Host == "" || cfg.From == "" {
+		return nil, errors.New("the email notifications need a host and a from address")
+	}
+	if len(cfg.Routes) == 0 {
+		return nil, errors.New("the email notifications need at least one route")
+	}
+	for _, route := range cfg.Routes {
+		if len(route.To) == 0 {
+			return nil, errors.Errorf("an email route needs recipients min severity:%v", route.MinSeverity)
+		}
+		switch route.MinSeverity {
+		case "", SeverityInfo, SeverityWarning, SeverityCritical:
+		default:
+			return nil, errors.Errorf("invalid email route min severity:%v", route.MinSeverity)
+		}
+	}
+	if cfg.Subject == "" {
+		cfg.Subject = DefaultEmailSubject
+	}
+	if cfg.Body == "" {
+		cfg.Body = DefaultEmailBody
+	}
+	subject, err := template.New("subject").Parse(cfg.Subject)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing the subject template")
+	}
+	body, err := template.New("body").Parse(cfg.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing the body template")
+	}
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	return &Email{
+		cfg:      cfg,
+		password: password,
+		timeout:  timeout,
+		subject:  subject,
+		body:     body,
+	}, nil
+}
+
+func (self *Email) Notify(ctx context.Context, msg Message) error {
+	to := self.recipients(msg)
+	if len(to) == 0 {
+		return nil
+	}
+	data, err := self.message(msg, to)
+	if err != nil {
+		return err
+	}
+	return self.send(ctx, to, data)
+}
+
+// recipients returns the unique recipients of all routes that match the message.
+func (self *Email) recipients(msg Message) []string {
+	set := make(map[string]bool)
+	for _, route := range self.cfg.Routes {
+		if !route.matches(msg) {
+			continue
+		}
+		for _, to := range route.To {
+			set[to] = true
+		}
+	}
+	var to []string
+	for addr := range set {
+		to = append(to, addr)
+	}
+	sort.Strings(to)
+	return to
+}
+
+// message renders the templates into a plain text email.
+// The event and the severity are also in headers so that the pager systems can route on them.
+func (self *Email) message(msg Message, to []string) ([]byte, error) {
+	var subject, body bytes.Buffer
+	if err := self.subject.Execute(&subject, msg); err != nil {
+		return nil, errors.Wrap(err, "executing the subject template")
+	}
+	if err := self.body.Execute(&body, msg); err != nil {
+		return nil, errors.Wrap(err, "executing the body template")
+	}
+
+	var data bytes.Buffer
+	for _, h := range [][2]string{
+		{"From", self.cfg.From},
+		{"To", strings.Join(to, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", strings.TrimSpace(subject.String()))},
+		{"Date", msg.Time.Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=UTF-8"},
+		{"X-Telliot-Event", msg.Event},
+		{"X-Telliot-Severity", string(msg.Severity)},
+	} {
+		fmt.Fprintf(&data, "%s: %s\r\n", h[0], h[1])
+	}
+	data.WriteString("\r\n")
+	data.WriteString(strings.ReplaceAll(strings.ReplaceAll(body.String(), "\r\n", "\n"), "\n", "\r\n"))
+	return data.Bytes(), nil
+}
+
+func (self *Email) send(ctx context.Context, to []string, data []byte) error {
+	addr := net.JoinHostPort(self.cfg.Host, strconv.Itoa(int(self.cfg.Port)))
+	tlsCfg := &tls.Config{ServerName: self.cfg.Host}
+
+	dialer := &net.Dialer{Timeout: self.timeout}
+	var conn net.Conn
+	var err error
+	if self.cfg.TLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsCfg)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "connecting to:%v", addr)
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(self.timeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return errors.Wrap(err, "setting the deadline")
+	}
+
+	c, err := smtp.NewClient(conn, self.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return errors.Wrap(err, "creating the SMTP client")
+	}
+	defer c.Close()
+
+	if !self.cfg.TLS {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(tlsCfg); err != nil {
+				return errors.Wrap(err, "starting TLS")
+			}
+		}
+	}
+	if self.cfg.Username != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("the SMTP server doesn't support auth")
+		}
+		if err := c.Auth(smtp.PlainAuth("", self.cfg.Username, self.password, self.cfg.Host)); err != nil {
+			return errors.Wrap(err, "SMTP auth")
+		}
+	}
+	if err := c.Mail(self.cfg.From); err != nil {
+		return errors.Wrap(err, "SMTP MAIL")
+	}
+	for _, addr := range to {
+		if err := c.Rcpt(addr); err != nil {
+			return errors.Wrapf(err, "SMTP RCPT to:%v", addr)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return errors.Wrap(err, "SMTP DATA")
+	}
+	if _, err := w.Write(data); err != nil {
+		return errors.Wrap(err, "writing the message")
+	}
+	if err := w.Close(); err != nil {
+		return errors.Wrap(err, "sending the message")
+	}
+	return c.Quit()
+}
@@ -0,0 +1,220 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package notify
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// smtpServer is a plain text SMTP server which records the commands of a session
+// and rejects the recipients in reject.
+type smtpServer struct {
+	listener net.Listener
+	reject   map[string]bool
+	sessions chan smtpSession
+}
+
+type smtpSession struct {
+	commands []string
+	data     string
+}
+
+func newSMTPServer(t *testing.T, reject ...string) *smtpServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	testutil.Ok(t, err)
+	self := &smtpServer{listener: l, reject: make(map[string]bool), sessions: make(chan smtpSession, 10)}
+	for _, addr := range reject {
+		self.reject["RCPT TO:<"+addr+">"] = true
+	}
+	go self.serve()
+	return self
+}
+
+func (self *smtpServer) port() uint {
+	return uint(self.listener.Addr().(*net.TCPAddr).Port)
+}
+
+func (self *smtpServer) serve() {
+	for {
+		conn, err := self.listener.Accept()
+		if err != nil {
+			return
+		}
+		self.sessions <- self.session(textproto.NewConn(conn))
+	}
+}
+
+func (self *smtpServer) session(c *textproto.Conn) smtpSession {
+	defer c.Close()
+	var s smtpSession
+	if err := c.PrintfLine("220 localhost ESMTP"); err != nil {
+		return s
+	}
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return s
+		}
+		s.commands = append(s.commands, line)
+		switch {
+		case strings.HasPrefix(line, "EHLO"):
+			err = c.PrintfLine("250-localhost\r\n250 AUTH PLAIN")
+		case strings.HasPrefix(line, "AUTH"):
+			err = c.PrintfLine("235 authenticated")
+		case self.reject[line]:
+			err = c.PrintfLine("550 no such user")
+		case line == "DATA":
+			if err = c.PrintfLine("354 go ahead"); err != nil {
+				return s
+			}
+			var data []byte
+			if data, err = c.ReadDotBytes(); err != nil {
+				return s
+			}
+			s.data = string(data)
+			err = c.PrintfLine("250 queued")
+		case line == "QUIT":
+			_ = c.PrintfLine("221 bye")
+			return s
+		default:
+			err = c.PrintfLine("250 ok")
+		}
+		if err != nil {
+			return s
+		}
+	}
+}
+
+func (self *smtpServer) Close() {
+	self.listener.Close()
+}
+
+func testEmailConfig(port uint) EmailConfig {
+	return EmailConfig{
+		Enabled:  true,
+		Host:     "127.0.0.1",
+		Port:     port,
+		Username: "telliot",
+		From:     "telliot@example.com",
+		Routes: []EmailRoute{
+			{To: []string{"team@example.com"}},
+			{To: []string{"pager@example.com", "team@example.com"}, MinSeverity: SeverityCritical},
+			{To: []string{"disputes@example.com"}, Events: []string{EventDisputeAgainstMe}},
+		},
+	}
+}
+
+func TestNewEmail(t *testing.T) {
+	_, err := NewEmail(EmailConfig{Host: "127.0.0.1", Routes: testEmailConfig(0).Routes}, "", 0)
+	testutil.NotOk(t, err, "an email without a from address")
+	_, err = NewEmail(EmailConfig{Host: "127.0.0.1", From: "telliot@example.com"}, "", 0)
+	testutil.NotOk(t, err, "an email without routes")
+	_, err = NewEmail(EmailConfig{Host: "127.0.0.1", From: "telliot@example.com", Routes: []EmailRoute{{MinSeverity: SeverityInfo}}}, "", 0)
+	testutil.NotOk(t, err, "a route without recipients")
+	_, err = NewEmail(EmailConfig{Host: "127.0.0.1", From: "telliot@example.com", Routes: []EmailRoute{{To: []string{"a@example.com"}, MinSeverity: "urgent"}}}, "", 0)
+	testutil.NotOk(t, err, "a route with an unknown severity")
+	cfg := testEmailConfig(0)
+	cfg.Subject = "{{.Title"
+	_, err = NewEmail(cfg, "", 0)
+	testutil.NotOk(t, err, "an invalid subject template")
+}
+
+func TestEmailRecipients(t *testing.T) {
+	email, err := NewEmail(testEmailConfig(0), "", 0)
+	testutil.Ok(t, err)
+
+	testutil.Equals(t, []string{"team@example.com"}, email.recipients(Message{Event: EventLowBalance, Severity: SeverityWarning}))
+	testutil.Equals(t, []string{"pager@example.com", "team@example.com"}, email.recipients(Message{Event: EventLowBalance, Severity: SeverityCritical}))
+	testutil.Equals(t, []string{"disputes@example.com", "team@example.com"}, email.recipients(Message{Event: EventDisputeAgainstMe, Severity: SeverityInfo}))
+}
+
+// TestEmailSend ensures that a message is sent to the recipients of the matching routes
+// with the auth, the rendered templates and the routing headers.
+func TestEmailSend(t *testing.T) {
+	srv := newSMTPServer(t)
+	defer srv.Close()
+
+	email, err := NewEmail(testEmailConfig(srv.port()), "secret", time.Second)
+	testutil.Ok(t, err)
+	msg := Message{
+		Event:    EventLowBalance,
+		Severity: SeverityCritical,
+		Title:    "Low balance",
+		Body:     "The balance is below the threshold.",
+		Time:     time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC),
+		Data:     map[string]string{"account": "0x1"},
+	}
+	testutil.Ok(t, email.Notify(context.Background(), msg))
+
+	s := <-srv.sessions
+	testutil.Assert(t, strings.HasPrefix(s.commands[1], "AUTH PLAIN "), "no auth:%v", s.commands)
+	testutil.Equals(t, []string{
+		"MAIL FROM:<telliot@example.com>",
+		"RCPT TO:<pager@example.com>",
+		"RCPT TO:<team@example.com>",
+		"DATA",
+		"QUIT",
+	}, s.commands[2:])
+	for _, line := range []string{
+		"To: pager@example.com, team@example.com",
+		"Subject: [telliot] critical: Low balance",
+		"X-Telliot-Event: " + EventLowBalance,
+		"X-Telliot-Severity: critical",
+		"The balance is below the threshold.",
+		"Time: 2021-06-01 12:00:00 UTC",
+		"account: 0x1",
+	} {
+		testutil.Assert(t, strings.Contains(s.data, line+"\n"), "the message doesn't have the line:%v\n%v", line, s.data)
+	}
+}
+
+func TestEmailSendFails(t *testing.T) {
+	srv := newSMTPServer(t, "pager@example.com")
+	defer srv.Close()
+	email, err := NewEmail(testEmailConfig(srv.port()), "secret", time.Second)
+	testutil.Ok(t, err)
+
+	err = email.Notify(context.Background(), Message{Event: EventLowBalance, Severity: SeverityCritical, Time: time.Now()})
+	testutil.NotOk(t, err, "a rejected recipient")
+	testutil.Assert(t, strings.Contains(err.Error(), "pager@example.com"), "the error doesn't have the recipient:%v", err)
+	s := <-srv.sessions
+	for _, c := range s.commands {
+		testutil.Assert(t, c != "DATA", "the message was sent after a rejected recipient")
+	}
+
+	// Without auth support on the server.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	testutil.Ok(t, err)
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		c := textproto.NewConn(conn)
+		defer c.Close()
+		_ = c.PrintfLine("220 localhost ESMTP")
+		_, _ = c.ReadLine()
+		_ = c.PrintfLine("250 localhost")
+		_, _ = c.ReadLine()
+	}()
+	email, err = NewEmail(testEmailConfig(uint(l.Addr().(*net.TCPAddr).Port)), "secret", time.Second)
+	testutil.Ok(t, err)
+	err = email.Notify(context.Background(), Message{Event: EventLowBalance, Severity: SeverityInfo, Time: time.Now()})
+	testutil.NotOk(t, err, "auth without server support")
+
+	// Nothing is sent when no route matches.
+	cfg := testEmailConfig(1)
+	cfg.Routes = []EmailRoute{{To: []string{"pager@example.com"}, MinSeverity: SeverityCritical}}
+	email, err = NewEmail(cfg, "", time.Second)
+	testutil.Ok(t, err)
+	testutil.Ok(t, email.Notify(context.Background(), Message{Event: EventLowBalance, Severity: SeverityInfo, Time: time.Now()}))
+}
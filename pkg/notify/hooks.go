@@ -0,0 +1,154 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Lifecycle events for the hooks in addition to the notifications.
+const (
+	EventSolutionFound       = "solution_found"
+	EventSubmissionConfirmed = "submission_confirmed"
+	EventSubmissionReverted  = "submission_reverted"
+	EventDisputeAgainstMe    = "dispute_against_me"
+	EventLowBalance          = "low_balance"
+	EventBreakerTripped      = "breaker_tripped"
+	EventTopUp               = "top_up"
+	EventTopUpBlocked        = "top_up_blocked"
+	EventApprovalRequested   = "approval_requested"
+	EventApprovalApproved    = "approval_approved"
+)
+
+// HookEnvName is the env variable with the event name for the hook commands.
+const HookEnvName = "TELLIOT_EVENT"
+
+// hookBaseEnv are the env variables of the process passed to all hook commands.
+// The others e.g. the private keys and the API keys of the data sources are only passed when listed in the hook env.
+var hookBaseEnv = []string{"PATH", "HOME"}
+
+// HookConfig is a command or a webhook fired on events.
+type HookConfig struct {
+	// Events that fire the hook e.g. solution_found, all events when empty.
+	Events []string
+	// Command is run with the JSON of the message on stdin
+	// and the event name in the TELLIOT_EVENT env variable.
+	Command string
+	Args    []string
+	// Env are the names of the env variables passed to the command in addition to PATH and HOME.
+	Env []string
+	// URL receives the JSON of the message as a POST request.
+	URL string
+}
+
+func (self HookConfig) fires(event string) bool {
+	if len(self.Events) == 0 {
+		return true
+	}
+	for _, e := range self.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func (self HookConfig) name() string {
+	if self.Command != "" {
+		return self.Command
+	}
+	return self.URL
+}
+
+// Hooks runs the commands and calls the webhooks of the events
+// so that operators can wire arbitrary automation.
+// The hooks run in the background so a slow hook doesn't block the component
+// and the failures are only logged.
+type Hooks struct {
+	logger  log.Logger
+	hooks   []HookConfig
+	timeout time.Duration
+	fails   *prometheus.CounterVec
+}
+
+func NewHooks(logger log.Logger, hooks []HookConfig, timeout time.Duration) (*Hooks, error) {
+	for _, hook := range hooks {
+		if (hook.Command == "") == (hook.URL == "") {
+			return nil, errors.Errorf("a hook needs either a command or a url events:%v", hook.Events)
+		}
+	}
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	return &Hooks{
+		logger:  logger,
+		hooks:   hooks,
+		timeout: timeout,
+		fails: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "hook_fails_total",
+			Help:      "The total number of failed hooks",
+		},
+			[]string{"event"},
+		),
+	}, nil
+}
+
+func (self *Hooks) Notify(_ context.Context, msg Message) error {
+	for _, hook := range self.hooks {
+		if !hook.fires(msg.Event) {
+			continue
+		}
+		go func(hook HookConfig) {
+			ctx, cncl := context.WithTimeout(context.Background(), self.timeout)
+			defer cncl()
+			if err := self.run(ctx, hook, msg); err != nil {
+				self.fails.With(prometheus.Labels{"event": msg.Event}).Inc()
+				level.Error(self.logger).Log("msg", "running hook", "hook", hook.name(), "event", msg.Event, "err", err)
+			}
+		}(hook)
+	}
+	return nil
+}
+
+func (self *Hooks) run(ctx context.Context, hook HookConfig, msg Message) error {
+	if hook.URL != "" {
+		return NewWebhook(hook.URL, self.timeout).Notify(ctx, msg)
+	}
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return errors.Wrap(err, "marshal message")
+	}
+	cmd := exec.CommandContext(ctx, hook.Command, hook.Args...)
+	cmd.Env = hookEnv(hook, msg.Event)
+	cmd.Stdin = bytes.NewReader(payload)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "output:%v", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// hookEnv returns the env of a hook command with only the allowed variables of the process.
+func hookEnv(hook HookConfig, event string) []string {
+	var env []string
+	for _, name := range append(hookBaseEnv, hook.Env...) {
+		if v, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+v)
+		}
+	}
+	return append(env, HookEnvName+"="+event)
+}
@@ -0,0 +1,82 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// TestHooks ensures that the commands get the message and only the allowed env variables
+// and that the webhooks get the message.
+func TestHooks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hooks")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "out")
+
+	testutil.Ok(t, os.Setenv("TELLIOT_TEST_PRIVATE_KEY", "secret"))
+	defer os.Unsetenv("TELLIOT_TEST_PRIVATE_KEY")
+	testutil.Ok(t, os.Setenv("TELLIOT_TEST_HOOK_VAR", "allowed"))
+	defer os.Unsetenv("TELLIOT_TEST_HOOK_VAR")
+
+	received := make(chan Message, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg Message
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- msg
+	}))
+	defer srv.Close()
+
+	_, err = NewHooks(logging.NewLogger(), []HookConfig{{Events: []string{EventLowBalance}}}, 0)
+	testutil.NotOk(t, err, "a hook without a command or a url")
+
+	cmdHook := HookConfig{
+		Events:  []string{EventSolutionFound},
+		Command: "sh",
+		Args:    []string{"-c", "{ env; cat; } > " + out},
+		Env:     []string{"TELLIOT_TEST_HOOK_VAR"},
+	}
+	failHook := HookConfig{Command: "sh", Args: []string{"-c", "echo broken; exit 1"}}
+	urlHook := HookConfig{URL: srv.URL}
+	hooks, err := NewHooks(logging.NewLogger(), []HookConfig{cmdHook, failHook, urlHook}, time.Second)
+	testutil.Ok(t, err)
+
+	testutil.Assert(t, cmdHook.fires(EventSolutionFound), "the hook doesn't fire on its event")
+	testutil.Assert(t, !cmdHook.fires(EventLowBalance), "the hook fires on other events")
+	testutil.Assert(t, urlHook.fires(EventLowBalance), "the hook without events doesn't fire on all events")
+
+	msg := Message{Event: EventSolutionFound, Title: "solution", Data: map[string]string{"nonce": "42"}}
+	ctx := context.Background()
+
+	testutil.Ok(t, hooks.run(ctx, cmdHook, msg))
+	b, err := ioutil.ReadFile(out)
+	testutil.Ok(t, err)
+	output := string(b)
+	testutil.Assert(t, strings.Contains(output, HookEnvName+"="+EventSolutionFound), "missing the event in:%v", output)
+	testutil.Assert(t, strings.Contains(output, "TELLIOT_TEST_HOOK_VAR=allowed"), "missing the allowed variable in:%v", output)
+	testutil.Assert(t, !strings.Contains(output, "TELLIOT_TEST_PRIVATE_KEY"), "the command got a variable that isn't allowed:%v", output)
+	testutil.Assert(t, strings.Contains(output, `"nonce":"42"`), "missing the message in:%v", output)
+
+	err = hooks.run(ctx, failHook, msg)
+	testutil.NotOk(t, err)
+	testutil.Assert(t, strings.Contains(err.Error(), "broken"), "missing the output in the error:%v", err)
+
+	testutil.Ok(t, hooks.run(ctx, urlHook, msg))
+	testutil.Equals(t, "42", (<-received).Data["nonce"])
+}
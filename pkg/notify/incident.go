@@ -0,0 +1,339 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/format"
+)
+
+// The events of the critical conditions that open an incident and the events that resolve it.
+const (
+	EventBreakerReset     = "breaker_reset"
+	EventStakeLost        = "stake_lost"
+	EventStakeRestored    = "stake_restored"
+	EventSubmitterPaused  = "submitter_paused"
+	EventSubmitterResumed = "submitter_resumed"
+	EventNodeUnreachable  = "node_unreachable"
+	EventNodeReachable    = "node_reachable"
+)
+
+// incidentEvents are the events that open an incident by the events that resolve it.
+var incidentEvents = map[string]string{
+	EventBreakerReset:     EventBreakerTripped,
+	EventStakeRestored:    EventStakeLost,
+	EventSubmitterResumed: EventSubmitterPaused,
+	EventNodeReachable:    EventNodeUnreachable,
+}
+
+const (
+	pagerDutyBackend = "pagerduty"
+	opsgenieBackend  = "opsgenie"
+
+	// PagerDutyKeyEnvName is the env variable with the routing key of the PagerDuty Events API v2 integration.
+	PagerDutyKeyEnvName = "PAGERDUTY_ROUTING_KEY"
+	// OpsgenieKeyEnvName is the env variable with the key of the Opsgenie API integration.
+	OpsgenieKeyEnvName = "OPSGENIE_API_KEY"
+
+	DefaultPagerDutyURL = "https://events.pagerduty.com/v2/enqueue"
+	DefaultOpsgenieURL  = "https://api.opsgenie.com/v2/alerts"
+)
+
+// IncidentConfig opens incidents for the critical conditions and resolves them when the conditions clear.
+type IncidentConfig struct {
+	// ResolveDelay is how long a condition must stay cleared before its incident is resolved.
+	// A condition that comes back within the delay keeps the same incident open so a flapping one doesn't page again.
+	ResolveDelay format.Duration
+	PagerDuty    PagerDutyConfig
+	Opsgenie     OpsgenieConfig
+}
+
+// PagerDutyConfig opens the incidents through the PagerDuty Events API v2
+// with the routing key in the PAGERDUTY_ROUTING_KEY env variable.
+type PagerDutyConfig struct {
+	Enabled bool
+	// URL of the events API, DefaultPagerDutyURL when empty.
+	URL string
+}
+
+// OpsgenieConfig opens the incidents as Opsgenie alerts
+// with the API key in the OPSGENIE_API_KEY env variable.
+type OpsgenieConfig struct {
+	Enabled bool
+	// URL of the alerts API, DefaultOpsgenieURL when empty e.g. https://api.eu.opsgenie.com/v2/alerts.
+	URL string
+	// Priority of the alerts from P1 to P5.
+	Priority string
+}
+
+// IncidentClient opens and resolves the incidents of an incident management system.
+// The key deduplicates the incident so repeated triggers update the open one.
+type IncidentClient interface {
+	Trigger(ctx context.Context, key string, msg Message) error
+	Resolve(ctx context.Context, key string, msg Message) error
+}
+
+// Incidents opens an incident for each critical condition and resolves it
+// after the condition stays cleared for the resolve delay.
+// The other events are ignored as they have no event that resolves them.
+type Incidents struct {
+	logger       log.Logger
+	client       IncidentClient
+	timeout      time.Duration
+	resolveDelay time.Duration
+	mtx          sync.Mutex
+	// open are the open incidents by key with the pending resolve timer if any.
+	open map[string]*time.Timer
+}
+
+func NewIncidents(logger log.Logger, client IncidentClient, resolveDelay, timeout time.Duration) *Incidents {
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	return &Incidents{
+		logger:       logger,
+		client:       client,
+		timeout:      timeout,
+		resolveDelay: resolveDelay,
+		open:         make(map[string]*time.Timer),
+	}
+}
+
+func (self *Incidents) Notify(ctx context.Context, msg Message) error {
+	if trigger, ok := incidentEvents[msg.Event]; ok {
+		return self.resolve(ctx, incidentKey(trigger, msg), msg)
+	}
+	if !isIncident(msg.Event) {
+		return nil
+	}
+	key := incidentKey(msg.Event, msg)
+
+	self.mtx.Lock()
+	pending, open := self.open[key]
+	if open {
+		// The condition came back before the incident was resolved.
+		if pending != nil {
+			pending.Stop()
+			self.open[key] = nil
+		}
+		self.mtx.Unlock()
+		return nil
+	}
+	self.open[key] = nil
+	self.mtx.Unlock()
+
+	if err := self.client.Trigger(ctx, key, msg); err != nil {
+		self.mtx.Lock()
+		delete(self.open, key)
+		self.mtx.Unlock()
+		return errors.Wrapf(err, "triggering incident:%v", key)
+	}
+	return nil
+}
+
+func (self *Incidents) resolve(ctx context.Context, key string, msg Message) error {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	pending, open := self.open[key]
+	if !open || pending != nil {
+		return nil
+	}
+	if self.resolveDelay == 0 {
+		delete(self.open, key)
+		return errors.Wrapf(self.client.Resolve(ctx, key, msg), "resolving incident:%v", key)
+	}
+	self.open[key] = time.AfterFunc(self.resolveDelay, func() {
+		self.mtx.Lock()
+		if self.open[key] == nil {
+			// Triggered again within the delay.
+			self.mtx.Unlock()
+			return
+		}
+		delete(self.open, key)
+		self.mtx.Unlock()
+
+		ctx, cncl := context.WithTimeout(context.Background(), self.timeout)
+		defer cncl()
+		if err := self.client.Resolve(ctx, key, msg); err != nil {
+			level.Error(self.logger).Log("msg", "resolving incident", "key", key, "err", err)
+		}
+	})
+	return nil
+}
+
+func isIncident(event string) bool {
+	for _, trigger := range incidentEvents {
+		if trigger == event {
+			return true
+		}
+	}
+	return false
+}
+
+// incidentKey is the deduplication key of the condition of the event,
+// so that each account or node has its own incident.
+func incidentKey(event string, msg Message) string {
+	key := "telliot/" + event
+	for _, k := range []string{"account", "node"} {
+		if v := msg.Data[k]; v != "" {
+			key += "/" + v
+		}
+	}
+	return key
+}
+
+// PagerDuty sends the incidents to the PagerDuty Events API v2.
+type PagerDuty struct {
+	url    string
+	key    string
+	client *http.Client
+}
+
+func NewPagerDuty(cfg PagerDutyConfig, routingKey string, timeout time.Duration) (*PagerDuty, error) {
+	if routingKey == "" {
+		return nil, errors.Errorf("the PagerDuty incidents need a routing key in the %v env variable", PagerDutyKeyEnvName)
+	}
+	if cfg.URL == "" {
+		cfg.URL = DefaultPagerDutyURL
+	}
+	return &PagerDuty{
+		url:    cfg.URL,
+		key:    routingKey,
+		client: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+func (self *PagerDuty) Trigger(ctx context.Context, key string, msg Message) error {
+	source, _ := os.Hostname()
+	if source == "" {
+		source = "telliot"
+	}
+	details := map[string]string{"event": msg.Event, "body": msg.Body}
+	for k, v := range msg.Data {
+		details[k] = v
+	}
+	return self.send(ctx, map[string]interface{}{
+		"routing_key":  self.key,
+		"event_action": "trigger",
+		"dedup_key":    key,
+		"payload": map[string]interface{}{
+			"summary":        msg.Title,
+			"source":         source,
+			"severity":       "critical",
+			"timestamp":      msg.Time.Format(time.RFC3339),
+			"component":      "telliot",
+			"class":          msg.Event,
+			"custom_details": details,
+		},
+	})
+}
+
+func (self *PagerDuty) Resolve(ctx context.Context, key string, _ Message) error {
+	return self.send(ctx, map[string]interface{}{
+		"routing_key":  self.key,
+		"event_action": "resolve",
+		"dedup_key":    key,
+	})
+}
+
+func (self *PagerDuty) send(ctx context.Context, event interface{}) error {
+	return postIncident(ctx, self.client, self.url, "", event)
+}
+
+// Opsgenie sends the incidents as Opsgenie alerts.
+type Opsgenie struct {
+	url      string
+	key      string
+	priority string
+	client   *http.Client
+}
+
+func NewOpsgenie(cfg OpsgenieConfig, apiKey string, timeout time.Duration) (*Opsgenie, error) {
+	if apiKey == "" {
+		return nil, errors.Errorf("the Opsgenie incidents need an API key in the %v env variable", OpsgenieKeyEnvName)
+	}
+	if cfg.URL == "" {
+		cfg.URL = DefaultOpsgenieURL
+	}
+	switch cfg.Priority {
+	case "":
+		cfg.Priority = "P1"
+	case "P1", "P2", "P3", "P4", "P5":
+	default:
+		return nil, errors.Errorf("invalid Opsgenie priority:%v", cfg.Priority)
+	}
+	return &Opsgenie{
+		url:      strings.TrimSuffix(cfg.URL, "/"),
+		key:      apiKey,
+		priority: cfg.Priority,
+		client:   &http.Client{Timeout: timeout},
+	}, nil
+}
+
+func (self *Opsgenie) Trigger(ctx context.Context, key string, msg Message) error {
+	details := map[string]string{"event": msg.Event}
+	for k, v := range msg.Data {
+		details[k] = v
+	}
+	// The alias deduplicates the alert while it is open.
+	return postIncident(ctx, self.client, self.url, self.key, map[string]interface{}{
+		"message":     msg.Title,
+		"alias":       key,
+		"description": msg.Body,
+		"priority":    self.priority,
+		"source":      "telliot",
+		"tags":        []string{"telliot", msg.Event},
+		"details":     details,
+	})
+}
+
+func (self *Opsgenie) Resolve(ctx context.Context, key string, msg Message) error {
+	u := self.url + "/" + url.PathEscape(key) + "/close?identifierType=alias"
+	return postIncident(ctx, self.client, u, self.key, map[string]interface{}{
+		"source": "telliot",
+		"note":   msg.Title,
+	})
+}
+
+// postIncident sends the JSON of the event with the Opsgenie key when set.
+func postIncident(ctx context.Context, client *http.Client, url, genieKey string, event interface{}) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "marshal event")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if genieKey != "" {
+		req.Header.Set("Authorization", "GenieKey "+genieKey)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "send request")
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "read response body")
+	}
+	if resp.StatusCode/100 != 2 {
+		return errors.Errorf("response status code not OK code:%v, payload:%v", resp.StatusCode, string(data))
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+type incidentRequest struct {
+	path   string
+	query  string
+	auth   string
+	events map[string]interface{}
+}
+
+// newIncidentServer records the requests and responds with the status code.
+func newIncidentServer(t *testing.T, code int) (*httptest.Server, chan incidentRequest) {
+	requests := make(chan incidentRequest, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := incidentRequest{path: r.URL.EscapedPath(), query: r.URL.RawQuery, auth: r.Header.Get("Authorization")}
+		if err := json.NewDecoder(r.Body).Decode(&req.events); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		requests <- req
+		w.WriteHeader(code)
+	}))
+	return srv, requests
+}
+
+func tripped(account string) Message {
+	return Message{
+		Event:    EventBreakerTripped,
+		Severity: SeverityCritical,
+		Title:    "Circuit breaker tripped",
+		Body:     "Too many failed submissions.",
+		Time:     time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC),
+		Data:     map[string]string{"account": account},
+	}
+}
+
+func TestPagerDuty(t *testing.T) {
+	srv, requests := newIncidentServer(t, http.StatusAccepted)
+	defer srv.Close()
+
+	_, err := NewPagerDuty(PagerDutyConfig{URL: srv.URL}, "", time.Second)
+	testutil.NotOk(t, err, "PagerDuty without a routing key")
+	pd, err := NewPagerDuty(PagerDutyConfig{URL: srv.URL}, "routing", time.Second)
+	testutil.Ok(t, err)
+
+	msg := tripped("0x1")
+	testutil.Ok(t, pd.Trigger(context.Background(), "telliot/breaker_tripped/0x1", msg))
+	req := <-requests
+	testutil.Equals(t, "routing", req.events["routing_key"])
+	testutil.Equals(t, "trigger", req.events["event_action"])
+	testutil.Equals(t, "telliot/breaker_tripped/0x1", req.events["dedup_key"])
+	payload := req.events["payload"].(map[string]interface{})
+	testutil.Equals(t, "Circuit breaker tripped", payload["summary"])
+	testutil.Equals(t, "critical", payload["severity"])
+	testutil.Equals(t, "2021-06-01T12:00:00Z", payload["timestamp"])
+	testutil.Equals(t, map[string]interface{}{"event": EventBreakerTripped, "body": msg.Body, "account": "0x1"}, payload["custom_details"])
+
+	testutil.Ok(t, pd.Resolve(context.Background(), "telliot/breaker_tripped/0x1", msg))
+	req = <-requests
+	testutil.Equals(t, map[string]interface{}{"routing_key": "routing", "event_action": "resolve", "dedup_key": "telliot/breaker_tripped/0x1"}, req.events)
+
+	failing, _ := newIncidentServer(t, http.StatusBadRequest)
+	defer failing.Close()
+	pd, err = NewPagerDuty(PagerDutyConfig{URL: failing.URL}, "routing", time.Second)
+	testutil.Ok(t, err)
+	testutil.NotOk(t, pd.Trigger(context.Background(), "telliot/breaker_tripped/0x1", msg), "a rejected event")
+}
+
+func TestOpsgenie(t *testing.T) {
+	srv, requests := newIncidentServer(t, http.StatusAccepted)
+	defer srv.Close()
+
+	_, err := NewOpsgenie(OpsgenieConfig{URL: srv.URL}, "", time.Second)
+	testutil.NotOk(t, err, "Opsgenie without an API key")
+	_, err = NewOpsgenie(OpsgenieConfig{URL: srv.URL, Priority: "P6"}, "key", time.Second)
+	testutil.NotOk(t, err, "an invalid priority")
+	og, err := NewOpsgenie(OpsgenieConfig{URL: srv.URL + "/v2/alerts/"}, "key", time.Second)
+	testutil.Ok(t, err)
+
+	msg := tripped("0x1")
+	testutil.Ok(t, og.Trigger(context.Background(), "telliot/breaker_tripped/0x1", msg))
+	req := <-requests
+	testutil.Equals(t, "/v2/alerts", req.path)
+	testutil.Equals(t, "GenieKey key", req.auth)
+	testutil.Equals(t, "Circuit breaker tripped", req.events["message"])
+	testutil.Equals(t, "telliot/breaker_tripped/0x1", req.events["alias"])
+	testutil.Equals(t, "P1", req.events["priority"])
+	testutil.Equals(t, []interface{}{"telliot", EventBreakerTripped}, req.events["tags"])
+	testutil.Equals(t, map[string]interface{}{"event": EventBreakerTripped, "account": "0x1"}, req.events["details"])
+
+	testutil.Ok(t, og.Resolve(context.Background(), "telliot/breaker_tripped/0x1", msg))
+	req = <-requests
+	testutil.Equals(t, "/v2/alerts/telliot%2Fbreaker_tripped%2F0x1/close", req.path)
+	testutil.Equals(t, "identifierType=alias", req.query)
+	testutil.Equals(t, "GenieKey key", req.auth)
+}
+
+// TestIncidents ensures that a condition opens one incident for each account
+// and that it is resolved only after it stays cleared for the resolve delay.
+func TestIncidents(t *testing.T) {
+	srv, requests := newIncidentServer(t, http.StatusAccepted)
+	defer srv.Close()
+	pd, err := NewPagerDuty(PagerDutyConfig{URL: srv.URL}, "routing", time.Second)
+	testutil.Ok(t, err)
+	ctx := context.Background()
+
+	incidents := NewIncidents(logging.NewLogger(), pd, 0, time.Second)
+	// Not a critical condition.
+	testutil.Ok(t, incidents.Notify(ctx, Message{Event: EventSolutionFound}))
+	// Resolving a condition without an incident.
+	testutil.Ok(t, incidents.Notify(ctx, Message{Event: EventBreakerReset, Data: map[string]string{"account": "0x1"}}))
+
+	testutil.Ok(t, incidents.Notify(ctx, tripped("0x1")))
+	testutil.Ok(t, incidents.Notify(ctx, tripped("0x1")))
+	testutil.Ok(t, incidents.Notify(ctx, tripped("0x2")))
+	testutil.Equals(t, "telliot/breaker_tripped/0x1", (<-requests).events["dedup_key"])
+	testutil.Equals(t, "telliot/breaker_tripped/0x2", (<-requests).events["dedup_key"])
+
+	testutil.Ok(t, incidents.Notify(ctx, Message{Event: EventBreakerReset, Data: map[string]string{"account": "0x1"}}))
+	req := <-requests
+	testutil.Equals(t, "resolve", req.events["event_action"])
+	testutil.Equals(t, "telliot/breaker_tripped/0x1", req.events["dedup_key"])
+	testutil.Equals(t, 0, len(requests))
+
+	// A flapping condition keeps the incident open.
+	incidents = NewIncidents(logging.NewLogger(), pd, 50*time.Millisecond, time.Second)
+	testutil.Ok(t, incidents.Notify(ctx, tripped("0x1")))
+	<-requests
+	testutil.Ok(t, incidents.Notify(ctx, Message{Event: EventBreakerReset, Data: map[string]string{"account": "0x1"}}))
+	testutil.Ok(t, incidents.Notify(ctx, tripped("0x1")))
+	time.Sleep(100 * time.Millisecond)
+	testutil.Equals(t, 0, len(requests))
+
+	testutil.Ok(t, incidents.Notify(ctx, Message{Event: EventBreakerReset, Data: map[string]string{"account": "0x1"}}))
+	select {
+	case req := <-requests:
+		testutil.Equals(t, "resolve", req.events["event_action"])
+	case <-time.After(time.Second):
+		t.Fatal("the incident wasn't resolved after the delay")
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+)
+
+const ComponentName = "notify"
+
+const hooksBackend = "hooks"
+
+// hookOnly are the lifecycle events which are sent only to the hooks.
+var hookOnly = map[string]bool{
+	EventSolutionFound:       true,
+	EventSubmissionConfirmed: true,
+}
+
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+type Config struct {
+	LogLevel string
+	// WebhookURL when set all notifications are also sent as a JSON POST request to this URL.
+	WebhookURL string
+	// Timeout of the webhooks and the hook commands.
+	Timeout format.Duration
+	// Hooks are commands or webhooks fired on events including
+	// the lifecycle events which are too frequent for the other backends.
+	Hooks []HookConfig
+	// Email sends the notifications by email routed by severity.
+	Email EmailConfig
+	// Incidents opens and resolves PagerDuty or Opsgenie incidents for the critical conditions.
+	Incidents IncidentConfig
+}
+
+// Message is a single notification sent to all configured backends.
+type Message struct {
+	Event    string
+	Severity Severity
+	Title    string
+	Body     string
+	Time     time.Time
+	// Data are the details of the event for the hooks e.g. the tx hash.
+	Data map[string]string `json:",omitempty"`
+}
+
+// Notifier sends a message to an external system.
+type Notifier interface {
+	Notify(context.Context, Message) error
+}
+
+// Notify sends messages to all configured backends.
+type Notify struct {
+	logger    log.Logger
+	cfg       Config
+	backends  map[string]Notifier
+	sentCount *prometheus.CounterVec
+	failCount *prometheus.CounterVec
+}
+
+func New(logger log.Logger, cfg Config) (*Notify, error) {
+	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	logger = log.With(logger, "component", ComponentName)
+
+	self := &Notify{
+		logger: logger,
+		cfg:    cfg,
+		backends: map[string]Notifier{
+			"log": &Log{logger: logger},
+		},
+		sentCount: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "sent_total",
+			Help:      "The total number of sent notifications",
+		},
+			[]string{"backend", "event"},
+		),
+		failCount: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "fails_total",
+			Help:      "The total number of failed notifications",
+		},
+			[]string{"backend", "event"},
+		),
+	}
+
+	if cfg.WebhookURL != "" {
+		self.backends["webhook"] = NewWebhook(cfg.WebhookURL, cfg.Timeout.Duration)
+	}
+	if len(cfg.Hooks) > 0 {
+		hooks, err := NewHooks(logger, cfg.Hooks, cfg.Timeout.Duration)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating hooks")
+		}
+		self.backends[hooksBackend] = hooks
+	}
+	if cfg.Email.Enabled {
+		email, err := NewEmail(cfg.Email, os.Getenv(SMTPPasswordEnvName), cfg.Timeout.Duration)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating email notifications")
+		}
+		self.backends[emailBackend] = email
+	}
+	if cfg.Incidents.PagerDuty.Enabled {
+		pagerDuty, err := NewPagerDuty(cfg.Incidents.PagerDuty, os.Getenv(PagerDutyKeyEnvName), cfg.Timeout.Duration)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating PagerDuty incidents")
+		}
+		self.backends[pagerDutyBackend] = NewIncidents(logger, pagerDuty, cfg.Incidents.ResolveDelay.Duration, cfg.Timeout.Duration)
+	}
+	if cfg.Incidents.Opsgenie.Enabled {
+		opsgenie, err := NewOpsgenie(cfg.Incidents.Opsgenie, os.Getenv(OpsgenieKeyEnvName), cfg.Timeout.Duration)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating Opsgenie incidents")
+		}
+		self.backends[opsgenieBackend] = NewIncidents(logger, opsgenie, cfg.Incidents.ResolveDelay.Duration, cfg.Timeout.Duration)
+	}
+
+	return self, nil
+}
+
+// Notify sends the message to all backends.
+// A failing backend doesn't prevent sending to the rest.
+func (self *Notify) Notify(ctx context.Context, msg Message) error {
+	if msg.Time.IsZero() {
+		msg.Time = time.Now()
+	}
+	var lastErr error
+	for name, backend := range self.backends {
+		if hookOnly[msg.Event] && name != hooksBackend {
+			continue
+		}
+		if err := backend.Notify(ctx, msg); err != nil {
+			self.failCount.With(prometheus.Labels{"backend": name, "event": msg.Event}).Inc()
+			level.Error(self.logger).Log("msg", "sending notification", "backend", name, "event", msg.Event, "err", err)
+			lastErr = errors.Wrapf(err, "sending notification to:%v", name)
+			continue
+		}
+		self.sentCount.With(prometheus.Labels{"backend": name, "event": msg.Event}).Inc()
+	}
+	return lastErr
+}
+
+// Log writes the notifications to the logger.
+type Log struct {
+	logger log.Logger
+}
+
+func (self *Log) Notify(_ context.Context, msg Message) error {
+	logger := level.Info(self.logger)
+	switch msg.Severity {
+	case SeverityWarning:
+		logger = level.Warn(self.logger)
+	case SeverityCritical:
+		logger = level.Error(self.logger)
+	}
+	return logger.Log("msg", "notification", "event", msg.Event, "title", msg.Title, "body", msg.Body)
+}
+
+// Webhook sends the notifications as JSON POST requests.
+type Webhook struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhook(url string, timeout time.Duration) *Webhook {
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	return &Webhook{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (self *Webhook) Notify(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return errors.Wrap(err, "marshal message")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, self.url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := self.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "send request")
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "read response body")
+	}
+	if err := resp.Body.Close(); err != nil {
+		return errors.Wrap(err, "close response body")
+	}
+	if resp.StatusCode/100 != 2 {
+		return errors.Errorf("response status code not OK code:%v, payload:%v", resp.StatusCode, string(data))
+	}
+	return nil
+}
@@ -0,0 +1,448 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/errclass"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// The handshake of the external plugins.
+// The plugin is started with the magic cookie, the socket path and the token in its environment,
+// listens on the unix socket, writes "<APIVersion>|unix|<socket>" as the first line of its stdout
+// and then serves the gRPC service in plugin.proto to the calls with the token until it is stopped.
+// The socket is in a dir only accessible by the user running telliot.
+// Serve implements the plugin side.
+const (
+	MagicCookieKey   = "TELLIOT_PLUGIN_MAGIC_COOKIE"
+	MagicCookieValue = "telliot"
+	SocketEnvName    = "TELLIOT_PLUGIN_SOCKET"
+	TokenEnvName     = "TELLIOT_PLUGIN_TOKEN"
+)
+
+// pluginBaseEnv are the env variables of the process passed to all external plugins.
+// The others e.g. the private keys are only passed when listed in the Env of the plugin.
+var pluginBaseEnv = []string{"PATH", "HOME"}
+
+// The delays between the restarts of an external plugin that exited.
+const (
+	minRestartDelay = time.Second
+	maxRestartDelay = time.Minute
+)
+
+// ErrNotRunning is returned by the calls while an external plugin is restarting.
+var ErrNotRunning = errclass.New(errclass.Retryable, "plugin not running")
+
+var restarts = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "telliot",
+	Subsystem: ComponentName,
+	Name:      "restarts_total",
+	Help:      "The total number of restarts of the external plugins",
+}, []string{"plugin"})
+
+// process is a running external plugin.
+type process struct {
+	cmd    *exec.Cmd
+	conn   *grpc.ClientConn
+	dir    string
+	exited chan struct{}
+}
+
+func (self *process) stop() error {
+	self.conn.Close()
+	err := self.cmd.Process.Kill()
+	<-self.exited
+	os.RemoveAll(self.dir)
+	if err != nil && !strings.Contains(err.Error(), "process already finished") {
+		return err
+	}
+	return nil
+}
+
+// external is a plugin running in another process, which is restarted when it exits.
+// The instances created before a restart are created again in the new process on their next call.
+type external struct {
+	logger  log.Logger
+	cfg     ExternalConfig
+	timeout time.Duration
+	name    string
+
+	mtx    sync.Mutex
+	proc   *process
+	gen    uint64
+	closed chan struct{}
+}
+
+func startExternal(logger log.Logger, cfg ExternalConfig, timeout time.Duration) (Plugin, func() error, error) {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	self := &external{
+		logger:  log.With(logger, "plugin", cfg.Command),
+		cfg:     cfg,
+		timeout: timeout,
+		closed:  make(chan struct{}),
+	}
+	proc, err := self.start()
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx, cncl := context.WithTimeout(context.Background(), timeout)
+	defer cncl()
+	var reply NameReply
+	if err := invoke(ctx, proc.conn, methodName, struct{}{}, &reply); err != nil {
+		_ = proc.stop()
+		return nil, nil, errors.Wrap(err, "getting the plugin name")
+	}
+	self.name = reply.Name
+	self.proc = proc
+	go self.supervise(proc)
+	return self, self.close, nil
+}
+
+// start starts the plugin process and connects to it.
+func (self *external) start() (*process, error) {
+	dir, err := ioutil.TempDir("", "telliot-plugin")
+	if err != nil {
+		return nil, errors.Wrap(err, "creating the socket dir")
+	}
+	socket := filepath.Join(dir, "plugin.sock")
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		os.RemoveAll(dir)
+		return nil, errors.Wrap(err, "generating the token")
+	}
+
+	handshake := &handshakeWriter{line: make(chan string, 1)}
+	cmd := exec.Command(self.cfg.Command, self.cfg.Args...)
+	cmd.Env = pluginEnv(self.cfg, socket, hex.EncodeToString(token))
+	cmd.Stdout = handshake
+	cmd.Stderr = logWriter{logger: self.logger}
+	if err := cmd.Start(); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	proc := &process{cmd: cmd, dir: dir, exited: make(chan struct{})}
+	go func() {
+		_ = cmd.Wait()
+		close(proc.exited)
+	}()
+	fail := func(err error) (*process, error) {
+		_ = cmd.Process.Kill()
+		<-proc.exited
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	var line string
+	select {
+	case line = <-handshake.line:
+	case <-proc.exited:
+		return fail(errors.New("exited before the handshake"))
+	case <-time.After(self.timeout):
+		return fail(errors.Errorf("no handshake within:%v", self.timeout))
+	}
+	parts := strings.Split(strings.TrimSpace(line), "|")
+	if len(parts) != 3 {
+		return fail(errors.Errorf("invalid handshake:%q", line))
+	}
+	if version, err := strconv.Atoi(parts[0]); err != nil || version != APIVersion {
+		return fail(errors.Errorf("plugin API version:%v, expected:%v", parts[0], APIVersion))
+	}
+	if parts[1] != "unix" || parts[2] != socket {
+		return fail(errors.Errorf("the plugin should listen on the unix socket:%v, got:%v %v", socket, parts[1], parts[2]))
+	}
+	proc.conn, err = grpc.Dial("unix://"+socket,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithPerRPCCredentials(tokenCreds(hex.EncodeToString(token))),
+	)
+	if err != nil {
+		return fail(errors.Wrap(err, "connecting to the plugin"))
+	}
+	return proc, nil
+}
+
+// supervise restarts the plugin when it exits until it is closed.
+func (self *external) supervise(proc *process) {
+	delay := minRestartDelay
+	for {
+		select {
+		case <-self.closed:
+			return
+		case <-proc.exited:
+		}
+		self.mtx.Lock()
+		self.proc = nil
+		self.mtx.Unlock()
+		proc.conn.Close()
+		os.RemoveAll(proc.dir)
+		level.Error(self.logger).Log("msg", "external plugin exited, restarting", "state", proc.cmd.ProcessState, "delay", delay)
+
+		for {
+			select {
+			case <-self.closed:
+				return
+			case <-time.After(delay):
+			}
+			restarts.With(prometheus.Labels{"plugin": self.name}).Inc()
+			next, err := self.start()
+			if err == nil {
+				proc = next
+				break
+			}
+			level.Error(self.logger).Log("msg", "restarting external plugin", "err", err)
+			if delay *= 2; delay > maxRestartDelay {
+				delay = maxRestartDelay
+			}
+		}
+		delay = minRestartDelay
+
+		self.mtx.Lock()
+		select {
+		case <-self.closed:
+			self.mtx.Unlock()
+			_ = proc.stop()
+			return
+		default:
+		}
+		self.proc = proc
+		self.gen++
+		self.mtx.Unlock()
+		level.Info(self.logger).Log("msg", "restarted external plugin")
+	}
+}
+
+func (self *external) close() error {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	select {
+	case <-self.closed:
+		return nil
+	default:
+	}
+	close(self.closed)
+	if self.proc == nil {
+		return nil
+	}
+	err := self.proc.stop()
+	self.proc = nil
+	return err
+}
+
+// conn returns the connection to the running process and its generation.
+func (self *external) conn() (*grpc.ClientConn, uint64, error) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	if self.proc == nil {
+		return nil, 0, errors.Wrapf(ErrNotRunning, "plugin:%v", self.name)
+	}
+	return self.proc.conn, self.gen, nil
+}
+
+func (self *external) Name() string {
+	return self.name
+}
+
+func (self *external) NewDataSource(symbol, url, param string) (DataSource, error) {
+	inst, err := self.newInstance(methodNewDataSource, NewArgs{Symbol: symbol, URL: url, Param: param})
+	if err != nil {
+		return nil, err
+	}
+	return &remoteDataSource{inst}, nil
+}
+
+func (self *external) NewAggregation(name string) (Aggregation, error) {
+	inst, err := self.newInstance(methodNewAggregation, NewArgs{Name: name})
+	if err != nil {
+		return nil, err
+	}
+	return &remoteAggregation{inst}, nil
+}
+
+func (self *external) NewSubmitStrategy(name string) (SubmitStrategy, error) {
+	inst, err := self.newInstance(methodNewSubmitStrategy, NewArgs{Name: name})
+	if err != nil {
+		return nil, err
+	}
+	return &remoteSubmitStrategy{inst}, nil
+}
+
+func (self *external) newInstance(method string, args NewArgs) (*instance, error) {
+	inst := &instance{external: self, method: method, args: args}
+	conn, gen, err := self.conn()
+	if err != nil {
+		return nil, err
+	}
+	if err := inst.create(conn, gen); err != nil {
+		return nil, err
+	}
+	return inst, nil
+}
+
+// instance is a data source, aggregation or submit strategy created in the plugin process.
+type instance struct {
+	*external
+	method string
+	args   NewArgs
+
+	mtx   sync.Mutex
+	gen   uint64
+	reply NewReply
+}
+
+func (self *instance) create(conn *grpc.ClientConn, gen uint64) error {
+	ctx, cncl := context.WithTimeout(context.Background(), self.timeout)
+	defer cncl()
+	var reply NewReply
+	if err := invoke(ctx, conn, self.method, self.args, &reply); err != nil {
+		return err
+	}
+	self.gen, self.reply = gen, reply
+	return nil
+}
+
+// call calls a method of the instance
+// after creating it again when the plugin was restarted since it was created.
+func (self *instance) call(ctx context.Context, method string, args func(id uint64) interface{}, reply interface{}) error {
+	conn, gen, err := self.conn()
+	if err != nil {
+		return err
+	}
+	self.mtx.Lock()
+	if self.gen != gen {
+		if err := self.create(conn, gen); err != nil {
+			self.mtx.Unlock()
+			return errors.Wrap(err, "creating the instance in the restarted plugin")
+		}
+	}
+	id := self.reply.ID
+	self.mtx.Unlock()
+	return invoke(ctx, conn, method, args(id), reply)
+}
+
+type remoteDataSource struct {
+	*instance
+}
+
+func (self *remoteDataSource) Source() string {
+	return self.reply.Source
+}
+
+func (self *remoteDataSource) Interval() time.Duration {
+	return duration(self.reply.Interval)
+}
+
+func (self *remoteDataSource) Get(ctx context.Context) (float64, error) {
+	var reply GetReply
+	err := self.call(ctx, methodGet, func(id uint64) interface{} { return CallArgs{ID: id} }, &reply)
+	return reply.Value, err
+}
+
+type remoteAggregation struct {
+	*instance
+}
+
+func (self *remoteAggregation) Symbols() []string {
+	return self.reply.Symbols
+}
+
+func (self *remoteAggregation) Window() time.Duration {
+	return duration(self.reply.Window)
+}
+
+func (self *remoteAggregation) Aggregate(ts time.Time, samples map[string][]Sample) (float64, float64, error) {
+	wire := make(map[string][]wireSample, len(samples))
+	for symbol, ss := range samples {
+		for _, s := range ss {
+			wire[symbol] = append(wire[symbol], wireSample(s))
+		}
+	}
+	var reply AggregateReply
+	err := self.call(context.Background(), methodAggregate, func(id uint64) interface{} {
+		return AggregateArgs{ID: id, TS: ts, Samples: wire}
+	}, &reply)
+	return reply.Value, reply.Confidence, err
+}
+
+type remoteSubmitStrategy struct {
+	*instance
+}
+
+func (self *remoteSubmitStrategy) Name() string {
+	return self.reply.Name
+}
+
+func (self *remoteSubmitStrategy) Wait(ctx context.Context) error {
+	return self.call(ctx, methodWait, func(id uint64) interface{} { return CallArgs{ID: id} }, nil)
+}
+
+// pluginEnv returns the env of an external plugin with only the allowed variables of the process.
+func pluginEnv(cfg ExternalConfig, socket, token string) []string {
+	var env []string
+	for _, name := range append(pluginBaseEnv, cfg.Env...) {
+		if v, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+v)
+		}
+	}
+	return append(env,
+		MagicCookieKey+"="+MagicCookieValue,
+		SocketEnvName+"="+socket,
+		TokenEnvName+"="+token,
+	)
+}
+
+// handshakeWriter passes the first line written to the stdout of an external plugin
+// and ignores anything else.
+type handshakeWriter struct {
+	mtx  sync.Mutex
+	buf  bytes.Buffer
+	line chan string
+	done bool
+}
+
+func (self *handshakeWriter) Write(p []byte) (int, error) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	if self.done {
+		return len(p), nil
+	}
+	self.buf.Write(p)
+	if i := bytes.IndexByte(self.buf.Bytes(), '\n'); i >= 0 {
+		self.line <- string(self.buf.Bytes()[:i])
+		self.done = true
+		self.buf.Reset()
+	}
+	return len(p), nil
+}
+
+// logWriter logs the stderr output of an external plugin.
+type logWriter struct {
+	logger log.Logger
+}
+
+func (self logWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimSpace(string(p)), "\n") {
+		level.Info(self.logger).Log("msg", line)
+	}
+	return len(p), nil
+}
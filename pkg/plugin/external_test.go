@@ -0,0 +1,190 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type testPlugin struct{}
+
+func (testPlugin) Name() string { return "test" }
+
+func (testPlugin) NewDataSource(symbol, url, param string) (DataSource, error) {
+	return &testSource{source: symbol + ":" + param}, nil
+}
+
+func (testPlugin) NewAggregation(name string) (Aggregation, error) {
+	if name != "mean" {
+		return nil, errors.Wrapf(ErrNotProvided, "aggregation:%v", name)
+	}
+	return testMean{}, nil
+}
+
+func (testPlugin) NewSubmitStrategy(name string) (SubmitStrategy, error) {
+	return testStrategy{}, nil
+}
+
+type testSource struct {
+	source string
+}
+
+func (self *testSource) Source() string          { return self.source }
+func (self *testSource) Interval() time.Duration { return time.Minute }
+func (self *testSource) Get(ctx context.Context) (float64, error) {
+	switch self.source {
+	case "ETH/USD:env":
+		// Whether the secret of the test leaked to the plugin.
+		if os.Getenv("TELLIOT_TEST_SECRET") != "" {
+			return 1, nil
+		}
+		return 0, nil
+	case "ETH/USD:exit":
+		os.Exit(1)
+	}
+	return 42, nil
+}
+
+type testMean struct{}
+
+func (testMean) Symbols() []string     { return []string{"ETH/USD"} }
+func (testMean) Window() time.Duration { return time.Hour }
+func (testMean) Aggregate(ts time.Time, samples map[string][]Sample) (float64, float64, error) {
+	var sum float64
+	for _, s := range samples["ETH/USD"] {
+		sum += s.Value
+	}
+	return sum / float64(len(samples["ETH/USD"])), 100, nil
+}
+
+// testStrategy waits until it is canceled.
+type testStrategy struct{}
+
+func (testStrategy) Name() string { return "forever" }
+func (testStrategy) Wait(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// TestRemote ensures that a plugin served over gRPC behaves like the local one
+// and only serves the calls with the token.
+func TestRemote(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telliot-plugin-test")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+	l, err := net.Listen("unix", filepath.Join(dir, "plugin.sock"))
+	testutil.Ok(t, err)
+	go func() {
+		_ = serve(l, "secret", testPlugin{})
+	}()
+
+	dial := func(token string) *external {
+		conn, err := grpc.Dial("unix://"+l.Addr().String(),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithPerRPCCredentials(tokenCreds(token)),
+		)
+		testutil.Ok(t, err)
+		return &external{proc: &process{conn: conn}, timeout: 5 * time.Second, closed: make(chan struct{})}
+	}
+
+	wrong := dial("wrong")
+	defer wrong.proc.conn.Close()
+	_, err = wrong.NewDataSource("ETH/USD", "", "spot")
+	testutil.NotOk(t, err)
+
+	p := dial("secret")
+	defer p.proc.conn.Close()
+
+	source, err := p.NewDataSource("ETH/USD", "", "spot")
+	testutil.Ok(t, err)
+	testutil.Equals(t, "ETH/USD:spot", source.Source())
+	testutil.Equals(t, time.Minute, source.Interval())
+	value, err := source.Get(context.Background())
+	testutil.Ok(t, err)
+	testutil.Equals(t, 42.0, value)
+
+	_, err = p.NewAggregation("median")
+	testutil.Assert(t, errors.Is(err, ErrNotProvided), "unexpected error:%v", err)
+	aggr, err := p.NewAggregation("mean")
+	testutil.Ok(t, err)
+	testutil.Equals(t, []string{"ETH/USD"}, aggr.Symbols())
+	testutil.Equals(t, time.Hour, aggr.Window())
+	value, conf, err := aggr.Aggregate(time.Now(), map[string][]Sample{"ETH/USD": {{Value: 1}, {Value: 3}}})
+	testutil.Ok(t, err)
+	testutil.Equals(t, 2.0, value)
+	testutil.Equals(t, 100.0, conf)
+
+	strategy, err := p.NewSubmitStrategy("forever")
+	testutil.Ok(t, err)
+	testutil.Equals(t, "forever", strategy.Name())
+	ctx, cncl := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cncl()
+	testutil.Equals(t, context.DeadlineExceeded, strategy.Wait(ctx))
+}
+
+// TestHelperPlugin is the plugin process started by TestExternal.
+func TestHelperPlugin(t *testing.T) {
+	if os.Getenv("TELLIOT_TEST_PLUGIN") == "" {
+		t.Skip("only run as a plugin")
+	}
+	if err := Serve(testPlugin{}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// TestExternal ensures that an external plugin only gets the allowed env
+// and is restarted with its instances when it exits.
+func TestExternal(t *testing.T) {
+	testutil.Ok(t, os.Setenv("TELLIOT_TEST_PLUGIN", "1"))
+	testutil.Ok(t, os.Setenv("TELLIOT_TEST_SECRET", "1"))
+	defer os.Unsetenv("TELLIOT_TEST_PLUGIN")
+	defer os.Unsetenv("TELLIOT_TEST_SECRET")
+
+	p, closer, err := startExternal(log.NewNopLogger(), ExternalConfig{
+		Command: os.Args[0],
+		Args:    []string{"-test.run=TestHelperPlugin"},
+		Env:     []string{"TELLIOT_TEST_PLUGIN"},
+	}, 10*time.Second)
+	testutil.Ok(t, err)
+	defer closer()
+	testutil.Equals(t, "test", p.Name())
+
+	env, err := p.NewDataSource("ETH/USD", "", "env")
+	testutil.Ok(t, err)
+	value, err := env.Get(context.Background())
+	testutil.Ok(t, err)
+	testutil.Equals(t, 0.0, value)
+
+	exit, err := p.NewDataSource("ETH/USD", "", "exit")
+	testutil.Ok(t, err)
+	_, err = exit.Get(context.Background())
+	testutil.NotOk(t, err)
+
+	// The data source is created again in the restarted process.
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		value, err = env.Get(context.Background())
+		if err == nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	testutil.Ok(t, err)
+	testutil.Equals(t, 0.0, value)
+	testutil.Equals(t, uint64(1), p.(*external).gen)
+}
@@ -0,0 +1,32 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package plugin
+
+import (
+	goplugin "plugin"
+
+	"github.com/pkg/errors"
+)
+
+// openGo opens a Go plugin that exports its Plugin as a variable named Plugin e.g.
+//
+//	var Plugin plugin.Plugin = &myPlugin{}
+//
+// Go plugins need cgo and must be built with the same Go version and dependencies as telliot.
+func openGo(path string) (Plugin, error) {
+	p, err := goplugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	sym, err := p.Lookup("Plugin")
+	if err != nil {
+		return nil, err
+	}
+	// The symbol of a variable is a pointer to it.
+	ptr, ok := sym.(*Plugin)
+	if !ok || *ptr == nil {
+		return nil, errors.Errorf("the Plugin variable is not a plugin.Plugin:%T", sym)
+	}
+	return *ptr, nil
+}
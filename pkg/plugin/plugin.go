@@ -0,0 +1,215 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+// Package plugin defines the interfaces for custom data sources, aggregations and submit strategies
+// and loads their implementations from Go plugins or from plugins running in a separate process
+// so that proprietary feeds and strategies can be added without forking.
+package plugin
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+)
+
+const ComponentName = "plugin"
+
+// APIVersion is increased on every breaking change of the plugin interfaces.
+// Plugins built for another version are refused.
+const APIVersion = 2
+
+// ErrNotProvided is returned by a plugin that doesn't provide the requested implementation.
+var ErrNotProvided = errors.New("not provided by the plugin")
+
+type Config struct {
+	LogLevel string
+	// Go are the paths of the Go plugins built with -buildmode=plugin
+	// against the same telliot version. Each exports its Plugin as a variable named Plugin.
+	Go []string
+	// External are the plugins running in a separate process e.g. written in another language.
+	External []ExternalConfig
+	// StartTimeout is how long to wait for an external plugin to start.
+	StartTimeout format.Duration
+}
+
+// ExternalConfig is the command that starts an external plugin.
+type ExternalConfig struct {
+	Command string
+	Args    []string
+	// Env are the names of the env variables passed to the plugin in addition to PATH and HOME.
+	Env []string
+}
+
+// Ref references a named implementation of a plugin.
+type Ref struct {
+	Plugin string
+	Name   string
+}
+
+func (self Ref) String() string {
+	return self.Plugin + "/" + self.Name
+}
+
+// DataSource is a custom source of values for a symbol of the index tracker.
+type DataSource interface {
+	// Source is a unique name of the source used in the labels of the stored values.
+	Source() string
+	// Get returns the current value.
+	Get(context.Context) (float64, error)
+	// Interval is how often to call Get.
+	Interval() time.Duration
+}
+
+// Sample is a value of a symbol from a single source.
+type Sample struct {
+	Source string
+	Time   time.Time
+	Value  float64
+}
+
+// Aggregation computes the value of a request ID from the samples of its symbols.
+type Aggregation interface {
+	// Symbols are the symbols of the samples passed to Aggregate.
+	Symbols() []string
+	// Window is how long before the timestamp of the value to pass the samples.
+	Window() time.Duration
+	// Aggregate returns the value and the confidence in percent at the timestamp.
+	Aggregate(ts time.Time, samples map[string][]Sample) (value float64, confidence float64, err error)
+}
+
+// SubmitStrategy decides when to broadcast a solution within the timing window.
+type SubmitStrategy interface {
+	Name() string
+	// Wait blocks until it is time to broadcast or the context is canceled.
+	Wait(ctx context.Context) error
+}
+
+// Plugin is the entry point of a plugin.
+// The New methods return ErrNotProvided for the implementations the plugin doesn't provide.
+type Plugin interface {
+	Name() string
+	// NewDataSource returns the source for an endpoint of a symbol in the index file.
+	NewDataSource(symbol, url, param string) (DataSource, error)
+	NewAggregation(name string) (Aggregation, error)
+	NewSubmitStrategy(name string) (SubmitStrategy, error)
+}
+
+// Plugins are the loaded plugins by name.
+// A nil Plugins is valid and has no plugins.
+type Plugins struct {
+	logger  log.Logger
+	plugins map[string]Plugin
+	closers []func() error
+}
+
+// Load opens the Go plugins and starts the external plugins.
+// Close stops the external plugins.
+func Load(logger log.Logger, cfg Config) (*Plugins, error) {
+	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	self := &Plugins{
+		logger:  log.With(logger, "component", ComponentName),
+		plugins: make(map[string]Plugin),
+	}
+	for _, path := range cfg.Go {
+		p, err := openGo(path)
+		if err != nil {
+			self.Close()
+			return nil, errors.Wrapf(err, "opening Go plugin:%v", path)
+		}
+		if err := self.add(p); err != nil {
+			self.Close()
+			return nil, err
+		}
+		level.Info(self.logger).Log("msg", "loaded Go plugin", "name", p.Name(), "path", path)
+	}
+	for _, ext := range cfg.External {
+		p, closer, err := startExternal(self.logger, ext, cfg.StartTimeout.Duration)
+		if err != nil {
+			self.Close()
+			return nil, errors.Wrapf(err, "starting external plugin:%v", ext.Command)
+		}
+		self.closers = append(self.closers, closer)
+		if err := self.add(p); err != nil {
+			self.Close()
+			return nil, err
+		}
+		level.Info(self.logger).Log("msg", "started external plugin", "name", p.Name(), "command", ext.Command)
+	}
+	return self, nil
+}
+
+func (self *Plugins) add(p Plugin) error {
+	if _, ok := self.plugins[p.Name()]; ok {
+		return errors.Errorf("duplicate plugin name:%v", p.Name())
+	}
+	self.plugins[p.Name()] = p
+	return nil
+}
+
+func (self *Plugins) get(name string) (Plugin, error) {
+	if self == nil {
+		return nil, errors.Errorf("plugin not loaded:%v", name)
+	}
+	p, ok := self.plugins[name]
+	if !ok {
+		return nil, errors.Errorf("plugin not loaded:%v", name)
+	}
+	return p, nil
+}
+
+func (self *Plugins) DataSource(plugin, symbol, url, param string) (DataSource, error) {
+	p, err := self.get(plugin)
+	if err != nil {
+		return nil, err
+	}
+	source, err := p.NewDataSource(symbol, url, param)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating data source plugin:%v symbol:%v", plugin, symbol)
+	}
+	return source, nil
+}
+
+func (self *Plugins) Aggregation(ref Ref) (Aggregation, error) {
+	p, err := self.get(ref.Plugin)
+	if err != nil {
+		return nil, err
+	}
+	aggr, err := p.NewAggregation(ref.Name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating aggregation:%v", ref)
+	}
+	return aggr, nil
+}
+
+func (self *Plugins) SubmitStrategy(ref Ref) (SubmitStrategy, error) {
+	p, err := self.get(ref.Plugin)
+	if err != nil {
+		return nil, err
+	}
+	strategy, err := p.NewSubmitStrategy(ref.Name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating submit strategy:%v", ref)
+	}
+	return strategy, nil
+}
+
+// Close stops the external plugins.
+func (self *Plugins) Close() {
+	if self == nil {
+		return
+	}
+	for _, closer := range self.closers {
+		if err := closer(); err != nil {
+			level.Error(self.logger).Log("msg", "stopping external plugin", "err", err)
+		}
+	}
+	self.closers = nil
+}
@@ -0,0 +1,207 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// The external plugins serve the gRPC service in plugin.proto.
+// All methods take and return a google.protobuf.Struct with the fields of the types below
+// so that a plugin in any language only needs the well known types and no generated code.
+const serviceName = "telliot.plugin.v1.Plugin"
+
+// The methods of the service.
+const (
+	methodName              = "Name"
+	methodNewDataSource     = "NewDataSource"
+	methodNewAggregation    = "NewAggregation"
+	methodNewSubmitStrategy = "NewSubmitStrategy"
+	methodGet               = "Get"
+	methodAggregate         = "Aggregate"
+	methodWait              = "Wait"
+)
+
+// tokenKey is the metadata key of the token that authenticates the calls of telliot.
+const tokenKey = "authorization"
+
+// The fields of the requests and responses of the methods.
+type (
+	NameReply struct {
+		Name string `json:"name"`
+	}
+	NewArgs struct {
+		Symbol string `json:"symbol,omitempty"`
+		URL    string `json:"url,omitempty"`
+		Param  string `json:"param,omitempty"`
+		Name   string `json:"name,omitempty"`
+	}
+	// NewReply identifies the created instance in the later calls.
+	// The durations are in seconds.
+	NewReply struct {
+		ID       uint64   `json:"id"`
+		Source   string   `json:"source,omitempty"`
+		Name     string   `json:"name,omitempty"`
+		Interval float64  `json:"interval,omitempty"`
+		Symbols  []string `json:"symbols,omitempty"`
+		Window   float64  `json:"window,omitempty"`
+	}
+	CallArgs struct {
+		ID uint64 `json:"id"`
+	}
+	GetReply struct {
+		Value float64 `json:"value"`
+	}
+	// AggregateArgs has the samples by symbol, the times are in RFC 3339.
+	AggregateArgs struct {
+		ID      uint64                  `json:"id"`
+		TS      time.Time               `json:"ts"`
+		Samples map[string][]wireSample `json:"samples"`
+	}
+	AggregateReply struct {
+		Value      float64 `json:"value"`
+		Confidence float64 `json:"confidence"`
+	}
+)
+
+type wireSample struct {
+	Source string    `json:"source"`
+	Time   time.Time `json:"time"`
+	Value  float64   `json:"value"`
+}
+
+func seconds(d time.Duration) float64 {
+	return d.Seconds()
+}
+
+func duration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+func toStruct(v interface{}) (*structpb.Struct, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal message")
+	}
+	m := make(map[string]interface{})
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, errors.Wrap(err, "unmarshal message")
+	}
+	return structpb.NewStruct(m)
+}
+
+func fromStruct(s *structpb.Struct, v interface{}) error {
+	b, err := json.Marshal(s.AsMap())
+	if err != nil {
+		return errors.Wrap(err, "marshal message")
+	}
+	return errors.Wrap(json.Unmarshal(b, v), "unmarshal message")
+}
+
+// invoke calls a method of the plugin.
+func invoke(ctx context.Context, conn *grpc.ClientConn, method string, args, reply interface{}) error {
+	in, err := toStruct(args)
+	if err != nil {
+		return err
+	}
+	out := &structpb.Struct{}
+	if err := conn.Invoke(ctx, "/"+serviceName+"/"+method, in, out); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		s := status.Convert(err)
+		if s.Code() == codes.Unimplemented {
+			return errors.Wrap(ErrNotProvided, s.Message())
+		}
+		return errors.Errorf("plugin call:%v code:%v error:%v", method, s.Code(), s.Message())
+	}
+	if reply == nil {
+		return nil
+	}
+	return fromStruct(out, reply)
+}
+
+// handler is the plugin side of a method.
+type handler func(ctx context.Context, self *service, in *structpb.Struct) (interface{}, error)
+
+// serviceDesc describes the service without generated code.
+func serviceDesc(handlers map[string]handler) *grpc.ServiceDesc {
+	desc := &grpc.ServiceDesc{
+		ServiceName: serviceName,
+		HandlerType: (*interface{})(nil),
+		Metadata:    "plugin.proto",
+	}
+	for name, h := range handlers {
+		name, h := name, h
+		desc.Methods = append(desc.Methods, grpc.MethodDesc{
+			MethodName: name,
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := &structpb.Struct{}
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				call := func(ctx context.Context, req interface{}) (interface{}, error) {
+					reply, err := h(ctx, srv.(*service), req.(*structpb.Struct))
+					if err != nil {
+						return nil, statusErr(err)
+					}
+					return toStruct(reply)
+				}
+				if interceptor == nil {
+					return call(ctx, in)
+				}
+				return interceptor(ctx, in, &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + serviceName + "/" + name}, call)
+			},
+		})
+	}
+	return desc
+}
+
+// statusErr returns the errors of the plugin with the codes that telliot matches.
+func statusErr(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	switch {
+	case errors.Is(err, ErrNotProvided):
+		return status.Error(codes.Unimplemented, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	return status.Error(codes.Unknown, err.Error())
+}
+
+// authenticate rejects the calls without the token that telliot passed to the plugin.
+func authenticate(token string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if vals := md.Get(tokenKey); len(vals) != 1 || vals[0] != "Bearer "+token {
+			return nil, status.Error(codes.Unauthenticated, "invalid plugin token")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// tokenCreds sends the token with every call.
+// The connection is a unix socket in a private dir so it doesn't need transport security.
+type tokenCreds string
+
+func (self tokenCreds) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{tokenKey: "Bearer " + string(self)}, nil
+}
+
+func (self tokenCreds) RequireTransportSecurity() bool {
+	return false
+}
@@ -0,0 +1,172 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Serve runs a plugin in the process started by telliot until telliot stops it.
+// It is called from the main function of the plugin e.g.
+//
+//	func main() {
+//		if err := plugin.Serve(&myPlugin{}); err != nil {
+//			fmt.Fprintln(os.Stderr, err)
+//			os.Exit(1)
+//		}
+//	}
+func Serve(p Plugin) error {
+	if os.Getenv(MagicCookieKey) != MagicCookieValue {
+		return errors.New("this is a telliot plugin, add it to the Plugins.External config to use it")
+	}
+	socket, token := os.Getenv(SocketEnvName), os.Getenv(TokenEnvName)
+	if socket == "" || token == "" {
+		return errors.Errorf("missing %v or %v, the plugin needs telliot with plugin API version:%v", SocketEnvName, TokenEnvName, APIVersion)
+	}
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		return errors.Wrap(err, "listening for telliot")
+	}
+	fmt.Printf("%d|unix|%s\n", APIVersion, socket)
+	return serve(l, token, p)
+}
+
+// serve serves the plugin to the calls with the token.
+func serve(l net.Listener, token string, p Plugin) error {
+	srv := grpc.NewServer(grpc.UnaryInterceptor(authenticate(token)))
+	srv.RegisterService(serviceDesc(handlers), newService(p))
+	return srv.Serve(l)
+}
+
+// service exposes a plugin and the instances created by it.
+type service struct {
+	plugin Plugin
+
+	mtx        sync.Mutex
+	lastID     uint64
+	sources    map[uint64]DataSource
+	aggrs      map[uint64]Aggregation
+	strategies map[uint64]SubmitStrategy
+}
+
+func newService(p Plugin) *service {
+	return &service{
+		plugin:     p,
+		sources:    make(map[uint64]DataSource),
+		aggrs:      make(map[uint64]Aggregation),
+		strategies: make(map[uint64]SubmitStrategy),
+	}
+}
+
+func unknown(kind string, id uint64) error {
+	return status.Errorf(codes.NotFound, "unknown %v:%v", kind, id)
+}
+
+var handlers = map[string]handler{
+	methodName: func(_ context.Context, self *service, _ *structpb.Struct) (interface{}, error) {
+		return NameReply{Name: self.plugin.Name()}, nil
+	},
+	methodNewDataSource: func(_ context.Context, self *service, in *structpb.Struct) (interface{}, error) {
+		var args NewArgs
+		if err := fromStruct(in, &args); err != nil {
+			return nil, err
+		}
+		source, err := self.plugin.NewDataSource(args.Symbol, args.URL, args.Param)
+		if err != nil {
+			return nil, err
+		}
+		self.mtx.Lock()
+		defer self.mtx.Unlock()
+		self.lastID++
+		self.sources[self.lastID] = source
+		return NewReply{ID: self.lastID, Source: source.Source(), Interval: seconds(source.Interval())}, nil
+	},
+	methodNewAggregation: func(_ context.Context, self *service, in *structpb.Struct) (interface{}, error) {
+		var args NewArgs
+		if err := fromStruct(in, &args); err != nil {
+			return nil, err
+		}
+		aggr, err := self.plugin.NewAggregation(args.Name)
+		if err != nil {
+			return nil, err
+		}
+		self.mtx.Lock()
+		defer self.mtx.Unlock()
+		self.lastID++
+		self.aggrs[self.lastID] = aggr
+		return NewReply{ID: self.lastID, Symbols: aggr.Symbols(), Window: seconds(aggr.Window())}, nil
+	},
+	methodNewSubmitStrategy: func(_ context.Context, self *service, in *structpb.Struct) (interface{}, error) {
+		var args NewArgs
+		if err := fromStruct(in, &args); err != nil {
+			return nil, err
+		}
+		strategy, err := self.plugin.NewSubmitStrategy(args.Name)
+		if err != nil {
+			return nil, err
+		}
+		self.mtx.Lock()
+		defer self.mtx.Unlock()
+		self.lastID++
+		self.strategies[self.lastID] = strategy
+		return NewReply{ID: self.lastID, Name: strategy.Name()}, nil
+	},
+	methodGet: func(ctx context.Context, self *service, in *structpb.Struct) (interface{}, error) {
+		var args CallArgs
+		if err := fromStruct(in, &args); err != nil {
+			return nil, err
+		}
+		self.mtx.Lock()
+		source, ok := self.sources[args.ID]
+		self.mtx.Unlock()
+		if !ok {
+			return nil, unknown("data source", args.ID)
+		}
+		value, err := source.Get(ctx)
+		return GetReply{Value: value}, err
+	},
+	methodAggregate: func(_ context.Context, self *service, in *structpb.Struct) (interface{}, error) {
+		var args AggregateArgs
+		if err := fromStruct(in, &args); err != nil {
+			return nil, err
+		}
+		self.mtx.Lock()
+		aggr, ok := self.aggrs[args.ID]
+		self.mtx.Unlock()
+		if !ok {
+			return nil, unknown("aggregation", args.ID)
+		}
+		samples := make(map[string][]Sample, len(args.Samples))
+		for symbol, ss := range args.Samples {
+			for _, s := range ss {
+				samples[symbol] = append(samples[symbol], Sample(s))
+			}
+		}
+		value, conf, err := aggr.Aggregate(args.TS, samples)
+		return AggregateReply{Value: value, Confidence: conf}, err
+	},
+	methodWait: func(ctx context.Context, self *service, in *structpb.Struct) (interface{}, error) {
+		var args CallArgs
+		if err := fromStruct(in, &args); err != nil {
+			return nil, err
+		}
+		self.mtx.Lock()
+		strategy, ok := self.strategies[args.ID]
+		self.mtx.Unlock()
+		if !ok {
+			return nil, unknown("submit strategy", args.ID)
+		}
+		return struct{}{}, strategy.Wait(ctx)
+	},
+}
@@ -0,0 +1,98 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package psr
+
+import (
+	"math/big"
+	"sync"
+	"time"
+)
+
+type cacheKey struct {
+	id int64
+	ts int64
+}
+
+type cacheEntry struct {
+	done    chan struct{}
+	value   *big.Int
+	err     error
+	created time.Time
+}
+
+// Cache keeps the values of a PSR for a short time so that the components
+// asking for the same value at about the same time evaluate it only once.
+// The timestamps are rounded down to the resolution and concurrent requests
+// for the same value wait for a single evaluation. Errors are not cached.
+// It is safe for concurrent use.
+type Cache struct {
+	psr        Getter
+	resolution time.Duration
+	ttl        time.Duration
+	mtx        sync.Mutex
+	entries    map[cacheKey]*cacheEntry
+}
+
+func NewCache(psr Getter, resolution, ttl time.Duration) *Cache {
+	return &Cache{
+		psr:        psr,
+		resolution: resolution,
+		ttl:        ttl,
+		entries:    make(map[cacheKey]*cacheEntry),
+	}
+}
+
+func (self *Cache) GetValue(reqID int64, ts time.Time) (*big.Int, error) {
+	ts = ts.Truncate(self.resolution)
+	key := cacheKey{id: reqID, ts: ts.UnixNano()}
+
+	self.mtx.Lock()
+	now := time.Now()
+	for k, e := range self.entries {
+		if now.Sub(e.created) > self.ttl {
+			delete(self.entries, k)
+		}
+	}
+	entry, ok := self.entries[key]
+	if !ok {
+		entry = &cacheEntry{done: make(chan struct{}), created: now}
+		self.entries[key] = entry
+	}
+	self.mtx.Unlock()
+
+	if ok {
+		<-entry.done
+	} else {
+		entry.value, entry.err = self.psr.GetValue(reqID, ts)
+		close(entry.done)
+		if entry.err != nil {
+			self.mtx.Lock()
+			if self.entries[key] == entry {
+				delete(self.entries, key)
+			}
+			self.mtx.Unlock()
+		}
+	}
+	if entry.err != nil || entry.value == nil {
+		return nil, entry.err
+	}
+	return new(big.Int).Set(entry.value), nil
+}
+
+// GetValues evaluates the values of the request IDs concurrently.
+// The values and errors are in the order of the IDs.
+func GetValues(psr Getter, reqIDs []int64, ts time.Time) ([]*big.Int, []error) {
+	values := make([]*big.Int, len(reqIDs))
+	errs := make([]error, len(reqIDs))
+	var wg sync.WaitGroup
+	for i, id := range reqIDs {
+		wg.Add(1)
+		go func(i int, id int64) {
+			defer wg.Done()
+			values[i], errs[i] = psr.GetValue(id, ts)
+		}(i, id)
+	}
+	wg.Wait()
+	return values, errs
+}
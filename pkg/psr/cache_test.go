@@ -0,0 +1,67 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package psr
+
+import (
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// countingGetter counts the evaluations and holds them until released.
+type countingGetter struct {
+	getter
+	calls   int64
+	release chan struct{}
+}
+
+func (self *countingGetter) GetValue(reqID int64, ts time.Time) (*big.Int, error) {
+	atomic.AddInt64(&self.calls, 1)
+	<-self.release
+	return self.getter.GetValue(reqID, ts)
+}
+
+// TestCache ensures that the concurrent requests for the same value
+// within the resolution are evaluated once and the errors are not cached.
+func TestCache(t *testing.T) {
+	psr := &countingGetter{getter: getter{2: errors.New("no value")}, release: make(chan struct{})}
+	cache := NewCache(psr, 10*time.Second, time.Minute)
+	ts := time.Unix(1000, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			val, err := cache.GetValue(1, ts.Add(time.Duration(i)*time.Second))
+			testutil.Ok(t, err)
+			testutil.Equals(t, new(big.Int).Mul(big.NewInt(10), big.NewInt(1e18)), val)
+		}(i)
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(psr.release)
+	wg.Wait()
+	testutil.Equals(t, int64(1), atomic.LoadInt64(&psr.calls))
+
+	_, err := cache.GetValue(1, ts.Add(10*time.Second))
+	testutil.Ok(t, err)
+	testutil.Equals(t, int64(2), atomic.LoadInt64(&psr.calls))
+
+	_, err = cache.GetValue(2, ts)
+	testutil.NotOk(t, err)
+	_, err = cache.GetValue(2, ts)
+	testutil.NotOk(t, err)
+	testutil.Equals(t, int64(4), atomic.LoadInt64(&psr.calls))
+
+	values, errs := GetValues(cache, []int64{1, 2, 3}, ts)
+	testutil.Ok(t, errs[0])
+	testutil.NotOk(t, errs[1])
+	testutil.Ok(t, errs[2])
+	testutil.Equals(t, new(big.Int).Mul(big.NewInt(30), big.NewInt(1e18)), values[2])
+}
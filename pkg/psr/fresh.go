@@ -0,0 +1,127 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package psr
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/aggregator"
+	"github.com/tellor-io/telliot/pkg/errclass"
+	"github.com/tellor-io/telliot/pkg/format"
+)
+
+// ErrStale is returned when the newest samples for a value are older than the max age.
+var ErrStale = errclass.New(errclass.DataQuality, "stale samples")
+
+// Aggregation is the output of an aggregation used for a PSR value.
+type Aggregation struct {
+	Method     string
+	Symbol     string
+	Value      float64
+	Confidence float64
+	Err        string `json:",omitempty"`
+}
+
+// Fresh wraps the aggregator so that the aggregations
+// return ErrStale when the newest sample of the symbol is too old.
+// MedianAtEOD is not checked as the end of day sources update once a day.
+type Fresh struct {
+	*aggregator.Aggregator
+	maxAge  time.Duration
+	maxAges map[string]format.Duration
+	observe func(Aggregation)
+}
+
+// NewFresh creates a Fresh aggregator.
+// The max ages by symbol override the max age e.g. for the sources that update less often.
+// A zero max age disables the check.
+func NewFresh(aggr *aggregator.Aggregator, maxAge time.Duration, maxAges map[string]format.Duration) *Fresh {
+	return &Fresh{Aggregator: aggr, maxAge: maxAge, maxAges: maxAges}
+}
+
+// Observe sets a function that is called with the output of every aggregation
+// e.g. to show how a PSR value was calculated. It is not safe for concurrent use
+// and should be set before the first aggregation.
+func (self *Fresh) Observe(fn func(Aggregation)) {
+	self.observe = fn
+}
+
+// maxAgeOf returns the max age of the newest sample of the symbol.
+func (self *Fresh) maxAgeOf(symbol string) time.Duration {
+	if maxAge, ok := self.maxAges[symbol]; ok {
+		return maxAge.Duration
+	}
+	return self.maxAge
+}
+
+func (self *Fresh) check(symbol string, at time.Time) error {
+	maxAge := self.maxAgeOf(symbol)
+	if maxAge <= 0 {
+		return nil
+	}
+	last, err := self.Aggregator.LastSample(symbol, at, maxAge)
+	if err != nil {
+		return errors.Wrapf(err, "getting the last sample for symbol:%v", symbol)
+	}
+	if last.IsZero() {
+		return errors.Wrapf(ErrStale, "no samples for symbol:%v in the last:%v", symbol, maxAge)
+	}
+	return nil
+}
+
+func (self *Fresh) record(method, symbol string, val, conf float64, err error) (float64, float64, error) {
+	if self.observe != nil {
+		a := Aggregation{Method: method, Symbol: symbol, Value: val, Confidence: conf}
+		if err != nil {
+			a.Err = err.Error()
+		}
+		self.observe(a)
+	}
+	return val, conf, err
+}
+
+func (self *Fresh) MedianAt(symbol string, at time.Time) (float64, float64, error) {
+	if err := self.check(symbol, at); err != nil {
+		return self.record("median", symbol, 0, 0, err)
+	}
+	val, conf, err := self.Aggregator.MedianAt(symbol, at)
+	return self.record("median", symbol, val, conf, err)
+}
+
+func (self *Fresh) MedianAtEOD(symbol string, at time.Time) (float64, float64, error) {
+	val, conf, err := self.Aggregator.MedianAtEOD(symbol, at)
+	return self.record("medianEOD", symbol, val, conf, err)
+}
+
+func (self *Fresh) MeanAt(symbol string, at time.Time) (float64, float64, error) {
+	if err := self.check(symbol, at); err != nil {
+		return self.record("mean", symbol, 0, 0, err)
+	}
+	val, conf, err := self.Aggregator.MeanAt(symbol, at)
+	return self.record("mean", symbol, val, conf, err)
+}
+
+func (self *Fresh) TimeWeightedAvg(symbol string, at time.Time, lookBack time.Duration) (float64, float64, error) {
+	if err := self.check(symbol, at); err != nil {
+		return self.record("twap:"+lookBack.String(), symbol, 0, 0, err)
+	}
+	val, conf, err := self.Aggregator.TimeWeightedAvg(symbol, at, lookBack)
+	return self.record("twap:"+lookBack.String(), symbol, val, conf, err)
+}
+
+func (self *Fresh) VolumWeightedAvg(symbol string, start, end time.Time, aggrWindow time.Duration) (float64, float64, error) {
+	if err := self.check(symbol, end); err != nil {
+		return self.record("vwap", symbol, 0, 0, err)
+	}
+	val, conf, err := self.Aggregator.VolumWeightedAvg(symbol, start, end, aggrWindow)
+	return self.record("vwap", symbol, val, conf, err)
+}
+
+func (self *Fresh) Samples(symbol string, from, to time.Time) ([]aggregator.Sample, error) {
+	if err := self.check(symbol, to); err != nil {
+		return nil, err
+	}
+	return self.Aggregator.Samples(symbol, from, to)
+}
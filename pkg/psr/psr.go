@@ -0,0 +1,107 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package psr
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/web"
+)
+
+// Getter returns the encoded value to submit for a data ID.
+type Getter interface {
+	GetValue(reqID int64, ts time.Time) (*big.Int, error)
+}
+
+// Observable is a Getter which reports the aggregations of its values.
+type Observable interface {
+	Getter
+	Observe(func(Aggregation))
+}
+
+// Response is the response of the Handler.
+type Response struct {
+	Value *big.Int `json:"value"`
+}
+
+// Handler serves the values of a PSR to the instances running in the submitter role.
+// For example: curl 'localhost:9090/api/v1/psr/tellor?id=1'
+// The optional ts param is a unix timestamp and defaults to now.
+type Handler struct {
+	logger log.Logger
+	psr    Getter
+}
+
+func NewHandler(logger log.Logger, psr Getter) *Handler {
+	return &Handler{logger: logger, psr: psr}
+}
+
+func (self *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	value, code, err := self.value(r)
+	resp := Response{Value: value}
+	if err := web.WriteJSON(w, code, resp, err); err != nil {
+		level.Error(self.logger).Log("msg", "encoding psr response", "err", err)
+	}
+}
+
+func (self *Handler) value(r *http.Request) (*big.Int, int, error) {
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		return nil, http.StatusBadRequest, errors.Wrap(err, "parsing the id")
+	}
+	ts := time.Now()
+	if _ts := r.URL.Query().Get("ts"); _ts != "" {
+		unix, err := strconv.ParseInt(_ts, 10, 64)
+		if err != nil {
+			return nil, http.StatusBadRequest, errors.Wrap(err, "parsing the ts")
+		}
+		ts = time.Unix(unix, 0)
+	}
+	value, err := self.psr.GetValue(id, ts)
+	if errors.Is(err, ErrStale) {
+		// A separate code so that the remote PSR can return ErrStale as well.
+		return nil, http.StatusConflict, err
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return value, http.StatusOK, nil
+}
+
+// Remote gets the values from an instance running in the aggregator role.
+type Remote struct {
+	url    string
+	client *http.Client
+}
+
+func NewRemote(url string, timeout time.Duration) *Remote {
+	return &Remote{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (self *Remote) GetValue(reqID int64, ts time.Time) (*big.Int, error) {
+	var resp Response
+	u := fmt.Sprintf("%v?id=%v&ts=%v", self.url, reqID, ts.Unix())
+	if err := web.GetJSON(context.Background(), self.client, u, &resp); err != nil {
+		var statusErr *web.StatusError
+		if errors.As(err, &statusErr) && statusErr.Code == http.StatusConflict {
+			return nil, errors.Wrapf(ErrStale, "getting remote value for id:%v %v", reqID, statusErr.Message)
+		}
+		return nil, errors.Wrapf(err, "getting remote value for id:%v", reqID)
+	}
+	if resp.Value == nil {
+		return nil, errors.Errorf("no remote value for id:%v", reqID)
+	}
+	return resp.Value, nil
+}
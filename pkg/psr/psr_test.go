@@ -0,0 +1,82 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package psr
+
+import (
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+type getter map[int64]error
+
+func (self getter) GetValue(reqID int64, _ time.Time) (*big.Int, error) {
+	if err := self[reqID]; err != nil {
+		return nil, err
+	}
+	// Beyond int64 like the values with 1e18 granularity.
+	return new(big.Int).Mul(big.NewInt(reqID*10), big.NewInt(1e18)), nil
+}
+
+// TestRemoteStale ensures that the remote PSR returns ErrStale
+// when the values of the aggregator instance are stale.
+func TestRemoteStale(t *testing.T) {
+	srv := httptest.NewServer(NewHandler(logging.NewLogger(), getter{
+		2: errors.Wrap(ErrStale, "no samples"),
+		3: errors.New("not enough confidence"),
+	}))
+	defer srv.Close()
+	remote := NewRemote(srv.URL, time.Second)
+
+	val, err := remote.GetValue(1, time.Now())
+	testutil.Ok(t, err)
+	testutil.Equals(t, "10000000000000000000", val.String())
+
+	_, err = remote.GetValue(2, time.Now())
+	testutil.Assert(t, errors.Is(err, ErrStale), "stale error not returned:%v", err)
+
+	_, err = remote.GetValue(3, time.Now())
+	testutil.NotOk(t, err)
+	testutil.Assert(t, !errors.Is(err, ErrStale), "other errors returned as stale")
+}
+
+func TestHandler(t *testing.T) {
+	handler := NewHandler(logging.NewLogger(), getter{
+		2: errors.Wrap(ErrStale, "no samples"),
+		3: errors.New("not enough confidence"),
+	})
+	for query, exp := range map[string]int{
+		"id=1":          http.StatusOK,
+		"id=1&ts=1000":  http.StatusOK,
+		"":              http.StatusBadRequest,
+		"id=abc":        http.StatusBadRequest,
+		"id=1&ts=later": http.StatusBadRequest,
+		"id=2":          http.StatusConflict,
+		"id=3":          http.StatusInternalServerError,
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/psr/tellor?"+query, nil))
+		testutil.Equals(t, exp, rec.Code, query)
+		testutil.Equals(t, "application/json", rec.Header().Get("Content-Type"), query)
+	}
+}
+
+// TestFreshMaxAges ensures that the max age of a symbol overrides the default one.
+func TestFreshMaxAges(t *testing.T) {
+	fresh := NewFresh(nil, 10*time.Minute, map[string]format.Duration{
+		"DEFITVL": {Duration: 2 * time.Hour},
+		"SLOW":    {},
+	})
+	testutil.Equals(t, 2*time.Hour, fresh.maxAgeOf("DEFITVL"))
+	testutil.Equals(t, 10*time.Minute, fresh.maxAgeOf("ETH/USD"))
+	// A zero max age disables the check without touching the aggregator.
+	testutil.Ok(t, fresh.check("SLOW", time.Now()))
+}
@@ -0,0 +1,54 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package psr
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/tellor-io/telliot/pkg/health"
+)
+
+// Readiness reports whether the PSR has a value for each request ID.
+// The request IDs are not ready while the warmup is in progress
+// or when a value is missing, but only a missing value after the warmup is degraded.
+type Readiness struct {
+	name     string
+	psr      Getter
+	ids      []int64
+	now      func() time.Time
+	warmedUp <-chan struct{}
+}
+
+// NewReadiness creates the readiness of the request IDs.
+// The values are for the time returned by now e.g. the chain time.
+func NewReadiness(name string, psr Getter, ids []int64, now func() time.Time, warmedUp <-chan struct{}) *Readiness {
+	return &Readiness{name: name, psr: psr, ids: ids, now: now, warmedUp: warmedUp}
+}
+
+func (self *Readiness) Health(ctx context.Context) []health.Status {
+	starting := false
+	select {
+	case <-self.warmedUp:
+	default:
+		starting = true
+	}
+
+	ts := self.now()
+	statuses := make([]health.Status, 0, len(self.ids))
+	for _, id := range self.ids {
+		status := health.Status{Name: self.name + ":" + strconv.FormatInt(id, 10), Healthy: true, Ready: true}
+		if starting {
+			status.Ready = false
+			status.Message = health.ErrStarting.Error()
+		} else if _, err := self.psr.GetValue(id, ts); err != nil {
+			status.Healthy = false
+			status.Ready = false
+			status.Message = err.Error()
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
@@ -0,0 +1,31 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package psr
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+func TestReadiness(t *testing.T) {
+	warmedUp := make(chan struct{})
+	readiness := NewReadiness("psrTellor", getter{2: errors.Wrap(ErrStale, "no samples")}, []int64{1, 2}, time.Now, warmedUp)
+
+	// Not ready but healthy during the warmup.
+	for _, s := range readiness.Health(context.Background()) {
+		testutil.Assert(t, !s.Ready && s.Healthy, "status during the warmup:%+v", s)
+	}
+
+	close(warmedUp)
+	statuses := readiness.Health(context.Background())
+	testutil.Equals(t, 2, len(statuses))
+	testutil.Equals(t, "psrTellor:1", statuses[0].Name)
+	testutil.Assert(t, statuses[0].Ready && statuses[0].Healthy, "status with a value:%+v", statuses[0])
+	testutil.Equals(t, "psrTellor:2", statuses[1].Name)
+	testutil.Assert(t, !statuses[1].Ready && !statuses[1].Healthy, "status without a value:%+v", statuses[1])
+}
@@ -0,0 +1,83 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package psr
+
+import (
+	"sync"
+	"time"
+
+	"github.com/tellor-io/telliot/pkg/aggregator"
+)
+
+// sourcesLookBack is how long before a time the last sample of a source is searched for.
+const sourcesLookBack = 15 * time.Minute
+
+// Sources counts the sources that moved the value of a data ID
+// by checking the samples of the symbols of its aggregations.
+type Sources struct {
+	psr     Observable
+	samples func(symbol string, from, to time.Time) ([]aggregator.Sample, error)
+
+	// mtx is held while calculating a value as the aggregations
+	// are collected from a single observer.
+	mtx          sync.Mutex
+	aggregations []Aggregation
+}
+
+// NewSources creates a counter of the sources of the PSR.
+// The PSR should be a separate instance as its observer is replaced.
+func NewSources(p Observable, samples func(symbol string, from, to time.Time) ([]aggregator.Sample, error)) *Sources {
+	self := &Sources{psr: p, samples: samples}
+	p.Observe(self.observe)
+	return self
+}
+
+func (self *Sources) observe(a Aggregation) {
+	self.aggregations = append(self.aggregations, a)
+}
+
+// Moved returns the number of sources whose last sample at the to time
+// changed by at least change percent from their last sample at the from time.
+// A negative change counts the sources that dropped.
+// A source that has samples for more than one symbol of the value is counted once.
+func (self *Sources) Moved(reqID int64, from, to time.Time, change float64) (int, error) {
+	self.mtx.Lock()
+	self.aggregations = nil
+	// The value itself isn't needed and errors are in the aggregations.
+	_, _ = self.psr.GetValue(reqID, to)
+	aggregations := self.aggregations
+	self.mtx.Unlock()
+
+	moved := make(map[string]bool)
+	symbols := make(map[string]bool)
+	for _, a := range aggregations {
+		if symbols[a.Symbol] {
+			continue
+		}
+		symbols[a.Symbol] = true
+		samples, err := self.samples(a.Symbol, from.Add(-sourcesLookBack), to)
+		if err != nil {
+			return 0, err
+		}
+		before := make(map[string]float64)
+		after := make(map[string]float64)
+		// The samples are ordered by time so the last ones win.
+		for _, s := range samples {
+			if !s.Time.After(from) {
+				before[s.Source] = s.Value
+			}
+			after[s.Source] = s.Value
+		}
+		for source, b := range before {
+			if b == 0 {
+				continue
+			}
+			c := (after[source] - b) / b * 100
+			if (change >= 0 && c >= change) || (change < 0 && c <= change) {
+				moved[source] = true
+			}
+		}
+	}
+	return len(moved), nil
+}
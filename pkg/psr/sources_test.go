@@ -0,0 +1,53 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package psr
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/tellor-io/telliot/pkg/aggregator"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+type observable struct {
+	observe func(Aggregation)
+}
+
+func (self *observable) Observe(fn func(Aggregation)) { self.observe = fn }
+
+func (self *observable) GetValue(reqID int64, ts time.Time) (*big.Int, error) {
+	self.observe(Aggregation{Method: "median", Symbol: "ETH/USD"})
+	self.observe(Aggregation{Method: "median", Symbol: "ETH/USD"})
+	return big.NewInt(1), nil
+}
+
+func TestSourcesMoved(t *testing.T) {
+	from := time.Unix(1600000000, 0)
+	to := from.Add(10 * time.Minute)
+	samples := func(symbol string, _, _ time.Time) ([]aggregator.Sample, error) {
+		return []aggregator.Sample{
+			{Source: "a", Time: from.Add(-time.Minute), Value: 100},
+			{Source: "b", Time: from.Add(-time.Minute), Value: 100},
+			{Source: "c", Time: from.Add(-time.Minute), Value: 100},
+			{Source: "a", Time: from.Add(time.Minute), Value: 130},
+			{Source: "b", Time: from.Add(time.Minute), Value: 105},
+			{Source: "c", Time: from.Add(time.Minute), Value: 60},
+			// Without a sample before the from time.
+			{Source: "d", Time: from.Add(time.Minute), Value: 130},
+		}, nil
+	}
+	sources := NewSources(&observable{}, samples)
+
+	moved, err := sources.Moved(1, from, to, 10)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 1, moved)
+	moved, err = sources.Moved(1, from, to, -10)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 1, moved)
+	moved, err = sources.Moved(1, from, to, 5)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 2, moved)
+}
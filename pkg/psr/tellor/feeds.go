@@ -0,0 +1,142 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package tellor
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/format"
+)
+
+// VWAPConfig is the pipeline of a volume weighted average feed like the AMPL/USD 24h VWAP.
+type VWAPConfig struct {
+	Symbol string
+	// Window is the period of the average.
+	Window format.Duration
+	// AggrWindow is the period the prices and volumes of each source are grouped by
+	// before weighting the prices with the volumes.
+	AggrWindow format.Duration
+	// Cutoff is the UTC time of day as 15:04 at which the window ends
+	// so that all values until the next cutoff are for the same window.
+	// Empty uses a rolling window ending at the time of the value.
+	Cutoff string
+}
+
+// window returns the start and end of the average for a value at the given time.
+func (self VWAPConfig) window(at time.Time) (time.Time, time.Time, error) {
+	if self.Window.Duration <= 0 || self.AggrWindow.Duration <= 0 || self.AggrWindow.Duration > self.Window.Duration {
+		return time.Time{}, time.Time{}, errors.Errorf("invalid window:%v or aggregation window:%v", self.Window, self.AggrWindow)
+	}
+	end := at
+	if self.Cutoff != "" {
+		cutoff, err := time.Parse("15:04", self.Cutoff)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.Wrapf(err, "parse cutoff:%v", self.Cutoff)
+		}
+		at = at.UTC()
+		end = time.Date(at.Year(), at.Month(), at.Day(), cutoff.Hour(), cutoff.Minute(), 0, 0, time.UTC)
+		if end.After(at) {
+			end = end.AddDate(0, 0, -1)
+		}
+	}
+	return end.Add(-self.Window.Duration), end, nil
+}
+
+func (self *Psr) vwap(cfg VWAPConfig, at time.Time) (float64, float64, error) {
+	start, end, err := cfg.window(at)
+	if err != nil {
+		return 0, 0, err
+	}
+	return self.aggregator.VolumWeightedAvg(cfg.Symbol, start, end, cfg.AggrWindow.Duration)
+}
+
+// MonthlyConfig is the pipeline of a feed averaging the latest published monthly levels like the US PCE.
+type MonthlyConfig struct {
+	// File is the JSON list of the monthly levels with their publish dates.
+	File string
+	// Months is the number of consecutive months in the average.
+	Months int
+	// MaxAge is the max age of the newest published level. 0 disables the check.
+	MaxAge format.Duration
+}
+
+// MonthlyLevel is the level of a month as published.
+type MonthlyLevel struct {
+	// Month is the month of the level as 2006-01.
+	Month     string    `json:"month"`
+	Value     float64   `json:"value"`
+	Published time.Time `json:"published"`
+}
+
+// monthlyAverage returns the average of the latest consecutive monthly levels published before the given time.
+// The levels published later are ignored so that the value for a past time
+// doesn't change when new levels are added.
+func monthlyAverage(levels []MonthlyLevel, cfg MonthlyConfig, at time.Time) (float64, error) {
+	if cfg.Months <= 0 {
+		return 0, errors.Errorf("invalid number of months:%v", cfg.Months)
+	}
+	type level struct {
+		month     time.Time
+		value     float64
+		published time.Time
+	}
+	var published []level
+	for _, l := range levels {
+		month, err := time.Parse("2006-01", l.Month)
+		if err != nil {
+			return 0, errors.Wrapf(err, "parse month:%v", l.Month)
+		}
+		if l.Published.After(at) {
+			continue
+		}
+		published = append(published, level{month: month, value: l.Value, published: l.Published})
+	}
+	if len(published) < cfg.Months {
+		return 0, errors.Errorf("not enough monthly levels published before:%v, need:%v, have:%v", at, cfg.Months, len(published))
+	}
+	sort.Slice(published, func(i, j int) bool { return published[i].month.Before(published[j].month) })
+	published = published[len(published)-cfg.Months:]
+
+	latest := published[len(published)-1]
+	if cfg.MaxAge.Duration > 0 && at.Sub(latest.published) > cfg.MaxAge.Duration {
+		return 0, errors.Errorf("the newest monthly level:%v was published at:%v, more than:%v ago", latest.month.Format("2006-01"), latest.published, cfg.MaxAge)
+	}
+
+	var sum float64
+	for i, l := range published {
+		if i > 0 && !l.month.Equal(published[i-1].month.AddDate(0, 1, 0)) {
+			return 0, errors.Errorf("missing monthly level after:%v", published[i-1].month.Format("2006-01"))
+		}
+		sum += l.value
+	}
+	return sum / float64(cfg.Months), nil
+}
+
+func (self *Psr) monthly(cfg MonthlyConfig, at time.Time) (float64, float64, error) {
+	data, err := ioutil.ReadFile(cfg.File)
+	if os.IsNotExist(err) {
+		return 0, 0, errors.Errorf("no manual entry and no monthly levels file:%v", cfg.File)
+	}
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "read monthly levels file path:%s", cfg.File)
+	}
+	var levels []MonthlyLevel
+	if err := json.Unmarshal(data, &levels); err != nil {
+		return 0, 0, errors.Wrap(err, "unmarshal monthly levels")
+	}
+	if len(levels) == 0 {
+		return 0, 0, errors.Errorf("no manual entry and no monthly levels in file:%v", cfg.File)
+	}
+	val, err := monthlyAverage(levels, cfg, at)
+	if err != nil {
+		return 0, 0, err
+	}
+	// The published levels are exact.
+	return val, 100, nil
+}
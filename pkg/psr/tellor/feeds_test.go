@@ -0,0 +1,180 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package tellor
+
+import (
+	"context"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/tellor-io/telliot/pkg/aggregator"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/registry"
+	"github.com/tellor-io/telliot/pkg/testutil"
+	"github.com/tellor-io/telliot/pkg/tracker/index"
+)
+
+func TestVWAPWindow(t *testing.T) {
+	cfg := VWAPConfig{
+		Window:     format.Duration{Duration: 24 * time.Hour},
+		AggrWindow: format.Duration{Duration: 10 * time.Minute},
+	}
+	at := time.Date(2021, 6, 1, 21, 37, 0, 0, time.UTC)
+
+	// Rolling.
+	start, end, err := cfg.window(at)
+	testutil.Ok(t, err)
+	testutil.Equals(t, at, end)
+	testutil.Equals(t, at.Add(-24*time.Hour), start)
+
+	// After the cutoff the window ends at today's cutoff.
+	cfg.Cutoff = "20:00"
+	start, end, err = cfg.window(at)
+	testutil.Ok(t, err)
+	testutil.Equals(t, time.Date(2021, 6, 1, 20, 0, 0, 0, time.UTC), end)
+	testutil.Equals(t, time.Date(2021, 5, 31, 20, 0, 0, 0, time.UTC), start)
+
+	// Before the cutoff the window ends at yesterday's cutoff.
+	_, end, err = cfg.window(time.Date(2021, 6, 1, 19, 59, 0, 0, time.UTC))
+	testutil.Ok(t, err)
+	testutil.Equals(t, time.Date(2021, 5, 31, 20, 0, 0, 0, time.UTC), end)
+
+	// The cutoff is in UTC regardless of the time zone.
+	_, end, err = cfg.window(at.In(time.FixedZone("UTC+5", 5*3600)))
+	testutil.Ok(t, err)
+	testutil.Equals(t, time.Date(2021, 6, 1, 20, 0, 0, 0, time.UTC), end)
+
+	cfg.Cutoff = "8pm"
+	_, _, err = cfg.window(at)
+	testutil.NotOk(t, err)
+}
+
+// TestAMPLVWAP ensures that the VWAP is the sum of the prices weighted by the volumes
+// over the sum of the volumes within the window before the cutoff.
+func TestAMPLVWAP(t *testing.T) {
+	dir, err := ioutil.TempDir("", "psrTellor")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+	tsDB, err := tsdb.Open(dir, nil, nil, tsdb.DefaultOptions())
+	testutil.Ok(t, err)
+	defer tsDB.Close()
+
+	cfg := VWAPConfig{
+		Symbol:     "AMPL/USD",
+		Window:     format.Duration{Duration: 24 * time.Hour},
+		AggrWindow: format.Duration{Duration: 10 * time.Minute},
+		Cutoff:     "20:00",
+	}
+	end := time.Date(2021, 6, 1, 20, 0, 0, 0, time.UTC)
+
+	app := tsDB.Appender(context.Background())
+	appendSample := func(name, symbol string, at time.Time, v float64) {
+		lbls := labels.FromStrings(
+			"__name__", name,
+			"symbol", format.SanitizeMetricName(symbol),
+			"domain", "api.exchange",
+			"source", "https://api.exchange/"+symbol,
+		)
+		_, err := app.Append(0, lbls, timestamp.FromTime(at), v)
+		testutil.Ok(t, err)
+	}
+	var weighted, volumes float64
+	// A sample in the middle of every aggregation window.
+	for at := end.Add(-cfg.Window.Duration + 5*time.Minute); at.Before(end); at = at.Add(cfg.AggrWindow.Duration) {
+		price := 1 + float64(at.Hour())/100
+		volume := float64(1000 + at.Minute()*10)
+		appendSample(index.ValueMetricName, cfg.Symbol, at, price)
+		appendSample(index.ValueMetricName, cfg.Symbol+"/VOLUME", at, volume)
+		appendSample(index.IntervalMetricName, cfg.Symbol, at, float64(cfg.AggrWindow.Duration))
+		appendSample(index.IntervalMetricName, cfg.Symbol+"/VOLUME", at, float64(cfg.AggrWindow.Duration))
+		weighted += price * volume
+		volumes += volume
+	}
+	// Samples before the window and after the cutoff are not used.
+	appendSample(index.ValueMetricName, cfg.Symbol, end.Add(-cfg.Window.Duration-5*time.Minute), 100)
+	appendSample(index.ValueMetricName, cfg.Symbol+"/VOLUME", end.Add(-cfg.Window.Duration-5*time.Minute), 1e6)
+	appendSample(index.ValueMetricName, cfg.Symbol, end.Add(5*time.Minute), 100)
+	appendSample(index.ValueMetricName, cfg.Symbol+"/VOLUME", end.Add(5*time.Minute), 1e6)
+	testutil.Ok(t, app.Commit())
+
+	aggr, err := aggregator.New(log.NewNopLogger(), context.Background(), aggregator.Config{LogLevel: "info"}, tsDB)
+	testutil.Ok(t, err)
+	p := New(log.NewNopLogger(), Config{AMPL: cfg}, aggr, registry.Default())
+
+	val, conf, err := p.vwap(cfg, end.Add(3*time.Hour))
+	testutil.Ok(t, err)
+	testutil.Assert(t, math.Abs(weighted/volumes-val) < 1e-9, "expected:%v, actual:%v", weighted/volumes, val)
+	testutil.Assert(t, conf > 95, "low confidence:%v", conf)
+}
+
+func TestMonthlyAverage(t *testing.T) {
+	published := func(s string) time.Time {
+		t, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+	levels := []MonthlyLevel{
+		{Month: "2021-01", Value: 111.2, Published: published("2021-02-26")},
+		{Month: "2021-03", Value: 112.1, Published: published("2021-04-30")},
+		{Month: "2021-02", Value: 111.5, Published: published("2021-03-26")},
+		{Month: "2021-04", Value: 112.9, Published: published("2021-05-28")},
+	}
+	cfg := MonthlyConfig{Months: 3}
+
+	// Only the levels published before the time are used.
+	val, err := monthlyAverage(levels, cfg, published("2021-05-01"))
+	testutil.Ok(t, err)
+	testutil.Assert(t, math.Abs((111.2+111.5+112.1)/3-val) < 1e-9, "unexpected average:%v", val)
+
+	val, err = monthlyAverage(levels, cfg, published("2021-06-01"))
+	testutil.Ok(t, err)
+	testutil.Assert(t, math.Abs((111.5+112.1+112.9)/3-val) < 1e-9, "unexpected average:%v", val)
+
+	_, err = monthlyAverage(levels, cfg, published("2021-04-01"))
+	testutil.NotOk(t, err)
+
+	cfg.MaxAge = format.Duration{Duration: 45 * 24 * time.Hour}
+	_, err = monthlyAverage(levels, cfg, published("2021-08-01"))
+	testutil.NotOk(t, err)
+
+	// The months should be consecutive.
+	_, err = monthlyAverage(levels[1:], MonthlyConfig{Months: 3}, published("2021-06-01"))
+	testutil.Ok(t, err)
+	_, err = monthlyAverage([]MonthlyLevel{levels[0], levels[1], levels[3]}, MonthlyConfig{Months: 3}, published("2021-06-01"))
+	testutil.NotOk(t, err)
+}
+
+// TestMonthlyFile ensures that the shipped levels file is valid
+// and that without levels the value needs a manual entry.
+func TestMonthlyFile(t *testing.T) {
+	p := &Psr{}
+	_, _, err := p.monthly(MonthlyConfig{File: filepath.Join("..", "..", "..", "configs", "uspce.json"), Months: 3}, time.Now())
+	testutil.NotOk(t, err)
+	testutil.Assert(t, strings.Contains(err.Error(), "no manual entry"), "unexpected error:%v", err)
+
+	dir, err := ioutil.TempDir("", "uspce")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "uspce.json")
+	testutil.Ok(t, ioutil.WriteFile(file, []byte(`[
+		{"month": "2021-02", "value": 111.5, "published": "2021-03-26T12:30:00Z"},
+		{"month": "2021-03", "value": 112.1, "published": "2021-04-30T12:30:00Z"},
+		{"month": "2021-04", "value": 112.9, "published": "2021-05-28T12:30:00Z"}
+	]`), 0666))
+	val, conf, err := p.monthly(MonthlyConfig{File: file, Months: 3}, time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC))
+	testutil.Ok(t, err)
+	testutil.Assert(t, math.Abs((111.5+112.1+112.9)/3-val) < 1e-9, "unexpected average:%v", val)
+	testutil.Equals(t, float64(100), conf)
+}
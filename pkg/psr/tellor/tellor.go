@@ -4,41 +4,89 @@
 package tellor
 
 import (
-	"math"
+	"math/big"
 	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/pkg/errors"
 	"github.com/tellor-io/telliot/pkg/aggregator"
+	"github.com/tellor-io/telliot/pkg/errclass"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/plugin"
+	"github.com/tellor-io/telliot/pkg/psr"
+	"github.com/tellor-io/telliot/pkg/registry"
 )
 
-const (
-	ComponentName      = "psrTellor"
-	DefaultGranularity = 1000000
-)
+const ComponentName = "psrTellor"
 
-func New(logger log.Logger, cfg Config, aggregator *aggregator.Aggregator) *Psr {
+func New(logger log.Logger, cfg Config, aggregator *aggregator.Aggregator, registry *registry.Registry) *Psr {
 	return &Psr{
 		logger:     log.With(logger, "component", ComponentName),
-		aggregator: aggregator,
+		aggregator: psr.NewFresh(aggregator, cfg.MaxAge.Duration, cfg.MaxAges),
+		registry:   registry,
 		cfg:        cfg,
 	}
 }
 
 type Config struct {
 	MinConfidence float64
+	// MaxAge is the max age of the newest sample of a symbol.
+	// Values with older samples return psr.ErrStale. 0 disables the check.
+	MaxAge format.Duration
+	// MaxAges override MaxAge for the symbols whose sources update less often
+	// e.g. DEFITVL every hour.
+	MaxAges map[string]format.Duration
+	// AMPL is the AMPL/USD VWAP feed of request ID 10.
+	AMPL VWAPConfig
+	// USPCE is the US PCE three month average feed of request ID 41.
+	USPCE MonthlyConfig
+	// Plugins are the request IDs calculated with a plugin aggregation instead of the built-in one.
+	Plugins []PluginAggregation
+}
+
+// PluginAggregation calculates the value of a request ID with an aggregation of a plugin.
+type PluginAggregation struct {
+	RequestID int64
+	plugin.Ref
 }
 
 type Psr struct {
-	logger     log.Logger
-	aggregator *aggregator.Aggregator
-	cfg        Config
+	logger       log.Logger
+	aggregator   *psr.Fresh
+	registry     *registry.Registry
+	cfg          Config
+	aggregations map[int64]plugin.Aggregation
+}
+
+// Observe sets a function that is called with the output of the aggregations used for the values.
+func (self *Psr) Observe(fn func(psr.Aggregation)) {
+	self.aggregator.Observe(fn)
 }
 
-func (self *Psr) GetValue(reqID int64, ts time.Time) (int64, error) {
+// SetPlugins creates the plugin aggregations of the config.
+// It should be called before the first value.
+func (self *Psr) SetPlugins(plugins *plugin.Plugins) error {
+	self.aggregations = make(map[int64]plugin.Aggregation)
+	for _, p := range self.cfg.Plugins {
+		aggr, err := plugins.Aggregation(p.Ref)
+		if err != nil {
+			return errors.Wrapf(err, "request ID:%v", p.RequestID)
+		}
+		self.aggregations[p.RequestID] = aggr
+	}
+	return nil
+}
+
+func (self *Psr) GetValue(reqID int64, ts time.Time) (*big.Int, error) {
 	val, err := self.getValue(reqID, ts)
-	return int64(math.Round(val * DefaultGranularity)), err
+	if err != nil {
+		return nil, err
+	}
+	if err := self.registry.Check(registry.OracleTellor, reqID, val); err != nil {
+		return nil, err
+	}
+	return self.registry.Encode(registry.OracleTellor, reqID, val)
 }
 
 func (self *Psr) getValue(reqID int64, ts time.Time) (float64, error) {
@@ -52,6 +100,17 @@ func (self *Psr) getValue(reqID int64, ts time.Time) (float64, error) {
 	}
 
 	var conf float64
+	if aggr, ok := self.aggregations[reqID]; ok {
+		val, conf, err = self.pluginValue(aggr, ts)
+		if err != nil {
+			return 0, err
+		}
+		if conf < self.cfg.MinConfidence {
+			return 0, errclass.Wrap(errclass.DataQuality, errors.Errorf("not enough confidence - value:%v, conf:%v,confidence threshold:%v", val, conf, self.cfg.MinConfidence))
+		}
+		return val, nil
+	}
+
 	switch reqID {
 	case 1:
 		val, conf, err = self.aggregator.MedianAt("ETH/USD", ts)
@@ -72,7 +131,7 @@ func (self *Psr) getValue(reqID int64, ts time.Time) (float64, error) {
 	case 9:
 		val, conf, err = self.aggregator.MedianAtEOD("ETH/USD", ts)
 	case 10: // For more details see https://docs.google.com/document/d/1RFCApk1PznMhSRVhiyFl_vBDPA4mP2n1dTmfqjvuTNw/edit
-		val, conf, err = self.aggregator.VolumWeightedAvg("AMPL/USD", time.Now().Add(-(24 * time.Hour)), time.Now(), 10*time.Minute)
+		val, conf, err = self.vwap(self.cfg.AMPL, ts)
 	case 11:
 		val, conf, err = self.aggregator.MedianAt("ZEC/ETH", ts)
 	case 12:
@@ -134,9 +193,9 @@ func (self *Psr) getValue(reqID int64, ts time.Time) (float64, error) {
 	case 40:
 		val, conf, err = self.aggregator.MedianAt("STEEM/BTC", ts)
 	case 41:
-		// ID 41 is always manual so it sholud never get here.
 		// It is three month average for US PCE (monthly levels): https://www.bea.gov/data/personal-consumption-expenditures-price-index-excluding-food-and-energy
-		return 0, errors.New("no manual entry for request ID 41")
+		// A manual entry takes precedence over the levels file.
+		val, conf, err = self.monthly(self.cfg.USPCE, ts)
 	case 42:
 		val, conf, err = self.aggregator.MedianAtEOD("BTC/USD", ts)
 	case 43:
@@ -172,7 +231,7 @@ func (self *Psr) getValue(reqID int64, ts time.Time) (float64, error) {
 	case 58:
 		val, conf, err = self.aggregator.MeanAt("DEFIMCAP", ts)
 	default:
-		return 0, errors.Errorf("undeclared request ID:%v", reqID)
+		return 0, errclass.Wrap(errclass.Config, errors.Errorf("undeclared request ID:%v", reqID))
 	}
 
 	if err != nil {
@@ -180,8 +239,23 @@ func (self *Psr) getValue(reqID int64, ts time.Time) (float64, error) {
 	}
 
 	if conf < self.cfg.MinConfidence {
-		return 0, errors.Errorf("not enough confidence - value:%v, conf:%v,confidence threshold:%v", val, conf, self.cfg.MinConfidence)
+		return 0, errclass.Wrap(errclass.DataQuality, errors.Errorf("not enough confidence - value:%v, conf:%v,confidence threshold:%v", val, conf, self.cfg.MinConfidence))
 	}
 
 	return val, err
 }
+
+// pluginValue passes the samples of the symbols within the window of a plugin aggregation to it.
+func (self *Psr) pluginValue(aggr plugin.Aggregation, ts time.Time) (float64, float64, error) {
+	samples := make(map[string][]plugin.Sample)
+	for _, symbol := range aggr.Symbols() {
+		ss, err := self.aggregator.Samples(symbol, ts.Add(-aggr.Window()), ts)
+		if err != nil {
+			return 0, 0, errors.Wrapf(err, "getting the samples for symbol:%v", symbol)
+		}
+		for _, s := range ss {
+			samples[symbol] = append(samples[symbol], plugin.Sample{Source: s.Source, Time: s.Time, Value: s.Value})
+		}
+	}
+	return aggr.Aggregate(ts, samples)
+}
@@ -4,41 +4,55 @@
 package tellorAccess
 
 import (
-	"math"
+	"math/big"
 	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/pkg/errors"
 	"github.com/tellor-io/telliot/pkg/aggregator"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/psr"
+	"github.com/tellor-io/telliot/pkg/registry"
 )
 
-const (
-	ComponentName      = "psrTellorAccess"
-	DefaultGranularity = 1000000
-)
+const ComponentName = "psrTellorAccess"
 
-func New(logger log.Logger, cfg Config, aggregator *aggregator.Aggregator) *Psr {
+func New(logger log.Logger, cfg Config, aggregator *aggregator.Aggregator, registry *registry.Registry) *Psr {
 	return &Psr{
 		logger:     log.With(logger, "component", ComponentName),
-		aggregator: aggregator,
+		aggregator: psr.NewFresh(aggregator, cfg.MaxAge.Duration, cfg.MaxAges),
+		registry:   registry,
 		cfg:        cfg,
 	}
 }
 
 type Config struct {
 	MinConfidence float64
+	// MaxAge is the max age of the newest sample of a symbol.
+	// Values with older samples return psr.ErrStale. 0 disables the check.
+	MaxAge format.Duration
+	// MaxAges override MaxAge for the symbols whose sources update less often
+	// e.g. DEFITVL every hour.
+	MaxAges map[string]format.Duration
 }
 
 type Psr struct {
 	logger     log.Logger
-	aggregator *aggregator.Aggregator
+	aggregator *psr.Fresh
+	registry   *registry.Registry
 	cfg        Config
 }
 
-func (self *Psr) GetValue(reqID int64, ts time.Time) (int64, error) {
+func (self *Psr) GetValue(reqID int64, ts time.Time) (*big.Int, error) {
 	val, err := self.getValue(reqID, ts)
-	return int64(math.Round(val * DefaultGranularity)), err
+	if err != nil {
+		return nil, err
+	}
+	if err := self.registry.Check(registry.OracleTellorAccess, reqID, val); err != nil {
+		return nil, err
+	}
+	return self.registry.Encode(registry.OracleTellorAccess, reqID, val)
 }
 
 func (self *Psr) getValue(reqID int64, ts time.Time) (float64, error) {
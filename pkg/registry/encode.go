@@ -0,0 +1,54 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package registry
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// Encode converts a value to the integer submitted on-chain.
+// The value is rounded, or truncated when the spec says so, to the request ID decimals
+// and then multiplied by the granularity. It uses the shortest decimal representation of the value
+// and exact big number arithmetic so that granularities like 1e18 don't overflow or add float noise.
+func (self *Registry) Encode(oracle string, requestID int64, value float64) (*big.Int, error) {
+	if math.IsNaN(value) || math.IsInf(value, 0) || value < 0 {
+		return nil, errors.Errorf("request ID:%v invalid value:%v", requestID, value)
+	}
+	sp := self.Spec(oracle, requestID)
+	v, ok := new(big.Rat).SetString(strconv.FormatFloat(value, 'f', -1, 64))
+	if !ok {
+		return nil, errors.Errorf("request ID:%v invalid value:%v", requestID, value)
+	}
+	p := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(sp.Decimals)), nil))
+	scaled := integer(v.Mul(v, p), sp.Truncate)
+
+	granularity := new(big.Rat)
+	if granularity.SetFloat64(sp.Granularity) == nil {
+		return nil, errors.Errorf("request ID:%v invalid granularity:%v", requestID, sp.Granularity)
+	}
+	encoded := new(big.Rat).SetInt(scaled)
+	encoded.Mul(encoded, granularity).Quo(encoded, p)
+	return integer(encoded, false), nil
+}
+
+// integer rounds half up or truncates a positive number.
+func integer(r *big.Rat, truncate bool) *big.Int {
+	q, m := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if !truncate && new(big.Int).Mul(m, big.NewInt(2)).Cmp(r.Denom()) >= 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	return q
+}
+
+// Decode converts an on-chain value back to the PSR units.
+func (self *Registry) Decode(oracle string, requestID int64, value *big.Int) float64 {
+	f := new(big.Float).SetPrec(256).SetInt(value)
+	f.Quo(f, new(big.Float).SetPrec(256).SetFloat64(self.Spec(oracle, requestID).Granularity))
+	v, _ := f.Float64()
+	return v
+}
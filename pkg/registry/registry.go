@@ -0,0 +1,151 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package registry
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/errclass"
+)
+
+const (
+	ComponentName = "registry"
+
+	// DefaultDecimals are the decimals of the request IDs missing from the registry file.
+	DefaultDecimals = 6
+
+	OracleTellor       = "tellor"
+	OracleTellorAccess = "tellorAccess"
+)
+
+// ErrOutOfBounds is returned when a value is outside the plausible bounds of its request ID.
+var ErrOutOfBounds = errclass.New(errclass.DataQuality, "value out of bounds")
+
+type Config struct {
+	// File is the JSON file with the request IDs of each oracle.
+	// When it doesn't exist all request IDs use the default decimals without bounds.
+	File string
+}
+
+// Spec describes how the values of a request ID are submitted.
+type Spec struct {
+	// Granularity is the multiplier applied to a value before submitting it.
+	Granularity float64
+	// Decimals is the precision the value is rounded to before applying the granularity.
+	Decimals int
+	// Min and Max are the plausible bounds of a value, 0 means no bound.
+	Min float64
+	Max float64
+	// Truncate drops the digits beyond the decimals instead of rounding them.
+	Truncate bool
+}
+
+// Registry holds the specs of the request IDs for each oracle
+// so that the PSR, the submitter and the trackers use the same granularity and bounds.
+type Registry struct {
+	specs map[string]map[int64]Spec
+}
+
+type spec struct {
+	Granularity float64 `json:"granularity"`
+	Decimals    *int    `json:"decimals"`
+	Min         float64 `json:"min"`
+	Max         float64 `json:"max"`
+	Truncate    bool    `json:"truncate"`
+}
+
+func New(logger log.Logger, cfg Config) (*Registry, error) {
+	logger = log.With(logger, "component", ComponentName)
+
+	data, err := ioutil.ReadFile(cfg.File)
+	if os.IsNotExist(err) {
+		level.Warn(logger).Log("msg", "registry file doesn't exist, using the default decimals for all request IDs", "file", cfg.File, "decimals", DefaultDecimals)
+		return Default(), nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "read registry file path:%s", cfg.File)
+	}
+	return Parse(data)
+}
+
+// Default returns a registry without any specs
+// so all request IDs use the default decimals without bounds.
+func Default() *Registry {
+	return &Registry{specs: make(map[string]map[int64]Spec)}
+}
+
+// Parse parses the registry JSON which is keyed by the oracle name and the request ID.
+// The granularity defaults to 10^decimals and the decimals to the digits of the granularity.
+func Parse(data []byte) (*Registry, error) {
+	var raw map[string]map[string]spec
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, errors.Wrap(err, "unmarshal registry file")
+	}
+	self := Default()
+	for oracle, ids := range raw {
+		self.specs[oracle] = make(map[int64]Spec)
+		for idS, s := range ids {
+			id, err := strconv.ParseInt(idS, 10, 64)
+			if err != nil {
+				return nil, errors.Wrapf(err, "parse request ID:%v oracle:%v", idS, oracle)
+			}
+			sp, err := s.spec()
+			if err != nil {
+				return nil, errors.Wrapf(err, "request ID:%v oracle:%v", id, oracle)
+			}
+			self.specs[oracle][id] = sp
+		}
+	}
+	return self, nil
+}
+
+func (self spec) spec() (Spec, error) {
+	sp := Spec{Granularity: self.Granularity, Min: self.Min, Max: self.Max, Truncate: self.Truncate}
+	switch {
+	case self.Decimals == nil && self.Granularity == 0:
+		sp.Decimals = DefaultDecimals
+	case self.Decimals == nil:
+		sp.Decimals = int(math.Ceil(math.Log10(self.Granularity)))
+	default:
+		sp.Decimals = *self.Decimals
+	}
+	if sp.Granularity == 0 {
+		sp.Granularity = math.Pow10(sp.Decimals)
+	}
+	if sp.Granularity < 0 || sp.Decimals < 0 {
+		return Spec{}, errors.Errorf("negative granularity:%v or decimals:%v", sp.Granularity, sp.Decimals)
+	}
+	if sp.Min < 0 || sp.Max < 0 || (sp.Max > 0 && sp.Min > sp.Max) {
+		return Spec{}, errors.Errorf("invalid bounds min:%v max:%v", sp.Min, sp.Max)
+	}
+	return sp, nil
+}
+
+// Spec returns the spec of a request ID or the default spec when it is not in the registry.
+func (self *Registry) Spec(oracle string, requestID int64) Spec {
+	if sp, ok := self.specs[oracle][requestID]; ok {
+		return sp
+	}
+	return Spec{Granularity: math.Pow10(DefaultDecimals), Decimals: DefaultDecimals}
+}
+
+// Check returns an error wrapping ErrOutOfBounds when a value
+// is outside the bounds of the request ID.
+func (self *Registry) Check(oracle string, requestID int64, value float64) error {
+	sp := self.Spec(oracle, requestID)
+	if sp.Min > 0 && value < sp.Min {
+		return errors.Wrapf(ErrOutOfBounds, "request ID:%v value:%v is below the min:%v", requestID, value, sp.Min)
+	}
+	if sp.Max > 0 && value > sp.Max {
+		return errors.Wrapf(ErrOutOfBounds, "request ID:%v value:%v is above the max:%v", requestID, value, sp.Max)
+	}
+	return nil
+}
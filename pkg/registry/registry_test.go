@@ -0,0 +1,80 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package registry
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+func TestRegistry(t *testing.T) {
+	reg, err := Parse([]byte(`{
+		"tellor": {
+			"1": {"decimals": 2, "min": 10, "max": 100000},
+			"2": {"granularity": 1000}
+		}
+	}`))
+	testutil.Ok(t, err)
+
+	testutil.Equals(t, Spec{Granularity: 100, Decimals: 2, Min: 10, Max: 100000}, reg.Spec(OracleTellor, 1))
+	testutil.Equals(t, Spec{Granularity: 1000, Decimals: 3}, reg.Spec(OracleTellor, 2))
+	testutil.Equals(t, Spec{Granularity: 1000000, Decimals: DefaultDecimals}, reg.Spec(OracleTellor, 3))
+	testutil.Equals(t, Spec{Granularity: 1000000, Decimals: DefaultDecimals}, reg.Spec(OracleTellorAccess, 1))
+
+	testutil.Equals(t, 1234.57, reg.Decode(OracleTellor, 1, big.NewInt(123457)))
+
+	testutil.Ok(t, reg.Check(OracleTellor, 1, 1234.5678))
+	testutil.Assert(t, errors.Is(reg.Check(OracleTellor, 1, 0), ErrOutOfBounds), "value below the min not rejected")
+	testutil.Assert(t, errors.Is(reg.Check(OracleTellor, 1, 1e6), ErrOutOfBounds), "value above the max not rejected")
+	testutil.Ok(t, reg.Check(OracleTellor, 3, 0))
+
+	_, err = Parse([]byte(`{"tellor": {"1": {"min": 10, "max": 1}}}`))
+	testutil.NotOk(t, err)
+	_, err = Parse([]byte(`{"tellor": {"a": {}}}`))
+	testutil.NotOk(t, err)
+}
+
+func TestEncode(t *testing.T) {
+	reg, err := Parse([]byte(`{
+		"tellor": {
+			"1": {"decimals": 2},
+			"2": {"decimals": 2, "truncate": true},
+			"3": {"decimals": 18},
+			"4": {"granularity": 1e18, "decimals": 6}
+		}
+	}`))
+	testutil.Ok(t, err)
+
+	for _, c := range []struct {
+		id    int64
+		value float64
+		exp   string
+	}{
+		{1, 1234.5678, "123457"},
+		{2, 1234.5678, "123456"},
+		{5, 1234.5678, "1234567800"},
+		// Beyond int64.
+		{3, 2345.67, "2345670000000000000000"},
+		{4, 2345.6789012, "2345678901000000000000"},
+	} {
+		encoded, err := reg.Encode(OracleTellor, c.id, c.value)
+		testutil.Ok(t, err)
+		testutil.Equals(t, c.exp, encoded.String(), "request ID:%v", c.id)
+	}
+	testutil.Equals(t, 2345.67, reg.Decode(OracleTellor, 3, mustBig("2345670000000000000000")))
+
+	_, err = reg.Encode(OracleTellor, 1, -1)
+	testutil.NotOk(t, err)
+}
+
+func mustBig(s string) *big.Int {
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic(s)
+	}
+	return v
+}
@@ -0,0 +1,351 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+// Package reputation scores the miners by the deviation of their submissions from the PSR,
+// the disputes they lost and the regularity of their submissions.
+package reputation
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/tracker/disputeHistory"
+	"github.com/tellor-io/telliot/pkg/web"
+)
+
+const ComponentName = "reputation"
+
+// The series of the dispute tracker with the submissions of all miners.
+const (
+	valueMetricName     = "oracle_value"
+	deviationMetricName = "oracle_psr_deviation_percent"
+)
+
+// minIntervals is the number of intervals between the submissions of a miner
+// needed to measure their regularity.
+const minIntervals = 3
+
+var scoreGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "telliot",
+	Subsystem: ComponentName,
+	Name:      "score",
+	Help:      "The reputation score of a miner from 0 for the worst to 100",
+}, []string{"miner"})
+
+type Config struct {
+	Enabled  bool
+	LogLevel string
+	Interval format.Duration
+	// Window of the submissions and the disputes that are scored.
+	Window format.Duration
+	// MaxDeviation is the percent from the PSR value above which a submission is deviating.
+	MaxDeviation float64
+	// MaxDisputeLosses is the number of disputes lost within the window that takes the full dispute penalty.
+	MaxDisputeLosses int
+	// The weights of the share of deviating submissions, the dispute losses
+	// and the irregularity of the submissions in the score.
+	DeviationWeight  float64
+	DisputeWeight    float64
+	RegularityWeight float64
+	// DisputeThreshold is the deviation percent worth disputing for a miner with a score of 100.
+	// It is lowered with the score down to MinThresholdFactor of it for a score of 0
+	// so that the submissions of the historically bad actors are disputed sooner.
+	DisputeThreshold   float64
+	MinThresholdFactor float64
+}
+
+// Score is the reputation of a miner within the window.
+type Score struct {
+	Miner string
+	// Score is from 0 for the worst to 100.
+	Score       float64
+	Submissions int
+	// Deviating are the submitted values more than MaxDeviation from the PSR.
+	Deviating     int
+	MeanDeviation float64
+	DisputesLost  int
+	DisputesWon   int
+	// Irregularity is the coefficient of variation of the intervals between the submissions capped at 1,
+	// 0 when there are too few submissions.
+	Irregularity float64
+	// DisputeThreshold is the deviation percent worth disputing for the miner.
+	DisputeThreshold float64
+}
+
+// Disputes returns the history of the disputes.
+type Disputes interface {
+	Disputes() []disputeHistory.Dispute
+}
+
+// Scorer calculates the scores of all miners periodically.
+// It is safe for concurrent use.
+type Scorer struct {
+	ctx      context.Context
+	close    context.CancelFunc
+	logger   log.Logger
+	cfg      Config
+	db       storage.Queryable
+	disputes Disputes
+
+	mtx     sync.Mutex
+	scores  map[string]Score
+	updated time.Time
+}
+
+// New creates a scorer of the submissions in the DB.
+// The disputes are optional and the dispute losses are not scored without them.
+func New(logger log.Logger, ctx context.Context, cfg Config, db storage.Queryable, disputes Disputes) (*Scorer, error) {
+	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	logger = log.With(logger, "component", ComponentName)
+	if cfg.MinThresholdFactor < 0 || cfg.MinThresholdFactor > 1 {
+		return nil, errors.Errorf("the min threshold factor should be between 0 and 1:%v", cfg.MinThresholdFactor)
+	}
+	if cfg.DeviationWeight < 0 || cfg.DisputeWeight < 0 || cfg.RegularityWeight < 0 {
+		return nil, errors.New("the weights can't be negative")
+	}
+	ctx, close := context.WithCancel(ctx)
+	return &Scorer{
+		ctx:      ctx,
+		close:    close,
+		logger:   logger,
+		cfg:      cfg,
+		db:       db,
+		disputes: disputes,
+		scores:   make(map[string]Score),
+	}, nil
+}
+
+func (self *Scorer) Start() error {
+	level.Info(self.logger).Log("msg", "starting", "window", self.cfg.Window, "disputes", self.disputes != nil)
+
+	ticker := time.NewTicker(self.cfg.Interval.Duration)
+	defer ticker.Stop()
+	for {
+		if err := self.update(time.Now()); err != nil {
+			level.Error(self.logger).Log("msg", "scoring the miners", "err", err)
+		}
+		select {
+		case <-self.ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (self *Scorer) Stop() {
+	self.close()
+}
+
+// update scores all miners with submissions or disputes within the window before now.
+func (self *Scorer) update(now time.Time) error {
+	from := now.Add(-self.cfg.Window.Duration)
+	scores := make(map[string]*Score)
+	get := func(miner string) *Score {
+		key := strings.ToLower(miner)
+		s, ok := scores[key]
+		if !ok {
+			s = &Score{Miner: miner}
+			scores[key] = s
+		}
+		return s
+	}
+
+	q, err := self.db.Querier(self.ctx, timestamp.FromTime(from), timestamp.FromTime(now))
+	if err != nil {
+		return errors.Wrap(err, "create querier")
+	}
+	defer q.Close()
+
+	// A submission has a value per request ID at the same time.
+	times := make(map[*Score]map[int64]bool)
+	err = selectSamples(q, valueMetricName, func(miner string, t int64, _ float64) {
+		s := get(miner)
+		if times[s] == nil {
+			times[s] = make(map[int64]bool)
+		}
+		times[s][t] = true
+	})
+	if err != nil {
+		return err
+	}
+	for s, ts := range times {
+		s.Submissions = len(ts)
+		s.Irregularity = irregularity(ts)
+	}
+
+	deviations := make(map[*Score]int)
+	err = selectSamples(q, deviationMetricName, func(miner string, _ int64, v float64) {
+		s := get(miner)
+		if math.Abs(v) > self.cfg.MaxDeviation {
+			s.Deviating++
+		}
+		s.MeanDeviation += math.Abs(v)
+		deviations[s]++
+	})
+	if err != nil {
+		return err
+	}
+	for s, n := range deviations {
+		s.MeanDeviation /= float64(n)
+	}
+
+	if self.disputes != nil {
+		for _, d := range self.disputes.Disputes() {
+			if d.Miner == "" || d.Opened.Before(from) || d.Opened.After(now) {
+				continue
+			}
+			switch d.Outcome {
+			case disputeHistory.OutcomePassed:
+				get(d.Miner).DisputesLost++
+			case disputeHistory.OutcomeFailed:
+				get(d.Miner).DisputesWon++
+			}
+		}
+	}
+
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	self.scores = make(map[string]Score, len(scores))
+	for key, s := range scores {
+		s.Score = self.score(deviations[s], s)
+		s.DisputeThreshold = self.threshold(s.Score)
+		self.scores[key] = *s
+		scoreGauge.With(prometheus.Labels{"miner": s.Miner}).Set(s.Score)
+	}
+	self.updated = now
+	level.Debug(self.logger).Log("msg", "scored miners", "count", len(scores))
+	return nil
+}
+
+// score combines the share of deviating values out of the compared ones,
+// the dispute losses and the irregularity by their weights.
+func (self *Scorer) score(compared int, s *Score) float64 {
+	total := self.cfg.DeviationWeight + self.cfg.DisputeWeight + self.cfg.RegularityWeight
+	if total == 0 {
+		return 100
+	}
+	var deviating, losses float64
+	if compared > 0 {
+		deviating = float64(s.Deviating) / float64(compared)
+	}
+	if self.cfg.MaxDisputeLosses > 0 {
+		losses = math.Min(1, float64(s.DisputesLost)/float64(self.cfg.MaxDisputeLosses))
+	} else if s.DisputesLost > 0 {
+		losses = 1
+	}
+	penalty := self.cfg.DeviationWeight*deviating + self.cfg.DisputeWeight*losses + self.cfg.RegularityWeight*s.Irregularity
+	return 100 * (1 - penalty/total)
+}
+
+func (self *Scorer) threshold(score float64) float64 {
+	f := self.cfg.MinThresholdFactor
+	return self.cfg.DisputeThreshold * (f + (1-f)*score/100)
+}
+
+// Score returns the reputation of a miner and false when it has no submissions or disputes within the window.
+func (self *Scorer) Score(miner string) (Score, bool) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	s, ok := self.scores[strings.ToLower(miner)]
+	return s, ok
+}
+
+// DisputeThreshold returns the deviation percent worth disputing for a submission of the miner.
+// It is the threshold of a score of 100 for the unknown miners.
+func (self *Scorer) DisputeThreshold(miner string) float64 {
+	if s, ok := self.Score(miner); ok {
+		return s.DisputeThreshold
+	}
+	return self.threshold(100)
+}
+
+// ServeHTTP lists the scores of all miners, the worst first.
+// The results can be filtered by the miner query param.
+// For example: curl 'localhost:9090/api/v1/reputation'.
+func (self *Scorer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	miner := r.URL.Query().Get("miner")
+	self.mtx.Lock()
+	list := make([]Score, 0, len(self.scores))
+	for _, s := range self.scores {
+		if miner != "" && !strings.EqualFold(s.Miner, miner) {
+			continue
+		}
+		list = append(list, s)
+	}
+	self.mtx.Unlock()
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Score != list[j].Score {
+			return list[i].Score < list[j].Score
+		}
+		return list[i].Miner < list[j].Miner
+	})
+	if err := web.WriteJSON(w, http.StatusOK, list, nil); err != nil {
+		level.Error(self.logger).Log("msg", "encoding reputation response", "err", err)
+	}
+}
+
+// selectSamples calls the function with every sample of the tellor series of the metric.
+func selectSamples(q storage.Querier, metric string, fn func(miner string, t int64, v float64)) error {
+	set := q.Select(false, nil,
+		labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, metric),
+		labels.MustNewMatcher(labels.MatchEqual, "contract", "tellor"),
+	)
+	for set.Next() {
+		miner := set.At().Labels().Get("miner")
+		it := set.At().Iterator()
+		for it.Next() {
+			t, v := it.At()
+			fn(miner, t, v)
+		}
+		if err := it.Err(); err != nil {
+			return errors.Wrapf(err, "iterate samples of:%v", metric)
+		}
+	}
+	return errors.Wrapf(set.Err(), "select series:%v", metric)
+}
+
+// irregularity returns the coefficient of variation of the intervals between the times capped at 1.
+func irregularity(times map[int64]bool) float64 {
+	if len(times) < minIntervals+1 {
+		return 0
+	}
+	sorted := make([]int64, 0, len(times))
+	for t := range times {
+		sorted = append(sorted, t)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	intervals := make([]float64, len(sorted)-1)
+	var mean float64
+	for i := 1; i < len(sorted); i++ {
+		intervals[i-1] = float64(sorted[i] - sorted[i-1])
+		mean += intervals[i-1]
+	}
+	mean /= float64(len(intervals))
+	if mean == 0 {
+		return 0
+	}
+	var variance float64
+	for _, d := range intervals {
+		variance += (d - mean) * (d - mean)
+	}
+	variance /= float64(len(intervals))
+	return math.Min(1, math.Sqrt(variance)/mean)
+}
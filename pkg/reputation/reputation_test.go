@@ -0,0 +1,99 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package reputation
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/testutil"
+	"github.com/tellor-io/telliot/pkg/tracker/disputeHistory"
+)
+
+type disputesMock []disputeHistory.Dispute
+
+func (self disputesMock) Disputes() []disputeHistory.Dispute { return self }
+
+func TestScore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reputation")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+	tsDB, err := tsdb.Open(dir, nil, nil, tsdb.DefaultOptions())
+	testutil.Ok(t, err)
+	defer tsDB.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+	good, bad := "0xA1", "0xA2"
+	app := tsDB.Appender(ctx)
+	add := func(metric, miner string, at time.Time, v float64) {
+		lbls := labels.FromStrings(labels.MetricName, metric, "contract", "tellor", "id", "1", "miner", miner)
+		_, err := app.Append(0, lbls, timestamp.FromTime(at), v)
+		testutil.Ok(t, err)
+	}
+	for i, offset := range []int{50, 40, 30, 20, 10} {
+		at := now.Add(-time.Duration(offset) * time.Minute)
+		add(valueMetricName, good, at, 2000)
+		add(deviationMetricName, good, at, 1)
+
+		// Irregular submissions with two deviating values.
+		at = now.Add(-time.Duration(offset*offset) * time.Minute / 50)
+		add(valueMetricName, bad, at, 2000)
+		deviation := 1.0
+		if i < 2 {
+			deviation = -20
+		}
+		add(deviationMetricName, bad, at, deviation)
+	}
+	testutil.Ok(t, app.Commit())
+
+	cfg := Config{
+		LogLevel:           "info",
+		Interval:           format.Duration{Duration: time.Hour},
+		Window:             format.Duration{Duration: 24 * time.Hour},
+		MaxDeviation:       5,
+		MaxDisputeLosses:   2,
+		DeviationWeight:    1,
+		DisputeWeight:      1,
+		RegularityWeight:   1,
+		DisputeThreshold:   5,
+		MinThresholdFactor: 0.5,
+	}
+	disputes := disputesMock{
+		{Miner: bad, Opened: now.Add(-time.Hour), Outcome: disputeHistory.OutcomePassed},
+		// Outside the window.
+		{Miner: bad, Opened: now.Add(-48 * time.Hour), Outcome: disputeHistory.OutcomePassed},
+		{Miner: good, Opened: now.Add(-time.Hour), Outcome: disputeHistory.OutcomeFailed},
+	}
+	scorer, err := New(log.NewNopLogger(), ctx, cfg, tsDB, disputes)
+	testutil.Ok(t, err)
+	testutil.Ok(t, scorer.update(now))
+
+	s, ok := scorer.Score(good)
+	testutil.Assert(t, ok, "no score for the good miner")
+	testutil.Equals(t, 100.0, s.Score)
+	testutil.Equals(t, 5, s.Submissions)
+	testutil.Equals(t, 1, s.DisputesWon)
+	testutil.Equals(t, 5.0, scorer.DisputeThreshold(good))
+
+	s, ok = scorer.Score("0xa2")
+	testutil.Assert(t, ok, "no score for the bad miner")
+	testutil.Equals(t, 2, s.Deviating)
+	testutil.Equals(t, 1, s.DisputesLost)
+	testutil.Equals(t, 8.6, s.MeanDeviation)
+	testutil.Assert(t, s.Irregularity > 0, "regularity of the bad miner not scored")
+	testutil.Assert(t, s.Score < 70, "unexpected score:%v", s.Score)
+	testutil.Assert(t, scorer.DisputeThreshold(bad) < 4, "threshold not lowered:%v", scorer.DisputeThreshold(bad))
+
+	// The unknown miners get the threshold of a perfect score.
+	testutil.Equals(t, 5.0, scorer.DisputeThreshold("0xA3"))
+}
@@ -4,7 +4,6 @@
 package reward
 
 import (
-	"fmt"
 	"math/big"
 	"time"
 
@@ -46,30 +45,41 @@ func (self *Reward) Current(slot *big.Int, gasPriceEth1e18 *big.Int) (int64, err
 		return 0, err
 	}
 
-	rewardEth1e18, err := self.rewardInEth1e18()
+	trbAmount1e18, trbPrice, confidence, err := self.Price()
 	if err != nil {
 		return 0, errors.New("getting trb current TRB price")
 	}
-
-	txCostEth1e18 := big.NewInt(0).Mul(gasPriceEth1e18, gasUsed)
-	profit := big.NewInt(0).Sub(rewardEth1e18, txCostEth1e18)
-	profitPercentFloat := float64(profit.Int64()) / float64(txCostEth1e18.Int64()) * 100
-	profitPercent := int64(profitPercentFloat)
+	profitPercent, err := Profit(trbAmount1e18, trbPrice, confidence, gasUsed, gasPriceEth1e18)
+	if err != nil {
+		return 0, err
+	}
 
 	level.Debug(self.logger).Log(
 		"msg", "profit checking",
-		"reward", fmt.Sprintf("%.2e", float64(rewardEth1e18.Int64())),
-		"txCost", fmt.Sprintf("%.2e", float64(txCostEth1e18.Int64())),
+		"reward", trbAmount1e18,
+		"trbPrice", trbPrice,
 		"slot", slot,
 		"gasUsed", gasUsed,
 		"gasPrice", gasPriceEth1e18,
-		"profit", fmt.Sprintf("%.2e", float64(profit.Int64())),
 		"profitMargin", profitPercent,
 	)
 
 	return profitPercent, nil
 }
 
+// Profit returns the profit in percents of a transaction with the gas used at the gas price
+// for the reward in TRB at the TRB price in ETH.
+func Profit(trbAmount1e18 *big.Int, trbPrice, confidence float64, gasUsed, gasPriceEth1e18 *big.Int) (int64, error) {
+	rewardEth1e18, err := inEth1e18(trbAmount1e18, trbPrice, confidence)
+	if err != nil {
+		return 0, err
+	}
+	txCostEth1e18 := big.NewInt(0).Mul(gasPriceEth1e18, gasUsed)
+	profit := big.NewInt(0).Sub(rewardEth1e18, txCostEth1e18)
+	profitPercentFloat := float64(profit.Int64()) / float64(txCostEth1e18.Int64()) * 100
+	return int64(profitPercentFloat), nil
+}
+
 func (self *Reward) GasUsed(slot *big.Int) (*big.Int, error) {
 	if gas, ok := self.gasUsed[slot.Int64()]; ok {
 		return gas, nil
@@ -95,17 +105,31 @@ func (self *Reward) SaveGasUsed(slot *big.Int, _gasUsed uint64) {
 	level.Info(self.logger).Log("msg", "saved transaction gas used", "amount", gasUsed.Int64(), "slot", slot.Int64())
 }
 
-func (self *Reward) rewardInEth1e18() (*big.Int, error) {
+// InEth1e18 returns the current mining reward converted to ETH.
+func (self *Reward) InEth1e18() (*big.Int, error) {
+	trbAmount1e18, trbPrice, confidence, err := self.Price()
+	if err != nil {
+		return nil, err
+	}
+	return inEth1e18(trbAmount1e18, trbPrice, confidence)
+}
+
+// Price returns the current mining reward with the tips in TRB
+// and the TRB price in ETH with its confidence.
+func (self *Reward) Price() (*big.Int, float64, float64, error) {
 	trbAmount1e18, err := self.contractCaller.CurrentReward(nil)
 	if err != nil {
-		return nil, errors.New("getting currentReward from the chain")
+		return nil, 0, 0, errors.New("getting currentReward from the chain")
 	}
 
 	trbPrice, confidence, err := self.aggr.TimeWeightedAvg("TRB/ETH", time.Now(), time.Hour)
 	if err != nil {
-		return nil, errors.New("getting the trb price from the aggregator")
+		return nil, 0, 0, errors.New("getting the trb price from the aggregator")
 	}
+	return trbAmount1e18, trbPrice, confidence, nil
+}
 
+func inEth1e18(trbAmount1e18 *big.Int, trbPrice, confidence float64) (*big.Int, error) {
 	if confidence < 0.5 {
 		return nil, errors.New("trb price confidence too low")
 
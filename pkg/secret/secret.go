@@ -0,0 +1,47 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+// Package secret keeps the private keys loaded from the env
+// after removing them from the env of the process
+// so that they aren't inherited by the child processes like the hooks and the plugins.
+package secret
+
+import (
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	mtx   sync.Mutex
+	taken = make(map[string]string)
+)
+
+// Take moves the env variables into the secrets, the unset ones are skipped.
+func Take(names ...string) error {
+	mtx.Lock()
+	defer mtx.Unlock()
+	for _, name := range names {
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		taken[name] = v
+		if err := os.Unsetenv(name); err != nil {
+			return errors.Wrapf(err, "unset env variable:%v", name)
+		}
+	}
+	return nil
+}
+
+// Get returns the value of a secret taken from the env
+// or of the env variable when it wasn't taken.
+func Get(name string) string {
+	mtx.Lock()
+	defer mtx.Unlock()
+	if v, ok := taken[name]; ok {
+		return v
+	}
+	return os.Getenv(name)
+}
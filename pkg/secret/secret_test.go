@@ -0,0 +1,26 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package secret
+
+import (
+	"os"
+	"testing"
+
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+func TestTake(t *testing.T) {
+	const name = "TELLIOT_TEST_SECRET"
+	testutil.Ok(t, os.Setenv(name, "key"))
+	testutil.Equals(t, "key", Get(name))
+
+	testutil.Ok(t, Take(name, "TELLIOT_TEST_UNSET"))
+	_, ok := os.LookupEnv(name)
+	testutil.Assert(t, !ok, "the secret is still in the env")
+	testutil.Equals(t, "key", Get(name))
+
+	_, ok = os.LookupEnv("TELLIOT_TEST_UNSET")
+	testutil.Assert(t, !ok, "an unset variable was set")
+	testutil.Equals(t, "", Get("TELLIOT_TEST_UNSET"))
+}
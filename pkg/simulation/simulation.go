@@ -0,0 +1,231 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package simulation
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+)
+
+const ComponentName = "simulation"
+
+// Supported fork backends.
+const (
+	BackendAnvil   = "anvil"
+	BackendHardhat = "hardhat"
+)
+
+// readyTimeout is how long to wait for a spawned fork to accept connections.
+const readyTimeout = time.Minute
+
+type Config struct {
+	LogLevel string
+	// Backend is the node used for the fork(anvil or hardhat).
+	// It decides the names of the RPC methods.
+	Backend string
+	// Attach is the URL of an already running fork.
+	// When empty a new fork is spawned.
+	Attach string
+	// ForkURL is the node to fork from when spawning.
+	ForkURL string
+	// ForkBlock pins the fork to a block so that the runs are deterministic.
+	// 0 forks from the latest block.
+	ForkBlock uint64
+	Port      uint
+	// FastForward is how much to move the chain time forward on every Interval.
+	// 0 disables the time travel.
+	FastForward format.Duration
+	Interval    format.Duration
+	// Balance in ETH to set for each account.
+	Balance uint
+	// StartTime is the unix time of the first simulated block so that the chain time is the same on every run.
+	// 0 keeps the time of the fork.
+	StartTime int64
+	// Seed makes the random choices of the pipeline the same on every run, 0 uses the start time.
+	Seed int64
+}
+
+// Fork runs a local fork of a chain and controls its time and blocks.
+type Fork struct {
+	ctx    context.Context
+	close  context.CancelFunc
+	logger log.Logger
+	cfg    Config
+	url    string
+	cmd    *exec.Cmd
+	client *rpc.Client
+	// ticker returns the ticks of the time travel and a func to stop them.
+	ticker func(time.Duration) (<-chan time.Time, func())
+}
+
+func newTicker(d time.Duration) (<-chan time.Time, func()) {
+	ticker := time.NewTicker(d)
+	return ticker.C, ticker.Stop
+}
+
+func New(logger log.Logger, ctx context.Context, cfg Config) (*Fork, error) {
+	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	if cfg.Backend != BackendAnvil && cfg.Backend != BackendHardhat {
+		return nil, errors.Errorf("unknown backend:%v", cfg.Backend)
+	}
+	if cfg.Attach == "" && cfg.ForkURL == "" {
+		return nil, errors.New("either a fork URL or an attach URL is required")
+	}
+
+	ctx, close := context.WithCancel(ctx)
+	self := &Fork{
+		ctx:    ctx,
+		close:  close,
+		logger: log.With(logger, "component", ComponentName),
+		cfg:    cfg,
+		url:    cfg.Attach,
+		ticker: newTicker,
+	}
+	if cfg.Attach == "" {
+		self.url = fmt.Sprintf("http://127.0.0.1:%d", cfg.Port)
+		self.cmd = self.command()
+	}
+	return self, nil
+}
+
+func (self *Fork) command() *exec.Cmd {
+	port := strconv.Itoa(int(self.cfg.Port))
+	if self.cfg.Backend == BackendHardhat {
+		args := []string{"hardhat", "node", "--fork", self.cfg.ForkURL, "--port", port}
+		if self.cfg.ForkBlock > 0 {
+			args = append(args, "--fork-block-number", strconv.FormatUint(self.cfg.ForkBlock, 10))
+		}
+		return exec.CommandContext(self.ctx, "npx", args...)
+	}
+	args := []string{"--fork-url", self.cfg.ForkURL, "--port", port}
+	if self.cfg.ForkBlock > 0 {
+		args = append(args, "--fork-block-number", strconv.FormatUint(self.cfg.ForkBlock, 10))
+	}
+	return exec.CommandContext(self.ctx, "anvil", args...)
+}
+
+// Launch spawns the fork when not attaching and waits until it accepts connections.
+func (self *Fork) Launch() error {
+	if self.cmd != nil {
+		level.Info(self.logger).Log("msg", "spawning fork", "cmd", self.cmd.String())
+		if err := self.cmd.Start(); err != nil {
+			return errors.Wrap(err, "spawning the fork")
+		}
+	}
+
+	ctx, cncl := context.WithTimeout(self.ctx, readyTimeout)
+	defer cncl()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		client, err := rpc.DialContext(ctx, self.url)
+		if err == nil {
+			var block hexutil.Uint64
+			if err = client.CallContext(ctx, &block, "eth_blockNumber"); err == nil {
+				self.client = client
+				level.Info(self.logger).Log("msg", "fork ready", "url", self.url, "block", uint64(block))
+				return self.setStartTime(ctx)
+			}
+			client.Close()
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(err, "fork not ready at:%v", self.url)
+		case <-ticker.C:
+		}
+	}
+}
+
+// setStartTime mines a block with the configured start time.
+func (self *Fork) setStartTime(ctx context.Context) error {
+	if self.cfg.StartTime == 0 {
+		return nil
+	}
+	if err := self.client.CallContext(ctx, nil, "evm_setNextBlockTimestamp", self.cfg.StartTime); err != nil {
+		return errors.Wrapf(err, "evm_setNextBlockTimestamp start time:%v", self.cfg.StartTime)
+	}
+	return self.Mine(ctx, 1)
+}
+
+// URL is the address of the fork node.
+func (self *Fork) URL() string {
+	return self.url
+}
+
+// Start moves the chain time forward on every interval until stopped.
+func (self *Fork) Start() error {
+	if self.cfg.FastForward.Duration <= 0 {
+		<-self.ctx.Done()
+		return nil
+	}
+	level.Info(self.logger).Log("msg", "starting", "fastForward", self.cfg.FastForward, "interval", self.cfg.Interval)
+	ticks, stop := self.ticker(self.cfg.Interval.Duration)
+	defer stop()
+	for {
+		select {
+		case <-self.ctx.Done():
+			return nil
+		case <-ticks:
+		}
+		if err := self.IncreaseTime(self.ctx, self.cfg.FastForward.Duration); err != nil {
+			level.Error(self.logger).Log("msg", "fast forwarding", "err", err)
+		}
+	}
+}
+
+// Stop stops the time travel and the spawned fork.
+func (self *Fork) Stop() {
+	self.close()
+	if self.client != nil {
+		self.client.Close()
+	}
+	if self.cmd != nil {
+		if err := self.cmd.Wait(); err != nil {
+			level.Debug(self.logger).Log("msg", "fork exited", "err", err)
+		}
+	}
+}
+
+// IncreaseTime moves the chain time forward and mines a block with the new time.
+func (self *Fork) IncreaseTime(ctx context.Context, d time.Duration) error {
+	if err := self.client.CallContext(ctx, nil, "evm_increaseTime", int64(d.Seconds())); err != nil {
+		return errors.Wrap(err, "evm_increaseTime")
+	}
+	return self.Mine(ctx, 1)
+}
+
+// Mine mines the given number of blocks.
+func (self *Fork) Mine(ctx context.Context, blocks int) error {
+	for i := 0; i < blocks; i++ {
+		if err := self.client.CallContext(ctx, nil, "evm_mine"); err != nil {
+			return errors.Wrap(err, "evm_mine")
+		}
+	}
+	return nil
+}
+
+// SetBalance sets the ETH balance of an account.
+func (self *Fork) SetBalance(ctx context.Context, addr common.Address, wei *big.Int) error {
+	method := self.cfg.Backend + "_setBalance"
+	if err := self.client.CallContext(ctx, nil, method, addr, (*hexutil.Big)(wei)); err != nil {
+		return errors.Wrap(err, method)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package simulation
+
+import (
+	"context"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+type node struct {
+	time     int64
+	blocks   uint64
+	balances map[common.Address]*big.Int
+	mined    chan struct{}
+}
+
+type ethAPI struct{ n *node }
+
+func (self *ethAPI) BlockNumber() hexutil.Uint64 { return hexutil.Uint64(self.n.blocks) }
+
+type evmAPI struct{ n *node }
+
+func (self *evmAPI) IncreaseTime(seconds int64) { self.n.time += seconds }
+
+func (self *evmAPI) SetNextBlockTimestamp(timestamp int64) { self.n.time = timestamp }
+
+func (self *evmAPI) Mine() {
+	self.n.blocks++
+	self.n.mined <- struct{}{}
+}
+
+type anvilAPI struct{ n *node }
+
+func (self *anvilAPI) SetBalance(addr common.Address, wei *hexutil.Big) {
+	self.n.balances[addr] = (*big.Int)(wei)
+}
+
+func newNode(t *testing.T) (*node, *httptest.Server) {
+	n := &node{balances: make(map[common.Address]*big.Int), mined: make(chan struct{}, 10)}
+	srv := rpc.NewServer()
+	testutil.Ok(t, srv.RegisterName("eth", &ethAPI{n}))
+	testutil.Ok(t, srv.RegisterName("evm", &evmAPI{n}))
+	testutil.Ok(t, srv.RegisterName("anvil", &anvilAPI{n}))
+	return n, httptest.NewServer(srv)
+}
+
+// TestAttach ensures that the time travel and the balance changes
+// are sent to an attached fork.
+func TestAttach(t *testing.T) {
+	n, httpSrv := newNode(t)
+	defer httpSrv.Close()
+
+	ctx := context.Background()
+	fork, err := New(logging.NewLogger(), ctx, Config{LogLevel: "info", Backend: BackendAnvil, Attach: httpSrv.URL})
+	testutil.Ok(t, err)
+	testutil.Ok(t, fork.Launch())
+	defer fork.Stop()
+
+	testutil.Ok(t, fork.IncreaseTime(ctx, 15*time.Minute))
+	testutil.Equals(t, int64(900), n.time)
+	testutil.Equals(t, uint64(1), n.blocks)
+
+	testutil.Ok(t, fork.Mine(ctx, 3))
+	testutil.Equals(t, uint64(4), n.blocks)
+
+	addr := common.HexToAddress("0x1")
+	testutil.Ok(t, fork.SetBalance(ctx, addr, big.NewInt(1e18)))
+	testutil.Equals(t, int64(1e18), n.balances[addr].Int64())
+}
+
+// TestStart ensures that every run starts at the same chain time
+// and that the time moves forward on every tick.
+func TestStart(t *testing.T) {
+	n, httpSrv := newNode(t)
+	defer httpSrv.Close()
+
+	cfg := Config{
+		LogLevel:    "info",
+		Backend:     BackendAnvil,
+		Attach:      httpSrv.URL,
+		FastForward: format.Duration{Duration: time.Minute},
+		Interval:    format.Duration{Duration: time.Hour},
+		StartTime:   1600000000,
+	}
+	fork, err := New(logging.NewLogger(), context.Background(), cfg)
+	testutil.Ok(t, err)
+	ticks := make(chan time.Time)
+	fork.ticker = func(time.Duration) (<-chan time.Time, func()) { return ticks, func() {} }
+	testutil.Ok(t, fork.Launch())
+	testutil.Equals(t, int64(1600000000), n.time)
+	testutil.Equals(t, uint64(1), n.blocks)
+	<-n.mined
+
+	done := make(chan error)
+	go func() { done <- fork.Start() }()
+	ticks <- time.Now()
+	<-n.mined
+	testutil.Equals(t, int64(1600000060), n.time)
+	testutil.Equals(t, uint64(2), n.blocks)
+
+	fork.Stop()
+	testutil.Ok(t, <-done)
+}
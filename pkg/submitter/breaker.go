@@ -0,0 +1,282 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package submitter
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	eth "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/notify"
+	"github.com/tellor-io/telliot/pkg/web"
+)
+
+// BreakerName is the name of the circuit breaker in the gate pauses.
+const BreakerName = "circuitBreaker"
+
+type BreakerConfig struct {
+	Enabled bool
+	// Threshold is the number of consecutive reverted or failed submissions that trips the breaker.
+	Threshold int
+	// Timeout after which a tripped breaker resumes the submissions.
+	// When 0 the submissions resume only with the reset API.
+	Timeout format.Duration
+}
+
+// BreakerStatus is the state of the breaker of an account.
+type BreakerStatus struct {
+	Account   string     `json:"account"`
+	Tripped   bool       `json:"tripped"`
+	TrippedAt *time.Time `json:"trippedAt,omitempty"`
+	ResumeAt  *time.Time `json:"resumeAt,omitempty"`
+	// Failures are the reasons of the consecutive failed submissions.
+	Failures []string `json:"failures"`
+}
+
+// Breaker pauses the submissions of a single account after too many consecutive
+// reverted or failed submissions instead of burning gas while something is misconfigured.
+// A tripped breaker resumes after the timeout or with Reset.
+// A nil breaker is disabled and it is safe for concurrent use.
+type Breaker struct {
+	logger   log.Logger
+	cfg      BreakerConfig
+	account  string
+	gate     *Gate
+	notifier notify.Notifier
+
+	mtx       sync.Mutex
+	failures  []string
+	trippedAt time.Time
+	timer     *time.Timer
+
+	trips   prometheus.Counter
+	tripped prometheus.Gauge
+}
+
+// NewBreaker creates the breaker of an account or nil when it is disabled.
+func NewBreaker(logger log.Logger, cfg BreakerConfig, account string, gate *Gate, notifier notify.Notifier) (*Breaker, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if cfg.Threshold < 1 {
+		return nil, errors.Errorf("invalid breaker threshold:%v", cfg.Threshold)
+	}
+	labels := prometheus.Labels{"account": account}
+	return &Breaker{
+		logger:   log.With(logger, "component", BreakerName, "account", account),
+		cfg:      cfg,
+		account:  account,
+		gate:     gate,
+		notifier: notifier,
+		trips: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace:   "telliot",
+			Subsystem:   BreakerName,
+			Name:        "trips_total",
+			Help:        "The total number of times the circuit breaker paused the submissions",
+			ConstLabels: labels,
+		}),
+		tripped: promauto.NewGauge(prometheus.GaugeOpts{
+			Namespace:   "telliot",
+			Subsystem:   BreakerName,
+			Name:        "tripped",
+			Help:        "1 while the circuit breaker pauses the submissions",
+			ConstLabels: labels,
+		}),
+	}, nil
+}
+
+// Success clears the consecutive failures.
+func (self *Breaker) Success() {
+	if self == nil {
+		return
+	}
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	if self.trippedAt.IsZero() {
+		self.failures = nil
+	}
+}
+
+// Failure records a reverted or failed submission with its reason
+// and trips the breaker after the threshold of consecutive failures.
+func (self *Breaker) Failure(ctx context.Context, reason string) {
+	if self == nil {
+		return
+	}
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	self.failures = append(self.failures, reason)
+	if len(self.failures) > self.cfg.Threshold {
+		self.failures = self.failures[len(self.failures)-self.cfg.Threshold:]
+	}
+	if len(self.failures) < self.cfg.Threshold || !self.trippedAt.IsZero() {
+		return
+	}
+
+	self.trippedAt = time.Now()
+	self.trips.Inc()
+	self.tripped.Set(1)
+	self.gate.Pause(BreakerName, fmt.Sprintf("%v consecutive failed submissions, last:%v", len(self.failures), reason))
+	resume := "Resume the submissions with a POST request to /api/v1/submitter/breaker/reset?account=" + self.account
+	if self.cfg.Timeout.Duration > 0 {
+		self.timer = time.AfterFunc(self.cfg.Timeout.Duration, func() { self.reset("timeout") })
+		resume = fmt.Sprintf("The submissions resume in %v or earlier with a POST request to /api/v1/submitter/breaker/reset?account=%v", self.cfg.Timeout.Duration, self.account)
+	}
+	level.Error(self.logger).Log("msg", "circuit breaker tripped, pausing the submissions", "failures", strings.Join(self.failures, "; "))
+
+	msg := notify.Message{
+		Event:    notify.EventBreakerTripped,
+		Severity: notify.SeverityCritical,
+		Title:    "Submissions paused by the circuit breaker",
+		Body: fmt.Sprintf("Account %v had %v consecutive failed submissions:\n%v\n%v",
+			self.account, len(self.failures), strings.Join(self.failures, "\n"), resume),
+		Data: map[string]string{"account": self.account, "failures": strings.Join(self.failures, "\n")},
+	}
+	go func() {
+		if err := self.notifier.Notify(ctx, msg); err != nil {
+			level.Error(self.logger).Log("msg", "sending notification", "err", err)
+		}
+	}()
+}
+
+// Reset resumes the submissions of a tripped breaker.
+// Returns true if the breaker was tripped.
+func (self *Breaker) Reset() bool {
+	if self == nil {
+		return false
+	}
+	return self.reset("manual")
+}
+
+func (self *Breaker) reset(by string) bool {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	if self.trippedAt.IsZero() {
+		return false
+	}
+	if self.timer != nil {
+		self.timer.Stop()
+		self.timer = nil
+	}
+	self.trippedAt = time.Time{}
+	self.failures = nil
+	self.tripped.Set(0)
+	self.gate.Resume(BreakerName)
+	level.Info(self.logger).Log("msg", "circuit breaker reset, resuming the submissions", "by", by)
+
+	msg := notify.Message{
+		Event:    notify.EventBreakerReset,
+		Severity: notify.SeverityInfo,
+		Title:    "Submissions resumed by the circuit breaker",
+		Body:     fmt.Sprintf("The circuit breaker of account %v was reset by:%v", self.account, by),
+		Data:     map[string]string{"account": self.account},
+	}
+	go func() {
+		if err := self.notifier.Notify(context.Background(), msg); err != nil {
+			level.Error(self.logger).Log("msg", "sending notification", "err", err)
+		}
+	}()
+	return true
+}
+
+func (self *Breaker) Status() BreakerStatus {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	status := BreakerStatus{
+		Account:  self.account,
+		Tripped:  !self.trippedAt.IsZero(),
+		Failures: append([]string{}, self.failures...),
+	}
+	if status.Tripped {
+		trippedAt := self.trippedAt
+		status.TrippedAt = &trippedAt
+		if self.cfg.Timeout.Duration > 0 {
+			resumeAt := trippedAt.Add(self.cfg.Timeout.Duration)
+			status.ResumeAt = &resumeAt
+		}
+	}
+	return status
+}
+
+// Breakers are the breakers of all accounts by address.
+type Breakers map[string]*Breaker
+
+// ServeHTTP returns the status of the breakers.
+func (self Breakers) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	statuses := []BreakerStatus{}
+	for _, breaker := range self {
+		statuses = append(statuses, breaker.Status())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Account < statuses[j].Account })
+	_ = web.WriteJSON(w, http.StatusOK, statuses, nil)
+}
+
+// ServeReset resumes the submissions of the account in the query.
+func (self Breakers) ServeReset(w http.ResponseWriter, r *http.Request) {
+	account := r.URL.Query().Get("account")
+	breaker, ok := self[common.HexToAddress(account).String()]
+	if !ok {
+		_ = web.WriteJSON(w, http.StatusNotFound, nil, errors.Errorf("no breaker for account:%v", account))
+		return
+	}
+	if !breaker.Reset() {
+		_ = web.WriteJSON(w, http.StatusBadRequest, nil, errors.Errorf("the breaker of account:%v isn't tripped", account))
+		return
+	}
+	_ = web.WriteJSON(w, http.StatusOK, breaker.Status(), nil)
+}
+
+// RevertReason replays a reverted transaction in the state before its block
+// and returns the decoded revert reason.
+// The state can differ from the one the transaction was mined in
+// so the reason is a best effort.
+func RevertReason(ctx context.Context, client contracts.ETHClient, from common.Address, tx *types.Transaction, block *big.Int) string {
+	var parent *big.Int
+	if block != nil && block.Sign() > 0 {
+		parent = new(big.Int).Sub(block, big.NewInt(1))
+	}
+	_, err := client.CallContract(ctx, eth.CallMsg{
+		From:     from,
+		To:       tx.To(),
+		Gas:      tx.Gas(),
+		GasPrice: tx.GasPrice(),
+		Value:    tx.Value(),
+		Data:     tx.Data(),
+	}, parent)
+	if err == nil {
+		return "unknown, the transaction succeeds when replayed"
+	}
+	return DecodeRevert(err)
+}
+
+// DecodeRevert returns the reason string of a reverted call
+// or the error message when the node doesn't return the revert data.
+func DecodeRevert(err error) string {
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		if data, ok := dataErr.ErrorData().(string); ok {
+			if reason, err := abi.UnpackRevert(common.FromHex(data)); err == nil {
+				return reason
+			}
+		}
+	}
+	return err.Error()
+}
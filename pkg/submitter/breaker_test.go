@@ -0,0 +1,115 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package submitter
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/notify"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+type recordNotifier struct {
+	mtx  sync.Mutex
+	msgs []notify.Message
+}
+
+func (self *recordNotifier) Notify(_ context.Context, msg notify.Message) error {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	self.msgs = append(self.msgs, msg)
+	return nil
+}
+
+func (self *recordNotifier) count() int {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	return len(self.msgs)
+}
+
+// TestBreaker ensures that only consecutive failures trip the breaker
+// and that it stays tripped until reset.
+func TestBreaker(t *testing.T) {
+	ctx := context.Background()
+	gate := NewGate("0x1")
+	notifier := &recordNotifier{}
+	breaker, err := NewBreaker(logging.NewLogger(), BreakerConfig{Enabled: true, Threshold: 3}, "0x1", gate, notifier)
+	testutil.Ok(t, err)
+
+	breaker.Failure(ctx, "a")
+	breaker.Failure(ctx, "b")
+	breaker.Success()
+	breaker.Failure(ctx, "c")
+	breaker.Failure(ctx, "d")
+	testutil.Ok(t, gate.Err())
+	testutil.Equals(t, []string{"c", "d"}, breaker.Status().Failures)
+
+	breaker.Failure(ctx, "e")
+	testutil.NotOk(t, gate.Err())
+	status := breaker.Status()
+	testutil.Assert(t, status.Tripped, "breaker should be tripped")
+	testutil.Assert(t, status.ResumeAt == nil, "breaker without a timeout resumes only manually")
+	testutil.Equals(t, []string{"c", "d", "e"}, status.Failures)
+
+	// A success of an earlier submission doesn't resume it.
+	breaker.Success()
+	breaker.Failure(ctx, "f")
+	testutil.NotOk(t, gate.Err())
+	for i := 0; i < 100 && notifier.count() == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	testutil.Equals(t, 1, notifier.count())
+	testutil.Equals(t, notify.EventBreakerTripped, notifier.msgs[0].Event)
+
+	testutil.Assert(t, breaker.Reset(), "reset should resume a tripped breaker")
+	testutil.Ok(t, gate.Err())
+	testutil.Assert(t, !breaker.Reset(), "repeated reset shouldn't report a change")
+	testutil.Equals(t, 0, len(breaker.Status().Failures))
+
+	var nilBreaker *Breaker
+	nilBreaker.Failure(ctx, "a")
+	nilBreaker.Success()
+	testutil.Assert(t, !nilBreaker.Reset(), "nil breaker is never tripped")
+}
+
+// TestBreakerTimeout ensures that a tripped breaker resumes after the timeout.
+func TestBreakerTimeout(t *testing.T) {
+	gate := NewGate("0x2")
+	breaker, err := NewBreaker(logging.NewLogger(), BreakerConfig{Enabled: true, Threshold: 1, Timeout: format.Duration{Duration: 50 * time.Millisecond}}, "0x2", gate, &recordNotifier{})
+	testutil.Ok(t, err)
+
+	breaker.Failure(context.Background(), "a")
+	testutil.NotOk(t, gate.Err())
+	testutil.Assert(t, breaker.Status().ResumeAt != nil, "breaker with a timeout should report the resume time")
+	for i := 0; i < 100 && gate.Err() != nil; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	testutil.Ok(t, gate.Err())
+	testutil.Assert(t, !breaker.Status().Tripped, "breaker should be reset after the timeout")
+}
+
+type dataError struct {
+	data string
+}
+
+func (self dataError) Error() string          { return "execution reverted" }
+func (self dataError) ErrorData() interface{} { return self.data }
+
+// TestDecodeRevert ensures that the revert reason is decoded from the error data
+// and that the error message is used without it.
+func TestDecodeRevert(t *testing.T) {
+	// Error(string) with "miner is not staked".
+	data := "0x08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"0000000000000000000000000000000000000000000000000000000000000013" +
+		"6d696e6572206973206e6f74207374616b656400000000000000000000000000"
+	testutil.Equals(t, "miner is not staked", DecodeRevert(errors.Wrap(dataError{data: data}, "simulate transaction")))
+	testutil.Equals(t, "nonce too low", DecodeRevert(errors.New("nonce too low")))
+}
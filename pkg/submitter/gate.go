@@ -0,0 +1,78 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package submitter
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/health"
+)
+
+// Gate pauses the submissions of a single account.
+// Any watchdog can pause it and the submissions continue
+// only when all watchdogs that paused it have resumed it.
+// It is safe for concurrent use.
+type Gate struct {
+	account string
+	mtx     sync.Mutex
+	reasons map[string]string
+}
+
+func NewGate(account string) *Gate {
+	return &Gate{
+		account: account,
+		reasons: make(map[string]string),
+	}
+}
+
+// Pause pauses the submissions until the watchdog with the given name resumes them.
+// Returns true if the watchdog hasn't paused the gate already.
+func (self *Gate) Pause(name, reason string) bool {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	_, ok := self.reasons[name]
+	self.reasons[name] = reason
+	return !ok
+}
+
+// Resume removes the pause set by the watchdog with the given name.
+// Returns true if the watchdog had paused the gate.
+func (self *Gate) Resume(name string) bool {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	_, ok := self.reasons[name]
+	delete(self.reasons, name)
+	return ok
+}
+
+// Err returns an error with all the reasons when the gate is paused and nil otherwise.
+func (self *Gate) Err() error {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	if len(self.reasons) == 0 {
+		return nil
+	}
+	var reasons []string
+	for name, reason := range self.reasons {
+		reasons = append(reasons, name+":"+reason)
+	}
+	sort.Strings(reasons)
+	return errors.Errorf("submitting is paused %v", strings.Join(reasons, ", "))
+}
+
+// Health reports the gate as degraded while it is paused.
+// A pause is intentional so it is never critical.
+func (self *Gate) Health(context.Context) []health.Status {
+	status := health.Status{Name: "submitGate:" + self.account, Healthy: true, Ready: true}
+	if err := self.Err(); err != nil {
+		status.Healthy = false
+		status.Ready = false
+		status.Message = err.Error()
+	}
+	return []health.Status{status}
+}
@@ -0,0 +1,27 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package submitter
+
+import (
+	"testing"
+
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// TestGate ensures that the gate stays paused until all watchdogs resume it.
+func TestGate(t *testing.T) {
+	gate := NewGate("0x1")
+	testutil.Ok(t, gate.Err())
+
+	testutil.Assert(t, gate.Pause("balance", "low ETH"), "first pause should report a change")
+	testutil.Assert(t, !gate.Pause("balance", "low ETH"), "repeated pause shouldn't report a change")
+	gate.Pause("stake", "in dispute")
+	testutil.Equals(t, "submitting is paused balance:low ETH, stake:in dispute", gate.Err().Error())
+
+	testutil.Assert(t, gate.Resume("balance"), "resume should report a change")
+	testutil.NotOk(t, gate.Err())
+	testutil.Assert(t, !gate.Resume("balance"), "repeated resume shouldn't report a change")
+	gate.Resume("stake")
+	testutil.Ok(t, gate.Err())
+}
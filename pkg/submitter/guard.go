@@ -0,0 +1,345 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package submitter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/errclass"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/psr"
+	"github.com/tellor-io/telliot/pkg/registry"
+)
+
+// ErrValueDeviates is returned when a value is too far from the recent on-chain values
+// or from the reference.
+var ErrValueDeviates = errclass.New(errclass.DataQuality, "value deviates")
+
+// ErrUnitMismatch is returned when a value differs from the recent on-chain values
+// by about a power of 10 which is usually a granularity mismatch in the registry.
+var ErrUnitMismatch = errclass.New(errclass.DataQuality, "unit mismatch")
+
+// ErrVolatile is returned when a value moved too fast from the last on-chain value
+// without enough sources confirming the move.
+var ErrVolatile = errclass.New(errclass.DataQuality, "value too volatile")
+
+var volatilityFlags = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "telliot",
+	Subsystem: "valueGuard",
+	Name:      "volatility_flags_total",
+	Help:      "The total number of values that moved too fast without enough sources confirming the move",
+}, []string{"oracle", "requestID"})
+
+// defaultReferenceTimeout is used without a ReferenceTimeout.
+const defaultReferenceTimeout = 10 * time.Second
+
+// unitMismatchMagnitude is the least number of orders of magnitude
+// between a value and the on-chain median for a unit mismatch.
+const unitMismatchMagnitude = 3
+
+type GuardConfig struct {
+	Enabled bool
+	// MaxDeviation is the max percent a value can deviate
+	// from the median of the recent on-chain values.
+	MaxDeviation float64
+	// Recent is the number of the latest on-chain values used for the median.
+	Recent int64
+	// MedianTTL is how long to cache the median of the on-chain values.
+	MedianTTL format.Duration
+	// ReferenceURL is a secondary PSR endpoint to compare with
+	// e.g. the /api/v1/psr/tellor endpoint of another instance.
+	// Optional and when it can't be reached the check is skipped.
+	ReferenceURL string
+	// ReferenceTimeout is how long to wait for the reference value.
+	ReferenceTimeout format.Duration
+	// MaxReferenceDeviation is the max percent a value can deviate from the reference.
+	MaxReferenceDeviation float64
+	// Volatility flags the values that moved too fast from the last on-chain value.
+	Volatility VolatilityConfig
+}
+
+// VolatilityConfig flags a value that moved more than MaxChange percent
+// from the last on-chain value submitted within Period
+// unless at least MinSources sources moved as much in the same direction.
+// It protects from the flash crash of a single source while still allowing the real market moves.
+type VolatilityConfig struct {
+	Enabled    bool
+	MaxChange  float64
+	Period     format.Duration
+	MinSources int
+	// Block refuses the flagged values instead of only logging them.
+	Block bool
+	// Feeds overrides the limits of some request IDs.
+	// The zero fields use the limits above.
+	Feeds []VolatilityFeed
+}
+
+type VolatilityFeed struct {
+	RequestID  int64
+	MaxChange  float64
+	Period     format.Duration
+	MinSources int
+}
+
+// limits returns the limits of a request ID.
+func (self VolatilityConfig) limits(requestID int64) (float64, time.Duration, int) {
+	maxChange, period, minSources := self.MaxChange, self.Period.Duration, self.MinSources
+	for _, f := range self.Feeds {
+		if f.RequestID != requestID {
+			continue
+		}
+		if f.MaxChange != 0 {
+			maxChange = f.MaxChange
+		}
+		if f.Period.Duration != 0 {
+			period = f.Period.Duration
+		}
+		if f.MinSources != 0 {
+			minSources = f.MinSources
+		}
+	}
+	return maxChange, period, minSources
+}
+
+// CrossChecker compares the decoded value of a request ID with other oracles.
+type CrossChecker interface {
+	Check(ctx context.Context, requestID int64, value float64) error
+}
+
+// Sources counts the sources of a request ID which moved by at least change percent between two times.
+// A negative change counts the sources that dropped.
+type Sources interface {
+	Moved(requestID int64, from, to time.Time, change float64) (int, error)
+}
+
+type cachedMedian struct {
+	value   float64
+	expires time.Time
+}
+
+// Guard refuses values that deviate too much from the recent on-chain values
+// or from a reference source to avoid submitting a value that would be disputed
+// e.g. when an API returns 0.
+// It is safe for concurrent use.
+type Guard struct {
+	logger    log.Logger
+	cfg       GuardConfig
+	oracle    string
+	reader    contracts.ValueReader
+	registry  *registry.Registry
+	reference psr.Getter
+	sources   Sources
+	cross     CrossChecker
+
+	mtx     sync.Mutex
+	medians map[int64]cachedMedian
+}
+
+// NewGuard creates a guard for the values of an oracle in the registry e.g. registry.OracleTellor.
+// The sources are optional and without them every value that moved too fast is flagged.
+// The cross checker is optional.
+func NewGuard(logger log.Logger, cfg GuardConfig, oracle string, reader contracts.ValueReader, registry *registry.Registry, sources Sources, cross CrossChecker) *Guard {
+	self := &Guard{
+		logger:   log.With(logger, "component", "valueGuard", "oracle", oracle),
+		cfg:      cfg,
+		oracle:   oracle,
+		reader:   reader,
+		registry: registry,
+		sources:  sources,
+		cross:    cross,
+		medians:  make(map[int64]cachedMedian),
+	}
+	if cfg.ReferenceURL != "" {
+		timeout := cfg.ReferenceTimeout.Duration
+		if timeout <= 0 {
+			timeout = defaultReferenceTimeout
+		}
+		self.reference = psr.NewRemote(cfg.ReferenceURL, timeout)
+	}
+	return self
+}
+
+// Check returns an error wrapping ErrValueDeviates when the encoded value
+// of a request ID deviates more than allowed or is outside the registry bounds
+// and ErrUnitMismatch when it looks encoded with a different granularity than the on-chain values.
+func (self *Guard) Check(ctx context.Context, requestID int64, encoded *big.Int) error {
+	if err := self.registry.Check(self.oracle, requestID, self.registry.Decode(self.oracle, requestID, encoded)); err != nil {
+		return errors.Wrap(ErrValueDeviates, err.Error())
+	}
+	value, _ := new(big.Float).SetInt(encoded).Float64()
+
+	median, err := self.median(ctx, requestID)
+	if err != nil {
+		return errors.Wrapf(err, "getting the on-chain median for request ID:%v", requestID)
+	}
+	if median > 0 {
+		if m := magnitude(value, median); math.Abs(m) >= unitMismatchMagnitude {
+			return errors.Wrapf(ErrUnitMismatch, "request ID:%v value:%v is about 1e%v times the on-chain median:%v, check its granularity in the registry", requestID, value, m, median)
+		}
+		if d := deviation(value, median); d > self.cfg.MaxDeviation {
+			return errors.Wrapf(ErrValueDeviates, "request ID:%v value:%v is %.2f%% from the on-chain median:%v", requestID, value, d, median)
+		}
+	}
+
+	if self.cfg.Volatility.Enabled {
+		if err := self.checkVolatility(ctx, requestID, value); err != nil {
+			return err
+		}
+	}
+
+	if self.cross != nil {
+		if err := self.cross.Check(ctx, requestID, self.registry.Decode(self.oracle, requestID, encoded)); err != nil {
+			return err
+		}
+	}
+
+	if self.reference == nil {
+		return nil
+	}
+	ref, err := self.reference.GetValue(requestID, time.Now())
+	if err != nil {
+		level.Warn(self.logger).Log("msg", "getting the reference value, skipping the reference check", "requestID", requestID, "err", err)
+		return nil
+	}
+	if ref.Sign() > 0 {
+		refValue, _ := new(big.Float).SetInt(ref).Float64()
+		if d := deviation(value, refValue); d > self.cfg.MaxReferenceDeviation {
+			return errors.Wrapf(ErrValueDeviates, "request ID:%v value:%v is %.2f%% from the reference:%v", requestID, value, d, ref)
+		}
+	}
+	return nil
+}
+
+// checkVolatility flags a value that moved too fast from the last on-chain value
+// and returns an error wrapping ErrVolatile when the flagged values are blocked.
+func (self *Guard) checkVolatility(ctx context.Context, requestID int64, value float64) error {
+	maxChange, period, minSources := self.cfg.Volatility.limits(requestID)
+	queryID := contracts.LegacyQueryID(requestID)
+	count, err := self.reader.ValueCount(ctx, queryID)
+	if err != nil {
+		return errors.Wrap(err, "getting the value count")
+	}
+	if count == 0 {
+		return nil
+	}
+	ts, err := self.reader.TimestampByIndex(ctx, queryID, count-1)
+	if err != nil {
+		return errors.Wrap(err, "getting the last timestamp")
+	}
+	now := time.Now()
+	if now.Sub(ts) > period {
+		return nil
+	}
+	val, err := self.reader.Value(ctx, queryID, ts)
+	if err != nil {
+		return errors.Wrapf(err, "getting the value timestamp:%v", ts)
+	}
+	last, _ := new(big.Float).SetInt(val).Float64()
+	if last <= 0 {
+		return nil
+	}
+	change := (value - last) / last * 100
+	if math.Abs(change) <= maxChange {
+		return nil
+	}
+
+	var moved int
+	if self.sources != nil {
+		// The sources should move at least as much as allowed in the same direction.
+		limit := maxChange
+		if change < 0 {
+			limit = -maxChange
+		}
+		moved, err = self.sources.Moved(requestID, ts, now, limit)
+		if err != nil {
+			level.Warn(self.logger).Log("msg", "counting the sources that moved", "requestID", requestID, "err", err)
+		}
+	}
+	if moved >= minSources {
+		level.Info(self.logger).Log("msg", "fast move confirmed by the sources", "requestID", requestID, "change", change, "sources", moved)
+		return nil
+	}
+
+	volatilityFlags.With(prometheus.Labels{"oracle": self.oracle, "requestID": strconv.FormatInt(requestID, 10)}).Inc()
+	msg := fmt.Sprintf("request ID:%v value:%v moved %.2f%% from the last on-chain value:%v since:%v confirmed by %v of %v sources", requestID, value, change, last, ts.Format(time.RFC3339), moved, minSources)
+	if self.cfg.Volatility.Block {
+		return errors.Wrap(ErrVolatile, msg)
+	}
+	level.Warn(self.logger).Log("msg", "value flagged as too volatile", "details", msg)
+	return nil
+}
+
+// median returns the median of the latest on-chain values
+// or 0 when there are no values for the ID.
+func (self *Guard) median(ctx context.Context, requestID int64) (float64, error) {
+	self.mtx.Lock()
+	m, ok := self.medians[requestID]
+	self.mtx.Unlock()
+	if ok && time.Now().Before(m.expires) {
+		return m.value, nil
+	}
+
+	queryID := contracts.LegacyQueryID(requestID)
+	count, err := self.reader.ValueCount(ctx, queryID)
+	if err != nil {
+		return 0, errors.Wrap(err, "getting the value count")
+	}
+	var values []float64
+	for i := count - 1; i >= 0 && i >= count-self.cfg.Recent; i-- {
+		ts, err := self.reader.TimestampByIndex(ctx, queryID, i)
+		if err != nil {
+			return 0, errors.Wrapf(err, "getting the timestamp index:%v", i)
+		}
+		val, err := self.reader.Value(ctx, queryID, ts)
+		if err != nil {
+			return 0, errors.Wrapf(err, "getting the value timestamp:%v", ts)
+		}
+		v, _ := new(big.Float).SetInt(val).Float64()
+		values = append(values, v)
+	}
+
+	m = cachedMedian{value: middle(values), expires: time.Now().Add(self.cfg.MedianTTL.Duration)}
+	self.mtx.Lock()
+	self.medians[requestID] = m
+	self.mtx.Unlock()
+	return m.value, nil
+}
+
+func middle(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Float64s(values)
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2
+	}
+	return values[mid]
+}
+
+// magnitude returns the orders of magnitude between a value and the expected rounded to an integer.
+func magnitude(value, expected float64) float64 {
+	if value <= 0 {
+		return 0
+	}
+	return math.Round(math.Log10(value / expected))
+}
+
+// deviation in percent of a value from the expected.
+func deviation(value, expected float64) float64 {
+	return math.Abs(value-expected) / expected * 100
+}
@@ -0,0 +1,167 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package submitter
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/psr"
+	"github.com/tellor-io/telliot/pkg/registry"
+	"github.com/tellor-io/telliot/pkg/testutil"
+	"github.com/tellor-io/telliot/pkg/web"
+)
+
+// values is a ValueReader with a value every minute.
+type values []int64
+
+func (self values) ValueCount(context.Context, [32]byte) (int64, error) {
+	return int64(len(self)), nil
+}
+
+func (self values) TimestampByIndex(_ context.Context, _ [32]byte, index int64) (time.Time, error) {
+	return time.Unix(index*60, 0), nil
+}
+
+func (self values) Value(_ context.Context, _ [32]byte, ts time.Time) (*big.Int, error) {
+	return big.NewInt(self[ts.Unix()/60]), nil
+}
+
+// TestGuard ensures that the values far from the recent on-chain values are refused.
+func TestGuard(t *testing.T) {
+	ctx := context.Background()
+	// The first value is outside the recent values.
+	reader := values{1, 100, 90, 110, 105, 95}
+	guard := NewGuard(logging.NewLogger(), GuardConfig{MaxDeviation: 20, Recent: 5}, registry.OracleTellor, reader, registry.Default(), nil, nil)
+
+	testutil.Ok(t, guard.Check(ctx, 1, big.NewInt(100)))
+	testutil.Ok(t, guard.Check(ctx, 1, big.NewInt(119)))
+	err := guard.Check(ctx, 1, big.NewInt(0))
+	testutil.Assert(t, errors.Is(err, ErrValueDeviates), "a zero value not refused")
+	err = guard.Check(ctx, 1, big.NewInt(130))
+	testutil.Assert(t, errors.Is(err, ErrValueDeviates), "a value too far from the median not refused")
+
+	// A value encoded with 1e18 instead of 1e6 granularity.
+	err = guard.Check(ctx, 1, new(big.Int).Mul(big.NewInt(100), big.NewInt(1e12)))
+	testutil.Assert(t, errors.Is(err, ErrUnitMismatch), "a unit mismatch not refused:%v", err)
+
+	// No on-chain values so nothing to compare with.
+	guard = NewGuard(logging.NewLogger(), GuardConfig{MaxDeviation: 20, Recent: 5}, registry.OracleTellor, values{}, registry.Default(), nil, nil)
+	testutil.Ok(t, guard.Check(ctx, 1, big.NewInt(0)))
+}
+
+// recentValues is a ValueReader with a value every minute until now.
+type recentValues struct {
+	now    time.Time
+	values []int64
+}
+
+func (self recentValues) ValueCount(context.Context, [32]byte) (int64, error) {
+	return int64(len(self.values)), nil
+}
+
+func (self recentValues) TimestampByIndex(_ context.Context, _ [32]byte, index int64) (time.Time, error) {
+	return self.now.Add(-time.Duration(int64(len(self.values))-1-index) * time.Minute), nil
+}
+
+func (self recentValues) Value(_ context.Context, _ [32]byte, ts time.Time) (*big.Int, error) {
+	return big.NewInt(self.values[int64(len(self.values))-1-int64(self.now.Sub(ts)/time.Minute)]), nil
+}
+
+type moved int
+
+func (self moved) Moved(int64, time.Time, time.Time, float64) (int, error) {
+	return int(self), nil
+}
+
+// TestGuardVolatility ensures that the fast moves are refused unless enough sources moved as well.
+func TestGuardVolatility(t *testing.T) {
+	ctx := context.Background()
+	reader := recentValues{now: time.Now().Add(-time.Minute), values: []int64{100, 100, 100}}
+	cfg := GuardConfig{
+		MaxDeviation: 100,
+		Recent:       5,
+		Volatility: VolatilityConfig{
+			Enabled:    true,
+			MaxChange:  10,
+			Period:     format.Duration{Duration: time.Hour},
+			MinSources: 2,
+			Block:      true,
+		},
+	}
+
+	guard := NewGuard(logging.NewLogger(), cfg, registry.OracleTellor, reader, registry.Default(), moved(1), nil)
+	testutil.Ok(t, guard.Check(ctx, 1, big.NewInt(105)))
+	err := guard.Check(ctx, 1, big.NewInt(130))
+	testutil.Assert(t, errors.Is(err, ErrVolatile), "a move of a single source not refused:%v", err)
+	err = guard.Check(ctx, 1, big.NewInt(70))
+	testutil.Assert(t, errors.Is(err, ErrVolatile), "a drop of a single source not refused:%v", err)
+
+	guard = NewGuard(logging.NewLogger(), cfg, registry.OracleTellor, reader, registry.Default(), nil, nil)
+	err = guard.Check(ctx, 1, big.NewInt(130))
+	testutil.Assert(t, errors.Is(err, ErrVolatile), "a move without sources not refused:%v", err)
+
+	// Confirmed by enough sources.
+	guard = NewGuard(logging.NewLogger(), cfg, registry.OracleTellor, reader, registry.Default(), moved(2), nil)
+	testutil.Ok(t, guard.Check(ctx, 1, big.NewInt(130)))
+
+	// A feed with a higher limit.
+	feeds := cfg
+	feeds.Volatility.Feeds = []VolatilityFeed{{RequestID: 1, MaxChange: 50}}
+	guard = NewGuard(logging.NewLogger(), feeds, registry.OracleTellor, reader, registry.Default(), moved(1), nil)
+	testutil.Ok(t, guard.Check(ctx, 1, big.NewInt(130)))
+
+	// Only flagged.
+	flagOnly := cfg
+	flagOnly.Volatility.Block = false
+	guard = NewGuard(logging.NewLogger(), flagOnly, registry.OracleTellor, reader, registry.Default(), moved(1), nil)
+	testutil.Ok(t, guard.Check(ctx, 1, big.NewInt(130)))
+
+	// The last on-chain value is older than the period.
+	old := cfg
+	old.Volatility.Period = format.Duration{Duration: 30 * time.Second}
+	guard = NewGuard(logging.NewLogger(), old, registry.OracleTellor, reader, registry.Default(), moved(1), nil)
+	testutil.Ok(t, guard.Check(ctx, 1, big.NewInt(130)))
+}
+
+// TestGuardReference ensures that the values far from the reference are refused
+// and that the check is skipped when the reference doesn't respond in time.
+func TestGuardReference(t *testing.T) {
+	ctx := context.Background()
+	delay := make(chan time.Duration, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case d := <-delay:
+			time.Sleep(d)
+		default:
+		}
+		_ = web.WriteJSON(w, http.StatusOK, psr.Response{Value: big.NewInt(100)}, nil)
+	}))
+	defer srv.Close()
+
+	cfg := GuardConfig{
+		MaxDeviation:          20,
+		Recent:                5,
+		ReferenceURL:          srv.URL,
+		ReferenceTimeout:      format.Duration{Duration: 100 * time.Millisecond},
+		MaxReferenceDeviation: 5,
+	}
+	guard := NewGuard(logging.NewLogger(), cfg, registry.OracleTellor, values{}, registry.Default(), nil, nil)
+
+	testutil.Ok(t, guard.Check(ctx, 1, big.NewInt(104)))
+	err := guard.Check(ctx, 1, big.NewInt(110))
+	testutil.Assert(t, errors.Is(err, ErrValueDeviates), "a value too far from the reference not refused")
+
+	delay <- time.Second
+	start := time.Now()
+	testutil.Ok(t, guard.Check(ctx, 1, big.NewInt(110)))
+	testutil.Assert(t, time.Since(start) < time.Second, "the reference timeout isn't applied")
+}
@@ -0,0 +1,120 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package submitter
+
+import (
+	"regexp"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/contracts"
+)
+
+// The default methods of the multicalls.
+const (
+	defaultMulticallMethod = "multicall(bytes[])"
+	defaultBatcherMethod   = "batch(address,bytes[])"
+)
+
+var (
+	multicallMethod = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*\(bytes\[\]\)$`)
+	batcherMethod   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*\(address,bytes\[\]\)$`)
+)
+
+// MulticallConfig batches the submissions of several values in one transaction
+// so they share the base gas and the latency of a single transaction.
+type MulticallConfig struct {
+	Enabled bool
+	// Batcher is a contract that calls the oracle with each of the calls.
+	// The oracle sees the batcher as the sender so it should be the reporter.
+	// The calls are sent to the multicall method of the oracle itself when empty,
+	// which keeps the account as the sender.
+	Batcher string
+	// Method is the multicall method with the calls, multicall(bytes[]) for the oracle
+	// and batch(address,bytes[]) with the oracle address for a batcher by default.
+	Method string
+}
+
+// Multicall sends several calls of the oracle in one transaction.
+type Multicall struct {
+	contract *bind.BoundContract
+	address  common.Address
+	oracle   common.Address
+	batcher  bool
+	selector []byte
+	args     abi.Arguments
+}
+
+func NewMulticall(cfg MulticallConfig, oracle common.Address, client contracts.ETHClient) (*Multicall, error) {
+	bytesArr, _ := abi.NewType("bytes[]", "", nil)
+	self := &Multicall{
+		address: oracle,
+		oracle:  oracle,
+		args:    abi.Arguments{{Type: bytesArr}},
+	}
+	method := cfg.Method
+	if cfg.Batcher == "" {
+		if method == "" {
+			method = defaultMulticallMethod
+		}
+		if !multicallMethod.MatchString(method) {
+			return nil, errors.Errorf("multicall method:%v should have the calls param like %v", method, defaultMulticallMethod)
+		}
+	} else {
+		if !common.IsHexAddress(cfg.Batcher) {
+			return nil, errors.Errorf("invalid batcher address:%v", cfg.Batcher)
+		}
+		if method == "" {
+			method = defaultBatcherMethod
+		}
+		if !batcherMethod.MatchString(method) {
+			return nil, errors.Errorf("batcher method:%v should have the oracle address and calls params like %v", method, defaultBatcherMethod)
+		}
+		address, _ := abi.NewType("address", "", nil)
+		self.address = common.HexToAddress(cfg.Batcher)
+		self.batcher = true
+		self.args = abi.Arguments{{Type: address}, {Type: bytesArr}}
+	}
+	self.selector = crypto.Keccak256([]byte(method))[:4]
+	self.contract = bind.NewBoundContract(self.address, abi.ABI{}, client, client, client)
+	return self, nil
+}
+
+// Sender returns the address that the oracle sees as the sender of the calls.
+func (self *Multicall) Sender(account common.Address) common.Address {
+	if self.batcher {
+		return self.address
+	}
+	return account
+}
+
+// Pack returns the calldata of the multicall with the calldata of the oracle calls.
+func (self *Multicall) Pack(calls [][]byte) ([]byte, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if self.batcher {
+		data, err = self.args.Pack(self.oracle, calls)
+	} else {
+		data, err = self.args.Pack(calls)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "packing the multicall")
+	}
+	return append(append([]byte{}, self.selector...), data...), nil
+}
+
+// Transact sends the multicall of the oracle calls.
+func (self *Multicall) Transact(auth *bind.TransactOpts, calls [][]byte) (*types.Transaction, error) {
+	data, err := self.Pack(calls)
+	if err != nil {
+		return nil, err
+	}
+	return self.contract.RawTransact(auth, data)
+}
@@ -0,0 +1,64 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package submitter
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tellor-io/telliot/pkg/testutil"
+	"github.com/tellor-io/telliot/pkg/testutil/chain"
+)
+
+func TestMulticall(t *testing.T) {
+	c, err := chain.New(1)
+	testutil.Ok(t, err)
+	defer c.Close()
+	account := c.Accounts[0].Address
+	oracle := common.HexToAddress("0x00000000000000000000000000000000000000a1")
+	batcher := common.HexToAddress("0x00000000000000000000000000000000000000b1")
+	calls := [][]byte{{0x01, 0x02}, {0x03}}
+	auth := &bind.TransactOpts{
+		From: account,
+		Signer: func(from common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			return tx, nil
+		},
+		Nonce:    big.NewInt(0),
+		GasPrice: big.NewInt(1),
+		GasLimit: 300000,
+		NoSend:   true,
+	}
+
+	m, err := NewMulticall(MulticallConfig{Enabled: true}, oracle, c)
+	testutil.Ok(t, err)
+	testutil.Equals(t, account, m.Sender(account))
+	tx, err := m.Transact(auth, calls)
+	testutil.Ok(t, err)
+	testutil.Equals(t, oracle, *tx.To())
+	testutil.Equals(t, crypto.Keccak256([]byte(defaultMulticallMethod))[:4], tx.Data()[:4])
+	args, err := m.args.Unpack(tx.Data()[4:])
+	testutil.Ok(t, err)
+	testutil.Equals(t, calls, args[0])
+
+	m, err = NewMulticall(MulticallConfig{Enabled: true, Batcher: batcher.Hex()}, oracle, c)
+	testutil.Ok(t, err)
+	testutil.Equals(t, batcher, m.Sender(account))
+	tx, err = m.Transact(auth, calls)
+	testutil.Ok(t, err)
+	testutil.Equals(t, batcher, *tx.To())
+	testutil.Equals(t, crypto.Keccak256([]byte(defaultBatcherMethod))[:4], tx.Data()[:4])
+	args, err = m.args.Unpack(tx.Data()[4:])
+	testutil.Ok(t, err)
+	testutil.Equals(t, oracle, args[0])
+	testutil.Equals(t, calls, args[1])
+
+	_, err = NewMulticall(MulticallConfig{Enabled: true, Method: "multicall(address,bytes[])"}, oracle, c)
+	testutil.NotOk(t, err)
+	_, err = NewMulticall(MulticallConfig{Enabled: true, Batcher: batcher.Hex(), Method: "batch(bytes[])"}, oracle, c)
+	testutil.NotOk(t, err)
+}
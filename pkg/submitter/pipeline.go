@@ -0,0 +1,311 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package submitter
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	eth "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/errclass"
+	"github.com/tellor-io/telliot/pkg/tracing"
+	"github.com/tellor-io/telliot/pkg/transactor"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrRejected is returned when the value guard refuses the values of a submission.
+var ErrRejected = errclass.New(errclass.DataQuality, "values rejected by the guard")
+
+// ErrDryRun is returned after a successful simulation in dry-run mode.
+var ErrDryRun = errors.New("dry-run")
+
+// ErrReverted is returned when a submission is mined with a failed status.
+var ErrReverted = errclass.New(errclass.Revert, "receipt status not success")
+
+// Submission is a single transaction of a submitter.
+type Submission struct {
+	// Function is the contract function used for the transactor confirmations and the gas records.
+	Function string
+	IDs      []int64
+	Values   []*big.Int
+	// Unchecked are the IDs with manual values which are not checked by the guard.
+	Unchecked map[int64]bool
+	// Build creates the transaction. It is called with NoSend
+	// and can change the options e.g. to set the gas price.
+	Build func(auth *bind.TransactOpts) (*types.Transaction, error)
+	// OnSimulated and OnBroadcast are optional e.g. to record the submission in the journal.
+	OnSimulated func(tx *types.Transaction)
+	OnBroadcast func(tx *types.Transaction)
+}
+
+// Pipeline is the part of the submitters from the values to the confirmed transaction.
+// It checks the values with the guard, simulates the transaction before broadcasting it
+// through the transactor and records the metrics.
+// In dry-run mode the transaction is only simulated and never signed or sent.
+type Pipeline struct {
+	logger     log.Logger
+	component  string
+	client     contracts.ETHClient
+	account    common.Address
+	transactor transactor.Transactor
+	guard      *Guard
+	breaker    *Breaker
+	dryRun     bool
+
+	submitCount       prometheus.Counter
+	submitFailCount   prometheus.Counter
+	submitRevertCount prometheus.Counter
+	guardRejects      prometheus.Counter
+	dryRuns           prometheus.Counter
+	gasUsed           prometheus.Counter
+	gasCost           prometheus.Counter
+	submitValue       *prometheus.GaugeVec
+}
+
+// NewPipeline creates the pipeline of a single account with the metrics
+// in the subsystem of the submitter component. The guard and the breaker are optional.
+func NewPipeline(
+	logger log.Logger,
+	component string,
+	client contracts.ETHClient,
+	account common.Address,
+	transactor transactor.Transactor,
+	guard *Guard,
+	breaker *Breaker,
+	dryRun bool,
+) *Pipeline {
+	labels := prometheus.Labels{"account": account.String()}
+	return &Pipeline{
+		logger:     logger,
+		component:  component,
+		client:     client,
+		account:    account,
+		transactor: transactor,
+		guard:      guard,
+		breaker:    breaker,
+		dryRun:     dryRun,
+		submitCount: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace:   "telliot",
+			Subsystem:   component,
+			Name:        "submit_total",
+			Help:        "The total number of submitted solutions",
+			ConstLabels: labels,
+		}),
+		submitFailCount: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace:   "telliot",
+			Subsystem:   component,
+			Name:        "submit_fails_total",
+			Help:        "The total number of failed submission",
+			ConstLabels: labels,
+		}),
+		submitRevertCount: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace:   "telliot",
+			Subsystem:   component,
+			Name:        "submit_reverts_total",
+			Help:        "The total number of submissions reverted on chain",
+			ConstLabels: labels,
+		}),
+		guardRejects: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace:   "telliot",
+			Subsystem:   component,
+			Name:        "guard_rejects_total",
+			Help:        "The total number of times the value guard refused to submit the values",
+			ConstLabels: labels,
+		}),
+		dryRuns: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace:   "telliot",
+			Subsystem:   component,
+			Name:        "dry_runs_total",
+			Help:        "The total number of submissions only simulated in dry-run mode",
+			ConstLabels: labels,
+		}),
+		gasUsed: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace:   "telliot",
+			Subsystem:   component,
+			Name:        "gas_used_total",
+			Help:        "The total amount of gas used by the submissions including the reverted ones",
+			ConstLabels: labels,
+		}),
+		gasCost: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace:   "telliot",
+			Subsystem:   component,
+			Name:        "gas_cost_total",
+			Help:        "The total ETH spent on gas by the submissions including the reverted ones",
+			ConstLabels: labels,
+		}),
+		submitValue: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   "telliot",
+			Subsystem:   component,
+			Name:        "submit_value",
+			Help:        "The submitted value",
+			ConstLabels: labels,
+		},
+			[]string{"id"},
+		),
+	}
+}
+
+// Submit sends the submission and waits for it to be mined.
+// It returns an error wrapping ErrRejected when the guard refuses the values,
+// ErrDryRun after a successful simulation in dry-run mode
+// and ErrReverted with the transaction and receipt when it is mined with a failed status.
+// The reverted and failed submissions are reported to the breaker.
+func (self *Pipeline) Submit(ctx context.Context, s Submission) (*types.Transaction, *types.Receipt, error) {
+	if err := self.check(ctx, s); err != nil {
+		self.guardRejects.Inc()
+		err = errors.Wrap(ErrRejected, err.Error())
+		errclass.Observe(self.component, err)
+		return nil, nil, err
+	}
+
+	if self.dryRun {
+		tx, err := self.simulateOnly(ctx, s)
+		if err != nil {
+			self.submitFailCount.Inc()
+			return nil, nil, errors.Wrap(err, "dry-run")
+		}
+		self.dryRuns.Inc()
+		level.Info(self.logger).Log("msg", "dry-run, the submission is not broadcasted", "IDs", fmt.Sprintf("%v", s.IDs), "vals", fmt.Sprintf("%v", s.Values), "gasLimit", tx.Gas(), "data", fmt.Sprintf("%x", tx.Data()))
+		return tx, nil, ErrDryRun
+	}
+
+	f := func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		// Sign the transaction without sending it so it can be simulated first.
+		auth.NoSend = true
+		tx, err := s.Build(auth)
+		if err != nil {
+			return nil, err
+		}
+		if err := Simulate(ctx, self.client, auth.From, tx); err != nil {
+			return nil, errors.Wrap(err, "simulate transaction")
+		}
+		if s.OnSimulated != nil {
+			s.OnSimulated(tx)
+		}
+		_, sendSpan := tracing.Start(ctx, "submitter.broadcast", attribute.String("txHash", tx.Hash().String()))
+		defer sendSpan.End()
+		// The transactor sends it through the fee route of the function when there is one.
+		tx, err = self.transactor.Send(transactor.WithFunction(ctx, s.Function), tx)
+		if err != nil {
+			tracing.Error(sendSpan, err)
+			return nil, err
+		}
+		if s.OnBroadcast != nil {
+			s.OnBroadcast(tx)
+		}
+		return tx, nil
+	}
+	tx, receipt, err := self.transactor.Transact(transactor.WithFunction(ctx, s.Function), f)
+	if err != nil {
+		self.submitFailCount.Inc()
+		// A canceled submission isn't a failure.
+		if ctx.Err() == nil {
+			errclass.Observe(self.component, err)
+			self.breaker.Failure(ctx, DecodeRevert(err))
+		}
+		return nil, nil, err
+	}
+
+	self.recordGas(tx, receipt)
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		self.submitFailCount.Inc()
+		self.submitRevertCount.Inc()
+		if self.breaker != nil {
+			reason := RevertReason(ctx, self.client, self.account, tx, receipt.BlockNumber)
+			level.Error(self.logger).Log("msg", "submission reverted", "txHash", tx.Hash(), "reason", reason)
+			self.breaker.Failure(ctx, fmt.Sprintf("tx:%v reverted:%v", tx.Hash().String(), reason))
+		}
+		err := errors.Wrapf(ErrReverted, "status:%v tx hash:%v", receipt.Status, tx.Hash())
+		errclass.Observe(self.component, err)
+		return tx, receipt, err
+	}
+	self.breaker.Success()
+	self.submitCount.Inc()
+	for i, id := range s.IDs {
+		val, _ := new(big.Float).SetInt(s.Values[i]).Float64()
+		self.submitValue.With(prometheus.Labels{"id": strconv.FormatInt(id, 10)}).(prometheus.Gauge).Set(val)
+	}
+	return tx, receipt, nil
+}
+
+// check runs the values through the guard when enabled.
+func (self *Pipeline) check(ctx context.Context, s Submission) error {
+	if self.guard == nil {
+		return nil
+	}
+	for i, id := range s.IDs {
+		if s.Unchecked[id] {
+			continue
+		}
+		if err := self.guard.Check(ctx, id, s.Values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// simulateOnly builds the transaction without a private key and simulates it.
+// The nonce and gas limit are filled in by the binding.
+func (self *Pipeline) simulateOnly(ctx context.Context, s Submission) (*types.Transaction, error) {
+	gasPrice, err := self.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting the gas price")
+	}
+	auth := &bind.TransactOpts{
+		From:     self.account,
+		Context:  ctx,
+		GasPrice: gasPrice,
+		NoSend:   true,
+		Signer: func(_ common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			return tx, nil
+		},
+	}
+	tx, err := s.Build(auth)
+	if err != nil {
+		return nil, err
+	}
+	if err := Simulate(ctx, self.client, auth.From, tx); err != nil {
+		return nil, errors.Wrap(err, "simulate transaction")
+	}
+	return tx, nil
+}
+
+// recordGas adds the gas spent by a mined transaction.
+func (self *Pipeline) recordGas(tx *types.Transaction, receipt *types.Receipt) {
+	cost, _ := big.NewFloat(0).Mul(new(big.Float).SetInt(tx.GasPrice()), big.NewFloat(float64(receipt.GasUsed))).Float64()
+	self.gasUsed.Add(float64(receipt.GasUsed))
+	self.gasCost.Add(cost / 1e18)
+}
+
+// Simulate executes the transaction against the latest state without including it in a block.
+func Simulate(ctx context.Context, client contracts.ETHClient, from common.Address, tx *types.Transaction) error {
+	ctx, span := tracing.Start(ctx, "submitter.simulate")
+	defer span.End()
+	ctx, cncl := context.WithTimeout(ctx, time.Minute)
+	defer cncl()
+	_, err := client.CallContract(ctx, eth.CallMsg{
+		From:     from,
+		To:       tx.To(),
+		Gas:      tx.Gas(),
+		GasPrice: tx.GasPrice(),
+		Value:    tx.Value(),
+		Data:     tx.Data(),
+	}, nil)
+	if err != nil {
+		tracing.Error(span, err)
+	}
+	return err
+}
@@ -0,0 +1,116 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package submitter
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/testutil"
+	"github.com/tellor-io/telliot/pkg/testutil/chain"
+	"github.com/tellor-io/telliot/pkg/transactor"
+)
+
+// submitValue is a submission of a value to the oracle of the chain
+// which counts its callbacks.
+func submitValue(c *chain.Chain, requestID int64, value *big.Int, simulated, broadcast *int) Submission {
+	return Submission{
+		Function: transactor.FunctionSubmitValue,
+		IDs:      []int64{requestID},
+		Values:   []*big.Int{value},
+		Build: func(auth *bind.TransactOpts) (*types.Transaction, error) {
+			return c.TellorAccess.SubmitValue(auth, big.NewInt(requestID), value)
+		},
+		OnSimulated: func(*types.Transaction) { *simulated++ },
+		OnBroadcast: func(*types.Transaction) { *broadcast++ },
+	}
+}
+
+func newTestPipeline(t *testing.T, c *chain.Chain, account *ethereum.Account, dryRun bool) (*Pipeline, *Breaker, *Gate) {
+	gate := NewGate(account.Address.String())
+	breaker, err := NewBreaker(logging.NewLogger(), BreakerConfig{Enabled: true, Threshold: 1}, account.Address.String(), gate, &recordNotifier{})
+	testutil.Ok(t, err)
+	return NewPipeline(logging.NewLogger(), "testPipeline", c, account.Address, c.Transactor(account), nil, breaker, dryRun), breaker, gate
+}
+
+// TestPipeline ensures that a submission is simulated, broadcasted and confirmed
+// and that its value is on chain.
+func TestPipeline(t *testing.T) {
+	ctx := context.Background()
+	c, err := chain.New(1)
+	testutil.Ok(t, err)
+	defer c.Close()
+	pipeline, _, gate := newTestPipeline(t, c, c.Accounts[0], false)
+
+	var simulated, broadcast int
+	tx, receipt, err := pipeline.Submit(ctx, submitValue(c, 1, big.NewInt(100), &simulated, &broadcast))
+	testutil.Ok(t, err)
+	testutil.Equals(t, types.ReceiptStatusSuccessful, receipt.Status)
+	testutil.Equals(t, tx.Hash(), receipt.TxHash)
+	testutil.Equals(t, 1, simulated)
+	testutil.Equals(t, 1, broadcast)
+	testutil.Ok(t, gate.Err())
+
+	exists, val, _, err := c.TellorAccess.GetCurrentValue(&bind.CallOpts{Context: ctx}, big.NewInt(1))
+	testutil.Ok(t, err)
+	testutil.Assert(t, exists, "the submitted value isn't on chain")
+	testutil.Equals(t, big.NewInt(100), val)
+}
+
+// TestPipelineDryRun ensures that a dry run only simulates the submission.
+func TestPipelineDryRun(t *testing.T) {
+	ctx := context.Background()
+	c, err := chain.New(2)
+	testutil.Ok(t, err)
+	defer c.Close()
+	account := c.Accounts[1]
+	pipeline, _, _ := newTestPipeline(t, c, account, true)
+
+	var simulated, broadcast int
+	tx, receipt, err := pipeline.Submit(ctx, submitValue(c, 1, big.NewInt(100), &simulated, &broadcast))
+	testutil.Assert(t, errors.Is(err, ErrDryRun), "dry run error not returned:%v", err)
+	testutil.Assert(t, tx != nil, "the simulated transaction not returned")
+	testutil.Assert(t, receipt == nil, "a dry run has no receipt")
+	testutil.Equals(t, 0, broadcast)
+
+	nonce, err := c.NonceAt(ctx, account.Address)
+	testutil.Ok(t, err)
+	testutil.Equals(t, uint64(0), nonce)
+	exists, _, _, err := c.TellorAccess.GetCurrentValue(&bind.CallOpts{Context: ctx}, big.NewInt(1))
+	testutil.Ok(t, err)
+	testutil.Assert(t, !exists, "a dry run submitted the value")
+}
+
+// TestPipelineSimulationFailure ensures that a submission that would revert
+// is never broadcasted and is reported to the breaker.
+func TestPipelineSimulationFailure(t *testing.T) {
+	ctx := context.Background()
+	c, err := chain.New(3)
+	testutil.Ok(t, err)
+	defer c.Close()
+	account := c.Accounts[2]
+	opts, err := c.Opts(c.Accounts[0])
+	testutil.Ok(t, err)
+	_, err = c.TellorAccess.RemoveReporter(opts, account.Address)
+	testutil.Ok(t, err)
+	pipeline, breaker, gate := newTestPipeline(t, c, account, false)
+
+	var simulated, broadcast int
+	_, _, err = pipeline.Submit(ctx, submitValue(c, 1, big.NewInt(100), &simulated, &broadcast))
+	testutil.NotOk(t, err)
+	testutil.Equals(t, 0, simulated)
+	testutil.Equals(t, 0, broadcast)
+
+	nonce, err := c.NonceAt(ctx, account.Address)
+	testutil.Ok(t, err)
+	testutil.Equals(t, uint64(0), nonce)
+	testutil.Assert(t, breaker.Status().Tripped, "the failure isn't reported to the breaker")
+	testutil.NotOk(t, gate.Err())
+}
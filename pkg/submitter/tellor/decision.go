@@ -0,0 +1,163 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package tellor
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/mempool"
+	"github.com/tellor-io/telliot/pkg/reward"
+)
+
+// The actions of a submission decision.
+const (
+	ActionSubmit = "submit"
+	// ActionTiming waits for the timing strategy before checking the slots and the values.
+	ActionTiming = "timing"
+	// ActionWait checks the slots, the pauses and the values again after a second.
+	ActionWait = "wait"
+	// ActionRetry waits for the submit period and the timing strategy again.
+	ActionRetry = "retry"
+	// ActionSkip abandons the submission of the challenge.
+	ActionSkip = "skip"
+)
+
+// DecisionConfig is the part of the config used by the submission decisions.
+type DecisionConfig struct {
+	ProfitThreshold    uint64
+	MinSlotProbability float64
+}
+
+// Inputs are everything a submission decision depends on
+// so that the decision can be replayed from a record.
+type Inputs struct {
+	Time       time.Time
+	Account    string
+	Challenge  string
+	RequestIDs []int64
+	// Gate is the reason the submit gate of the account is closed.
+	Gate   string `json:",omitempty"`
+	Profit ProfitInputs
+	// MinerStatus is the staker status of the account.
+	MinerStatus    int64
+	MinerStatusErr string `json:",omitempty"`
+	// Timing is the strategy that allowed the broadcast, empty before it did.
+	Timing string `json:",omitempty"`
+	Slots  SlotInputs
+	Paused *Pause `json:",omitempty"`
+	// Values are the values to submit for the request IDs.
+	Values    []string `json:",omitempty"`
+	ValuesErr string   `json:",omitempty"`
+	// Stale is set when the values are missing as the samples of a symbol are too old.
+	Stale bool `json:",omitempty"`
+	// Overrides are the request IDs with a manual value.
+	Overrides []int64 `json:",omitempty"`
+}
+
+// ProfitInputs are the inputs of the profit check.
+type ProfitInputs struct {
+	// Slot is the slot of the submission.
+	Slot int64
+	// GasUsed is the gas used by the last submission for the slot, nil when unknown.
+	GasUsed *big.Int `json:",omitempty"`
+	// GasPrice is the current gas price in wei.
+	GasPrice *big.Int `json:",omitempty"`
+	// Reward is the current reward with the tips in TRB.
+	Reward        *big.Int `json:",omitempty"`
+	TRBPrice      float64
+	TRBConfidence float64
+	Err           string `json:",omitempty"`
+}
+
+// SlotInputs are the inputs of the slot probability check.
+type SlotInputs struct {
+	Filled int
+	// Competing are the pending submissions paying at least the current gas price,
+	// -1 without the mempool watcher.
+	Competing int
+	Err       string `json:",omitempty"`
+}
+
+// Decision is the outcome of a submission decision.
+type Decision struct {
+	Action string
+	Reason string `json:",omitempty"`
+}
+
+// Decide returns whether to submit a challenge with the inputs.
+// It only depends on its arguments so the recorded decisions can be replayed.
+func Decide(cfg DecisionConfig, in Inputs) Decision {
+	if in.Gate != "" {
+		return Decision{Action: ActionRetry, Reason: in.Gate}
+	}
+	if cfg.ProfitThreshold > 0 {
+		p := in.Profit
+		switch {
+		case p.Err != "":
+			return Decision{Action: ActionRetry, Reason: "submit solution profit check: " + p.Err}
+		// The check is skipped when the slot has no record for how much gas it uses.
+		case p.GasUsed == nil:
+		default:
+			profitPercent, err := reward.Profit(p.Reward, p.TRBPrice, p.TRBConfidence, p.GasUsed, p.GasPrice)
+			if err != nil {
+				return Decision{Action: ActionRetry, Reason: "submit solution profit check: " + err.Error()}
+			}
+			if profitPercent < int64(cfg.ProfitThreshold) {
+				return Decision{Action: ActionRetry, Reason: fmt.Sprintf("profit:%v lower then the profit threshold:%v", profitPercent, cfg.ProfitThreshold)}
+			}
+		}
+	}
+	if in.MinerStatusErr != "" {
+		return Decision{Action: ActionRetry, Reason: "getting miner status: " + in.MinerStatusErr}
+	}
+	if in.MinerStatus != 1 {
+		return Decision{Action: ActionRetry, Reason: fmt.Sprintf("miner is not in a status that can submit:%v", contracts.StakerStatusName(in.MinerStatus))}
+	}
+	if in.Timing == "" {
+		return Decision{Action: ActionTiming}
+	}
+
+	// A failed slot check doesn't delay the submission.
+	if in.Slots.Err == "" {
+		probability, filled := slotProbability(in.Slots)
+		if filled {
+			return Decision{Action: ActionSkip, Reason: "all slots are filled"}
+		}
+		if probability < cfg.MinSlotProbability {
+			return Decision{Action: ActionWait, Reason: fmt.Sprintf("chance to land a slot:%v is lower than the min:%v", probability, cfg.MinSlotProbability)}
+		}
+	}
+	if in.Paused != nil {
+		return Decision{Action: ActionWait, Reason: fmt.Sprintf("submissions of request ID:%v are paused until:%v", in.Paused.RequestID, in.Paused.Until)}
+	}
+	if in.Stale {
+		// Submitting old values would get disputed.
+		return Decision{Action: ActionSkip, Reason: in.ValuesErr}
+	}
+	if in.ValuesErr != "" {
+		return Decision{Action: ActionWait, Reason: in.ValuesErr}
+	}
+	return Decision{Action: ActionSubmit}
+}
+
+// slotProbability estimates the chance of landing one of the remaining slots
+// assuming that the pending submissions paying at least the current gas price are mined first,
+// and returns true when all slots are filled.
+// Without the mempool watcher only the filled slots are considered.
+func slotProbability(in SlotInputs) (float64, bool) {
+	left := mempool.NumSlots - in.Filled
+	if left <= 0 {
+		return 0, true
+	}
+	if in.Competing < 0 {
+		return 1, false
+	}
+	if in.Competing >= left {
+		return 0, false
+	}
+	return float64(left-in.Competing) / float64(left), false
+}
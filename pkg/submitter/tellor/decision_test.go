@@ -0,0 +1,99 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package tellor
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+func TestDecide(t *testing.T) {
+	ready := Inputs{MinerStatus: 1, Timing: "immediate", Slots: SlotInputs{Competing: -1}}
+	profit := ProfitInputs{
+		GasUsed:       big.NewInt(100000),
+		GasPrice:      big.NewInt(1e12),
+		Reward:        big.NewInt(1e18),
+		TRBPrice:      0.5,
+		TRBConfidence: 1,
+	}
+	cases := []struct {
+		name   string
+		cfg    DecisionConfig
+		modify func(in *Inputs)
+		action string
+	}{
+		{name: "ready", action: ActionSubmit},
+		{name: "gate closed", modify: func(in *Inputs) { in.Gate = "breaker open" }, action: ActionRetry},
+		{name: "not staked", modify: func(in *Inputs) { in.MinerStatus = 2 }, action: ActionRetry},
+		{name: "miner status error", modify: func(in *Inputs) { in.MinerStatusErr = "node down" }, action: ActionRetry},
+		{name: "before the timing", modify: func(in *Inputs) { in.Timing = "" }, action: ActionTiming},
+		{name: "profit above the threshold", cfg: DecisionConfig{ProfitThreshold: 300}, modify: func(in *Inputs) { in.Profit = profit }, action: ActionSubmit},
+		{name: "profit below the threshold", cfg: DecisionConfig{ProfitThreshold: 500}, modify: func(in *Inputs) { in.Profit = profit }, action: ActionRetry},
+		{name: "profit without gas used", cfg: DecisionConfig{ProfitThreshold: 500}, action: ActionSubmit},
+		{name: "profit error", cfg: DecisionConfig{ProfitThreshold: 500}, modify: func(in *Inputs) { in.Profit.Err = "no price" }, action: ActionRetry},
+		{name: "slots filled", modify: func(in *Inputs) { in.Slots.Filled = 5 }, action: ActionSkip},
+		{name: "slot check error", modify: func(in *Inputs) { in.Slots = SlotInputs{Filled: 5, Err: "node down"} }, action: ActionSubmit},
+		{name: "low slot probability", cfg: DecisionConfig{MinSlotProbability: 0.5}, modify: func(in *Inputs) { in.Slots = SlotInputs{Filled: 2, Competing: 2} }, action: ActionWait},
+		{name: "enough slot probability", cfg: DecisionConfig{MinSlotProbability: 0.5}, modify: func(in *Inputs) { in.Slots = SlotInputs{Filled: 1, Competing: 1} }, action: ActionSubmit},
+		{name: "paused", modify: func(in *Inputs) { in.Paused = &Pause{RequestID: 1} }, action: ActionWait},
+		{name: "stale values", modify: func(in *Inputs) { in.Stale, in.ValuesErr = true, "stale" }, action: ActionSkip},
+		{name: "missing values", modify: func(in *Inputs) { in.ValuesErr = "no value" }, action: ActionWait},
+	}
+	for _, c := range cases {
+		in := ready
+		if c.modify != nil {
+			c.modify(&in)
+		}
+		testutil.Equals(t, c.action, Decide(c.cfg, in).Action, c.name)
+	}
+}
+
+func TestReplayLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "replay")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "replay.log")
+
+	l, err := OpenReplayLog(path)
+	testutil.Ok(t, err)
+	cfg := DecisionConfig{MinSlotProbability: 0.5}
+	in := Inputs{
+		Time:        time.Now().Round(0).UTC(),
+		Account:     "0x1",
+		Challenge:   "aa",
+		RequestIDs:  []int64{1, 2, 3, 4, 5},
+		MinerStatus: 1,
+		Timing:      "immediate",
+		Slots:       SlotInputs{Filled: 2, Competing: 2},
+	}
+	waiting := Record{Inputs: in, Config: cfg, Decision: Decide(cfg, in)}
+	testutil.Ok(t, l.Record(waiting))
+	// Same inputs at a later time are not recorded again.
+	in.Time = in.Time.Add(time.Second)
+	testutil.Ok(t, l.Record(Record{Inputs: in, Config: cfg, Decision: Decide(cfg, in)}))
+	in.Slots.Competing = 0
+	in.Values = []string{"1", "2", "3", "4", "5"}
+	testutil.Ok(t, l.Record(Record{Inputs: in, Config: cfg, Decision: Decide(cfg, in)}))
+	testutil.Ok(t, l.Close())
+
+	records, err := ReadReplayLog(path)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 2, len(records))
+	testutil.Equals(t, waiting, records[0])
+	testutil.Equals(t, ActionWait, records[0].Decision.Action)
+	testutil.Equals(t, ActionSubmit, records[1].Decision.Action)
+	for _, r := range records {
+		testutil.Equals(t, r.Decision, r.Replay(r.Config))
+	}
+	testutil.Equals(t, ActionSubmit, records[0].Replay(DecisionConfig{MinSlotProbability: 0.1}).Action)
+
+	var nilLog *ReplayLog
+	testutil.Ok(t, nilLog.Record(waiting))
+}
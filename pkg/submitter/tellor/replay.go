@@ -0,0 +1,104 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package tellor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Record is a submission decision with its inputs and config.
+type Record struct {
+	Inputs
+	Config   DecisionConfig
+	Decision Decision
+}
+
+// Replay runs the decision of the record again with the config.
+func (self Record) Replay(cfg DecisionConfig) Decision {
+	return Decide(cfg, self.Inputs)
+}
+
+// ReplayLog appends the submission decisions to a file as JSON lines.
+// A decision with the same inputs and outcome as the previous one of the account
+// is not recorded again so that the waits don't fill the log.
+// It is safe for concurrent use and a nil log records nothing.
+type ReplayLog struct {
+	mtx  sync.Mutex
+	file *os.File
+	last map[string][]byte
+}
+
+// OpenReplayLog opens the log file for appending.
+func OpenReplayLog(path string) (*ReplayLog, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening replay log path:%v", path)
+	}
+	return &ReplayLog{file: file, last: make(map[string][]byte)}, nil
+}
+
+func (self *ReplayLog) Record(r Record) error {
+	if self == nil {
+		return nil
+	}
+	// Compared without the time which changes with every decision.
+	key := r
+	key.Time = time.Time{}
+	k, err := json.Marshal(key)
+	if err != nil {
+		return errors.Wrap(err, "marshal replay record")
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return errors.Wrap(err, "marshal replay record")
+	}
+
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	if bytes.Equal(self.last[r.Account], k) {
+		return nil
+	}
+	if _, err := self.file.Write(append(data, '\n')); err != nil {
+		return errors.Wrap(err, "write replay record")
+	}
+	self.last[r.Account] = k
+	return nil
+}
+
+func (self *ReplayLog) Close() error {
+	if self == nil {
+		return nil
+	}
+	return self.file.Close()
+}
+
+// ReadReplayLog reads the records of a replay log file.
+func ReadReplayLog(path string) ([]Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening replay log path:%v", path)
+	}
+	defer file.Close()
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, errors.Wrapf(err, "unmarshal replay record line:%v", line)
+		}
+		records = append(records, r)
+	}
+	return records, errors.Wrap(scanner.Err(), "reading replay log")
+}
@@ -0,0 +1,295 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package tellor
+
+import (
+	"math/big"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/registry"
+	"github.com/tellor-io/telliot/pkg/web"
+)
+
+// Override is a manual value to submit instead of the PSR value until it expires.
+type Override struct {
+	RequestID int64
+	// Value is in the same units as the PSR values before the granularity is applied.
+	Value  float64
+	Expiry time.Time
+}
+
+// Pause stops the submissions of the challenges that include a request ID,
+// or of all challenges when the request ID is 0, between two times
+// e.g. during an outage of an exchange used by the sources.
+// The submissions resume automatically at the end of the window.
+type Pause struct {
+	RequestID int64
+	From      time.Time
+	Until     time.Time
+	Reason    string
+	// By is who set the pause through the API.
+	By string `json:",omitempty"`
+}
+
+func (self Pause) validate() error {
+	if self.Until.IsZero() {
+		return errors.Errorf("pause for request ID:%v has no end", self.RequestID)
+	}
+	if !self.Until.After(self.From) {
+		return errors.Errorf("pause for request ID:%v ends before it starts", self.RequestID)
+	}
+	return nil
+}
+
+func (self Pause) active(now time.Time) bool {
+	return !now.Before(self.From) && now.Before(self.Until)
+}
+
+// Requests applies the per request ID exclusions, overrides and pauses.
+// The pauses can be changed at runtime and are safe for concurrent use.
+type Requests struct {
+	logger    log.Logger
+	excluded  map[int64]bool
+	overrides map[int64]Override
+	registry  *registry.Registry
+
+	mtx    sync.Mutex
+	pauses []Pause
+}
+
+func NewRequests(logger log.Logger, cfg Config, reg *registry.Registry) (*Requests, error) {
+	self := &Requests{
+		logger:    log.With(logger, "component", ComponentName),
+		registry:  reg,
+		excluded:  make(map[int64]bool),
+		overrides: make(map[int64]Override),
+	}
+	for _, id := range cfg.ExcludeRequestIDs {
+		self.excluded[id] = true
+	}
+	for _, o := range cfg.Overrides {
+		if o.Expiry.IsZero() {
+			return nil, errors.Errorf("override for request ID:%v has no expiry", o.RequestID)
+		}
+		if self.excluded[o.RequestID] {
+			return nil, errors.Errorf("request ID:%v is both excluded and overridden", o.RequestID)
+		}
+		if _, ok := self.overrides[o.RequestID]; ok {
+			return nil, errors.Errorf("duplicate override for request ID:%v", o.RequestID)
+		}
+		if _, err := self.registry.Encode(registry.OracleTellor, o.RequestID, o.Value); err != nil {
+			return nil, errors.Wrap(err, "override")
+		}
+		self.overrides[o.RequestID] = o
+	}
+	for _, p := range cfg.Pauses {
+		if err := p.validate(); err != nil {
+			return nil, err
+		}
+		self.pauses = append(self.pauses, p)
+	}
+	return self, nil
+}
+
+// Excluded returns the first excluded request ID of a challenge.
+// A challenge needs values for all its IDs so it can't be submitted when any of them is excluded.
+func (self *Requests) Excluded(requestIDs [5]*big.Int) (int64, bool) {
+	for _, id := range requestIDs {
+		if id != nil && self.excluded[id.Int64()] {
+			return id.Int64(), true
+		}
+	}
+	return 0, false
+}
+
+// Paused returns the first active pause of a challenge.
+func (self *Requests) Paused(requestIDs [5]*big.Int, now time.Time) (Pause, bool) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	for _, p := range self.pauses {
+		if !p.active(now) {
+			continue
+		}
+		if p.RequestID == 0 {
+			return p, true
+		}
+		for _, id := range requestIDs {
+			if id != nil && id.Int64() == p.RequestID {
+				return p, true
+			}
+		}
+	}
+	return Pause{}, false
+}
+
+// Pause adds a pause and removes the ended ones.
+func (self *Requests) Pause(p Pause, now time.Time) error {
+	if err := p.validate(); err != nil {
+		return err
+	}
+	if !p.Until.After(now) {
+		return errors.Errorf("pause for request ID:%v has already ended", p.RequestID)
+	}
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	self.pauses = append(self.current(now), p)
+	return nil
+}
+
+// Resume removes the pauses of a request ID, 0 for the global ones,
+// and returns how many were removed.
+func (self *Requests) Resume(requestID int64, now time.Time) int {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	var (
+		kept    []Pause
+		removed int
+	)
+	for _, p := range self.current(now) {
+		if p.RequestID == requestID {
+			removed++
+			continue
+		}
+		kept = append(kept, p)
+	}
+	self.pauses = kept
+	return removed
+}
+
+// current returns the pauses that haven't ended.
+func (self *Requests) current(now time.Time) []Pause {
+	var current []Pause
+	for _, p := range self.pauses {
+		if now.Before(p.Until) {
+			current = append(current, p)
+		}
+	}
+	return current
+}
+
+// Override returns the encoded override value for a request ID when one is active.
+func (self *Requests) Override(requestID int64, now time.Time) (*big.Int, bool) {
+	o, ok := self.overrides[requestID]
+	if !ok || !now.Before(o.Expiry) {
+		return nil, false
+	}
+	// The values are validated when creating the overrides.
+	val, _ := self.registry.Encode(registry.OracleTellor, requestID, o.Value)
+	return val, true
+}
+
+// OverrideStatus is an override and whether it is still used.
+type OverrideStatus struct {
+	Override
+	Active bool
+}
+
+// PauseStatus is a pause and whether it is in effect.
+type PauseStatus struct {
+	Pause
+	Active bool
+}
+
+// RequestsStatus are the exclusions, overrides and pauses in effect.
+type RequestsStatus struct {
+	Excluded  []int64
+	Overrides []OverrideStatus
+	// Pauses are the current and the scheduled pauses.
+	Pauses []PauseStatus
+}
+
+func (self *Requests) Status(now time.Time) RequestsStatus {
+	status := RequestsStatus{
+		Excluded:  []int64{},
+		Overrides: []OverrideStatus{},
+		Pauses:    []PauseStatus{},
+	}
+	for id := range self.excluded {
+		status.Excluded = append(status.Excluded, id)
+	}
+	sort.Slice(status.Excluded, func(i, j int) bool { return status.Excluded[i] < status.Excluded[j] })
+	for _, o := range self.overrides {
+		status.Overrides = append(status.Overrides, OverrideStatus{Override: o, Active: now.Before(o.Expiry)})
+	}
+	sort.Slice(status.Overrides, func(i, j int) bool { return status.Overrides[i].RequestID < status.Overrides[j].RequestID })
+	self.mtx.Lock()
+	for _, p := range self.current(now) {
+		status.Pauses = append(status.Pauses, PauseStatus{Pause: p, Active: p.active(now)})
+	}
+	self.mtx.Unlock()
+	sort.Slice(status.Pauses, func(i, j int) bool { return status.Pauses[i].From.Before(status.Pauses[j].From) })
+	return status
+}
+
+func (self *Requests) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := web.WriteJSON(w, http.StatusOK, self.Status(time.Now()), nil); err != nil {
+		level.Error(self.logger).Log("msg", "encoding requests response", "err", err)
+	}
+}
+
+// ServePause pauses the submissions of a request ID, 0 or none for all,
+// for a duration from now or from the optional RFC3339 from time.
+// For example: curl -X POST 'localhost:9090/api/v1/submitter/pause?id=1&for=2h&reason=exchange+outage'.
+func (self *Requests) ServePause(w http.ResponseWriter, r *http.Request) {
+	code, err := func() (int, error) {
+		q := r.URL.Query()
+		now := time.Now()
+		p := Pause{From: now, Reason: q.Get("reason"), By: web.Actor(r)}
+		if id := q.Get("id"); id != "" {
+			var err error
+			if p.RequestID, err = strconv.ParseInt(id, 10, 64); err != nil {
+				return http.StatusBadRequest, errors.Wrap(err, "parsing the id")
+			}
+		}
+		if from := q.Get("from"); from != "" {
+			var err error
+			if p.From, err = time.Parse(time.RFC3339, from); err != nil {
+				return http.StatusBadRequest, errors.Wrap(err, "parsing the from time")
+			}
+		}
+		d, err := time.ParseDuration(q.Get("for"))
+		if err != nil {
+			return http.StatusBadRequest, errors.Wrap(err, "parsing the duration")
+		}
+		p.Until = p.From.Add(d)
+		if err := self.Pause(p, now); err != nil {
+			return http.StatusBadRequest, err
+		}
+		level.Info(self.logger).Log("msg", "submissions paused", "requestID", p.RequestID, "from", p.From, "until", p.Until, "reason", p.Reason, "by", p.By)
+		return http.StatusOK, nil
+	}()
+	if err := web.WriteJSON(w, code, self.Status(time.Now()), err); err != nil {
+		level.Error(self.logger).Log("msg", "encoding pause response", "err", err)
+	}
+}
+
+// ServeResume removes the pauses of a request ID, 0 or none for the global ones.
+// For example: curl -X POST 'localhost:9090/api/v1/submitter/resume?id=1'.
+func (self *Requests) ServeResume(w http.ResponseWriter, r *http.Request) {
+	code, err := func() (int, error) {
+		var id int64
+		if _id := r.URL.Query().Get("id"); _id != "" {
+			var err error
+			if id, err = strconv.ParseInt(_id, 10, 64); err != nil {
+				return http.StatusBadRequest, errors.Wrap(err, "parsing the id")
+			}
+		}
+		removed := self.Resume(id, time.Now())
+		if removed == 0 {
+			return http.StatusNotFound, errors.Errorf("no pauses for request ID:%v", id)
+		}
+		level.Info(self.logger).Log("msg", "submissions resumed", "requestID", id, "pauses", removed, "by", web.Actor(r))
+		return http.StatusOK, nil
+	}()
+	if err := web.WriteJSON(w, code, self.Status(time.Now()), err); err != nil {
+		level.Error(self.logger).Log("msg", "encoding resume response", "err", err)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package tellor
+
+import (
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/tellor-io/telliot/pkg/registry"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+func TestRequests(t *testing.T) {
+	now := time.Now()
+	requests, err := NewRequests(log.NewNopLogger(), Config{
+		ExcludeRequestIDs: []int64{3},
+		Overrides: []Override{
+			{RequestID: 1, Value: 2.5, Expiry: now.Add(time.Hour)},
+			{RequestID: 2, Value: 10, Expiry: now.Add(-time.Hour)},
+		},
+	}, registry.Default())
+	testutil.Ok(t, err)
+
+	ids := [5]*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3), big.NewInt(4), big.NewInt(5)}
+	id, ok := requests.Excluded(ids)
+	testutil.Assert(t, ok, "challenge with an excluded ID not excluded")
+	testutil.Equals(t, int64(3), id)
+	ids[2] = big.NewInt(6)
+	_, ok = requests.Excluded(ids)
+	testutil.Assert(t, !ok, "challenge without excluded IDs excluded")
+
+	val, ok := requests.Override(1, now)
+	testutil.Assert(t, ok, "active override not used")
+	testutil.Equals(t, big.NewInt(2500000), val)
+	_, ok = requests.Override(2, now)
+	testutil.Assert(t, !ok, "expired override used")
+
+	status := requests.Status(now)
+	testutil.Equals(t, []int64{3}, status.Excluded)
+	testutil.Equals(t, 2, len(status.Overrides))
+	testutil.Assert(t, status.Overrides[0].Active && !status.Overrides[1].Active, "wrong override status")
+
+	_, err = NewRequests(log.NewNopLogger(), Config{Overrides: []Override{{RequestID: 1, Value: 1}}}, registry.Default())
+	testutil.NotOk(t, err)
+}
+
+func TestPauses(t *testing.T) {
+	now := time.Now()
+	requests, err := NewRequests(log.NewNopLogger(), Config{
+		Pauses: []Pause{{RequestID: 2, From: now.Add(time.Hour), Until: now.Add(2 * time.Hour), Reason: "maintenance"}},
+	}, registry.Default())
+	testutil.Ok(t, err)
+
+	ids := [5]*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3), big.NewInt(4), big.NewInt(5)}
+	_, ok := requests.Paused(ids, now)
+	testutil.Assert(t, !ok, "paused before the scheduled window")
+	p, ok := requests.Paused(ids, now.Add(90*time.Minute))
+	testutil.Assert(t, ok, "not paused during the scheduled window")
+	testutil.Equals(t, "maintenance", p.Reason)
+	_, ok = requests.Paused(ids, now.Add(3*time.Hour))
+	testutil.Assert(t, !ok, "paused after the scheduled window")
+
+	rec := httptest.NewRecorder()
+	requests.ServePause(rec, httptest.NewRequest(http.MethodPost, "/api/v1/submitter/pause?for=1h&reason=outage", nil))
+	testutil.Equals(t, http.StatusOK, rec.Code)
+	p, ok = requests.Paused(ids, now.Add(time.Minute))
+	testutil.Assert(t, ok, "not paused globally")
+	testutil.Equals(t, int64(0), p.RequestID)
+	testutil.Assert(t, p.By != "", "pause without the actor")
+	testutil.Equals(t, 2, len(requests.Status(now).Pauses))
+
+	rec = httptest.NewRecorder()
+	requests.ServeResume(rec, httptest.NewRequest(http.MethodPost, "/api/v1/submitter/resume", nil))
+	testutil.Equals(t, http.StatusOK, rec.Code)
+	_, ok = requests.Paused(ids, now.Add(time.Minute))
+	testutil.Assert(t, !ok, "paused after resuming")
+	testutil.Equals(t, 0, requests.Resume(0, now))
+
+	testutil.NotOk(t, requests.Pause(Pause{RequestID: 1, From: now, Until: now.Add(-time.Minute)}, now))
+	_, err = NewRequests(log.NewNopLogger(), Config{Pauses: []Pause{{RequestID: 1, From: now}}}, registry.Default())
+	testutil.NotOk(t, err)
+}
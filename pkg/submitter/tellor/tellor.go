@@ -8,9 +8,12 @@ import (
 	"encoding/hex"
 	"fmt"
 	"math/big"
+	"strings"
 	"time"
 
+	eth "github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
@@ -18,14 +21,27 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/db"
 	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/evidence"
 	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/health"
 	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/mempool"
 	"github.com/tellor-io/telliot/pkg/mining"
-	psr "github.com/tellor-io/telliot/pkg/psr/tellor"
+	"github.com/tellor-io/telliot/pkg/notify"
+	"github.com/tellor-io/telliot/pkg/plugin"
+	"github.com/tellor-io/telliot/pkg/psr"
 	"github.com/tellor-io/telliot/pkg/reward"
+	"github.com/tellor-io/telliot/pkg/submitter"
+	"github.com/tellor-io/telliot/pkg/tracing"
+	"github.com/tellor-io/telliot/pkg/tracker/clock"
 	"github.com/tellor-io/telliot/pkg/tracker/gasPrice"
+	"github.com/tellor-io/telliot/pkg/tracker/race"
+	"github.com/tellor-io/telliot/pkg/tracker/slot"
 	"github.com/tellor-io/telliot/pkg/transactor"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const ComponentName = "submitterTellor"
@@ -39,7 +55,42 @@ type Config struct {
 	// the gas cost is lowered.
 	// a ProfitThreshold of 199% or less will submit
 	ProfitThreshold uint64
+	// MinSubmitPeriod is the time the contract requires between the submissions of an account.
 	MinSubmitPeriod format.Duration
+	// SubmitMargin delays the submissions after the submit window opens in the contract
+	// in case the local clock is ahead of the block timestamps.
+	SubmitMargin format.Duration
+	// Timing is the strategy for when to broadcast within the TimingWindow
+	// which starts when the submitter is allowed to submit.
+	// One of immediate, jitter, lastN, gasReactive, plugin.
+	Timing       string
+	TimingWindow format.Duration
+	// TimingLastN is used by the lastN strategy.
+	TimingLastN format.Duration
+	// TimingGasPrice in gwei is used by the gasReactive strategy.
+	TimingGasPrice uint
+	// TimingPlugin is the submit strategy used by the plugin strategy.
+	TimingPlugin plugin.Ref
+	// MinSlotProbability delays the broadcast while the estimated chance
+	// of landing one of the remaining slots is lower.
+	MinSlotProbability float64
+	// ExcludeRequestIDs are the IDs with untrusted sources.
+	// Challenges that include any of them are not submitted.
+	ExcludeRequestIDs []int64
+	// Overrides are manual values submitted instead of the PSR values until they expire.
+	Overrides []Override
+	// Pauses are the scheduled windows without submissions e.g. for maintenance.
+	// More can be added at runtime through the API.
+	Pauses []Pause
+	// Guard refuses to submit values that deviate too much
+	// from the recent on-chain values or from a reference.
+	// The override values are not checked.
+	Guard submitter.GuardConfig
+	// DryRun only simulates the submissions without signing or sending them.
+	DryRun bool
+	// ReplayLog is the file with the inputs and the outcome of every submission decision
+	// which can be replayed with the replay command. Disabled when empty.
+	ReplayLog string
 }
 
 /**
@@ -57,14 +108,27 @@ type Submitter struct {
 	client           contracts.ETHClient
 	contractInstance *contracts.ITellor
 	resultCh         chan *mining.Result
-	submitCount      prometheus.Counter
-	submitFailCount  prometheus.Counter
-	submitValue      *prometheus.GaugeVec
 	lastSubmitCncl   context.CancelFunc
-	transactor       transactor.Transactor
+	pipeline         *submitter.Pipeline
 	reward           *reward.Reward
 	gasPriceTracker  *gasPrice.GasTracker
-	psr              *psr.Psr
+	psr              psr.Getter
+	journal          *db.Journal
+	gate             *submitter.Gate
+	notifier         notify.Notifier
+	mempool          *mempool.Watcher
+	maxGasPrice      *big.Int
+	slots            *slot.Tracker
+	clock            *clock.Tracker
+	races            *race.Tracker
+	publisher        *evidence.Publisher
+	timing           Timing
+	requests         *Requests
+	replay           *ReplayLog
+	timingSubmits    prometheus.Counter
+	timingGasCost    prometheus.Counter
+	timingReward     prometheus.Counter
+	lastSubmitted    health.Timestamp
 }
 
 func New(
@@ -76,14 +140,34 @@ func New(
 	account *ethereum.Account,
 	reward *reward.Reward,
 	transactor transactor.Transactor,
+	maxGasPrice *big.Int,
 	gasPriceTracker *gasPrice.GasTracker,
-	psr *psr.Psr,
+	psr psr.Getter,
+	requests *Requests,
+	replay *ReplayLog,
+	journal *db.Journal,
+	gate *submitter.Gate,
+	guard *submitter.Guard,
+	breaker *submitter.Breaker,
+	notifier notify.Notifier,
+	mempool *mempool.Watcher,
+	slots *slot.Tracker,
+	clock *clock.Tracker,
+	races *race.Tracker,
+	publisher *evidence.Publisher,
+	plugins *plugin.Plugins,
 ) (*Submitter, chan *mining.Result, error) {
 	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "apply filter logger")
 	}
 	logger = log.With(logger, "component", ComponentName)
+	timing, err := NewTiming(logger, cfg, gasPriceTracker, plugins)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "creating timing strategy")
+	}
+	timingLabels := prometheus.Labels{"account": account.Address.String(), "strategy": timing.Name()}
+	pipeline := submitter.NewPipeline(logger, ComponentName, client, account.Address, transactor, guard, breaker, cfg.DryRun)
 	ctx, close := context.WithCancel(ctx)
 	submitter := &Submitter{
 		ctx:              ctx,
@@ -95,38 +179,50 @@ func New(
 		reward:           reward,
 		logger:           logger,
 		contractInstance: contractInstance,
-		transactor:       transactor,
+		pipeline:         pipeline,
 		gasPriceTracker:  gasPriceTracker,
 		psr:              psr,
-		submitCount: promauto.NewCounter(prometheus.CounterOpts{
+		journal:          journal,
+		gate:             gate,
+		notifier:         notifier,
+		mempool:          mempool,
+		maxGasPrice:      maxGasPrice,
+		slots:            slots,
+		clock:            clock,
+		races:            races,
+		publisher:        publisher,
+		timing:           timing,
+		requests:         requests,
+		replay:           replay,
+		timingSubmits: promauto.NewCounter(prometheus.CounterOpts{
 			Namespace:   "telliot",
 			Subsystem:   ComponentName,
-			Name:        "submit_total",
-			Help:        "The total number of submitted solutions",
-			ConstLabels: prometheus.Labels{"account": account.Address.String()},
+			Name:        "timing_submit_total",
+			Help:        "The total number of successful submissions per timing strategy",
+			ConstLabels: timingLabels,
 		}),
-		submitFailCount: promauto.NewCounter(prometheus.CounterOpts{
+		timingGasCost: promauto.NewCounter(prometheus.CounterOpts{
 			Namespace:   "telliot",
 			Subsystem:   ComponentName,
-			Name:        "submit_fails_total",
-			Help:        "The total number of failed submission",
-			ConstLabels: prometheus.Labels{"account": account.Address.String()},
+			Name:        "timing_gas_cost_total",
+			Help:        "The total ETH spent on gas by the successful submissions per timing strategy",
+			ConstLabels: timingLabels,
 		}),
-		submitValue: promauto.NewGaugeVec(prometheus.GaugeOpts{
+		timingReward: promauto.NewCounter(prometheus.CounterOpts{
 			Namespace:   "telliot",
 			Subsystem:   ComponentName,
-			Name:        "submit_value",
-			Help:        "The submitted value",
-			ConstLabels: prometheus.Labels{"account": account.Address.String()},
-		},
-			[]string{"id"},
-		),
+			Name:        "timing_reward_total",
+			Help:        "The total reward in ETH of the successful submissions per timing strategy",
+			ConstLabels: timingLabels,
+		}),
 	}
 
 	return submitter, submitter.resultCh, nil
 }
 
 func (self *Submitter) Start() error {
+	self.resumePending()
+
 	for {
 		select {
 		case <-self.ctx.Done():
@@ -143,6 +239,26 @@ func (self *Submitter) Start() error {
 				"difficulty", result.Work.Challenge.Difficulty,
 				"requestIDs", fmt.Sprintf("%+v", result.Work.Challenge.RequestIDs),
 			)
+			var reqIDs []string
+			for _, id := range result.Work.Challenge.RequestIDs {
+				reqIDs = append(reqIDs, id.String())
+			}
+			self.record(db.Submission{
+				ID:         self.submissionID(result),
+				Function:   transactor.FunctionSubmitMiningSolution,
+				State:      db.StateMined,
+				Challenge:  fmt.Sprintf("%x", result.Work.Challenge.Challenge),
+				RequestIDs: reqIDs,
+			})
+			self.notifyEvent(notify.EventSolutionFound, notify.SeverityInfo,
+				"Solution found",
+				fmt.Sprintf("Account %v found a solution for challenge %x", self.account.Address.String(), result.Work.Challenge.Challenge),
+				map[string]string{
+					"challenge":  fmt.Sprintf("%x", result.Work.Challenge.Challenge),
+					"nonce":      result.Nonce,
+					"requestIDs": strings.Join(reqIDs, ","),
+				},
+			)
 			self.Submit(ctx, result)
 		}
 	}
@@ -154,79 +270,83 @@ func (self *Submitter) CancelPendingSubmit() {
 	}
 }
 
+// LastSubmitted returns the time of the last confirmed submission.
+func (self *Submitter) LastSubmitted() time.Time {
+	return self.lastSubmitted.Get()
+}
+
 func (self *Submitter) Stop() {
 	self.close()
 }
 
+// blockUntilTimeToSubmit waits until the submit window of the account is open in the contract.
 func (self *Submitter) blockUntilTimeToSubmit(newChallengeReplace context.Context) {
-	var (
-		lastSubmit time.Duration
-		timestamp  *time.Time
-		err        error
-	)
 	for {
-		select {
-		case <-newChallengeReplace.Done():
-			level.Info(self.logger).Log("msg", "canceled pending submit while gettting last submit time")
-		default:
-		}
-		lastSubmit, timestamp, err = self.lastSubmit()
+		wait := time.Second
+		window, err := self.submitWindow()
 		if err != nil {
-			level.Debug(self.logger).Log("msg", "checking last submit time", "err", err)
-			time.Sleep(1 * time.Second)
-			continue
+			level.Debug(self.logger).Log("msg", "checking the submit window", "err", err)
+		} else {
+			wait = window.Wait(self.cfg.SubmitMargin.Duration)
+			if wait <= 0 {
+				return
+			}
+			level.Info(self.logger).Log("msg", "min transaction submit threshold hasn't passed",
+				"nextSubmit", wait,
+				"lastSubmitTimestamp", window.Last.Format("2006-01-02 15:04:05"),
+				"latestBlockTimestamp", window.Block.Format("2006-01-02 15:04:05"),
+				"minSubmitPeriod", self.cfg.MinSubmitPeriod,
+				"submitMargin", self.cfg.SubmitMargin,
+			)
 		}
-		break
-	}
-	if lastSubmit < self.cfg.MinSubmitPeriod.Duration {
-		level.Info(self.logger).Log("msg", "min transaction submit threshold hasn't passed",
-			"nextSubmit", time.Duration(self.cfg.MinSubmitPeriod.Nanoseconds())-lastSubmit,
-			"lastSubmit", lastSubmit,
-			"lastSubmitTimestamp", timestamp.Format("2006-01-02 15:04:05.000000"),
-			"minSubmitPeriod", self.cfg.MinSubmitPeriod,
-		)
-		timeToSubmit, cncl := context.WithDeadline(newChallengeReplace, timestamp.Add(self.cfg.MinSubmitPeriod.Duration))
-		defer cncl()
+		// Checks the window again after the wait as the contract has the final word.
 		select {
 		case <-newChallengeReplace.Done():
 			level.Info(self.logger).Log("msg", "canceled pending submit while waiting for the time to submit")
-		case <-timeToSubmit.Done(): // 15min since last submit has passed so can unblock.
+			return
+		case <-time.After(wait):
 		}
 	}
 }
 
-func (self *Submitter) canSubmit() error {
+// readyInputs returns the inputs of the checks of the account before the timing strategy.
+func (self *Submitter) readyInputs(result *mining.Result) Inputs {
+	in := Inputs{
+		Time:      self.clock.Now(),
+		Account:   self.account.Address.String(),
+		Challenge: fmt.Sprintf("%x", result.Work.Challenge.Challenge),
+	}
+	for _, id := range result.Work.Challenge.RequestIDs {
+		in.RequestIDs = append(in.RequestIDs, id.Int64())
+	}
+	if err := self.gate.Err(); err != nil {
+		in.Gate = err.Error()
+		return in
+	}
 	if self.cfg.ProfitThreshold > 0 { // Profit check is enabled.
-		profitPercent, err := self.profitPercent()
-		if _, ok := errors.Cause(err).(reward.ErrNoDataForSlot); ok {
-			level.Warn(self.logger).Log("msg", "skipping profit check when the slot has no record for how much gas it uses", "err", err)
-		} else if err != nil {
-			return errors.Wrapf(err, "submit solution profit check")
-		} else if profitPercent < int64(self.cfg.ProfitThreshold) {
-			return errors.Errorf("profit:%v lower then the profit threshold:%v", profitPercent, self.cfg.ProfitThreshold)
-		}
+		in.Profit = self.profitInputs()
 	}
-
 	statusID, err := self.minerStatus()
 	if err != nil {
-		return errors.Wrap(err, "getting miner status")
+		in.MinerStatusErr = err.Error()
 	}
-	if statusID != 1 {
-		return errors.Errorf("miner is not in a status that can submit:%v", minerStatusName(statusID))
-	}
-
-	return nil
+	in.MinerStatus = statusID
+	return in
 }
 
-func (self *Submitter) profitPercent() (int64, error) {
+func (self *Submitter) profitInputs() ProfitInputs {
+	var in ProfitInputs
 	slot, err := self.reward.Slot()
 	if err != nil {
-		return 0, errors.Wrapf(err, "getting current slot")
+		in.Err = errors.Wrapf(err, "getting current slot").Error()
+		return in
 	}
 	gasPrice, err := self.gasPriceTracker.Query(self.ctx)
 	if err != nil {
-		return 0, errors.Wrapf(err, "getting current Gas price")
+		in.Err = errors.Wrapf(err, "getting current Gas price").Error()
+		return in
 	}
+	in.GasPrice = big.NewInt(gasPrice)
 
 	// Need the price for next slot transaction so increment by one.
 	slot.Add(slot, big.NewInt(1))
@@ -236,63 +356,184 @@ func (self *Submitter) profitPercent() (int64, error) {
 	if slot.Int64() == 5 {
 		slot.SetInt64(0)
 	}
+	in.Slot = slot.Int64()
+
+	gasUsed, err := self.reward.GasUsed(slot)
+	if err != nil {
+		level.Warn(self.logger).Log("msg", "skipping profit check when the slot has no record for how much gas it uses", "err", err)
+		return in
+	}
+	in.GasUsed = gasUsed
+	if in.Reward, in.TRBPrice, in.TRBConfidence, err = self.reward.Price(); err != nil {
+		in.Err = errors.Wrap(err, "getting trb current TRB price").Error()
+	}
+	return in
+}
+
+// submitInputs adds the inputs of the checks of the challenge after the timing strategy
+// and returns the values to submit.
+func (self *Submitter) submitInputs(in Inputs, result *mining.Result) (Inputs, [5]*big.Int) {
+	in.Time = self.clock.Now()
+	in.Timing = self.timing.Name()
+	in.Slots = self.slotInputs(result.Work.Challenge)
+	in.Paused = nil
+	if p, ok := self.requests.Paused(result.Work.Challenge.RequestIDs, in.Time); ok {
+		in.Paused = &p
+	}
+
+	in.Values, in.ValuesErr, in.Stale, in.Overrides = nil, "", false, nil
+	reqVals, err := self.requestVals(result.Work.Challenge.RequestIDs, in.Time)
+	if err != nil {
+		in.ValuesErr = err.Error()
+		in.Stale = errors.Is(err, psr.ErrStale)
+		return in, reqVals
+	}
+	for _, id := range result.Work.Challenge.RequestIDs {
+		if _, ok := self.requests.Override(id.Int64(), in.Time); ok {
+			in.Overrides = append(in.Overrides, id.Int64())
+		}
+	}
+	for _, val := range reqVals {
+		in.Values = append(in.Values, val.String())
+	}
+	return in, reqVals
+}
 
-	return self.reward.Current(slot, big.NewInt(int64(gasPrice)))
+// decide records the decision with its inputs in the replay log.
+func (self *Submitter) decide(in Inputs) Decision {
+	cfg := DecisionConfig{ProfitThreshold: self.cfg.ProfitThreshold, MinSlotProbability: self.cfg.MinSlotProbability}
+	d := Decide(cfg, in)
+	if err := self.replay.Record(Record{Inputs: in, Config: cfg, Decision: d}); err != nil {
+		level.Error(self.logger).Log("msg", "recording the decision in the replay log", "err", err)
+	}
+	return d
 }
 
 func (self *Submitter) Submit(newChallengeReplace context.Context, result *mining.Result) {
 	go func(newChallengeReplace context.Context, result *mining.Result) {
 		ticker := time.NewTicker(1 * time.Second)
 		defer ticker.Stop()
+		id := self.submissionID(result)
+
+		// The span context doesn't carry the cancelation of a new challenge
+		// as the transaction should complete once it is sent.
+		ctx, span := tracing.Start(
+			trace.ContextWithSpanContext(self.ctx, result.Work.Trace),
+			"submitter.submit",
+			attribute.String("account", self.account.Address.String()),
+			attribute.String("challenge", fmt.Sprintf("%x", result.Work.Challenge.Challenge)),
+		)
+		defer span.End()
+
 		for {
 			select {
 			case <-newChallengeReplace.Done():
 				level.Info(self.logger).Log("msg", "pending submit canceled")
+				self.record(db.Submission{ID: id, State: db.StateFailed, Err: "canceled"})
+				tracing.Error(span, errors.New("canceled"))
 				return
 			default:
 			}
 
+			_, waitSpan := tracing.Start(ctx, "submitter.waitSubmitPeriod")
 			self.blockUntilTimeToSubmit(newChallengeReplace)
-			if err := self.canSubmit(); err != nil {
-				level.Info(self.logger).Log("msg", "can't submit and will retry later", "reason", err)
+			waitSpan.End()
+			in := self.readyInputs(result)
+			if d := self.decide(in); d.Action == ActionRetry {
+				span.AddEvent("can't submit", trace.WithAttributes(attribute.String("reason", d.Reason)))
+				level.Info(self.logger).Log("msg", "can't submit and will retry later", "reason", d.Reason)
 				<-ticker.C
 				continue
 			}
+
+			_, timingSpan := tracing.Start(ctx, "submitter.timing", attribute.String("strategy", self.timing.Name()))
+			err := self.timing.Wait(newChallengeReplace)
+			timingSpan.End()
+			if err != nil {
+				continue
+			}
+
 			for {
 				select {
 				case <-newChallengeReplace.Done():
 					level.Info(self.logger).Log("msg", "pending submit canceled")
+					self.record(db.Submission{ID: id, State: db.StateFailed, Err: "canceled"})
+					tracing.Error(span, errors.New("canceled"))
 					return
 				default:
 				}
 
-				reqVals, err := self.requestVals(result.Work.Challenge.RequestIDs)
-				if err != nil {
-					level.Error(self.logger).Log("msg", "adding the request ids, retrying", "err", err)
+				_, psrSpan := tracing.Start(ctx, "psr.values")
+				var reqVals [5]*big.Int
+				in, reqVals = self.submitInputs(in, result)
+				if in.ValuesErr != "" {
+					tracing.Error(psrSpan, errors.New(in.ValuesErr))
+				}
+				psrSpan.End()
+				if in.Slots.Err != "" {
+					level.Error(self.logger).Log("msg", "checking the filled slots", "err", in.Slots.Err)
+				}
+
+				d := self.decide(in)
+				if d.Action == ActionSkip {
+					level.Info(self.logger).Log("msg", "skipping the submission", "reason", d.Reason)
+					self.record(db.Submission{ID: id, State: db.StateFailed, Err: d.Reason})
+					span.AddEvent("skipped", trace.WithAttributes(attribute.String("reason", d.Reason)))
+					if in.Stale {
+						self.notifyStale(errors.New(in.ValuesErr))
+					}
+					return
+				}
+				if d.Action == ActionWait {
+					if in.Paused != nil {
+						span.AddEvent("paused", trace.WithAttributes(attribute.Int64("requestID", in.Paused.RequestID)))
+					}
+					level.Info(self.logger).Log("msg", "delaying the submission", "reason", d.Reason)
 					<-ticker.C
 					continue
 				}
+
 				level.Info(self.logger).Log(
 					"msg", "sending solution to the chain",
 					"solutionNonce", result.Nonce,
 					"IDs", fmt.Sprintf("%+v", result.Work.Challenge.RequestIDs),
 					"vals", fmt.Sprintf("%+v", reqVals),
 				)
-				f := func(auth *bind.TransactOpts) (*types.Transaction, error) {
-					return self.contractInstance.SubmitMiningSolution(auth, result.Nonce, result.Work.Challenge.RequestIDs, reqVals)
+				var vals []string
+				for _, val := range reqVals {
+					vals = append(vals, val.String())
 				}
-				tx, recieipt, err := self.transactor.Transact(self.ctx, f)
-				if err != nil {
-					self.submitFailCount.Inc()
-					level.Error(self.logger).Log("msg", "submiting a solution", "err", err)
+				tx, recieipt, err := self.pipeline.Submit(ctx, self.submission(id, result, reqVals, vals))
+				if errors.Is(err, submitter.ErrRejected) {
+					span.AddEvent("guard", trace.WithAttributes(attribute.String("reason", err.Error())))
+					level.Error(self.logger).Log("msg", "value guard refused the values, retrying", "err", err)
+					<-ticker.C
+					continue
+				}
+				if errors.Is(err, submitter.ErrDryRun) {
+					self.record(db.Submission{ID: id, State: db.StateDryRun, Values: vals})
+					span.AddEvent("skipped", trace.WithAttributes(attribute.String("reason", err.Error())))
 					return
 				}
-
-				if recieipt.Status != types.ReceiptStatusSuccessful {
-					self.submitFailCount.Inc()
+				if errors.Is(err, submitter.ErrReverted) {
+					tracing.Error(span, err)
 					level.Error(self.logger).Log("msg", "submiting solution status not success", "status", recieipt.Status, "hash", tx.Hash())
+					self.record(db.Submission{ID: id, State: db.StateFailed, TxHash: tx.Hash().String(), Err: "receipt status not success"})
+					self.notifyEvent(notify.EventSubmissionReverted, notify.SeverityWarning,
+						"Submission reverted",
+						fmt.Sprintf("The submission of account %v was reverted tx:%v", self.account.Address.String(), tx.Hash().String()),
+						map[string]string{"challenge": fmt.Sprintf("%x", result.Work.Challenge.Challenge), "txHash": tx.Hash().String(), "gasUsed": fmt.Sprint(recieipt.GasUsed)},
+					)
+					return
+				}
+				if err != nil {
+					tracing.Error(span, err)
+					level.Error(self.logger).Log("msg", "submiting a solution", "err", err)
+					self.record(db.Submission{ID: id, State: db.StateFailed, Err: err.Error()})
 					return
 				}
+				self.record(db.Submission{ID: id, State: db.StateConfirmed, TxHash: tx.Hash().String(), Nonce: tx.Nonce(), GasUsed: recieipt.GasUsed})
+				self.lastSubmitted.Set(self.clock.Now())
 				level.Info(self.logger).Log("msg", "successfully submited solution",
 					"txHash", tx.Hash().String(),
 					"nonce", tx.Nonce(),
@@ -301,15 +542,21 @@ func (self *Submitter) Submit(newChallengeReplace context.Context, result *minin
 					"gasLimit", tx.Gas(),
 					"data", fmt.Sprintf("%x", tx.Data()),
 				)
-				self.submitCount.Inc()
-
-				for i, id := range result.Work.Challenge.RequestIDs {
-					self.submitValue.With(
-						prometheus.Labels{
-							"id": id.String(),
-						},
-					).(prometheus.Gauge).Set(float64(reqVals[i].Int64()))
+				self.recordTiming(tx, recieipt)
+				if self.publisher != nil {
+					self.publisher.Publish(self.published(tx, result, reqVals, in.Time))
 				}
+				self.notifyEvent(notify.EventSubmissionConfirmed, notify.SeverityInfo,
+					"Submission confirmed",
+					fmt.Sprintf("The submission of account %v was confirmed tx:%v", self.account.Address.String(), tx.Hash().String()),
+					map[string]string{
+						"challenge": fmt.Sprintf("%x", result.Work.Challenge.Challenge),
+						"txHash":    tx.Hash().String(),
+						"values":    strings.Join(vals, ","),
+						"gasUsed":   fmt.Sprint(recieipt.GasUsed),
+						"gasPrice":  tx.GasPrice().String(),
+					},
+				)
 
 				slot, err := self.reward.Slot()
 				if err != nil {
@@ -324,18 +571,241 @@ func (self *Submitter) Submit(newChallengeReplace context.Context, result *minin
 	}(newChallengeReplace, result)
 }
 
-func (self *Submitter) requestVals(requestIDs [5]*big.Int) ([5]*big.Int, error) {
+// submission of the solution with the slot gas price and the journal records.
+// The overridden values are set manually so are not checked by the guard.
+func (self *Submitter) submission(id string, result *mining.Result, reqVals [5]*big.Int, vals []string) submitter.Submission {
+	s := submitter.Submission{
+		Function:  transactor.FunctionSubmitMiningSolution,
+		Unchecked: make(map[int64]bool),
+		Build: func(auth *bind.TransactOpts) (*types.Transaction, error) {
+			gasPrice, err := self.slotGasPrice(result.Work.Challenge, auth.GasPrice)
+			if err != nil {
+				return nil, errors.Wrap(err, "checking pending submissions")
+			}
+			auth.GasPrice = gasPrice
+			return self.contractInstance.SubmitMiningSolution(auth, result.Nonce, result.Work.Challenge.RequestIDs, reqVals)
+		},
+		OnSimulated: func(tx *types.Transaction) {
+			self.record(db.Submission{ID: id, State: db.StateSimulated, Values: vals})
+		},
+		OnBroadcast: func(tx *types.Transaction) {
+			self.record(db.Submission{ID: id, State: db.StateBroadcast, TxHash: tx.Hash().String(), Nonce: tx.Nonce()})
+			var c [32]byte
+			copy(c[:], result.Work.Challenge.Challenge)
+			self.races.Broadcast(self.ctx, c, self.account.Address, tx.GasPrice(), self.clock.Now())
+		},
+	}
+	for i, reqID := range result.Work.Challenge.RequestIDs {
+		s.IDs = append(s.IDs, reqID.Int64())
+		s.Values = append(s.Values, reqVals[i])
+		if _, ok := self.requests.Override(reqID.Int64(), self.clock.Now()); ok {
+			s.Unchecked[reqID.Int64()] = true
+		}
+	}
+	return s
+}
+
+// published is the evidence of a confirmed submission.
+func (self *Submitter) published(tx *types.Transaction, result *mining.Result, reqVals [5]*big.Int, ts time.Time) evidence.Published {
+	p := evidence.Published{
+		Account:   self.account.Address.String(),
+		TxHash:    tx.Hash().String(),
+		Challenge: fmt.Sprintf("%x", result.Work.Challenge.Challenge),
+		Timestamp: ts,
+		Overrides: make(map[int64]bool),
+	}
+	for i, reqID := range result.Work.Challenge.RequestIDs {
+		p.RequestIDs = append(p.RequestIDs, reqID.Int64())
+		p.Values = append(p.Values, reqVals[i])
+		if _, ok := self.requests.Override(reqID.Int64(), ts); ok {
+			p.Overrides[reqID.Int64()] = true
+		}
+	}
+	return p
+}
+
+// recordTiming adds the gas cost and the reward of a successful submission
+// so that the timing strategies can be compared.
+func (self *Submitter) recordTiming(tx *types.Transaction, receipt *types.Receipt) {
+	cost, _ := big.NewFloat(0).Mul(new(big.Float).SetInt(tx.GasPrice()), big.NewFloat(float64(receipt.GasUsed))).Float64()
+	self.timingSubmits.Inc()
+	self.timingGasCost.Add(cost / 1e18)
+	reward, err := self.reward.InEth1e18()
+	if err != nil {
+		level.Error(self.logger).Log("msg", "getting the reward for the timing metrics", "err", err)
+		return
+	}
+	rewardF, _ := new(big.Float).SetInt(reward).Float64()
+	self.timingReward.Add(rewardF / 1e18)
+}
+
+// slotGasPrice returns the gas price needed to outbid the pending submissions
+// that compete for the remaining slots of the challenge.
+// It is never above the max gas price of the transactor.
+func (self *Submitter) slotGasPrice(challenge *mining.MiningChallenge, gasPrice *big.Int) (*big.Int, error) {
+	if self.mempool == nil {
+		return gasPrice, nil
+	}
+	filled, err := self.filledSlots(challenge)
+	if err != nil {
+		return nil, err
+	}
+	return self.mempool.GasPrice(challenge.RequestIDs, self.account.Address, filled, gasPrice, self.maxGasPrice)
+}
+
+// slotInputs returns the filled slots of the challenge and the pending submissions
+// paying at least the current gas price when the mempool watcher is enabled.
+func (self *Submitter) slotInputs(challenge *mining.MiningChallenge) SlotInputs {
+	in := SlotInputs{Competing: -1}
+	filled, err := self.filledSlots(challenge)
+	if err != nil {
+		in.Err = err.Error()
+		return in
+	}
+	in.Filled = filled
+	if self.mempool == nil {
+		return in
+	}
+
+	gasPrice, err := self.gasPriceTracker.Query(self.ctx)
+	if err != nil {
+		in.Err = errors.Wrap(err, "getting current gas price").Error()
+		return in
+	}
+	in.Competing = 0
+	for _, s := range self.mempool.Competing(challenge.RequestIDs, self.account.Address) {
+		if s.GasPrice.Cmp(big.NewInt(gasPrice)) >= 0 {
+			in.Competing++
+		}
+	}
+	return in
+}
+
+// filledSlots returns the number of filled slots for the challenge.
+// It falls back to the slot progress in the contract when
+// the slot tracker hasn't seen any submissions for the challenge.
+func (self *Submitter) filledSlots(challenge *mining.MiningChallenge) (int, error) {
+	if self.slots != nil {
+		var c [32]byte
+		copy(c[:], challenge.Challenge)
+		if filled, ok := self.slots.Filled(c); ok {
+			return filled, nil
+		}
+	}
+	progress, err := self.reward.Slot()
+	if err != nil {
+		return 0, errors.Wrap(err, "getting current slot")
+	}
+	return int(progress.Int64()), nil
+}
+
+func (self *Submitter) submissionID(result *mining.Result) string {
+	return fmt.Sprintf("%x", result.Work.Challenge.Challenge) + ":" + self.account.Address.String()
+}
+
+func (self *Submitter) record(s db.Submission) {
+	s.Account = self.account.Address.String()
+	if err := self.journal.Record(s); err != nil {
+		level.Error(self.logger).Log("msg", "recording submission state", "id", s.ID, "state", s.State, "err", err)
+	}
+}
+
+// resumePending continues monitoring the transactions that were
+// in-flight when the previous run was stopped.
+// Submissions that weren't broadcasted can't be resumed as the challenge has most likely changed.
+func (self *Submitter) resumePending() {
+	for _, s := range self.journal.Pending(self.account.Address.String(), transactor.FunctionSubmitMiningSolution) {
+		if s.State != db.StateBroadcast {
+			level.Info(self.logger).Log("msg", "abandoning submission interrupted by a restart", "id", s.ID, "state", s.State)
+			self.record(db.Submission{ID: s.ID, State: db.StateFailed, Err: "interrupted by a restart"})
+			continue
+		}
+		level.Info(self.logger).Log("msg", "resuming monitoring of in-flight transaction", "id", s.ID, "txHash", s.TxHash)
+		go self.waitReceipt(s)
+	}
+}
+
+func (self *Submitter) waitReceipt(s db.Submission) {
+	logger := log.With(self.logger, "id", s.ID, "txHash", s.TxHash)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		receipt, err := self.client.TransactionReceipt(self.ctx, common.HexToHash(s.TxHash))
+		if err == nil {
+			if receipt.Status != types.ReceiptStatusSuccessful {
+				level.Error(logger).Log("msg", "resumed transaction status not success", "status", receipt.Status)
+				self.record(db.Submission{ID: s.ID, State: db.StateFailed, Err: "receipt status not success"})
+				return
+			}
+			level.Info(logger).Log("msg", "resumed transaction confirmed", "gasUsed", receipt.GasUsed)
+			self.record(db.Submission{ID: s.ID, State: db.StateConfirmed, GasUsed: receipt.GasUsed})
+			return
+		}
+		if err != eth.NotFound {
+			level.Debug(logger).Log("msg", "receipt retrieval", "err", err)
+		}
+		// The transaction was dropped or replaced when it is not found and the nonce was already used.
+		nonce, err := self.client.NonceAt(self.ctx, self.account.Address)
+		if err == nil && nonce > s.Nonce {
+			if _, _, err := self.client.TransactionByHash(self.ctx, common.HexToHash(s.TxHash)); err == eth.NotFound {
+				level.Warn(logger).Log("msg", "resumed transaction was dropped or replaced")
+				self.record(db.Submission{ID: s.ID, State: db.StateFailed, Err: "dropped or replaced"})
+				return
+			}
+		}
+		select {
+		case <-self.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (self *Submitter) requestVals(requestIDs [5]*big.Int, ts time.Time) ([5]*big.Int, error) {
 	var currentValues [5]*big.Int
 	for i, reqID := range requestIDs {
-		val, err := self.psr.GetValue(reqID.Int64(), time.Now())
+		if val, ok := self.requests.Override(reqID.Int64(), self.clock.Now()); ok {
+			level.Info(self.logger).Log("msg", "using override value", "requestID", reqID, "value", val)
+			currentValues[i] = val
+			continue
+		}
+		val, err := self.psr.GetValue(reqID.Int64(), ts)
 		if err != nil {
 			return currentValues, errors.Wrapf(err, "getting value for request ID:%v", reqID)
 		}
-		currentValues[i] = big.NewInt(int64(val))
+		currentValues[i] = val
 	}
 	return currentValues, nil
 }
 
+// notifyEvent sends a lifecycle event in the background
+// so that a slow notification backend doesn't delay the submission.
+func (self *Submitter) notifyEvent(event string, severity notify.Severity, title, body string, data map[string]string) {
+	data["account"] = self.account.Address.String()
+	go func() {
+		if err := self.notifier.Notify(self.ctx, notify.Message{
+			Event:    event,
+			Severity: severity,
+			Title:    title,
+			Body:     body,
+			Data:     data,
+		}); err != nil {
+			level.Error(self.logger).Log("msg", "sending notification", "event", event, "err", err)
+		}
+	}()
+}
+
+func (self *Submitter) notifyStale(err error) {
+	if err := self.notifier.Notify(self.ctx, notify.Message{
+		Event:    "staleValues",
+		Severity: notify.SeverityCritical,
+		Title:    "Submission skipped because of stale values",
+		Body:     fmt.Sprintf("Account %v skipped a submission: %v", self.account.Address.String(), err),
+	}); err != nil {
+		level.Error(self.logger).Log("msg", "sending notification", "err", err)
+	}
+}
+
 func (self *Submitter) minerStatus() (int64, error) {
 	// Check if the staked account is in dispute before sending a transaction.
 	statusID, _, err := self.contractInstance.GetStakerInfo(&bind.CallOpts{}, self.account.Address)
@@ -345,50 +815,57 @@ func (self *Submitter) minerStatus() (int64, error) {
 	return statusID.Int64(), nil
 }
 
-func (self *Submitter) lastSubmit() (time.Duration, *time.Time, error) {
-	address := "000000000000000000000000" + self.account.Address.Hex()[2:]
-	decoded, err := hex.DecodeString(address)
-	if err != nil {
-		return 0, nil, errors.Wrapf(err, "decoding address")
-	}
-	last, err := self.contractInstance.GetUintVar(nil, ethereum.Keccak256(decoded))
+// SubmitWindow is the contract's view of when an account can submit again.
+type SubmitWindow struct {
+	// Last is the block timestamp of the last submission of the account.
+	Last time.Time
+	// Opens is the first block timestamp that the contract accepts the next submission at.
+	Opens time.Time
+	// Block is the timestamp of the latest block.
+	Block time.Time
+	// Now is the local time corrected by the clock tracker.
+	Now time.Time
+}
 
-	if err != nil {
-		return 0, nil, errors.Wrapf(err, "getting last submit time for:%v", self.account.Address.String())
-	}
-	// The Miner has never submitted so put a timestamp at the beginning of unix time.
-	if last.Int64() == 0 {
-		last.Set(big.NewInt(1))
+// NewSubmitWindow returns the window of an account with the last submit timestamp in the contract.
+// The contract requires more than the min submit period between the block timestamps
+// which are in whole seconds so the window opens a second after the period.
+func NewSubmitWindow(last time.Time, minSubmitPeriod time.Duration, block, now time.Time) SubmitWindow {
+	return SubmitWindow{
+		Last:  last,
+		Opens: last.Add(minSubmitPeriod).Truncate(time.Second).Add(time.Second),
+		Block: block,
+		Now:   now,
 	}
+}
 
-	lastInt := last.Int64()
-	now := time.Now()
-	var lastSubmit time.Duration
-	var tm time.Time
-	if lastInt > 0 {
-		tm = time.Unix(lastInt, 0)
-		lastSubmit = now.Sub(tm)
+// Wait returns how long to wait until a submission lands in a block at least the margin after the window opens.
+// The next block has a timestamp after the latest block so the chain time is at least that
+// even when the local clock is behind.
+func (self SubmitWindow) Wait(margin time.Duration) time.Duration {
+	now := self.Now
+	if next := self.Block.Add(time.Second); next.After(now) {
+		now = next
 	}
-
-	return lastSubmit, &tm, nil
+	return self.Opens.Add(margin).Sub(now)
 }
 
-func minerStatusName(statusID int64) string {
-	// From https://github.com/tellor-io/tellor3/blob/7c2f38a0e3f96631fb0f96e0d0a9f73e7b355766/contracts/TellorStorage.sol#L41
-	switch statusID {
-	case 0:
-		return "Not staked"
-	case 1:
-		return "Staked"
-	case 2:
-		return "LockedForWithdraw"
-	case 3:
-		return "OnDispute"
-	case 4:
-		return "ReadyForUnlocking"
-	case 5:
-		return "Unlocked"
-	default:
-		return "Unknown"
+// submitWindow reads the last submit timestamp of the account from the contract
+// rather than trusting the local bookkeeping.
+func (self *Submitter) submitWindow() (SubmitWindow, error) {
+	address := "000000000000000000000000" + self.account.Address.Hex()[2:]
+	decoded, err := hex.DecodeString(address)
+	if err != nil {
+		return SubmitWindow{}, errors.Wrapf(err, "decoding address")
+	}
+	last, err := self.contractInstance.GetUintVar(&bind.CallOpts{Context: self.ctx}, ethereum.Keccak256(decoded))
+	if err != nil {
+		return SubmitWindow{}, errors.Wrapf(err, "getting last submit time for:%v", self.account.Address.String())
+	}
+	header, err := self.client.HeaderByNumber(self.ctx, nil)
+	if err != nil {
+		return SubmitWindow{}, errors.Wrap(err, "getting the latest block")
 	}
+	// The Miner has never submitted so the window opened at the beginning of unix time.
+	return NewSubmitWindow(time.Unix(last.Int64(), 0), self.cfg.MinSubmitPeriod.Duration, time.Unix(int64(header.Time), 0), self.clock.Now()), nil
 }
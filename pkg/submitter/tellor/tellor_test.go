@@ -0,0 +1,38 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package tellor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+func TestSubmitWindow(t *testing.T) {
+	last := time.Unix(1600000000, 0)
+	period := 15 * time.Minute
+	cases := []struct {
+		name   string
+		last   time.Time
+		block  time.Time
+		now    time.Time
+		margin time.Duration
+		wait   time.Duration
+	}{
+		{name: "before the period", block: last.Add(10 * time.Minute), now: last.Add(10 * time.Minute), wait: 5 * time.Minute},
+		{name: "at the period", block: last.Add(period - 10*time.Second), now: last.Add(period), wait: time.Second},
+		{name: "after the period", block: last.Add(period), now: last.Add(period + time.Second), wait: 0},
+		{name: "with a margin", block: last.Add(period), now: last.Add(period + time.Second), margin: 2 * time.Second, wait: 2 * time.Second},
+		{name: "local clock behind the chain", block: last.Add(period), now: last.Add(period - time.Minute), wait: 0},
+		{name: "never submitted", last: time.Unix(0, 0), block: last, now: last, wait: time.Unix(0, 0).Add(period).Sub(last)},
+	}
+	for _, c := range cases {
+		if c.last.IsZero() {
+			c.last = last
+		}
+		w := NewSubmitWindow(c.last, period, c.block, c.now)
+		testutil.Equals(t, c.wait, w.Wait(c.margin), c.name)
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package tellor
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/plugin"
+	"github.com/tellor-io/telliot/pkg/tracker/gasPrice"
+)
+
+// Timing strategies.
+const (
+	TimingImmediate   = "immediate"
+	TimingJitter      = "jitter"
+	TimingLastN       = "lastN"
+	TimingGasReactive = "gasReactive"
+	// TimingPlugin is the submit strategy of a plugin set in TimingPlugin.
+	TimingPlugin = "plugin"
+)
+
+// gasReactivePollInterval is how often the gas reactive strategy checks the gas price.
+const gasReactivePollInterval = 5 * time.Second
+
+// Timing decides when to broadcast a solution within the eligible window.
+// The window starts when the submitter is allowed to submit.
+type Timing interface {
+	Name() string
+	// Wait blocks until it is time to broadcast or the context is canceled.
+	Wait(ctx context.Context) error
+}
+
+func NewTiming(logger log.Logger, cfg Config, gasPriceTracker *gasPrice.GasTracker, plugins *plugin.Plugins) (Timing, error) {
+	window := cfg.TimingWindow.Duration
+	switch cfg.Timing {
+	case "", TimingImmediate:
+		return &immediate{}, nil
+	case TimingJitter:
+		return &jitter{window: window}, nil
+	case TimingLastN:
+		if cfg.TimingLastN.Duration > window {
+			return nil, errors.Errorf("TimingLastN:%v is longer than the TimingWindow:%v", cfg.TimingLastN, cfg.TimingWindow)
+		}
+		return &lastN{window: window, lastN: cfg.TimingLastN.Duration}, nil
+	case TimingGasReactive:
+		if cfg.TimingGasPrice == 0 {
+			return nil, errors.New("TimingGasPrice is required for the gas reactive timing")
+		}
+		return &gasReactive{
+			logger:          logger,
+			window:          window,
+			maxGasPrice:     int64(cfg.TimingGasPrice) * 1e9,
+			gasPriceTracker: gasPriceTracker,
+		}, nil
+	case TimingPlugin:
+		return plugins.SubmitStrategy(cfg.TimingPlugin)
+	default:
+		return nil, errors.Errorf("unknown timing strategy:%v", cfg.Timing)
+	}
+}
+
+// immediate broadcasts as soon as possible.
+type immediate struct{}
+
+func (self *immediate) Name() string { return TimingImmediate }
+
+func (self *immediate) Wait(context.Context) error { return nil }
+
+// jitter broadcasts at a random time within the window.
+type jitter struct {
+	window time.Duration
+}
+
+func (self *jitter) Name() string { return TimingJitter }
+
+func (self *jitter) Wait(ctx context.Context) error {
+	if self.window <= 0 {
+		return nil
+	}
+	return sleep(ctx, time.Duration(rand.Int63n(int64(self.window))))
+}
+
+// lastN broadcasts in the last N seconds of the window.
+type lastN struct {
+	window time.Duration
+	lastN  time.Duration
+}
+
+func (self *lastN) Name() string { return TimingLastN }
+
+func (self *lastN) Wait(ctx context.Context) error {
+	return sleep(ctx, self.window-self.lastN)
+}
+
+// gasReactive broadcasts as soon as the gas price drops to the max gas price
+// or at the end of the window.
+type gasReactive struct {
+	logger          log.Logger
+	window          time.Duration
+	maxGasPrice     int64
+	gasPriceTracker *gasPrice.GasTracker
+}
+
+func (self *gasReactive) Name() string { return TimingGasReactive }
+
+func (self *gasReactive) Wait(ctx context.Context) error {
+	deadline := time.Now().Add(self.window)
+	for {
+		price, err := self.gasPriceTracker.Query(ctx)
+		if err != nil {
+			level.Error(self.logger).Log("msg", "getting gas price for the timing strategy", "err", err)
+		} else if price <= self.maxGasPrice {
+			return nil
+		}
+		left := time.Until(deadline)
+		if left <= 0 {
+			level.Info(self.logger).Log("msg", "gas price didn't drop within the timing window", "gasPrice", price, "max", self.maxGasPrice)
+			return nil
+		}
+		if left > gasReactivePollInterval {
+			left = gasReactivePollInterval
+		}
+		if err := sleep(ctx, left); err != nil {
+			return err
+		}
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -9,9 +9,12 @@ import (
 	"math"
 	"math/big"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
@@ -19,9 +22,13 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/db"
 	"github.com/tellor-io/telliot/pkg/ethereum"
 	"github.com/tellor-io/telliot/pkg/logging"
-	psr "github.com/tellor-io/telliot/pkg/psr/tellorAccess"
+	"github.com/tellor-io/telliot/pkg/psr"
+	"github.com/tellor-io/telliot/pkg/submitter"
+	"github.com/tellor-io/telliot/pkg/tracker/clock"
+	"github.com/tellor-io/telliot/pkg/tracker/gasPrice"
 	"github.com/tellor-io/telliot/pkg/transactor"
 )
 
@@ -33,6 +40,19 @@ const (
 type Config struct {
 	Enabled  bool
 	LogLevel string
+	// Minimum percent of profit when submitting a value.
+	// The contract doesn't pay for the submissions so the profit is
+	// from the Reward paid to the reporter by other means.
+	ProfitThreshold uint64
+	// Reward in ETH for each submitted value used by the profit check.
+	Reward float64
+	// Guard refuses to submit values that deviate too much
+	// from the recent on-chain values or from a reference.
+	Guard submitter.GuardConfig
+	// DryRun only simulates the submissions without signing or sending them.
+	DryRun bool
+	// Multicall submits the values of all due request IDs in one transaction.
+	Multicall submitter.MulticallConfig
 }
 
 /**
@@ -49,14 +69,19 @@ type Submitter struct {
 	account         *ethereum.Account
 	client          contracts.ETHClient
 	contract        *contracts.ITellorAccess
-	transactor      transactor.Transactor
-	submitCount     prometheus.Counter
-	submitFailCount prometheus.Counter
-	submitValue     *prometheus.GaugeVec
-	psr             *psr.Psr
+	pipeline        *submitter.Pipeline
+	journal         *db.Journal
+	multicall       *submitter.Multicall
+	abi             abi.ABI
+	gasPriceTracker *gasPrice.GasTracker
+	psr             psr.Getter
 	lastSubmitValue map[int64]float64
 	lastSubmitTime  map[int64]time.Time
+	lastGasUsed     uint64
 	reqIDs          []int64
+	gate            *submitter.Gate
+	clock           *clock.Tracker
+	profitSkips     prometheus.Counter
 }
 
 func New(
@@ -67,13 +92,31 @@ func New(
 	contract *contracts.ITellorAccess,
 	account *ethereum.Account,
 	transactor transactor.Transactor,
-	psr *psr.Psr,
+	gasPriceTracker *gasPrice.GasTracker,
+	psr psr.Getter,
+	journal *db.Journal,
+	gate *submitter.Gate,
+	guard *submitter.Guard,
+	breaker *submitter.Breaker,
+	clock *clock.Tracker,
 ) (*Submitter, error) {
 	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
 	if err != nil {
 		return nil, errors.Wrap(err, "apply filter logger")
 	}
 	logger = log.With(logger, "component", ComponentName)
+	var multicall *submitter.Multicall
+	if cfg.Multicall.Enabled {
+		multicall, err = submitter.NewMulticall(cfg.Multicall, contract.Address, client)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating multicall")
+		}
+	}
+	parsed, err := abi.JSON(strings.NewReader(contracts.TellorAccessABI))
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing the contract abi")
+	}
+	pipeline := submitter.NewPipeline(logger, ComponentName, client, account.Address, transactor, guard, breaker, cfg.DryRun)
 	ctx, close := context.WithCancel(ctx)
 	submitter := &Submitter{
 		ctx:             ctx,
@@ -83,34 +126,24 @@ func New(
 		account:         account,
 		logger:          logger,
 		contract:        contract,
-		transactor:      transactor,
+		pipeline:        pipeline,
+		journal:         journal,
+		multicall:       multicall,
+		abi:             parsed,
+		gasPriceTracker: gasPriceTracker,
 		psr:             psr,
+		gate:            gate,
+		clock:           clock,
 		reqIDs:          []int64{1, 2},
 		lastSubmitValue: make(map[int64]float64),
 		lastSubmitTime:  make(map[int64]time.Time),
-		submitCount: promauto.NewCounter(prometheus.CounterOpts{
+		profitSkips: promauto.NewCounter(prometheus.CounterOpts{
 			Namespace:   "telliot",
 			Subsystem:   ComponentName,
-			Name:        "submit_total",
-			Help:        "The total number of submitted solutions",
+			Name:        "profit_skips_total",
+			Help:        "The total number of submissions skipped by the profit check",
 			ConstLabels: prometheus.Labels{"account": account.Address.String()},
 		}),
-		submitFailCount: promauto.NewCounter(prometheus.CounterOpts{
-			Namespace:   "telliot",
-			Subsystem:   ComponentName,
-			Name:        "submit_fails_total",
-			Help:        "The total number of failed submission",
-			ConstLabels: prometheus.Labels{"account": account.Address.String()},
-		}),
-		submitValue: promauto.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace:   "telliot",
-			Subsystem:   ComponentName,
-			Name:        "submit_value",
-			Help:        "The submitted value",
-			ConstLabels: prometheus.Labels{"account": account.Address.String()},
-		},
-			[]string{"id"},
-		),
 	}
 
 	// Set the initial values
@@ -123,8 +156,11 @@ func New(
 }
 
 func (self *Submitter) Start() error {
+	self.resumePending()
+	self.restoreGasUsed()
+
 	for _, reqID := range self.reqIDs {
-		exists, val, ts, err := self.contract.GetCurrentValue(&bind.CallOpts{Context: self.ctx}, big.NewInt(1))
+		exists, val, ts, err := self.contract.GetCurrentValue(&bind.CallOpts{Context: self.ctx}, big.NewInt(reqID))
 		if err != nil {
 			level.Error(self.logger).Log("msg", "retrieve current value", "reqID", reqID, "err", err)
 			break
@@ -133,7 +169,7 @@ func (self *Submitter) Start() error {
 			level.Error(self.logger).Log("msg", "current value doesn't exist", "reqID", reqID, "err", err)
 			break
 		}
-		self.lastSubmitValue[reqID] = float64(val.Int64())
+		self.lastSubmitValue[reqID], _ = new(big.Float).SetInt(val).Float64()
 		self.lastSubmitTime[reqID] = time.Unix(ts.Int64(), 0)
 		level.Debug(self.logger).Log(
 			"msg", "recorded initial values",
@@ -143,11 +179,7 @@ func (self *Submitter) Start() error {
 		)
 	}
 
-	for _, reqID := range self.reqIDs {
-		if err := self.Submit(reqID); err != nil {
-			level.Error(self.logger).Log("msg", "submit", "err", err)
-		}
-	}
+	self.submitAll()
 
 	ticker := time.NewTicker(2 * time.Minute)
 	defer ticker.Stop()
@@ -156,11 +188,7 @@ func (self *Submitter) Start() error {
 		case <-self.ctx.Done():
 			return self.ctx.Err()
 		case <-ticker.C:
-			for _, reqID := range self.reqIDs {
-				if err := self.Submit(reqID); err != nil {
-					level.Error(self.logger).Log("msg", "submit", "reqID", reqID, "err", err)
-				}
-			}
+			self.submitAll()
 		}
 	}
 }
@@ -169,47 +197,120 @@ func (self *Submitter) Stop() {
 	self.close()
 }
 
-func (self *Submitter) Submit(reqID int64) error {
+// submitAll submits the due values of all request IDs,
+// in one transaction with the multicall.
+func (self *Submitter) submitAll() {
+	if self.multicall != nil {
+		if err := self.Submit(self.reqIDs...); err != nil {
+			level.Error(self.logger).Log("msg", "submit", "reqIDs", fmt.Sprint(self.reqIDs), "err", err)
+		}
+		return
+	}
+	for _, reqID := range self.reqIDs {
+		if err := self.Submit(reqID); err != nil {
+			level.Error(self.logger).Log("msg", "submit", "reqID", reqID, "err", err)
+		}
+	}
+}
+
+// Submit submits the values of the request IDs that need a new value in one transaction.
+// Several request IDs need the multicall.
+func (self *Submitter) Submit(reqIDs ...int64) error {
+	if len(reqIDs) > 1 && self.multicall == nil {
+		return errors.New("submitting several values needs the multicall")
+	}
+	if err := self.gate.Err(); err != nil {
+		return err
+	}
 	ctx, cncl := context.WithTimeout(self.ctx, time.Minute)
 	defer cncl()
-	isReporter, err := self.contract.IsReporter(&bind.CallOpts{Context: ctx}, self.account.Address)
+	reporter := self.account.Address
+	if self.multicall != nil {
+		reporter = self.multicall.Sender(reporter)
+	}
+	isReporter, err := self.contract.IsReporter(&bind.CallOpts{Context: ctx}, reporter)
 	if err != nil {
 		return errors.Wrap(err, "checking reporter status")
 	}
 	if !isReporter {
-		return errors.Wrap(err, "addr not a reporter")
+		return errors.Errorf("addr not a reporter:%v", reporter.String())
 	}
 
-	val, err := self.psr.GetValue(reqID, time.Now())
-	if err != nil {
-		return errors.Wrap(err, "getting the value from the aggregator")
+	var (
+		ids  []int64
+		vals []*big.Int
+	)
+	for _, reqID := range reqIDs {
+		val, err := self.psr.GetValue(reqID, self.clock.Now())
+		if err != nil {
+			level.Error(self.logger).Log("msg", "getting the value from the aggregator", "reqID", reqID, "err", err)
+			continue
+		}
+		valF, _ := new(big.Float).SetInt(val).Float64()
+		if !self.shouldSubmit(reqID, valF) {
+			continue
+		}
+		ids = append(ids, reqID)
+		vals = append(vals, val)
 	}
-
-	if !self.shouldSubmit(reqID, val) {
+	if len(ids) == 0 {
 		return nil
 	}
+	if err := self.checkProfit(ctx); err != nil {
+		self.profitSkips.Inc()
+		return err
+	}
 	level.Info(self.logger).Log(
 		"msg", "sending values to the chain",
-		"ID", reqID,
-		"val", val,
+		"IDs", fmt.Sprint(ids),
+		"vals", fmt.Sprint(vals),
 	)
 
-	f := func(auth *bind.TransactOpts) (*types.Transaction, error) {
-		_reqID := big.NewInt(reqID)
-		_val := big.NewInt(val)
-		return self.contract.SubmitValue(auth, _reqID, _val)
+	id := fmt.Sprintf("%x:%v", time.Now().UnixNano(), self.account.Address.String())
+	var idsStr, values []string
+	for i, reqID := range ids {
+		idsStr = append(idsStr, strconv.FormatInt(reqID, 10))
+		values = append(values, vals[i].String())
 	}
-	tx, recieipt, err := self.transactor.Transact(ctx, f)
-	if err != nil {
-		self.submitFailCount.Inc()
-		return errors.Wrap(err, "submiting a solution")
+	tx, recieipt, err := self.pipeline.Submit(ctx, submitter.Submission{
+		Function: transactor.FunctionSubmitValue,
+		IDs:      ids,
+		Values:   vals,
+		OnSimulated: func(tx *types.Transaction) {
+			self.record(db.Submission{ID: id, Function: transactor.FunctionSubmitValue, State: db.StateSimulated, RequestIDs: idsStr, Values: values})
+		},
+		OnBroadcast: func(tx *types.Transaction) {
+			self.record(db.Submission{ID: id, State: db.StateBroadcast, TxHash: tx.Hash().String(), Nonce: tx.Nonce()})
+		},
+		Build: func(auth *bind.TransactOpts) (*types.Transaction, error) {
+			if self.multicall == nil {
+				return self.contract.SubmitValue(auth, big.NewInt(ids[0]), vals[0])
+			}
+			var calls [][]byte
+			for i, id := range ids {
+				call, err := self.abi.Pack("submitValue", big.NewInt(id), vals[i])
+				if err != nil {
+					return nil, errors.Wrap(err, "packing the submit call")
+				}
+				calls = append(calls, call)
+			}
+			return self.multicall.Transact(auth, calls)
+		},
+	})
+	if errors.Is(err, submitter.ErrDryRun) {
+		self.record(db.Submission{ID: id, Function: transactor.FunctionSubmitValue, State: db.StateDryRun, RequestIDs: idsStr, Values: values})
+		return nil
 	}
-
-	if recieipt.Status != types.ReceiptStatusSuccessful {
-		self.submitFailCount.Inc()
-		return errors.Wrapf(err, "submiting solution status not success status:%v, tx hash:%v", recieipt.Status, tx.Hash())
+	if err != nil {
+		s := db.Submission{ID: id, Function: transactor.FunctionSubmitValue, State: db.StateFailed, RequestIDs: idsStr, Values: values, Err: err.Error()}
+		if tx != nil {
+			s.TxHash, s.Nonce = tx.Hash().String(), tx.Nonce()
+		}
+		self.record(s)
+		return errors.Wrap(err, "submiting a value")
 	}
-	level.Info(self.logger).Log("msg", "successfully submited solution",
+	self.record(db.Submission{ID: id, State: db.StateConfirmed, TxHash: tx.Hash().String(), Nonce: tx.Nonce(), GasUsed: recieipt.GasUsed})
+	level.Info(self.logger).Log("msg", "successfully submited value",
 		"txHash", tx.Hash().String(),
 		"nonce", tx.Nonce(),
 		"gasPrice", tx.GasPrice(),
@@ -217,26 +318,66 @@ func (self *Submitter) Submit(reqID int64) error {
 		"gasLimit", tx.Gas(),
 		"data", fmt.Sprintf("%x", tx.Data()),
 	)
-	self.submitCount.Inc()
+	// The profit check is per value so a batch shares its gas between the values.
+	self.lastGasUsed = recieipt.GasUsed / uint64(len(ids))
 
-	self.submitValue.With(
-		prometheus.Labels{
-			"id": strconv.Itoa(int(reqID)),
-		},
-	).(prometheus.Gauge).Set(float64(val))
-
-	self.lastSubmitValue[reqID] = float64(val)
-	self.lastSubmitTime[reqID] = time.Now()
-	level.Debug(self.logger).Log(
-		"msg", "recorded new values after a submit",
-		"reqID", reqID,
-		"lastSubmitValue", self.lastSubmitValue[reqID],
-		"lastSubmitTime", time.Since(self.lastSubmitTime[reqID]),
-	)
+	for i, reqID := range ids {
+		self.lastSubmitValue[reqID], _ = new(big.Float).SetInt(vals[i]).Float64()
+		self.lastSubmitTime[reqID] = time.Now()
+		level.Debug(self.logger).Log(
+			"msg", "recorded new values after a submit",
+			"reqID", reqID,
+			"lastSubmitValue", self.lastSubmitValue[reqID],
+			"lastSubmitTime", time.Since(self.lastSubmitTime[reqID]),
+		)
+	}
 	return nil
 }
 
-func (self *Submitter) shouldSubmit(reqID int64, newVal int64) bool {
+func (self *Submitter) record(s db.Submission) {
+	s.Account = self.account.Address.String()
+	if err := self.journal.Record(s); err != nil {
+		level.Error(self.logger).Log("msg", "recording submission state", "id", s.ID, "state", s.State, "err", err)
+	}
+}
+
+// restoreGasUsed sets the gas used by a value from the last confirmed submission
+// so that the profit check doesn't wait for a new submission after a restart.
+func (self *Submitter) restoreGasUsed() {
+	s, ok := self.journal.LastConfirmed(self.account.Address.String(), transactor.FunctionSubmitValue)
+	if !ok || s.GasUsed == 0 || len(s.RequestIDs) == 0 {
+		return
+	}
+	self.lastGasUsed = s.GasUsed / uint64(len(s.RequestIDs))
+	level.Info(self.logger).Log("msg", "restored the gas used by a submission", "gasUsed", self.lastGasUsed, "txHash", s.TxHash)
+}
+
+// resumePending settles the submissions that were in-flight when the previous run was stopped.
+// The values are submitted again when needed so only the receipts of the broadcasted ones are checked.
+func (self *Submitter) resumePending() {
+	for _, s := range self.journal.Pending(self.account.Address.String(), transactor.FunctionSubmitValue) {
+		if s.Function != transactor.FunctionSubmitValue {
+			continue
+		}
+		if s.State != db.StateBroadcast {
+			self.record(db.Submission{ID: s.ID, State: db.StateFailed, Err: "interrupted by a restart"})
+			continue
+		}
+		receipt, err := self.client.TransactionReceipt(self.ctx, common.HexToHash(s.TxHash))
+		if err != nil {
+			level.Warn(self.logger).Log("msg", "submission interrupted by a restart not mined", "id", s.ID, "txHash", s.TxHash, "err", err)
+			self.record(db.Submission{ID: s.ID, State: db.StateFailed, Err: "not mined before the restart"})
+			continue
+		}
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			self.record(db.Submission{ID: s.ID, State: db.StateFailed, Err: "receipt status not success"})
+			continue
+		}
+		self.record(db.Submission{ID: s.ID, State: db.StateConfirmed, GasUsed: receipt.GasUsed})
+	}
+}
+
+func (self *Submitter) shouldSubmit(reqID int64, newVal float64) bool {
 	logger := log.With(self.logger, "msg", "should submit check passed", "reqID", reqID)
 
 	if self.lastSubmitTime[reqID].IsZero() {
@@ -259,7 +400,7 @@ func (self *Submitter) shouldSubmit(reqID int64, newVal int64) bool {
 		level.Error(self.logger).Log("msg", "last value check - no record for last value")
 	}
 
-	percentageChange := math.Abs((lastSubmitValue-float64(newVal))/lastSubmitValue) * 100
+	percentageChange := math.Abs((lastSubmitValue-newVal)/lastSubmitValue) * 100
 	if percentageChange > percentageChangeThreshold {
 		level.Debug(logger).Log(
 			"reason", "value change more then threshold",
@@ -272,3 +413,34 @@ func (self *Submitter) shouldSubmit(reqID int64, newVal int64) bool {
 	}
 	return false
 }
+
+// checkProfit returns an error when the profit of a submission
+// at the current gas price is lower than the threshold.
+// The check is skipped until the gas used by a submission is known.
+func (self *Submitter) checkProfit(ctx context.Context) error {
+	if self.cfg.ProfitThreshold == 0 {
+		return nil
+	}
+	if self.lastGasUsed == 0 {
+		level.Warn(self.logger).Log("msg", "skipping profit check when there is no record for how much gas a submission uses")
+		return nil
+	}
+	gasPrice, err := self.gasPriceTracker.Query(ctx)
+	if err != nil {
+		return errors.Wrap(err, "getting current gas price")
+	}
+	txCost := float64(gasPrice) * float64(self.lastGasUsed) / 1e18
+	profitPercent := int64((self.cfg.Reward - txCost) / txCost * 100)
+	level.Debug(self.logger).Log(
+		"msg", "profit checking",
+		"reward", self.cfg.Reward,
+		"txCost", txCost,
+		"gasUsed", self.lastGasUsed,
+		"gasPrice", gasPrice,
+		"profitMargin", profitPercent,
+	)
+	if profitPercent < int64(self.cfg.ProfitThreshold) {
+		return errors.Errorf("profit:%v lower then the profit threshold:%v", profitPercent, self.cfg.ProfitThreshold)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package tellorAccess
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/db"
+	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/submitter"
+	"github.com/tellor-io/telliot/pkg/testutil"
+	"github.com/tellor-io/telliot/pkg/testutil/chain"
+	"github.com/tellor-io/telliot/pkg/transactor"
+)
+
+type getter map[int64]*big.Int
+
+func (self getter) GetValue(reqID int64, _ time.Time) (*big.Int, error) {
+	return self[reqID], nil
+}
+
+func openJournal(t *testing.T) (*db.Journal, func()) {
+	dir, err := ioutil.TempDir("", "journal")
+	testutil.Ok(t, err)
+	j, err := db.OpenJournal(filepath.Join(dir, "submissions.journal"))
+	testutil.Ok(t, err)
+	return j, func() {
+		j.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func newTestSubmitter(t *testing.T, c *chain.Chain, account *ethereum.Account, cfg Config, journal *db.Journal) *Submitter {
+	contract := &contracts.ITellorAccess{Address: c.TellorAccessAddress, TellorAccess: c.TellorAccess}
+	s, err := New(
+		context.Background(),
+		logging.NewLogger(),
+		cfg,
+		c,
+		contract,
+		account,
+		c.Transactor(account),
+		nil,
+		getter{1: big.NewInt(1000), 2: big.NewInt(2000)},
+		journal,
+		submitter.NewGate(account.Address.String()),
+		nil,
+		nil,
+		nil,
+	)
+	testutil.Ok(t, err)
+	return s
+}
+
+// TestSubmit ensures that a submission is journaled with its gas used
+// which is restored after a restart for the profit check.
+func TestSubmit(t *testing.T) {
+	c, err := chain.New(1)
+	testutil.Ok(t, err)
+	defer c.Close()
+	journal, cleanup := openJournal(t)
+	defer cleanup()
+	account := c.Accounts[0]
+	s := newTestSubmitter(t, c, account, Config{LogLevel: "info"}, journal)
+
+	testutil.Ok(t, s.Submit(1))
+	_, val, _, err := c.TellorAccess.GetCurrentValue(&bind.CallOpts{}, big.NewInt(1))
+	testutil.Ok(t, err)
+	testutil.Equals(t, big.NewInt(1000), val)
+
+	last, ok := journal.LastConfirmed(account.Address.String(), transactor.FunctionSubmitValue)
+	testutil.Assert(t, ok, "the submission isn't journaled")
+	testutil.Equals(t, []string{"1"}, last.RequestIDs)
+	testutil.Equals(t, []string{"1000"}, last.Values)
+	testutil.Assert(t, last.GasUsed > 0, "the gas used isn't journaled")
+	testutil.Equals(t, last.GasUsed, s.lastGasUsed)
+
+	// The value didn't change so it isn't submitted again.
+	testutil.Ok(t, s.Submit(1))
+	testutil.Equals(t, 1, len(journal.Submissions()))
+
+	restarted := &Submitter{logger: s.logger, account: account, journal: journal}
+	restarted.restoreGasUsed()
+	testutil.Equals(t, last.GasUsed, restarted.lastGasUsed)
+}
+
+// TestSubmitDryRun ensures that a dry run is journaled in its own final state.
+func TestSubmitDryRun(t *testing.T) {
+	c, err := chain.New(2)
+	testutil.Ok(t, err)
+	defer c.Close()
+	journal, cleanup := openJournal(t)
+	defer cleanup()
+	account := c.Accounts[1]
+	s := newTestSubmitter(t, c, account, Config{LogLevel: "info", DryRun: true}, journal)
+
+	testutil.Ok(t, s.Submit(2))
+	subs := journal.Submissions()
+	testutil.Equals(t, 1, len(subs))
+	testutil.Equals(t, db.StateDryRun, subs[0].State)
+	testutil.Equals(t, []string{"2000"}, subs[0].Values)
+	testutil.Equals(t, 0, len(journal.Pending(account.Address.String(), transactor.FunctionSubmitValue)))
+
+	exists, _, _, err := c.TellorAccess.GetCurrentValue(&bind.CallOpts{}, big.NewInt(2))
+	testutil.Ok(t, err)
+	testutil.Assert(t, !exists, "a dry run submitted the value")
+}
+
+// TestResumePending ensures that the submissions interrupted by a restart are settled.
+func TestResumePending(t *testing.T) {
+	c, err := chain.New(1)
+	testutil.Ok(t, err)
+	defer c.Close()
+	journal, cleanup := openJournal(t)
+	defer cleanup()
+	account := c.Accounts[0]
+
+	opts, err := c.Opts(account)
+	testutil.Ok(t, err)
+	tx, err := c.TellorAccess.SubmitValue(opts, big.NewInt(1), big.NewInt(1000))
+	testutil.Ok(t, err)
+
+	addr := account.Address.String()
+	testutil.Ok(t, journal.Record(db.Submission{ID: "mined", Account: addr, Function: transactor.FunctionSubmitValue, State: db.StateBroadcast, RequestIDs: []string{"1"}, TxHash: tx.Hash().String()}))
+	testutil.Ok(t, journal.Record(db.Submission{ID: "simulated", Account: addr, Function: transactor.FunctionSubmitValue, State: db.StateSimulated}))
+	testutil.Ok(t, journal.Record(db.Submission{ID: "solution", Account: addr, Function: transactor.FunctionSubmitMiningSolution, State: db.StateBroadcast}))
+
+	s := &Submitter{ctx: context.Background(), logger: logging.NewLogger(), account: account, client: c, journal: journal}
+	s.resumePending()
+	s.restoreGasUsed()
+
+	states := make(map[string]db.SubmissionState)
+	for _, sub := range journal.Submissions() {
+		states[sub.ID] = sub.State
+	}
+	testutil.Equals(t, db.StateConfirmed, states["mined"])
+	testutil.Equals(t, db.StateFailed, states["simulated"])
+	testutil.Equals(t, db.StateBroadcast, states["solution"])
+	testutil.Assert(t, s.lastGasUsed > 0, "the gas used of the resumed submission isn't restored")
+}
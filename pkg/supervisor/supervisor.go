@@ -0,0 +1,246 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/health"
+	"github.com/tellor-io/telliot/pkg/logging"
+)
+
+const ComponentName = "supervisor"
+
+type Config struct {
+	LogLevel string
+	// MaxRestarts is how many times a component is restarted before shutting down the whole process.
+	// 0 means no limit.
+	MaxRestarts int
+	BackoffMin  format.Duration
+	BackoffMax  format.Duration
+}
+
+// Component is the contract that all long running components implement.
+// Start blocks until the component is stopped or fails.
+type Component interface {
+	Start() error
+	Stop()
+}
+
+type State string
+
+const (
+	StateRunning    State = "running"
+	StateRestarting State = "restarting"
+	StateStopped    State = "stopped"
+	StateFailed     State = "failed"
+)
+
+// Status is the current state of a supervised component.
+type Status struct {
+	Name      string
+	State     State
+	Critical  bool
+	Restarts  int
+	LastError string `json:",omitempty"`
+	Since     time.Time
+}
+
+// Supervisor runs the components, restarts them with a backoff when
+// they crash and keeps track of their state.
+type Supervisor struct {
+	logger   log.Logger
+	cfg      Config
+	mtx      sync.Mutex
+	statuses map[string]*Status
+	order    []string
+
+	restarts *prometheus.CounterVec
+	up       *prometheus.GaugeVec
+}
+
+func New(logger log.Logger, cfg Config) (*Supervisor, error) {
+	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	if cfg.BackoffMin.Duration <= 0 {
+		return nil, errors.Errorf("invalid min backoff:%v", cfg.BackoffMin)
+	}
+	if cfg.BackoffMax.Duration < cfg.BackoffMin.Duration {
+		return nil, errors.Errorf("max backoff:%v lower than the min backoff:%v", cfg.BackoffMax, cfg.BackoffMin)
+	}
+	return &Supervisor{
+		logger:   log.With(logger, "component", ComponentName),
+		cfg:      cfg,
+		statuses: make(map[string]*Status),
+		restarts: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "restarts_total",
+			Help:      "The total number of component restarts",
+		},
+			[]string{"name"},
+		),
+		up: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "up",
+			Help:      "Whether the component is running(1) or not(0)",
+		},
+			[]string{"name"},
+		),
+	}, nil
+}
+
+// Actor returns the execute and interrupt functions for adding the component to a run group.
+// When the component exits unexpectedly or panics it is restarted with an exponential backoff.
+// The execute function returns only when the component is interrupted or
+// when it has reached the maximum number of restarts.
+func (self *Supervisor) Actor(name string, critical bool, c Component) (func() error, func(error)) {
+	self.mtx.Lock()
+	self.statuses[name] = &Status{Name: name, Critical: critical, State: StateStopped, Since: time.Now()}
+	self.order = append(self.order, name)
+	self.mtx.Unlock()
+
+	logger := log.With(self.logger, "name", name)
+	stop := make(chan struct{})
+	var once sync.Once
+
+	execute := func() error {
+		backoff := self.cfg.BackoffMin.Duration
+		for {
+			self.setState(name, StateRunning, nil)
+			started := time.Now()
+			err := run(c)
+
+			select {
+			case <-stop:
+				self.setState(name, StateStopped, err)
+				level.Info(logger).Log("msg", "shutdown complete")
+				return err
+			default:
+			}
+
+			if err == nil {
+				err = errors.New("exited unexpectedly")
+			}
+
+			restarts := self.incRestarts(name)
+			if self.cfg.MaxRestarts > 0 && restarts > self.cfg.MaxRestarts {
+				self.setState(name, StateFailed, err)
+				return errors.Wrapf(err, "component:%v failed after %v restarts", name, self.cfg.MaxRestarts)
+			}
+
+			// Reset the backoff when the component was running fine for a while.
+			if time.Since(started) > self.cfg.BackoffMax.Duration {
+				backoff = self.cfg.BackoffMin.Duration
+			}
+			self.setState(name, StateRestarting, err)
+			level.Error(logger).Log("msg", "component crashed, restarting", "restarts", restarts, "backoff", backoff, "err", err)
+
+			select {
+			case <-stop:
+				self.setState(name, StateStopped, err)
+				return nil
+			case <-time.After(backoff):
+			}
+
+			backoff *= 2
+			if backoff > self.cfg.BackoffMax.Duration {
+				backoff = self.cfg.BackoffMax.Duration
+			}
+		}
+	}
+
+	interrupt := func(error) {
+		once.Do(func() { close(stop) })
+		c.Stop()
+	}
+
+	return execute, interrupt
+}
+
+// run starts the component and converts a panic into an error.
+func run(c Component) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("panic:%v stack:%s", r, debug.Stack())
+		}
+	}()
+	return c.Start()
+}
+
+func (self *Supervisor) setState(name string, state State, err error) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	status := self.statuses[name]
+	status.State = state
+	status.Since = time.Now()
+	if err != nil {
+		status.LastError = err.Error()
+	}
+	up := 0.0
+	if state == StateRunning {
+		up = 1
+	}
+	self.up.With(prometheus.Labels{"name": name}).Set(up)
+}
+
+func (self *Supervisor) incRestarts(name string) int {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	self.statuses[name].Restarts++
+	self.restarts.With(prometheus.Labels{"name": name}).Inc()
+	return self.statuses[name].Restarts
+}
+
+// Statuses returns the status of all supervised components in the order they were added.
+func (self *Supervisor) Statuses() []Status {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	statuses := make([]Status, 0, len(self.order))
+	for _, name := range self.order {
+		statuses = append(statuses, *self.statuses[name])
+	}
+	return statuses
+}
+
+// Healthy reports whether all critical components are running.
+func (self *Supervisor) Healthy() bool {
+	for _, status := range self.Statuses() {
+		if status.Critical && status.State != StateRunning {
+			return false
+		}
+	}
+	return true
+}
+
+// Health reports a component as healthy only while it is running.
+func (self *Supervisor) Health(context.Context) []health.Status {
+	var statuses []health.Status
+	for _, status := range self.Statuses() {
+		s := health.Status{
+			Name:     status.Name,
+			Critical: status.Critical,
+			Healthy:  status.State == StateRunning,
+			Ready:    status.State == StateRunning,
+		}
+		if !s.Healthy {
+			s.Message = fmt.Sprintf("state:%v since:%v restarts:%v lastError:%v", status.State, status.Since.Format(time.RFC3339), status.Restarts, status.LastError)
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses
+}
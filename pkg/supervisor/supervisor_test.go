@@ -0,0 +1,70 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package supervisor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+type crashingComponent struct {
+	starts int
+	stop   chan struct{}
+}
+
+func (self *crashingComponent) Start() error {
+	self.starts++
+	if self.starts < 3 {
+		panic("crash")
+	}
+	<-self.stop
+	return nil
+}
+
+func (self *crashingComponent) Stop() {
+	close(self.stop)
+}
+
+// TestSupervisor ensures that a crashing component is restarted and
+// its state is reflected in the health status.
+func TestSupervisor(t *testing.T) {
+	sup, err := New(log.NewNopLogger(), Config{
+		LogLevel:   "info",
+		BackoffMin: format.Duration{Duration: time.Millisecond},
+		BackoffMax: format.Duration{Duration: 10 * time.Millisecond},
+	})
+	testutil.Ok(t, err)
+
+	c := &crashingComponent{stop: make(chan struct{})}
+	execute, interrupt := sup.Actor("crashing", true, c)
+
+	done := make(chan error)
+	go func() { done <- execute() }()
+
+	for i := 0; i < 100; i++ {
+		if sup.Healthy() && sup.Statuses()[0].Restarts == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	status := sup.Statuses()[0]
+	testutil.Equals(t, StateRunning, status.State)
+	testutil.Equals(t, 2, status.Restarts)
+
+	interrupt(nil)
+	testutil.Ok(t, <-done)
+	testutil.Equals(t, StateStopped, sup.Statuses()[0].State)
+	testutil.Assert(t, !sup.Healthy(), "a stopped critical component should make the status unhealthy")
+
+	// Ensure that the supervisor gives up after the max restarts.
+	sup.cfg.MaxRestarts = 1
+	c = &crashingComponent{stop: make(chan struct{})}
+	execute, _ = sup.Actor("crashingMax", true, c)
+	testutil.NotOk(t, execute())
+	testutil.Equals(t, StateFailed, sup.Statuses()[1].State)
+}
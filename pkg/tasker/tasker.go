@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"math/big"
 	"math/rand"
 	"time"
 
@@ -16,11 +17,18 @@ import (
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/tellor-io/telliot/pkg/contracts"
 	"github.com/tellor-io/telliot/pkg/contracts/tellor"
 	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/health"
 	"github.com/tellor-io/telliot/pkg/logging"
 	"github.com/tellor-io/telliot/pkg/mining"
+	"github.com/tellor-io/telliot/pkg/tracing"
+	"github.com/tellor-io/telliot/pkg/tracker/race"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const ComponentName = "taskerNewChallenge"
@@ -29,6 +37,11 @@ type Config struct {
 	LogLevel string
 }
 
+// Excluder returns the first request ID of a challenge that shouldn't be submitted.
+type Excluder interface {
+	Excluded(requestIDs [5]*big.Int) (int64, bool)
+}
+
 // SubmitCanceler will be used to cancel current submits when new event arrives.
 type SubmitCanceler interface {
 	CancelPendingSubmit()
@@ -44,6 +57,11 @@ type Tasker struct {
 	workSinks       map[string]chan *mining.Work
 	SubmitCancelers []SubmitCanceler
 	txPending       context.CancelFunc
+	lastEvent       health.Timestamp
+	upgrades        <-chan contracts.Addresses
+	races           *race.Tracker
+	excluder        Excluder
+	reconnects      prometheus.Counter
 }
 
 func New(
@@ -53,6 +71,9 @@ func New(
 	client contracts.ETHClient,
 	contract *contracts.ITellor,
 	accounts []*ethereum.Account,
+	upgrades <-chan contracts.Addresses,
+	races *race.Tracker,
+	excluder Excluder,
 ) (*Tasker, map[string]chan *mining.Work, error) {
 	ctx, close := context.WithCancel(ctx)
 	workSinks := make(map[string]chan *mining.Work)
@@ -73,6 +94,15 @@ func New(
 		logger:          log.With(logger, "component", ComponentName),
 		client:          client,
 		SubmitCancelers: make([]SubmitCanceler, 0),
+		upgrades:        upgrades,
+		races:           races,
+		excluder:        excluder,
+		reconnects: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "subscription_reconnects_total",
+			Help:      "The total number of event re-subscriptions",
+		}),
 	}
 	return tasker, tasker.workSinks, nil
 }
@@ -99,6 +129,23 @@ func (self *Tasker) sendWork(challenge *tellor.ITellorNewChallenge) {
 		Difficulty: challenge.Difficulty,
 		RequestIDs: challenge.CurrentRequestId,
 	}
+	_, span := tracing.Start(self.ctx, "tasker.newChallenge",
+		attribute.String("challenge", fmt.Sprintf("%x", newChallenge.Challenge)),
+		attribute.String("requestIDs", fmt.Sprintf("%+v", newChallenge.RequestIDs)),
+	)
+	defer span.End()
+
+	// The solution of a challenge with an excluded request ID can't be submitted
+	// so a nil work stops the mining until the next challenge.
+	var excluded bool
+	if self.excluder != nil {
+		var reqID int64
+		if reqID, excluded = self.excluder.Excluded(newChallenge.RequestIDs); excluded {
+			level.Info(self.logger).Log("msg", "challenge includes an excluded request ID, not mining it", "requestID", reqID)
+			span.AddEvent("excluded", trace.WithAttributes(attribute.Int64("requestID", reqID)))
+		}
+	}
+
 	for _, acc := range self.accounts {
 		level.Info(self.logger).Log("msg", "new event",
 			"addr", acc.Address.String(),
@@ -107,8 +154,12 @@ func (self *Tasker) sendWork(challenge *tellor.ITellorNewChallenge) {
 			"requestIDs", fmt.Sprintf("%+v", newChallenge.RequestIDs),
 		)
 
+		var work *mining.Work
+		if !excluded {
+			work = &mining.Work{Challenge: newChallenge, PublicAddr: acc.Address.String(), Start: uint64(rand.Int63()), N: math.MaxInt64, Trace: span.SpanContext()}
+		}
 		select {
-		case self.workSinks[acc.Address.String()] <- &mining.Work{Challenge: newChallenge, PublicAddr: acc.Address.String(), Start: uint64(rand.Int63()), N: math.MaxInt64}:
+		case self.workSinks[acc.Address.String()] <- work:
 		case <-self.ctx.Done():
 			return
 		}
@@ -186,15 +237,22 @@ func (self *Tasker) Start() error {
 				}
 				break
 			}
+			self.reconnects.Inc()
 			level.Info(self.logger).Log("msg", "re-subscribed to events")
+		case <-self.upgrades:
+			// Closes the error channel so the case above resubscribes.
+			level.Info(self.logger).Log("msg", "contract upgraded, resubscribing to events")
+			sub.Unsubscribe()
 		case event := <-events:
 			level.Debug(self.logger).Log("msg", "new event", "reorg", event.Raw.Removed)
+			self.lastEvent.Set(time.Now())
 			if self.txPending != nil {
 				self.txPending()
 				self.txPending = nil
 			}
 
 			if !event.Raw.Removed { // For reorg events just cancel the old TXs without sending this one.
+				self.races.Arrived(event.CurrentChallenge, event.Raw.BlockNumber, time.Now())
 				ctxPending, ctxPendingCncl := context.WithCancel(self.ctx)
 				self.txPending = ctxPendingCncl
 				go self.sendWhenConfirmed(ctxPending, event)
@@ -235,6 +293,11 @@ func (self *Tasker) sendWhenConfirmed(ctx context.Context, vLog *tellor.ITellorN
 	}
 }
 
+// LastEvent returns the time of the last received new challenge event.
+func (self *Tasker) LastEvent() time.Time {
+	return self.lastEvent.Get()
+}
+
 func (self *Tasker) Stop() {
 	self.close()
 }
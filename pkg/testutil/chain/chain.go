@@ -0,0 +1,200 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+// Package chain is an in-memory simulated chain for end-to-end tests
+// of the trackers and the submitters without a node.
+//
+// The chain has deterministic funded accounts and a TellorAccess oracle
+// with all accounts as reporters. The legacy Tellor contracts are behind
+// a master proxy which is not in the contract bindings so they are not deployed.
+package chain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/contracts/tellorAccess"
+	"github.com/tellor-io/telliot/pkg/ethereum"
+)
+
+// GasLimit is the block gas limit of the chain.
+const GasLimit = 30000000
+
+// Balance is the ETH balance of every account at the start.
+var Balance = new(big.Int).Mul(big.NewInt(1000), big.NewInt(1e18))
+
+var _ contracts.ETHClient = &Chain{}
+
+// Chain is a simulated chain which implements contracts.ETHClient
+// so it can be passed to any component instead of a node client.
+// Every transaction is mined in its own block as soon as it is sent.
+type Chain struct {
+	*backends.SimulatedBackend
+	// Accounts are funded with Balance and are reporters of the TellorAccess oracle.
+	// The first one is the admin of the oracle.
+	Accounts []*ethereum.Account
+	// TellorAccess is the oracle deployed at TellorAccessAddress.
+	TellorAccess        *tellorAccess.TellorAccess
+	TellorAccessAddress common.Address
+}
+
+// New creates a chain with the number of accounts.
+// The keys of the accounts are the same on every run.
+func New(accounts int) (*Chain, error) {
+	if accounts < 1 {
+		return nil, errors.New("the chain needs at least one account")
+	}
+	self := &Chain{}
+	alloc := make(core.GenesisAlloc)
+	for i := 0; i < accounts; i++ {
+		key, err := Key(i)
+		if err != nil {
+			return nil, err
+		}
+		account := &ethereum.Account{Address: crypto.PubkeyToAddress(key.PublicKey), PrivateKey: key}
+		self.Accounts = append(self.Accounts, account)
+		alloc[account.Address] = core.GenesisAccount{Balance: Balance}
+	}
+	self.SimulatedBackend = backends.NewSimulatedBackend(alloc, GasLimit)
+
+	if err := self.deploy(); err != nil {
+		self.Close()
+		return nil, err
+	}
+	return self, nil
+}
+
+// Key returns the deterministic private key of the account with the index.
+func Key(i int) (*ecdsa.PrivateKey, error) {
+	key, err := crypto.ToECDSA(crypto.Keccak256([]byte("telliot-testutil-" + strconv.Itoa(i))))
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating key:%v", i)
+	}
+	return key, nil
+}
+
+func (self *Chain) deploy() error {
+	admin, err := self.Opts(self.Accounts[0])
+	if err != nil {
+		return err
+	}
+	addr, _, instance, err := tellorAccess.DeployTellorAccess(admin, self)
+	if err != nil {
+		return errors.Wrap(err, "deploying TellorAccess")
+	}
+	self.TellorAccess, self.TellorAccessAddress = instance, addr
+
+	for _, account := range self.Accounts {
+		opts, err := self.Opts(self.Accounts[0])
+		if err != nil {
+			return err
+		}
+		if _, err := instance.AddReporter(opts, account.Address); err != nil {
+			return errors.Wrapf(err, "adding reporter:%v", account.Address.Hex())
+		}
+	}
+	return nil
+}
+
+// Opts returns the transaction options signed by the account.
+func (self *Chain) Opts(account *ethereum.Account) (*bind.TransactOpts, error) {
+	chainID, err := self.NetworkID(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	opts, err := bind.NewKeyedTransactorWithChainID(account.PrivateKey, chainID)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating transactor")
+	}
+	return opts, nil
+}
+
+// SubmitValue submits a value of a request ID to the TellorAccess oracle from the account.
+func (self *Chain) SubmitValue(account *ethereum.Account, requestID int64, value *big.Int) error {
+	opts, err := self.Opts(account)
+	if err != nil {
+		return err
+	}
+	if _, err := self.TellorAccess.SubmitValue(opts, big.NewInt(requestID), value); err != nil {
+		return errors.Wrapf(err, "submitting value request ID:%v", requestID)
+	}
+	return nil
+}
+
+// Advance moves the time of the chain forward with an empty block.
+func (self *Chain) Advance(d time.Duration) error {
+	if err := self.AdjustTime(d); err != nil {
+		return errors.Wrap(err, "adjusting the time")
+	}
+	self.Commit()
+	return nil
+}
+
+// SendTransaction sends and mines the transaction.
+func (self *Chain) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := self.SimulatedBackend.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	self.Commit()
+	return nil
+}
+
+func (self *Chain) Close() {
+	_ = self.SimulatedBackend.Close()
+}
+
+func (self *Chain) NonceAt(ctx context.Context, address common.Address) (uint64, error) {
+	return self.SimulatedBackend.NonceAt(ctx, address, nil)
+}
+
+func (self *Chain) IsSyncing(context.Context) (bool, error) {
+	return false, nil
+}
+
+func (self *Chain) NetworkID(context.Context) (*big.Int, error) {
+	return self.Blockchain().Config().ChainID, nil
+}
+
+// Transactor sends the transactions of an account and waits for their receipts
+// like the transactor of the submitters without its gas price and nonce management.
+type Transactor struct {
+	chain   *Chain
+	account *ethereum.Account
+}
+
+// Transactor returns the transactor of the account.
+func (self *Chain) Transactor(account *ethereum.Account) *Transactor {
+	return &Transactor{chain: self, account: account}
+}
+
+func (self *Transactor) Transact(ctx context.Context, f func(*bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, *types.Receipt, error) {
+	opts, err := self.chain.Opts(self.account)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts.Context = ctx
+	tx, err := f(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	receipt, err := bind.WaitMined(ctx, self.chain, tx)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "waiting for the receipt")
+	}
+	return tx, receipt, nil
+}
+
+func (self *Transactor) Send(ctx context.Context, tx *types.Transaction) (*types.Transaction, error) {
+	return tx, self.chain.SendTransaction(ctx, tx)
+}
@@ -0,0 +1,53 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package chain
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// TestChain ensures that the accounts are the same on every run
+// and that they can report to the deployed oracle.
+func TestChain(t *testing.T) {
+	ctx := context.Background()
+	chain, err := New(2)
+	testutil.Ok(t, err)
+	defer chain.Close()
+
+	other, err := New(2)
+	testutil.Ok(t, err)
+	defer other.Close()
+	testutil.Equals(t, chain.Accounts[1].Address, other.Accounts[1].Address)
+	testutil.Equals(t, chain.TellorAccessAddress, other.TellorAccessAddress)
+
+	opts := &bind.CallOpts{Context: ctx}
+	for _, account := range chain.Accounts {
+		isReporter, err := chain.TellorAccess.IsReporter(opts, account.Address)
+		testutil.Ok(t, err)
+		testutil.Assert(t, isReporter, "all accounts should be reporters")
+	}
+
+	testutil.Ok(t, chain.SubmitValue(chain.Accounts[1], 1, big.NewInt(2000e6)))
+	found, val, _, err := chain.TellorAccess.GetCurrentValue(opts, big.NewInt(1))
+	testutil.Ok(t, err)
+	testutil.Assert(t, found, "the submitted value should be found")
+	testutil.Equals(t, int64(2000e6), val.Int64())
+
+	nonce, err := chain.NonceAt(ctx, chain.Accounts[1].Address)
+	testutil.Ok(t, err)
+	testutil.Equals(t, uint64(1), nonce)
+
+	head, err := chain.HeaderByNumber(ctx, nil)
+	testutil.Ok(t, err)
+	testutil.Ok(t, chain.Advance(time.Hour))
+	next, err := chain.HeaderByNumber(ctx, nil)
+	testutil.Ok(t, err)
+	testutil.Assert(t, next.Time-head.Time >= 3600, "advance should move the time forward")
+}
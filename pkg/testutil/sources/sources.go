@@ -0,0 +1,128 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+// Package sources serves canned responses of price APIs for end-to-end tests
+// of the index tracker without external dependencies.
+package sources
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Param is the JSON path of the value in the responses.
+const Param = "$.price"
+
+// Server is a price API which responds with the set price of a symbol
+// e.g. {"price":2000} for /price?symbol=ETH/USD.
+type Server struct {
+	*httptest.Server
+	mtx      sync.Mutex
+	prices   map[string]float64
+	statuses map[string]int
+	requests map[string]int
+}
+
+// New starts a server, close it at the end of the test.
+func New() *Server {
+	self := &Server{
+		prices:   make(map[string]float64),
+		statuses: make(map[string]int),
+		requests: make(map[string]int),
+	}
+	self.Server = httptest.NewServer(http.HandlerFunc(self.serve))
+	return self
+}
+
+func (self *Server) serve(w http.ResponseWriter, r *http.Request) {
+	symbol := r.URL.Query().Get("symbol")
+
+	self.mtx.Lock()
+	self.requests[symbol]++
+	price, ok := self.prices[symbol]
+	status := self.statuses[symbol]
+	self.mtx.Unlock()
+
+	switch {
+	case status != 0:
+		w.WriteHeader(status)
+	case !ok:
+		http.NotFound(w, r)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"price":` + strconv.FormatFloat(price, 'f', -1, 64) + `}`))
+	}
+}
+
+// Set sets the price of the symbol and clears its failure status.
+func (self *Server) Set(symbol string, price float64) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	self.prices[symbol] = price
+	delete(self.statuses, symbol)
+}
+
+// Fail responds with the status code for the symbol until the next Set.
+func (self *Server) Fail(symbol string, status int) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	self.statuses[symbol] = status
+}
+
+// Requests returns the number of requests for the symbol.
+func (self *Server) Requests(symbol string) int {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	return self.requests[symbol]
+}
+
+// URL returns the url of the price of the symbol.
+func (self *Server) URL(symbol string) string {
+	return self.Server.URL + "/price?symbol=" + url.QueryEscape(symbol)
+}
+
+// Symbols lists the symbols with a price.
+func (self *Server) Symbols() []string {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	var symbols []string
+	for s := range self.prices {
+		symbols = append(symbols, s)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// WriteIndex writes an index file for the index tracker with an endpoint
+// on every server for each of their symbols.
+func WriteIndex(path string, servers ...*Server) error {
+	type endpoint struct {
+		URL   string
+		Param string
+	}
+	type api struct {
+		Endpoints []endpoint
+	}
+	index := make(map[string]*api)
+	for _, s := range servers {
+		for _, symbol := range s.Symbols() {
+			if index[symbol] == nil {
+				index[symbol] = &api{}
+			}
+			index[symbol].Endpoints = append(index[symbol].Endpoints, endpoint{URL: s.URL(symbol), Param: Param})
+		}
+	}
+	data, err := json.MarshalIndent(index, "", "    ")
+	if err != nil {
+		return errors.Wrap(err, "marshal index")
+	}
+	return errors.Wrap(ioutil.WriteFile(path, data, 0644), "write index file")
+}
@@ -0,0 +1,51 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package sources
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tellor-io/telliot/pkg/testutil"
+	"github.com/tellor-io/telliot/pkg/tracker/index"
+	"github.com/tellor-io/telliot/pkg/web"
+)
+
+// TestSources ensures that the index tracker gets the set prices
+// from the index file of the servers.
+func TestSources(t *testing.T) {
+	a, b := New(), New()
+	defer a.Close()
+	defer b.Close()
+	a.Set("ETH/USD", 2000)
+	b.Set("ETH/USD", 2010)
+	b.Set("TRB/USD", 50.5)
+	b.Fail("TRB/USD", http.StatusTooManyRequests)
+
+	dir, err := ioutil.TempDir("", "sources")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "index.json")
+	testutil.Ok(t, WriteIndex(path, a, b))
+
+	results, err := index.Probe(context.Background(), index.Config{IndexFile: path, Retry: web.RetryConfig{MaxAttempts: 1}}, nil, nil)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 3, len(results))
+	values := make(map[string]float64)
+	var failed int
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			continue
+		}
+		values[r.Source] = r.Value
+	}
+	testutil.Equals(t, 1, failed)
+	testutil.Equals(t, map[string]float64{a.URL("ETH/USD"): 2000, b.URL("ETH/USD"): 2010}, values)
+	testutil.Equals(t, 1, a.Requests("ETH/USD"))
+}
@@ -0,0 +1,421 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package tipper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/fsutil"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/submitter"
+	"github.com/tellor-io/telliot/pkg/transactor"
+)
+
+const ComponentName = "tipper"
+
+type Config struct {
+	Enabled  bool
+	LogLevel string
+	// RequestIDs are the data IDs to keep alive by adding tips to them.
+	RequestIDs []int64
+	// Amount is the tip in TRB added for a single request ID.
+	Amount float64
+	// Interval is how often to check whether the request IDs need a new tip.
+	Interval format.Duration
+	// MinInterval is the minimum time between two tips for the same request ID.
+	MinInterval format.Duration
+	// DailyCap is the maximum TRB amount spent on tips in a single UTC day.
+	DailyCap float64
+	// File keeps the amount spent in the day and the times of the last tips across restarts.
+	File string
+}
+
+// state is the spend window saved in the file.
+type state struct {
+	Day     time.Time
+	Spent   float64
+	LastTip map[int64]time.Time
+}
+
+// Tipper periodically adds tips for the configured request IDs
+// while staying within a daily TRB budget.
+type Tipper struct {
+	ctx        context.Context
+	close      context.CancelFunc
+	logger     log.Logger
+	cfg        Config
+	account    *ethereum.Account
+	client     contracts.ETHClient
+	contract   *contracts.ITellor
+	abi        *abi.ABI
+	transactor transactor.Transactor
+	gate       *submitter.Gate
+	now        func() time.Time
+
+	lastTip    map[int64]time.Time
+	spentDay   time.Time
+	spentToday float64
+	// tips are the current tips of the request IDs read at the start of each round.
+	tips map[int64]*big.Int
+
+	currentTip   *prometheus.GaugeVec
+	tipCount     *prometheus.CounterVec
+	tipFailCount *prometheus.CounterVec
+	tipAmount    *prometheus.CounterVec
+}
+
+func New(
+	ctx context.Context,
+	logger log.Logger,
+	cfg Config,
+	client contracts.ETHClient,
+	contract *contracts.ITellor,
+	account *ethereum.Account,
+	transactor transactor.Transactor,
+	gate *submitter.Gate,
+) (*Tipper, error) {
+	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	if cfg.Amount <= 0 {
+		return nil, errors.Errorf("invalid tip amount:%v", cfg.Amount)
+	}
+	if cfg.Amount > cfg.DailyCap {
+		return nil, errors.Errorf("tip amount:%v is bigger than the daily cap:%v", cfg.Amount, cfg.DailyCap)
+	}
+	if cfg.Interval.Duration <= 0 {
+		return nil, errors.Errorf("invalid tip interval:%v", cfg.Interval)
+	}
+	logger = log.With(logger, "component", ComponentName)
+	parsed, err := contracts.ParseABI(contracts.ITellorABI)
+	if err != nil {
+		return nil, err
+	}
+	ctx, close := context.WithCancel(ctx)
+
+	self := &Tipper{
+		ctx:        ctx,
+		close:      close,
+		logger:     logger,
+		cfg:        cfg,
+		account:    account,
+		client:     client,
+		contract:   contract,
+		abi:        parsed,
+		transactor: transactor,
+		gate:       gate,
+		now:        time.Now,
+		lastTip:    make(map[int64]time.Time),
+		currentTip: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   "telliot",
+			Subsystem:   ComponentName,
+			Name:        "current_tip",
+			Help:        "The current TRB tip of the request ID",
+			ConstLabels: prometheus.Labels{"account": account.Address.String()},
+		},
+			[]string{"id"},
+		),
+		tipCount: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   "telliot",
+			Subsystem:   ComponentName,
+			Name:        "tips_total",
+			Help:        "The total number of added tips",
+			ConstLabels: prometheus.Labels{"account": account.Address.String()},
+		},
+			[]string{"id"},
+		),
+		tipFailCount: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   "telliot",
+			Subsystem:   ComponentName,
+			Name:        "tips_fails_total",
+			Help:        "The total number of failed tips",
+			ConstLabels: prometheus.Labels{"account": account.Address.String()},
+		},
+			[]string{"id"},
+		),
+		tipAmount: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   "telliot",
+			Subsystem:   ComponentName,
+			Name:        "tips_amount_total",
+			Help:        "The total TRB amount spent on tips",
+			ConstLabels: prometheus.Labels{"account": account.Address.String()},
+		},
+			[]string{"id"},
+		),
+	}
+	if err := self.load(); err != nil {
+		close()
+		return nil, err
+	}
+	return self, nil
+}
+
+func (self *Tipper) Start() error {
+	level.Info(self.logger).Log(
+		"msg", "starting",
+		"ids", fmt.Sprint(self.cfg.RequestIDs),
+		"amount", self.cfg.Amount,
+		"dailyCap", self.cfg.DailyCap,
+	)
+
+	self.tipAll()
+
+	ticker := time.NewTicker(self.cfg.Interval.Duration)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-self.ctx.Done():
+			return nil
+		case <-ticker.C:
+			self.tipAll()
+		}
+	}
+}
+
+func (self *Tipper) Stop() {
+	self.close()
+}
+
+func (self *Tipper) tipAll() {
+	self.readTips()
+
+	// The transactors of the smart accounts send all tips in one operation.
+	if batcher, ok := self.transactor.(transactor.Batcher); ok {
+		if err := self.tipBatch(batcher); err != nil {
+			level.Error(self.logger).Log("msg", "adding tips", "err", err)
+		}
+		return
+	}
+	for _, reqID := range self.cfg.RequestIDs {
+		if err := self.tip(reqID); err != nil {
+			level.Error(self.logger).Log("msg", "adding tip", "reqID", reqID, "err", err)
+		}
+	}
+}
+
+// due reports whether the request ID needs a tip that fits in the budget.
+func (self *Tipper) due(reqID int64) bool {
+	// The tips are sent from a submitting account so they respect its gate.
+	if err := self.gate.Err(); err != nil {
+		level.Info(self.logger).Log("msg", "skipping tip", "reqID", reqID, "reason", err)
+		return false
+	}
+
+	now := self.now()
+	if last, ok := self.lastTip[reqID]; ok && now.Sub(last) < self.cfg.MinInterval.Duration {
+		level.Debug(self.logger).Log("msg", "skipping tip, min interval not reached", "reqID", reqID, "lastTip", now.Sub(last))
+		return false
+	}
+
+	// Another tipper already keeps it alive.
+	if tip, ok := self.tips[reqID]; ok && tip.Cmp(trbToWei(self.cfg.Amount)) >= 0 {
+		level.Debug(self.logger).Log("msg", "skipping tip, already tipped", "reqID", reqID, "tip", tip)
+		return false
+	}
+
+	if !self.withinBudget(now) {
+		level.Warn(self.logger).Log("msg", "skipping tip, daily cap reached", "reqID", reqID, "spentToday", self.spentToday, "dailyCap", self.cfg.DailyCap)
+		return false
+	}
+	return true
+}
+
+// readTips reads the current tips of all request IDs in a single batch.
+// The request IDs without a current tip are tipped as usual.
+func (self *Tipper) readTips() {
+	self.tips = make(map[int64]*big.Int)
+	calls := make([]*contracts.BatchCall, len(self.cfg.RequestIDs))
+	for i, reqID := range self.cfg.RequestIDs {
+		calls[i] = &contracts.BatchCall{
+			Contract: self.contract.Address,
+			ABI:      self.abi,
+			Method:   "getRequestVars",
+			Args:     []interface{}{big.NewInt(reqID)},
+		}
+	}
+	if err := contracts.CallBatch(self.ctx, self.client, nil, calls); err != nil {
+		level.Error(self.logger).Log("msg", "getting the current tips", "err", err)
+		return
+	}
+	for i, reqID := range self.cfg.RequestIDs {
+		if calls[i].Err != nil {
+			level.Error(self.logger).Log("msg", "getting the current tip", "reqID", reqID, "err", calls[i].Err)
+			continue
+		}
+		tip, ok := calls[i].Result[1].(*big.Int)
+		if !ok {
+			level.Error(self.logger).Log("msg", "unexpected tip type", "reqID", reqID, "type", fmt.Sprintf("%T", calls[i].Result[1]))
+			continue
+		}
+		self.tips[reqID] = tip
+		trb, _ := new(big.Float).Quo(new(big.Float).SetInt(tip), big.NewFloat(1e18)).Float64()
+		self.currentTip.With(prometheus.Labels{"id": strconv.FormatInt(reqID, 10)}).Set(trb)
+	}
+}
+
+func (self *Tipper) addTip(reqID int64) func(*bind.TransactOpts) (*types.Transaction, error) {
+	amount := trbToWei(self.cfg.Amount)
+	return func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return self.contract.AddTip(auth, big.NewInt(reqID), amount)
+	}
+}
+
+func (self *Tipper) tip(reqID int64) error {
+	if !self.due(reqID) {
+		return nil
+	}
+
+	ctx, cncl := context.WithTimeout(self.ctx, 5*time.Minute)
+	defer cncl()
+
+	tx, receipt, err := self.transactor.Transact(transactor.WithFunction(transactor.WithPriority(ctx, transactor.PriorityTip), transactor.FunctionAddTip), self.addTip(reqID))
+	if err != nil {
+		self.tipFailCount.With(prometheus.Labels{"id": strconv.FormatInt(reqID, 10)}).Inc()
+		return errors.Wrap(err, "sending the tip transaction")
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		self.tipFailCount.With(prometheus.Labels{"id": strconv.FormatInt(reqID, 10)}).Inc()
+		return errors.Errorf("tip transaction status not success status:%v, tx hash:%v", receipt.Status, tx.Hash())
+	}
+
+	self.tipped(reqID)
+	if err := self.save(); err != nil {
+		level.Error(self.logger).Log("msg", "saving the tips", "err", err)
+	}
+	level.Info(self.logger).Log(
+		"msg", "successfully added tip",
+		"reqID", reqID,
+		"amount", self.cfg.Amount,
+		"spentToday", self.spentToday,
+		"txHash", tx.Hash().String(),
+	)
+	return nil
+}
+
+// tipBatch adds the tips of all due request IDs with a single transaction.
+func (self *Tipper) tipBatch(batcher transactor.Batcher) error {
+	var (
+		ids   []int64
+		calls []func(*bind.TransactOpts) (*types.Transaction, error)
+	)
+	for _, reqID := range self.cfg.RequestIDs {
+		if !self.due(reqID) {
+			continue
+		}
+		// Reserved so that the whole batch stays within the daily cap.
+		self.spentToday += self.cfg.Amount
+		ids = append(ids, reqID)
+		calls = append(calls, self.addTip(reqID))
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	self.spentToday -= self.cfg.Amount * float64(len(ids))
+
+	ctx, cncl := context.WithTimeout(self.ctx, 5*time.Minute)
+	defer cncl()
+
+	tx, receipt, err := batcher.TransactBatch(transactor.WithFunction(transactor.WithPriority(ctx, transactor.PriorityTip), transactor.FunctionAddTip), calls...)
+	if err == nil && receipt.Status != types.ReceiptStatusSuccessful {
+		err = errors.Errorf("tips transaction status not success status:%v, tx hash:%v", receipt.Status, tx.Hash())
+	}
+	if err != nil {
+		for _, reqID := range ids {
+			self.tipFailCount.With(prometheus.Labels{"id": strconv.FormatInt(reqID, 10)}).Inc()
+		}
+		return errors.Wrap(err, "sending the tips transaction")
+	}
+
+	for _, reqID := range ids {
+		self.tipped(reqID)
+	}
+	if err := self.save(); err != nil {
+		level.Error(self.logger).Log("msg", "saving the tips", "err", err)
+	}
+	level.Info(self.logger).Log(
+		"msg", "successfully added tips",
+		"reqIDs", fmt.Sprint(ids),
+		"amount", self.cfg.Amount,
+		"spentToday", self.spentToday,
+		"txHash", tx.Hash().String(),
+	)
+	return nil
+}
+
+func (self *Tipper) tipped(reqID int64) {
+	self.lastTip[reqID] = self.now()
+	self.spentToday += self.cfg.Amount
+	self.tipCount.With(prometheus.Labels{"id": strconv.FormatInt(reqID, 10)}).Inc()
+	self.tipAmount.With(prometheus.Labels{"id": strconv.FormatInt(reqID, 10)}).Add(self.cfg.Amount)
+}
+
+// withinBudget reports whether another tip fits in the budget for the day of now.
+// The spent amount is reset when a new UTC day begins.
+func (self *Tipper) withinBudget(now time.Time) bool {
+	day := now.UTC().Truncate(24 * time.Hour)
+	if !day.Equal(self.spentDay) {
+		self.spentDay = day
+		self.spentToday = 0
+	}
+	return self.spentToday+self.cfg.Amount <= self.cfg.DailyCap
+}
+
+// load restores the spend window so that a restart doesn't reset the daily cap.
+func (self *Tipper) load() error {
+	if self.cfg.File == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(self.cfg.File)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "read tipper file path:%s", self.cfg.File)
+	}
+	var st state
+	if err := json.Unmarshal(data, &st); err != nil {
+		return errors.Wrap(err, "unmarshal tipper file")
+	}
+	self.spentDay = st.Day
+	self.spentToday = st.Spent
+	for reqID, last := range st.LastTip {
+		self.lastTip[reqID] = last
+	}
+	return nil
+}
+
+func (self *Tipper) save() error {
+	if self.cfg.File == "" {
+		return nil
+	}
+	data, err := json.Marshal(state{Day: self.spentDay, Spent: self.spentToday, LastTip: self.lastTip})
+	if err != nil {
+		return errors.Wrap(err, "marshal tipper state")
+	}
+	return errors.Wrap(fsutil.WriteAtomic(self.cfg.File, data, 0600), "write tipper file")
+}
+
+func trbToWei(amount float64) *big.Int {
+	wei, _ := new(big.Float).Mul(big.NewFloat(amount), big.NewFloat(1e18)).Int(nil)
+	return wei
+}
@@ -0,0 +1,203 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package tipper
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	eth "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/submitter"
+	"github.com/tellor-io/telliot/pkg/testutil"
+	"github.com/tellor-io/telliot/pkg/transactor"
+)
+
+// fakeTransactor mines every transaction with the status without calling the contract.
+type fakeTransactor struct {
+	status uint64
+	err    error
+	sent   int
+}
+
+func (self *fakeTransactor) Transact(context.Context, func(*bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, *types.Receipt, error) {
+	if self.err != nil {
+		return nil, nil, self.err
+	}
+	self.sent++
+	return types.NewTx(&types.LegacyTx{}), &types.Receipt{Status: self.status}, nil
+}
+
+func (self *fakeTransactor) Send(_ context.Context, tx *types.Transaction) (*types.Transaction, error) {
+	return tx, nil
+}
+
+// batcher records the number of calls of each batch.
+type batcher struct {
+	fakeTransactor
+	batches []int
+}
+
+func (self *batcher) TransactBatch(_ context.Context, calls ...func(*bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, *types.Receipt, error) {
+	self.batches = append(self.batches, len(calls))
+	return types.NewTx(&types.LegacyTx{}), &types.Receipt{Status: self.status}, nil
+}
+
+// tipsClient returns the current tip of each request ID.
+type tipsClient struct {
+	contracts.ETHClient
+	abi   *abi.ABI
+	tips  map[int64]int64
+	calls int
+}
+
+func newTipsClient(t *testing.T) *tipsClient {
+	parsed, err := contracts.ParseABI(contracts.ITellorABI)
+	testutil.Ok(t, err)
+	return &tipsClient{abi: parsed, tips: make(map[int64]int64)}
+}
+
+func (self *tipsClient) CallContract(_ context.Context, call eth.CallMsg, _ *big.Int) ([]byte, error) {
+	self.calls++
+	method := self.abi.Methods["getRequestVars"]
+	args, err := method.Inputs.Unpack(call.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+	return method.Outputs.Pack(big.NewInt(0), big.NewInt(self.tips[args[0].(*big.Int).Int64()]))
+}
+
+// The metrics have the account as a label so each tipper has its own account.
+func newTestTipper(t *testing.T, addr string, cfg Config, tr transactor.Transactor, now *time.Time) *Tipper {
+	return newTestTipperWithClient(t, addr, cfg, newTipsClient(t), tr, now)
+}
+
+func newTestTipperWithClient(t *testing.T, addr string, cfg Config, client contracts.ETHClient, tr transactor.Transactor, now *time.Time) *Tipper {
+	account := &ethereum.Account{Address: common.HexToAddress(addr)}
+	tipper, err := New(context.Background(), logging.NewLogger(), cfg, client, &contracts.ITellor{}, account, tr, submitter.NewGate(account.Address.String()))
+	testutil.Ok(t, err)
+	tipper.now = func() time.Time { return *now }
+	return tipper
+}
+
+func testConfig() Config {
+	return Config{
+		LogLevel:    "info",
+		RequestIDs:  []int64{1, 2, 3},
+		Amount:      1,
+		Interval:    format.Duration{Duration: time.Minute},
+		MinInterval: format.Duration{Duration: time.Hour},
+		DailyCap:    2,
+	}
+}
+
+// TestDailyCap ensures that the tips stop at the daily cap until the next UTC day
+// and that the failed tips don't count.
+func TestDailyCap(t *testing.T) {
+	now := time.Date(2021, 6, 1, 23, 0, 0, 0, time.UTC)
+	tr := &fakeTransactor{status: types.ReceiptStatusSuccessful}
+	tipper := newTestTipper(t, "0x1", testConfig(), tr, &now)
+
+	tr.err = errors.New("nonce too low")
+	tipper.tipAll()
+	testutil.Equals(t, float64(0), tipper.spentToday)
+
+	tr.err = nil
+	tipper.tipAll()
+	testutil.Equals(t, 2, tr.sent)
+	testutil.Equals(t, float64(2), tipper.spentToday)
+
+	// The next day.
+	now = now.Add(90 * time.Minute)
+	tipper.tipAll()
+	testutil.Equals(t, 4, tr.sent)
+	testutil.Equals(t, float64(2), tipper.spentToday)
+	testutil.Equals(t, now, tipper.lastTip[2])
+
+	// Within the min interval and the cap is reached.
+	now = now.Add(time.Minute)
+	tipper.tipAll()
+	testutil.Equals(t, 4, tr.sent)
+}
+
+// TestRestart ensures that a restarted tipper keeps the spend window of the day.
+func TestRestart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tipper")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+	cfg := testConfig()
+	cfg.File = filepath.Join(dir, "tipper.json")
+	cfg.MinInterval = format.Duration{}
+
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	tr := &fakeTransactor{status: types.ReceiptStatusSuccessful}
+	tipper := newTestTipper(t, "0x2", cfg, tr, &now)
+	tipper.tipAll()
+	testutil.Equals(t, 2, tr.sent)
+
+	restarted := newTestTipper(t, "0x3", cfg, tr, &now)
+	testutil.Equals(t, float64(2), restarted.spentToday)
+	testutil.Equals(t, now, restarted.lastTip[1])
+	restarted.tipAll()
+	testutil.Equals(t, 2, tr.sent)
+
+	now = now.Add(24 * time.Hour)
+	restarted.tipAll()
+	testutil.Equals(t, 4, tr.sent)
+
+	testutil.Ok(t, ioutil.WriteFile(cfg.File, []byte("{"), 0666))
+	account := &ethereum.Account{Address: common.HexToAddress("0x4")}
+	_, err = New(context.Background(), logging.NewLogger(), cfg, nil, nil, account, tr, submitter.NewGate(account.Address.String()))
+	testutil.NotOk(t, err, "a corrupted tipper file")
+}
+
+// TestTipBatch ensures that a batch only includes the tips within the daily cap.
+func TestTipBatch(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	b := &batcher{fakeTransactor: fakeTransactor{status: types.ReceiptStatusSuccessful}}
+	tipper := newTestTipper(t, "0x5", testConfig(), b, &now)
+
+	tipper.tipAll()
+	testutil.Equals(t, []int{2}, b.batches)
+	testutil.Equals(t, float64(2), tipper.spentToday)
+
+	b.status = types.ReceiptStatusFailed
+	now = now.Add(24 * time.Hour)
+	tipper.tipAll()
+	testutil.Equals(t, []int{2, 2}, b.batches)
+	testutil.Equals(t, float64(0), tipper.spentToday)
+}
+
+// TestCurrentTips ensures that the current tips are read in a single batch
+// and that the request IDs which already have a big enough tip are skipped.
+func TestCurrentTips(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	client := newTipsClient(t)
+	client.tips[1] = 1e18
+	client.tips[2] = 1e17
+	b := &batcher{fakeTransactor: fakeTransactor{status: types.ReceiptStatusSuccessful}}
+	cfg := testConfig()
+	cfg.DailyCap = 10
+	tipper := newTestTipperWithClient(t, "0x6", cfg, client, b, &now)
+
+	tipper.tipAll()
+	testutil.Equals(t, 3, client.calls)
+	testutil.Equals(t, []int{2}, b.batches)
+	_, ok := tipper.lastTip[1]
+	testutil.Assert(t, !ok, "a request ID with a big enough tip was tipped")
+	testutil.Equals(t, now, tipper.lastTip[2])
+}
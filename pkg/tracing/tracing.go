@@ -0,0 +1,101 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package tracing
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const ComponentName = "tracing"
+
+type Config struct {
+	LogLevel string
+	Enabled  bool
+	// Endpoint is the host:port of the OTLP HTTP receiver.
+	Endpoint string
+	// Insecure disables TLS when connecting to the receiver.
+	Insecure bool
+	// SampleRatio is the fraction of the traces that are exported.
+	SampleRatio float64
+}
+
+// Tracer creates the spans for all components.
+// It is a no-op until tracing is enabled with New.
+var Tracer = otel.Tracer("github.com/tellor-io/telliot")
+
+// Tracing exports the spans from all components via OTLP.
+type Tracing struct {
+	logger   log.Logger
+	provider *sdktrace.TracerProvider
+}
+
+func New(ctx context.Context, logger log.Logger, cfg Config) (*Tracing, error) {
+	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	logger = log.With(logger, "component", ComponentName)
+
+	if cfg.SampleRatio <= 0 || cfg.SampleRatio > 1 {
+		return nil, errors.Errorf("sample ratio should be between 0 and 1:%v", cfg.SampleRatio)
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating the OTLP exporter")
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceNameKey.String("telliot"),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		level.Error(logger).Log("msg", "exporting spans", "err", err)
+	}))
+
+	level.Info(logger).Log("msg", "exporting traces", "endpoint", cfg.Endpoint, "sampleRatio", cfg.SampleRatio)
+
+	return &Tracing{
+		logger:   logger,
+		provider: provider,
+	}, nil
+}
+
+// Shutdown flushes all pending spans.
+func (self *Tracing) Shutdown(ctx context.Context) error {
+	return self.provider.Shutdown(ctx)
+}
+
+// Start creates a span that is a child of the span in the context.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// Error marks the span as failed.
+func Error(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
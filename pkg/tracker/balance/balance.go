@@ -0,0 +1,256 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package balance
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/notify"
+	"github.com/tellor-io/telliot/pkg/submitter"
+	"github.com/tellor-io/telliot/pkg/tracker/gasPrice"
+	"github.com/tellor-io/telliot/pkg/tuning"
+	"github.com/tellor-io/telliot/pkg/watchdog"
+)
+
+const ComponentName = "balanceTracker"
+
+type Config struct {
+	Enabled  bool
+	LogLevel string
+	Interval format.Duration
+	// MinSubmissions is how many submissions the ETH balance should cover.
+	// A notification is sent when the balance falls below that.
+	MinSubmissions uint
+	// GasPerSubmission is the estimated gas used by a single submission.
+	GasPerSubmission uint64
+	// AutoPause pauses the submitter while the ETH balance can't cover a single submission.
+	AutoPause bool
+}
+
+// Tracker monitors the ETH and TRB balances of the submitting accounts.
+type Tracker struct {
+	ctx             context.Context
+	close           context.CancelFunc
+	logger          log.Logger
+	watchdog        *watchdog.Watchdog
+	cfg             Config
+	interval        *tuning.Duration
+	client          contracts.ETHClient
+	contract        *contracts.ITellor
+	abi             *abi.ABI
+	gasPriceTracker *gasPrice.GasTracker
+	accounts        []*ethereum.Account
+	gates           map[string]*submitter.Gate
+	notifier        notify.Notifier
+
+	// low holds the accounts for which a low balance notification was sent.
+	low map[string]bool
+
+	balance         *prometheus.GaugeVec
+	submissionsLeft *prometheus.GaugeVec
+}
+
+func New(
+	logger log.Logger,
+	ctx context.Context,
+	cfg Config,
+	client contracts.ETHClient,
+	contract *contracts.ITellor,
+	gasPriceTracker *gasPrice.GasTracker,
+	accounts []*ethereum.Account,
+	gates map[string]*submitter.Gate,
+	notifier notify.Notifier,
+	watchdog *watchdog.Watchdog,
+) (*Tracker, error) {
+	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	if cfg.GasPerSubmission == 0 {
+		return nil, errors.New("gas per submission should be more than 0")
+	}
+	logger = log.With(logger, "component", ComponentName)
+	parsed, err := contracts.ParseABI(contracts.ITellorABI)
+	if err != nil {
+		return nil, err
+	}
+	ctx, close := context.WithCancel(ctx)
+
+	return &Tracker{
+		ctx:             ctx,
+		close:           close,
+		watchdog:        watchdog,
+		interval:        tuning.NewDuration(ComponentName+".interval", "How often the balances of the accounts are checked.", cfg.Interval.Duration),
+		logger:          logger,
+		cfg:             cfg,
+		client:          client,
+		contract:        contract,
+		abi:             parsed,
+		gasPriceTracker: gasPriceTracker,
+		accounts:        accounts,
+		gates:           gates,
+		notifier:        notifier,
+		low:             make(map[string]bool),
+		balance: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "balance",
+			Help:      "The current balance of the account",
+		},
+			[]string{"addr", "token"},
+		),
+		submissionsLeft: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "submissions_left",
+			Help:      "How many submissions the ETH balance can cover at the current gas price",
+		},
+			[]string{"addr"},
+		),
+	}, nil
+}
+
+func (self *Tracker) Start() error {
+	level.Info(self.logger).Log("msg", "starting", "minSubmissions", self.cfg.MinSubmissions, "autoPause", self.cfg.AutoPause)
+
+	ticker := time.NewTicker(self.interval.Get())
+	defer ticker.Stop()
+	for {
+		stop := self.watchdog.Watch(ComponentName)
+		self.checkAll()
+		stop()
+		select {
+		case <-self.ctx.Done():
+			return nil
+		case <-ticker.C:
+			// Applies the interval when it was changed.
+			ticker.Reset(self.interval.Get())
+		}
+	}
+}
+
+func (self *Tracker) Stop() {
+	self.close()
+}
+
+func (self *Tracker) checkAll() {
+	gasPrice, err := self.gasPriceTracker.Query(self.ctx)
+	if err != nil {
+		level.Error(self.logger).Log("msg", "getting gas price", "err", err)
+		return
+	}
+	submitCost := new(big.Int).Mul(big.NewInt(gasPrice), new(big.Int).SetUint64(self.cfg.GasPerSubmission))
+
+	calls := make([]*contracts.BatchCall, len(self.accounts))
+	for i, account := range self.accounts {
+		calls[i] = &contracts.BatchCall{
+			Contract: self.contract.Address,
+			ABI:      self.abi,
+			Method:   "balanceOf",
+			Args:     []interface{}{account.Address},
+		}
+	}
+	if err := contracts.CallBatch(self.ctx, self.client, nil, calls); err != nil {
+		level.Error(self.logger).Log("msg", "getting TRB balances", "err", err)
+		return
+	}
+
+	for i, account := range self.accounts {
+		if err := self.check(account, calls[i], submitCost); err != nil {
+			level.Error(self.logger).Log("msg", "checking balance", "addr", account.Address.String(), "err", err)
+		}
+	}
+}
+
+func (self *Tracker) check(account *ethereum.Account, trbCall *contracts.BatchCall, submitCost *big.Int) error {
+	addr := account.Address.String()
+	logger := log.With(self.logger, "addr", addr)
+
+	if trbCall.Err != nil {
+		return errors.Wrap(trbCall.Err, "getting TRB balance")
+	}
+	trb, ok := trbCall.Result[0].(*big.Int)
+	if !ok {
+		return errors.Errorf("unexpected TRB balance type:%T", trbCall.Result[0])
+	}
+	self.balance.With(prometheus.Labels{"addr": addr, "token": "TRB"}).Set(weiToFloat(trb))
+
+	eth, err := self.client.BalanceAt(self.ctx, account.Address, nil)
+	if err != nil {
+		return errors.Wrap(err, "getting ETH balance")
+	}
+	self.balance.With(prometheus.Labels{"addr": addr, "token": "ETH"}).Set(weiToFloat(eth))
+
+	var submissionsLeft int64
+	if submitCost.Sign() > 0 {
+		submissionsLeft = new(big.Int).Div(eth, submitCost).Int64()
+	}
+	self.submissionsLeft.With(prometheus.Labels{"addr": addr}).Set(float64(submissionsLeft))
+	level.Debug(logger).Log("msg", "balance", "ETH", weiToFloat(eth), "TRB", weiToFloat(trb), "submissionsLeft", submissionsLeft)
+
+	if submissionsLeft < int64(self.cfg.MinSubmissions) {
+		if !self.low[addr] {
+			self.low[addr] = true
+			self.notify(logger, addr, notify.SeverityWarning, notify.EventLowBalance,
+				fmt.Sprintf("Low ETH balance for %v", addr),
+				fmt.Sprintf("The balance of %v ETH covers only %v submissions at the current gas price.", weiToFloat(eth), submissionsLeft),
+			)
+		}
+	} else if self.low[addr] {
+		self.low[addr] = false
+		self.notify(logger, addr, notify.SeverityInfo, "low_balance_resolved",
+			fmt.Sprintf("ETH balance for %v is back to normal", addr),
+			fmt.Sprintf("The balance of %v ETH covers %v submissions at the current gas price.", weiToFloat(eth), submissionsLeft),
+		)
+	}
+
+	gate, ok := self.gates[addr]
+	if !self.cfg.AutoPause || !ok {
+		return nil
+	}
+	if submissionsLeft < 1 {
+		if gate.Pause(ComponentName, "the ETH balance can't cover the gas for a single submission") {
+			self.notify(logger, addr, notify.SeverityCritical, notify.EventSubmitterPaused,
+				fmt.Sprintf("Submitting paused for %v", addr),
+				fmt.Sprintf("The balance of %v ETH can't cover the gas for a single submission. Submitting continues after a top up.", weiToFloat(eth)),
+			)
+		}
+	} else if gate.Resume(ComponentName) {
+		self.notify(logger, addr, notify.SeverityInfo, notify.EventSubmitterResumed,
+			fmt.Sprintf("Submitting resumed for %v", addr),
+			fmt.Sprintf("The balance of %v ETH covers %v submissions.", weiToFloat(eth), submissionsLeft),
+		)
+	}
+	return nil
+}
+
+func (self *Tracker) notify(logger log.Logger, addr string, severity notify.Severity, event, title, body string) {
+	if err := self.notifier.Notify(self.ctx, notify.Message{
+		Event:    event,
+		Severity: severity,
+		Title:    title,
+		Body:     body,
+		Data:     map[string]string{"account": addr},
+	}); err != nil {
+		level.Error(logger).Log("msg", "sending notification", "event", event, "err", err)
+	}
+}
+
+func weiToFloat(wei *big.Int) float64 {
+	f, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e18)).Float64()
+	return f
+}
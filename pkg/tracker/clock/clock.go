@@ -0,0 +1,221 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package clock
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/health"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/tuning"
+	"github.com/tellor-io/telliot/pkg/watchdog"
+)
+
+const ComponentName = "clockTracker"
+
+type Config struct {
+	LogLevel string
+	// Interval is how often to check for a new block.
+	Interval format.Duration
+	// Samples is how many of the recent blocks the offset is estimated from.
+	Samples int
+	// MaxSkew is the offset from the block timestamps above which the local clock is considered skewed.
+	MaxSkew format.Duration
+	// Correct applies the offset of a skewed clock to the times compared with the chain time
+	// like the submit window and the time of the submitted values.
+	Correct bool
+}
+
+// Tracker estimates the offset of the local clock from the block timestamps.
+// A block is seen some time after its timestamp so each new block gives
+// an upper bound of the local time and like NTP the sample with the smallest delay is used,
+// which is the biggest offset of the recent blocks.
+// A nil Tracker is valid and returns the local time.
+type Tracker struct {
+	ctx      context.Context
+	close    context.CancelFunc
+	logger   log.Logger
+	watchdog *watchdog.Watchdog
+	cfg      Config
+	interval *tuning.Duration
+	client   contracts.ETHClient
+
+	mtx        sync.Mutex
+	lastBlock  *big.Int
+	samples    []time.Duration
+	offset     time.Duration
+	correction time.Duration
+	skewed     bool
+
+	offsetGauge     prometheus.Gauge
+	correctionGauge prometheus.Gauge
+	skewedGauge     prometheus.Gauge
+}
+
+func New(
+	logger log.Logger,
+	ctx context.Context,
+	cfg Config,
+	client contracts.ETHClient,
+	watchdog *watchdog.Watchdog,
+) (*Tracker, error) {
+	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	if cfg.Interval.Duration <= 0 {
+		return nil, errors.Errorf("invalid interval:%v", cfg.Interval)
+	}
+	if cfg.Samples <= 0 {
+		return nil, errors.Errorf("invalid number of samples:%v", cfg.Samples)
+	}
+	ctx, close := context.WithCancel(ctx)
+	return &Tracker{
+		ctx:      ctx,
+		close:    close,
+		watchdog: watchdog,
+		interval: tuning.NewDuration(ComponentName+".interval", "How often the chain time is compared with the local time.", cfg.Interval.Duration),
+		logger:   log.With(logger, "component", ComponentName),
+		cfg:      cfg,
+		client:   client,
+		offsetGauge: promauto.NewGauge(prometheus.GaugeOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "offset_seconds",
+			Help:      "The estimated offset of the block timestamps from the local clock",
+		}),
+		correctionGauge: promauto.NewGauge(prometheus.GaugeOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "correction_seconds",
+			Help:      "The correction added to the local time when comparing it with the chain time",
+		}),
+		skewedGauge: promauto.NewGauge(prometheus.GaugeOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "skewed",
+			Help:      "1 when the offset of the local clock is above the max skew",
+		}),
+	}, nil
+}
+
+func (self *Tracker) Start() error {
+	level.Info(self.logger).Log("msg", "starting", "maxSkew", self.cfg.MaxSkew, "correct", self.cfg.Correct)
+
+	ticker := time.NewTicker(self.interval.Get())
+	defer ticker.Stop()
+	for {
+		stop := self.watchdog.Watch(ComponentName)
+		if err := self.poll(); err != nil {
+			level.Error(self.logger).Log("msg", "checking the latest block", "err", err)
+		}
+		stop()
+		select {
+		case <-self.ctx.Done():
+			return nil
+		case <-ticker.C:
+			// Applies the interval when it was changed.
+			ticker.Reset(self.interval.Get())
+		}
+	}
+}
+
+func (self *Tracker) Stop() {
+	self.close()
+}
+
+func (self *Tracker) poll() error {
+	ctx, cncl := context.WithTimeout(self.ctx, self.interval.Get())
+	defer cncl()
+	sent := time.Now()
+	header, err := self.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "getting the latest header")
+	}
+	received := time.Now()
+
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	if self.lastBlock != nil && header.Number.Cmp(self.lastBlock) <= 0 {
+		return nil
+	}
+	self.lastBlock = header.Number
+	// The middle of the round trip like NTP.
+	seen := sent.Add(received.Sub(sent) / 2)
+	self.add(time.Unix(int64(header.Time), 0).Sub(seen))
+	return nil
+}
+
+// add records the offset of a new block and updates the correction.
+func (self *Tracker) add(sample time.Duration) {
+	self.samples = append(self.samples, sample)
+	if len(self.samples) > self.cfg.Samples {
+		self.samples = self.samples[len(self.samples)-self.cfg.Samples:]
+	}
+	self.offset = self.samples[0]
+	for _, s := range self.samples[1:] {
+		if s > self.offset {
+			self.offset = s
+		}
+	}
+	self.offsetGauge.Set(self.offset.Seconds())
+
+	skewed := self.offset > self.cfg.MaxSkew.Duration || self.offset < -self.cfg.MaxSkew.Duration
+	switch {
+	case skewed && !self.skewed:
+		level.Warn(self.logger).Log(
+			"msg", "the local clock is off from the block timestamps, check the time sync of the host",
+			"offset", self.offset,
+			"maxSkew", self.cfg.MaxSkew,
+			"correct", self.cfg.Correct,
+		)
+	case !skewed && self.skewed:
+		level.Info(self.logger).Log("msg", "the local clock is back in sync with the block timestamps", "offset", self.offset)
+	}
+	self.skewed = skewed
+
+	self.correction = 0
+	if skewed && self.cfg.Correct {
+		self.correction = self.offset
+	}
+	self.correctionGauge.Set(self.correction.Seconds())
+	if skewed {
+		self.skewedGauge.Set(1)
+	} else {
+		self.skewedGauge.Set(0)
+	}
+}
+
+// Now returns the local time corrected with the offset from the chain time.
+func (self *Tracker) Now() time.Time {
+	if self == nil {
+		return time.Now()
+	}
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	return time.Now().Add(self.correction)
+}
+
+// Health reports a skewed clock which isn't corrected as degraded.
+func (self *Tracker) Health(context.Context) []health.Status {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	status := health.Status{Name: ComponentName, Healthy: true, Ready: true}
+	if self.skewed {
+		status.Message = fmt.Sprintf("clock offset:%v correction:%v", self.offset, self.correction)
+		status.Healthy = self.cfg.Correct
+	}
+	return []health.Status{status}
+}
@@ -0,0 +1,73 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+func newTestTracker(cfg Config) *Tracker {
+	return &Tracker{
+		logger:          log.NewNopLogger(),
+		cfg:             cfg,
+		offsetGauge:     prometheus.NewGauge(prometheus.GaugeOpts{Name: "offset"}),
+		correctionGauge: prometheus.NewGauge(prometheus.GaugeOpts{Name: "correction"}),
+		skewedGauge:     prometheus.NewGauge(prometheus.GaugeOpts{Name: "skewed"}),
+	}
+}
+
+// TestAdd ensures that the offset is the biggest of the recent samples
+// and that only a skewed offset is corrected.
+func TestAdd(t *testing.T) {
+	tracker := newTestTracker(Config{Samples: 3, MaxSkew: format.Duration{Duration: 5 * time.Second}, Correct: true})
+
+	// The blocks seen late give a smaller offset.
+	tracker.add(-3 * time.Second)
+	tracker.add(-1 * time.Second)
+	tracker.add(-2 * time.Second)
+	testutil.Equals(t, -1*time.Second, tracker.offset)
+	testutil.Assert(t, !tracker.skewed, "an offset within the max skew reported as skewed")
+	testutil.Equals(t, time.Duration(0), tracker.correction)
+
+	// A local clock behind the chain.
+	tracker.add(10 * time.Second)
+	testutil.Equals(t, 10*time.Second, tracker.offset)
+	testutil.Assert(t, tracker.skewed, "an offset above the max skew not reported as skewed")
+	testutil.Equals(t, 10*time.Second, tracker.correction)
+	now := tracker.Now()
+	testutil.Assert(t, now.Sub(time.Now()) > 9*time.Second, "the correction isn't applied to the time:%v", now)
+
+	// The old samples drop out of the window.
+	tracker.add(-2 * time.Second)
+	tracker.add(-2 * time.Second)
+	testutil.Equals(t, 10*time.Second, tracker.offset)
+	tracker.add(-2 * time.Second)
+	testutil.Equals(t, 3, len(tracker.samples))
+	testutil.Equals(t, -2*time.Second, tracker.offset)
+	testutil.Assert(t, !tracker.skewed, "the clock back in sync reported as skewed")
+	testutil.Equals(t, time.Duration(0), tracker.correction)
+
+	// A local clock ahead of the chain.
+	for i := 0; i < 3; i++ {
+		tracker.add(-8 * time.Second)
+	}
+	testutil.Assert(t, tracker.skewed, "a negative offset above the max skew not reported as skewed")
+	testutil.Equals(t, -8*time.Second, tracker.correction)
+}
+
+// TestAddNoCorrect ensures that a skewed clock isn't corrected unless enabled.
+func TestAddNoCorrect(t *testing.T) {
+	tracker := newTestTracker(Config{Samples: 3, MaxSkew: format.Duration{Duration: 5 * time.Second}})
+	tracker.add(time.Minute)
+	testutil.Assert(t, tracker.skewed, "an offset above the max skew not reported as skewed")
+	testutil.Equals(t, time.Duration(0), tracker.correction)
+	now := tracker.Now()
+	testutil.Assert(t, now.Sub(time.Now()) < time.Second, "a correction applied when disabled:%v", now)
+}
@@ -5,30 +5,69 @@ package dispute
 
 import (
 	"context"
+	"math/big"
 	"sort"
 	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/prometheus/pkg/labels"
 	"github.com/prometheus/prometheus/pkg/timestamp"
-	"github.com/prometheus/prometheus/tsdb"
 	"github.com/tellor-io/telliot/pkg/contracts"
 	"github.com/tellor-io/telliot/pkg/contracts/tellor"
+	"github.com/tellor-io/telliot/pkg/db"
+	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/health"
 	"github.com/tellor-io/telliot/pkg/logging"
-	psrTellor "github.com/tellor-io/telliot/pkg/psr/tellor"
+	"github.com/tellor-io/telliot/pkg/psr"
+	"github.com/tellor-io/telliot/pkg/registry"
+	"github.com/tellor-io/telliot/pkg/tracker/clock"
 )
 
 const ComponentName = "disputeTracker"
 
 const reorgEventWait = 3 * time.Minute
 
+// appendedTTL is how long the appended events are remembered to skip the ones replayed after a re-subscription.
+const appendedTTL = 24 * time.Hour
+
 type Config struct {
 	LogLevel string
+	// PendingPath is the file that keeps the events waiting for any re-org events across restarts, empty disables it.
+	// It follows Db.Path unless set explicitly.
+	PendingPath string
+	Storm       StormConfig
+}
+
+// eventKey identifies a single event as a transaction can emit multiple submit events.
+type eventKey struct {
+	tx    common.Hash
+	index uint
+}
+
+func keyOf(event *tellor.TellorNonceSubmitted) eventKey {
+	return eventKey{tx: event.Raw.TxHash, index: event.Raw.Index}
+}
+
+// pendingEvent is an event waiting for any re-org events that can cancel its append.
+// appendedEvent is the time an event was appended, in the order of the appends.
+type appendedEvent struct {
+	key eventKey
+	at  time.Time
+}
+
+type pendingEvent struct {
+	cancel   context.CancelFunc
+	block    uint64
+	event    *tellor.TellorNonceSubmitted
+	received time.Time
 }
 
 type Dispute struct {
@@ -36,22 +75,44 @@ type Dispute struct {
 	ctx           context.Context
 	close         context.CancelFunc
 	cfg           Config
-	tsDB          *tsdb.DB
+	tsDB          *db.DB
 	client        contracts.ETHClient
 	contract      *contracts.ITellor
-	pendingAppend map[string]context.CancelFunc
+	pendingAppend map[eventKey]*pendingEvent
+	appended      map[eventKey]time.Time
+	// appendedOrder expires the appended events oldest first.
+	appendedOrder []appendedEvent
+	store         *pendingStore
 	mtx           sync.Mutex
-	psrTellor     *psrTellor.Psr
+	// The reorg storm state is only used by the events loop.
+	removals      []time.Time
+	storm         *storm
+	maxBlock      uint64
+	psrTellor     psr.Getter
+	registry      *registry.Registry
+	verifier      *ethereum.Verifier
+	clock         *clock.Tracker
+	lastEvent     health.Timestamp
+	reconnects    prometheus.Counter
+	dbAppendFails prometheus.Counter
+	outOfBounds   *prometheus.CounterVec
+	duplicates    prometheus.Counter
+	reorgDepth    prometheus.Histogram
+	storms        prometheus.Counter
+	protective    prometheus.Gauge
 }
 
 func New(
 	logger log.Logger,
 	ctx context.Context,
 	cfg Config,
-	tsDB *tsdb.DB,
+	tsDB *db.DB,
 	client contracts.ETHClient,
 	contract *contracts.ITellor,
-	psrTellor *psrTellor.Psr,
+	psrTellor psr.Getter,
+	registry *registry.Registry,
+	verifier *ethereum.Verifier,
+	clock *clock.Tracker,
 ) (*Dispute, error) {
 	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
 	if err != nil {
@@ -64,16 +125,66 @@ func New(
 		client:        client,
 		contract:      contract,
 		psrTellor:     psrTellor,
+		registry:      registry,
+		verifier:      verifier,
+		clock:         clock,
 		cfg:           cfg,
 		ctx:           ctx,
 		close:         close,
 		tsDB:          tsDB,
 		logger:        logger,
-		pendingAppend: make(map[string]context.CancelFunc),
+		pendingAppend: make(map[eventKey]*pendingEvent),
+		appended:      make(map[eventKey]time.Time),
+		store:         newPendingStore(logger, cfg.PendingPath),
+		reconnects: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "subscription_reconnects_total",
+			Help:      "The total number of event re-subscriptions",
+		}),
+		dbAppendFails: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "db_append_fails_total",
+			Help:      "The total number of failed appends to the DB",
+		}),
+		outOfBounds: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "out_of_bounds_total",
+			Help:      "The total number of submitted values outside the request ID registry bounds",
+		},
+			[]string{"id"},
+		),
+		duplicates: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "duplicate_events_total",
+			Help:      "The total number of skipped events that were already pending or appended",
+		}),
+		reorgDepth: promauto.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "reorg_depth_blocks",
+			Help:      "The depth of the removed events from the latest event block",
+			Buckets:   []float64{1, 2, 3, 5, 8, 13, 21, 34, 64},
+		}),
+		storms: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "reorg_storms_total",
+			Help:      "The total number of reorg storms that started the protective mode",
+		}),
+		protective: promauto.NewGauge(prometheus.GaugeOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "protective_mode",
+			Help:      "Whether the events are ignored until the chain settles after a reorg storm",
+		}),
 	}, nil
 }
 
-func (self *Dispute) Start() {
+func (self *Dispute) Start() error {
 	var err error
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
@@ -83,10 +194,18 @@ func (self *Dispute) Start() {
 	var sub event.Subscription
 	events := make(chan *tellor.TellorNonceSubmitted)
 
+	if err := self.restorePending(logger); err != nil {
+		level.Error(logger).Log("msg", "restoring the pending events", "err", err)
+	}
+	go self.store.run(self.ctx, self.pendingRecords)
+
+	stormTicker := time.NewTicker(stormCheckInterval)
+	defer stormTicker.Stop()
+
 	for {
 		select {
 		case <-self.ctx.Done():
-			return
+			return nil
 		default:
 		}
 		sub, err = self.newSubTellor(events)
@@ -101,7 +220,7 @@ func (self *Dispute) Start() {
 	for {
 		select {
 		case <-self.ctx.Done():
-			return
+			return nil
 		case err := <-sub.Err():
 			if err != nil {
 				level.Error(logger).Log(
@@ -114,7 +233,7 @@ func (self *Dispute) Start() {
 			for {
 				select {
 				case <-self.ctx.Done():
-					return
+					return nil
 				default:
 				}
 				sub, err = self.newSubTellor(events)
@@ -125,41 +244,128 @@ func (self *Dispute) Start() {
 				}
 				break
 			}
+			self.reconnects.Inc()
 			level.Info(logger).Log("msg", "re-subscribed to events")
+		case <-stormTicker.C:
+			if self.storm == nil {
+				continue
+			}
+			if err := self.resyncStorm(); err != nil {
+				level.Error(logger).Log("msg", "pulling the confirmed events after the reorg storm", "err", err)
+			}
 		case event := <-events:
+			self.lastEvent.Set(time.Now())
 			level.Debug(self.logger).Log(
 				"msg", "new event",
 				"removed", event.Raw.Removed,
 				"hash", event.Raw.TxHash.String()[:8],
 				"miner", event.Miner.String()[:8],
 			)
+			if event.Raw.BlockNumber > self.maxBlock {
+				self.maxBlock = event.Raw.BlockNumber
+			}
+			if event.Raw.Removed && self.observeRemoval(event) && self.storm == nil {
+				self.enterStorm(event)
+			}
+			if self.storm != nil {
+				self.storm.extend(event)
+				continue
+			}
 			if event.Raw.Removed {
 				self.removePending(event)
+				continue
 			}
 
-			ctx, cncl := context.WithCancel(self.ctx)
-			self.mtx.Lock()
-			self.pendingAppend[event.Raw.TxHash.String()] = cncl
-			self.mtx.Unlock()
+			self.schedule(logger, event, time.Now())
+		}
+	}
+}
 
-			go func(ctx context.Context) {
-				ticker := time.NewTicker(reorgEventWait) // Wait this long for any re-org events that can cancel this append.
-				defer ticker.Stop()
+// schedule appends the event after the re-org wait since it was received
+// unless a re-org event cancels it.
+func (self *Dispute) schedule(logger log.Logger, event *tellor.TellorNonceSubmitted, received time.Time) {
+	ctx, cncl := context.WithCancel(self.ctx)
+	pending := &pendingEvent{cancel: cncl, block: event.Raw.BlockNumber, event: event, received: received}
+	if !self.addPending(event, pending) {
+		cncl()
+		self.duplicates.Inc()
+		level.Debug(self.logger).Log("msg", "skipping duplicate event", "hash", event.Raw.TxHash.String()[:8], "index", event.Raw.Index)
+		return
+	}
 
-				select {
-				case <-ticker.C:
-					if err := self.addValTellor(event); err != nil {
-						level.Error(logger).Log(
-							"msg", "adding value",
-							"err", err,
-						)
-					}
-					self.removePending(event)
-				case <-ctx.Done():
-					level.Debug(self.logger).Log("msg", "append canceled", "hash", event.Raw.TxHash.String()[:8])
-					return
-				}
-			}(ctx)
+	go func(ctx context.Context) {
+		timer := time.NewTimer(reorgEventWait - time.Since(received)) // Wait this long for any re-org events that can cancel this append.
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+			if err := self.verifier.VerifyLog(ctx, event.Raw); err != nil {
+				level.Error(logger).Log(
+					"msg", "verifying event",
+					"hash", event.Raw.TxHash.String(),
+					"err", err,
+				)
+				self.donePending(event, pending, false)
+				return
+			}
+			err := self.addValTellor(event)
+			if err != nil {
+				level.Error(logger).Log(
+					"msg", "adding value",
+					"err", err,
+				)
+			}
+			self.donePending(event, pending, err == nil)
+		case <-ctx.Done():
+			level.Debug(self.logger).Log("msg", "append canceled", "hash", event.Raw.TxHash.String()[:8])
+			return
+		}
+	}(ctx)
+}
+
+// addPending returns false when the event is already pending or appended
+// e.g. replayed after a re-subscription.
+func (self *Dispute) addPending(event *tellor.TellorNonceSubmitted, pending *pendingEvent) bool {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	key := keyOf(event)
+	if _, ok := self.pendingAppend[key]; ok {
+		return false
+	}
+	if _, ok := self.appended[key]; ok {
+		return false
+	}
+	self.pendingAppend[key] = pending
+	self.store.add(event, pending.received)
+	return true
+}
+
+// donePending removes the pending event when it is still the same one
+// and remembers the appended events.
+func (self *Dispute) donePending(event *tellor.TellorNonceSubmitted, pending *pendingEvent, appended bool) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	key := keyOf(event)
+	if self.pendingAppend[key] == pending {
+		delete(self.pendingAppend, key)
+		self.store.done(key)
+	}
+	if appended {
+		self.markAppended(key)
+	}
+}
+
+// markAppended should be called with the lock held.
+func (self *Dispute) markAppended(key eventKey) {
+	now := time.Now()
+	self.appended[key] = now
+	self.appendedOrder = append(self.appendedOrder, appendedEvent{key: key, at: now})
+	for len(self.appendedOrder) > 0 && now.Sub(self.appendedOrder[0].at) > appendedTTL {
+		oldest := self.appendedOrder[0]
+		self.appendedOrder = self.appendedOrder[1:]
+		// Keep an event appended again since.
+		if self.appended[oldest.key].Equal(oldest.at) {
+			delete(self.appended, oldest.key)
 		}
 	}
 }
@@ -169,35 +375,56 @@ func (self *Dispute) Start() {
 func (self *Dispute) removePending(event *tellor.TellorNonceSubmitted) {
 	self.mtx.Lock()
 	defer self.mtx.Unlock()
-	pendingCncl, ok := self.pendingAppend[event.Raw.TxHash.String()]
+	key := keyOf(event)
+	pending, ok := self.pendingAppend[key]
 	if !ok {
+		if _, ok := self.appended[key]; ok {
+			level.Warn(self.logger).Log("msg", "removed event was already appended", "hash", event.Raw.TxHash.String(), "index", event.Raw.Index)
+			return
+		}
 		level.Error(self.logger).Log("msg", "missing pending TX for removed event")
 		return
 	}
-	pendingCncl()
-	delete(self.pendingAppend, event.Raw.TxHash.String())
+	pending.cancel()
+	delete(self.pendingAppend, key)
+	self.store.done(key)
+}
+
+// LastEvent returns the time of the last received submit event.
+func (self *Dispute) LastEvent() time.Time {
+	return self.lastEvent.Get()
 }
 
 func (self *Dispute) Stop() {
 	self.close()
 }
 
-func (self *Dispute) addValTellor(event *tellor.TellorNonceSubmitted) (err error) {
-	appender := self.tsDB.Appender(self.ctx)
-	defer func() { // An appender always needs to be committed or rolled back.
-		if err != nil {
-			if err := appender.Rollback(); err != nil {
-				level.Error(self.logger).Log("msg", "db rollback failed", "err", err)
-			}
-			return
-		}
-		if errC := appender.Commit(); errC != nil {
-			err = errors.Wrap(err, "db append commit failed")
-		}
-	}()
+func (self *Dispute) addValTellor(event *tellor.TellorNonceSubmitted) error {
+	// Evaluate the PSR values before queuing the samples so that the queue isn't held while waiting for them.
+	ids := make([]int64, len(event.RequestId))
+	for i, id := range event.RequestId {
+		ids[i] = id.Int64()
+	}
+	// The values are stored with the chain time like the index values they are compared with.
+	now := self.clock.Now()
+	psrValues, psrErrs := psr.GetValues(self.psrTellor, ids, now.Add(-reorgEventWait))
+
+	// Nothing is appended when the PSR value of any ID is missing.
+	var samples []db.Sample
+	for i, _valAct := range event.Value {
+		ts := timestamp.FromTime(now)
+		valAct, _ := new(big.Float).SetInt(_valAct).Float64()
 
-	for i, valAct := range event.Value {
-		ts := timestamp.FromTime(time.Now())
+		id := event.RequestId[i].Int64()
+		if err := self.registry.Check(registry.OracleTellor, id, self.registry.Decode(registry.OracleTellor, id, _valAct)); err != nil {
+			self.outOfBounds.With(prometheus.Labels{"id": event.RequestId[i].String()}).Inc()
+			level.Warn(self.logger).Log(
+				"msg", "submitted value is outside the registry bounds",
+				"miner", event.Miner.String(),
+				"hash", event.Raw.TxHash.String(),
+				"err", err,
+			)
+		}
 		lbls := labels.Labels{
 			labels.Label{Name: "__name__", Value: "oracle_value"},
 			labels.Label{Name: "contract", Value: "tellor"},
@@ -207,15 +434,12 @@ func (self *Dispute) addValTellor(event *tellor.TellorNonceSubmitted) (err error
 
 		sort.Sort(lbls) // This is important! The labels need to be sorted to avoid creating the same series with duplicate reference.
 
-		_, err = appender.Append(0, lbls, ts, float64(valAct.Int64()))
-		if err != nil {
-			return errors.Wrap(err, "append values to the DB")
-		}
+		samples = append(samples, db.Sample{Labels: lbls, T: ts, V: valAct, Event: &event.Raw, Fails: self.dbAppendFails})
 
-		valExp, err := self.psrTellor.GetValue(event.RequestId[i].Int64(), time.Now().Add(-reorgEventWait))
-		if err != nil {
-			return errors.Wrapf(err, "getting value from the PSR id:%v", event.RequestId[i].Int64())
+		if psrErrs[i] != nil {
+			return errors.Wrapf(psrErrs[i], "getting value from the PSR id:%v", ids[i])
 		}
+		valExp, _ := new(big.Float).SetInt(psrValues[i]).Float64()
 
 		lbls = labels.Labels{
 			labels.Label{Name: "__name__", Value: "psr_value"},
@@ -225,21 +449,36 @@ func (self *Dispute) addValTellor(event *tellor.TellorNonceSubmitted) (err error
 
 		sort.Sort(lbls) // This is important! The labels need to be sorted to avoid creating the same series with duplicate reference.
 
-		_, err = appender.Append(0, lbls, ts, float64(valExp))
-		if err != nil {
-			return errors.Wrap(err, "append values to the DB")
+		samples = append(samples, db.Sample{Labels: lbls, T: ts, V: valExp, Event: &event.Raw, Fails: self.dbAppendFails})
+
+		// The deviation is stored at the same timestamp as both values
+		// so that the alerts don't need to match the two series.
+		// It is undefined for a zero PSR value.
+		var deviation float64
+		if valExp != 0 {
+			deviation = ((valAct - valExp) / valExp) * 100
+			lbls = labels.Labels{
+				labels.Label{Name: "__name__", Value: "oracle_psr_deviation_percent"},
+				labels.Label{Name: "contract", Value: "tellor"},
+				labels.Label{Name: "id", Value: event.RequestId[i].String()},
+				labels.Label{Name: "miner", Value: event.Miner.String()},
+			}
+
+			sort.Sort(lbls) // This is important! The labels need to be sorted to avoid creating the same series with duplicate reference.
+
+			samples = append(samples, db.Sample{Labels: lbls, T: ts, V: deviation, Event: &event.Raw, Fails: self.dbAppendFails})
 		}
 
 		level.Debug(self.logger).Log(
-			"msg", "added dispute tracker values",
+			"msg", "queued dispute tracker values",
 			"id", event.RequestId[i].String(),
 			"miner", event.Miner.String(),
 			"oracleValue", valAct,
 			"psrValue", valExp,
-			"difference", ((float64(valExp)-float64(valAct.Int64()))/float64(valExp))*100,
+			"deviation", deviation,
 		)
 	}
-	return nil
+	return self.tsDB.Append(self.ctx, samples...)
 }
 
 func (self *Dispute) newSubTellor(output chan *tellor.TellorNonceSubmitted) (event.Subscription, error) {
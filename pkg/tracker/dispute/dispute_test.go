@@ -0,0 +1,71 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package dispute
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// TestEventsOfOneTx ensures that the events of the same transaction are tracked apart
+// and that the replayed events are skipped.
+func TestEventsOfOneTx(t *testing.T) {
+	d := newTestDispute(context.Background(), nil, "")
+	first := nonceSubmitted(t, 1, common.HexToHash("0xb1"), common.HexToHash("0x1"), 0)
+	second := nonceSubmitted(t, 1, common.HexToHash("0xb1"), common.HexToHash("0x1"), 1)
+
+	var canceled []uint
+	newPending := func(index uint) *pendingEvent {
+		return &pendingEvent{cancel: func() { canceled = append(canceled, index) }, block: 1, received: time.Now()}
+	}
+	firstPending := newPending(0)
+	testutil.Assert(t, d.addPending(first, firstPending), "the first event isn't pending")
+	testutil.Assert(t, d.addPending(second, newPending(1)), "the second event of the tx isn't pending")
+	testutil.Assert(t, !d.addPending(second, newPending(1)), "the second event is pending twice")
+
+	// The removal of one event doesn't cancel the other.
+	removed := *second
+	removed.Raw.Removed = true
+	d.removePending(&removed)
+	testutil.Equals(t, []uint{1}, canceled)
+	testutil.Equals(t, 1, len(d.pendingAppend))
+
+	d.donePending(first, firstPending, true)
+	testutil.Equals(t, 0, len(d.pendingAppend))
+
+	// The appended event replayed after a re-subscription is skipped.
+	d.schedule(d.logger, first, time.Now())
+	testutil.Equals(t, 0, len(d.pendingAppend))
+	testutil.Equals(t, float64(1), promtestutil.ToFloat64(d.duplicates))
+
+	// The removed event is added again when it is back in the chain.
+	testutil.Assert(t, d.addPending(second, newPending(1)), "the event back in the chain isn't pending")
+}
+
+func TestMarkAppended(t *testing.T) {
+	d := newTestDispute(context.Background(), nil, "")
+	expired := eventKey{tx: common.HexToHash("0x1")}
+	again := eventKey{tx: common.HexToHash("0x2")}
+	old := time.Now().Add(-appendedTTL - time.Minute)
+	d.appended[expired] = old
+	recent := time.Now()
+	d.appended[again] = recent
+	d.appendedOrder = []appendedEvent{{key: expired, at: old}, {key: again, at: old}, {key: again, at: recent}}
+
+	key := eventKey{tx: common.HexToHash("0x3")}
+	d.markAppended(key)
+
+	_, ok := d.appended[expired]
+	testutil.Assert(t, !ok, "the expired event is still appended")
+	_, ok = d.appended[again]
+	testutil.Assert(t, ok, "the event appended again since is expired")
+	_, ok = d.appended[key]
+	testutil.Assert(t, ok, "the new event isn't appended")
+	testutil.Equals(t, 2, len(d.appendedOrder))
+}
@@ -0,0 +1,301 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package dispute
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/contracts/tellor"
+	"github.com/tellor-io/telliot/pkg/fsutil"
+)
+
+// minCompact is the number of records in the pending file
+// below which it isn't compacted.
+const minCompact = 64
+
+// pendingRecord is a line of the pending file.
+// A record with the log adds a pending event and a done record removes it.
+type pendingRecord struct {
+	Log      *types.Log `json:",omitempty"`
+	Received time.Time  `json:",omitempty"`
+	Done     bool       `json:",omitempty"`
+	TxHash   common.Hash
+	Index    uint
+}
+
+func (self pendingRecord) key() eventKey {
+	return eventKey{tx: self.TxHash, index: self.Index}
+}
+
+// pendingStore keeps the pending events across restarts in an append only file.
+// The records are queued without waiting for the disk and written by a background goroutine,
+// which replaces the file with only the pending events when the done records outnumber them.
+type pendingStore struct {
+	logger log.Logger
+	path   string
+
+	mtx    sync.Mutex
+	queue  []pendingRecord
+	notify chan struct{}
+
+	// Only used by the writer.
+	file    *os.File
+	records int
+	live    int
+}
+
+func newPendingStore(logger log.Logger, path string) *pendingStore {
+	if path == "" {
+		return nil
+	}
+	return &pendingStore{
+		logger: logger,
+		path:   path,
+		notify: make(chan struct{}, 1),
+	}
+}
+
+// add queues the record of a new pending event.
+// A nil store doesn't keep anything.
+func (self *pendingStore) add(event *tellor.TellorNonceSubmitted, received time.Time) {
+	if self == nil {
+		return
+	}
+	l := event.Raw
+	self.push(pendingRecord{Log: &l, Received: received, TxHash: l.TxHash, Index: l.Index})
+}
+
+// done queues the record of an event that is no longer pending.
+func (self *pendingStore) done(key eventKey) {
+	if self == nil {
+		return
+	}
+	self.push(pendingRecord{Done: true, TxHash: key.tx, Index: key.index})
+}
+
+func (self *pendingStore) push(r pendingRecord) {
+	self.mtx.Lock()
+	self.queue = append(self.queue, r)
+	self.mtx.Unlock()
+	select {
+	case self.notify <- struct{}{}:
+	default:
+	}
+}
+
+// run writes the queued records until the context is canceled.
+// It starts by compacting the file to the events returned by snapshot
+// and compacts it again when the done records outnumber the pending events.
+func (self *pendingStore) run(ctx context.Context, snapshot func() []pendingRecord) {
+	if self == nil {
+		return
+	}
+	defer func() {
+		if self.file != nil {
+			self.file.Close()
+		}
+	}()
+	if err := self.compact(snapshot); err != nil {
+		level.Error(self.logger).Log("msg", "compacting the pending events", "err", err)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			if err := self.flush(); err != nil {
+				level.Error(self.logger).Log("msg", "saving the pending events", "err", err)
+			}
+			return
+		case <-self.notify:
+		}
+		if err := self.flush(); err != nil {
+			level.Error(self.logger).Log("msg", "saving the pending events", "err", err)
+			continue
+		}
+		if self.records > minCompact && self.records > 2*self.live {
+			if err := self.compact(snapshot); err != nil {
+				level.Error(self.logger).Log("msg", "compacting the pending events", "err", err)
+			}
+		}
+	}
+}
+
+// flush appends the queued records to the file.
+func (self *pendingStore) flush() error {
+	self.mtx.Lock()
+	queue := self.queue
+	self.queue = nil
+	self.mtx.Unlock()
+	if len(queue) == 0 {
+		return nil
+	}
+	if self.file == nil {
+		if err := os.MkdirAll(filepath.Dir(self.path), 0777); err != nil {
+			return errors.Wrap(err, "creating pending events folder")
+		}
+		f, err := os.OpenFile(self.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return errors.Wrap(err, "opening pending events file")
+		}
+		self.file = f
+	}
+	var buf bytes.Buffer
+	for _, r := range queue {
+		b, err := json.Marshal(r)
+		if err != nil {
+			return errors.Wrap(err, "marshal pending record")
+		}
+		buf.Write(append(b, '\n'))
+		if r.Done {
+			self.live--
+		} else {
+			self.live++
+		}
+	}
+	self.records += len(queue)
+	// A single write so that a crash can only cut the last line.
+	_, err := self.file.Write(buf.Bytes())
+	return errors.Wrap(err, "writing pending records")
+}
+
+// compact replaces the file with the current pending events.
+// The queued records are dropped as the snapshot taken after them already has their changes.
+func (self *pendingStore) compact(snapshot func() []pendingRecord) error {
+	self.mtx.Lock()
+	self.queue = nil
+	self.mtx.Unlock()
+	records := snapshot()
+
+	var buf bytes.Buffer
+	for _, r := range records {
+		b, err := json.Marshal(r)
+		if err != nil {
+			return errors.Wrap(err, "marshal pending record")
+		}
+		buf.Write(append(b, '\n'))
+	}
+	if self.file != nil {
+		self.file.Close()
+		self.file = nil
+	}
+	if err := fsutil.WriteAtomic(self.path, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	self.records, self.live = len(records), len(records)
+	return nil
+}
+
+// load returns the pending events in the file in the order they were added.
+func (self *pendingStore) load() ([]pendingRecord, error) {
+	if self == nil {
+		return nil, nil
+	}
+	f, err := os.Open(self.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "opening pending events file")
+	}
+	defer f.Close()
+
+	var order []eventKey
+	pending := make(map[eventKey]pendingRecord)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		var r pendingRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			// The last line of a crashed write.
+			level.Warn(self.logger).Log("msg", "skipping invalid pending record", "err", err)
+			continue
+		}
+		if r.Done {
+			delete(pending, r.key())
+			continue
+		}
+		if r.Log == nil {
+			continue
+		}
+		if _, ok := pending[r.key()]; !ok {
+			order = append(order, r.key())
+		}
+		pending[r.key()] = r
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "reading pending events file")
+	}
+	var records []pendingRecord
+	for _, key := range order {
+		if r, ok := pending[key]; ok {
+			records = append(records, r)
+		}
+	}
+	return records, nil
+}
+
+// pendingRecords returns the add records of the pending events for compacting the pending file.
+func (self *Dispute) pendingRecords() []pendingRecord {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	records := make([]pendingRecord, 0, len(self.pendingAppend))
+	for _, pending := range self.pendingAppend {
+		l := pending.event.Raw
+		records = append(records, pendingRecord{Log: &l, Received: pending.received, TxHash: l.TxHash, Index: l.Index})
+	}
+	return records
+}
+
+// restorePending schedules the events that were pending before a restart
+// with the remaining time of their re-org wait.
+// The events in blocks that were replaced while it wasn't running are dropped
+// as their removal was never received.
+func (self *Dispute) restorePending(logger log.Logger) error {
+	saved, err := self.store.load()
+	if err != nil {
+		return err
+	}
+	if len(saved) == 0 {
+		return nil
+	}
+	filterer, err := tellor.NewTellorFilterer(self.contract.Address, self.client)
+	if err != nil {
+		return errors.Wrap(err, "getting instance")
+	}
+	var restored int
+	for _, s := range saved {
+		event, err := filterer.ParseNonceSubmitted(*s.Log)
+		if err != nil {
+			level.Error(logger).Log("msg", "parsing pending event", "hash", s.Log.TxHash.String(), "err", err)
+			continue
+		}
+		header, err := self.client.HeaderByNumber(self.ctx, new(big.Int).SetUint64(event.Raw.BlockNumber))
+		if err != nil {
+			// The verifier still checks the block before the append when enabled.
+			level.Warn(logger).Log("msg", "checking the block of a pending event, restoring it unchecked", "hash", event.Raw.TxHash.String(), "block", event.Raw.BlockNumber, "err", err)
+		} else if header.Hash() != event.Raw.BlockHash {
+			level.Warn(logger).Log("msg", "dropping pending event of a replaced block", "hash", event.Raw.TxHash.String(), "block", event.Raw.BlockNumber, "eventBlock", event.Raw.BlockHash.String(), "chainBlock", header.Hash().String())
+			continue
+		}
+		if event.Raw.BlockNumber > self.maxBlock {
+			self.maxBlock = event.Raw.BlockNumber
+		}
+		self.schedule(logger, event, s.Received)
+		restored++
+	}
+	level.Info(logger).Log("msg", "restored pending events", "count", restored, "dropped", len(saved)-restored)
+	return nil
+}
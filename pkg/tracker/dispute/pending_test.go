@@ -0,0 +1,158 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package dispute
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/contracts/tellor"
+	"github.com/tellor-io/telliot/pkg/testutil"
+	"github.com/tellor-io/telliot/pkg/testutil/chain"
+)
+
+// nonceSubmitted returns a submit event log in the block.
+func nonceSubmitted(t *testing.T, blockNumber uint64, blockHash common.Hash, tx common.Hash, index uint) *tellor.TellorNonceSubmitted {
+	parsed, err := abi.JSON(strings.NewReader(tellor.TellorABI))
+	testutil.Ok(t, err)
+	ev := parsed.Events["NonceSubmitted"]
+	var ids, vals [5]*big.Int
+	for i := range ids {
+		ids[i], vals[i] = big.NewInt(int64(i+1)), big.NewInt(int64(100*(i+1)))
+	}
+	data, err := ev.Inputs.NonIndexed().Pack("nonce", ids, vals, big.NewInt(1))
+	testutil.Ok(t, err)
+	l := types.Log{
+		Topics:      []common.Hash{ev.ID, common.HexToHash("0xa1"), common.HexToHash("0xc1")},
+		Data:        data,
+		BlockNumber: blockNumber,
+		BlockHash:   blockHash,
+		TxHash:      tx,
+		Index:       index,
+	}
+	filterer, err := tellor.NewTellorFilterer(common.Address{}, nil)
+	testutil.Ok(t, err)
+	event, err := filterer.ParseNonceSubmitted(l)
+	testutil.Ok(t, err)
+	return event
+}
+
+func newTestDispute(ctx context.Context, client contracts.ETHClient, path string) *Dispute {
+	return &Dispute{
+		logger:        log.NewNopLogger(),
+		ctx:           ctx,
+		client:        client,
+		contract:      &contracts.ITellor{},
+		pendingAppend: make(map[eventKey]*pendingEvent),
+		appended:      make(map[eventKey]time.Time),
+		store:         newPendingStore(log.NewNopLogger(), path),
+		duplicates:    prometheus.NewCounter(prometheus.CounterOpts{Name: "duplicates"}),
+		reorgDepth:    prometheus.NewHistogram(prometheus.HistogramOpts{Name: "reorg_depth"}),
+		storms:        prometheus.NewCounter(prometheus.CounterOpts{Name: "storms"}),
+		protective:    prometheus.NewGauge(prometheus.GaugeOpts{Name: "protective"}),
+	}
+}
+
+// TestPendingStore ensures that the pending file keeps only the pending events
+// across compactions and a cut last line.
+func TestPendingStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pending")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "db", "dispute.pending")
+	store := newPendingStore(log.NewNopLogger(), path)
+
+	received := time.Unix(1600000000, 0).UTC()
+	var live *tellor.TellorNonceSubmitted
+	for i := 0; i < 100; i++ {
+		event := nonceSubmitted(t, 1, common.HexToHash("0xb1"), common.BigToHash(big.NewInt(int64(i))), 0)
+		store.add(event, received)
+		if i == 50 {
+			live = event
+			continue
+		}
+		store.done(keyOf(event))
+	}
+	testutil.Ok(t, store.flush())
+	testutil.Equals(t, 199, store.records)
+	testutil.Equals(t, 1, store.live)
+
+	loaded, err := store.load()
+	testutil.Ok(t, err)
+	testutil.Equals(t, 1, len(loaded))
+	testutil.Equals(t, keyOf(live), loaded[0].key())
+	testutil.Equals(t, received, loaded[0].Received)
+
+	// The compaction keeps the snapshot and drops the records already in it.
+	store.done(keyOf(live))
+	testutil.Ok(t, store.compact(func() []pendingRecord { return loaded }))
+	testutil.Equals(t, 1, store.records)
+	testutil.Ok(t, store.flush())
+	data, err := ioutil.ReadFile(path)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 1, strings.Count(string(data), "\n"))
+
+	// A write cut by a crash is skipped.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	testutil.Ok(t, err)
+	_, err = f.Write([]byte(`{"Log":{"address"`))
+	testutil.Ok(t, err)
+	testutil.Ok(t, f.Close())
+	loaded, err = store.load()
+	testutil.Ok(t, err)
+	testutil.Equals(t, 1, len(loaded))
+}
+
+// TestRestorePending ensures that only the pending events still in the chain are restored.
+func TestRestorePending(t *testing.T) {
+	c, err := chain.New(1)
+	testutil.Ok(t, err)
+	defer c.Close()
+	header, err := c.HeaderByNumber(context.Background(), big.NewInt(1))
+	testutil.Ok(t, err)
+
+	dir, err := ioutil.TempDir("", "pending")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "dispute.pending")
+
+	kept := nonceSubmitted(t, 1, header.Hash(), common.HexToHash("0x1"), 0)
+	// Another event of the same tx.
+	second := nonceSubmitted(t, 1, header.Hash(), common.HexToHash("0x1"), 1)
+	replaced := nonceSubmitted(t, 1, common.HexToHash("0xdead"), common.HexToHash("0x2"), 0)
+	done := nonceSubmitted(t, 1, header.Hash(), common.HexToHash("0x3"), 0)
+
+	store := newPendingStore(log.NewNopLogger(), path)
+	for _, event := range []*tellor.TellorNonceSubmitted{kept, second, replaced, done} {
+		store.add(event, time.Now())
+	}
+	store.done(keyOf(done))
+	testutil.Ok(t, store.flush())
+
+	ctx, cncl := context.WithCancel(context.Background())
+	defer cncl()
+	d := newTestDispute(ctx, c, path)
+	testutil.Ok(t, d.restorePending(d.logger))
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	testutil.Equals(t, 2, len(d.pendingAppend))
+	_, ok := d.pendingAppend[keyOf(kept)]
+	testutil.Assert(t, ok, "the event in the chain should be restored")
+	_, ok = d.pendingAppend[keyOf(second)]
+	testutil.Assert(t, ok, "the second event of the tx should be restored")
+	testutil.Equals(t, uint64(1), d.maxBlock)
+}
@@ -0,0 +1,159 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package dispute
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/contracts/tellor"
+	"github.com/tellor-io/telliot/pkg/format"
+)
+
+// stormCheckInterval is how often to check whether the chain has settled after a reorg storm.
+const stormCheckInterval = 15 * time.Second
+
+// maxStormBlocks is the widest block range of a reorg storm.
+// The events of older blocks are deeper than any expected reorg and are kept as appended.
+const maxStormBlocks = 1000
+
+// StormConfig is the protection against deep reorgs.
+// During a reorg storm pairing every added and removed event is unreliable
+// so the events are ignored and the confirmed ones are pulled from the chain once it settles.
+type StormConfig struct {
+	// Removals is the number of removed events within the Window that starts the protective mode, 0 disables it.
+	Removals int
+	Window   format.Duration
+	// Confirmations is the number of blocks after the last event of the storm
+	// to wait before pulling the confirmed events.
+	Confirmations uint64
+}
+
+// storm is the block range affected by a reorg storm.
+type storm struct {
+	from, to uint64
+	// removedTo is the latest block with a removed event.
+	removedTo   uint64
+	lastRemoval time.Time
+}
+
+func (self *storm) extend(event *tellor.TellorNonceSubmitted) {
+	self.include(event.Raw.BlockNumber)
+	if event.Raw.Removed {
+		if event.Raw.BlockNumber > self.removedTo {
+			self.removedTo = event.Raw.BlockNumber
+		}
+		self.lastRemoval = time.Now()
+	}
+}
+
+// include widens the range to the block, keeping at most the newest maxStormBlocks.
+func (self *storm) include(block uint64) {
+	if block < self.from {
+		self.from = block
+	}
+	if block > self.to {
+		self.to = block
+	}
+	if self.to-self.from > maxStormBlocks {
+		self.from = self.to - maxStormBlocks
+	}
+}
+
+// observeRemoval records the depth of a removed event and
+// returns true when the removals within the window reach the storm threshold.
+func (self *Dispute) observeRemoval(event *tellor.TellorNonceSubmitted) bool {
+	self.reorgDepth.Observe(float64(self.maxBlock - event.Raw.BlockNumber + 1))
+	if self.cfg.Storm.Removals <= 0 {
+		return false
+	}
+
+	now := time.Now()
+	self.removals = append(self.removals, now)
+	for len(self.removals) > 0 && now.Sub(self.removals[0]) > self.cfg.Storm.Window.Duration {
+		self.removals = self.removals[1:]
+	}
+	return len(self.removals) >= self.cfg.Storm.Removals
+}
+
+// enterStorm cancels all pending appends and starts tracking the affected block range.
+func (self *Dispute) enterStorm(event *tellor.TellorNonceSubmitted) {
+	self.storm = &storm{from: event.Raw.BlockNumber, to: event.Raw.BlockNumber, removedTo: event.Raw.BlockNumber, lastRemoval: time.Now()}
+
+	self.mtx.Lock()
+	for key, pending := range self.pendingAppend {
+		pending.cancel()
+		self.storm.include(pending.block)
+		delete(self.pendingAppend, key)
+		self.store.done(key)
+	}
+	self.mtx.Unlock()
+
+	self.removals = nil
+	self.storms.Inc()
+	self.protective.Set(1)
+	level.Warn(self.logger).Log("msg", "reorg storm, ignoring events until the chain settles", "fromBlock", self.storm.from, "toBlock", self.storm.to)
+}
+
+// resyncStorm adds the events from the start of the storm block range until the latest block
+// once there are no more removals and the last removed block has enough confirmations.
+// The events after the last removal don't wait for the confirmations as new events
+// keep coming during the storm and would otherwise hold it forever.
+func (self *Dispute) resyncStorm() error {
+	if time.Since(self.storm.lastRemoval) < self.cfg.Storm.Window.Duration {
+		return nil
+	}
+	header, err := self.client.HeaderByNumber(self.ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "get latest eth block header")
+	}
+	if header.Number.Uint64() < self.storm.removedTo+self.cfg.Storm.Confirmations {
+		return nil
+	}
+
+	filterer, err := tellor.NewTellorFilterer(self.contract.Address, self.client)
+	if err != nil {
+		return errors.Wrap(err, "getting instance")
+	}
+	to := header.Number.Uint64()
+	iter, err := filterer.FilterNonceSubmitted(&bind.FilterOpts{Context: self.ctx, Start: self.storm.from, End: &to}, nil, nil)
+	if err != nil {
+		return errors.Wrap(err, "filtering the confirmed events")
+	}
+	defer iter.Close()
+
+	var added int
+	for iter.Next() {
+		event := iter.Event
+		self.mtx.Lock()
+		_, ok := self.appended[keyOf(event)]
+		self.mtx.Unlock()
+		if ok {
+			self.duplicates.Inc()
+			continue
+		}
+		if err := self.verifier.VerifyLog(self.ctx, event.Raw); err != nil {
+			level.Error(self.logger).Log("msg", "verifying event", "hash", event.Raw.TxHash.String(), "err", err)
+			continue
+		}
+		if err := self.addValTellor(event); err != nil {
+			level.Error(self.logger).Log("msg", "adding value", "err", err)
+			continue
+		}
+		self.mtx.Lock()
+		self.markAppended(keyOf(event))
+		self.mtx.Unlock()
+		added++
+	}
+	if err := iter.Error(); err != nil {
+		return errors.Wrap(err, "iterating the confirmed events")
+	}
+
+	level.Info(self.logger).Log("msg", "chain settled after the reorg storm", "fromBlock", self.storm.from, "toBlock", to, "added", added)
+	self.storm = nil
+	self.protective.Set(0)
+	return nil
+}
@@ -0,0 +1,111 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package dispute
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/testutil"
+	"github.com/tellor-io/telliot/pkg/testutil/chain"
+)
+
+func TestObserveRemoval(t *testing.T) {
+	d := newTestDispute(context.Background(), nil, "")
+	d.maxBlock = 10
+	removed := nonceSubmitted(t, 8, common.HexToHash("0xb1"), common.HexToHash("0x1"), 0)
+	removed.Raw.Removed = true
+
+	// Disabled.
+	testutil.Assert(t, !d.observeRemoval(removed), "a storm with the protection disabled")
+	testutil.Equals(t, 0, len(d.removals))
+
+	d.cfg.Storm = StormConfig{Removals: 3, Window: format.Duration{Duration: time.Minute}}
+	// The removals before the window don't count.
+	d.removals = []time.Time{time.Now().Add(-2 * time.Minute), time.Now().Add(-90 * time.Second)}
+	testutil.Assert(t, !d.observeRemoval(removed), "a storm after the first removal in the window")
+	testutil.Equals(t, 1, len(d.removals))
+	testutil.Assert(t, !d.observeRemoval(removed), "a storm after the second removal in the window")
+	testutil.Assert(t, d.observeRemoval(removed), "no storm after the third removal in the window")
+}
+
+func TestEnterStorm(t *testing.T) {
+	d := newTestDispute(context.Background(), nil, "")
+	var canceled int
+	for _, block := range []uint64{5, 9} {
+		event := nonceSubmitted(t, block, common.HexToHash("0xb1"), common.BigToHash(new(big.Int).SetUint64(block)), 0)
+		d.pendingAppend[keyOf(event)] = &pendingEvent{cancel: func() { canceled++ }, block: block, event: event}
+	}
+	d.removals = []time.Time{time.Now()}
+
+	removed := nonceSubmitted(t, 7, common.HexToHash("0xb1"), common.HexToHash("0x7"), 0)
+	removed.Raw.Removed = true
+	d.enterStorm(removed)
+
+	testutil.Equals(t, 2, canceled)
+	testutil.Equals(t, 0, len(d.pendingAppend))
+	testutil.Equals(t, 0, len(d.removals))
+	testutil.Equals(t, uint64(5), d.storm.from)
+	testutil.Equals(t, uint64(9), d.storm.to)
+	testutil.Equals(t, uint64(7), d.storm.removedTo)
+	testutil.Equals(t, float64(1), promtestutil.ToFloat64(d.storms))
+	testutil.Equals(t, float64(1), promtestutil.ToFloat64(d.protective))
+}
+
+// TestStormExtend ensures that the storm range only keeps the newest blocks.
+func TestStormExtend(t *testing.T) {
+	s := &storm{from: 5000, to: 5000, removedTo: 5000}
+
+	s.extend(nonceSubmitted(t, 5100, common.Hash{}, common.HexToHash("0x1"), 0))
+	testutil.Equals(t, uint64(5000), s.from)
+	testutil.Equals(t, uint64(5100), s.to)
+	testutil.Equals(t, uint64(5000), s.removedTo)
+
+	removed := nonceSubmitted(t, 1, common.Hash{}, common.HexToHash("0x2"), 0)
+	removed.Raw.Removed = true
+	s.extend(removed)
+	testutil.Equals(t, uint64(5100-maxStormBlocks), s.from)
+	testutil.Equals(t, uint64(5100), s.to)
+	testutil.Equals(t, uint64(5000), s.removedTo)
+
+	s.extend(nonceSubmitted(t, 9000, common.Hash{}, common.HexToHash("0x3"), 0))
+	testutil.Equals(t, uint64(9000-maxStormBlocks), s.from)
+	testutil.Equals(t, uint64(9000), s.to)
+}
+
+// TestResyncStorm ensures that the storm only ends once there are no more removals
+// and the last removed block has the confirmations even when new events keep extending the range.
+func TestResyncStorm(t *testing.T) {
+	c, err := chain.New(1)
+	testutil.Ok(t, err)
+	defer c.Close()
+	testutil.Ok(t, c.Advance(time.Second))
+	header, err := c.HeaderByNumber(context.Background(), nil)
+	testutil.Ok(t, err)
+	head := header.Number.Uint64()
+	d := newTestDispute(context.Background(), c, "")
+	d.cfg.Storm = StormConfig{Removals: 3, Window: format.Duration{Duration: time.Minute}, Confirmations: 2}
+	d.protective.Set(1)
+
+	// Still removing.
+	d.storm = &storm{from: 1, to: head, removedTo: head - 2, lastRemoval: time.Now()}
+	testutil.Ok(t, d.resyncStorm())
+	testutil.Assert(t, d.storm != nil, "the storm ended with recent removals")
+
+	// The last removal doesn't have the confirmations.
+	d.storm = &storm{from: 1, to: head, removedTo: head - 1, lastRemoval: time.Now().Add(-2 * time.Minute)}
+	testutil.Ok(t, d.resyncStorm())
+	testutil.Assert(t, d.storm != nil, "the storm ended before the confirmations")
+
+	// The events after the last removal don't hold the storm.
+	d.storm = &storm{from: 1, to: head, removedTo: head - 2, lastRemoval: time.Now().Add(-2 * time.Minute)}
+	testutil.Ok(t, d.resyncStorm())
+	testutil.Assert(t, d.storm == nil, "the storm didn't end")
+	testutil.Equals(t, float64(0), promtestutil.ToFloat64(d.protective))
+}
@@ -0,0 +1,253 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+// Package disputeHistory records the events of all disputes with their outcomes
+// for the analytics of the dispute frequency and the exposure of the accounts.
+package disputeHistory
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	eth "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/watchdog"
+)
+
+const ComponentName = "disputeHistory"
+
+var recordedCount = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "telliot",
+	Subsystem: ComponentName,
+	Name:      "events_total",
+	Help:      "The total number of recorded dispute events",
+}, []string{"kind"})
+
+type Config struct {
+	Enabled  bool
+	LogLevel string
+	// Path is the file that records all dispute events.
+	Path     string
+	Interval format.Duration
+	// LookBack is how far in the past the events are fetched at startup.
+	// The events recorded before are kept in the file.
+	LookBack format.Duration
+	// Confirmations is the number of blocks after the block of an event
+	// before it is recorded so that a re-org doesn't change the history.
+	Confirmations uint64
+}
+
+// Tracker fetches the dispute, vote and tally events and records them in a store.
+type Tracker struct {
+	ctx      context.Context
+	close    context.CancelFunc
+	logger   log.Logger
+	watchdog *watchdog.Watchdog
+	cfg      Config
+	client   contracts.ETHClient
+	fetcher  *ethereum.LogFetcher
+	contract *contracts.ITellor
+	abi      *abi.ABI
+	store    *Store
+	accounts []common.Address
+	// next is the first block of the next fetch.
+	next uint64
+}
+
+func New(
+	logger log.Logger,
+	ctx context.Context,
+	cfg Config,
+	client contracts.ETHClient,
+	fetcher *ethereum.LogFetcher,
+	contract *contracts.ITellor,
+	store *Store,
+	accounts []common.Address,
+	watchdog *watchdog.Watchdog,
+) (*Tracker, error) {
+	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	logger = log.With(logger, "component", ComponentName)
+	parsed, err := contracts.ParseABI(contracts.ITellorABI)
+	if err != nil {
+		return nil, err
+	}
+	ctx, close := context.WithCancel(ctx)
+
+	return &Tracker{
+		ctx:      ctx,
+		close:    close,
+		watchdog: watchdog,
+		logger:   logger,
+		cfg:      cfg,
+		client:   client,
+		fetcher:  fetcher,
+		contract: contract,
+		abi:      parsed,
+		store:    store,
+		accounts: accounts,
+	}, nil
+}
+
+func (self *Tracker) Start() error {
+	level.Info(self.logger).Log("msg", "starting", "lookBack", self.cfg.LookBack, "confirmations", self.cfg.Confirmations)
+
+	ticker := time.NewTicker(self.cfg.Interval.Duration)
+	defer ticker.Stop()
+	for {
+		stop := self.watchdog.Watch(ComponentName)
+		if err := self.sync(); err != nil {
+			level.Error(self.logger).Log("msg", "recording the dispute events", "err", err)
+		}
+		stop()
+		select {
+		case <-self.ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (self *Tracker) Stop() {
+	self.close()
+}
+
+// sync records the events of the confirmed blocks since the last fetch.
+func (self *Tracker) sync() error {
+	header, err := self.client.HeaderByNumber(self.ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "get latest eth block header")
+	}
+	if header.Number.Uint64() < self.cfg.Confirmations {
+		return nil
+	}
+	to := header.Number.Uint64() - self.cfg.Confirmations
+	if self.next == 0 {
+		// Average block time is ~13 seconds.
+		lookBackBlocks := uint64(self.cfg.LookBack.Seconds() / 13)
+		if to > lookBackBlocks {
+			self.next = to - lookBackBlocks
+		}
+		// The events of the last recorded block are fetched again
+		// in case not all were recorded before a restart.
+		if last := self.store.LastBlock(); last > self.next {
+			self.next = last
+		}
+	}
+	if self.next > to {
+		return nil
+	}
+
+	query := eth.FilterQuery{
+		Addresses: []common.Address{self.contract.Address},
+		Topics: [][]common.Hash{{
+			self.abi.Events["NewDispute"].ID,
+			self.abi.Events["Voted"].ID,
+			self.abi.Events["DisputeVoteTallied"].ID,
+		}},
+	}
+	err = self.fetcher.Walk(self.ctx, query, self.next, to, func(_, end uint64, logs []types.Log) error {
+		events, err := self.events(logs)
+		if err != nil {
+			return err
+		}
+		recorded, err := self.store.Record(events...)
+		if err != nil {
+			return err
+		}
+		if recorded > 0 {
+			level.Info(self.logger).Log("msg", "recorded dispute events", "count", recorded, "block", end)
+		}
+		self.next = end + 1
+		return nil
+	})
+	return errors.Wrap(err, "fetching dispute events")
+}
+
+// events parses the logs and adds the block time and the disputer of the opened disputes.
+func (self *Tracker) events(logs []types.Log) ([]Event, error) {
+	times := make(map[uint64]time.Time)
+	var events []Event
+	for _, l := range logs {
+		if l.Removed || len(l.Topics) == 0 {
+			continue
+		}
+		t, ok := times[l.BlockNumber]
+		if !ok {
+			header, err := self.client.HeaderByNumber(self.ctx, new(big.Int).SetUint64(l.BlockNumber))
+			if err != nil {
+				return nil, errors.Wrapf(err, "get block header:%v", l.BlockNumber)
+			}
+			t = time.Unix(int64(header.Time), 0).UTC()
+			times[l.BlockNumber] = t
+		}
+		e := Event{Block: l.BlockNumber, TxHash: l.TxHash.Hex(), Index: l.Index, Time: t}
+
+		switch l.Topics[0] {
+		case self.abi.Events["NewDispute"].ID:
+			parsed, err := self.contract.ParseNewDispute(l)
+			if err != nil {
+				return nil, errors.Wrap(err, "parse dispute event")
+			}
+			e.Kind = KindOpened
+			e.DisputeID = parsed.DisputeId.Int64()
+			e.RequestID = parsed.RequestId.Int64()
+			e.Timestamp = parsed.Timestamp.Int64()
+			e.Miner = parsed.Miner.Hex()
+			disputer, err := self.sender(l.TxHash)
+			if err != nil {
+				level.Warn(self.logger).Log("msg", "getting the disputer", "disputeID", e.DisputeID, "err", err)
+			}
+			e.Disputer = disputer
+		case self.abi.Events["Voted"].ID:
+			parsed, err := self.contract.ParseVoted(l)
+			if err != nil {
+				return nil, errors.Wrap(err, "parse vote event")
+			}
+			e.Kind = KindVoted
+			e.DisputeID = parsed.DisputeID.Int64()
+			e.Voter = parsed.Voter.Hex()
+			e.Position = parsed.Position
+			e.Weight = parsed.VoteWeight.String()
+		case self.abi.Events["DisputeVoteTallied"].ID:
+			parsed, err := self.contract.ParseDisputeVoteTallied(l)
+			if err != nil {
+				return nil, errors.Wrap(err, "parse tally event")
+			}
+			e.Kind = KindTallied
+			e.DisputeID = parsed.DisputeID.Int64()
+			e.Result = parsed.Result.String()
+		default:
+			continue
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// sender returns the address that sent a transaction.
+func (self *Tracker) sender(hash common.Hash) (string, error) {
+	tx, _, err := self.client.TransactionByHash(self.ctx, hash)
+	if err != nil {
+		return "", errors.Wrap(err, "get transaction")
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return "", errors.Wrap(err, "recover transaction sender")
+	}
+	return from.Hex(), nil
+}
@@ -0,0 +1,179 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package disputeHistory
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/web"
+)
+
+// Count are the disputes of a disputer, a miner or a request ID by outcome.
+type Count struct {
+	Key      string
+	Disputes int
+	Open     int
+	Passed   int
+	Failed   int
+	// PassRate is the share of the tallied disputes that passed,
+	// the win rate of a disputer and the loss rate of a miner.
+	PassRate float64
+}
+
+func (self *Count) add(d Dispute) {
+	self.Disputes++
+	switch d.Outcome {
+	case OutcomePassed:
+		self.Passed++
+	case OutcomeFailed:
+		self.Failed++
+	default:
+		self.Open++
+	}
+	if tallied := self.Passed + self.Failed; tallied > 0 {
+		self.PassRate = float64(self.Passed) / float64(tallied)
+	}
+}
+
+// Exposure are the disputes against the accounts opened within a week.
+type Exposure struct {
+	Week time.Time
+	Count
+}
+
+// Stats are the analytics of the disputes opened within a time range.
+type Stats struct {
+	From time.Time
+	To   time.Time
+	Count
+	ByDisputer  []Count
+	ByMiner     []Count
+	ByRequestID []Count
+	// Exposure are the disputes against the accounts per week.
+	Exposure []Exposure
+}
+
+// Analyze returns the stats of the disputes opened within the time range.
+// The disputes without an opened event are skipped as their details are unknown.
+func Analyze(disputes []Dispute, accounts []common.Address, from, to time.Time) Stats {
+	own := make(map[string]bool)
+	for _, a := range accounts {
+		own[strings.ToLower(a.Hex())] = true
+	}
+	stats := Stats{From: from, To: to}
+	byDisputer := make(map[string]*Count)
+	byMiner := make(map[string]*Count)
+	byRequestID := make(map[string]*Count)
+	exposure := make(map[time.Time]*Exposure)
+	for _, d := range disputes {
+		if d.Opened.IsZero() || d.Opened.Before(from) || d.Opened.After(to) {
+			continue
+		}
+		stats.add(d)
+		counter(byDisputer, d.Disputer).add(d)
+		counter(byMiner, d.Miner).add(d)
+		counter(byRequestID, strconv.FormatInt(d.RequestID, 10)).add(d)
+		if own[strings.ToLower(d.Miner)] {
+			week := startOfWeek(d.Opened)
+			e, ok := exposure[week]
+			if !ok {
+				e = &Exposure{Week: week}
+				exposure[week] = e
+			}
+			e.add(d)
+		}
+	}
+	stats.ByDisputer = sorted(byDisputer)
+	stats.ByMiner = sorted(byMiner)
+	stats.ByRequestID = sorted(byRequestID)
+	stats.Exposure = []Exposure{}
+	for _, e := range exposure {
+		stats.Exposure = append(stats.Exposure, *e)
+	}
+	sort.Slice(stats.Exposure, func(i, j int) bool { return stats.Exposure[i].Week.Before(stats.Exposure[j].Week) })
+	return stats
+}
+
+func counter(counts map[string]*Count, key string) *Count {
+	c, ok := counts[key]
+	if !ok {
+		c = &Count{Key: key}
+		counts[key] = c
+	}
+	return c
+}
+
+// sorted returns the counts with the most disputes first.
+func sorted(counts map[string]*Count) []Count {
+	list := make([]Count, 0, len(counts))
+	for _, c := range counts {
+		list = append(list, *c)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Disputes != list[j].Disputes {
+			return list[i].Disputes > list[j].Disputes
+		}
+		return list[i].Key < list[j].Key
+	})
+	return list
+}
+
+// startOfWeek returns the Monday 00:00 UTC of the week of the time.
+func startOfWeek(t time.Time) time.Time {
+	t = t.UTC().Truncate(24 * time.Hour)
+	return t.AddDate(0, 0, -(int(t.Weekday())+6)%7)
+}
+
+// ServeHTTP lists the history of the disputes.
+// The results can be filtered by the miner, disputer, id and outcome query params.
+func (self *Tracker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	list := []Dispute{}
+	for _, d := range self.store.Disputes() {
+		if miner := q.Get("miner"); miner != "" && !strings.EqualFold(d.Miner, miner) {
+			continue
+		}
+		if disputer := q.Get("disputer"); disputer != "" && !strings.EqualFold(d.Disputer, disputer) {
+			continue
+		}
+		if id := q.Get("id"); id != "" && strconv.FormatInt(d.RequestID, 10) != id {
+			continue
+		}
+		if outcome := q.Get("outcome"); outcome != "" && d.Outcome != outcome {
+			continue
+		}
+		list = append(list, d)
+	}
+	if err := web.WriteJSON(w, http.StatusOK, list, nil); err != nil {
+		level.Error(self.logger).Log("msg", "encoding disputes response", "err", err)
+	}
+}
+
+// ServeStats returns the analytics of the disputes opened within the window query param,
+// all disputes when it is not set.
+// For example: curl 'localhost:9090/api/v1/disputes/stats?window=720h'.
+func (self *Tracker) ServeStats(w http.ResponseWriter, r *http.Request) {
+	code, stats, err := func() (int, Stats, error) {
+		to := time.Now()
+		var from time.Time
+		if window := r.URL.Query().Get("window"); window != "" {
+			d, err := time.ParseDuration(window)
+			if err != nil {
+				return http.StatusBadRequest, Stats{}, errors.Wrap(err, "parsing the window")
+			}
+			from = to.Add(-d)
+		}
+		return http.StatusOK, Analyze(self.store.Disputes(), self.accounts, from, to), nil
+	}()
+	if err := web.WriteJSON(w, code, stats, err); err != nil {
+		level.Error(self.logger).Log("msg", "encoding dispute stats response", "err", err)
+	}
+}
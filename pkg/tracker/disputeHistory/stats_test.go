@@ -0,0 +1,70 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package disputeHistory
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+func TestAnalyze(t *testing.T) {
+	dir, err := ioutil.TempDir("", "disputeHistory")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "disputes.jsonl")
+
+	own := common.HexToAddress("0x00000000000000000000000000000000000000a1")
+	other := common.HexToAddress("0x00000000000000000000000000000000000000a2")
+	// A Wednesday.
+	opened := time.Date(2021, 6, 2, 10, 0, 0, 0, time.UTC)
+	events := []Event{
+		{Kind: KindOpened, DisputeID: 1, TxHash: "0x1", Time: opened, RequestID: 1, Miner: own.Hex(), Disputer: "0xd1"},
+		{Kind: KindVoted, DisputeID: 1, TxHash: "0x2", Time: opened.Add(time.Hour), Voter: "0xv", Position: true, Weight: "10"},
+		{Kind: KindTallied, DisputeID: 1, TxHash: "0x3", Time: opened.Add(48 * time.Hour), Result: "10"},
+		{Kind: KindOpened, DisputeID: 2, TxHash: "0x4", Time: opened.Add(7 * 24 * time.Hour), RequestID: 2, Miner: own.Hex(), Disputer: "0xd1"},
+		{Kind: KindTallied, DisputeID: 2, TxHash: "0x5", Time: opened.Add(9 * 24 * time.Hour), Result: "-5"},
+		{Kind: KindOpened, DisputeID: 3, TxHash: "0x6", Time: opened.Add(8 * 24 * time.Hour), RequestID: 1, Miner: other.Hex(), Disputer: "0xd2"},
+		// A dispute opened before the look back.
+		{Kind: KindVoted, DisputeID: 4, TxHash: "0x7", Time: opened, Voter: "0xv"},
+	}
+	store, err := OpenStore(path)
+	testutil.Ok(t, err)
+	recorded, err := store.Record(events...)
+	testutil.Ok(t, err)
+	testutil.Equals(t, len(events), recorded)
+	// The duplicates are skipped.
+	recorded, err = store.Record(events[0])
+	testutil.Ok(t, err)
+	testutil.Equals(t, 0, recorded)
+	testutil.Ok(t, store.Close())
+
+	store, err = OpenStore(path)
+	testutil.Ok(t, err)
+	defer store.Close()
+	disputes := store.Disputes()
+	testutil.Equals(t, 4, len(disputes))
+	testutil.Equals(t, OutcomePassed, disputes[0].Outcome)
+	testutil.Equals(t, 1, len(disputes[0].Votes))
+	testutil.Equals(t, OutcomeFailed, disputes[1].Outcome)
+	testutil.Equals(t, OutcomeOpen, disputes[2].Outcome)
+
+	stats := Analyze(disputes, []common.Address{own}, time.Time{}, opened.Add(30*24*time.Hour))
+	testutil.Equals(t, 3, stats.Disputes)
+	testutil.Equals(t, Count{Key: "0xd1", Disputes: 2, Passed: 1, Failed: 1, PassRate: 0.5}, stats.ByDisputer[0])
+	testutil.Equals(t, Count{Key: own.Hex(), Disputes: 2, Passed: 1, Failed: 1, PassRate: 0.5}, stats.ByMiner[0])
+	testutil.Equals(t, Count{Key: "1", Disputes: 2, Open: 1, Passed: 1, PassRate: 1}, stats.ByRequestID[0])
+	testutil.Equals(t, 2, len(stats.Exposure))
+	testutil.Equals(t, time.Date(2021, 5, 31, 0, 0, 0, 0, time.UTC), stats.Exposure[0].Week)
+	testutil.Equals(t, 1, stats.Exposure[1].Failed)
+
+	// Only the disputes opened within the range.
+	stats = Analyze(disputes, []common.Address{own}, opened.Add(24*time.Hour), opened.Add(30*24*time.Hour))
+	testutil.Equals(t, 2, stats.Disputes)
+}
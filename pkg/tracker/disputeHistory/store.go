@@ -0,0 +1,216 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package disputeHistory
+
+import (
+	"bufio"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The kinds of the dispute events.
+const (
+	KindOpened  = "opened"
+	KindVoted   = "voted"
+	KindTallied = "tallied"
+)
+
+// The outcomes of a dispute.
+const (
+	OutcomeOpen = "open"
+	// OutcomePassed is a dispute that the disputer won and the miner was slashed.
+	OutcomePassed = "passed"
+	OutcomeFailed = "failed"
+)
+
+// Event is a single dispute event record.
+type Event struct {
+	Kind      string
+	DisputeID int64
+	Block     uint64
+	TxHash    string
+	Index     uint
+	Time      time.Time
+	// The details of the opened events.
+	RequestID int64  `json:",omitempty"`
+	Timestamp int64  `json:",omitempty"`
+	Miner     string `json:",omitempty"`
+	Disputer  string `json:",omitempty"`
+	// The details of the voted events.
+	Voter    string `json:",omitempty"`
+	Position bool   `json:",omitempty"`
+	Weight   string `json:",omitempty"`
+	// Result of the tallied events, more than 0 when the dispute passed.
+	Result string `json:",omitempty"`
+}
+
+func (self Event) key() string {
+	return self.TxHash + ":" + strconv.FormatUint(uint64(self.Index), 10)
+}
+
+// Dispute is the history of a single dispute built from its events.
+type Dispute struct {
+	DisputeID int64
+	RequestID int64
+	Timestamp int64
+	Miner     string
+	Disputer  string
+	Opened    time.Time
+	Votes     []Vote
+	Tallied   *time.Time `json:",omitempty"`
+	Outcome   string
+}
+
+type Vote struct {
+	Voter    string
+	Position bool
+	Weight   string
+	Time     time.Time
+}
+
+// Store is an append only log of all dispute events.
+// It is replayed on startup to rebuild the history of the disputes.
+type Store struct {
+	mtx       sync.Mutex
+	file      *os.File
+	seen      map[string]bool
+	disputes  map[int64]*Dispute
+	lastBlock uint64
+}
+
+// OpenStore opens or creates the store at the given path and replays its content.
+func OpenStore(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return nil, errors.Wrap(err, "creating dispute history folder")
+	}
+	self := &Store{
+		seen:     make(map[string]bool),
+		disputes: make(map[int64]*Dispute),
+	}
+	if err := self.replay(path); err != nil {
+		return nil, errors.Wrap(err, "replay dispute history")
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+	if err != nil {
+		return nil, errors.Wrap(err, "open dispute history file")
+	}
+	self.file = f
+	return self, nil
+}
+
+func (self *Store) replay(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "open dispute history file")
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		e := Event{}
+		// A partial last line is expected when crashing in the middle of a write.
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		self.apply(e)
+	}
+	return scanner.Err()
+}
+
+func (self *Store) apply(e Event) {
+	self.seen[e.key()] = true
+	if e.Block > self.lastBlock {
+		self.lastBlock = e.Block
+	}
+	d, ok := self.disputes[e.DisputeID]
+	if !ok {
+		// The events of a dispute opened before the look back have no opened event.
+		d = &Dispute{DisputeID: e.DisputeID, Outcome: OutcomeOpen}
+		self.disputes[e.DisputeID] = d
+	}
+	switch e.Kind {
+	case KindOpened:
+		d.RequestID = e.RequestID
+		d.Timestamp = e.Timestamp
+		d.Miner = e.Miner
+		d.Disputer = e.Disputer
+		d.Opened = e.Time
+	case KindVoted:
+		d.Votes = append(d.Votes, Vote{Voter: e.Voter, Position: e.Position, Weight: e.Weight, Time: e.Time})
+	case KindTallied:
+		t := e.Time
+		d.Tallied = &t
+		d.Outcome = OutcomeFailed
+		if result, ok := new(big.Int).SetString(e.Result, 10); ok && result.Sign() > 0 {
+			d.Outcome = OutcomePassed
+		}
+	}
+}
+
+// Record appends the events that aren't recorded yet.
+func (self *Store) Record(events ...Event) (int, error) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	var recorded int
+	for _, e := range events {
+		if self.seen[e.key()] {
+			continue
+		}
+		b, err := json.Marshal(e)
+		if err != nil {
+			return recorded, errors.Wrap(err, "marshal dispute event")
+		}
+		if _, err := self.file.Write(append(b, '\n')); err != nil {
+			return recorded, errors.Wrap(err, "write dispute event")
+		}
+		self.apply(e)
+		recordedCount.With(prometheus.Labels{"kind": e.Kind}).Inc()
+		recorded++
+	}
+	if recorded == 0 {
+		return 0, nil
+	}
+	return recorded, errors.Wrap(self.file.Sync(), "sync dispute history file")
+}
+
+// Disputes returns the history of all disputes sorted by their ID.
+func (self *Store) Disputes() []Dispute {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	list := make([]Dispute, 0, len(self.disputes))
+	for _, d := range self.disputes {
+		dispute := *d
+		dispute.Votes = append([]Vote(nil), d.Votes...)
+		list = append(list, dispute)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].DisputeID < list[j].DisputeID })
+	return list
+}
+
+// LastBlock returns the block of the latest recorded event.
+func (self *Store) LastBlock() uint64 {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	return self.lastBlock
+}
+
+func (self *Store) Close() error {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	return self.file.Close()
+}
@@ -0,0 +1,211 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package index
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/format"
+)
+
+const captureExt = ".json.gz"
+
+type CaptureConfig struct {
+	Enabled bool
+	// Dir is where the responses are stored, under Db.Path by default.
+	Dir string
+	// Responses is the number of the latest responses kept per source.
+	Responses int
+	// MaxBytes is the max size of the compressed responses kept per source.
+	MaxBytes int64
+}
+
+// Captured is a raw API response stored on disk.
+type Captured struct {
+	Symbol string
+	Domain string
+	Time   time.Time
+	Path   string `json:"-"`
+}
+
+// Read returns the uncompressed response.
+func (self Captured) Read() ([]byte, error) {
+	f, err := os.Open(self.Path)
+	if err != nil {
+		return nil, errors.Wrap(err, "open captured response")
+	}
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, errors.Wrap(err, "decompress captured response")
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// Capture stores the latest raw responses of each source compressed on disk
+// so that disputes and debugging can reference exactly what an API returned.
+// The oldest responses of a source are removed when it has more than
+// the configured number of responses or bytes.
+type Capture struct {
+	logger log.Logger
+	cfg    CaptureConfig
+}
+
+func NewCapture(logger log.Logger, cfg CaptureConfig) (*Capture, error) {
+	if cfg.Responses <= 0 {
+		return nil, errors.New("captured responses per source should be more than 0")
+	}
+	if err := os.MkdirAll(cfg.Dir, 0777); err != nil {
+		return nil, errors.Wrap(err, "creating the capture dir")
+	}
+	return &Capture{
+		logger: log.With(logger, "component", "capture"),
+		cfg:    cfg,
+	}, nil
+}
+
+// Store saves a response and removes the oldest responses of the source over the limits.
+// A source is stored in a directory per symbol named by its domain and a hash of the url
+// to not expose any API keys in the url.
+func (self *Capture) Store(symbol, source string, at time.Time, body []byte) error {
+	dir := filepath.Join(self.cfg.Dir, format.SanitizeMetricName(symbol), sourceDir(source))
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return errors.Wrap(err, "creating the source capture dir")
+	}
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(body); err != nil {
+		return errors.Wrap(err, "compress response")
+	}
+	if err := w.Close(); err != nil {
+		return errors.Wrap(err, "compress response")
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, strconv.FormatInt(at.UnixNano(), 10)+captureExt), buf.Bytes(), 0666); err != nil {
+		return errors.Wrap(err, "write captured response")
+	}
+	return self.rotate(dir)
+}
+
+func (self *Capture) rotate(dir string) error {
+	files, err := captured(dir)
+	if err != nil {
+		return err
+	}
+	var size int64
+	// Newest first so that the oldest are removed.
+	for i := len(files) - 1; i >= 0; i-- {
+		size += files[i].size
+		if len(files)-i <= self.cfg.Responses && (self.cfg.MaxBytes <= 0 || size <= self.cfg.MaxBytes) {
+			continue
+		}
+		if err := os.Remove(files[i].path); err != nil {
+			return errors.Wrap(err, "remove old captured response")
+		}
+		level.Debug(self.logger).Log("msg", "removed old captured response", "path", files[i].path)
+	}
+	return nil
+}
+
+// Find returns the captured responses of all sources of a symbol between the given times ordered by time.
+func (self *Capture) Find(symbol string, from, to time.Time) ([]Captured, error) {
+	symbolDir := filepath.Join(self.cfg.Dir, format.SanitizeMetricName(symbol))
+	dirs, err := ioutil.ReadDir(symbolDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "read the symbol capture dir")
+	}
+	var found []Captured
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		files, err := captured(filepath.Join(symbolDir, d.Name()))
+		if err != nil {
+			return nil, err
+		}
+		domain := d.Name()
+		if i := strings.LastIndex(domain, "-"); i > 0 {
+			domain = domain[:i]
+		}
+		for _, f := range files {
+			if f.time.Before(from) || f.time.After(to) {
+				continue
+			}
+			found = append(found, Captured{Symbol: symbol, Domain: domain, Time: f.time, Path: f.path})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].Time.Before(found[j].Time) })
+	return found, nil
+}
+
+type capturedFile struct {
+	path string
+	time time.Time
+	size int64
+}
+
+// captured returns the captured responses in a source dir ordered by time.
+func captured(dir string) ([]capturedFile, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "read the source capture dir")
+	}
+	var files []capturedFile
+	for _, info := range infos {
+		if info.IsDir() || !strings.HasSuffix(info.Name(), captureExt) {
+			continue
+		}
+		ns, err := strconv.ParseInt(strings.TrimSuffix(info.Name(), captureExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		files = append(files, capturedFile{
+			path: filepath.Join(dir, info.Name()),
+			time: time.Unix(0, ns),
+			size: info.Size(),
+		})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].time.Before(files[j].time) })
+	return files, nil
+}
+
+func sourceDir(source string) string {
+	hash := sha256.Sum256([]byte(source))
+	host := "unknown"
+	if u, err := url.Parse(source); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	return host + "-" + hex.EncodeToString(hash[:4])
+}
+
+// storer returns a function that stores the responses of a source
+// or nil when the capture is disabled.
+func (self *Capture) storer(symbol, source string) func([]byte) {
+	if self == nil {
+		return nil
+	}
+	return func(body []byte) {
+		if err := self.Store(symbol, source, time.Now(), body); err != nil {
+			level.Error(self.logger).Log("msg", "capturing response", "symbol", symbol, "err", err)
+		}
+	}
+}
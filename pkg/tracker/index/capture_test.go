@@ -0,0 +1,50 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package index
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// TestCapture ensures that only the latest responses are kept
+// and that they can be found by time.
+func TestCapture(t *testing.T) {
+	dir, err := ioutil.TempDir("", "capture")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	capture, err := NewCapture(logging.NewLogger(), CaptureConfig{Dir: dir, Responses: 3})
+	testutil.Ok(t, err)
+
+	start := time.Unix(1600000000, 0)
+	for i := 0; i < 5; i++ {
+		testutil.Ok(t, capture.Store("ETH/USD", "https://api.kraken.com/0/public/Ticker?pair=ETHUSD", start.Add(time.Duration(i)*time.Minute), []byte(`{"price":`+strconv.Itoa(i)+`}`)))
+	}
+	testutil.Ok(t, capture.Store("ETH/USD", "https://api.pro.coinbase.com/products/ETH-USD/ticker", start, []byte(`{"price":10}`)))
+
+	found, err := capture.Find("ETH/USD", start, start.Add(time.Hour))
+	testutil.Ok(t, err)
+	testutil.Equals(t, 4, len(found))
+	testutil.Equals(t, "api.pro.coinbase.com", found[0].Domain)
+	testutil.Equals(t, start.Add(2*time.Minute), found[1].Time)
+
+	body, err := found[3].Read()
+	testutil.Ok(t, err)
+	testutil.Equals(t, `{"price":4}`, string(body))
+
+	found, err = capture.Find("ETH/USD", start.Add(3*time.Minute), start.Add(3*time.Minute))
+	testutil.Ok(t, err)
+	testutil.Equals(t, 1, len(found))
+
+	found, err = capture.Find("BTC/USD", start, start.Add(time.Hour))
+	testutil.Ok(t, err)
+	testutil.Equals(t, 0, len(found))
+}
@@ -0,0 +1,87 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package index
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ECBParser parses the reference rates of the European Central Bank like
+// https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml
+// The param is the currency and the value is its rate for one EUR, e.g. USD for EUR/USD.
+type ECBParser struct {
+	currency string
+}
+
+func (self *ECBParser) Parse(input []byte) (float64, time.Time, error) {
+	envelope := struct {
+		Days []struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string `xml:"currency,attr"`
+				Rate     string `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube>Cube"`
+	}{}
+	if err := xml.Unmarshal(input, &envelope); err != nil {
+		return 0, time.Time{}, errors.Wrap(err, "xml unmarshal")
+	}
+	// The daily file has a single day and the historical files have the latest first.
+	if len(envelope.Days) == 0 {
+		return 0, time.Time{}, errors.New("no rates in the response")
+	}
+	day := envelope.Days[0]
+	ts, err := time.Parse("2006-01-02", day.Time)
+	if err != nil {
+		return 0, time.Time{}, errors.Wrapf(err, "parse the rates date:%v", day.Time)
+	}
+	for _, r := range day.Rates {
+		if r.Currency != self.currency {
+			continue
+		}
+		val, err := strconv.ParseFloat(r.Rate, 64)
+		if err != nil {
+			return 0, time.Time{}, errors.Wrapf(err, "rate needs to be a valid float:%v", r.Rate)
+		}
+		return val, ts, nil
+	}
+	return 0, time.Time{}, errors.Errorf("no rate for currency:%v", self.currency)
+}
+
+// MetalsAPIParser parses the latest rates of the metals APIs like https://metals-api.com
+// which are in ounces of the metal for one unit of the base currency.
+// The param is the metal symbol and the value is its price in the base currency, e.g. XAU with base USD for XAU/USD.
+type MetalsAPIParser struct {
+	metal string
+}
+
+func (self *MetalsAPIParser) Parse(input []byte) (float64, time.Time, error) {
+	resp := struct {
+		Timestamp int64
+		Rates     map[string]float64
+		Error     *struct {
+			Info string
+		}
+	}{}
+	if err := json.Unmarshal(input, &resp); err != nil {
+		return 0, time.Time{}, errors.Wrap(err, "json unmarshal")
+	}
+	if resp.Error != nil {
+		return 0, time.Time{}, errors.Errorf("metals api error:%v", resp.Error.Info)
+	}
+	rate, ok := resp.Rates[self.metal]
+	if !ok || rate <= 0 {
+		return 0, time.Time{}, errors.Errorf("no rate for metal:%v", self.metal)
+	}
+	ts := time.Now()
+	if resp.Timestamp > 0 {
+		ts = time.Unix(resp.Timestamp, 0)
+	}
+	return 1 / rate, ts, nil
+}
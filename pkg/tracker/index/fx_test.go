@@ -0,0 +1,38 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package index
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+func TestFXParsers(t *testing.T) {
+	ecb := `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2021-06-04">
+			<Cube currency="USD" rate="1.2169"/>
+			<Cube currency="JPY" rate="133.81"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+	val, ts, err := NewParser(Endpoint{Parser: ecbParser, Param: "USD"}).Parse([]byte(ecb))
+	testutil.Ok(t, err)
+	testutil.Equals(t, 1.2169, val)
+	testutil.Equals(t, time.Date(2021, 6, 4, 0, 0, 0, 0, time.UTC), ts)
+	_, _, err = NewParser(Endpoint{Parser: ecbParser, Param: "CHF"}).Parse([]byte(ecb))
+	testutil.NotOk(t, err)
+
+	metals := `{"success":true,"timestamp":1622808000,"base":"USD","rates":{"XAU":0.0005,"XAG":0.036}}`
+	val, ts, err = NewParser(Endpoint{Parser: metalsAPIParser, Param: "XAU"}).Parse([]byte(metals))
+	testutil.Ok(t, err)
+	testutil.Equals(t, 2000.0, val)
+	testutil.Equals(t, int64(1622808000), ts.Unix())
+	_, _, err = NewParser(Endpoint{Parser: metalsAPIParser, Param: "XAU"}).Parse([]byte(`{"success":false,"error":{"info":"invalid key"}}`))
+	testutil.NotOk(t, err)
+}
@@ -4,6 +4,7 @@
 package index
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -13,6 +14,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-kit/kit/log"
@@ -22,11 +24,19 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/prometheus/pkg/labels"
 	"github.com/prometheus/prometheus/pkg/timestamp"
-	"github.com/prometheus/prometheus/tsdb"
+	"github.com/tellor-io/telliot/pkg/calendar"
+	"github.com/tellor-io/telliot/pkg/chaos"
 	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/db"
+	"github.com/tellor-io/telliot/pkg/errclass"
 	"github.com/tellor-io/telliot/pkg/ethereum"
 	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/health"
 	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/plugin"
+	"github.com/tellor-io/telliot/pkg/tracker/clock"
+	"github.com/tellor-io/telliot/pkg/tuning"
+	"github.com/tellor-io/telliot/pkg/watchdog"
 	"github.com/tellor-io/telliot/pkg/web"
 	"github.com/yalp/jsonpath"
 )
@@ -37,41 +47,94 @@ const (
 	IntervalSuffix     = "interval"
 	ValueMetricName    = ComponentName + "_" + ValueSuffix
 	IntervalMetricName = ComponentName + "_" + IntervalSuffix
+	// WarmupName is the name of the pause of the submissions until the warmup is done.
+	WarmupName = ComponentName + ":warmup"
 )
 
 type Config struct {
 	LogLevel  string
 	Interval  format.Duration
 	IndexFile string
+	// Capture stores the latest raw responses of the http sources.
+	Capture CaptureConfig
+	// Retry is the retry policy of the http sources
+	// which can be overridden per endpoint in the index file.
+	Retry web.RetryConfig
+	// Proxy is the http, https or socks5 proxy url for the http sources, "tor" for a local Tor daemon.
+	// It can be overridden per endpoint in the index file.
+	Proxy string
+	// Cache honors the Cache-Control, ETag and Last-Modified headers of the http sources
+	// to not request unchanged responses again.
+	Cache bool
+	// Warmup gets the values of all sources once at startup.
+	Warmup WarmupConfig
+}
+
+type WarmupConfig struct {
+	// Enabled gets the values of all sources at once at startup
+	// before the sources continue at their intervals.
+	Enabled bool
+	// Timeout is how long to wait for the slow sources before the warmup is done.
+	Timeout format.Duration
+	// RequestIDs are the request IDs whose PSR readiness is reported in the health of the instance.
+	RequestIDs []int64
 }
 
 type IndexTracker struct {
-	logger      log.Logger
-	ctx         context.Context
-	stop        context.CancelFunc
-	tsDB        *tsdb.DB
-	cfg         Config
-	dataSources map[string][]DataSource
-	value       *prometheus.GaugeVec
-	getErrors   *prometheus.CounterVec
+	logger        log.Logger
+	ctx           context.Context
+	stop          context.CancelFunc
+	tsDB          *db.DB
+	cfg           Config
+	interval      *tuning.Duration
+	dataSources   map[string][]DataSource
+	value         *prometheus.GaugeVec
+	getErrors     *prometheus.CounterVec
+	getDuration   *prometheus.HistogramVec
+	dbAppendFails prometheus.Counter
+	closedSkips   *prometheus.CounterVec
+	lastPoll      health.Timestamp
+	clock         *clock.Tracker
+	watchdog      *watchdog.Watchdog
+	warmedUp      chan struct{}
+	warmupOnce    sync.Once
 }
 
 func New(
 	logger log.Logger,
 	ctx context.Context,
 	cfg Config,
-	tsDB *tsdb.DB,
+	tsDB *db.DB,
 	client contracts.ETHClient,
+	plugins *plugin.Plugins,
+	clock *clock.Tracker,
+	faults *chaos.Injector,
+	watchdog *watchdog.Watchdog,
 ) (*IndexTracker, error) {
 	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
 	if err != nil {
 		return nil, errors.Wrap(err, "apply filter logger")
 	}
 
-	dataSources, err := createDataSources(ctx, cfg, client)
+	var capture *Capture
+	if cfg.Capture.Enabled {
+		capture, err = NewCapture(logger, cfg.Capture)
+		if err != nil {
+			return nil, errors.Wrap(err, "create response capture")
+		}
+	}
+
+	dataSources, err := createDataSources(ctx, cfg, client, capture, web.NewFetchMetrics(ComponentName), plugins)
 	if err != nil {
 		return nil, errors.Wrap(err, "create data sources")
 	}
+	if faults != nil {
+		for _, sources := range dataSources {
+			for i, source := range sources {
+				sources[i] = &faultySource{DataSource: source, faults: faults}
+			}
+		}
+	}
 
 	ctx, stop := context.WithCancel(ctx)
 
@@ -82,12 +145,35 @@ func New(
 		dataSources: dataSources,
 		tsDB:        tsDB,
 		cfg:         cfg,
+		interval:    tuning.NewDuration(ComponentName+".interval", "The interval of the sources without their own interval in the index file.", cfg.Interval.Duration),
+		clock:       clock,
+		watchdog:    watchdog,
+		warmedUp:    make(chan struct{}),
 		getErrors: promauto.NewCounterVec(prometheus.CounterOpts{
 			Namespace: "telliot",
 			Subsystem: ComponentName,
 			Name:      "errors_total",
 			Help:      "The total number of get errors. Usually caused by API throtling.",
 		}, []string{"source"}),
+		getDuration: promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "get_duration_seconds",
+			Help:      "The time it takes to get a value from a data source",
+			Buckets:   prometheus.ExponentialBuckets(0.05, 2, 10),
+		}, []string{"symbol", "domain"}),
+		dbAppendFails: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "db_append_fails_total",
+			Help:      "The total number of failed appends to the DB",
+		}),
+		closedSkips: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "market_closed_skips_total",
+			Help:      "The total number of values that weren't recorded because the market of the symbol was closed",
+		}, []string{"symbol"}),
 		value: promauto.NewGaugeVec(prometheus.GaugeOpts{
 			Namespace: "telliot",
 			Subsystem: ComponentName,
@@ -99,7 +185,7 @@ func New(
 	}, nil
 }
 
-func createDataSources(ctx context.Context, cfg Config, client contracts.ETHClient) (map[string][]DataSource, error) {
+func createDataSources(ctx context.Context, cfg Config, client contracts.ETHClient, capture *Capture, fetchMetrics *web.FetchMetrics, plugins *plugin.Plugins) (map[string][]DataSource, error) {
 	// Load index file.
 	byteValue, err := ioutil.ReadFile(cfg.IndexFile)
 	if err != nil {
@@ -115,6 +201,14 @@ func createDataSources(ctx context.Context, cfg Config, client contracts.ETHClie
 	dataSources := make(map[string][]DataSource)
 
 	for symbol, api := range indexes {
+		if err := api.Market.Validate(); err != nil {
+			return nil, errors.Wrapf(err, "symbol:%v", symbol)
+		}
+		switch api.Closed {
+		case "", ClosedSkip, ClosedLive:
+		default:
+			return nil, errors.Errorf("unknown closed market policy:%v for symbol:%v, should be skip or live", api.Closed, symbol)
+		}
 		for _, endpoint := range api.Endpoints {
 			var err error
 			endpoint.URL = os.Expand(endpoint.URL, func(key string) string {
@@ -141,9 +235,30 @@ func createDataSources(ctx context.Context, cfg Config, client contracts.ETHClie
 			switch endpoint.Type {
 			case httpSource:
 				{
-					source = NewJSONapi(api.Interval.Duration, endpoint.URL, NewParser(endpoint))
+					retry := cfg.Retry.Override(endpoint.Retry)
+					if err := retry.Validate(); err != nil {
+						return nil, errors.Wrapf(err, "retry policy for symbol:%v url:%v", symbol, endpoint.URL)
+					}
+					proxy := cfg.Proxy
+					if endpoint.Proxy != "" {
+						proxy = endpoint.Proxy
+					}
+					proxyURL, err := web.ParseProxy(proxy)
+					if err != nil {
+						return nil, errors.Wrapf(err, "proxy for symbol:%v url:%v", symbol, endpoint.URL)
+					}
+					fetcher := web.NewFetcher(retry, proxyURL, cfg.Cache, fetchMetrics)
+					fetcher.SetName(endpoint.Name)
+					if endpoint.Auth != nil {
+						auth, err := web.NewSourceAuth(*endpoint.Auth)
+						if err != nil {
+							return nil, errors.Wrapf(err, "auth for symbol:%v url:%v", symbol, endpoint.URL)
+						}
+						fetcher.SetAuth(auth)
+					}
+					source = NewJSONapi(api.Interval.Duration, endpoint.URL, NewParser(endpoint), fetcher, capture.storer(symbol, endpoint.URL))
 					if strings.Contains(strings.ToLower(symbol), "volume") {
-						source = NewJSONapiVolume(api.Interval.Duration, endpoint.URL, NewParser(endpoint))
+						source = NewJSONapiVolume(api.Interval.Duration, endpoint.URL, NewParser(endpoint), fetcher, capture.storer(symbol, endpoint.URL))
 					}
 				}
 			case ethereumSource:
@@ -167,9 +282,19 @@ func createDataSources(ctx context.Context, cfg Config, client contracts.ETHClie
 						return nil, errors.Wrapf(err, "unknown source for on-chain index tracker")
 					}
 				}
+			case pluginSource:
+				{
+					source, err = plugins.DataSource(endpoint.Plugin, symbol, endpoint.URL, endpoint.Param)
+					if err != nil {
+						return nil, err
+					}
+				}
 			default:
 				return nil, errors.Errorf("unknown index type for index object:%v", endpoint.Type)
 			}
+			if api.Market != calendar.MarketCrypto && api.Closed != ClosedLive {
+				source = &marketSource{DataSource: source, market: api.Market, now: time.Now}
+			}
 
 			dataSources[symbol] = append(dataSources[symbol], source)
 		}
@@ -179,17 +304,23 @@ func createDataSources(ctx context.Context, cfg Config, client contracts.ETHClie
 
 }
 
-func (self *IndexTracker) Run() error {
+func (self *IndexTracker) Start() error {
+	warmedUp := false
+	if self.cfg.Warmup.Enabled {
+		self.warmup()
+		warmedUp = true
+	}
+	self.warmupOnce.Do(func() { close(self.warmedUp) })
+
 	delay := time.Second
 	for symbol, dataSources := range self.dataSources {
 		for _, dataSource := range dataSources {
-			// Use the default interval when not set.
-			interval := dataSource.Interval()
-			if int64(interval) == 0 {
-				interval = self.cfg.Interval.Duration
+			if warmedUp {
+				// The first value was recorded by the warmup.
+				go self.record(self.sourceInterval(dataSource), symbol, dataSource)
+				continue
 			}
-
-			go self.record(delay, symbol, interval, dataSource)
+			go self.record(delay, symbol, dataSource)
 			delay += time.Second
 		}
 	}
@@ -197,27 +328,69 @@ func (self *IndexTracker) Run() error {
 	return nil
 }
 
+// WarmedUp is closed once the warmup is done or right after starting without a warmup.
+func (self *IndexTracker) WarmedUp() <-chan struct{} {
+	return self.warmedUp
+}
+
+// warmup records the values of all sources at once so that
+// the PSR values are available before the first interval.
+// It returns when all sources are done or after the timeout
+// and the slow sources record their values in the background.
+func (self *IndexTracker) warmup() {
+	start := time.Now()
+	ts := timestamp.FromTime(self.clock.Now())
+
+	var wg sync.WaitGroup
+	var mtx sync.Mutex
+	var failed int
+	for symbol, dataSources := range self.dataSources {
+		for _, dataSource := range dataSources {
+			wg.Add(1)
+			go func(symbol string, dataSource DataSource) {
+				defer wg.Done()
+				if !self.poll(ts, symbol, dataSource) {
+					mtx.Lock()
+					failed++
+					mtx.Unlock()
+				}
+			}(symbol, dataSource)
+		}
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		level.Info(self.logger).Log("msg", "warmup done", "duration", time.Since(start), "failed", failed)
+	case <-time.After(self.cfg.Warmup.Timeout.Duration):
+		level.Warn(self.logger).Log("msg", "warmup timed out, continuing without the slow sources", "timeout", self.cfg.Warmup.Timeout)
+	case <-self.ctx.Done():
+	}
+}
+
 // record from all API calls.
 // The request delay is used to avoid rate limiting at startup
 // for when all API calls try to happen at the same time.
-func (self *IndexTracker) record(delay time.Duration, symbol string, interval time.Duration, dataSource DataSource) {
-	time.Sleep(delay)
+func (self *IndexTracker) record(delay time.Duration, symbol string, dataSource DataSource) {
+	select {
+	case <-self.ctx.Done():
+		return
+	case <-time.After(delay):
+	}
 
-	ticker := time.NewTicker(interval)
-	logger := log.With(self.logger, "source", dataSource.Source())
+	ticker := time.NewTicker(self.sourceInterval(dataSource))
+	defer ticker.Stop()
 
 	for {
-		ts := timestamp.FromTime(time.Now())
-
-		// Record the source interval to use it for the confidence calculation.
-		// Confidence = avg(actualSamplesCount/expectedMaxSamplesCount) for a given period.
-		if err := self.recordInterval(logger, ts, interval, symbol, dataSource); err != nil {
-			level.Error(logger).Log("msg", "record interval to the DB", "err", err)
-		}
+		// Applies the default interval when it was changed.
+		ticker.Reset(self.sourceInterval(dataSource))
 
-		if err := self.recordValue(logger, ts, interval, symbol, dataSource); err != nil {
-			level.Error(logger).Log("msg", "record value to the DB", "err", err)
-		}
+		// The values are for the chain time.
+		self.poll(timestamp.FromTime(self.clock.Now()), symbol, dataSource)
 
 		select {
 		case <-self.ctx.Done():
@@ -229,23 +402,39 @@ func (self *IndexTracker) record(delay time.Duration, symbol string, interval ti
 	}
 }
 
-func (self *IndexTracker) recordInterval(logger log.Logger, ts int64, interval time.Duration, symbol string, dataSource DataSource) (err error) {
+// poll records the interval and the value of a source and returns true when the value is recorded.
+func (self *IndexTracker) poll(ts int64, symbol string, dataSource DataSource) bool {
+	logger := log.With(self.logger, "source", dataSource.Source())
+	interval := self.sourceInterval(dataSource)
+
+	// Record the source interval to use it for the confidence calculation.
+	// Confidence = avg(actualSamplesCount/expectedMaxSamplesCount) for a given period.
+	if err := self.recordInterval(logger, ts, interval, symbol, dataSource); err != nil {
+		level.Error(logger).Log("msg", "record interval to the DB", "err", err)
+	}
+
+	if err := self.recordValue(logger, ts, interval, symbol, dataSource); err != nil {
+		class := errclass.Observe(ComponentName, err)
+		level.Error(logger).Log("msg", "record value to the DB", "class", class, "err", err)
+		return false
+	}
+	self.lastPoll.Set(time.Now())
+	return true
+}
+
+// sourceInterval returns the interval of the source or the default interval when not set.
+func (self *IndexTracker) sourceInterval(dataSource DataSource) time.Duration {
+	if interval := dataSource.Interval(); interval != 0 {
+		return interval
+	}
+	return self.interval.Get()
+}
+
+func (self *IndexTracker) recordInterval(logger log.Logger, ts int64, interval time.Duration, symbol string, dataSource DataSource) error {
 	source, err := url.Parse(dataSource.Source())
 	if err != nil {
 		return errors.Wrap(err, "parsing url from data source")
 	}
-	appender := self.tsDB.Appender(self.ctx)
-	defer func() { // An appender always needs to be committed or rolled back.
-		if err != nil {
-			if err := appender.Rollback(); err != nil {
-				level.Error(logger).Log("msg", "db rollback failed", "err", err)
-			}
-			return
-		}
-		if errC := appender.Commit(); errC != nil {
-			err = errors.Wrap(err, "db append commit failed")
-		}
-	}()
 
 	lbls := labels.Labels{
 		labels.Label{Name: "__name__", Value: IntervalMetricName},
@@ -256,37 +445,36 @@ func (self *IndexTracker) recordInterval(logger log.Logger, ts int64, interval t
 
 	sort.Sort(lbls) // This is important! The labels need to be sorted to avoid creating the same series with duplicate reference.
 
-	_, err = appender.Append(0, lbls, ts, float64(interval))
-	if err != nil {
-		return errors.Wrap(err, "append values to the DB")
-	}
-	return nil
+	return self.append(db.Sample{Labels: lbls, T: ts, V: float64(interval), Fails: self.dbAppendFails})
 }
 
-func (self *IndexTracker) recordValue(logger log.Logger, ts int64, interval time.Duration, symbol string, dataSource DataSource) (err error) {
-	value, err := dataSource.Get(self.ctx)
+func (self *IndexTracker) recordValue(logger log.Logger, ts int64, interval time.Duration, symbol string, dataSource DataSource) error {
+	source, err := url.Parse(dataSource.Source())
 	if err != nil {
-		return errors.Wrap(err, "getting values from data source")
+		return errors.Wrap(err, "parsing url from data source")
 	}
 
-	source, err := url.Parse(dataSource.Source())
+	start := time.Now()
+	// The value is only read when the fetch returned before its deadline.
+	var value float64
+	err = self.watchdog.Run(self.ctx, ComponentName+".fetch", func(ctx context.Context) error {
+		var err error
+		value, err = dataSource.Get(ctx)
+		return err
+	})
+	self.getDuration.With(prometheus.Labels{
+		"symbol": format.SanitizeMetricName(symbol),
+		"domain": source.Host,
+	}).Observe(time.Since(start).Seconds())
+	if errors.Is(err, calendar.ErrMarketClosed) {
+		level.Debug(logger).Log("msg", "market closed, skipped value", "symbol", symbol)
+		self.closedSkips.With(prometheus.Labels{"symbol": format.SanitizeMetricName(symbol)}).Inc()
+		return nil
+	}
 	if err != nil {
-		return errors.Wrap(err, "parsing url from data source")
+		self.getErrors.With(prometheus.Labels{"source": dataSource.Source()}).Inc()
+		return errors.Wrap(err, "getting values from data source")
 	}
-	appender := self.tsDB.Appender(self.ctx)
-	defer func() { // An appender always needs to be committed or rolled back.
-		if err != nil {
-			if err := appender.Rollback(); err != nil {
-				level.Error(logger).Log("msg", "db rollback failed", "err", err)
-				return
-			}
-			level.Debug(logger).Log("msg", "added interval to db", "source", dataSource.Source(), "host", source.Host, "symbol", format.SanitizeMetricName(symbol), "value", value, "interval", interval)
-			return
-		}
-		if errC := appender.Commit(); errC != nil {
-			err = errors.Wrap(err, "db append commit failed")
-		}
-	}()
 
 	lbls := labels.Labels{
 		labels.Label{Name: "__name__", Value: ValueMetricName},
@@ -296,10 +484,10 @@ func (self *IndexTracker) recordValue(logger log.Logger, ts int64, interval time
 	}
 	sort.Sort(lbls) // This is important! The labels need to be sorted to avoid creating the same series with duplicate reference.
 
-	_, err = appender.Append(0, lbls, ts, value)
-	if err != nil {
-		return errors.Wrap(err, "append values to the DB")
+	if err := self.append(db.Sample{Labels: lbls, T: ts, V: value, Fails: self.dbAppendFails}); err != nil {
+		return err
 	}
+	level.Debug(logger).Log("msg", "added value to db", "host", source.Host, "symbol", format.SanitizeMetricName(symbol), "value", value, "interval", interval)
 
 	self.value.With(
 		prometheus.Labels{
@@ -312,6 +500,18 @@ func (self *IndexTracker) recordValue(logger log.Logger, ts int64, interval time
 	return nil
 }
 
+// append commits a sample to the DB within the deadline of the DB commits.
+func (self *IndexTracker) append(sample db.Sample) error {
+	return self.watchdog.Run(self.ctx, "db.commit", func(ctx context.Context) error {
+		return self.tsDB.Append(ctx, sample)
+	})
+}
+
+// LastPoll returns the time of the last successful poll of any data source.
+func (self *IndexTracker) LastPoll() time.Time {
+	return self.lastPoll.Get()
+}
+
 func (self *IndexTracker) Stop() {
 	self.stop()
 }
@@ -322,15 +522,19 @@ type IndexType string
 const (
 	httpSource     IndexType = "http"
 	ethereumSource IndexType = "ethereum"
+	// pluginSource is a data source provided by a plugin.
+	pluginSource IndexType = "plugin"
 )
 
 // ParserType -> index parser for Api.
 type ParserType string
 
 const (
-	jsonPathParser ParserType = "jsonPath"
-	uniswapParser  ParserType = "Uniswap"
-	balancerParser ParserType = "Balancer"
+	jsonPathParser  ParserType = "jsonPath"
+	uniswapParser   ParserType = "Uniswap"
+	balancerParser  ParserType = "Balancer"
+	ecbParser       ParserType = "ECB"
+	metalsAPIParser ParserType = "MetalsAPI"
 )
 
 type Endpoint struct {
@@ -338,6 +542,18 @@ type Endpoint struct {
 	Type   IndexType
 	Parser ParserType
 	Param  string
+	// Retry overrides the non zero fields of the default retry policy for http endpoints.
+	Retry *web.RetryConfig
+	// Proxy overrides the global proxy for http endpoints, "direct" to not use a proxy.
+	Proxy string
+	// Auth signs the requests or adds the headers and tokens for the http endpoints of the providers that require it.
+	Auth *web.SourceAuthConfig
+	// Plugin is the name of the plugin for the plugin endpoints.
+	// The URL and Param are passed to the plugin.
+	Plugin string
+	// Name is the source label of the retry and cache metrics of the http endpoints,
+	// the host of the URL when empty.
+	Name string
 }
 
 // Apis will be used in parsing index file.
@@ -345,7 +561,12 @@ type Apis struct {
 	// The recommended interval for calling the Get method.
 	// Some APIs will return an error if called more often
 	// Due to API rate limiting of the provider.
-	Interval  format.Duration
+	Interval format.Duration
+	// Market sets the trading hours of the symbol, open all the time when empty.
+	Market calendar.Market
+	// Closed is the policy for the values while the market is closed,
+	// skip by default so that the last close isn't recorded as a live value.
+	Closed    string
 	Endpoints []Endpoint
 }
 
@@ -353,13 +574,9 @@ type Apis struct {
 // This is to avoid double counting volumes for the same time period.
 // Another way is to skip adding the data, but this messes up the confidence calculations
 // which counts total added data points.
-func NewJSONapiVolume(interval time.Duration, url string, parser Parser) *JSONapiVolume {
+func NewJSONapiVolume(interval time.Duration, url string, parser Parser, fetcher *web.Fetcher, capture func([]byte)) *JSONapiVolume {
 	return &JSONapiVolume{
-		JSONapi: &JSONapi{
-			url:      url,
-			interval: interval,
-			Parser:   parser,
-		},
+		JSONapi: NewJSONapi(interval, url, parser, fetcher, capture),
 	}
 }
 
@@ -369,9 +586,9 @@ type JSONapiVolume struct {
 }
 
 func (self *JSONapiVolume) Get(ctx context.Context) (float64, error) {
-	vals, err := web.Fetch(ctx, self.url)
+	vals, changed, err := self.fetch(ctx)
 	if err != nil {
-		return 0, errors.Wrapf(err, "fetching data from API url:%v", self.url)
+		return 0, err
 	}
 	val, ts, err := self.Parse(vals)
 	if err != nil {
@@ -379,7 +596,7 @@ func (self *JSONapiVolume) Get(ctx context.Context) (float64, error) {
 	}
 
 	// Use 0 value for the volume as this has already been requested.
-	if self.lastTS.Equal(ts) {
+	if self.lastTS.Equal(ts) || !changed {
 		val = 0
 	}
 	self.lastTS = ts
@@ -388,29 +605,54 @@ func (self *JSONapiVolume) Get(ctx context.Context) (float64, error) {
 
 }
 
-func NewJSONapi(interval time.Duration, url string, parser Parser) *JSONapi {
+// NewJSONapi creates a http data source.
+// The capture is called with every response when not nil.
+func NewJSONapi(interval time.Duration, url string, parser Parser, fetcher *web.Fetcher, capture func([]byte)) *JSONapi {
 	return &JSONapi{
 		url:      url,
 		interval: interval,
 		Parser:   parser,
+		fetcher:  fetcher,
+		capture:  capture,
 	}
 }
 
 type JSONapi struct {
 	url      string
 	interval time.Duration
+	fetcher  *web.Fetcher
+	capture  func([]byte)
+	last     []byte
 	Parser
 }
 
+// Get returns the value of the latest response.
+// An unchanged response still returns its value
+// so that the tracker writes a heartbeat sample for the confidence calculations.
 func (self *JSONapi) Get(ctx context.Context) (float64, error) {
-	vals, err := web.Fetch(ctx, self.url)
+	vals, _, err := self.fetch(ctx)
 	if err != nil {
-		return 0, errors.Wrapf(err, "fetching data from API url:%v", self.url)
+		return 0, err
 	}
 	val, _, err := self.Parse(vals)
 	return val, err
 }
 
+// fetch returns the response and whether it changed since the last fetch.
+// Unchanged responses are not captured again.
+func (self *JSONapi) fetch(ctx context.Context) ([]byte, bool, error) {
+	vals, err := self.fetcher.Fetch(ctx, self.url)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "fetching data from API url:%v", self.url)
+	}
+	changed := !bytes.Equal(vals, self.last)
+	self.last = vals
+	if changed && self.capture != nil {
+		self.capture(vals)
+	}
+	return vals, changed, nil
+}
+
 func (self *JSONapi) Interval() time.Duration {
 	return self.interval
 }
@@ -430,6 +672,19 @@ type DataSource interface {
 	Interval() time.Duration
 }
 
+// faultySource fails some of the gets with the injected faults.
+type faultySource struct {
+	DataSource
+	faults *chaos.Injector
+}
+
+func (self *faultySource) Get(ctx context.Context) (float64, error) {
+	if err := self.faults.SourceError(self.Source()); err != nil {
+		return 0, err
+	}
+	return self.DataSource.Get(ctx)
+}
+
 type Parser interface {
 	Parse([]byte) (value float64, timestamp time.Time, err error)
 }
@@ -500,6 +755,10 @@ func NewParser(t Endpoint) Parser {
 		return &JsonPathParser{
 			param: t.Param,
 		}
+	case ecbParser:
+		return &ECBParser{currency: t.Param}
+	case metalsAPIParser:
+		return &MetalsAPIParser{metal: t.Param}
 	default:
 		return nil
 	}
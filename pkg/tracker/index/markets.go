@@ -0,0 +1,34 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package index
+
+import (
+	"context"
+	"time"
+
+	"github.com/tellor-io/telliot/pkg/calendar"
+)
+
+// The policies for the values of a source while its market is closed.
+const (
+	// ClosedSkip records no values while the market is closed
+	// as the sources keep returning the last close which isn't a live value.
+	ClosedSkip = "skip"
+	// ClosedLive records the values as if the market was open.
+	ClosedLive = "live"
+)
+
+// marketSource is a source that returns calendar.ErrMarketClosed while its market is closed.
+type marketSource struct {
+	DataSource
+	market calendar.Market
+	now    func() time.Time
+}
+
+func (self *marketSource) Get(ctx context.Context) (float64, error) {
+	if !self.market.Open(self.now()) {
+		return 0, calendar.ErrMarketClosed
+	}
+	return self.DataSource.Get(ctx)
+}
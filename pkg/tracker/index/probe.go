@@ -0,0 +1,103 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package index
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/plugin"
+)
+
+// ProbeMaxDeviation is the max deviation of a value from the median of its symbol
+// as a fraction of the median before the value is reported as implausible.
+const ProbeMaxDeviation = 0.5
+
+// ProbeResult is a single value of a data source.
+type ProbeResult struct {
+	Symbol string
+	Source string
+	Value  float64
+	Err    error
+}
+
+// Probe gets a single value from every data source in the index file without recording it
+// so that the sources can be checked before starting the tracker.
+// A value that isn't positive or deviates too much from the median
+// of the other sources of the symbol is returned with an error.
+func Probe(ctx context.Context, cfg Config, client contracts.ETHClient, plugins *plugin.Plugins) ([]ProbeResult, error) {
+	dataSources, err := createDataSources(ctx, cfg, client, nil, nil, plugins)
+	if err != nil {
+		return nil, errors.Wrap(err, "create data sources")
+	}
+
+	var (
+		mtx     sync.Mutex
+		wg      sync.WaitGroup
+		results []ProbeResult
+	)
+	for symbol, sources := range dataSources {
+		for _, source := range sources {
+			wg.Add(1)
+			go func(symbol string, source DataSource) {
+				defer wg.Done()
+				val, err := source.Get(ctx)
+				mtx.Lock()
+				defer mtx.Unlock()
+				results = append(results, ProbeResult{Symbol: symbol, Source: source.Source(), Value: val, Err: err})
+			}(symbol, source)
+		}
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Symbol != results[j].Symbol {
+			return results[i].Symbol < results[j].Symbol
+		}
+		return results[i].Source < results[j].Source
+	})
+	checkPlausible(results)
+	return results, nil
+}
+
+// checkPlausible sets the error of the values that are not plausible.
+func checkPlausible(results []ProbeResult) {
+	values := make(map[string][]float64)
+	for _, r := range results {
+		if r.Err == nil && r.Value > 0 && !math.IsInf(r.Value, 0) {
+			values[r.Symbol] = append(values[r.Symbol], r.Value)
+		}
+	}
+	for i, r := range results {
+		if r.Err != nil {
+			continue
+		}
+		if math.IsNaN(r.Value) || math.IsInf(r.Value, 0) || r.Value <= 0 {
+			results[i].Err = errors.Errorf("implausible value:%v", r.Value)
+			continue
+		}
+		// A single source has nothing to compare with.
+		if len(values[r.Symbol]) < 2 {
+			continue
+		}
+		median := median(values[r.Symbol])
+		if dev := math.Abs(r.Value-median) / median; dev > ProbeMaxDeviation {
+			results[i].Err = errors.Errorf("value:%v deviates %.0f%% from the median:%v of the other sources", r.Value, dev*100, median)
+		}
+	}
+}
+
+func median(vals []float64) float64 {
+	sorted := append([]float64{}, vals...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,36 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package index
+
+import (
+	"math"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// TestCheckPlausible ensures that the values which are not positive
+// or far from the other sources of the symbol are reported.
+func TestCheckPlausible(t *testing.T) {
+	results := []ProbeResult{
+		{Symbol: "ETH/USD", Source: "a", Value: 2000},
+		{Symbol: "ETH/USD", Source: "b", Value: 2010},
+		{Symbol: "ETH/USD", Source: "c", Value: 20},
+		{Symbol: "ETH/USD", Source: "d", Err: errors.New("timeout")},
+		{Symbol: "BTC/USD", Source: "a", Value: 0},
+		{Symbol: "BTC/USD", Source: "b", Value: math.NaN()},
+		{Symbol: "TRB/USD", Source: "a", Value: 50},
+	}
+	checkPlausible(results)
+
+	var failed []string
+	for _, r := range results {
+		if r.Err != nil {
+			failed = append(failed, r.Symbol+" "+r.Source)
+		}
+	}
+	testutil.Equals(t, []string{"ETH/USD c", "ETH/USD d", "BTC/USD a", "BTC/USD b"}, failed)
+	testutil.Equals(t, "timeout", results[3].Err.Error())
+}
@@ -0,0 +1,170 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package node
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/notify"
+	"github.com/tellor-io/telliot/pkg/tuning"
+	"github.com/tellor-io/telliot/pkg/watchdog"
+)
+
+const ComponentName = "nodeTracker"
+
+type Config struct {
+	Enabled  bool
+	LogLevel string
+	Interval format.Duration
+	// UnreachableAfter is how long the node must fail all requests before it is reported as unreachable,
+	// so that a single failed request or a node restart doesn't page.
+	UnreachableAfter format.Duration
+}
+
+// Tracker checks that the ethereum node responds and
+// notifies when it stays unreachable and when it is back.
+type Tracker struct {
+	ctx      context.Context
+	close    context.CancelFunc
+	logger   log.Logger
+	watchdog *watchdog.Watchdog
+	cfg      Config
+	interval *tuning.Duration
+	client   contracts.ETHClient
+	node     string
+	notifier notify.Notifier
+
+	// failingSince is the time of the first failure, zero while the node responds.
+	failingSince time.Time
+	unreachable  bool
+
+	up prometheus.Gauge
+}
+
+// New creates a node tracker. Only the host of the node url is in the notifications
+// as the rest can hold an API key.
+func New(
+	logger log.Logger,
+	ctx context.Context,
+	cfg Config,
+	client contracts.ETHClient,
+	nodeURL string,
+	notifier notify.Notifier,
+	watchdog *watchdog.Watchdog,
+) (*Tracker, error) {
+	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	logger = log.With(logger, "component", ComponentName)
+	if cfg.Interval.Duration == 0 {
+		return nil, errors.New("the node tracker needs an interval")
+	}
+	node := "unknown"
+	if u, err := url.Parse(nodeURL); err == nil && u.Host != "" {
+		node = u.Host
+	}
+	ctx, close := context.WithCancel(ctx)
+
+	return &Tracker{
+		ctx:      ctx,
+		close:    close,
+		watchdog: watchdog,
+		interval: tuning.NewDuration(ComponentName+".interval", "How often the ethereum node is checked.", cfg.Interval.Duration),
+		logger:   logger,
+		cfg:      cfg,
+		client:   client,
+		node:     node,
+		notifier: notifier,
+		up: promauto.NewGauge(prometheus.GaugeOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "up",
+			Help:      "1 when the last request to the ethereum node succeeded",
+		}),
+	}, nil
+}
+
+func (self *Tracker) Start() error {
+	level.Info(self.logger).Log("msg", "starting", "interval", self.cfg.Interval, "unreachableAfter", self.cfg.UnreachableAfter)
+
+	ticker := time.NewTicker(self.interval.Get())
+	defer ticker.Stop()
+	for {
+		stop := self.watchdog.Watch(ComponentName)
+		self.check()
+		stop()
+		select {
+		case <-self.ctx.Done():
+			return nil
+		case <-ticker.C:
+			// Applies the interval when it was changed.
+			ticker.Reset(self.interval.Get())
+		}
+	}
+}
+
+func (self *Tracker) Stop() {
+	self.close()
+}
+
+func (self *Tracker) check() {
+	ctx, cncl := context.WithTimeout(self.ctx, self.interval.Get())
+	defer cncl()
+	_, err := self.client.HeaderByNumber(ctx, nil)
+	if self.ctx.Err() != nil {
+		return
+	}
+
+	if err == nil {
+		self.up.Set(1)
+		if self.unreachable {
+			level.Info(self.logger).Log("msg", "node is reachable again", "downtime", time.Since(self.failingSince).Round(time.Second))
+			self.notify(notify.SeverityInfo, notify.EventNodeReachable,
+				fmt.Sprintf("Ethereum node %v is reachable again", self.node),
+				fmt.Sprintf("The node responds again after %v.", time.Since(self.failingSince).Round(time.Second)),
+			)
+		}
+		self.failingSince = time.Time{}
+		self.unreachable = false
+		return
+	}
+
+	self.up.Set(0)
+	level.Warn(self.logger).Log("msg", "node request failed", "err", err)
+	if self.failingSince.IsZero() {
+		self.failingSince = time.Now()
+	}
+	if self.unreachable || time.Since(self.failingSince) < self.cfg.UnreachableAfter.Duration {
+		return
+	}
+	self.unreachable = true
+	self.notify(notify.SeverityCritical, notify.EventNodeUnreachable,
+		fmt.Sprintf("Ethereum node %v is unreachable", self.node),
+		fmt.Sprintf("All requests to the node failed for %v, last error:%v. Mining and submitting can't continue until it is back.", time.Since(self.failingSince).Round(time.Second), err),
+	)
+}
+
+func (self *Tracker) notify(severity notify.Severity, event, title, body string) {
+	if err := self.notifier.Notify(self.ctx, notify.Message{
+		Event:    event,
+		Severity: severity,
+		Title:    title,
+		Body:     body,
+		Data:     map[string]string{"node": self.node},
+	}); err != nil {
+		level.Error(self.logger).Log("msg", "sending notification", "event", event, "err", err)
+	}
+}
@@ -6,10 +6,12 @@ package profit
 import (
 	"context"
 	"math/big"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/bluele/gcache"
+	eth "github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
@@ -20,8 +22,12 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/timestamp"
 	"github.com/tellor-io/telliot/pkg/contracts"
 	"github.com/tellor-io/telliot/pkg/contracts/tellor"
+	"github.com/tellor-io/telliot/pkg/db"
+	"github.com/tellor-io/telliot/pkg/ethereum"
 	"github.com/tellor-io/telliot/pkg/logging"
 )
 
@@ -31,10 +37,14 @@ type Config struct {
 	LogLevel string
 }
 
+const balancesRefreshInterval = 5 * time.Minute
+
 type ProfitTracker struct {
 	client           contracts.ETHClient
+	fetcher          *ethereum.LogFetcher
 	logger           log.Logger
 	contractInstance *contracts.ITellor
+	filterer         *tellor.TellorFilterer
 	abi              abi.ABI
 	ctx              context.Context
 	stop             context.CancelFunc
@@ -44,18 +54,26 @@ type ProfitTracker struct {
 	cacheTXsProfit     gcache.Cache
 	cacheTXsCost       gcache.Cache
 	cacheTXsCostFailed gcache.Cache
+	cacheTXsTips       gcache.Cache
 	lastFailedBlock    int64
 
+	tsDB          *db.DB
+	dbAppendFails prometheus.Counter
+
 	submitProfit *prometheus.GaugeVec
 	submitCost   *prometheus.GaugeVec
+	tipsCost     *prometheus.GaugeVec
 	balances     *prometheus.GaugeVec
+	reconnects   *prometheus.CounterVec
 }
 
 func NewProfitTracker(
 	logger log.Logger,
 	ctx context.Context,
 	cfg Config,
+	tsDB *db.DB,
 	client contracts.ETHClient,
+	fetcher *ethereum.LogFetcher,
 	contractInstance *contracts.ITellor,
 	addrs []common.Address,
 ) (*ProfitTracker, error) {
@@ -69,6 +87,10 @@ func NewProfitTracker(
 	if err != nil {
 		return nil, errors.Wrap(err, "abi read")
 	}
+	filterer, err := tellor.NewTellorFilterer(contractInstance.Address, client)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting instance")
+	}
 
 	addrsMap := make(map[common.Address]struct{})
 	for _, addr := range addrs {
@@ -77,8 +99,10 @@ func NewProfitTracker(
 	ctx, cncl := context.WithCancel(ctx)
 	return &ProfitTracker{
 		client:           client,
+		fetcher:          fetcher,
 		logger:           logger,
 		contractInstance: contractInstance,
+		filterer:         filterer,
 		abi:              abi,
 		addrs:            addrs,
 		addrsMap:         addrsMap,
@@ -88,6 +112,15 @@ func NewProfitTracker(
 		cacheTXsProfit:     gcache.New(50).LRU().Build(),
 		cacheTXsCost:       gcache.New(50).LRU().Build(),
 		cacheTXsCostFailed: gcache.New(20).LRU().Build(),
+		cacheTXsTips:       gcache.New(50).LRU().Build(),
+
+		tsDB: tsDB,
+		dbAppendFails: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "db_append_fails_total",
+			Help:      "The total number of failed appends to the DB",
+		}),
 
 		submitProfit: promauto.NewGaugeVec(prometheus.GaugeOpts{
 			Namespace: "telliot",
@@ -105,6 +138,14 @@ func NewProfitTracker(
 		},
 			[]string{"addr"},
 		),
+		tipsCost: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "tips_cost",
+			Help:      "Accumulated TRB amount spent on tips for all registered addresses",
+		},
+			[]string{"addr"},
+		),
 		balances: promauto.NewGaugeVec(prometheus.GaugeOpts{
 			Namespace: "telliot",
 			Subsystem: ComponentName,
@@ -113,36 +154,59 @@ func NewProfitTracker(
 		},
 			[]string{"addr", "token"},
 		),
+		reconnects: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "subscription_reconnects_total",
+			Help:      "The total number of event re-subscriptions",
+		},
+			[]string{"event"},
+		),
 	}, nil
 }
 
 func (self *ProfitTracker) Start() error {
 	level.Info(self.logger).Log("msg", "starting")
 
+	self.updateBalances(level.Info(self.logger))
+
+	go self.monitorCost()
+	go self.monitorReward()
+	go self.monitorCostFailed()
+	go self.monitorTips()
+
+	// The balances are also updated on every event,
+	// but this catches changes made outside of the monitored events.
+	ticker := time.NewTicker(balancesRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-self.ctx.Done():
+			return nil
+		case <-ticker.C:
+			self.updateBalances(level.Debug(self.logger))
+		}
+	}
+}
+
+func (self *ProfitTracker) updateBalances(logger log.Logger) {
 	for _, addr := range self.addrs {
 		balance, err := self.getTRBBalance(addr)
 		if err != nil {
-			level.Error(self.logger).Log("msg", "getting initial TRB balance", "addr", addr.String(), "err", err)
+			level.Error(self.logger).Log("msg", "getting TRB balance", "addr", addr.String(), "err", err)
+		} else {
+			logger.Log("msg", "TRB balance", "addr", addr.String(), "balance", balance)
+			self.balances.With(prometheus.Labels{"addr": addr.String(), "token": "TRB"}).(prometheus.Gauge).Set(balance)
 		}
-		level.Info(self.logger).Log("msg", "initial TRB balance", "addr", addr.String(), "balance", balance)
-		self.balances.With(prometheus.Labels{"addr": addr.String(), "token": "TRB"}).(prometheus.Gauge).Set(balance)
-	}
 
-	for _, addr := range self.addrs {
-		balance, err := self.getETHBalance(addr)
+		balance, err = self.getETHBalance(addr)
 		if err != nil {
-			level.Error(self.logger).Log("msg", "getting initial ETH balance", "addr", addr.String(), "err", err)
+			level.Error(self.logger).Log("msg", "getting ETH balance", "addr", addr.String(), "err", err)
+		} else {
+			logger.Log("msg", "ETH balance", "addr", addr.String(), "balance", balance)
+			self.balances.With(prometheus.Labels{"addr": addr.String(), "token": "ETH"}).(prometheus.Gauge).Set(balance)
 		}
-		level.Info(self.logger).Log("msg", "initial ETH balance", "addr", addr.String(), "balance", balance)
-		self.balances.With(prometheus.Labels{"addr": addr.String(), "token": "ETH"}).(prometheus.Gauge).Set(balance)
 	}
-
-	go self.monitorCost()
-	go self.monitorReward()
-	go self.monitorCostFailed()
-
-	<-self.ctx.Done()
-	return nil
 }
 
 func (self *ProfitTracker) Stop() {
@@ -156,7 +220,8 @@ func (self *ProfitTracker) monitorReward() {
 	var sub event.Subscription
 	events := make(chan *tellor.TellorTransferred)
 
-	logger := log.With(self.logger, "event", "Transfer")
+	eventName := "Transfer"
+	logger := log.With(self.logger, "event", eventName)
 
 	for {
 		select {
@@ -172,6 +237,8 @@ func (self *ProfitTracker) monitorReward() {
 		}
 		break
 	}
+	// The events since this block are backfilled after a re-subscription.
+	last := self.head(logger)
 
 	for {
 		select {
@@ -200,9 +267,21 @@ func (self *ProfitTracker) monitorReward() {
 				}
 				break
 			}
+			self.reconnects.With(prometheus.Labels{"event": eventName}).Inc()
 			level.Info(logger).Log("msg", "re-subscribed to events")
+			last = self.backfill(logger, eventName, last, func(l types.Log) error {
+				event, err := self.filterer.ParseTransferred(l)
+				if err != nil {
+					return err
+				}
+				if !self.cacheTXsProfit.Has(txIDTransfer(event)) {
+					self.setProfitWhenConfirmed(log.With(logger, "addr", event.To.String()[:6], "tx", event.Raw.TxHash), event)
+				}
+				return nil
+			}, []interface{}{common.Address{}}, self.addrsQuery())
 		case event := <-events:
 			logger := log.With(logger, "addr", event.To.String()[:6], "tx", event.Raw.TxHash)
+			last = event.Raw.BlockNumber
 
 			if event.Raw.Removed {
 				val, err := self.cacheTXsProfit.Get(txIDTransfer(event))
@@ -225,7 +304,8 @@ func (self *ProfitTracker) monitorCost() {
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
 
-	logger := log.With(self.logger, "event", "NonceSubmitted")
+	eventName := "NonceSubmitted"
+	logger := log.With(self.logger, "event", eventName)
 
 	var sub event.Subscription
 	events := make(chan *tellor.TellorNonceSubmitted)
@@ -244,6 +324,8 @@ func (self *ProfitTracker) monitorCost() {
 		}
 		break
 	}
+	// The events since this block are backfilled after a re-subscription.
+	last := self.head(logger)
 
 	for {
 		select {
@@ -272,9 +354,21 @@ func (self *ProfitTracker) monitorCost() {
 				}
 				break
 			}
+			self.reconnects.With(prometheus.Labels{"event": eventName}).Inc()
 			level.Info(logger).Log("msg", "re-subscribed to events")
+			last = self.backfill(logger, eventName, last, func(l types.Log) error {
+				event, err := self.filterer.ParseNonceSubmitted(l)
+				if err != nil {
+					return err
+				}
+				if !self.cacheTXsCost.Has(txIDNonceSubmit(event)) {
+					self.setCostWhenConfirmed(log.With(logger, "addr", event.Miner.String()[:6], "tx", event.Raw.TxHash), event)
+				}
+				return nil
+			}, self.addrsQuery())
 		case event := <-events:
 			logger := log.With(logger, "addr", event.Miner.String()[:6], "tx", event.Raw.TxHash)
+			last = event.Raw.BlockNumber
 
 			if event.Raw.Removed {
 				val, err := self.cacheTXsCost.Get(txIDNonceSubmit(event))
@@ -292,12 +386,119 @@ func (self *ProfitTracker) monitorCost() {
 	}
 }
 
+func (self *ProfitTracker) monitorTips() {
+	var err error
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	eventName := "TipAdded"
+	logger := log.With(self.logger, "event", eventName)
+
+	var sub event.Subscription
+	events := make(chan *tellor.TellorTipAdded)
+
+	for {
+		select {
+		case <-self.ctx.Done():
+			return
+		default:
+		}
+		sub, err = self.tipAddedSub(events)
+		if err != nil {
+			level.Error(logger).Log("msg", "initial subscribing to events failed", "err", err)
+			<-ticker.C
+			continue
+		}
+		break
+	}
+	// The events since this block are backfilled after a re-subscription.
+	last := self.head(logger)
+
+	for {
+		select {
+		case <-self.ctx.Done():
+			return
+		case err := <-sub.Err():
+			if err != nil {
+				level.Error(logger).Log(
+					"msg",
+					"subscription error",
+					"err", err)
+			}
+
+			// Trying to resubscribe until it succeeds.
+			for {
+				select {
+				case <-self.ctx.Done():
+					return
+				default:
+				}
+				sub, err = self.tipAddedSub(events)
+				if err != nil {
+					level.Error(logger).Log("msg", "re-subscribing to events failed", "err", err)
+					<-ticker.C
+					continue
+				}
+				break
+			}
+			self.reconnects.With(prometheus.Labels{"event": eventName}).Inc()
+			level.Info(logger).Log("msg", "re-subscribed to events")
+			last = self.backfill(logger, eventName, last, func(l types.Log) error {
+				event, err := self.filterer.ParseTipAdded(l)
+				if err != nil {
+					return err
+				}
+				if !self.cacheTXsTips.Has(txIDTipAdded(event)) {
+					self.addTip(log.With(logger, "addr", event.Sender.String()[:6], "tx", event.Raw.TxHash), event)
+				}
+				return nil
+			}, self.addrsQuery())
+		case event := <-events:
+			logger := log.With(logger, "addr", event.Sender.String()[:6], "tx", event.Raw.TxHash)
+			last = event.Raw.BlockNumber
+
+			if event.Raw.Removed {
+				val, err := self.cacheTXsTips.Get(txIDTipAdded(event))
+				if err != nil {
+					level.Error(logger).Log("msg", "getting cache amount for removed event", "err", err)
+					continue
+				}
+				level.Debug(logger).Log("msg", "removing tip from dropped event", "amount", val.(float64))
+				self.tipsCost.With(prometheus.Labels{"addr": event.Sender.String()}).(prometheus.Gauge).Sub(val.(float64))
+				continue
+			}
+
+			self.addTip(logger, event)
+		}
+	}
+}
+
+func (self *ProfitTracker) addTip(logger log.Logger, event *tellor.TellorTipAdded) {
+	tip, _ := new(big.Float).Quo(new(big.Float).SetInt(event.Tip), big.NewFloat(1e18)).Float64()
+	level.Debug(logger).Log("msg", "adding tip cost", "amount", tip, "id", event.RequestId)
+	self.tipsCost.With(prometheus.Labels{"addr": event.Sender.String()}).(prometheus.Gauge).Add(tip)
+	self.record(logger, "tip", event.Sender, tip, event.Raw)
+
+	if err := self.cacheTXsTips.Set(txIDTipAdded(event), tip); err != nil {
+		level.Error(logger).Log("msg", "adding tip to the cache", "err", err)
+	}
+
+	balance, err := self.getTRBBalance(event.Sender)
+	if err != nil {
+		level.Error(logger).Log("msg", "getting TRB balance", "err", err)
+		return
+	}
+	level.Debug(logger).Log("msg", "new TRB balance", "balance", balance)
+	self.balances.With(prometheus.Labels{"addr": event.Sender.String(), "token": "TRB"}).(prometheus.Gauge).Set(balance)
+}
+
 func (self *ProfitTracker) monitorCostFailed() {
 	var err error
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
 
-	logger := log.With(self.logger, "event", "NewHead")
+	eventName := "NewHead"
+	logger := log.With(self.logger, "event", eventName)
 
 	var sub event.Subscription
 	events := make(chan *types.Header)
@@ -345,6 +546,7 @@ func (self *ProfitTracker) monitorCostFailed() {
 				}
 				break
 			}
+			self.reconnects.With(prometheus.Labels{"event": eventName}).Inc()
 			level.Info(logger).Log("msg", "re-subscribed to events")
 		case event := <-events:
 			if event.Bloom.Test(self.abi.Events["NonceSubmitted"].ID.Bytes()) {
@@ -398,6 +600,7 @@ func (self *ProfitTracker) monitorCostFailed() {
 						cost = cost / 1e18
 						level.Debug(logger).Log("msg", "adding cost", "amount", cost)
 						self.submitCost.With(prometheus.Labels{"addr": addr.String()}).(prometheus.Gauge).Add(cost)
+						self.record(logger, "cost_failed", addr, cost, types.Log{TxHash: tx.Hash(), BlockNumber: event.Number.Uint64()})
 
 						if err := self.cacheTXsCostFailed.Set(event.Number.Int64(), cost); err != nil {
 							level.Error(logger).Log("msg", "adding cost to the cache", "err", err)
@@ -440,6 +643,7 @@ func (self *ProfitTracker) setCostWhenConfirmed(logger log.Logger, event *tellor
 			cost = cost / 1e18
 			level.Debug(logger).Log("msg", "adding cost", "amount", cost)
 			self.submitCost.With(prometheus.Labels{"addr": event.Miner.String()}).(prometheus.Gauge).Add(cost)
+			self.record(logger, "cost", event.Miner, cost, event.Raw)
 
 			if err := self.cacheTXsCost.Set(txIDNonceSubmit(event), cost); err != nil {
 				level.Error(logger).Log("msg", "adding cost to the cache", "err", err)
@@ -486,6 +690,7 @@ func (self *ProfitTracker) setProfitWhenConfirmed(logger log.Logger, event *tell
 			trb = trb / 1e18
 			level.Debug(logger).Log("msg", "adding profit", "amount", trb)
 			self.submitProfit.With(prometheus.Labels{"addr": event.To.String()}).(prometheus.Gauge).Add(trb)
+			self.record(logger, "reward", event.To, trb, event.Raw)
 
 			if err := self.cacheTXsProfit.Set(txIDTransfer(event), trb); err != nil {
 				level.Error(logger).Log("msg", "adding amount to the cache", "err", err)
@@ -507,6 +712,78 @@ func (self *ProfitTracker) setProfitWhenConfirmed(logger log.Logger, event *tell
 	}
 }
 
+// record appends the amount of a transaction to the DB
+// with the transaction hash so that the changes of the profit metrics can be traced back to it.
+func (self *ProfitTracker) record(logger log.Logger, kind string, addr common.Address, amount float64, event types.Log) {
+	if self.tsDB == nil {
+		return
+	}
+	lbls := labels.Labels{
+		labels.Label{Name: "__name__", Value: "profit_tx"},
+		labels.Label{Name: "addr", Value: addr.String()},
+		labels.Label{Name: "kind", Value: kind},
+	}
+	sort.Sort(lbls) // This is important! The labels need to be sorted to avoid creating the same series with duplicate reference.
+
+	if err := self.tsDB.Append(self.ctx, db.Sample{Labels: lbls, T: timestamp.FromTime(time.Now()), V: amount, Event: &event, Fails: self.dbAppendFails}); err != nil {
+		level.Error(logger).Log("msg", "adding the transaction amount to the DB", "err", err)
+	}
+}
+
+// head returns the latest block number or 0 when it can't be retrieved.
+func (self *ProfitTracker) head(logger log.Logger) uint64 {
+	header, err := self.client.HeaderByNumber(self.ctx, nil)
+	if err != nil {
+		level.Error(logger).Log("msg", "getting the latest block", "err", err)
+		return 0
+	}
+	return header.Number.Uint64()
+}
+
+// backfill handles the events which were missed while the subscription was down,
+// from the block of the last handled event until the latest block.
+// The events of the from block which were already handled are skipped by the handlers.
+// Returns the block until which the events were handled.
+func (self *ProfitTracker) backfill(logger log.Logger, eventName string, from uint64, handle func(types.Log) error, query ...[]interface{}) uint64 {
+	if from == 0 {
+		level.Warn(logger).Log("msg", "the events missed while the subscription was down are not tracked")
+		return self.head(logger)
+	}
+	to := self.head(logger)
+	if to == 0 {
+		return from
+	}
+	topics, err := abi.MakeTopics(query...)
+	if err != nil {
+		level.Error(logger).Log("msg", "creating the event topics", "err", err)
+		return from
+	}
+	q := eth.FilterQuery{
+		Addresses: []common.Address{self.contractInstance.Address},
+		Topics:    append([][]common.Hash{{self.abi.Events[eventName].ID}}, topics...),
+	}
+	logs, err := self.fetcher.FilterLogs(self.ctx, q, from, to)
+	if err != nil {
+		level.Error(logger).Log("msg", "fetching the missed events", "from", from, "to", to, "err", err)
+		return from
+	}
+	for _, l := range logs {
+		if err := handle(l); err != nil {
+			level.Error(logger).Log("msg", "parsing a missed event", "tx", l.TxHash, "err", err)
+		}
+	}
+	level.Info(logger).Log("msg", "backfilled the missed events", "from", from, "to", to, "count", len(logs))
+	return to
+}
+
+func (self *ProfitTracker) addrsQuery() []interface{} {
+	query := make([]interface{}, len(self.addrs))
+	for i, addr := range self.addrs {
+		query[i] = addr
+	}
+	return query
+}
+
 func (self *ProfitTracker) nonceSubmittedSub(output chan *tellor.TellorNonceSubmitted) (event.Subscription, error) {
 	tellorFilterer, err := tellor.NewTellorFilterer(self.contractInstance.Address, self.client)
 	if err != nil {
@@ -538,6 +815,18 @@ func (self *ProfitTracker) transferSub(output chan *tellor.TellorTransferred) (e
 	return sub, nil
 }
 
+func (self *ProfitTracker) tipAddedSub(output chan *tellor.TellorTipAdded) (event.Subscription, error) {
+	tellorFilterer, err := tellor.NewTellorFilterer(self.contractInstance.Address, self.client)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting instance")
+	}
+	sub, err := tellorFilterer.WatchTipAdded(&bind.WatchOpts{Context: self.ctx}, output, self.addrs, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting channel")
+	}
+	return sub, nil
+}
+
 func (self *ProfitTracker) headSub(output chan *types.Header) (event.Subscription, error) {
 	sub, err := self.client.SubscribeNewHead(self.ctx, output)
 	if err != nil {
@@ -581,3 +870,7 @@ func txIDTransfer(event *tellor.TellorTransferred) string {
 func txIDNonceSubmit(event *tellor.TellorNonceSubmitted) string {
 	return event.Raw.TxHash.String() + event.Miner.String()
 }
+
+func txIDTipAdded(event *tellor.TellorTipAdded) string {
+	return event.Raw.TxHash.String() + event.Sender.String() + event.RequestId.String()
+}
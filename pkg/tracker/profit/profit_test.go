@@ -0,0 +1,66 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package profit
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	eth "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// logsClient has a log on every block until the head and records the queries.
+type logsClient struct {
+	contracts.ETHClient
+	head    uint64
+	queries []eth.FilterQuery
+}
+
+func (self *logsClient) HeaderByNumber(context.Context, *big.Int) (*types.Header, error) {
+	return &types.Header{Number: new(big.Int).SetUint64(self.head)}, nil
+}
+
+func (self *logsClient) FilterLogs(_ context.Context, q eth.FilterQuery) ([]types.Log, error) {
+	self.queries = append(self.queries, q)
+	var logs []types.Log
+	for b := q.FromBlock.Uint64(); b <= q.ToBlock.Uint64(); b++ {
+		logs = append(logs, types.Log{BlockNumber: b})
+	}
+	return logs, nil
+}
+
+// TestBackfill ensures that the events missed while the subscription was down
+// are fetched in chunks from the last handled block until the head.
+func TestBackfill(t *testing.T) {
+	client := &logsClient{head: 120}
+	fetcher, err := ethereum.NewLogFetcher(logging.NewLogger(), client, ethereum.LogFetcherConfig{ChunkSize: 50})
+	testutil.Ok(t, err)
+	addr := common.HexToAddress("0x1")
+	tracker, err := NewProfitTracker(logging.NewLogger(), context.Background(), Config{LogLevel: "info"}, nil, client, fetcher, &contracts.ITellor{Address: common.HexToAddress("0x2")}, []common.Address{addr})
+	testutil.Ok(t, err)
+
+	var handled []uint64
+	handle := func(l types.Log) error {
+		handled = append(handled, l.BlockNumber)
+		return nil
+	}
+
+	// Without a last handled block nothing is fetched.
+	testutil.Equals(t, uint64(120), tracker.backfill(tracker.logger, "TipAdded", 0, handle, tracker.addrsQuery()))
+	testutil.Equals(t, 0, len(client.queries))
+
+	testutil.Equals(t, uint64(120), tracker.backfill(tracker.logger, "TipAdded", 20, handle, tracker.addrsQuery()))
+	testutil.Equals(t, 3, len(client.queries))
+	testutil.Equals(t, 101, len(handled))
+	testutil.Equals(t, uint64(20), handled[0])
+	testutil.Equals(t, []common.Address{tracker.contractInstance.Address}, client.queries[0].Addresses)
+	testutil.Equals(t, [][]common.Hash{{tracker.abi.Events["TipAdded"].ID}, {common.BytesToHash(addr.Bytes())}}, client.queries[0].Topics)
+}
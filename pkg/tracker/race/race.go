@@ -0,0 +1,496 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package race
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/logging"
+)
+
+const ComponentName = "raceTracker"
+
+const (
+	BroadcastDelayMetricName  = ComponentName + "_broadcast_delay_seconds"
+	CompetitorDelayMetricName = ComponentName + "_competitor_delay_seconds"
+	BlockDistanceMetricName   = ComponentName + "_block_distance"
+	LandedMetricName          = ComponentName + "_landed"
+	RequiredGasMetricName     = ComponentName + "_required_gas_price_gwei"
+)
+
+// The results of a race.
+const (
+	ResultLanded  = "landed"
+	ResultLost    = "lost"
+	ResultSkipped = "skipped"
+)
+
+// maxChallenges is how many challenges are kept open for late inclusion events.
+const maxChallenges = 3
+
+type Config struct {
+	LogLevel string
+	// History is how many of the latest races are kept in memory for the API.
+	History int
+}
+
+// Race is the submission of an account for a challenge compared to the first competitor.
+// The delays are from the arrival of the challenge and the block distance is
+// from the block at our broadcast to the block of the first competitor inclusion,
+// so a negative distance means that a competitor was included before we broadcast.
+type Race struct {
+	Challenge      string    `json:"challenge"`
+	Account        string    `json:"account"`
+	Result         string    `json:"result"`
+	Arrived        time.Time `json:"arrived"`
+	ArrivedBlock   uint64    `json:"arrivedBlock"`
+	BroadcastDelay float64   `json:"broadcastDelay,omitempty"`
+	BroadcastBlock uint64    `json:"broadcastBlock,omitempty"`
+	// BroadcastGasPrice and RequiredGasPrice are in gwei.
+	BroadcastGasPrice float64 `json:"broadcastGasPrice,omitempty"`
+	RequiredGasPrice  float64 `json:"requiredGasPrice,omitempty"`
+	IncludedBlock     uint64  `json:"includedBlock,omitempty"`
+	CompetitorDelay   float64 `json:"competitorDelay,omitempty"`
+	CompetitorBlock   uint64  `json:"competitorBlock,omitempty"`
+	BlockDistance     int64   `json:"blockDistance,omitempty"`
+	HasCompetitor     bool    `json:"hasCompetitor"`
+
+	broadcast time.Time
+}
+
+type challenge struct {
+	id       [32]byte
+	arrived  time.Time
+	block    uint64
+	first    time.Time
+	firstBlk uint64
+	races    map[common.Address]*Race
+	included map[common.Address]uint64
+	// gasPrices of all included submissions by tx.
+	gasPrices map[common.Hash]*big.Int
+}
+
+type required struct {
+	t        time.Time
+	gasPrice *big.Int
+}
+
+// Tracker measures per challenge how fast our accounts broadcast
+// compared to the first competitor that lands a slot
+// so that operators can see whether they lose the races on latency or on gas.
+// The tasker reports the arrival of the challenges, the submitters their broadcasts
+// and the slot tracker the inclusions.
+// The races of a challenge are final when it is older than the last few challenges
+// so that the late inclusions are still counted.
+// A nil Tracker is valid and doesn't record anything.
+type Tracker struct {
+	logger   log.Logger
+	cfg      Config
+	client   contracts.ETHClient
+	db       storage.Appendable
+	accounts map[common.Address]bool
+
+	mtx        sync.Mutex
+	challenges []*challenge
+	history    []Race
+	required   []required
+
+	broadcastDelay  *prometheus.HistogramVec
+	competitorDelay prometheus.Histogram
+	blockDistance   *prometheus.HistogramVec
+	requiredGas     prometheus.Histogram
+	races           *prometheus.CounterVec
+}
+
+// New creates a race tracker. The races are written to the DB as well when it isn't nil.
+func New(
+	logger log.Logger,
+	cfg Config,
+	client contracts.ETHClient,
+	db storage.Appendable,
+	accounts []common.Address,
+) (*Tracker, error) {
+	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	if cfg.History <= 0 {
+		return nil, errors.Errorf("invalid history size:%v", cfg.History)
+	}
+	accs := make(map[common.Address]bool)
+	for _, a := range accounts {
+		accs[a] = true
+	}
+	delayBuckets := []float64{0.5, 1, 2, 5, 10, 20, 30, 60, 120, 300, 600, 900}
+	return &Tracker{
+		logger:   log.With(logger, "component", ComponentName),
+		cfg:      cfg,
+		client:   client,
+		db:       db,
+		accounts: accs,
+		broadcastDelay: promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "broadcast_delay_seconds",
+			Help:      "The time from the arrival of a challenge to our broadcast",
+			Buckets:   delayBuckets,
+		},
+			[]string{"account"},
+		),
+		competitorDelay: promauto.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "competitor_delay_seconds",
+			Help:      "The time from the arrival of a challenge to the inclusion of the first competitor",
+			Buckets:   delayBuckets,
+		}),
+		blockDistance: promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "block_distance",
+			Help:      "The blocks from our broadcast to the inclusion of the first competitor, negative when the competitor was included before we broadcast",
+			Buckets:   []float64{-10, -5, -3, -2, -1, 0, 1, 2, 3, 5, 10},
+		},
+			[]string{"account"},
+		),
+		requiredGas: promauto.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "required_gas_price_gwei",
+			Help:      "The lowest gas price of the included submissions of a challenge",
+			Buckets:   prometheus.ExponentialBuckets(1, 1.5, 15),
+		}),
+		races: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "races_total",
+			Help:      "The total number of races per result(landed, lost, skipped)",
+		},
+			[]string{"account", "result"},
+		),
+	}, nil
+}
+
+// Arrived records the arrival of a new challenge and finalizes the old ones.
+func (self *Tracker) Arrived(id [32]byte, block uint64, t time.Time) {
+	if self == nil {
+		return
+	}
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	if self.find(id) != nil {
+		return
+	}
+	self.challenges = append(self.challenges, &challenge{
+		id:        id,
+		arrived:   t,
+		block:     block,
+		races:     make(map[common.Address]*Race),
+		included:  make(map[common.Address]uint64),
+		gasPrices: make(map[common.Hash]*big.Int),
+	})
+	if len(self.challenges) > maxChallenges {
+		self.finalize(self.challenges[0])
+		self.challenges = self.challenges[1:]
+	}
+}
+
+// Broadcast records the broadcast of a submission of an account.
+func (self *Tracker) Broadcast(ctx context.Context, id [32]byte, account common.Address, gasPrice *big.Int, t time.Time) {
+	if self == nil {
+		return
+	}
+	// The block number at the broadcast to compare with the competitor inclusion.
+	var block uint64
+	header, err := self.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		level.Error(self.logger).Log("msg", "getting the block at the broadcast", "err", err)
+	} else {
+		block = header.Number.Uint64()
+	}
+
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	c := self.find(id)
+	if c == nil {
+		level.Debug(self.logger).Log("msg", "broadcast for a challenge without an arrival", "challenge", fmt.Sprintf("%x", id))
+		return
+	}
+	if _, ok := c.races[account]; ok {
+		// Keep the first broadcast of a replaced transaction.
+		return
+	}
+	c.races[account] = &Race{broadcast: t, BroadcastBlock: block, BroadcastGasPrice: gwei(gasPrice)}
+}
+
+// Included records a submission included in a block or removed by a re-org.
+func (self *Tracker) Included(ctx context.Context, id [32]byte, miner common.Address, txHash common.Hash, block uint64, removed bool, t time.Time) {
+	if self == nil {
+		return
+	}
+	// The gas price that landed the slot.
+	var gasPrice *big.Int
+	if !removed {
+		tx, _, err := self.client.TransactionByHash(ctx, txHash)
+		if err != nil {
+			level.Error(self.logger).Log("msg", "getting the gas price of an included submission", "tx", txHash, "err", err)
+		} else {
+			gasPrice = tx.GasPrice()
+		}
+	}
+
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	c := self.find(id)
+	if c == nil {
+		return
+	}
+	if removed {
+		delete(c.gasPrices, txHash)
+	} else if gasPrice != nil {
+		c.gasPrices[txHash] = gasPrice
+	}
+	if self.accounts[miner] {
+		if removed {
+			delete(c.included, miner)
+		} else {
+			c.included[miner] = block
+		}
+		return
+	}
+	if removed {
+		if c.firstBlk == block {
+			c.first, c.firstBlk = time.Time{}, 0
+		}
+		return
+	}
+	if c.first.IsZero() || block < c.firstBlk {
+		c.first, c.firstBlk = t, block
+	}
+}
+
+func (self *Tracker) find(id [32]byte) *challenge {
+	for _, c := range self.challenges {
+		if c.id == id {
+			return c
+		}
+	}
+	return nil
+}
+
+// finalize records the races of all accounts for the challenge.
+func (self *Tracker) finalize(c *challenge) {
+	var competitorDelay float64
+	if !c.first.IsZero() {
+		competitorDelay = c.first.Sub(c.arrived).Seconds()
+		self.competitorDelay.Observe(competitorDelay)
+	}
+	accounts := make([]common.Address, 0, len(self.accounts))
+	for a := range self.accounts {
+		accounts = append(accounts, a)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].Hex() < accounts[j].Hex() })
+
+	// The lowest gas price that landed a slot was enough to win the race.
+	var requiredGasPrice *big.Int
+	for _, p := range c.gasPrices {
+		if requiredGasPrice == nil || p.Cmp(requiredGasPrice) < 0 {
+			requiredGasPrice = p
+		}
+	}
+	if requiredGasPrice != nil {
+		self.requiredGas.Observe(gwei(requiredGasPrice))
+		self.required = append(self.required, required{t: c.arrived, gasPrice: requiredGasPrice})
+		if len(self.required) > self.cfg.History {
+			self.required = self.required[len(self.required)-self.cfg.History:]
+		}
+	}
+
+	for _, account := range accounts {
+		r := c.races[account]
+		if r == nil {
+			r = &Race{}
+		}
+		r.Challenge = fmt.Sprintf("%x", c.id)
+		r.Account = account.Hex()
+		r.Arrived = c.arrived
+		r.ArrivedBlock = c.block
+		r.IncludedBlock = c.included[account]
+		r.HasCompetitor = !c.first.IsZero()
+		r.CompetitorDelay = competitorDelay
+		r.CompetitorBlock = c.firstBlk
+		r.RequiredGasPrice = gwei(requiredGasPrice)
+		switch {
+		case r.IncludedBlock > 0:
+			r.Result = ResultLanded
+		case !r.broadcast.IsZero():
+			r.Result = ResultLost
+		default:
+			r.Result = ResultSkipped
+		}
+		if !r.broadcast.IsZero() {
+			r.BroadcastDelay = r.broadcast.Sub(c.arrived).Seconds()
+			self.broadcastDelay.With(prometheus.Labels{"account": r.Account}).Observe(r.BroadcastDelay)
+			if r.HasCompetitor && r.BroadcastBlock > 0 {
+				r.BlockDistance = int64(r.CompetitorBlock) - int64(r.BroadcastBlock)
+				self.blockDistance.With(prometheus.Labels{"account": r.Account}).Observe(float64(r.BlockDistance))
+			}
+		}
+		self.races.With(prometheus.Labels{"account": r.Account, "result": r.Result}).Inc()
+		if err := self.record(*r); err != nil {
+			level.Error(self.logger).Log("msg", "recording the race", "err", err)
+		}
+		level.Debug(self.logger).Log(
+			"msg", "race finished",
+			"challenge", r.Challenge,
+			"account", r.Account,
+			"result", r.Result,
+			"broadcastDelay", r.BroadcastDelay,
+			"competitorDelay", r.CompetitorDelay,
+			"blockDistance", r.BlockDistance,
+		)
+		self.history = append(self.history, *r)
+	}
+	if len(self.history) > self.cfg.History {
+		self.history = self.history[len(self.history)-self.cfg.History:]
+	}
+}
+
+// record writes the race to the DB at the arrival time of the challenge.
+func (self *Tracker) record(r Race) (err error) {
+	if self.db == nil {
+		return nil
+	}
+	appender := self.db.Appender(context.Background())
+	defer func() { // An appender always needs to be committed or rolled back.
+		if err != nil {
+			if err := appender.Rollback(); err != nil {
+				level.Error(self.logger).Log("msg", "db rollback failed", "err", err)
+			}
+			return
+		}
+		err = errors.Wrap(appender.Commit(), "db append commit failed")
+	}()
+
+	values := map[string]float64{LandedMetricName: 0}
+	if r.Result == ResultLanded {
+		values[LandedMetricName] = 1
+	}
+	if r.Result != ResultSkipped {
+		values[BroadcastDelayMetricName] = r.BroadcastDelay
+		if r.HasCompetitor && r.BroadcastBlock > 0 {
+			values[BlockDistanceMetricName] = float64(r.BlockDistance)
+		}
+	}
+	if r.HasCompetitor {
+		values[CompetitorDelayMetricName] = r.CompetitorDelay
+	}
+	if r.RequiredGasPrice > 0 {
+		values[RequiredGasMetricName] = r.RequiredGasPrice
+	}
+	ts := timestamp.FromTime(r.Arrived)
+	for name, v := range values {
+		lbls := labels.FromStrings(labels.MetricName, name, "account", r.Account)
+		if _, err := appender.Append(0, lbls, ts, v); err != nil {
+			return errors.Wrap(err, "append race to the DB")
+		}
+	}
+	return nil
+}
+
+// RequiredGasPrice returns the percentile of the gas prices that were required to land a slot
+// in the challenges that arrived within the window before now and the number of these challenges.
+func (self *Tracker) RequiredGasPrice(percentile float64, window time.Duration, now time.Time) (*big.Int, int) {
+	if self == nil {
+		return nil, 0
+	}
+	self.mtx.Lock()
+	var prices []*big.Int
+	for _, r := range self.required {
+		if r.t.After(now.Add(-window)) {
+			prices = append(prices, r.gasPrice)
+		}
+	}
+	self.mtx.Unlock()
+	if len(prices) == 0 {
+		return nil, 0
+	}
+	sort.Slice(prices, func(i, j int) bool { return prices[i].Cmp(prices[j]) < 0 })
+	// The nearest rank so that the price landed at least the percentile of the challenges.
+	i := int(math.Ceil(percentile/100*float64(len(prices)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(prices) {
+		i = len(prices) - 1
+	}
+	return new(big.Int).Set(prices[i]), len(prices)
+}
+
+func gwei(wei *big.Int) float64 {
+	if wei == nil {
+		return 0
+	}
+	f, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(params.GWei)).Float64()
+	return f
+}
+
+// Races returns the latest finished races, newest first.
+func (self *Tracker) Races() []Race {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	races := make([]Race, 0, len(self.history))
+	for i := len(self.history) - 1; i >= 0; i-- {
+		races = append(races, self.history[i])
+	}
+	return races
+}
+
+// ServeHTTP returns the latest finished races.
+// The results can be filtered by the account and result query params.
+func (self *Tracker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	account := r.URL.Query().Get("account")
+	result := r.URL.Query().Get("result")
+
+	races := []Race{}
+	for _, race := range self.Races() {
+		if account != "" && race.Account != common.HexToAddress(account).Hex() {
+			continue
+		}
+		if result != "" && race.Result != result {
+			continue
+		}
+		races = append(races, race)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(struct {
+		Status string `json:"status"`
+		Data   []Race `json:"data"`
+	}{
+		Status: "success",
+		Data:   races,
+	}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
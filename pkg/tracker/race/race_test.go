@@ -0,0 +1,109 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package race
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// gasClient returns the transactions with the gas price in gwei of the first byte of the hash.
+type gasClient struct {
+	contracts.ETHClient
+}
+
+func (self gasClient) TransactionByHash(_ context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	return types.NewTransaction(0, common.Address{}, nil, 0, big.NewInt(int64(hash[0])*params.GWei), nil), false, nil
+}
+
+// TestRaces ensures that the races are final only after the next challenges
+// and that the late and re-orged inclusions are counted.
+func TestRaces(t *testing.T) {
+	ours := []common.Address{common.HexToAddress("0x1"), common.HexToAddress("0x2")}
+	competitor := common.HexToAddress("0x3")
+	tracker, err := New(logging.NewLogger(), Config{LogLevel: "info", History: 10}, gasClient{ethereum.NewMockClient()}, nil, ours)
+	testutil.Ok(t, err)
+
+	start := time.Unix(1000, 0)
+	var c1 [32]byte
+	c1[0] = 1
+	tracker.Arrived(c1, 1, start)
+	// The mock client is at block 1.
+	ctx := context.Background()
+	tracker.Broadcast(ctx, c1, ours[0], big.NewInt(20*params.GWei), start.Add(2*time.Second))
+	tracker.Broadcast(ctx, c1, ours[0], big.NewInt(30*params.GWei), start.Add(5*time.Second))
+	tracker.Included(ctx, c1, competitor, common.Hash{40}, 4, false, start.Add(40*time.Second))
+	tracker.Included(ctx, c1, competitor, common.Hash{30}, 3, false, start.Add(30*time.Second))
+	tracker.Included(ctx, c1, ours[0], common.Hash{20}, 2, false, start.Add(20*time.Second))
+	tracker.Included(ctx, c1, ours[1], common.Hash{10}, 2, false, start.Add(20*time.Second))
+	tracker.Included(ctx, c1, ours[1], common.Hash{10}, 2, true, start.Add(25*time.Second))
+
+	for i := byte(2); i <= maxChallenges; i++ {
+		var c [32]byte
+		c[0] = i
+		tracker.Arrived(c, uint64(i)*10, start.Add(time.Duration(i)*time.Minute))
+		testutil.Equals(t, 0, len(tracker.Races()))
+	}
+	var last [32]byte
+	last[0] = maxChallenges + 1
+	tracker.Arrived(last, 100, start.Add(time.Hour))
+
+	races := tracker.Races()
+	testutil.Equals(t, 2, len(races))
+	// Newest first.
+	skipped, landed := races[0], races[1]
+	testutil.Equals(t, ours[0].Hex(), landed.Account)
+	testutil.Equals(t, ResultLanded, landed.Result)
+	testutil.Equals(t, 2.0, landed.BroadcastDelay)
+	testutil.Equals(t, 30.0, landed.CompetitorDelay)
+	testutil.Equals(t, uint64(3), landed.CompetitorBlock)
+	testutil.Equals(t, int64(2), landed.BlockDistance)
+	testutil.Equals(t, uint64(2), landed.IncludedBlock)
+	testutil.Equals(t, 20.0, landed.BroadcastGasPrice)
+	// The re-orged submission doesn't count.
+	testutil.Equals(t, 20.0, landed.RequiredGasPrice)
+
+	testutil.Equals(t, ours[1].Hex(), skipped.Account)
+	testutil.Equals(t, ResultSkipped, skipped.Result)
+	testutil.Equals(t, uint64(0), skipped.IncludedBlock)
+	testutil.Equals(t, 30.0, skipped.CompetitorDelay)
+}
+
+// TestRequiredGasPrice ensures that the percentile is of the challenges within the window.
+func TestRequiredGasPrice(t *testing.T) {
+	tracker := &Tracker{}
+	now := time.Unix(10000, 0)
+	for i := 1; i <= 10; i++ {
+		tracker.required = append(tracker.required, required{
+			t:        now.Add(-time.Duration(11-i) * time.Minute),
+			gasPrice: big.NewInt(int64(i)),
+		})
+	}
+	price, samples := tracker.RequiredGasPrice(80, time.Hour, now)
+	testutil.Equals(t, 10, samples)
+	testutil.Equals(t, int64(8), price.Int64())
+
+	// Only the last 5.
+	price, samples = tracker.RequiredGasPrice(50, 5*time.Minute+time.Second, now)
+	testutil.Equals(t, 5, samples)
+	testutil.Equals(t, int64(8), price.Int64())
+
+	price, samples = tracker.RequiredGasPrice(100, time.Second, now)
+	testutil.Equals(t, 0, samples)
+	testutil.Assert(t, price == nil, "no price without samples")
+
+	var nilTracker *Tracker
+	_, samples = nilTracker.RequiredGasPrice(80, time.Hour, now)
+	testutil.Equals(t, 0, samples)
+}
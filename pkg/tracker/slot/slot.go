@@ -0,0 +1,195 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package slot
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/contracts/tellor"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/tracker/race"
+)
+
+const ComponentName = "slotTracker"
+
+// maxChallenges is how many challenges to keep the filled slots for.
+const maxChallenges = 10
+
+type Config struct {
+	LogLevel string
+}
+
+// Tracker keeps the filled slots of the recent challenges
+// from the NonceSubmitted events.
+type Tracker struct {
+	ctx      context.Context
+	close    context.CancelFunc
+	logger   log.Logger
+	client   contracts.ETHClient
+	contract *contracts.ITellor
+
+	mtx        sync.Mutex
+	challenges map[[32]byte]map[int64]bool
+	order      [][32]byte
+	upgrades   <-chan contracts.Addresses
+	races      *race.Tracker
+
+	reconnects prometheus.Counter
+}
+
+func New(
+	logger log.Logger,
+	ctx context.Context,
+	cfg Config,
+	client contracts.ETHClient,
+	contract *contracts.ITellor,
+	upgrades <-chan contracts.Addresses,
+	races *race.Tracker,
+) (*Tracker, error) {
+	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	ctx, close := context.WithCancel(ctx)
+	return &Tracker{
+		ctx:        ctx,
+		close:      close,
+		logger:     log.With(logger, "component", ComponentName),
+		client:     client,
+		contract:   contract,
+		challenges: make(map[[32]byte]map[int64]bool),
+		upgrades:   upgrades,
+		races:      races,
+		reconnects: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "subscription_reconnects_total",
+			Help:      "The total number of event re-subscriptions",
+		}),
+	}, nil
+}
+
+func (self *Tracker) Start() error {
+	var err error
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	level.Info(self.logger).Log("msg", "starting")
+
+	var sub event.Subscription
+	events := make(chan *tellor.TellorNonceSubmitted)
+
+	for {
+		select {
+		case <-self.ctx.Done():
+			return nil
+		default:
+		}
+		sub, err = self.newSub(events)
+		if err != nil {
+			level.Error(self.logger).Log("msg", "initial subscribing to events failed", "err", err)
+			<-ticker.C
+			continue
+		}
+		break
+	}
+
+	for {
+		select {
+		case <-self.ctx.Done():
+			return nil
+		case err := <-sub.Err():
+			if err != nil {
+				level.Error(self.logger).Log("msg", "subscription error", "err", err)
+			}
+
+			// Trying to resubscribe until it succeeds.
+			for {
+				select {
+				case <-self.ctx.Done():
+					return nil
+				default:
+				}
+				sub, err = self.newSub(events)
+				if err != nil {
+					level.Error(self.logger).Log("msg", "re-subscribing to events failed", "err", err)
+					<-ticker.C
+					continue
+				}
+				break
+			}
+			self.reconnects.Inc()
+			level.Info(self.logger).Log("msg", "re-subscribed to events")
+		case <-self.upgrades:
+			// Closes the error channel so the case above resubscribes.
+			level.Info(self.logger).Log("msg", "contract upgraded, resubscribing to events")
+			sub.Unsubscribe()
+		case event := <-events:
+			level.Debug(self.logger).Log(
+				"msg", "new event",
+				"removed", event.Raw.Removed,
+				"slot", event.Slot,
+				"miner", event.Miner.String()[:8],
+			)
+			self.set(event.CurrentChallenge, event.Slot.Int64(), !event.Raw.Removed)
+			self.races.Included(self.ctx, event.CurrentChallenge, event.Miner, event.Raw.TxHash, event.Raw.BlockNumber, event.Raw.Removed, time.Now())
+		}
+	}
+}
+
+func (self *Tracker) Stop() {
+	self.close()
+}
+
+func (self *Tracker) newSub(output chan *tellor.TellorNonceSubmitted) (event.Subscription, error) {
+	tellorFilterer, err := tellor.NewTellorFilterer(self.contract.Address, self.client)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting instance")
+	}
+	sub, err := tellorFilterer.WatchNonceSubmitted(&bind.WatchOpts{Context: self.ctx}, output, nil, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting channel")
+	}
+	return sub, nil
+}
+
+// set marks the slot as filled or as free again when the event was removed by a re-org.
+func (self *Tracker) set(challenge [32]byte, slot int64, filled bool) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	slots, ok := self.challenges[challenge]
+	if !ok {
+		slots = make(map[int64]bool)
+		self.challenges[challenge] = slots
+		self.order = append(self.order, challenge)
+		if len(self.order) > maxChallenges {
+			delete(self.challenges, self.order[0])
+			self.order = self.order[1:]
+		}
+	}
+	if filled {
+		slots[slot] = true
+	} else {
+		delete(slots, slot)
+	}
+}
+
+// Filled returns the number of filled slots for the challenge.
+// It returns false when there were no events for the challenge.
+func (self *Tracker) Filled(challenge [32]byte) (int, bool) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	slots, ok := self.challenges[challenge]
+	return len(slots), ok
+}
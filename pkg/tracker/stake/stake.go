@@ -0,0 +1,209 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package stake
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/notify"
+	"github.com/tellor-io/telliot/pkg/submitter"
+	"github.com/tellor-io/telliot/pkg/tuning"
+	"github.com/tellor-io/telliot/pkg/watchdog"
+)
+
+const ComponentName = "stakeTracker"
+
+// statusStaked is the only staker status that allows submitting.
+const statusStaked = 1
+
+type Config struct {
+	Enabled  bool
+	LogLevel string
+	Interval format.Duration
+}
+
+// Tracker checks the stake status of all accounts and
+// pauses the mining and submitting of accounts that are not staked.
+// Submitting while not staked wastes gas and
+// submitting while in dispute can compound the slashing.
+type Tracker struct {
+	ctx      context.Context
+	close    context.CancelFunc
+	logger   log.Logger
+	watchdog *watchdog.Watchdog
+	cfg      Config
+	interval *tuning.Duration
+	client   contracts.ETHClient
+	contract *contracts.ITellor
+	abi      *abi.ABI
+	accounts []*ethereum.Account
+	gates    map[string]*submitter.Gate
+	notifier notify.Notifier
+
+	// statuses holds the last known status of each account.
+	statuses map[string]int64
+
+	status *prometheus.GaugeVec
+}
+
+func New(
+	logger log.Logger,
+	ctx context.Context,
+	cfg Config,
+	client contracts.ETHClient,
+	contract *contracts.ITellor,
+	accounts []*ethereum.Account,
+	gates map[string]*submitter.Gate,
+	notifier notify.Notifier,
+	watchdog *watchdog.Watchdog,
+) (*Tracker, error) {
+	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	logger = log.With(logger, "component", ComponentName)
+	parsed, err := contracts.ParseABI(contracts.ITellorABI)
+	if err != nil {
+		return nil, err
+	}
+	ctx, close := context.WithCancel(ctx)
+
+	return &Tracker{
+		ctx:      ctx,
+		close:    close,
+		watchdog: watchdog,
+		interval: tuning.NewDuration(ComponentName+".interval", "How often the stakes of the accounts are checked.", cfg.Interval.Duration),
+		logger:   logger,
+		cfg:      cfg,
+		client:   client,
+		contract: contract,
+		abi:      parsed,
+		accounts: accounts,
+		gates:    gates,
+		notifier: notifier,
+		statuses: make(map[string]int64),
+		status: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "status",
+			Help:      "The stake status of the account as returned by getStakerInfo(1 means staked)",
+		},
+			[]string{"addr"},
+		),
+	}, nil
+}
+
+func (self *Tracker) Start() error {
+	level.Info(self.logger).Log("msg", "starting", "interval", self.cfg.Interval)
+
+	ticker := time.NewTicker(self.interval.Get())
+	defer ticker.Stop()
+	for {
+		stop := self.watchdog.Watch(ComponentName)
+		self.checkAll()
+		stop()
+		select {
+		case <-self.ctx.Done():
+			return nil
+		case <-ticker.C:
+			// Applies the interval when it was changed.
+			ticker.Reset(self.interval.Get())
+		}
+	}
+}
+
+func (self *Tracker) Stop() {
+	self.close()
+}
+
+// checkAll reads the status of all accounts in a single batch.
+func (self *Tracker) checkAll() {
+	calls := make([]*contracts.BatchCall, len(self.accounts))
+	for i, account := range self.accounts {
+		calls[i] = &contracts.BatchCall{
+			Contract: self.contract.Address,
+			ABI:      self.abi,
+			Method:   "getStakerInfo",
+			Args:     []interface{}{account.Address},
+		}
+	}
+	if err := contracts.CallBatch(self.ctx, self.client, nil, calls); err != nil {
+		level.Error(self.logger).Log("msg", "getting staker info", "err", err)
+		return
+	}
+	for i, account := range self.accounts {
+		if err := self.check(account, calls[i]); err != nil {
+			level.Error(self.logger).Log("msg", "checking stake status", "addr", account.Address.String(), "err", err)
+		}
+	}
+}
+
+func (self *Tracker) check(account *ethereum.Account, call *contracts.BatchCall) error {
+	addr := account.Address.String()
+	logger := log.With(self.logger, "addr", addr)
+
+	if call.Err != nil {
+		return errors.Wrap(call.Err, "getting staker info")
+	}
+	_status, ok := call.Result[0].(*big.Int)
+	if !ok {
+		return errors.Errorf("unexpected staker status type:%T", call.Result[0])
+	}
+	status := _status.Int64()
+	self.status.With(prometheus.Labels{"addr": addr}).Set(float64(status))
+
+	last, known := self.statuses[addr]
+	self.statuses[addr] = status
+	if known && last == status {
+		return nil
+	}
+	level.Info(logger).Log("msg", "stake status", "status", contracts.StakerStatusName(status))
+
+	gate := self.gates[addr]
+	if status == statusStaked {
+		resumed := gate != nil && gate.Resume(ComponentName)
+		// Don't notify on startup when everything is fine.
+		if resumed || known {
+			self.notify(logger, addr, notify.SeverityInfo, notify.EventStakeRestored,
+				fmt.Sprintf("Account %v is staked", addr),
+				fmt.Sprintf("The stake status changed from %v to %v. Mining and submitting continue.", contracts.StakerStatusName(last), contracts.StakerStatusName(status)),
+			)
+		}
+		return nil
+	}
+
+	if gate != nil {
+		gate.Pause(ComponentName, "stake status:"+contracts.StakerStatusName(status))
+	}
+	self.notify(logger, addr, notify.SeverityCritical, notify.EventStakeLost,
+		fmt.Sprintf("Account %v is not staked", addr),
+		fmt.Sprintf("The stake status is %v. Mining and submitting are paused until the account is staked again.", contracts.StakerStatusName(status)),
+	)
+	return nil
+}
+
+func (self *Tracker) notify(logger log.Logger, addr string, severity notify.Severity, event, title, body string) {
+	if err := self.notifier.Notify(self.ctx, notify.Message{
+		Event:    event,
+		Severity: severity,
+		Title:    title,
+		Body:     body,
+		Data:     map[string]string{"account": addr},
+	}); err != nil {
+		level.Error(logger).Log("msg", "sending notification", "event", event, "err", err)
+	}
+}
@@ -0,0 +1,570 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package vote
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	eth "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/contracts/tellor"
+	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/notify"
+	"github.com/tellor-io/telliot/pkg/tracker/clock"
+	"github.com/tellor-io/telliot/pkg/tuning"
+	"github.com/tellor-io/telliot/pkg/watchdog"
+)
+
+const ComponentName = "voteTracker"
+
+// Indexes of the uint vars returned by the getAllDisputeVars contract call.
+const (
+	disputeVarRequestID  = 0
+	disputeVarTimestamp  = 1
+	disputeVarValue      = 2
+	disputeVarVotingEnds = 3
+	disputeVarVotes      = 4
+	disputeVarFee        = 8
+)
+
+type Config struct {
+	Enabled  bool
+	LogLevel string
+	// Interval is how often to refresh the state of all open votes.
+	Interval format.Duration
+	// ReminderBefore is how long before the vote window closes to send a reminder
+	// for accounts that haven't voted yet.
+	ReminderBefore format.Duration
+	// LookBack is how far in the past to search for open votes at startup.
+	// Votes are open for 7 days so it should be at least that long.
+	LookBack format.Duration
+}
+
+// VoteCommand is the CLI command in the notifications that votes on the dispute.
+func VoteCommand(disputeID interface{}) string {
+	return fmt.Sprintf("telliot dispute vote %v <true|false>", disputeID)
+}
+
+// Vote is the state of a single governance/dispute vote.
+type Vote struct {
+	DisputeID  int64
+	RequestID  int64
+	Timestamp  int64
+	Value      string
+	VotingEnds time.Time
+	Votes      int64
+	// Fee is the dispute fee in TRB wei.
+	Fee      string
+	Executed bool
+	// Voted holds the voting status for each of the tracked accounts.
+	Voted map[common.Address]bool
+
+	reminded bool
+}
+
+// Tracker records all open votes and reminds
+// the tracked accounts to vote before the vote window closes.
+type Tracker struct {
+	ctx      context.Context
+	close    context.CancelFunc
+	logger   log.Logger
+	watchdog *watchdog.Watchdog
+	cfg      Config
+	interval *tuning.Duration
+	client   contracts.ETHClient
+	fetcher  *ethereum.LogFetcher
+	verifier *ethereum.Verifier
+	contract *contracts.ITellor
+	abi      *abi.ABI
+	addrs    []common.Address
+	notifier notify.Notifier
+	// clock corrects the local time so that the reminders follow the voting end of the chain.
+	clock *clock.Tracker
+
+	mtx       sync.Mutex
+	votes     map[int64]*Vote
+	refreshCh chan struct{}
+
+	openVotes  prometheus.Gauge
+	votingEnds *prometheus.GaugeVec
+	voted      *prometheus.GaugeVec
+	reconnects prometheus.Counter
+}
+
+func New(
+	logger log.Logger,
+	ctx context.Context,
+	cfg Config,
+	client contracts.ETHClient,
+	fetcher *ethereum.LogFetcher,
+	verifier *ethereum.Verifier,
+	contract *contracts.ITellor,
+	addrs []common.Address,
+	notifier notify.Notifier,
+	watchdog *watchdog.Watchdog,
+	clock *clock.Tracker,
+) (*Tracker, error) {
+	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	logger = log.With(logger, "component", ComponentName)
+	parsed, err := contracts.ParseABI(contracts.ITellorABI)
+	if err != nil {
+		return nil, err
+	}
+	ctx, close := context.WithCancel(ctx)
+
+	return &Tracker{
+		ctx:       ctx,
+		close:     close,
+		watchdog:  watchdog,
+		clock:     clock,
+		interval:  tuning.NewDuration(ComponentName+".interval", "How often the open votes are refreshed.", cfg.Interval.Duration),
+		logger:    logger,
+		cfg:       cfg,
+		client:    client,
+		fetcher:   fetcher,
+		verifier:  verifier,
+		contract:  contract,
+		abi:       parsed,
+		addrs:     addrs,
+		notifier:  notifier,
+		votes:     make(map[int64]*Vote),
+		refreshCh: make(chan struct{}, 1),
+		reconnects: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "subscription_reconnects_total",
+			Help:      "The total number of event re-subscriptions",
+		}),
+		openVotes: promauto.NewGauge(prometheus.GaugeOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "open",
+			Help:      "The number of currently open votes",
+		}),
+		votingEnds: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "voting_ends_timestamp_seconds",
+			Help:      "The unix time when the vote window closes",
+		},
+			[]string{"id"},
+		),
+		voted: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "voted",
+			Help:      "Whether the account has voted(1) or not(0)",
+		},
+			[]string{"id", "addr"},
+		),
+	}, nil
+}
+
+func (self *Tracker) Start() error {
+	level.Info(self.logger).Log("msg", "starting", "reminderBefore", self.cfg.ReminderBefore)
+
+	if err := self.loadOpenVotes(); err != nil {
+		level.Error(self.logger).Log("msg", "loading open votes", "err", err)
+	}
+	self.refresh()
+
+	go self.monitorNewDisputes()
+
+	ticker := time.NewTicker(self.interval.Get())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-self.ctx.Done():
+			return nil
+		case <-ticker.C:
+			// Applies the interval when it was changed.
+			ticker.Reset(self.interval.Get())
+			self.refresh()
+		case <-self.refreshCh:
+			self.refresh()
+		}
+	}
+}
+
+func (self *Tracker) Stop() {
+	self.close()
+}
+
+// OpenVotes returns a copy of all currently open votes sorted by their dispute ID.
+func (self *Tracker) OpenVotes() []Vote {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	votes := make([]Vote, 0, len(self.votes))
+	for _, v := range self.votes {
+		vote := *v
+		vote.Voted = make(map[common.Address]bool, len(v.Voted))
+		for addr, voted := range v.Voted {
+			vote.Voted[addr] = voted
+		}
+		votes = append(votes, vote)
+	}
+	sort.Slice(votes, func(i, j int) bool { return votes[i].DisputeID < votes[j].DisputeID })
+	return votes
+}
+
+// loadOpenVotes looks for disputes created in the look back period
+// so that votes opened while telliot wasn't running are also tracked.
+func (self *Tracker) loadOpenVotes() error {
+	header, err := self.client.HeaderByNumber(self.ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "get latest eth block header")
+	}
+	// Average block time is ~13 seconds.
+	lookBackBlocks := int64(self.cfg.LookBack.Seconds() / 13)
+	startBlock := header.Number.Int64() - lookBackBlocks
+	if startBlock < 0 {
+		startBlock = 0
+	}
+
+	filterer, err := tellor.NewTellorFilterer(self.contract.Address, self.client)
+	if err != nil {
+		return errors.Wrap(err, "getting instance")
+	}
+	tellorABI, err := abi.JSON(strings.NewReader(tellor.TellorABI))
+	if err != nil {
+		return errors.Wrap(err, "parse abi")
+	}
+	query := eth.FilterQuery{
+		Addresses: []common.Address{self.contract.Address},
+		Topics:    [][]common.Hash{{tellorABI.Events["NewDispute"].ID}},
+	}
+	logs, err := self.fetcher.FilterLogs(self.ctx, query, uint64(startBlock), header.Number.Uint64())
+	if err != nil {
+		return errors.Wrap(err, "filter dispute events")
+	}
+	for _, l := range logs {
+		event, err := filterer.ParseNewDispute(l)
+		if err != nil {
+			return errors.Wrap(err, "parse dispute event")
+		}
+		self.add(event.DisputeId)
+	}
+	return nil
+}
+
+func (self *Tracker) add(disputeID *big.Int) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	if _, ok := self.votes[disputeID.Int64()]; ok {
+		return
+	}
+	level.Info(self.logger).Log("msg", "tracking new vote", "disputeID", disputeID)
+	self.votes[disputeID.Int64()] = &Vote{
+		DisputeID: disputeID.Int64(),
+		Voted:     make(map[common.Address]bool),
+	}
+}
+
+// refresh updates the state of all open votes,
+// removes the closed ones and sends reminders for the ones about to close.
+func (self *Tracker) refresh() {
+	defer self.watchdog.Watch(ComponentName)()
+
+	self.mtx.Lock()
+	ids := make([]int64, 0, len(self.votes))
+	for id := range self.votes {
+		ids = append(ids, id)
+	}
+	self.mtx.Unlock()
+
+	votes, errs, err := self.fetch(ids)
+	if err != nil {
+		level.Error(self.logger).Log("msg", "fetching vote details", "err", err)
+		return
+	}
+	for i, id := range ids {
+		logger := log.With(self.logger, "disputeID", id)
+		if errs[i] != nil {
+			level.Error(logger).Log("msg", "fetching vote details", "err", errs[i])
+			continue
+		}
+		vote := votes[i]
+
+		now := self.clock.Now()
+		if vote.Executed || now.After(vote.VotingEnds) {
+			level.Info(logger).Log("msg", "vote closed", "executed", vote.Executed, "votingEnds", vote.VotingEnds)
+			self.remove(id)
+			continue
+		}
+
+		self.mtx.Lock()
+		if prev, ok := self.votes[id]; ok {
+			vote.reminded = prev.reminded
+		}
+		self.votes[id] = vote
+		self.mtx.Unlock()
+
+		self.votingEnds.With(prometheus.Labels{"id": fmt.Sprint(id)}).Set(float64(vote.VotingEnds.Unix()))
+		var pending []string
+		for addr, voted := range vote.Voted {
+			val := 0.0
+			if voted {
+				val = 1
+			} else {
+				pending = append(pending, addr.String())
+			}
+			self.voted.With(prometheus.Labels{"id": fmt.Sprint(id), "addr": addr.String()}).Set(val)
+		}
+
+		if len(pending) > 0 && !vote.reminded && vote.VotingEnds.Sub(now) < self.cfg.ReminderBefore.Duration {
+			sort.Strings(pending)
+			err := self.notifier.Notify(self.ctx, notify.Message{
+				Event:    "vote_reminder",
+				Severity: notify.SeverityWarning,
+				Title:    fmt.Sprintf("Vote for dispute %d closes in %v", id, vote.VotingEnds.Sub(now).Round(time.Minute)),
+				Body: fmt.Sprintf(
+					"Dispute %d for request ID %d with a fee of %v TRB wei closes at %v. Accounts that haven't voted:%v. Vote with: %v",
+					id, vote.RequestID, vote.Fee, vote.VotingEnds.UTC().Format(time.RFC3339), pending, VoteCommand(id),
+				),
+			})
+			if err != nil {
+				level.Error(logger).Log("msg", "sending vote reminder", "err", err)
+				continue
+			}
+			self.mtx.Lock()
+			self.votes[id].reminded = true
+			self.mtx.Unlock()
+		}
+	}
+
+	self.mtx.Lock()
+	self.openVotes.Set(float64(len(self.votes)))
+	self.mtx.Unlock()
+}
+
+func (self *Tracker) remove(id int64) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	delete(self.votes, id)
+	self.votingEnds.Delete(prometheus.Labels{"id": fmt.Sprint(id)})
+	for _, addr := range self.addrs {
+		self.voted.Delete(prometheus.Labels{"id": fmt.Sprint(id), "addr": addr.String()})
+	}
+}
+
+// fetch reads the details and the voting status of all votes in a single batch.
+// Returns the vote or the error of each ID in the order of the IDs.
+func (self *Tracker) fetch(ids []int64) ([]*Vote, []error, error) {
+	ctx, cncl := context.WithTimeout(self.ctx, time.Minute)
+	defer cncl()
+
+	// The details of each dispute are followed by the voting status of every account.
+	perVote := 1 + len(self.addrs)
+	calls := make([]*contracts.BatchCall, 0, len(ids)*perVote)
+	for _, id := range ids {
+		disputeID := big.NewInt(id)
+		calls = append(calls, &contracts.BatchCall{
+			Contract: self.contract.Address,
+			ABI:      self.abi,
+			Method:   "getAllDisputeVars",
+			Args:     []interface{}{disputeID},
+		})
+		for _, addr := range self.addrs {
+			calls = append(calls, &contracts.BatchCall{
+				Contract: self.contract.Address,
+				ABI:      self.abi,
+				Method:   "didVote",
+				Args:     []interface{}{disputeID, addr},
+			})
+		}
+	}
+	if err := contracts.CallBatch(ctx, self.client, nil, calls); err != nil {
+		return nil, nil, errors.Wrap(err, "get dispute details")
+	}
+
+	votes := make([]*Vote, len(ids))
+	errs := make([]error, len(ids))
+	for i, id := range ids {
+		votes[i], errs[i] = self.vote(id, calls[i*perVote:(i+1)*perVote])
+	}
+	return votes, errs, nil
+}
+
+func (self *Tracker) vote(id int64, calls []*contracts.BatchCall) (*Vote, error) {
+	details := calls[0]
+	if details.Err != nil {
+		return nil, errors.Wrap(details.Err, "get dispute details")
+	}
+	executed, ok := details.Result[1].(bool)
+	if !ok {
+		return nil, errors.Errorf("unexpected executed type:%T", details.Result[1])
+	}
+	uintVars, ok := details.Result[7].([9]*big.Int)
+	if !ok {
+		return nil, errors.Errorf("unexpected dispute vars type:%T", details.Result[7])
+	}
+	vote := &Vote{
+		DisputeID:  id,
+		RequestID:  uintVars[disputeVarRequestID].Int64(),
+		Timestamp:  uintVars[disputeVarTimestamp].Int64(),
+		Value:      uintVars[disputeVarValue].String(),
+		VotingEnds: time.Unix(uintVars[disputeVarVotingEnds].Int64(), 0),
+		Votes:      uintVars[disputeVarVotes].Int64(),
+		Fee:        uintVars[disputeVarFee].String(),
+		Executed:   executed,
+		Voted:      make(map[common.Address]bool),
+	}
+	for i, addr := range self.addrs {
+		call := calls[1+i]
+		if call.Err != nil {
+			return nil, errors.Wrapf(call.Err, "get voting status for:%v", addr.String())
+		}
+		voted, ok := call.Result[0].(bool)
+		if !ok {
+			return nil, errors.Errorf("unexpected voting status type:%T", call.Result[0])
+		}
+		vote.Voted[addr] = voted
+	}
+	return vote, nil
+}
+
+func (self *Tracker) monitorNewDisputes() {
+	var err error
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	logger := log.With(self.logger, "event", "NewDispute")
+
+	var sub event.Subscription
+	events := make(chan *tellor.TellorNewDispute)
+
+	for {
+		select {
+		case <-self.ctx.Done():
+			return
+		default:
+		}
+		sub, err = self.newDisputeSub(events)
+		if err != nil {
+			level.Error(logger).Log("msg", "initial subscribing to events failed", "err", err)
+			<-ticker.C
+			continue
+		}
+		break
+	}
+
+	for {
+		select {
+		case <-self.ctx.Done():
+			return
+		case err := <-sub.Err():
+			if err != nil {
+				level.Error(logger).Log(
+					"msg",
+					"subscription error",
+					"err", err)
+			}
+
+			// Trying to resubscribe until it succeeds.
+			for {
+				select {
+				case <-self.ctx.Done():
+					return
+				default:
+				}
+				sub, err = self.newDisputeSub(events)
+				if err != nil {
+					level.Error(logger).Log("msg", "re-subscribing to events failed", "err", err)
+					<-ticker.C
+					continue
+				}
+				break
+			}
+			self.reconnects.Inc()
+			level.Info(logger).Log("msg", "re-subscribed to events")
+		case event := <-events:
+			if event.Raw.Removed {
+				continue
+			}
+			if err := self.verifier.VerifyLog(self.ctx, event.Raw); err != nil {
+				level.Error(logger).Log("msg", "verifying new dispute event", "id", event.DisputeId, "err", err)
+				continue
+			}
+			self.add(event.DisputeId)
+			err := self.notifier.Notify(self.ctx, notify.Message{
+				Event:    "vote_opened",
+				Severity: notify.SeverityInfo,
+				Title:    fmt.Sprintf("New dispute %v opened for voting", event.DisputeId),
+				Body: fmt.Sprintf(
+					"Dispute %v for request ID %v timestamp %v against miner %v. Vote with: %v",
+					event.DisputeId, event.RequestId, event.Timestamp, event.Miner.String(), VoteCommand(event.DisputeId),
+				),
+			})
+			if err != nil {
+				level.Error(logger).Log("msg", "sending new vote notification", "err", err)
+			}
+			self.notifyAgainst(logger, event)
+			select {
+			case self.refreshCh <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+func (self *Tracker) newDisputeSub(output chan *tellor.TellorNewDispute) (event.Subscription, error) {
+	filterer, err := tellor.NewTellorFilterer(self.contract.Address, self.client)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting instance")
+	}
+	sub, err := filterer.WatchNewDispute(&bind.WatchOpts{Context: self.ctx}, output, nil, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting channel")
+	}
+	return sub, nil
+}
+
+// notifyAgainst sends a critical notification when the dispute is against one of the tracked accounts.
+func (self *Tracker) notifyAgainst(logger log.Logger, event *tellor.TellorNewDispute) {
+	for _, addr := range self.addrs {
+		if addr != event.Miner {
+			continue
+		}
+		err := self.notifier.Notify(self.ctx, notify.Message{
+			Event:    notify.EventDisputeAgainstMe,
+			Severity: notify.SeverityCritical,
+			Title:    fmt.Sprintf("Dispute %v opened against %v", event.DisputeId, addr.String()),
+			Body: fmt.Sprintf(
+				"Dispute %v for request ID %v timestamp %v is against the submission of account %v.",
+				event.DisputeId, event.RequestId, event.Timestamp, addr.String(),
+			),
+			Data: map[string]string{
+				"account":   addr.String(),
+				"disputeID": event.DisputeId.String(),
+				"requestID": event.RequestId.String(),
+				"timestamp": event.Timestamp.String(),
+				"txHash":    event.Raw.TxHash.String(),
+			},
+		})
+		if err != nil {
+			level.Error(logger).Log("msg", "sending dispute against notification", "err", err)
+		}
+	}
+}
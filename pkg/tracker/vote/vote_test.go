@@ -0,0 +1,95 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package vote
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	eth "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// disputesClient returns the dispute vars of the disputes in a batch,
+// a fee of 10 times the dispute ID and an error for the unknown disputes.
+type disputesClient struct {
+	contracts.ETHClient
+	abi      *abi.ABI
+	disputes map[int64]bool
+	voted    map[common.Address]bool
+	batches  []int
+}
+
+func (self *disputesClient) BatchCallContract(_ context.Context, calls []eth.CallMsg, _ *big.Int) ([][]byte, []error, error) {
+	self.batches = append(self.batches, len(calls))
+	results := make([][]byte, len(calls))
+	errs := make([]error, len(calls))
+	for i, call := range calls {
+		results[i], errs[i] = self.call(call)
+	}
+	return results, errs, nil
+}
+
+func (self *disputesClient) call(call eth.CallMsg) ([]byte, error) {
+	for _, method := range []string{"getAllDisputeVars", "didVote"} {
+		m := self.abi.Methods[method]
+		if !bytes.Equal(call.Data[:4], m.ID) {
+			continue
+		}
+		args, err := m.Inputs.Unpack(call.Data[4:])
+		if err != nil {
+			return nil, err
+		}
+		id := args[0].(*big.Int).Int64()
+		if !self.disputes[id] {
+			return nil, errors.New("execution reverted")
+		}
+		if method == "didVote" {
+			return m.Outputs.Pack(self.voted[args[1].(common.Address)])
+		}
+		var vars [9]*big.Int
+		for i := range vars {
+			vars[i] = big.NewInt(0)
+		}
+		vars[disputeVarRequestID] = big.NewInt(1)
+		vars[disputeVarVotingEnds] = big.NewInt(1622548800)
+		vars[disputeVarFee] = big.NewInt(10 * id)
+		return m.Outputs.Pack([32]byte{}, false, false, false, common.Address{}, common.Address{}, common.Address{}, vars, big.NewInt(0))
+	}
+	return nil, errors.New("unknown method")
+}
+
+// TestFetch ensures that the details and the voting status of all votes are read in a single batch
+// and that a failed dispute doesn't fail the others.
+func TestFetch(t *testing.T) {
+	parsed, err := contracts.ParseABI(contracts.ITellorABI)
+	testutil.Ok(t, err)
+	voter, other := common.HexToAddress("0x1"), common.HexToAddress("0x2")
+	client := &disputesClient{abi: parsed, disputes: map[int64]bool{1: true, 3: true}, voted: map[common.Address]bool{voter: true}}
+	tracker := &Tracker{ctx: context.Background(), client: client, contract: &contracts.ITellor{}, abi: parsed, addrs: []common.Address{voter, other}}
+
+	votes, errs, err := tracker.fetch([]int64{1, 2, 3})
+	testutil.Ok(t, err)
+	testutil.Equals(t, []int{9}, client.batches)
+
+	testutil.NotOk(t, errs[1], "an unknown dispute")
+	for _, i := range []int{0, 2} {
+		testutil.Ok(t, errs[i])
+		testutil.Equals(t, &Vote{
+			DisputeID:  int64(i + 1),
+			RequestID:  1,
+			Value:      "0",
+			VotingEnds: time.Unix(1622548800, 0),
+			Fee:        big.NewInt(int64(10 * (i + 1))).String(),
+			Voted:      map[common.Address]bool{voter: true, other: false},
+		}, votes[i])
+	}
+}
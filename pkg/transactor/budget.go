@@ -0,0 +1,306 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package transactor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/fsutil"
+)
+
+// ErrBudgetExceeded is returned without sending a transaction
+// when its max cost would exceed the spend cap of its priority.
+var ErrBudgetExceeded = errors.New("transaction budget exceeded")
+
+// Priority decides how much of the budget a transaction can use.
+type Priority int
+
+const (
+	PriorityTip Priority = iota
+	PrioritySubmission
+	PriorityDispute
+)
+
+func (self Priority) String() string {
+	switch self {
+	case PriorityTip:
+		return "tip"
+	case PriorityDispute:
+		return "dispute"
+	default:
+		return "submission"
+	}
+}
+
+type priorityKey struct{}
+
+// WithPriority sets the priority of the transactions sent with the context.
+// Transactions without a priority are submissions.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+func priority(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	return PrioritySubmission
+}
+
+type BudgetConfig struct {
+	Enabled bool
+	// Daily and Weekly are the max ETH spent on transaction fees
+	// within the last 24 hours and 7 days, 0 disables the cap.
+	Daily  float64
+	Weekly float64
+	// SubmissionShare and TipShare are the fractions of the caps
+	// the submissions and the tips can use so that the rest is kept for the disputes
+	// which can use the full caps.
+	SubmissionShare float64
+	TipShare        float64
+	// File keeps the spends across restarts.
+	File string
+}
+
+type spend struct {
+	// ID identifies the spend across the processes sharing the budget file.
+	ID   uint64 `json:",omitempty"`
+	Time time.Time
+	Wei  *big.Int
+}
+
+// Budget caps the ETH spent on transaction fees by all transactors sharing it
+// so that a gas spike can't drain the accounts overnight.
+// The max cost of a transaction is reserved before sending it
+// and replaced by the actual cost once mined.
+// The processes with the same file e.g. the dispute and vote commands
+// hold its lock while they read it, apply their change and write it back.
+// It is safe for concurrent use.
+type Budget struct {
+	logger  log.Logger
+	cfg     BudgetConfig
+	mtx     sync.Mutex
+	spends  []*spend
+	rejects *prometheus.CounterVec
+	spent   *prometheus.GaugeVec
+}
+
+func NewBudget(logger log.Logger, cfg BudgetConfig) (*Budget, error) {
+	for _, share := range []float64{cfg.SubmissionShare, cfg.TipShare} {
+		if share <= 0 || share > 1 {
+			return nil, errors.Errorf("budget share should be between 0 and 1:%v", share)
+		}
+	}
+	if cfg.Daily < 0 || cfg.Weekly < 0 {
+		return nil, errors.Errorf("negative budget daily:%v weekly:%v", cfg.Daily, cfg.Weekly)
+	}
+	self := &Budget{
+		logger: log.With(logger, "component", "budget"),
+		cfg:    cfg,
+		rejects: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "budget_rejects_total",
+			Help:      "The total number of transactions not sent because of the spend cap",
+		}, []string{"priority"}),
+		spent: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "budget_spent_eth",
+			Help:      "The ETH spent and reserved on transaction fees within the budget window",
+		}, []string{"window"}),
+	}
+	if err := self.load(); err != nil {
+		return nil, err
+	}
+	return self, nil
+}
+
+// Reserve reserves the max cost in wei of a transaction with the given priority
+// or returns ErrBudgetExceeded when it doesn't fit its caps.
+// The returned function should be called with the actual cost once the transaction is mined
+// or with nil when it wasn't sent.
+func (self *Budget) Reserve(p Priority, maxCost *big.Int, now time.Time) (func(*big.Int), error) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	defer self.lockFile()()
+
+	if err := self.merge(); err != nil {
+		level.Error(self.logger).Log("msg", "merging the budget file", "err", err)
+	}
+	self.prune(now)
+	share := 1.0
+	switch p {
+	case PriorityTip:
+		share = self.cfg.TipShare
+	case PrioritySubmission:
+		share = self.cfg.SubmissionShare
+	}
+	for _, w := range []struct {
+		name   string
+		window time.Duration
+		cap    float64
+	}{
+		{"daily", 24 * time.Hour, self.cfg.Daily},
+		{"weekly", 7 * 24 * time.Hour, self.cfg.Weekly},
+	} {
+		if w.cap <= 0 {
+			continue
+		}
+		total := new(big.Int).Add(self.total(now, w.window), maxCost)
+		if eth(total) > w.cap*share {
+			self.rejects.With(prometheus.Labels{"priority": p.String()}).Inc()
+			return nil, errors.Wrapf(ErrBudgetExceeded, "%v transaction max cost:%v ETH would exceed the %v cap:%v ETH, already spent:%v ETH", p, eth(maxCost), w.name, w.cap*share, eth(self.total(now, w.window)))
+		}
+	}
+
+	id, err := newSpendID()
+	if err != nil {
+		return nil, err
+	}
+	self.spends = append(self.spends, &spend{ID: id, Time: now, Wei: new(big.Int).Set(maxCost)})
+	self.update(now)
+	if err := self.save(); err != nil {
+		level.Error(self.logger).Log("msg", "saving the budget", "err", err)
+	}
+	return func(cost *big.Int) {
+		self.mtx.Lock()
+		defer self.mtx.Unlock()
+		defer self.lockFile()()
+
+		if err := self.merge(); err != nil {
+			level.Error(self.logger).Log("msg", "merging the budget file", "err", err)
+		}
+		// Another process might have pruned the spend already.
+		for i, s := range self.spends {
+			if s.ID != id {
+				continue
+			}
+			if cost != nil {
+				s.Wei = new(big.Int).Set(cost)
+			} else {
+				self.spends = append(self.spends[:i], self.spends[i+1:]...)
+			}
+			break
+		}
+		self.update(time.Now())
+		if err := self.save(); err != nil {
+			level.Error(self.logger).Log("msg", "saving the budget", "err", err)
+		}
+	}, nil
+}
+
+func (self *Budget) total(now time.Time, window time.Duration) *big.Int {
+	total := big.NewInt(0)
+	for _, s := range self.spends {
+		if now.Sub(s.Time) < window {
+			total.Add(total, s.Wei)
+		}
+	}
+	return total
+}
+
+func (self *Budget) prune(now time.Time) {
+	i := 0
+	for ; i < len(self.spends) && now.Sub(self.spends[i].Time) >= 7*24*time.Hour; i++ {
+	}
+	self.spends = self.spends[i:]
+}
+
+func (self *Budget) update(now time.Time) {
+	self.spent.With(prometheus.Labels{"window": "daily"}).Set(eth(self.total(now, 24*time.Hour)))
+	self.spent.With(prometheus.Labels{"window": "weekly"}).Set(eth(self.total(now, 7*24*time.Hour)))
+}
+
+func (self *Budget) load() error {
+	defer self.lockFile()()
+	if err := self.merge(); err != nil {
+		return err
+	}
+	self.prune(time.Now())
+	self.update(time.Now())
+	return nil
+}
+
+// lockFile holds the lock of the budget file until the returned function is called.
+// Without the lock the spends are still capped within the process so a failure is only logged.
+func (self *Budget) lockFile() func() {
+	if self.cfg.File == "" {
+		return func() {}
+	}
+	unlock, err := fsutil.Lock(self.cfg.File + ".lock")
+	if err != nil {
+		level.Error(self.logger).Log("msg", "locking the budget file", "err", err)
+		return func() {}
+	}
+	return func() {
+		if err := unlock(); err != nil {
+			level.Error(self.logger).Log("msg", "unlocking the budget file", "err", err)
+		}
+	}
+}
+
+// merge replaces the spends with the ones in the file which has
+// the reservations, the actual costs and the releases of all processes sharing it.
+// It should be called with the file lock held.
+func (self *Budget) merge() error {
+	if self.cfg.File == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(self.cfg.File)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "read budget file path:%s", self.cfg.File)
+	}
+	var spends []*spend
+	if err := json.Unmarshal(data, &spends); err != nil {
+		return errors.Wrap(err, "unmarshal budget file")
+	}
+	sort.SliceStable(spends, func(i, j int) bool {
+		return spends[i].Time.Before(spends[j].Time)
+	})
+	self.spends = spends
+	return nil
+}
+
+func newSpendID() (uint64, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return 0, errors.Wrap(err, "generating the spend id")
+	}
+	return binary.BigEndian.Uint64(b[:]), nil
+}
+
+func (self *Budget) save() error {
+	if self.cfg.File == "" {
+		return nil
+	}
+	data, err := json.Marshal(self.spends)
+	if err != nil {
+		return errors.Wrap(err, "marshal budget")
+	}
+	return errors.Wrap(fsutil.WriteAtomic(self.cfg.File, data, 0600), "write budget file")
+}
+
+func eth(wei *big.Int) float64 {
+	v, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(params.Ether)).Float64()
+	return v
+}
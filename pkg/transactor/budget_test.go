@@ -0,0 +1,117 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package transactor
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+func TestBudget(t *testing.T) {
+	dir, err := ioutil.TempDir("", "budget")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	cfg := BudgetConfig{
+		Daily:           1,
+		Weekly:          3,
+		SubmissionShare: 0.8,
+		TipShare:        0.5,
+		File:            filepath.Join(dir, "budget.json"),
+	}
+	budget, err := NewBudget(log.NewNopLogger(), cfg)
+	testutil.Ok(t, err)
+
+	ether := func(v float64) *big.Int {
+		wei, _ := new(big.Float).Mul(big.NewFloat(v), big.NewFloat(params.Ether)).Int(nil)
+		return wei
+	}
+	now := time.Now()
+
+	release, err := budget.Reserve(PrioritySubmission, ether(0.4), now)
+	testutil.Ok(t, err)
+	release(ether(0.3))
+
+	// The tips can only use half of the daily cap.
+	_, err = budget.Reserve(PriorityTip, ether(0.3), now)
+	testutil.Equals(t, ErrBudgetExceeded, errors.Cause(err))
+
+	// A transaction that wasn't sent doesn't use the budget.
+	release, err = budget.Reserve(PriorityTip, ether(0.2), now)
+	testutil.Ok(t, err)
+	release(nil)
+
+	release, err = budget.Reserve(PrioritySubmission, ether(0.5), now)
+	testutil.Ok(t, err)
+	release(ether(0.5))
+
+	// The rest of the daily cap is kept for the disputes.
+	_, err = budget.Reserve(PrioritySubmission, ether(0.1), now)
+	testutil.Equals(t, ErrBudgetExceeded, errors.Cause(err))
+	_, err = budget.Reserve(PriorityDispute, ether(0.1), now)
+	testutil.Ok(t, err)
+
+	// The spends are kept across restarts.
+	budget = &Budget{logger: budget.logger, cfg: cfg, rejects: budget.rejects, spent: budget.spent}
+	testutil.Ok(t, budget.load())
+	_, err = budget.Reserve(PrioritySubmission, ether(0.1), now)
+	testutil.Equals(t, ErrBudgetExceeded, errors.Cause(err))
+
+	// The spends of another process sharing the file e.g. a dispute command are merged
+	// and the released ones aren't added back.
+	other := &Budget{logger: budget.logger, cfg: cfg, rejects: budget.rejects, spent: budget.spent}
+	testutil.Ok(t, other.load())
+	release, err = other.Reserve(PriorityDispute, ether(0.05), now)
+	testutil.Ok(t, err)
+	release(ether(0.05))
+	release, err = budget.Reserve(PriorityDispute, ether(0.02), now)
+	testutil.Ok(t, err)
+	release(nil)
+	testutil.Equals(t, ether(0.95), budget.total(now, 24*time.Hour))
+	testutil.Ok(t, other.merge())
+	testutil.Equals(t, ether(0.95), other.total(now, 24*time.Hour))
+
+	// The actual costs and the releases of another process replace its reservations
+	// instead of being overwritten by the stale reservations of this process.
+	releaseOther, err := other.Reserve(PriorityDispute, ether(0.03), now)
+	testutil.Ok(t, err)
+	releaseOther2, err := other.Reserve(PriorityDispute, ether(0.01), now)
+	testutil.Ok(t, err)
+	testutil.Ok(t, budget.merge())
+	testutil.Equals(t, ether(0.99), budget.total(now, 24*time.Hour))
+	releaseOther(ether(0.02))
+	releaseOther2(nil)
+	release, err = budget.Reserve(PriorityDispute, ether(0.01), now)
+	testutil.Ok(t, err)
+	release(nil)
+	testutil.Equals(t, ether(0.97), budget.total(now, 24*time.Hour))
+	testutil.Ok(t, other.merge())
+	testutil.Equals(t, ether(0.97), other.total(now, 24*time.Hour))
+	info, err := os.Stat(cfg.File)
+	testutil.Ok(t, err)
+	testutil.Equals(t, os.FileMode(0600), info.Mode().Perm())
+
+	// The next day only the weekly cap applies to the previous spends.
+	_, err = budget.Reserve(PrioritySubmission, ether(0.7), now.Add(25*time.Hour))
+	testutil.Ok(t, err)
+	_, err = budget.Reserve(PrioritySubmission, ether(0.7), now.Add(50*time.Hour))
+	testutil.Ok(t, err)
+	_, err = budget.Reserve(PrioritySubmission, ether(0.7), now.Add(75*time.Hour))
+	testutil.Equals(t, ErrBudgetExceeded, errors.Cause(err))
+}
+
+func TestPriority(t *testing.T) {
+	testutil.Equals(t, PrioritySubmission, priority(context.Background()))
+	testutil.Equals(t, PriorityTip, priority(WithPriority(context.Background(), PriorityTip)))
+}
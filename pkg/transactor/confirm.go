@@ -0,0 +1,45 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package transactor
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/contracts"
+)
+
+// finalizedPollInterval is how often the confirmations of a mined transaction are checked.
+const finalizedPollInterval = 5 * time.Second
+
+// WaitFinalized waits until a mined transaction has the given number of confirmations
+// and returns its receipt at that time.
+// The receipt is checked again every time so that a transaction moved to another block
+// or dropped by a reorg needs the confirmations again.
+func WaitFinalized(ctx context.Context, client contracts.ETHClient, hash common.Hash, confirmations uint64) (*types.Receipt, error) {
+	ticker := time.NewTicker(finalizedPollInterval)
+	defer ticker.Stop()
+	for {
+		receipt, err := client.TransactionReceipt(ctx, hash)
+		if err == nil && receipt != nil {
+			head, err := client.HeaderByNumber(ctx, nil)
+			if err != nil {
+				return nil, errors.Wrap(err, "getting the latest block header")
+			}
+			depth := new(big.Int).Sub(head.Number, receipt.BlockNumber)
+			if depth.Sign() >= 0 && depth.Uint64() >= confirmations {
+				return receipt, nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
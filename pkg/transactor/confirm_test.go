@@ -0,0 +1,38 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package transactor
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/tellor-io/telliot/pkg/testutil"
+	"github.com/tellor-io/telliot/pkg/testutil/chain"
+)
+
+func TestWaitFinalized(t *testing.T) {
+	c, err := chain.New(1)
+	testutil.Ok(t, err)
+	defer c.Close()
+
+	opts, err := c.Opts(c.Accounts[0])
+	testutil.Ok(t, err)
+	tx, err := c.TellorAccess.SubmitValue(opts, big.NewInt(1), big.NewInt(1000))
+	testutil.Ok(t, err)
+	for i := 0; i < 2; i++ {
+		testutil.Ok(t, c.Advance(time.Second))
+	}
+
+	receipt, err := WaitFinalized(context.Background(), c, tx.Hash(), 2)
+	testutil.Ok(t, err)
+	testutil.Equals(t, tx.Hash(), receipt.TxHash)
+
+	// The transaction doesn't have the confirmations yet.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = WaitFinalized(ctx, c, tx.Hash(), 3)
+	testutil.Equals(t, context.DeadlineExceeded, err)
+}
@@ -0,0 +1,281 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package transactor
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/tellor-io/telliot/pkg/web"
+)
+
+const (
+	GasUsedMetricName  = ComponentName + "_gas_used"
+	GasPriceMetricName = ComponentName + "_gas_price"
+
+	// The contract functions of the recorded transactions.
+	FunctionSubmitMiningSolution = "submitMiningSolution"
+	FunctionSubmitValue          = "submitValue"
+	FunctionAddTip               = "addTip"
+	FunctionBeginDispute         = "beginDispute"
+	FunctionVote                 = "vote"
+	FunctionDepositStake         = "depositStake"
+	FunctionRequestWithdraw      = "requestStakingWithdraw"
+	FunctionWithdrawStake        = "withdrawStake"
+	FunctionTransfer             = "transfer"
+	// FunctionCancel is recorded for the mined cancel replacements.
+	FunctionCancel = "cancel"
+)
+
+// The limits of the gas records sent through the API.
+const (
+	maxRecordGasUsed  = 30000000
+	maxRecordGasPrice = 10000 * params.GWei
+	maxRecordAge      = time.Hour
+	maxRecordSkew     = time.Minute
+)
+
+// cliFunctions are the contract functions of the transactions sent by the CLI commands
+// whose gas can be recorded through the API.
+var cliFunctions = map[string]bool{
+	FunctionBeginDispute:    true,
+	FunctionVote:            true,
+	FunctionAddTip:          true,
+	FunctionDepositStake:    true,
+	FunctionRequestWithdraw: true,
+	FunctionWithdrawStake:   true,
+	FunctionTransfer:        true,
+}
+
+type functionKey struct{}
+
+// WithFunction sets the contract function of the transactions sent with the context
+// for the gas records.
+func WithFunction(ctx context.Context, fn string) context.Context {
+	return context.WithValue(ctx, functionKey{}, fn)
+}
+
+func function(ctx context.Context) string {
+	if fn, ok := ctx.Value(functionKey{}).(string); ok {
+		return fn
+	}
+	return "unknown"
+}
+
+// GasRecord is the gas used and the effective gas price in wei of a mined transaction.
+type GasRecord struct {
+	Function string    `json:"function"`
+	Account  string    `json:"account"`
+	GasUsed  uint64    `json:"gasUsed"`
+	GasPrice *big.Int  `json:"gasPrice"`
+	Time     time.Time `json:"time"`
+}
+
+// GasStats are the averages of the transactions of a contract function.
+type GasStats struct {
+	Function string  `json:"function"`
+	Count    int     `json:"count"`
+	GasUsed  float64 `json:"gasUsed"`
+	// GasPrice is in gwei.
+	GasPrice float64 `json:"gasPrice"`
+	// Cost is in ETH.
+	Cost float64 `json:"cost"`
+}
+
+type GasDB interface {
+	storage.Appendable
+	storage.Queryable
+}
+
+// GasRecorder writes the gas of every mined transaction in the DB
+// as series labeled with the contract function and the account.
+type GasRecorder struct {
+	logger log.Logger
+	db     GasDB
+}
+
+func NewGasRecorder(logger log.Logger, db GasDB) *GasRecorder {
+	return &GasRecorder{
+		logger: log.With(logger, "component", "gasRecorder"),
+		db:     db,
+	}
+}
+
+func (self *GasRecorder) Record(ctx context.Context, rec GasRecord) (err error) {
+	if rec.GasPrice == nil {
+		return errors.New("missing gas price")
+	}
+	appender := self.db.Appender(ctx)
+	defer func() { // An appender always needs to be committed or rolled back.
+		if err != nil {
+			if err := appender.Rollback(); err != nil {
+				level.Error(self.logger).Log("msg", "db rollback failed", "err", err)
+			}
+			return
+		}
+		err = errors.Wrap(appender.Commit(), "db append commit failed")
+	}()
+
+	ts := timestamp.FromTime(rec.Time)
+	gasPrice, _ := new(big.Float).SetInt(rec.GasPrice).Float64()
+	for name, v := range map[string]float64{
+		GasUsedMetricName:  float64(rec.GasUsed),
+		GasPriceMetricName: gasPrice,
+	} {
+		lbls := labels.Labels{
+			labels.Label{Name: "__name__", Value: name},
+			labels.Label{Name: "function", Value: rec.Function},
+			labels.Label{Name: "account", Value: rec.Account},
+		}
+		sort.Sort(lbls) // The labels need to be sorted to avoid creating the same series with duplicate reference.
+		if _, err := appender.Append(0, lbls, ts, v); err != nil {
+			return errors.Wrap(err, "append gas to the DB")
+		}
+	}
+	return nil
+}
+
+// Summary returns the average gas and cost per contract function
+// of the transactions mined within the window before the given time.
+func (self *GasRecorder) Summary(ctx context.Context, window time.Duration, now time.Time) ([]GasStats, error) {
+	q, err := self.db.Querier(ctx, timestamp.FromTime(now.Add(-window)), timestamp.FromTime(now))
+	if err != nil {
+		return nil, errors.Wrap(err, "create querier")
+	}
+	defer q.Close()
+
+	// The gas used and price of a transaction are at the same timestamp in the series with the same labels.
+	type series struct {
+		function, account string
+	}
+	type tx struct {
+		gasUsed, gasPrice float64
+	}
+	txs := make(map[series]map[int64]*tx)
+	set := q.Select(false, nil, labels.MustNewMatcher(labels.MatchRegexp, labels.MetricName, GasUsedMetricName+"|"+GasPriceMetricName))
+	for set.Next() {
+		lbls := set.At().Labels()
+		key := series{function: lbls.Get("function"), account: lbls.Get("account")}
+		if txs[key] == nil {
+			txs[key] = make(map[int64]*tx)
+		}
+		it := set.At().Iterator()
+		for it.Next() {
+			t, v := it.At()
+			if txs[key][t] == nil {
+				txs[key][t] = &tx{}
+			}
+			if lbls.Get(labels.MetricName) == GasUsedMetricName {
+				txs[key][t].gasUsed = v
+			} else {
+				txs[key][t].gasPrice = v
+			}
+		}
+		if err := it.Err(); err != nil {
+			return nil, errors.Wrap(err, "iterate samples")
+		}
+	}
+	if err := set.Err(); err != nil {
+		return nil, errors.Wrap(err, "select series")
+	}
+
+	stats := make(map[string]*GasStats)
+	for key, txs := range txs {
+		s, ok := stats[key.function]
+		if !ok {
+			s = &GasStats{Function: key.function}
+			stats[key.function] = s
+		}
+		for _, tx := range txs {
+			s.Count++
+			s.GasUsed += tx.gasUsed
+			s.GasPrice += tx.gasPrice / params.GWei
+			s.Cost += tx.gasUsed * tx.gasPrice / params.Ether
+		}
+	}
+	var summary []GasStats
+	for _, s := range stats {
+		s.GasUsed /= float64(s.Count)
+		s.GasPrice /= float64(s.Count)
+		s.Cost /= float64(s.Count)
+		summary = append(summary, *s)
+	}
+	sort.Slice(summary, func(i, j int) bool { return summary[i].Function < summary[j].Function })
+	return summary, nil
+}
+
+// ServeSummary serves the summary over the window in the query like `?window=24h`.
+func (self *GasRecorder) ServeSummary(w http.ResponseWriter, r *http.Request) {
+	var summary []GasStats
+	code, err := func() (int, error) {
+		window, err := time.ParseDuration(r.URL.Query().Get("window"))
+		if err != nil {
+			return http.StatusBadRequest, errors.Wrap(err, "parsing the window")
+		}
+		summary, err = self.Summary(r.Context(), window, time.Now())
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		return http.StatusOK, nil
+	}()
+	if err := web.WriteJSON(w, code, summary, err); err != nil {
+		level.Error(self.logger).Log("msg", "encoding gas summary response", "err", err)
+	}
+}
+
+// validate checks that a record sent through the API is a transaction a CLI command could have sent recently.
+func (self GasRecord) validate(now time.Time) error {
+	if !cliFunctions[self.Function] {
+		return errors.Errorf("invalid function:%v", self.Function)
+	}
+	if !common.IsHexAddress(self.Account) {
+		return errors.Errorf("invalid account:%v", self.Account)
+	}
+	if self.GasUsed == 0 || self.GasUsed > maxRecordGasUsed {
+		return errors.Errorf("gas used:%v should be between 1 and %v", self.GasUsed, maxRecordGasUsed)
+	}
+	if self.GasPrice == nil || self.GasPrice.Sign() <= 0 || self.GasPrice.Cmp(big.NewInt(maxRecordGasPrice)) > 0 {
+		return errors.Errorf("gas price:%v should be between 1 and %v wei", self.GasPrice, int64(maxRecordGasPrice))
+	}
+	if self.Time.Before(now.Add(-maxRecordAge)) || self.Time.After(now.Add(maxRecordSkew)) {
+		return errors.Errorf("time:%v should be within the last:%v", self.Time, maxRecordAge)
+	}
+	return nil
+}
+
+// ServeRecord records the gas of the transactions sent by the CLI commands
+// while the DB is used by a running instance.
+// It should only be served with the auth as it writes to the DB.
+func (self *GasRecorder) ServeRecord(w http.ResponseWriter, r *http.Request) {
+	code, err := func() (int, error) {
+		var rec GasRecord
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&rec); err != nil {
+			return http.StatusBadRequest, errors.Wrap(err, "decoding the gas record")
+		}
+		if err := rec.validate(time.Now()); err != nil {
+			return http.StatusBadRequest, err
+		}
+		if err := self.Record(r.Context(), rec); err != nil {
+			return http.StatusInternalServerError, err
+		}
+		return http.StatusOK, nil
+	}()
+	if err := web.WriteJSON(w, code, nil, err); err != nil {
+		level.Error(self.logger).Log("msg", "encoding gas record response", "err", err)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package transactor
+
+import (
+	"context"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+func TestGasSummary(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gas")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+	tsDB, err := tsdb.Open(dir, nil, nil, tsdb.DefaultOptions())
+	testutil.Ok(t, err)
+	defer tsDB.Close()
+
+	recorder := NewGasRecorder(log.NewNopLogger(), tsDB)
+	ctx := context.Background()
+	now := time.Now()
+	for _, rec := range []GasRecord{
+		// Outside the window.
+		{Function: FunctionSubmitMiningSolution, Account: "0x1", GasUsed: 1e6, GasPrice: big.NewInt(100 * params.GWei), Time: now.Add(-4 * time.Hour)},
+		{Function: FunctionSubmitMiningSolution, Account: "0x1", GasUsed: 200000, GasPrice: big.NewInt(10 * params.GWei), Time: now.Add(-time.Hour)},
+		{Function: FunctionSubmitMiningSolution, Account: "0x2", GasUsed: 300000, GasPrice: big.NewInt(30 * params.GWei), Time: now.Add(-time.Hour)},
+		{Function: FunctionVote, Account: "0x1", GasUsed: 100000, GasPrice: big.NewInt(20 * params.GWei), Time: now.Add(-2 * time.Hour)},
+	} {
+		testutil.Ok(t, recorder.Record(ctx, rec))
+	}
+
+	summary, err := recorder.Summary(ctx, 3*time.Hour, now)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 2, len(summary))
+
+	submit := summary[0]
+	testutil.Equals(t, FunctionSubmitMiningSolution, submit.Function)
+	testutil.Equals(t, 2, submit.Count)
+	testutil.Equals(t, 250000.0, submit.GasUsed)
+	testutil.Equals(t, 20.0, submit.GasPrice)
+	expCost := (200000*10e-9 + 300000*30e-9) / 2
+	testutil.Assert(t, math.Abs(expCost-submit.Cost) < 1e-12, "expected cost:%v, actual:%v", expCost, submit.Cost)
+
+	vote := summary[1]
+	testutil.Equals(t, FunctionVote, vote.Function)
+	testutil.Equals(t, 1, vote.Count)
+	testutil.Assert(t, math.Abs(0.002-vote.Cost) < 1e-12, "unexpected cost:%v", vote.Cost)
+}
+
+func TestGasRecordValidate(t *testing.T) {
+	now := time.Now()
+	valid := GasRecord{
+		Function: FunctionVote,
+		Account:  "0x0000000000000000000000000000000000000001",
+		GasUsed:  50000,
+		GasPrice: big.NewInt(params.GWei),
+		Time:     now,
+	}
+	testutil.Ok(t, valid.validate(now))
+
+	for name, modify := range map[string]func(*GasRecord){
+		"unknown function":    func(r *GasRecord) { r.Function = "drain" },
+		"submission":          func(r *GasRecord) { r.Function = FunctionSubmitMiningSolution },
+		"invalid account":     func(r *GasRecord) { r.Account = "0x01" },
+		"zero gas":            func(r *GasRecord) { r.GasUsed = 0 },
+		"gas above the block": func(r *GasRecord) { r.GasUsed = maxRecordGasUsed + 1 },
+		"missing gas price":   func(r *GasRecord) { r.GasPrice = nil },
+		"negative gas price":  func(r *GasRecord) { r.GasPrice = big.NewInt(-1) },
+		"huge gas price":      func(r *GasRecord) { r.GasPrice = new(big.Int).Mul(big.NewInt(maxRecordGasPrice), big.NewInt(2)) },
+		"too old":             func(r *GasRecord) { r.Time = now.Add(-2 * maxRecordAge) },
+		"in the future":       func(r *GasRecord) { r.Time = now.Add(2 * maxRecordSkew) },
+	} {
+		rec := valid
+		modify(&rec)
+		testutil.NotOk(t, rec.validate(now), name)
+	}
+}
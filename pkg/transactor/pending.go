@@ -0,0 +1,390 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package transactor
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/db"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/web"
+)
+
+// ErrCanceled is returned when a transaction was replaced by a cancel transaction.
+var ErrCanceled = errors.New("transaction canceled")
+
+const (
+	// cancelGas is the gas limit of a plain transfer used to cancel a transaction.
+	cancelGas = 21000
+	// minBump is the min gas price increase in percent for the nodes to accept a replacement.
+	minBump = 10
+	// defaultBump is used when bumping or canceling without a gas price.
+	defaultBump = 20
+)
+
+// Pending is an in-flight transaction as served by the API.
+type Pending struct {
+	Account  string          `json:"account"`
+	Hash     string          `json:"hash"`
+	Nonce    uint64          `json:"nonce"`
+	GasPrice *big.Int        `json:"gasPrice"`
+	Function string          `json:"function"`
+	Age      format.Duration `json:"age"`
+	// Replacements is the number of times the transaction was replaced with a higher gas price.
+	Replacements int  `json:"replacements"`
+	Canceled     bool `json:"canceled"`
+}
+
+// inFlight is a sent transaction waiting to be mined with all its replacements.
+// Any of them can be mined.
+type inFlight struct {
+	transactor *TransactorDefault
+	function   string
+	sent       time.Time
+	mtx        sync.Mutex
+	txs        []*types.Transaction
+	cancel     *types.Transaction
+	// route sends the replacements of the routed transactions.
+	route *route
+	// priority is the budget priority of the transaction.
+	priority Priority
+	// reservedPrice is the gas price of the max cost reserved in the budget
+	// and reservations are the ones of the replacements above the reservation of the transaction itself.
+	reservedPrice *big.Int
+	reservations  []reservation
+}
+
+type reservation struct {
+	release func(*big.Int)
+	maxCost *big.Int
+}
+
+func (self *inFlight) latest() *types.Transaction {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	return self.txs[len(self.txs)-1]
+}
+
+func (self *inFlight) all() []*types.Transaction {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	return append([]*types.Transaction(nil), self.txs...)
+}
+
+// Pool keeps the in-flight transactions of all transactors sharing it
+// for manual bumping and canceling of stuck transactions.
+// All broadcast transactions and their replacements are recorded in the audit log when not nil.
+// It is safe for concurrent use.
+type Pool struct {
+	logger log.Logger
+	audit  *db.Audit
+	mtx    sync.Mutex
+	txs    map[*inFlight]struct{}
+}
+
+func NewPool(logger log.Logger, audit *db.Audit) *Pool {
+	return &Pool{
+		logger: log.With(logger, "component", "txPool"),
+		audit:  audit,
+		txs:    make(map[*inFlight]struct{}),
+	}
+}
+
+func (self *Pool) add(tx *inFlight) {
+	self.mtx.Lock()
+	self.txs[tx] = struct{}{}
+	self.mtx.Unlock()
+	self.record("tx_broadcast", tx.pending(time.Now()))
+}
+
+// record appends a transaction to the audit log with the account as the actor.
+func (self *Pool) record(action string, p Pending) {
+	if err := self.audit.Record(db.AuditEntry{
+		Actor:  p.Account,
+		Action: action,
+		Target: p.Hash,
+		Details: map[string]string{
+			"function": p.Function,
+			"nonce":    strconv.FormatUint(p.Nonce, 10),
+			"gasPrice": p.GasPrice.String(),
+		},
+	}); err != nil {
+		level.Error(self.logger).Log("msg", "recording audit entry", "hash", p.Hash, "err", err)
+	}
+}
+
+func (self *Pool) remove(tx *inFlight) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	delete(self.txs, tx)
+}
+
+// List returns the in-flight transactions sorted by account and nonce.
+func (self *Pool) List(now time.Time) []Pending {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	var list []Pending
+	for tx := range self.txs {
+		list = append(list, tx.pending(now))
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Account != list[j].Account {
+			return list[i].Account < list[j].Account
+		}
+		return list[i].Nonce < list[j].Nonce
+	})
+	return list
+}
+
+func (self *inFlight) pending(now time.Time) Pending {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	latest := self.txs[len(self.txs)-1]
+	return Pending{
+		Account:      self.transactor.account.Address.String(),
+		Hash:         latest.Hash().String(),
+		Nonce:        latest.Nonce(),
+		GasPrice:     latest.GasPrice(),
+		Function:     self.function,
+		Age:          format.Duration{Duration: now.Sub(self.sent).Truncate(time.Second)},
+		Replacements: len(self.txs) - 1,
+		Canceled:     self.cancel != nil,
+	}
+}
+
+// find returns the in-flight transaction with the given hash or one of its replacements.
+func (self *Pool) find(hash string) (*inFlight, error) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	for tx := range self.txs {
+		for _, t := range tx.all() {
+			if strings.EqualFold(t.Hash().String(), hash) {
+				return tx, nil
+			}
+		}
+	}
+	return nil, errors.Errorf("no pending transaction with hash:%v", hash)
+}
+
+// Bump replaces a pending transaction with the same one at a higher gas price.
+// A nil gas price increases the current one by 20%.
+func (self *Pool) Bump(ctx context.Context, hash string, gasPrice *big.Int) (*Pending, error) {
+	tx, err := self.find(hash)
+	if err != nil {
+		return nil, err
+	}
+	latest := tx.latest()
+	gasPrice, err = bumpedPrice(latest.GasPrice(), gasPrice, tx.transactor.maxGasPrice())
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.replace(ctx, types.NewTransaction(latest.Nonce(), *latest.To(), latest.Value(), latest.Gas(), gasPrice, latest.Data()), tx.isCancel(latest)); err != nil {
+		return nil, err
+	}
+	p := tx.pending(time.Now())
+	self.record("tx_bump", p)
+	level.Info(self.logger).Log("msg", "bumped transaction", "hash", p.Hash, "nonce", p.Nonce, "gasPrice", p.GasPrice)
+	return &p, nil
+}
+
+// Cancel replaces a pending transaction with an empty transfer to the same account at a higher gas price.
+// A nil gas price increases the current one by 20%.
+func (self *Pool) Cancel(ctx context.Context, hash string, gasPrice *big.Int) (*Pending, error) {
+	tx, err := self.find(hash)
+	if err != nil {
+		return nil, err
+	}
+	latest := tx.latest()
+	gasPrice, err = bumpedPrice(latest.GasPrice(), gasPrice, tx.transactor.maxGasPrice())
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.replace(ctx, types.NewTransaction(latest.Nonce(), tx.transactor.account.Address, big.NewInt(0), cancelGas, gasPrice, nil), true); err != nil {
+		return nil, err
+	}
+	p := tx.pending(time.Now())
+	self.record("tx_cancel", p)
+	level.Info(self.logger).Log("msg", "canceled transaction", "hash", p.Hash, "nonce", p.Nonce, "gasPrice", p.GasPrice)
+	return &p, nil
+}
+
+// bumpedPrice returns the gas price of a replacement capped at the max gas price of the transactor.
+func bumpedPrice(current, requested, max *big.Int) (*big.Int, error) {
+	min := new(big.Int).Mul(current, big.NewInt(100+minBump))
+	min.Div(min, big.NewInt(100))
+	if requested == nil {
+		requested = new(big.Int).Mul(current, big.NewInt(100+defaultBump))
+		requested.Div(requested, big.NewInt(100))
+	}
+	if requested.Cmp(max) > 0 {
+		requested = new(big.Int).Set(max)
+	}
+	if requested.Cmp(min) < 0 {
+		if requested.Cmp(max) == 0 {
+			return nil, errors.Errorf("gas price:%v can't be bumped by %v%% without exceeding the max:%v", current, minBump, max)
+		}
+		return nil, errors.Errorf("gas price:%v should be at least %v%% higher than the current:%v", requested, minBump, current)
+	}
+	return requested, nil
+}
+
+func (self *inFlight) replace(ctx context.Context, tx *types.Transaction, cancel bool) error {
+	t := self.transactor
+	netID, err := t.client.NetworkID(ctx)
+	if err != nil {
+		return errors.Wrap(err, "getting network id")
+	}
+	r, err := self.reserve(tx.GasPrice())
+	if err != nil {
+		return err
+	}
+	signed, err := t.signer.SignTx(t.account.Address, tx, netID)
+	if err != nil {
+		r.release(nil)
+		return errors.Wrap(err, "signing the replacement")
+	}
+	send := t.client.SendTransaction
+	if self.route != nil {
+		send = self.route.Send
+	}
+	if err := send(ctx, signed); err != nil {
+		r.release(nil)
+		return errors.Wrap(err, "sending the replacement")
+	}
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	self.txs = append(self.txs, signed)
+	self.reservations = append(self.reservations, r)
+	if gasPrice := tx.GasPrice(); self.reservedPrice == nil || gasPrice.Cmp(self.reservedPrice) > 0 {
+		self.reservedPrice = gasPrice
+	}
+	if cancel {
+		self.cancel = signed
+	}
+	return nil
+}
+
+// reserve reserves in the budget the increase of the max cost of the transaction
+// when replaced at the given gas price.
+func (self *inFlight) reserve(gasPrice *big.Int) (reservation, error) {
+	self.mtx.Lock()
+	reserved := self.reservedPrice
+	self.mtx.Unlock()
+	budget := self.transactor.budget
+	if budget == nil || reserved == nil || gasPrice.Cmp(reserved) <= 0 {
+		return reservation{release: func(*big.Int) {}}, nil
+	}
+	maxCost := new(big.Int).Sub(gasPrice, reserved)
+	maxCost.Mul(maxCost, big.NewInt(estimatedGas))
+	release, err := budget.Reserve(self.priority, maxCost, time.Now())
+	if err != nil {
+		return reservation{}, errors.Wrap(err, "reserving the replacement cost")
+	}
+	return reservation{release: release, maxCost: maxCost}, nil
+}
+
+// settle releases the reservations of the replacements.
+// Once mined the actual cost is in the reservation of the transaction itself
+// otherwise the replacements can still be mined so their max costs are kept.
+func (self *inFlight) settle(mined bool) {
+	self.mtx.Lock()
+	reservations := self.reservations
+	self.reservations = nil
+	self.mtx.Unlock()
+	for _, r := range reservations {
+		if mined {
+			r.release(nil)
+		} else {
+			r.release(r.maxCost)
+		}
+	}
+}
+
+// isCancel returns true when the transaction is the cancel replacement.
+func (self *inFlight) isCancel(tx *types.Transaction) bool {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	return self.cancel != nil && self.cancel.Hash() == tx.Hash()
+}
+
+// waitMined waits until the transaction or any of its replacements is mined.
+func (self *inFlight) waitMined(ctx context.Context) (*types.Transaction, *types.Receipt, error) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		for _, tx := range self.all() {
+			receipt, err := self.transactor.client.TransactionReceipt(ctx, tx.Hash())
+			if err == nil && receipt != nil {
+				return tx, receipt, nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// ReplaceRequest is the body of the bump and cancel requests.
+type ReplaceRequest struct {
+	Hash string `json:"hash"`
+	// GasPrice is in gwei, 0 increases the current gas price by 20%.
+	GasPrice float64 `json:"gasPrice"`
+}
+
+// ServeList serves the in-flight transactions.
+func (self *Pool) ServeList(w http.ResponseWriter, r *http.Request) {
+	if err := web.WriteJSON(w, http.StatusOK, self.List(time.Now()), nil); err != nil {
+		level.Error(self.logger).Log("msg", "encoding pending transactions response", "err", err)
+	}
+}
+
+func (self *Pool) ServeBump(w http.ResponseWriter, r *http.Request) {
+	self.serveReplace(w, r, self.Bump)
+}
+
+func (self *Pool) ServeCancel(w http.ResponseWriter, r *http.Request) {
+	self.serveReplace(w, r, self.Cancel)
+}
+
+func (self *Pool) serveReplace(w http.ResponseWriter, r *http.Request, replace func(context.Context, string, *big.Int) (*Pending, error)) {
+	var resp *Pending
+	code, err := func() (int, error) {
+		var req ReplaceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return http.StatusBadRequest, errors.Wrap(err, "decoding the request")
+		}
+		if len(common.FromHex(req.Hash)) != common.HashLength {
+			return http.StatusBadRequest, errors.Errorf("invalid transaction hash:%v", req.Hash)
+		}
+		var gasPrice *big.Int
+		if req.GasPrice > 0 {
+			gasPrice, _ = new(big.Float).Mul(big.NewFloat(req.GasPrice), big.NewFloat(params.GWei)).Int(nil)
+		}
+		var err error
+		resp, err = replace(r.Context(), req.Hash, gasPrice)
+		if err != nil {
+			return http.StatusBadRequest, err
+		}
+		return http.StatusOK, nil
+	}()
+	if err := web.WriteJSON(w, code, resp, err); err != nil {
+		level.Error(self.logger).Log("msg", "encoding replaced transaction response", "err", err)
+	}
+}
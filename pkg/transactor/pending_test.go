@@ -0,0 +1,37 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package transactor
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+func TestBumpedPrice(t *testing.T) {
+	current := big.NewInt(100)
+	max := big.NewInt(200)
+
+	price, err := bumpedPrice(current, nil, max)
+	testutil.Ok(t, err)
+	testutil.Equals(t, big.NewInt(120), price)
+
+	price, err = bumpedPrice(current, big.NewInt(110), max)
+	testutil.Ok(t, err)
+	testutil.Equals(t, big.NewInt(110), price)
+
+	// The nodes don't accept a replacement with less than 10% higher gas price.
+	_, err = bumpedPrice(current, big.NewInt(109), max)
+	testutil.NotOk(t, err)
+
+	// The requested price is capped at the max gas price.
+	price, err = bumpedPrice(current, big.NewInt(1000), max)
+	testutil.Ok(t, err)
+	testutil.Equals(t, max, price)
+
+	// A transaction already close to the max can't be bumped.
+	_, err = bumpedPrice(big.NewInt(190), nil, max)
+	testutil.NotOk(t, err)
+}
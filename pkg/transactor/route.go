@@ -0,0 +1,151 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package transactor
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/contracts"
+)
+
+// The types of the fee routes.
+const (
+	// RouteRelay sends the signed transactions to the RPC of a relay instead of the node,
+	// e.g. of a sponsor that pays or refunds part of the gas.
+	RouteRelay = "relay"
+	// RouteForwarder calls a forwarder contract with the target and the calldata of the transactions,
+	// e.g. one that frees gas tokens to get a refund of part of the gas.
+	RouteForwarder = "forwarder"
+)
+
+// defaultForwardMethod is the method of the forwarder contracts without a configured one.
+const defaultForwardMethod = "forward(address,bytes)"
+
+var routedCount = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "telliot",
+	Subsystem: ComponentName,
+	Name:      "routed_total",
+	Help:      "The total number of transactions sent through a fee route by result",
+}, []string{"route", "result"})
+
+// RouteConfig is a fee optimizing path for the transactions on a network.
+type RouteConfig struct {
+	// Type is relay or forwarder.
+	Type string
+	// Functions are the contract functions sent through the route, all when empty.
+	Functions []string
+	// URL is the RPC of a relay which gets the signed transactions with eth_sendRawTransaction.
+	URL string
+	// Forwarder is the contract of a forwarder route.
+	// The oracle sees the forwarder as the sender so it should be the staked account.
+	Forwarder string
+	// Method of the forwarder with the target address and the calldata, forward(address,bytes) by default.
+	Method string
+	// Fallback sends the transaction through the node when the route fails.
+	// It doesn't apply to the forwarder routes as the oracle expects the forwarder as the sender.
+	Fallback bool
+}
+
+// Route sends the transactions through a fee optimizing path instead of the node.
+type Route interface {
+	// Prepare returns the transaction to sign and send instead of the one of the contract call
+	// or the same one when the route doesn't change it.
+	Prepare(tx *types.Transaction) (*types.Transaction, error)
+	// Send sends a signed transaction.
+	Send(ctx context.Context, tx *types.Transaction) error
+}
+
+// route is a configured route with the contract functions it applies to.
+type route struct {
+	Route
+	cfg       RouteConfig
+	functions map[string]bool
+}
+
+func (self *route) applies(fn string) bool {
+	return len(self.functions) == 0 || self.functions[fn]
+}
+
+// NewRoute creates a route from its config. The client sends the transactions of the forwarder routes.
+func NewRoute(cfg RouteConfig, client contracts.ETHClient) (Route, error) {
+	switch cfg.Type {
+	case RouteRelay:
+		if cfg.URL == "" {
+			return nil, errors.New("missing relay url")
+		}
+		// The HTTP clients connect with the first request.
+		rpcClient, err := rpc.Dial(cfg.URL)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating relay client")
+		}
+		return &relay{client: ethclient.NewClient(rpcClient)}, nil
+	case RouteForwarder:
+		if !common.IsHexAddress(cfg.Forwarder) {
+			return nil, errors.Errorf("invalid forwarder address:%v", cfg.Forwarder)
+		}
+		method := cfg.Method
+		if method == "" {
+			method = defaultForwardMethod
+		}
+		if !forwardMethod.MatchString(method) {
+			return nil, errors.Errorf("forwarder method:%v should have the address and bytes params like %v", method, defaultForwardMethod)
+		}
+		address, _ := abi.NewType("address", "", nil)
+		bytes, _ := abi.NewType("bytes", "", nil)
+		return &forwarder{
+			client:   client,
+			address:  common.HexToAddress(cfg.Forwarder),
+			selector: crypto.Keccak256([]byte(method))[:4],
+			args:     abi.Arguments{{Type: address}, {Type: bytes}},
+		}, nil
+	}
+	return nil, errors.Errorf("unknown route type:%v, should be relay or forwarder", cfg.Type)
+}
+
+var forwardMethod = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*\(address,bytes\)$`)
+
+type relay struct {
+	client *ethclient.Client
+}
+
+func (self *relay) Prepare(tx *types.Transaction) (*types.Transaction, error) {
+	return tx, nil
+}
+
+func (self *relay) Send(ctx context.Context, tx *types.Transaction) error {
+	return errors.Wrap(self.client.SendTransaction(ctx, tx), "sending to the relay")
+}
+
+type forwarder struct {
+	client   contracts.ETHClient
+	address  common.Address
+	selector []byte
+	args     abi.Arguments
+}
+
+// Prepare wraps the call in a call of the forwarder with the same nonce, gas and gas price.
+func (self *forwarder) Prepare(tx *types.Transaction) (*types.Transaction, error) {
+	if tx.To() == nil {
+		return nil, errors.New("can't forward a contract creation")
+	}
+	data, err := self.args.Pack(*tx.To(), tx.Data())
+	if err != nil {
+		return nil, errors.Wrap(err, "packing the forwarder call")
+	}
+	return types.NewTransaction(tx.Nonce(), self.address, tx.Value(), tx.Gas(), tx.GasPrice(), append(append([]byte{}, self.selector...), data...)), nil
+}
+
+func (self *forwarder) Send(ctx context.Context, tx *types.Transaction) error {
+	return errors.Wrap(self.client.SendTransaction(ctx, tx), "sending the forwarder call")
+}
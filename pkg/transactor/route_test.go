@@ -0,0 +1,68 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package transactor
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+func TestRoutes(t *testing.T) {
+	target := common.HexToAddress("0x00000000000000000000000000000000000000a1")
+	tx := types.NewTransaction(7, target, big.NewInt(0), 300000, big.NewInt(1e9), []byte{0xde, 0xad})
+
+	r, err := NewRoute(RouteConfig{Type: RouteForwarder, Forwarder: "0x00000000000000000000000000000000000000f1"}, nil)
+	testutil.Ok(t, err)
+	wrapped, err := r.Prepare(tx)
+	testutil.Ok(t, err)
+	testutil.Equals(t, common.HexToAddress("0x00000000000000000000000000000000000000f1"), *wrapped.To())
+	testutil.Equals(t, tx.Nonce(), wrapped.Nonce())
+	testutil.Equals(t, tx.GasPrice(), wrapped.GasPrice())
+	testutil.Equals(t, crypto.Keccak256([]byte(defaultForwardMethod))[:4], wrapped.Data()[:4])
+	args, err := r.(*forwarder).args.Unpack(wrapped.Data()[4:])
+	testutil.Ok(t, err)
+	testutil.Equals(t, target, args[0])
+	testutil.Equals(t, tx.Data(), args[1])
+
+	_, err = NewRoute(RouteConfig{Type: RouteForwarder, Forwarder: "0xf1", Method: "forward(bytes)"}, nil)
+	testutil.NotOk(t, err)
+
+	var sent []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		testutil.Ok(t, err)
+		req := struct {
+			ID     json.RawMessage
+			Method string
+			Params []hexutil.Bytes
+		}{}
+		testutil.Ok(t, json.Unmarshal(body, &req))
+		testutil.Equals(t, "eth_sendRawTransaction", req.Method)
+		sent = req.Params[0]
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.ID) + `,"result":"0x0000000000000000000000000000000000000000000000000000000000000000"}`))
+	}))
+	defer srv.Close()
+
+	r, err = NewRoute(RouteConfig{Type: RouteRelay, URL: srv.URL}, nil)
+	testutil.Ok(t, err)
+	key, err := crypto.GenerateKey()
+	testutil.Ok(t, err)
+	signed, err := types.SignTx(tx, types.NewEIP155Signer(big.NewInt(1)), key)
+	testutil.Ok(t, err)
+	testutil.Ok(t, r.Send(context.Background(), signed))
+	raw, err := signed.MarshalBinary()
+	testutil.Ok(t, err)
+	testutil.Equals(t, raw, sent)
+}
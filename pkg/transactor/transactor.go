@@ -6,32 +6,91 @@ package transactor
 import (
 	"context"
 	"math/big"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/errclass"
 	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/format"
 	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/tracing"
 	"github.com/tellor-io/telliot/pkg/tracker/gasPrice"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const ComponentName = "transactor"
 
+// estimatedGas is the gas used by a typical transaction
+// for checking the balance and the budget before sending it.
+const estimatedGas = 200000
+
+// MaxCost is the cost in wei reserved in the budget for a transaction with the gas price.
+func MaxCost(gasPrice *big.Int) *big.Int {
+	return new(big.Int).Mul(gasPrice, big.NewInt(estimatedGas))
+}
+
+// The strategies for the gas price of the mining submissions.
+const (
+	GasStrategyMultiplier = "multiplier"
+	GasStrategyAdaptive   = "adaptive"
+)
+
 type Config struct {
 	LogLevel      string
 	GasMax        uint
 	GasMultiplier int
+	// GasStrategy for the mining submissions, one of multiplier, adaptive.
+	// The other transactions always use the multiplier.
+	GasStrategy string
+	Adaptive    AdaptiveGasConfig
+	Budget      BudgetConfig
+	// Confirmations is the number of blocks after the block of a transaction
+	// before it is final per contract function.
+	// The transactions of the other functions are final when mined.
+	Confirmations map[string]uint64
+	// Routes are the fee optimizing paths for the transactions per network ID.
+	Routes map[string]RouteConfig
+	// UserOp sends the transactions of smart accounts through an ERC-4337 bundler.
+	UserOp UserOpConfig
+}
+
+// AdaptiveGasConfig learns the gas price of the mining submissions
+// from the gas prices that were required to land a slot in the recent challenges.
+type AdaptiveGasConfig struct {
+	// Percentile of the recent required gas prices to bid
+	// e.g. 80 bids the price that landed a slot in 80% of the recent challenges.
+	Percentile float64
+	// Window is how far back the challenges are considered.
+	Window format.Duration
+	// MinSamples is the number of challenges within the window needed
+	// to use the learned price instead of the multiplier.
+	MinSamples int
+}
+
+// GasLearner returns the percentile of the gas prices that were required to land a slot
+// in the challenges within the window and the number of these challenges.
+type GasLearner interface {
+	RequiredGasPrice(percentile float64, window time.Duration, now time.Time) (*big.Int, int)
 }
 
 // Transactor takes care of sending transactions over the blockchain network.
+// The priority of a transaction for the budget is set with WithPriority
+// and its contract function for the gas records and the confirmations with WithFunction.
+// It returns once the transaction is final.
 type Transactor interface {
 	Transact(context.Context, func(*bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, *types.Receipt, error)
+	// Send sends a signed transaction for the contract calls that sign it without sending it
+	// and send it themselves after other checks.
+	Send(context.Context, *types.Transaction) (*types.Transaction, error)
 }
 
 // TransactorDefault implements the Transactor interface.
@@ -41,6 +100,16 @@ type TransactorDefault struct {
 	gasPriceTracker *gasPrice.GasTracker
 	client          contracts.ETHClient
 	account         *ethereum.Account
+	signer          ethereum.Signer
+	budget          *Budget
+	gas             *GasRecorder
+	pool            *Pool
+	learner         GasLearner
+	routes          map[string]*route
+
+	mtx sync.Mutex
+	// sent are the transactions of the contract calls sent with Send.
+	sent map[common.Hash]bool
 }
 
 func New(
@@ -49,11 +118,44 @@ func New(
 	gasPriceTracker *gasPrice.GasTracker,
 	client contracts.ETHClient,
 	account *ethereum.Account,
+	signer ethereum.Signer,
+	budget *Budget,
+	gas *GasRecorder,
+	pool *Pool,
+	learner GasLearner,
 ) (*TransactorDefault, error) {
 	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
 	if err != nil {
 		return nil, errors.Wrap(err, "apply filter logger")
 	}
+	// Enforces that the read-only instances can't send any transactions.
+	if signer == nil || !signer.CanSign(account.Address) {
+		return nil, errors.Wrapf(ethereum.ErrReadOnly, "no key to sign the transactions of:%v", account.Address.Hex())
+	}
+	switch cfg.GasStrategy {
+	case "", GasStrategyMultiplier:
+	case GasStrategyAdaptive:
+		if cfg.Adaptive.Percentile <= 0 || cfg.Adaptive.Percentile > 100 {
+			return nil, errors.Errorf("invalid adaptive gas percentile:%v", cfg.Adaptive.Percentile)
+		}
+		if cfg.Adaptive.Window.Duration <= 0 {
+			return nil, errors.Errorf("invalid adaptive gas window:%v", cfg.Adaptive.Window)
+		}
+	default:
+		return nil, errors.Errorf("unknown gas strategy:%v", cfg.GasStrategy)
+	}
+
+	routes := make(map[string]*route)
+	for netID, rCfg := range cfg.Routes {
+		r, err := NewRoute(rCfg, client)
+		if err != nil {
+			return nil, errors.Wrapf(err, "creating the route of network:%v", netID)
+		}
+		routes[netID] = &route{Route: r, cfg: rCfg, functions: make(map[string]bool)}
+		for _, fn := range rCfg.Functions {
+			routes[netID].functions[fn] = true
+		}
+	}
 
 	return &TransactorDefault{
 		cfg:             cfg,
@@ -61,6 +163,13 @@ func New(
 		gasPriceTracker: gasPriceTracker,
 		client:          client,
 		account:         account,
+		signer:          signer,
+		budget:          budget,
+		gas:             gas,
+		pool:            pool,
+		learner:         learner,
+		routes:          routes,
+		sent:            make(map[common.Hash]bool),
 	}, nil
 }
 
@@ -73,14 +182,23 @@ func (self *TransactorDefault) Transact(ctx context.Context, contractCall func(*
 	// Use the same nonce in case there is a stuck transaction so thaself iself submits with the currenself nonce buself higher gas price.
 	IntNonce := int64(nonce)
 
+	_, gasSpan := tracing.Start(ctx, "transactor.gasPrice")
 	_gasPrice, err := self.gasPriceTracker.Query(ctx)
 	if err != nil {
+		tracing.Error(gasSpan, err)
+		gasSpan.End()
 		return nil, nil, errors.Wrap(err, "getting data from the db")
 	}
 	gasPrice := big.NewInt(int64(_gasPrice))
+	gasSpan.SetAttributes(attribute.Int64("gasPrice", gasPrice.Int64()))
+	gasSpan.End()
 
-	mul := self.cfg.GasMultiplier
-	if mul > 0 {
+	if learned, ok := self.learnedGasPrice(ctx); ok {
+		// Never bid below the current gas price.
+		if learned.Cmp(gasPrice) > 0 {
+			gasPrice = learned
+		}
+	} else if mul := self.cfg.GasMultiplier; mul > 0 {
 		level.Info(self.logger).Log("msg", "settings gas price multiplier", "value", mul)
 		gasPrice = gasPrice.Mul(gasPrice, big.NewInt(int64(mul)))
 	}
@@ -94,7 +212,7 @@ func (self *TransactorDefault) Transact(ctx context.Context, contractCall func(*
 		}
 
 		cost := big.NewInt(1)
-		cost = cost.Mul(gasPrice, big.NewInt(200000))
+		cost = cost.Mul(gasPrice, big.NewInt(estimatedGas))
 		if balance.Cmp(cost) < 0 {
 			finalError = errors.Errorf("insufficient funds to send transaction: %v < %v", balance, cost)
 			continue
@@ -104,10 +222,37 @@ func (self *TransactorDefault) Transact(ctx context.Context, contractCall func(*
 		if err != nil {
 			return nil, nil, errors.Wrap(err, "getting network id")
 		}
-		auth, err := bind.NewKeyedTransactorWithChainID(self.account.PrivateKey, netID)
-		if err != nil {
-			return nil, nil, errors.Wrap(err, "creating transactor")
+		// The budget is reserved when the contract call signs the transaction
+		// so that it covers the final gas price e.g. after outbidding the pending submissions.
+		var (
+			release       = func(*big.Int) {}
+			maxCost       *big.Int
+			reservedPrice *big.Int
+		)
+		auth := &bind.TransactOpts{
+			From: self.account.Address,
+			Signer: func(from common.Address, tx *types.Transaction) (*types.Transaction, error) {
+				maxCost = MaxCost(tx.GasPrice())
+				reservedPrice = tx.GasPrice()
+				if self.budget != nil {
+					// Signed again by the contract call so release the previous reservation.
+					release(nil)
+					release = func(*big.Int) {}
+					r, err := self.budget.Reserve(priority(ctx), maxCost, time.Now())
+					if err != nil {
+						return nil, err
+					}
+					release = r
+				}
+				return self.signer.SignTx(from, tx, netID)
+			},
+		}
+		route := self.routes[netID.String()]
+		if route != nil && !route.applies(function(ctx)) {
+			route = nil
 		}
+		// The routed transactions are only signed by the contract call and sent by the route.
+		auth.NoSend = route != nil
 		auth.Nonce = big.NewInt(IntNonce)
 		auth.Value = big.NewInt(0)      // in weiF
 		auth.GasLimit = uint64(3000000) // in units
@@ -122,30 +267,33 @@ func (self *TransactorDefault) Transact(ctx context.Context, contractCall func(*
 			// First time, try base gas price.
 			auth.GasPrice = gasPrice
 		}
-		max := self.cfg.GasMax
-		var maxGasPrice *big.Int
-		gasPrice1 := big.NewInt(params.GWei)
-		if max > 0 {
-			maxGasPrice = gasPrice1.Mul(gasPrice1, big.NewInt(int64(max)))
-		} else {
-			maxGasPrice = gasPrice1.Mul(gasPrice1, big.NewInt(int64(100)))
-		}
-
+		maxGasPrice := self.maxGasPrice()
 		if auth.GasPrice.Cmp(maxGasPrice) > 0 {
 			level.Info(self.logger).Log("msg", "gas price too high, will default to the max price", "current", auth.GasPrice, "defaultMax", maxGasPrice)
 			auth.GasPrice = maxGasPrice
 		}
 
 		tx, err := contractCall(auth)
+		if err == nil && route != nil && !self.sentByCall(tx) {
+			var routed bool
+			tx, routed, err = self.sendRouted(ctx, route, netID, tx)
+			if !routed {
+				route = nil
+			}
+		}
 		if err != nil {
-			if strings.Contains(strings.ToLower(err.Error()), "nonce too low") { // Can't use error type matching because of the way the eth client is implemented.
+			release(nil)
+			if errors.Is(err, ErrBudgetExceeded) {
+				return nil, nil, err
+			}
+			switch errclass.Observe(ComponentName, err) {
+			case errclass.Nonce:
 				IntNonce = IntNonce + 1
 				level.Warn(self.logger).Log("msg", "last transaction has been confirmed so will increase the nonce and resend the transaction.")
-
-			} else if strings.Contains(strings.ToLower(err.Error()), "replacement transaction underpriced") { // Can't use error type matching because of the way the eth client is implemented.
+			case errclass.Underpriced:
 				level.Warn(self.logger).Log("msg", "last transaction is stuck so will increase the gas price and try to resend")
 				finalError = err
-			} else {
+			default:
 				finalError = errors.Wrap(err, "contract call SubmitMiningSolution")
 			}
 
@@ -159,11 +307,167 @@ func (self *TransactorDefault) Transact(ctx context.Context, contractCall func(*
 			}
 		}
 
-		receipt, err := bind.WaitMined(ctx, self.client, tx)
+		if reservedPrice == nil {
+			reservedPrice = tx.GasPrice()
+		}
+
+		_, waitSpan := tracing.Start(ctx, "transactor.confirmation",
+			attribute.String("txHash", tx.Hash().String()),
+			attribute.Int("attempt", i),
+		)
+		// Tracks the replacements sent with the pool until any of them is mined.
+		pending := &inFlight{
+			transactor:    self,
+			function:      function(ctx),
+			sent:          time.Now(),
+			txs:           []*types.Transaction{tx},
+			route:         route,
+			priority:      priority(ctx),
+			reservedPrice: reservedPrice,
+		}
+		if self.pool != nil {
+			self.pool.add(pending)
+		}
+		mined, receipt, err := pending.waitMined(ctx)
+		if self.pool != nil {
+			self.pool.remove(pending)
+		}
 		if err != nil {
+			// The transaction can still be mined so keep its max cost.
+			release(maxCost)
+			pending.settle(false)
+			tracing.Error(waitSpan, err)
+			waitSpan.End()
 			return nil, nil, errors.Wrapf(err, "transaction result tx:%v", tx.Hash())
 		}
-		return tx, receipt, nil
+		canceled := pending.isCancel(mined)
+		fn := function(ctx)
+		if canceled {
+			fn = FunctionCancel
+		}
+		release(new(big.Int).Mul(mined.GasPrice(), new(big.Int).SetUint64(receipt.GasUsed)))
+		pending.settle(true)
+		if self.gas != nil {
+			if err := self.gas.Record(ctx, GasRecord{
+				Function: fn,
+				Account:  self.account.Address.String(),
+				GasUsed:  receipt.GasUsed,
+				GasPrice: mined.GasPrice(),
+				Time:     time.Now(),
+			}); err != nil {
+				level.Error(self.logger).Log("msg", "recording the transaction gas", "err", err)
+			}
+		}
+		waitSpan.SetAttributes(attribute.Int64("gasUsed", int64(receipt.GasUsed)))
+		waitSpan.End()
+
+		if confirmations := self.cfg.Confirmations[fn]; confirmations > 0 {
+			_, finalSpan := tracing.Start(ctx, "transactor.finalization",
+				attribute.String("txHash", mined.Hash().String()),
+				attribute.Int64("confirmations", int64(confirmations)),
+			)
+			receipt, err = WaitFinalized(ctx, self.client, mined.Hash(), confirmations)
+			if err != nil {
+				tracing.Error(finalSpan, err)
+				finalSpan.End()
+				return nil, nil, errors.Wrapf(err, "waiting for the confirmations of tx:%v", mined.Hash())
+			}
+			finalSpan.End()
+		}
+		if canceled {
+			return nil, nil, errors.Wrapf(ErrCanceled, "tx:%v replaced by:%v", tx.Hash(), mined.Hash())
+		}
+		return mined, receipt, nil
 	}
 	return nil, nil, errors.Wrapf(finalError, "submit tx after 5 attempts")
 }
+
+// maxGasPrice is the max gas price of the transactions and their replacements.
+func (self *TransactorDefault) maxGasPrice() *big.Int {
+	return MaxGasPrice(self.cfg)
+}
+
+// MaxGasPrice returns the max gas price of the transactions in wei, 100 gwei without GasMax.
+func MaxGasPrice(cfg Config) *big.Int {
+	max := int64(cfg.GasMax)
+	if max <= 0 {
+		max = 100
+	}
+	return new(big.Int).Mul(big.NewInt(params.GWei), big.NewInt(max))
+}
+
+// Send sends a signed transaction through the route of its network and the function in the context
+// or through the node and returns the transaction that was sent,
+// which is a different one for the forwarder routes.
+func (self *TransactorDefault) Send(ctx context.Context, tx *types.Transaction) (*types.Transaction, error) {
+	netID, err := self.client.NetworkID(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting network id")
+	}
+	route := self.routes[netID.String()]
+	if route == nil || !route.applies(function(ctx)) {
+		return tx, self.client.SendTransaction(ctx, tx)
+	}
+	sent, _, err := self.sendRouted(ctx, route, netID, tx)
+	if err != nil {
+		return nil, err
+	}
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	self.sent[sent.Hash()] = true
+	return sent, nil
+}
+
+// sentByCall returns whether the contract call sent the transaction with Send.
+func (self *TransactorDefault) sentByCall(tx *types.Transaction) bool {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	sent := self.sent[tx.Hash()]
+	delete(self.sent, tx.Hash())
+	return sent
+}
+
+// sendRouted sends the signed transaction of the contract call through the route
+// and returns the transaction that was sent and false when it was sent through the node instead.
+func (self *TransactorDefault) sendRouted(ctx context.Context, route *route, netID *big.Int, tx *types.Transaction) (*types.Transaction, bool, error) {
+	prepared, err := route.Prepare(tx)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "preparing the routed transaction")
+	}
+	if prepared != tx {
+		if prepared, err = self.signer.SignTx(self.account.Address, prepared, netID); err != nil {
+			return nil, false, errors.Wrap(err, "signing the routed transaction")
+		}
+	}
+	err = route.Send(ctx, prepared)
+	if err == nil {
+		routedCount.With(prometheus.Labels{"route": route.cfg.Type, "result": "sent"}).Inc()
+		level.Info(self.logger).Log("msg", "sent the transaction through the route", "route", route.cfg.Type, "tx", prepared.Hash())
+		return prepared, true, nil
+	}
+	routedCount.With(prometheus.Labels{"route": route.cfg.Type, "result": "failed"}).Inc()
+	if !route.cfg.Fallback || route.cfg.Type == RouteForwarder {
+		return nil, false, err
+	}
+	level.Warn(self.logger).Log("msg", "route failed, sending through the node", "route", route.cfg.Type, "err", err)
+	if err := self.client.SendTransaction(ctx, tx); err != nil {
+		return nil, false, errors.Wrap(err, "sending through the node")
+	}
+	return tx, false, nil
+}
+
+// learnedGasPrice returns the gas price learned from the recent challenges
+// for the mining submissions with the adaptive strategy.
+// It returns false until there are enough challenges within the window.
+func (self *TransactorDefault) learnedGasPrice(ctx context.Context) (*big.Int, bool) {
+	if self.cfg.GasStrategy != GasStrategyAdaptive || self.learner == nil || function(ctx) != FunctionSubmitMiningSolution {
+		return nil, false
+	}
+	gasPrice, samples := self.learner.RequiredGasPrice(self.cfg.Adaptive.Percentile, self.cfg.Adaptive.Window.Duration, time.Now())
+	if samples < self.cfg.Adaptive.MinSamples || gasPrice == nil {
+		level.Debug(self.logger).Log("msg", "not enough challenges to learn the gas price, using the multiplier", "samples", samples, "min", self.cfg.Adaptive.MinSamples)
+		return nil, false
+	}
+	level.Info(self.logger).Log("msg", "using the learned gas price", "gasPrice", gasPrice, "percentile", self.cfg.Adaptive.Percentile, "samples", samples)
+	return gasPrice, true
+}
@@ -0,0 +1,35 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package transactor
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// TestNewReadOnly ensures that a transactor can't be created
+// for an account without a key in the signer.
+func TestNewReadOnly(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	testutil.Ok(t, err)
+	account := &ethereum.Account{Address: crypto.PubkeyToAddress(key.PublicKey), PrivateKey: key}
+	observer := &ethereum.Account{Address: common.HexToAddress("0x1")}
+	signer := ethereum.NewKeySigner(log.NewNopLogger(), []*ethereum.Account{account, observer})
+	cfg := Config{LogLevel: "info"}
+
+	_, err = New(log.NewNopLogger(), cfg, nil, nil, account, signer, nil, nil, nil, nil)
+	testutil.Ok(t, err)
+
+	_, err = New(log.NewNopLogger(), cfg, nil, nil, observer, signer, nil, nil, nil, nil)
+	testutil.Assert(t, errors.Is(err, ethereum.ErrReadOnly), "creating a transactor for a read-only account should fail")
+
+	_, err = New(log.NewNopLogger(), cfg, nil, nil, account, nil, nil, nil, nil, nil)
+	testutil.Assert(t, errors.Is(err, ethereum.ErrReadOnly), "creating a transactor without a signer should fail")
+}
@@ -0,0 +1,462 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package transactor
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"sync"
+	"time"
+
+	goeth "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+)
+
+// userOpPollInterval is how often the bundler is asked whether it included an operation.
+const userOpPollInterval = 2 * time.Second
+
+// dummySignature has the length of an ECDSA signature for the gas estimates
+// of the operations before they are signed.
+var dummySignature = append(common.FromHex("0xfffffffffffffffffffffffffffffff0000000000000000000000000000000007aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"), 0x1c)
+
+var userOpCount = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "telliot",
+	Subsystem: ComponentName,
+	Name:      "user_operations_total",
+	Help:      "The total number of user operations sent to the bundler by result",
+}, []string{"result"})
+
+// UserOpConfig sends the transactions of the accounts as ERC-4337 user operations
+// of their smart accounts through a bundler. It is experimental.
+type UserOpConfig struct {
+	Enabled bool
+	// Bundler is the RPC of the ERC-4337 bundler.
+	Bundler string
+	// EntryPoint is the address of the entry point contract of the bundler, only v0.6 is supported.
+	EntryPoint string
+	// Accounts are the deployed smart accounts by the address of their owner
+	// which signs the operations. The other accounts send transactions as usual.
+	// The smart accounts need the execute(address,uint256,bytes) and executeBatch(address[],bytes[]) methods
+	// and are the reporters on the oracle.
+	Accounts map[string]string
+	// Paymaster is the hex paymasterAndData of a paymaster that sponsors the gas.
+	// The smart accounts pay the gas when empty.
+	Paymaster string
+	// Timeout is how long to wait for the bundler to include an operation.
+	Timeout format.Duration
+}
+
+// SmartAccount returns the smart account of the owner.
+func (self UserOpConfig) SmartAccount(owner common.Address) (string, bool) {
+	for o, account := range self.Accounts {
+		if common.HexToAddress(o) == owner {
+			return account, true
+		}
+	}
+	return "", false
+}
+
+// Batcher sends the transactions of several contract calls at once.
+type Batcher interface {
+	TransactBatch(context.Context, ...func(*bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, *types.Receipt, error)
+}
+
+// UserOperation is an ERC-4337 v0.6 user operation.
+type UserOperation struct {
+	Sender               common.Address `json:"sender"`
+	Nonce                *hexutil.Big   `json:"nonce"`
+	InitCode             hexutil.Bytes  `json:"initCode"`
+	CallData             hexutil.Bytes  `json:"callData"`
+	CallGasLimit         *hexutil.Big   `json:"callGasLimit"`
+	VerificationGasLimit *hexutil.Big   `json:"verificationGasLimit"`
+	PreVerificationGas   *hexutil.Big   `json:"preVerificationGas"`
+	MaxFeePerGas         *hexutil.Big   `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big   `json:"maxPriorityFeePerGas"`
+	PaymasterAndData     hexutil.Bytes  `json:"paymasterAndData"`
+	Signature            hexutil.Bytes  `json:"signature"`
+}
+
+// Hash returns the hash of the operation that the smart account verifies the signature of.
+func (self *UserOperation) Hash(entryPoint common.Address, chainID *big.Int) (common.Hash, error) {
+	packed, err := userOpArgs.Pack(
+		self.Sender,
+		(*big.Int)(self.Nonce),
+		crypto.Keccak256Hash(self.InitCode),
+		crypto.Keccak256Hash(self.CallData),
+		(*big.Int)(self.CallGasLimit),
+		(*big.Int)(self.VerificationGasLimit),
+		(*big.Int)(self.PreVerificationGas),
+		(*big.Int)(self.MaxFeePerGas),
+		(*big.Int)(self.MaxPriorityFeePerGas),
+		crypto.Keccak256Hash(self.PaymasterAndData),
+	)
+	if err != nil {
+		return common.Hash{}, errors.Wrap(err, "packing the operation")
+	}
+	encoded, err := userOpHashArgs.Pack(crypto.Keccak256Hash(packed), entryPoint, chainID)
+	if err != nil {
+		return common.Hash{}, errors.Wrap(err, "packing the operation hash")
+	}
+	return crypto.Keccak256Hash(encoded), nil
+}
+
+var (
+	userOpArgs     = abiArgs("address", "uint256", "bytes32", "bytes32", "uint256", "uint256", "uint256", "uint256", "uint256", "bytes32")
+	userOpHashArgs = abiArgs("bytes32", "address", "uint256")
+	executeArgs    = abiArgs("address", "uint256", "bytes")
+	batchArgs      = abiArgs("address[]", "bytes[]")
+	getNonceArgs   = abiArgs("address", "uint192")
+
+	executeSelector  = crypto.Keccak256([]byte("execute(address,uint256,bytes)"))[:4]
+	batchSelector    = crypto.Keccak256([]byte("executeBatch(address[],bytes[])"))[:4]
+	getNonceSelector = crypto.Keccak256([]byte("getNonce(address,uint192)"))[:4]
+)
+
+func abiArgs(types ...string) abi.Arguments {
+	var args abi.Arguments
+	for _, t := range types {
+		typ, err := abi.NewType(t, "", nil)
+		if err != nil {
+			panic(err)
+		}
+		args = append(args, abi.Argument{Type: typ})
+	}
+	return args
+}
+
+// executeCallData returns the calldata of the smart account that executes the calls.
+func executeCallData(calls []*types.Transaction) ([]byte, error) {
+	for _, call := range calls {
+		if call.To() == nil {
+			return nil, errors.New("can't execute a contract creation")
+		}
+	}
+	if len(calls) == 1 {
+		data, err := executeArgs.Pack(*calls[0].To(), calls[0].Value(), calls[0].Data())
+		if err != nil {
+			return nil, errors.Wrap(err, "packing the execute call")
+		}
+		return append(append([]byte{}, executeSelector...), data...), nil
+	}
+	var (
+		targets []common.Address
+		datas   [][]byte
+	)
+	for _, call := range calls {
+		// The batches of the smart accounts don't send value.
+		if call.Value().Sign() != 0 {
+			return nil, errors.New("can't send value in a batch")
+		}
+		targets = append(targets, *call.To())
+		datas = append(datas, call.Data())
+	}
+	data, err := batchArgs.Pack(targets, datas)
+	if err != nil {
+		return nil, errors.Wrap(err, "packing the batch call")
+	}
+	return append(append([]byte{}, batchSelector...), data...), nil
+}
+
+// UserOpTransactor implements the Transactor interface with user operations of a smart account
+// so that a paymaster can sponsor the gas and several calls can be sent in one operation.
+// The transactions it returns are the bundles which included the operations.
+type UserOpTransactor struct {
+	cfg        Config
+	logger     log.Logger
+	client     contracts.ETHClient
+	bundler    *rpc.Client
+	owner      *ethereum.Account
+	account    common.Address
+	signer     ethereum.HashSigner
+	entryPoint common.Address
+	paymaster  []byte
+
+	mtx sync.Mutex
+	// sent are the operations of the contract calls sent with Send
+	// by the hash of their call.
+	sent map[common.Hash]common.Hash
+}
+
+// NewUserOp creates a transactor for the smart account of the owner account.
+func NewUserOp(
+	logger log.Logger,
+	cfg Config,
+	client contracts.ETHClient,
+	owner *ethereum.Account,
+	signer ethereum.Signer,
+) (*UserOpTransactor, error) {
+	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	hashSigner, ok := signer.(ethereum.HashSigner)
+	if signer == nil || !ok || !signer.CanSign(owner.Address) {
+		return nil, errors.Wrapf(ethereum.ErrReadOnly, "no key to sign the user operations of:%v", owner.Address.Hex())
+	}
+	account, ok := cfg.UserOp.SmartAccount(owner.Address)
+	if !ok || !common.IsHexAddress(account) {
+		return nil, errors.Errorf("invalid smart account:%v of:%v", account, owner.Address.Hex())
+	}
+	if !common.IsHexAddress(cfg.UserOp.EntryPoint) {
+		return nil, errors.Errorf("invalid entry point address:%v", cfg.UserOp.EntryPoint)
+	}
+	paymaster, err := hexutil.Decode(cfg.UserOp.Paymaster)
+	if cfg.UserOp.Paymaster != "" && err != nil {
+		return nil, errors.Wrap(err, "decoding the paymaster data")
+	}
+	if cfg.UserOp.Timeout.Duration <= 0 {
+		return nil, errors.Errorf("invalid user operation timeout:%v", cfg.UserOp.Timeout)
+	}
+	// The HTTP clients connect with the first request.
+	bundler, err := rpc.Dial(cfg.UserOp.Bundler)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating bundler client")
+	}
+	return &UserOpTransactor{
+		cfg:        cfg,
+		logger:     log.With(logger, "component", ComponentName, "smartAccount", account),
+		client:     client,
+		bundler:    bundler,
+		owner:      owner,
+		account:    common.HexToAddress(account),
+		signer:     hashSigner,
+		entryPoint: common.HexToAddress(cfg.UserOp.EntryPoint),
+		paymaster:  paymaster,
+		sent:       make(map[common.Hash]common.Hash),
+	}, nil
+}
+
+func (self *UserOpTransactor) Transact(ctx context.Context, contractCall func(*bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, *types.Receipt, error) {
+	return self.TransactBatch(ctx, contractCall)
+}
+
+// TransactBatch sends the calls in a single operation, e.g. a submission with its tips.
+// The transactions of the calls are only built with the smart account as the sender and never signed.
+func (self *UserOpTransactor) TransactBatch(ctx context.Context, contractCalls ...func(*bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, *types.Receipt, error) {
+	var (
+		calls  []*types.Transaction
+		opHash common.Hash
+	)
+	for _, contractCall := range contractCalls {
+		call, err := contractCall(&bind.TransactOpts{
+			From: self.account,
+			Signer: func(from common.Address, tx *types.Transaction) (*types.Transaction, error) {
+				return tx, nil
+			},
+			// Set so that the binding doesn't query the node for the account.
+			Nonce:    big.NewInt(0),
+			GasPrice: big.NewInt(0),
+			GasLimit: uint64(3000000),
+			Value:    big.NewInt(0),
+			NoSend:   true,
+		})
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "contract call")
+		}
+		if hash, ok := self.sentByCall(call); ok {
+			if len(contractCalls) > 1 {
+				return nil, nil, errors.New("the calls of a batch can't send their operation")
+			}
+			opHash = hash
+			continue
+		}
+		calls = append(calls, call)
+	}
+	if len(calls) > 0 {
+		var err error
+		if opHash, err = self.sendOp(ctx, calls); err != nil {
+			return nil, nil, err
+		}
+	}
+	return self.waitOp(ctx, opHash)
+}
+
+// Send sends the operation of the call of a contract call which is then returned by Transact.
+// It returns the call as the transaction of the operation is known once it is included.
+func (self *UserOpTransactor) Send(ctx context.Context, call *types.Transaction) (*types.Transaction, error) {
+	opHash, err := self.sendOp(ctx, []*types.Transaction{call})
+	if err != nil {
+		return nil, err
+	}
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	self.sent[call.Hash()] = opHash
+	return call, nil
+}
+
+func (self *UserOpTransactor) sentByCall(call *types.Transaction) (common.Hash, bool) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	opHash, ok := self.sent[call.Hash()]
+	delete(self.sent, call.Hash())
+	return opHash, ok
+}
+
+// sendOp sends an operation that executes the calls with the gas estimated by the bundler.
+func (self *UserOpTransactor) sendOp(ctx context.Context, calls []*types.Transaction) (common.Hash, error) {
+	callData, err := executeCallData(calls)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	nonce, err := self.nonce(ctx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	gasPrice, err := self.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return common.Hash{}, errors.Wrap(err, "getting the gas price")
+	}
+	op := &UserOperation{
+		Sender:               self.account,
+		Nonce:                (*hexutil.Big)(nonce),
+		InitCode:             []byte{},
+		CallData:             callData,
+		CallGasLimit:         (*hexutil.Big)(big.NewInt(0)),
+		VerificationGasLimit: (*hexutil.Big)(big.NewInt(0)),
+		PreVerificationGas:   (*hexutil.Big)(big.NewInt(0)),
+		MaxFeePerGas:         (*hexutil.Big)(gasPrice),
+		MaxPriorityFeePerGas: (*hexutil.Big)(gasPrice),
+		PaymasterAndData:     self.paymaster,
+		Signature:            dummySignature,
+	}
+	if op.PaymasterAndData == nil {
+		op.PaymasterAndData = []byte{}
+	}
+
+	estimate := struct {
+		CallGasLimit         *hexutil.Big `json:"callGasLimit"`
+		VerificationGasLimit *hexutil.Big `json:"verificationGasLimit"`
+		PreVerificationGas   *hexutil.Big `json:"preVerificationGas"`
+	}{}
+	if err := self.bundler.CallContext(ctx, &estimate, "eth_estimateUserOperationGas", op, self.entryPoint); err != nil {
+		userOpCount.With(prometheus.Labels{"result": "failed"}).Inc()
+		return common.Hash{}, errors.Wrap(err, "estimating the operation gas")
+	}
+	if estimate.CallGasLimit == nil || estimate.VerificationGasLimit == nil || estimate.PreVerificationGas == nil {
+		return common.Hash{}, errors.New("incomplete operation gas estimate")
+	}
+	op.CallGasLimit = estimate.CallGasLimit
+	op.VerificationGasLimit = estimate.VerificationGasLimit
+	op.PreVerificationGas = estimate.PreVerificationGas
+
+	netID, err := self.client.NetworkID(ctx)
+	if err != nil {
+		return common.Hash{}, errors.Wrap(err, "getting network id")
+	}
+	hash, err := op.Hash(self.entryPoint, netID)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	// The smart accounts verify the signature of the hash as a signed message.
+	sig, err := self.signer.SignHash(self.owner.Address, accounts.TextHash(hash.Bytes()))
+	if err != nil {
+		return common.Hash{}, errors.Wrap(err, "signing the operation")
+	}
+	sig[64] += 27
+	op.Signature = sig
+
+	var opHash common.Hash
+	if err := self.bundler.CallContext(ctx, &opHash, "eth_sendUserOperation", op, self.entryPoint); err != nil {
+		userOpCount.With(prometheus.Labels{"result": "failed"}).Inc()
+		return common.Hash{}, errors.Wrap(err, "sending the operation to the bundler")
+	}
+	userOpCount.With(prometheus.Labels{"result": "sent"}).Inc()
+	level.Info(self.logger).Log("msg", "sent user operation", "opHash", opHash, "calls", len(calls), "sponsored", len(self.paymaster) > 0)
+	return opHash, nil
+}
+
+// nonce returns the nonce of the smart account from the entry point.
+func (self *UserOpTransactor) nonce(ctx context.Context) (*big.Int, error) {
+	data, err := getNonceArgs.Pack(self.account, big.NewInt(0))
+	if err != nil {
+		return nil, errors.Wrap(err, "packing the nonce call")
+	}
+	out, err := self.client.CallContract(ctx, goeth.CallMsg{
+		To:   &self.entryPoint,
+		Data: append(append([]byte{}, getNonceSelector...), data...),
+	}, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting the smart account nonce")
+	}
+	return new(big.Int).SetBytes(out), nil
+}
+
+// waitOp waits for the bundler to include the operation and returns its bundle transaction and receipt.
+// The receipt has a failed status when the operation reverted in a successful bundle.
+func (self *UserOpTransactor) waitOp(ctx context.Context, opHash common.Hash) (*types.Transaction, *types.Receipt, error) {
+	ctx, cncl := context.WithTimeout(ctx, self.cfg.UserOp.Timeout.Duration)
+	defer cncl()
+	ticker := time.NewTicker(userOpPollInterval)
+	defer ticker.Stop()
+
+	var result *struct {
+		Success bool   `json:"success"`
+		Reason  string `json:"reason"`
+		Receipt struct {
+			TransactionHash common.Hash `json:"transactionHash"`
+		} `json:"receipt"`
+	}
+	for {
+		var raw json.RawMessage
+		err := self.bundler.CallContext(ctx, &raw, "eth_getUserOperationReceipt", opHash)
+		if err == nil && len(raw) > 0 && string(raw) != "null" {
+			if err := json.Unmarshal(raw, &result); err != nil {
+				return nil, nil, errors.Wrap(err, "decoding the operation receipt")
+			}
+			break
+		}
+		if err != nil {
+			level.Warn(self.logger).Log("msg", "getting the operation receipt", "opHash", opHash, "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			userOpCount.With(prometheus.Labels{"result": "timeout"}).Inc()
+			return nil, nil, errors.Wrapf(ctx.Err(), "waiting for the bundler to include operation:%v", opHash)
+		case <-ticker.C:
+		}
+	}
+
+	txHash := result.Receipt.TransactionHash
+	tx, _, err := self.client.TransactionByHash(ctx, txHash)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "getting the bundle tx:%v", txHash)
+	}
+	receipt, err := self.client.TransactionReceipt(ctx, txHash)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "getting the receipt of the bundle tx:%v", txHash)
+	}
+	fn := function(ctx)
+	if confirmations := self.cfg.Confirmations[fn]; confirmations > 0 {
+		if receipt, err = WaitFinalized(ctx, self.client, txHash, confirmations); err != nil {
+			return nil, nil, errors.Wrapf(err, "waiting for the confirmations of tx:%v", txHash)
+		}
+	}
+	if !result.Success {
+		userOpCount.With(prometheus.Labels{"result": "reverted"}).Inc()
+		level.Error(self.logger).Log("msg", "user operation reverted", "opHash", opHash, "tx", txHash, "reason", result.Reason)
+		failed := *receipt
+		failed.Status = types.ReceiptStatusFailed
+		receipt = &failed
+	} else {
+		userOpCount.With(prometheus.Labels{"result": "included"}).Inc()
+	}
+	return tx, receipt, nil
+}
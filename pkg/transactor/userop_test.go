@@ -0,0 +1,168 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package transactor
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/go-kit/kit/log"
+	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/testutil"
+	"github.com/tellor-io/telliot/pkg/testutil/chain"
+)
+
+// TestUserOp sends the submissions of a smart account through a bundler
+// which checks the signature of the owner and executes the calls of the operations
+// from the smart account, played by a reporter of the chain.
+func TestUserOp(t *testing.T) {
+	c, err := chain.New(2)
+	testutil.Ok(t, err)
+	defer c.Close()
+	owner, smart := c.Accounts[0], c.Accounts[1]
+	smartKey := smart.PrivateKey
+	entryPoint := common.HexToAddress("0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789")
+	chainID, err := c.NetworkID(context.Background())
+	testutil.Ok(t, err)
+
+	var (
+		ops     []UserOperation
+		reverts bool
+		sentTxs = make(map[common.Hash]common.Hash)
+	)
+	bundler := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		testutil.Ok(t, err)
+		req := struct {
+			ID     json.RawMessage
+			Method string
+			Params []json.RawMessage
+		}{}
+		testutil.Ok(t, json.Unmarshal(body, &req))
+		reply := func(result string) {
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.ID) + `,"result":` + result + `}`))
+		}
+		switch req.Method {
+		case "eth_estimateUserOperationGas":
+			reply(`{"callGasLimit":"0x493e0","verificationGasLimit":"0x186a0","preVerificationGas":"0xc350"}`)
+		case "eth_sendUserOperation":
+			var op UserOperation
+			testutil.Ok(t, json.Unmarshal(req.Params[0], &op))
+			hash, err := op.Hash(entryPoint, chainID)
+			testutil.Ok(t, err)
+			sig := append([]byte{}, op.Signature...)
+			sig[64] -= 27
+			pub, err := crypto.SigToPub(accounts.TextHash(hash.Bytes()), sig)
+			testutil.Ok(t, err)
+			testutil.Equals(t, owner.Address, crypto.PubkeyToAddress(*pub))
+			testutil.Equals(t, smart.Address, op.Sender)
+			testutil.Equals(t, []byte{0xaa}, []byte(op.PaymasterAndData))
+			ops = append(ops, op)
+
+			// Executes the calls from the smart account as the entry point would.
+			var calls [][]interface{}
+			switch {
+			case string(op.CallData[:4]) == string(executeSelector):
+				args, err := executeArgs.Unpack(op.CallData[4:])
+				testutil.Ok(t, err)
+				calls = append(calls, []interface{}{args[0], args[2]})
+			case string(op.CallData[:4]) == string(batchSelector):
+				args, err := batchArgs.Unpack(op.CallData[4:])
+				testutil.Ok(t, err)
+				for i, to := range args[0].([]common.Address) {
+					calls = append(calls, []interface{}{to, args[1].([][]byte)[i]})
+				}
+			default:
+				t.Fatalf("unknown smart account call:%x", op.CallData[:4])
+			}
+			var last common.Hash
+			for _, call := range calls {
+				nonce, err := c.NonceAt(context.Background(), smart.Address)
+				testutil.Ok(t, err)
+				to := call[0].(common.Address)
+				tx, err := types.SignTx(types.NewTransaction(nonce, to, big.NewInt(0), 300000, big.NewInt(1e9), call[1].([]byte)), types.NewEIP155Signer(chainID), smartKey)
+				testutil.Ok(t, err)
+				testutil.Ok(t, c.SendTransaction(context.Background(), tx))
+				last = tx.Hash()
+			}
+			opHash := crypto.Keccak256Hash(hash.Bytes(), []byte("op"))
+			sentTxs[opHash] = last
+			reply(`"` + opHash.Hex() + `"`)
+		case "eth_getUserOperationReceipt":
+			var opHash common.Hash
+			testutil.Ok(t, json.Unmarshal(req.Params[0], &opHash))
+			reply(`{"success":` + strconv.FormatBool(!reverts) + `,"receipt":{"transactionHash":"` + sentTxs[opHash].Hex() + `"}}`)
+		default:
+			t.Fatalf("unexpected bundler method:%v", req.Method)
+		}
+	}))
+	defer bundler.Close()
+
+	signer := ethereum.NewKeySigner(log.NewNopLogger(), []*ethereum.Account{owner})
+	cfg := Config{
+		LogLevel: "info",
+		UserOp: UserOpConfig{
+			Enabled:    true,
+			Bundler:    bundler.URL,
+			EntryPoint: entryPoint.Hex(),
+			Accounts:   map[string]string{owner.Address.Hex(): smart.Address.Hex()},
+			Paymaster:  "0xaa",
+			Timeout:    format.Duration{Duration: time.Minute},
+		},
+	}
+	tr, err := NewUserOp(log.NewNopLogger(), cfg, c, owner, signer)
+	testutil.Ok(t, err)
+
+	submit := func(id int64, val int64) func(*bind.TransactOpts) (*types.Transaction, error) {
+		return func(auth *bind.TransactOpts) (*types.Transaction, error) {
+			testutil.Equals(t, smart.Address, auth.From)
+			return c.TellorAccess.SubmitValue(auth, big.NewInt(id), big.NewInt(val))
+		}
+	}
+	ctx := context.Background()
+
+	tx, receipt, err := tr.Transact(ctx, submit(1, 100))
+	testutil.Ok(t, err)
+	testutil.Equals(t, types.ReceiptStatusSuccessful, receipt.Status)
+	testutil.Equals(t, tx.Hash(), receipt.TxHash)
+	testutil.Equals(t, executeSelector, []byte(ops[0].CallData[:4]))
+
+	_, receipt, err = tr.TransactBatch(ctx, submit(2, 200), submit(3, 300))
+	testutil.Ok(t, err)
+	testutil.Equals(t, types.ReceiptStatusSuccessful, receipt.Status)
+	testutil.Equals(t, batchSelector, []byte(ops[1].CallData[:4]))
+	for id, want := range map[int64]int64{1: 100, 2: 200, 3: 300} {
+		_, val, _, err := c.TellorAccess.GetCurrentValue(&bind.CallOpts{}, big.NewInt(id))
+		testutil.Ok(t, err)
+		testutil.Equals(t, big.NewInt(want), val, "request ID:%v", id)
+	}
+
+	// The calls that send their transaction themselves send the operation with Send.
+	_, receipt, err = tr.Transact(ctx, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		call, err := submit(4, 400)(auth)
+		testutil.Ok(t, err)
+		return tr.Send(ctx, call)
+	})
+	testutil.Ok(t, err)
+	testutil.Equals(t, types.ReceiptStatusSuccessful, receipt.Status)
+	testutil.Equals(t, 3, len(ops))
+
+	reverts = true
+	_, receipt, err = tr.Transact(ctx, submit(5, 500))
+	testutil.Ok(t, err)
+	testutil.Equals(t, types.ReceiptStatusFailed, receipt.Status)
+}
@@ -0,0 +1,389 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+// Package treasury tops up the ETH of the submitting accounts from a treasury account
+// so that the hot accounts only hold the gas for a short time.
+package treasury
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/contracts"
+	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/fsutil"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/notify"
+	"github.com/tellor-io/telliot/pkg/secret"
+	"github.com/tellor-io/telliot/pkg/tracker/gasPrice"
+)
+
+const ComponentName = "treasury"
+
+// KeyEnvName is the env variable with the private key of the treasury account.
+// It should not be one of the submitting accounts.
+const KeyEnvName = "TREASURY_PRIVATE_KEY"
+
+var (
+	topUps = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "telliot",
+		Subsystem: ComponentName,
+		Name:      "top_ups_total",
+		Help:      "The total number of top ups sent to an account",
+	}, []string{"addr"})
+	topUpFails = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "telliot",
+		Subsystem: ComponentName,
+		Name:      "top_up_fails_total",
+		Help:      "The total number of top ups that couldn't be sent to an account",
+	}, []string{"addr"})
+	dailySent = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "telliot",
+		Subsystem: ComponentName,
+		Name:      "daily_sent_eth",
+		Help:      "The ETH sent by the treasury within the last 24 hours",
+	})
+)
+
+type Config struct {
+	Enabled  bool
+	LogLevel string
+	Interval format.Duration
+	// Threshold is the ETH balance below which an account is topped up.
+	Threshold float64
+	// TopUp is the ETH sent by a single transfer.
+	TopUp float64
+	// DailyCap is the max ETH sent to all accounts within the last 24 hours.
+	DailyCap float64
+	// Destinations are the only addresses that the treasury sends to.
+	Destinations []string
+	// File keeps the transfers across restarts so that a restart doesn't reset the daily cap.
+	File string
+}
+
+type transfer struct {
+	Time   time.Time
+	To     common.Address
+	TxHash common.Hash
+	Wei    *big.Int
+}
+
+// Account returns the treasury account with the private key in KeyEnvName.
+// Its key should be given to a signer and the treasury only gets the address.
+func Account() (*ethereum.Account, error) {
+	key := secret.Get(KeyEnvName)
+	if key == "" {
+		return nil, errors.Errorf("the treasury needs a private key in %v", KeyEnvName)
+	}
+	account, err := ethereum.NewAccount(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating the treasury account")
+	}
+	return account, nil
+}
+
+// Treasury checks the balances of the destinations and
+// sends them ETH from the treasury account when they fall below the threshold.
+type Treasury struct {
+	ctx             context.Context
+	close           context.CancelFunc
+	logger          log.Logger
+	cfg             Config
+	client          contracts.ETHClient
+	gasPriceTracker *gasPrice.GasTracker
+	address         common.Address
+	signer          ethereum.Signer
+	notifier        notify.Notifier
+
+	threshold    *big.Int
+	topUp        *big.Int
+	dailyCap     *big.Int
+	destinations []common.Address
+
+	transfers []*transfer
+	// pending are the top ups not mined yet by destination.
+	pending map[common.Address]common.Hash
+	// blocked holds the reason for which a top up of a destination
+	// wasn't sent so that it is notified once.
+	blocked map[common.Address]string
+}
+
+func New(
+	logger log.Logger,
+	ctx context.Context,
+	cfg Config,
+	client contracts.ETHClient,
+	gasPriceTracker *gasPrice.GasTracker,
+	address common.Address,
+	signer ethereum.Signer,
+	notifier notify.Notifier,
+) (*Treasury, error) {
+	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	logger = log.With(logger, "component", ComponentName)
+
+	if signer == nil || !signer.CanSign(address) {
+		return nil, errors.Wrapf(ethereum.ErrReadOnly, "the treasury can't sign for:%v", address.Hex())
+	}
+	if cfg.Threshold <= 0 || cfg.TopUp <= 0 {
+		return nil, errors.Errorf("the threshold:%v and the top up:%v should be more than 0", cfg.Threshold, cfg.TopUp)
+	}
+	if cfg.DailyCap < cfg.TopUp {
+		return nil, errors.Errorf("the daily cap:%v should be at least a single top up:%v", cfg.DailyCap, cfg.TopUp)
+	}
+	if len(cfg.Destinations) == 0 {
+		return nil, errors.New("the treasury needs at least one destination")
+	}
+	var destinations []common.Address
+	for _, d := range cfg.Destinations {
+		d = strings.TrimSpace(d)
+		if !common.IsHexAddress(d) {
+			return nil, errors.Errorf("invalid destination address:%v", d)
+		}
+		addr := common.HexToAddress(d)
+		if addr == address {
+			return nil, errors.Errorf("the treasury can't be a destination:%v", d)
+		}
+		destinations = append(destinations, addr)
+	}
+	ctx, close := context.WithCancel(ctx)
+
+	self := &Treasury{
+		ctx:             ctx,
+		close:           close,
+		logger:          logger,
+		cfg:             cfg,
+		client:          client,
+		gasPriceTracker: gasPriceTracker,
+		address:         address,
+		signer:          signer,
+		notifier:        notifier,
+		threshold:       wei(cfg.Threshold),
+		topUp:           wei(cfg.TopUp),
+		dailyCap:        wei(cfg.DailyCap),
+		destinations:    destinations,
+		pending:         make(map[common.Address]common.Hash),
+		blocked:         make(map[common.Address]string),
+	}
+	if err := self.load(); err != nil {
+		close()
+		return nil, err
+	}
+	return self, nil
+}
+
+func (self *Treasury) Start() error {
+	level.Info(self.logger).Log("msg", "starting", "treasury", self.address.Hex(), "destinations", len(self.destinations), "threshold", self.cfg.Threshold, "topUp", self.cfg.TopUp, "dailyCap", self.cfg.DailyCap)
+
+	ticker := time.NewTicker(self.cfg.Interval.Duration)
+	defer ticker.Stop()
+	for {
+		self.checkAll()
+		select {
+		case <-self.ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (self *Treasury) Stop() {
+	self.close()
+}
+
+func (self *Treasury) checkAll() {
+	for _, dest := range self.destinations {
+		if err := self.check(dest, time.Now()); err != nil {
+			topUpFails.With(prometheus.Labels{"addr": dest.Hex()}).Inc()
+			level.Error(self.logger).Log("msg", "topping up", "addr", dest.Hex(), "err", err)
+			self.block(dest, notify.SeverityCritical, fmt.Sprintf("Top up of %v failed", dest.Hex()), err.Error())
+		}
+	}
+}
+
+// check tops up a destination when its balance is below the threshold,
+// it has no pending top up and the top up fits the daily cap.
+func (self *Treasury) check(dest common.Address, now time.Time) error {
+	if hash, ok := self.pending[dest]; ok {
+		_, isPending, err := self.client.TransactionByHash(self.ctx, hash)
+		if err != nil {
+			return errors.Wrapf(err, "getting the pending top up:%v", hash.Hex())
+		}
+		if isPending {
+			level.Debug(self.logger).Log("msg", "top up still pending", "addr", dest.Hex(), "tx", hash.Hex())
+			return nil
+		}
+		delete(self.pending, dest)
+	}
+
+	balance, err := self.client.BalanceAt(self.ctx, dest, nil)
+	if err != nil {
+		return errors.Wrap(err, "getting the balance")
+	}
+	if balance.Cmp(self.threshold) >= 0 {
+		delete(self.blocked, dest)
+		return nil
+	}
+
+	sent := self.sent(now)
+	if new(big.Int).Add(sent, self.topUp).Cmp(self.dailyCap) > 0 {
+		self.block(dest, notify.SeverityWarning,
+			fmt.Sprintf("Top up of %v blocked by the daily cap", dest.Hex()),
+			fmt.Sprintf("The balance of %v ETH is below the threshold of %v ETH but %v ETH were already sent within the last 24 hours and the daily cap is %v ETH.", eth(balance), self.cfg.Threshold, eth(sent), self.cfg.DailyCap),
+		)
+		return nil
+	}
+
+	tx, err := self.send(dest)
+	if err != nil {
+		return err
+	}
+	self.pending[dest] = tx.Hash()
+	self.transfers = append(self.transfers, &transfer{Time: now, To: dest, TxHash: tx.Hash(), Wei: new(big.Int).Set(self.topUp)})
+	self.update(now)
+	if err := self.save(); err != nil {
+		level.Error(self.logger).Log("msg", "saving the transfers", "err", err)
+	}
+	delete(self.blocked, dest)
+	topUps.With(prometheus.Labels{"addr": dest.Hex()}).Inc()
+	level.Info(self.logger).Log("msg", "topped up", "addr", dest.Hex(), "amount", self.cfg.TopUp, "tx", tx.Hash().Hex())
+	self.notify(dest, notify.SeverityInfo, notify.EventTopUp,
+		fmt.Sprintf("Topped up %v", dest.Hex()),
+		fmt.Sprintf("Sent %v ETH from the treasury %v as the balance of %v ETH was below the threshold of %v ETH. Sent within the last 24 hours: %v ETH of the daily cap of %v ETH.", self.cfg.TopUp, self.address.Hex(), eth(balance), self.cfg.Threshold, eth(self.sent(now)), self.cfg.DailyCap),
+		map[string]string{"tx": tx.Hash().Hex()},
+	)
+	return nil
+}
+
+func (self *Treasury) send(dest common.Address) (*types.Transaction, error) {
+	nonce, err := self.client.PendingNonceAt(self.ctx, self.address)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting the treasury nonce")
+	}
+	gasPrice, err := self.gasPriceTracker.Query(self.ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting the gas price")
+	}
+	chainID, err := self.client.NetworkID(self.ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting the network id")
+	}
+	balance, err := self.client.BalanceAt(self.ctx, self.address, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting the treasury balance")
+	}
+	cost := new(big.Int).Add(self.topUp, new(big.Int).Mul(big.NewInt(gasPrice), big.NewInt(int64(params.TxGas))))
+	if balance.Cmp(cost) < 0 {
+		return nil, errors.Errorf("the treasury balance of %v ETH can't cover the top up and its gas of %v ETH", eth(balance), eth(cost))
+	}
+	tx := types.NewTransaction(nonce, dest, self.topUp, params.TxGas, big.NewInt(gasPrice), nil)
+	signed, err := self.signer.SignTx(self.address, tx, chainID)
+	if err != nil {
+		return nil, errors.Wrap(err, "signing the top up")
+	}
+	if err := self.client.SendTransaction(self.ctx, signed); err != nil {
+		return nil, errors.Wrap(err, "sending the top up")
+	}
+	return signed, nil
+}
+
+// sent returns the wei sent within the 24 hours before now.
+func (self *Treasury) sent(now time.Time) *big.Int {
+	total := big.NewInt(0)
+	for _, t := range self.transfers {
+		if now.Sub(t.Time) < 24*time.Hour {
+			total.Add(total, t.Wei)
+		}
+	}
+	return total
+}
+
+func (self *Treasury) update(now time.Time) {
+	i := 0
+	for ; i < len(self.transfers) && now.Sub(self.transfers[i].Time) >= 24*time.Hour; i++ {
+	}
+	self.transfers = self.transfers[i:]
+	dailySent.Set(eth(self.sent(now)))
+}
+
+// block notifies once per reason that a top up of a destination wasn't sent.
+func (self *Treasury) block(dest common.Address, severity notify.Severity, title, body string) {
+	if self.blocked[dest] == title {
+		return
+	}
+	self.blocked[dest] = title
+	self.notify(dest, severity, notify.EventTopUpBlocked, title, body, nil)
+}
+
+func (self *Treasury) notify(dest common.Address, severity notify.Severity, event, title, body string, data map[string]string) {
+	if data == nil {
+		data = make(map[string]string)
+	}
+	data["account"] = dest.Hex()
+	data["treasury"] = self.address.Hex()
+	if err := self.notifier.Notify(self.ctx, notify.Message{
+		Event:    event,
+		Severity: severity,
+		Title:    title,
+		Body:     body,
+		Data:     data,
+	}); err != nil {
+		level.Error(self.logger).Log("msg", "sending notification", "event", event, "err", err)
+	}
+}
+
+func (self *Treasury) load() error {
+	if self.cfg.File == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(self.cfg.File)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "read treasury file path:%s", self.cfg.File)
+	}
+	if err := json.Unmarshal(data, &self.transfers); err != nil {
+		return errors.Wrap(err, "unmarshal treasury file")
+	}
+	self.update(time.Now())
+	return nil
+}
+
+func (self *Treasury) save() error {
+	if self.cfg.File == "" {
+		return nil
+	}
+	data, err := json.Marshal(self.transfers)
+	if err != nil {
+		return errors.Wrap(err, "marshal transfers")
+	}
+	return errors.Wrap(fsutil.WriteAtomic(self.cfg.File, data, 0600), "write treasury file")
+}
+
+func wei(eth float64) *big.Int {
+	v, _ := new(big.Float).Mul(big.NewFloat(eth), big.NewFloat(params.Ether)).Int(nil)
+	return v
+}
+
+func eth(wei *big.Int) float64 {
+	v, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(params.Ether)).Float64()
+	return v
+}
@@ -0,0 +1,105 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package treasury
+
+import (
+	"context"
+	"encoding/hex"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/go-kit/kit/log"
+	"github.com/tellor-io/telliot/pkg/ethereum"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/notify"
+	"github.com/tellor-io/telliot/pkg/testutil"
+	"github.com/tellor-io/telliot/pkg/testutil/chain"
+	"github.com/tellor-io/telliot/pkg/tracker/gasPrice"
+)
+
+type recordNotifier struct {
+	msgs []notify.Message
+}
+
+func (self *recordNotifier) Notify(_ context.Context, msg notify.Message) error {
+	self.msgs = append(self.msgs, msg)
+	return nil
+}
+
+// TestTopUp ensures that only the destinations below the threshold are topped up
+// within the daily cap and that the cap is kept across restarts.
+func TestTopUp(t *testing.T) {
+	c, err := chain.New(1)
+	testutil.Ok(t, err)
+	defer c.Close()
+	testutil.Ok(t, os.Setenv(KeyEnvName, hex.EncodeToString(crypto.FromECDSA(c.Accounts[0].PrivateKey))))
+	defer os.Unsetenv(KeyEnvName)
+	account, err := Account()
+	testutil.Ok(t, err)
+	testutil.Equals(t, c.Accounts[0].Address, account.Address)
+	signer := ethereum.NewKeySigner(log.NewNopLogger(), []*ethereum.Account{account})
+
+	dir, err := ioutil.TempDir("", "treasury")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	first := common.HexToAddress("0x00000000000000000000000000000000000000a1")
+	second := common.HexToAddress("0x00000000000000000000000000000000000000a2")
+	cfg := Config{
+		Enabled:      true,
+		LogLevel:     "info",
+		Interval:     format.Duration{Duration: time.Minute},
+		Threshold:    1,
+		TopUp:        2,
+		DailyCap:     3,
+		Destinations: []string{first.Hex(), second.Hex()},
+		File:         filepath.Join(dir, "treasury.json"),
+	}
+	notifier := &recordNotifier{}
+	treasury, err := New(log.NewNopLogger(), context.Background(), cfg, c, gasPrice.New(log.NewNopLogger(), c), account.Address, signer, notifier)
+	testutil.Ok(t, err)
+
+	now := time.Now()
+	testutil.Ok(t, treasury.check(first, now))
+	balance, err := c.BalanceAt(context.Background(), first, nil)
+	testutil.Ok(t, err)
+	testutil.Equals(t, big.NewInt(2e18), balance)
+	testutil.Equals(t, 1, len(notifier.msgs))
+	testutil.Equals(t, notify.EventTopUp, notifier.msgs[0].Event)
+
+	// Above the threshold now.
+	testutil.Ok(t, treasury.check(first, now))
+	testutil.Equals(t, 1, len(notifier.msgs))
+
+	// Another top up would exceed the daily cap, notified once.
+	testutil.Ok(t, treasury.check(second, now))
+	testutil.Ok(t, treasury.check(second, now))
+	testutil.Equals(t, 2, len(notifier.msgs))
+	testutil.Equals(t, notify.EventTopUpBlocked, notifier.msgs[1].Event)
+
+	// The cap is kept after a restart and resets after 24 hours.
+	treasury, err = New(log.NewNopLogger(), context.Background(), cfg, c, gasPrice.New(log.NewNopLogger(), c), account.Address, signer, notifier)
+	testutil.Ok(t, err)
+	testutil.Equals(t, big.NewInt(2e18), treasury.sent(now))
+	testutil.Ok(t, treasury.check(second, now.Add(25*time.Hour)))
+	balance, err = c.BalanceAt(context.Background(), second, nil)
+	testutil.Ok(t, err)
+	testutil.Equals(t, big.NewInt(2e18), balance)
+
+	// Only the pinned addresses are destinations.
+	cfg.Destinations = []string{c.Accounts[0].Address.Hex()}
+	_, err = New(log.NewNopLogger(), context.Background(), cfg, c, gasPrice.New(log.NewNopLogger(), c), account.Address, signer, notifier)
+	testutil.NotOk(t, err, "the treasury as a destination")
+
+	// Only with a signer that has the treasury key.
+	cfg.Destinations = []string{first.Hex()}
+	_, err = New(log.NewNopLogger(), context.Background(), cfg, c, gasPrice.New(log.NewNopLogger(), c), account.Address, ethereum.NewKeySigner(log.NewNopLogger(), nil), notifier)
+	testutil.NotOk(t, err, "a signer without the treasury key")
+}
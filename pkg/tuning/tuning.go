@@ -0,0 +1,210 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+// Package tuning keeps the settings that can be changed without a restart
+// like the number of mining workers and the intervals of the trackers
+// so that an instance on a constrained host can be tuned while it is running.
+package tuning
+
+import (
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Setting is the current value of a setting.
+type Setting struct {
+	Name        string `json:"name"`
+	Value       string `json:"value"`
+	Configured  string `json:"configured"`
+	Description string `json:"description"`
+}
+
+type setting struct {
+	description string
+	configured  string
+	get         func() string
+	set         func(string) error
+}
+
+var settings = &registry{settings: make(map[string]*setting)}
+
+type registry struct {
+	mtx      sync.RWMutex
+	settings map[string]*setting
+}
+
+// Register adds a setting with its getter and setter.
+// The configured value is the current value which is restored by an empty value.
+// Registering a name again replaces the setting.
+func Register(name, description string, get func() string, set func(string) error) {
+	settings.mtx.Lock()
+	defer settings.mtx.Unlock()
+	settings.settings[name] = &setting{
+		description: description,
+		configured:  get(),
+		get:         get,
+		set:         set,
+	}
+}
+
+// Set changes the value of a setting, an empty value restores the configured value.
+func Set(name, value string) error {
+	settings.mtx.RLock()
+	s, ok := settings.settings[name]
+	settings.mtx.RUnlock()
+	if !ok {
+		return errors.Errorf("unknown setting:%v", name)
+	}
+	if value == "" {
+		value = s.configured
+	}
+	if err := s.set(value); err != nil {
+		return errors.Wrapf(err, "setting:%v value:%v", name, value)
+	}
+	return nil
+}
+
+// Settings returns the current value of all settings sorted by name.
+func Settings() []Setting {
+	settings.mtx.RLock()
+	defer settings.mtx.RUnlock()
+	var result []Setting
+	for name, s := range settings.settings {
+		result = append(result, Setting{
+			Name:        name,
+			Value:       s.get(),
+			Configured:  s.configured,
+			Description: s.description,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// Duration is a duration setting which is safe to read while it is changed.
+type Duration struct {
+	d int64
+}
+
+// NewDuration registers a duration setting which has to be positive.
+func NewDuration(name, description string, d time.Duration) *Duration {
+	self := &Duration{d: int64(d)}
+	Register(name, description, func() string { return self.Get().String() }, func(v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		if d <= 0 {
+			return errors.Errorf("duration should be positive:%v", d)
+		}
+		atomic.StoreInt64(&self.d, int64(d))
+		return nil
+	})
+	return self
+}
+
+func (self *Duration) Get() time.Duration {
+	return time.Duration(atomic.LoadInt64(&self.d))
+}
+
+// Int is an integer setting which is safe to read while it is changed.
+type Int struct {
+	v int64
+}
+
+// NewInt registers an integer setting limited to min and max.
+func NewInt(name, description string, v, min, max int64) *Int {
+	self := &Int{v: v}
+	Register(name, description, func() string { return strconv.FormatInt(self.Get(), 10) }, func(s string) error {
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		if v < min || v > max {
+			return errors.Errorf("value should be between %v and %v:%v", min, max, v)
+		}
+		atomic.StoreInt64(&self.v, v)
+		return nil
+	})
+	return self
+}
+
+func (self *Int) Get() int64 {
+	return atomic.LoadInt64(&self.v)
+}
+
+func init() {
+	Register("GOMAXPROCS", "The number of OS threads that run Go code at the same time.",
+		func() string { return strconv.Itoa(runtime.GOMAXPROCS(0)) },
+		func(v string) error {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return err
+			}
+			if n < 1 {
+				return errors.Errorf("should be at least 1:%v", n)
+			}
+			runtime.GOMAXPROCS(n)
+			return nil
+		},
+	)
+
+	// The runtime has no getters for the GC percent and the block profile rate
+	// so the last set values are kept.
+	gcPercent := int64(100)
+	if v, err := strconv.ParseInt(os.Getenv("GOGC"), 10, 64); err == nil {
+		gcPercent = v
+	}
+	Register("gcPercent", "The heap growth in percent that triggers a garbage collection, lower uses less memory and more CPU, -1 disables the GC.",
+		func() string { return strconv.FormatInt(atomic.LoadInt64(&gcPercent), 10) },
+		func(v string) error {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return err
+			}
+			debug.SetGCPercent(n)
+			atomic.StoreInt64(&gcPercent, int64(n))
+			return nil
+		},
+	)
+
+	var blockProfileRate int64
+	Register("blockProfileRate", "The nanoseconds of blocking per sampled event of the pprof block profile, 0 disables the profile.",
+		func() string { return strconv.FormatInt(atomic.LoadInt64(&blockProfileRate), 10) },
+		func(v string) error {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return err
+			}
+			if n < 0 {
+				return errors.Errorf("should not be negative:%v", n)
+			}
+			runtime.SetBlockProfileRate(n)
+			atomic.StoreInt64(&blockProfileRate, int64(n))
+			return nil
+		},
+	)
+
+	Register("mutexProfileFraction", "1 in how many mutex contention events are sampled by the pprof mutex profile, 0 disables the profile.",
+		func() string { return strconv.Itoa(runtime.SetMutexProfileFraction(-1)) },
+		func(v string) error {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return err
+			}
+			if n < 0 {
+				return errors.Errorf("should not be negative:%v", n)
+			}
+			runtime.SetMutexProfileFraction(n)
+			return nil
+		},
+	)
+}
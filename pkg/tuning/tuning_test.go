@@ -0,0 +1,45 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package tuning
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+func TestSet(t *testing.T) {
+	interval := NewDuration("test.interval", "", time.Minute)
+	workers := NewInt("test.workers", "", 1, 1, 4)
+
+	testutil.Ok(t, Set("test.interval", "10s"))
+	testutil.Equals(t, 10*time.Second, interval.Get())
+	testutil.NotOk(t, Set("test.interval", "-1s"))
+	testutil.NotOk(t, Set("test.unknown", "1"))
+
+	testutil.Ok(t, Set("test.workers", "4"))
+	testutil.Equals(t, int64(4), workers.Get())
+	testutil.NotOk(t, Set("test.workers", "5"))
+
+	// An empty value restores the configured value.
+	testutil.Ok(t, Set("test.interval", ""))
+	testutil.Equals(t, time.Minute, interval.Get())
+
+	procs := runtime.GOMAXPROCS(0)
+	defer runtime.GOMAXPROCS(procs)
+	testutil.Ok(t, Set("GOMAXPROCS", "1"))
+	testutil.Equals(t, 1, runtime.GOMAXPROCS(0))
+
+	var found bool
+	for _, s := range Settings() {
+		if s.Name == "test.workers" {
+			found = true
+			testutil.Equals(t, "4", s.Value)
+			testutil.Equals(t, "1", s.Configured)
+		}
+	}
+	testutil.Assert(t, found, "the setting should be listed")
+}
@@ -0,0 +1,37 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+// Package upgrade replaces the running binary with a new one on SIGUSR2.
+//
+// The new process inherits the listener of the web server so that no API request is refused
+// and it connects to the node before the old process is stopped. The old process then stops
+// and the new one opens the DB and the submissions journal only after it has exited
+// so the submissions pause only while the files change hands.
+// The transactions that were in-flight are in the journal which the new process resumes.
+package upgrade
+
+import (
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/format"
+)
+
+const ComponentName = "upgrade"
+
+// EnvName is set for a process started by an upgrade.
+const EnvName = "TELLIOT_UPGRADE"
+
+type Config struct {
+	Enabled  bool
+	LogLevel string
+	// Timeout is how long the old process waits for the new one to be ready
+	// and how long the new process waits for the old one to exit.
+	// The upgrade is aborted and the old process keeps running when the new one isn't ready in time.
+	Timeout format.Duration
+}
+
+func (self Config) Validate() error {
+	if self.Timeout.Duration <= 0 {
+		return errors.Errorf("timeout should be more than 0:%v", self.Timeout)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+//go:build !windows
+// +build !windows
+
+package upgrade
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+var testCfg = Config{
+	Enabled:  true,
+	LogLevel: "info",
+	Timeout:  format.Duration{Duration: 30 * time.Second},
+}
+
+// TestMain runs the new process of the upgrade test
+// which serves a single request on the inherited listener.
+func TestMain(m *testing.M) {
+	if os.Getenv(EnvName) == "" {
+		os.Exit(m.Run())
+	}
+	if err := serveUpgraded(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func serveUpgraded() error {
+	upgrader, err := New(log.NewNopLogger(), context.Background(), testCfg)
+	if err != nil {
+		return err
+	}
+	if err := upgrader.Handover(); err != nil {
+		return err
+	}
+	listener, err := upgrader.Listen("")
+	if err != nil {
+		return err
+	}
+	served := make(chan struct{})
+	go func() {
+		_ = http.Serve(listener, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "new")
+			close(served)
+		}))
+	}()
+	select {
+	case <-served:
+	case <-time.After(testCfg.Timeout.Duration):
+	}
+	// Give the response time to be written.
+	time.Sleep(100 * time.Millisecond)
+	return nil
+}
+
+func TestDisabled(t *testing.T) {
+	upgrader, err := New(log.NewNopLogger(), context.Background(), Config{})
+	testutil.Ok(t, err)
+	testutil.Assert(t, upgrader == nil, "disabled upgrader should be nil")
+}
+
+func TestUpgrade(t *testing.T) {
+	upgrader, err := New(log.NewNopLogger(), context.Background(), testCfg)
+	testutil.Ok(t, err)
+	defer upgrader.Stop()
+
+	_, err = upgrader.upgrade()
+	testutil.NotOk(t, err, "upgrading without a listener should fail")
+
+	listener, err := upgrader.Listen("127.0.0.1:0")
+	testutil.Ok(t, err)
+	addr := listener.Addr().String()
+
+	started := make(chan error, 1)
+	go func() { started <- upgrader.Start() }()
+	// Wait for the signal handler.
+	time.Sleep(100 * time.Millisecond)
+	testutil.Ok(t, syscall.Kill(os.Getpid(), syscall.SIGUSR2))
+
+	select {
+	case err := <-started:
+		testutil.Ok(t, err)
+	case <-time.After(testCfg.Timeout.Duration):
+		t.Fatal("the new process wasn't ready")
+	}
+
+	// Stopping the old process closes its listener while the new one keeps accepting on its copy.
+	testutil.Ok(t, listener.Close())
+	testutil.Ok(t, upgrader.exit.Close())
+
+	resp, err := http.Get("http://" + addr)
+	testutil.Ok(t, err)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	testutil.Ok(t, err)
+	testutil.Equals(t, "new", string(body))
+}
@@ -0,0 +1,230 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+//go:build !windows
+// +build !windows
+
+package upgrade
+
+import (
+	"context"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/logging"
+)
+
+// The files passed to the new process after stdin, stdout and stderr.
+const (
+	listenerFd = 3 + iota
+	readyFd
+	exitFd
+)
+
+// Upgrader starts a new process of the binary on SIGUSR2
+// and stops the current process when the new one is ready to take over.
+type Upgrader struct {
+	logger log.Logger
+	cfg    Config
+	ctx    context.Context
+	close  context.CancelFunc
+	// upgraded is set when the process was started by an upgrade.
+	upgraded bool
+	listener *net.TCPListener
+	// exit is kept open until the process exits
+	// so that the new process sees when the DB and the journal are closed.
+	exit *os.File
+}
+
+// New creates an upgrader or returns nil when it is disabled.
+func New(logger log.Logger, ctx context.Context, cfg Config) (*Upgrader, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, errors.Wrap(err, "validate config")
+	}
+	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	ctx, close := context.WithCancel(ctx)
+	self := &Upgrader{
+		logger:   log.With(logger, "component", ComponentName),
+		cfg:      cfg,
+		ctx:      ctx,
+		close:    close,
+		upgraded: os.Getenv(EnvName) != "",
+	}
+	// Not inherited by the plugins and the process of the next upgrade.
+	if err := os.Unsetenv(EnvName); err != nil {
+		return nil, errors.Wrap(err, "unset upgrade env")
+	}
+	return self, nil
+}
+
+// Listen returns the listener inherited from the old process after an upgrade
+// or otherwise a new listener on the address.
+// The inherited listener is used even when the address was changed in the config.
+func (self *Upgrader) Listen(addr string) (net.Listener, error) {
+	var (
+		listener net.Listener
+		err      error
+	)
+	if self.upgraded {
+		f := os.NewFile(listenerFd, "listener")
+		listener, err = net.FileListener(f)
+		f.Close()
+		if err != nil {
+			return nil, errors.Wrap(err, "inheriting the listener")
+		}
+		level.Info(self.logger).Log("msg", "inherited listener", "addr", listener.Addr())
+	} else {
+		listener, err = net.Listen("tcp", addr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "listening on:%v", addr)
+		}
+	}
+	tcp, ok := listener.(*net.TCPListener)
+	if !ok {
+		listener.Close()
+		return nil, errors.Errorf("not a TCP listener:%v", listener.Addr())
+	}
+	self.listener = tcp
+	return tcp, nil
+}
+
+// Handover tells the old process that this one is ready to take over
+// and waits until it has exited so that its DB and journal are closed.
+// It does nothing when the process wasn't started by an upgrade.
+func (self *Upgrader) Handover() error {
+	if !self.upgraded {
+		return nil
+	}
+	ready := os.NewFile(readyFd, "ready")
+	_, err := ready.Write([]byte{1})
+	ready.Close()
+	if err != nil {
+		return errors.Wrap(err, "signaling the old process")
+	}
+	level.Info(self.logger).Log("msg", "waiting for the old process to exit")
+
+	exit := os.NewFile(exitFd, "exit")
+	defer exit.Close()
+	// The read returns when the old process exits and its end of the pipe is closed.
+	exited := make(chan struct{})
+	go func() {
+		_, _ = exit.Read(make([]byte, 1))
+		close(exited)
+	}()
+	select {
+	case <-exited:
+		level.Info(self.logger).Log("msg", "the old process exited, taking over")
+		return nil
+	case <-time.After(self.cfg.Timeout.Duration):
+		return errors.Errorf("the old process didn't exit in:%v", self.cfg.Timeout)
+	case <-self.ctx.Done():
+		return self.ctx.Err()
+	}
+}
+
+// Start waits for SIGUSR2 and returns when the new process is ready
+// so that the run group stops this process.
+// When the upgrade fails the process keeps running.
+func (self *Upgrader) Start() error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR2)
+	defer signal.Stop(signals)
+	for {
+		select {
+		case <-self.ctx.Done():
+			return nil
+		case <-signals:
+		}
+		level.Info(self.logger).Log("msg", "upgrade requested, starting the new process")
+		pid, err := self.upgrade()
+		if err != nil {
+			level.Error(self.logger).Log("msg", "upgrade failed, the current process keeps running", "err", err)
+			continue
+		}
+		level.Info(self.logger).Log("msg", "the new process is ready, stopping", "pid", pid)
+		return nil
+	}
+}
+
+func (self *Upgrader) upgrade() (int, error) {
+	if self.listener == nil {
+		return 0, errors.New("no listener to hand over")
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return 0, errors.Wrap(err, "getting the binary path")
+	}
+	listener, err := self.listener.File()
+	if err != nil {
+		return 0, errors.Wrap(err, "getting the listener file")
+	}
+	defer listener.Close()
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return 0, errors.Wrap(err, "creating the ready pipe")
+	}
+	defer readyR.Close()
+	exitR, exitW, err := os.Pipe()
+	if err != nil {
+		readyW.Close()
+		return 0, errors.Wrap(err, "creating the exit pipe")
+	}
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = append(os.Environ(), EnvName+"=1")
+	cmd.ExtraFiles = []*os.File{listener, readyW, exitR}
+	err = cmd.Start()
+	// Only the new process keeps these ends so that the reads here and there
+	// return when the other process closes its end.
+	readyW.Close()
+	exitR.Close()
+	if err != nil {
+		exitW.Close()
+		return 0, errors.Wrapf(err, "starting:%v", exe)
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	ready := make(chan error, 1)
+	go func() {
+		_, err := readyR.Read(make([]byte, 1))
+		ready <- err
+	}()
+
+	select {
+	case err = <-ready:
+		if err == nil {
+			self.exit = exitW
+			return cmd.Process.Pid, nil
+		}
+		err = errors.Wrap(err, "the new process exited before it was ready")
+	case err = <-exited:
+		err = errors.Errorf("the new process exited before it was ready:%v", err)
+	case <-time.After(self.cfg.Timeout.Duration):
+		err = errors.Errorf("the new process wasn't ready in:%v", self.cfg.Timeout)
+	case <-self.ctx.Done():
+		err = self.ctx.Err()
+	}
+	if kErr := cmd.Process.Kill(); kErr != nil {
+		level.Error(self.logger).Log("msg", "killing the new process", "err", kErr)
+	}
+	exitW.Close()
+	return 0, err
+}
+
+func (self *Upgrader) Stop() {
+	self.close()
+}
@@ -0,0 +1,36 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package upgrade
+
+import (
+	"context"
+	"net"
+
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+)
+
+// Upgrader is not supported on windows as it has no SIGUSR2.
+type Upgrader struct{}
+
+func New(_ log.Logger, _ context.Context, cfg Config) (*Upgrader, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	return nil, errors.New("the upgrades are not supported on windows")
+}
+
+func (self *Upgrader) Listen(addr string) (net.Listener, error) {
+	return net.Listen("tcp", addr)
+}
+
+func (self *Upgrader) Handover() error {
+	return nil
+}
+
+func (self *Upgrader) Start() error {
+	return nil
+}
+
+func (self *Upgrader) Stop() {}
@@ -0,0 +1,143 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+// Package watchdog puts deadlines on the loop iterations and the external calls of the components
+// so that a single hung request can't freeze a component without anyone noticing.
+package watchdog
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/errclass"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+)
+
+const ComponentName = "watchdog"
+
+// ErrDeadline is returned when a call didn't return by its deadline.
+var ErrDeadline = errclass.New(errclass.Retryable, "deadline exceeded")
+
+type Config struct {
+	LogLevel string
+	// Deadlines are the max durations by operation e.g. balanceTracker for the loop iterations of the tracker
+	// or indexTracker.fetch for a single fetch of a data source.
+	Deadlines map[string]format.Duration
+	// Default is the deadline of the operations not in the deadlines, 0 disables them.
+	Default format.Duration
+}
+
+var overruns = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "telliot",
+	Subsystem: ComponentName,
+	Name:      "overruns_total",
+	Help:      "The total number of operations that were still running at their deadline",
+}, []string{"operation"})
+
+// Watchdog logs and counts the operations running past their deadlines.
+// A nil Watchdog has no deadlines. It is safe for concurrent use.
+type Watchdog struct {
+	logger log.Logger
+	cfg    Config
+}
+
+func New(logger log.Logger, cfg Config) (*Watchdog, error) {
+	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	for op, d := range cfg.Deadlines {
+		if d.Duration <= 0 {
+			return nil, errors.Errorf("the deadline of operation:%v should be more than 0", op)
+		}
+	}
+	return &Watchdog{
+		logger: log.With(logger, "component", ComponentName),
+		cfg:    cfg,
+	}, nil
+}
+
+// Deadline returns the deadline of an operation, 0 when it has none.
+func (self *Watchdog) Deadline(op string) time.Duration {
+	if self == nil {
+		return 0
+	}
+	if d, ok := self.cfg.Deadlines[op]; ok {
+		return d.Duration
+	}
+	return self.cfg.Default.Duration
+}
+
+// Watch starts watching an operation that can't be abandoned e.g. a loop iteration
+// and returns the function that stops watching it.
+// The operation is logged and counted once when it is still running at its deadline
+// and logged again when it completes.
+func (self *Watchdog) Watch(op string) func() {
+	deadline := self.Deadline(op)
+	if deadline <= 0 {
+		return func() {}
+	}
+	start := time.Now()
+	timer := time.AfterFunc(deadline, func() {
+		overruns.With(prometheus.Labels{"operation": op}).Inc()
+		level.Error(self.logger).Log("msg", "operation still running at its deadline", "operation", op, "deadline", deadline)
+	})
+	return func() {
+		if !timer.Stop() {
+			level.Warn(self.logger).Log("msg", "operation completed after its deadline", "operation", op, "took", time.Since(start))
+		}
+	}
+}
+
+// Run calls fn with a context canceled at the deadline of the operation.
+// When fn doesn't return by the deadline e.g. a request that ignores the context,
+// the overrun is logged and counted and Run returns ErrDeadline
+// while fn completes in the background so that the caller isn't blocked.
+func (self *Watchdog) Run(ctx context.Context, op string, fn func(context.Context) error) error {
+	deadline := self.Deadline(op)
+	err := Call(ctx, deadline, fn)
+	if errors.Is(err, ErrDeadline) {
+		overruns.With(prometheus.Labels{"operation": op}).Inc()
+		level.Error(self.logger).Log("msg", "operation abandoned at its deadline", "operation", op, "deadline", deadline)
+	}
+	return err
+}
+
+// Call calls fn with a context canceled after the deadline
+// and returns ErrDeadline when fn doesn't return by then.
+// fn completes in the background and its result is dropped. A deadline of 0 disables it.
+func Call(ctx context.Context, deadline time.Duration, fn func(context.Context) error) error {
+	if deadline <= 0 {
+		return fn(ctx)
+	}
+	ctx, cncl := context.WithTimeout(ctx, deadline)
+	done := make(chan error, 1)
+	go func() {
+		defer cncl()
+		done <- fn(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+	}
+	// Give fn a moment to return the error of the canceled context.
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(gracePeriod):
+		if ctx.Err() != context.DeadlineExceeded {
+			return ctx.Err()
+		}
+		return errors.Wrapf(ErrDeadline, "not done after:%v", deadline)
+	}
+}
+
+// gracePeriod is how long a call has to return after its context is canceled.
+const gracePeriod = time.Second
@@ -0,0 +1,59 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package watchdog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/errclass"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+func TestRun(t *testing.T) {
+	w, err := New(logging.NewLogger(), Config{
+		LogLevel:  "info",
+		Deadlines: map[string]format.Duration{"fetch": {Duration: 50 * time.Millisecond}},
+	})
+	testutil.Ok(t, err)
+	testutil.Equals(t, 50*time.Millisecond, w.Deadline("fetch"))
+	testutil.Equals(t, time.Duration(0), w.Deadline("other"))
+
+	// A call that returns in time.
+	testutil.Ok(t, w.Run(context.Background(), "fetch", func(context.Context) error { return nil }))
+
+	// A call that stops when its context is canceled at the deadline.
+	err = w.Run(context.Background(), "fetch", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	testutil.Assert(t, errors.Is(err, context.DeadlineExceeded), "unexpected error:%v", err)
+
+	// A hung call that ignores its context is abandoned.
+	release := make(chan struct{})
+	defer close(release)
+	start := time.Now()
+	err = w.Run(context.Background(), "fetch", func(context.Context) error {
+		<-release
+		return nil
+	})
+	testutil.Assert(t, errors.Is(err, ErrDeadline), "unexpected error:%v", err)
+	testutil.Equals(t, errclass.Retryable, errclass.Of(err))
+	testutil.Assert(t, time.Since(start) < 50*time.Millisecond+2*gracePeriod, "the hung call wasn't abandoned in time")
+
+	// Without a deadline the call runs until it returns.
+	testutil.Ok(t, w.Run(context.Background(), "other", func(context.Context) error {
+		time.Sleep(60 * time.Millisecond)
+		return nil
+	}))
+
+	// A nil watchdog has no deadlines.
+	var none *Watchdog
+	none.Watch("fetch")()
+	testutil.Ok(t, none.Run(context.Background(), "fetch", func(context.Context) error { return nil }))
+}
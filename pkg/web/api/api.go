@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"math"
 	"net/http"
+	"runtime"
 	"sort"
 	"strconv"
 	"time"
@@ -20,6 +21,7 @@ import (
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/common/route"
 	promConfig "github.com/prometheus/prometheus/config"
+	"github.com/prometheus/prometheus/pkg/exemplar"
 	"github.com/prometheus/prometheus/pkg/labels"
 	"github.com/prometheus/prometheus/pkg/timestamp"
 	"github.com/prometheus/prometheus/promql"
@@ -84,6 +86,7 @@ type apiFunc func(r *http.Request) apiFuncResult
 // them using the provided storage and query engine.
 type API struct {
 	Queryable         storage.SampleAndChunkQueryable
+	ExemplarQueryable storage.ExemplarQueryable
 	QueryEngine       *promql.Engine
 	now               func() time.Time
 	remoteReadHandler http.Handler
@@ -92,6 +95,7 @@ type API struct {
 
 func init() {
 	jsoniter.RegisterTypeEncoderFunc("promql.Point", marshalPointJSON, marshalPointJSONIsEmpty)
+	jsoniter.RegisterTypeEncoderFunc("exemplar.Exemplar", marshalExemplarJSON, marshalExemplarJSONEmpty)
 }
 
 // New returns an initialized API type.
@@ -110,6 +114,10 @@ func New(
 		logger:            logger,
 		remoteReadHandler: remote.NewReadHandler(logger, nil, q, configFunc, 5e7, 10, 1048576),
 	}
+	// The remote DB doesn't keep exemplars.
+	if eq, ok := q.(storage.ExemplarQueryable); ok {
+		a.ExemplarQueryable = eq
+	}
 
 	return a
 }
@@ -150,6 +158,11 @@ func (api *API) Register(r *route.Router) {
 	r.Get("/query_range", wrap(api.queryRange))
 	r.Post("/query_range", wrap(api.queryRange))
 
+	if api.ExemplarQueryable != nil {
+		r.Get("/query_exemplars", wrap(api.queryExemplars))
+		r.Post("/query_exemplars", wrap(api.queryExemplars))
+	}
+
 	r.Get("/labels", wrap(api.labelNames))
 	r.Post("/labels", wrap(api.labelNames))
 	r.Get("/label/:name/values", wrap(api.labelValues))
@@ -159,6 +172,35 @@ func (api *API) Register(r *route.Router) {
 
 	r.Post("/read", http.HandlerFunc(api.remoteRead))
 
+	// Called by Grafana to detect the features of the datasource and for the query editor.
+	r.Get("/status/buildinfo", wrap(api.buildInfo))
+	r.Get("/metadata", wrap(api.metadata))
+}
+
+// prometheusVersion is the Prometheus version of the vendored query engine.
+// Grafana enables the query editor features by it.
+const prometheusVersion = "2.27.0"
+
+type buildInfo struct {
+	Version   string `json:"version"`
+	Revision  string `json:"revision"`
+	Branch    string `json:"branch"`
+	BuildUser string `json:"buildUser"`
+	BuildDate string `json:"buildDate"`
+	GoVersion string `json:"goVersion"`
+}
+
+func (api *API) buildInfo(r *http.Request) apiFuncResult {
+	return apiFuncResult{&buildInfo{
+		Version:   prometheusVersion,
+		GoVersion: runtime.Version(),
+	}, nil, nil, nil}
+}
+
+// metadata always responds with no metadata as the DB keeps only the samples.
+func (api *API) metadata(r *http.Request) apiFuncResult {
+	// The same empty object as an empty map, which the vendored encoder can't iterate with the recent go versions.
+	return apiFuncResult{struct{}{}, nil, nil, nil}
 }
 
 type queryData struct {
@@ -312,6 +354,43 @@ func (api *API) queryRange(r *http.Request) (result apiFuncResult) {
 	}, nil, res.Warnings, qry.Close}
 }
 
+func (api *API) queryExemplars(r *http.Request) apiFuncResult {
+	start, err := parseTimeParam(r, "start", minTime)
+	if err != nil {
+		return invalidParamError(err, "start")
+	}
+	end, err := parseTimeParam(r, "end", maxTime)
+	if err != nil {
+		return invalidParamError(err, "end")
+	}
+	if end.Before(start) {
+		err := errors.New("end timestamp must not be before start timestamp")
+		return apiFuncResult{nil, &apiError{errorBadData, err}, nil, nil}
+	}
+
+	expr, err := parser.ParseExpr(r.FormValue("query"))
+	if err != nil {
+		return apiFuncResult{nil, &apiError{errorBadData, err}, nil, nil}
+	}
+
+	selectors := parser.ExtractSelectors(expr)
+	if len(selectors) < 1 {
+		return apiFuncResult{nil, nil, nil, nil}
+	}
+
+	eq, err := api.ExemplarQueryable.ExemplarQuerier(r.Context())
+	if err != nil {
+		return apiFuncResult{nil, &apiError{errorExec, err}, nil, nil}
+	}
+
+	res, err := eq.Select(timestamp.FromTime(start), timestamp.FromTime(end), selectors...)
+	if err != nil {
+		return apiFuncResult{nil, &apiError{errorExec, err}, nil, nil}
+	}
+
+	return apiFuncResult{res, nil, nil, nil}
+}
+
 func returnAPIError(err error) *apiError {
 	if err == nil {
 		return nil
@@ -700,6 +779,34 @@ func marshalPointJSONIsEmpty(ptr unsafe.Pointer) bool {
 	return false
 }
 
+// marshalExemplarJSON writes `{"labels": {...}, "value": "val", "timestamp": ts}`.
+func marshalExemplarJSON(ptr unsafe.Pointer, stream *jsoniter.Stream) {
+	p := *((*exemplar.Exemplar)(ptr))
+	stream.WriteObjectStart()
+
+	stream.WriteObjectField(`labels`)
+	lbls, err := p.Labels.MarshalJSON()
+	if err != nil {
+		stream.Error = err
+		return
+	}
+	stream.SetBuffer(append(stream.Buffer(), lbls...))
+
+	stream.WriteMore()
+	stream.WriteObjectField(`value`)
+	marshalValue(p.Value, stream)
+
+	stream.WriteMore()
+	stream.WriteObjectField(`timestamp`)
+	marshalTimestamp(p.Ts, stream)
+
+	stream.WriteObjectEnd()
+}
+
+func marshalExemplarJSONEmpty(ptr unsafe.Pointer) bool {
+	return false
+}
+
 func marshalTimestamp(t int64, stream *jsoniter.Stream) {
 	// Write out the timestamp as a float divided by 1000.
 	// This is ~3x faster than converting to a float.
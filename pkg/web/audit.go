@@ -0,0 +1,82 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/tellor-io/telliot/pkg/db"
+)
+
+// SetAudit records all API requests that change state in the audit log
+// and serves its entries only with the auth or on a loopback address
+// as they show who changed what.
+// It should be called before starting the server.
+func (self *Web) SetAudit(audit *db.Audit) {
+	self.audit = audit
+	if !self.Protected() {
+		level.Warn(self.logger).Log("msg", "the audit log isn't served because the API has no auth")
+		return
+	}
+	self.Handle("/api/v1/audit", audit, Operation{
+		Summary:  "List the audit log entries.",
+		Params:   []Param{{Name: "since", Description: "Only the entries in this duration e.g. 24h."}},
+		Response: []db.AuditEntry{},
+	})
+}
+
+// audited records the request with the remote address and the API key as the actor
+// after the handler has completed.
+func (self *Web) audited(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if self.audit == nil {
+			handler(w, r)
+			return
+		}
+		rec := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+		handler(rec, r)
+
+		actor := Actor(r)
+		details := map[string]string{"code": strconv.Itoa(rec.code)}
+		if r.URL.RawQuery != "" {
+			details["query"] = r.URL.RawQuery
+		}
+		if ua := r.UserAgent(); ua != "" {
+			details["userAgent"] = ua
+		}
+		if err := self.audit.Record(db.AuditEntry{
+			Actor:   actor,
+			Action:  "api_" + strings.ToLower(r.Method),
+			Target:  r.URL.Path,
+			Details: details,
+		}); err != nil {
+			level.Error(self.logger).Log("msg", "recording audit entry", "path", r.URL.Path, "err", err)
+		}
+	}
+}
+
+// Actor identifies who sent a request by its API key and address.
+func Actor(r *http.Request) string {
+	actor := "api:" + r.RemoteAddr
+	if key, ok := KeyFromContext(r.Context()); ok {
+		actor = "api:key:" + key.Name + "(" + key.ID + ") " + r.RemoteAddr
+	}
+	if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+		actor += " for:" + strings.TrimSpace(strings.Split(fwd, ",")[0])
+	}
+	return actor
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (self *statusRecorder) WriteHeader(code int) {
+	self.code = code
+	self.ResponseWriter.WriteHeader(code)
+}
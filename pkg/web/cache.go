@@ -0,0 +1,96 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cache keeps the last response of a source to reuse it while it is fresh by its Cache-Control max-age
+// and to revalidate it with its ETag and Last-Modified headers so that unchanged responses
+// don't count against the API quotas of the providers that support it.
+type cache struct {
+	mtx          sync.Mutex
+	body         []byte
+	etag         string
+	lastModified string
+	expires      time.Time
+}
+
+// fresh returns the cached body while it hasn't expired.
+func (self *cache) fresh(now time.Time) ([]byte, bool) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	if self.body == nil || !now.Before(self.expires) {
+		return nil, false
+	}
+	return self.body, true
+}
+
+// validate adds the conditional headers to the request.
+func (self *cache) validate(req *http.Request) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	if self.body == nil {
+		return
+	}
+	if self.etag != "" {
+		req.Header.Set("If-None-Match", self.etag)
+	}
+	if self.lastModified != "" {
+		req.Header.Set("If-Modified-Since", self.lastModified)
+	}
+}
+
+// notModified returns the cached body for a 304 response and extends its freshness.
+func (self *cache) notModified(now time.Time, h http.Header) []byte {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	self.expires = now.Add(maxAge(h))
+	return self.body
+}
+
+func (self *cache) store(now time.Time, h http.Header, body []byte) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	if noStore(h) {
+		self.body = nil
+		return
+	}
+	self.body = body
+	self.etag = h.Get("ETag")
+	self.lastModified = h.Get("Last-Modified")
+	self.expires = now.Add(maxAge(h))
+}
+
+// maxAge returns the max-age of the Cache-Control header or 0 when the response should be revalidated.
+func maxAge(h http.Header) time.Duration {
+	var age time.Duration
+	for _, d := range strings.Split(h.Get("Cache-Control"), ",") {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if d == "no-cache" || d == "must-revalidate" {
+			return 0
+		}
+		if strings.HasPrefix(d, "max-age=") {
+			s, err := strconv.Atoi(strings.TrimPrefix(d, "max-age="))
+			if err == nil && s > 0 {
+				age = time.Duration(s) * time.Second
+			}
+		}
+	}
+	return age
+}
+
+func noStore(h http.Header) bool {
+	for _, d := range strings.Split(h.Get("Cache-Control"), ",") {
+		if strings.ToLower(strings.TrimSpace(d)) == "no-store" {
+			return true
+		}
+	}
+	return false
+}
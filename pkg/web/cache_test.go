@@ -0,0 +1,63 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+func TestFetcherCacheRevalidate(t *testing.T) {
+	var requests, notModified int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	f := NewFetcher(RetryConfig{MaxAttempts: 1}, nil, true, nil)
+	for i := 0; i < 3; i++ {
+		data, err := f.Fetch(context.Background(), srv.URL)
+		testutil.Ok(t, err)
+		testutil.Equals(t, "ok", string(data))
+	}
+	testutil.Equals(t, 3, requests)
+	testutil.Equals(t, 2, notModified)
+}
+
+func TestFetcherCacheMaxAge(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Cache-Control", "public, max-age=60")
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	f := NewFetcher(RetryConfig{MaxAttempts: 1}, nil, true, nil)
+	for i := 0; i < 3; i++ {
+		data, err := f.Fetch(context.Background(), srv.URL)
+		testutil.Ok(t, err)
+		testutil.Equals(t, "ok", string(data))
+	}
+	testutil.Equals(t, 1, requests)
+
+	// Without the cache every fetch is a request.
+	f = NewFetcher(RetryConfig{MaxAttempts: 1}, nil, false, nil)
+	for i := 0; i < 2; i++ {
+		_, err := f.Fetch(context.Background(), srv.URL)
+		testutil.Ok(t, err)
+	}
+	testutil.Equals(t, 3, requests)
+}
@@ -8,55 +8,220 @@ import (
 	"crypto/tls"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/errclass"
 )
 
+// Fetch gets the url with the default retry policy.
 func Fetch(ctx context.Context, url string) ([]byte, error) {
+	return NewFetcher(DefaultRetry, nil, false, nil).Fetch(ctx, url)
+}
+
+// Fetcher gets a http source with retries using exponential backoff and jitter
+// and skips it while its circuit breaker is open.
+// A fetcher should be used for a single source as the breaker counts its consecutive failures
+// and the cache keeps its last response.
+type Fetcher struct {
+	client  *http.Client
+	cfg     RetryConfig
+	breaker *breaker
+	cache   *cache
+	auth    *SourceAuth
+	metrics *FetchMetrics
+	name    string
+}
+
+// NewFetcher creates a fetcher with the given retry policy
+// which sends the requests through the proxy when not nil.
+// With the cache enabled it honors the Cache-Control, ETag and Last-Modified headers of the responses.
+// The metrics can be nil.
+func NewFetcher(cfg RetryConfig, proxy *url.URL, withCache bool, metrics *FetchMetrics) *Fetcher {
 	tr := &http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 	}
-	client := http.Client{Transport: tr}
-	ticker := time.NewTicker(1 * time.Second)
+	if proxy != nil {
+		tr.Proxy = http.ProxyURL(proxy)
+	}
+	self := &Fetcher{
+		client:  &http.Client{Transport: tr},
+		cfg:     cfg,
+		breaker: &breaker{threshold: cfg.BreakerThreshold, cooldown: cfg.BreakerCooldown.Duration},
+		metrics: metrics,
+	}
+	if withCache {
+		self.cache = &cache{}
+	}
+	return self
+}
 
+// SetName sets the source label of the metrics of the fetcher.
+// Without a name the label is the host of the url
+// as the full url can have the API keys of the source.
+func (self *Fetcher) SetName(name string) {
+	self.name = name
+}
+
+// label returns the source label of the metrics for the url.
+func (self *Fetcher) label(rawURL string) string {
+	if self.name != "" {
+		return self.name
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "invalid"
+	}
+	return u.Host
+}
+
+// SetAuth authenticates every request of the fetcher.
+func (self *Fetcher) SetAuth(auth *SourceAuth) {
+	self.auth = auth
+}
+
+// FetchMetrics are the retry and cache counters shared by all fetchers of a component.
+type FetchMetrics struct {
+	retries      *prometheus.CounterVec
+	breakerTrips *prometheus.CounterVec
+	breakerSkips *prometheus.CounterVec
+	cacheHits    *prometheus.CounterVec
+}
+
+func NewFetchMetrics(subsystem string) *FetchMetrics {
+	return &FetchMetrics{
+		retries: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "telliot",
+			Subsystem: subsystem,
+			Name:      "retries_total",
+			Help:      "The total number of retried requests",
+		}, []string{"source"}),
+		breakerTrips: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "telliot",
+			Subsystem: subsystem,
+			Name:      "breaker_trips_total",
+			Help:      "The total number of times the circuit breaker of a source opened",
+		}, []string{"source"}),
+		breakerSkips: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "telliot",
+			Subsystem: subsystem,
+			Name:      "breaker_skips_total",
+			Help:      "The total number of fetches skipped while the circuit breaker was open",
+		}, []string{"source"}),
+		cacheHits: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "telliot",
+			Subsystem: subsystem,
+			Name:      "cache_hits_total",
+			Help:      "The total number of fetches that reused the cached response, either while fresh or after a not modified response",
+		}, []string{"source", "result"}),
+	}
+}
+
+func (self *Fetcher) Fetch(ctx context.Context, url string) ([]byte, error) {
+	if self.cache != nil {
+		if data, ok := self.cache.fresh(time.Now()); ok {
+			self.cacheHit(url, "fresh")
+			return data, nil
+		}
+	}
+	if !self.breaker.allow(time.Now()) {
+		if self.metrics != nil {
+			self.metrics.breakerSkips.WithLabelValues(self.label(url)).Inc()
+		}
+		return nil, ErrCircuitOpen
+	}
+
+	data, err := self.fetch(ctx, url)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if self.breaker.done(time.Now(), err == nil) && self.metrics != nil {
+		self.metrics.breakerTrips.WithLabelValues(self.label(url)).Inc()
+	}
+	return data, err
+}
+
+func (self *Fetcher) fetch(ctx context.Context, url string) ([]byte, error) {
 	var errFinal error
-	for i := 0; i < 5; i++ {
-		r, err := client.Get(url)
-		if err != nil {
-			errFinal = errors.Wrap(err, "fetching data")
+	for attempt := 1; attempt <= self.cfg.MaxAttempts; attempt++ {
+		if attempt > 1 {
 			select {
-			case <-ticker.C:
-				continue
+			case <-time.After(self.cfg.Backoff(attempt - 1)):
 			case <-ctx.Done():
 				return nil, ctx.Err()
 			}
+			if self.metrics != nil {
+				self.metrics.retries.WithLabelValues(self.label(url)).Inc()
+			}
 		}
 
-		data, err := ioutil.ReadAll(r.Body)
-		if err != nil {
-			errFinal = errors.Wrap(err, "read response body")
-			select {
-			case <-ticker.C:
-				continue
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			}
+		data, err := self.get(ctx, url)
+		if err == nil {
+			return data, nil
+		}
+		errFinal = err
+		if !errclass.Is(err, errclass.Retryable) {
+			break
 		}
-		r.Body.Close()
+	}
+	return nil, errFinal
+}
 
-		if r.StatusCode/100 != 2 {
-			errFinal = errors.Errorf("response status code not OK code:%v, payload:%v", r.StatusCode, string(data))
-			select {
-			case <-ticker.C:
-				continue
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			}
+// get does a single request and returns a retryable error when a failed request should be retried.
+// Client errors other than too many requests are config errors as they won't succeed on a retry.
+func (self *Fetcher) get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errclass.Wrap(errclass.Config, errors.Wrap(err, "creating request"))
+	}
+	if self.cache != nil {
+		self.cache.validate(req)
+	}
+	if self.auth != nil {
+		if err := self.auth.apply(ctx, self.client, req); err != nil {
+			return nil, errclass.Wrap(errclass.Retryable, errors.Wrap(err, "authenticating request"))
 		}
-		return data, nil
 	}
+	r, err := self.client.Do(req)
+	if err != nil {
+		return nil, errclass.Wrap(errclass.Retryable, errors.Wrap(err, "fetching data"))
+	}
+	defer r.Body.Close()
 
-	return nil, errFinal
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, errclass.Wrap(errclass.Retryable, errors.Wrap(err, "read response body"))
+	}
+
+	if r.StatusCode == http.StatusNotModified && self.cache != nil {
+		if data := self.cache.notModified(time.Now(), r.Header); data != nil {
+			self.cacheHit(url, "not_modified")
+			return data, nil
+		}
+	}
+	if r.StatusCode == http.StatusUnauthorized && self.auth != nil && self.auth.cfg.Token != nil {
+		// The token was revoked or expired early so the retry gets a new one.
+		self.auth.invalidate()
+		return nil, errclass.Wrap(errclass.Retryable, errors.Errorf("response status code not OK code:%v, payload:%v", r.StatusCode, string(data)))
+	}
+	if r.StatusCode/100 != 2 {
+		class := errclass.Retryable
+		if r.StatusCode/100 == 4 && r.StatusCode != http.StatusTooManyRequests {
+			class = errclass.Config
+		}
+		return nil, errclass.Wrap(class, errors.Errorf("response status code not OK code:%v, payload:%v", r.StatusCode, string(data)))
+	}
+	if self.cache != nil {
+		self.cache.store(time.Now(), r.Header, data)
+	}
+	return data, nil
+}
 
+func (self *Fetcher) cacheHit(url, result string) {
+	if self.metrics != nil {
+		self.metrics.cacheHits.WithLabelValues(self.label(url), result).Inc()
+	}
 }
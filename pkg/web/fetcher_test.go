@@ -0,0 +1,133 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+func TestFetcherRetry(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	cfg := RetryConfig{
+		MaxAttempts: 3,
+		BackoffMin:  format.Duration{Duration: time.Millisecond},
+		BackoffMax:  format.Duration{Duration: 2 * time.Millisecond},
+		Jitter:      0.5,
+	}
+	data, err := NewFetcher(cfg, nil, false, nil).Fetch(context.Background(), srv.URL)
+	testutil.Ok(t, err)
+	testutil.Equals(t, "ok", string(data))
+	testutil.Equals(t, 3, requests)
+}
+
+func TestFetcherNoRetryClientError(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	cfg := RetryConfig{MaxAttempts: 3, BackoffMin: format.Duration{Duration: time.Millisecond}, BackoffMax: format.Duration{Duration: time.Millisecond}}
+	_, err := NewFetcher(cfg, nil, false, nil).Fetch(context.Background(), srv.URL)
+	testutil.NotOk(t, err)
+	testutil.Equals(t, 1, requests)
+}
+
+func TestFetcherBreaker(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	cfg := RetryConfig{
+		MaxAttempts:      1,
+		BreakerThreshold: 2,
+		BreakerCooldown:  format.Duration{Duration: 50 * time.Millisecond},
+	}
+	f := NewFetcher(cfg, nil, false, nil)
+	for i := 0; i < 2; i++ {
+		_, err := f.Fetch(context.Background(), srv.URL)
+		testutil.NotOk(t, err)
+		testutil.Assert(t, !errors.Is(err, ErrCircuitOpen), "breaker opened before the threshold")
+	}
+	_, err := f.Fetch(context.Background(), srv.URL)
+	testutil.Assert(t, errors.Is(err, ErrCircuitOpen), "breaker should be open")
+	testutil.Equals(t, 2, requests)
+
+	// A single failure after the cooldown opens it again.
+	time.Sleep(60 * time.Millisecond)
+	_, err = f.Fetch(context.Background(), srv.URL)
+	testutil.Assert(t, !errors.Is(err, ErrCircuitOpen), "breaker should let a fetch through after the cooldown")
+	_, err = f.Fetch(context.Background(), srv.URL)
+	testutil.Assert(t, errors.Is(err, ErrCircuitOpen), "breaker should open again")
+	testutil.Equals(t, 3, requests)
+}
+
+func TestBackoff(t *testing.T) {
+	cfg := RetryConfig{
+		BackoffMin: format.Duration{Duration: time.Second},
+		BackoffMax: format.Duration{Duration: 5 * time.Second},
+	}
+	testutil.Equals(t, time.Second, cfg.Backoff(1))
+	testutil.Equals(t, 4*time.Second, cfg.Backoff(3))
+	testutil.Equals(t, 5*time.Second, cfg.Backoff(10))
+
+	cfg.Jitter = 0.5
+	for i := 0; i < 10; i++ {
+		b := cfg.Backoff(2)
+		testutil.Assert(t, b > time.Second && b <= 2*time.Second, "backoff with jitter out of range:%v", b)
+	}
+}
+
+// TestFetcherLabel ensures that the metrics don't have the url with its API keys.
+func TestFetcherLabel(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	testutil.Ok(t, err)
+
+	cfg := RetryConfig{MaxAttempts: 2, BackoffMin: format.Duration{Duration: time.Millisecond}, BackoffMax: format.Duration{Duration: time.Millisecond}}
+	metrics := NewFetchMetrics("test_fetcher_label")
+	_, err = NewFetcher(cfg, nil, false, metrics).Fetch(context.Background(), srv.URL+"/price?apikey=secret")
+	testutil.Ok(t, err)
+	testutil.Equals(t, float64(1), promtestutil.ToFloat64(metrics.retries.WithLabelValues(u.Host)))
+
+	requests = 0
+	f := NewFetcher(cfg, nil, false, metrics)
+	f.SetName("provider")
+	_, err = f.Fetch(context.Background(), srv.URL+"/price?apikey=secret")
+	testutil.Ok(t, err)
+	testutil.Equals(t, float64(1), promtestutil.ToFloat64(metrics.retries.WithLabelValues("provider")))
+}
@@ -0,0 +1,70 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/tellor-io/telliot/pkg/health"
+)
+
+// healthCheckTimeout limits how long a single probe can take.
+const healthCheckTimeout = 10 * time.Second
+
+// AddHealth adds a reporter to the /healthz and /readyz endpoints.
+// It should be called before starting the server.
+func (self *Web) AddHealth(reporter health.Reporter) {
+	self.healthReporters = append(self.healthReporters, reporter)
+}
+
+// healthReport is the response of the health probes.
+type healthReport struct {
+	Status     string          `json:"status"`
+	Components []health.Status `json:"components"`
+}
+
+// healthz is a liveness probe.
+// It responds with 503 when a critical component is degraded.
+func (self *Web) healthz(w http.ResponseWriter, r *http.Request) {
+	self.serveHealth(w, r, func(s health.Status) bool { return s.Healthy })
+}
+
+// readyz is a readiness probe.
+// It responds with 503 when a critical component is not ready.
+func (self *Web) readyz(w http.ResponseWriter, r *http.Request) {
+	self.serveHealth(w, r, func(s health.Status) bool { return s.Ready })
+}
+
+func (self *Web) serveHealth(w http.ResponseWriter, r *http.Request, ok func(health.Status) bool) {
+	ctx, cncl := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cncl()
+
+	statuses := []health.Status{}
+	for _, reporter := range self.healthReporters {
+		statuses = append(statuses, reporter.Health(ctx)...)
+	}
+
+	status := "ok"
+	code := http.StatusOK
+	for _, s := range statuses {
+		if s.Critical && !ok(s) {
+			status = "degraded"
+			code = http.StatusServiceUnavailable
+			break
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(healthReport{
+		Status:     status,
+		Components: statuses,
+	}); err != nil {
+		level.Error(self.logger).Log("msg", "encoding health response", "err", err)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// response is the format of all JSON API responses.
+type response struct {
+	Status string          `json:"status"`
+	Data   json.RawMessage `json:"data,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// WriteJSON writes the data or the error in the format of the JSON API.
+func WriteJSON(w http.ResponseWriter, code int, data interface{}, err error) error {
+	resp := struct {
+		Status string      `json:"status"`
+		Data   interface{} `json:"data,omitempty"`
+		Error  string      `json:"error,omitempty"`
+	}{
+		Status: "success",
+		Data:   data,
+	}
+	if err != nil {
+		resp.Status = "error"
+		resp.Error = err.Error()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	return json.NewEncoder(w).Encode(resp)
+}
+
+// StatusError is returned when another instance responds with an error.
+type StatusError struct {
+	Status  string
+	Code    int
+	Message string
+}
+
+func (self *StatusError) Error() string {
+	return fmt.Sprintf("response status:%v code:%v error:%v", self.Status, self.Code, self.Message)
+}
+
+// GetJSON calls a JSON API of another instance and decodes the response data into v.
+func GetJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	return doJSON(ctx, client, http.MethodGet, url, nil, v)
+}
+
+// PostJSON sends the body to a JSON API of another instance and decodes the response data into v.
+// A nil v ignores the response data.
+func PostJSON(ctx context.Context, client *http.Client, url string, body, v interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return errors.Wrap(err, "marshal request body")
+	}
+	return doJSON(ctx, client, http.MethodPost, url, bytes.NewReader(data), v)
+}
+
+func doJSON(ctx context.Context, client *http.Client, method, url string, body io.Reader, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return errors.Wrap(err, "create request")
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	SetAPIKey(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "send request")
+	}
+	defer resp.Body.Close()
+
+	var r response
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return errors.Wrapf(err, "decode response code:%v", resp.StatusCode)
+	}
+	if r.Status != "success" {
+		return &StatusError{Status: r.Status, Code: resp.StatusCode, Message: r.Error}
+	}
+	if v == nil {
+		return nil
+	}
+	return errors.Wrap(json.Unmarshal(r.Data, v), "decode response data")
+}
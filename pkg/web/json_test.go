@@ -0,0 +1,81 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+type testBody struct {
+	Name  string `json:"name"`
+	Value int    `json:"value"`
+}
+
+// TestJSON ensures that the data and the errors written by a handler
+// are decoded by the calls of another instance together with its API key.
+func TestJSON(t *testing.T) {
+	testutil.Ok(t, os.Setenv(APIKeyEnvName, "secret"))
+	defer os.Unsetenv(APIKeyEnvName)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			testutil.Ok(t, WriteJSON(w, http.StatusUnauthorized, nil, errors.New("missing API key")))
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			testutil.Ok(t, WriteJSON(w, http.StatusOK, testBody{Name: r.URL.Query().Get("name"), Value: 1}, nil))
+		case http.MethodPost:
+			testutil.Equals(t, "application/json", r.Header.Get("Content-Type"))
+			var body testBody
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				testutil.Ok(t, WriteJSON(w, http.StatusBadRequest, nil, err))
+				return
+			}
+			if body.Value < 0 {
+				testutil.Ok(t, WriteJSON(w, http.StatusBadRequest, nil, errors.New("negative value")))
+				return
+			}
+			body.Value++
+			testutil.Ok(t, WriteJSON(w, http.StatusOK, body, nil))
+		}
+	}))
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	var got testBody
+	testutil.Ok(t, GetJSON(ctx, client, srv.URL+"?name=a", &got))
+	testutil.Equals(t, testBody{Name: "a", Value: 1}, got)
+
+	testutil.Ok(t, PostJSON(ctx, client, srv.URL, testBody{Name: "b", Value: 1}, &got))
+	testutil.Equals(t, testBody{Name: "b", Value: 2}, got)
+
+	// A nil v ignores the data.
+	testutil.Ok(t, PostJSON(ctx, client, srv.URL, testBody{Name: "c"}, nil))
+
+	err := PostJSON(ctx, client, srv.URL, testBody{Value: -1}, &got)
+	var statusErr *StatusError
+	testutil.Assert(t, errors.As(err, &statusErr), "unexpected error:%v", err)
+	testutil.Equals(t, http.StatusBadRequest, statusErr.Code)
+	testutil.Equals(t, "negative value", statusErr.Message)
+
+	os.Unsetenv(APIKeyEnvName)
+	err = GetJSON(ctx, client, srv.URL, &got)
+	testutil.Assert(t, errors.As(err, &statusErr), "unexpected error:%v", err)
+	testutil.Equals(t, http.StatusUnauthorized, statusErr.Code)
+
+	// Not the format of the JSON API.
+	plain := httptest.NewServer(http.NotFoundHandler())
+	defer plain.Close()
+	testutil.NotOk(t, GetJSON(ctx, client, plain.URL, &got))
+}
@@ -0,0 +1,414 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tellor-io/telliot/pkg/fsutil"
+	"golang.org/x/time/rate"
+)
+
+// The scopes of the API keys, each scope includes the lower ones.
+const (
+	// ScopeRead allows the GET requests and the queries.
+	ScopeRead = "read"
+	// ScopeControl also allows the requests that change state e.g. bumping a transaction.
+	ScopeControl = "control"
+	// ScopeAdmin also allows managing the keys, the runtime settings, the debug endpoints and the approvals.
+	ScopeAdmin = "admin"
+)
+
+var scopeLevels = map[string]int{ScopeRead: 1, ScopeControl: 2, ScopeAdmin: 3}
+
+// APIKeyEnvName is the env variable with the API key
+// sent by the CLI and the remote components to other instances.
+const APIKeyEnvName = "TELLIOT_API_KEY"
+
+// AdminKeyEnvName is the env variable with a key that always has the admin scope
+// to issue the other keys. It can't be revoked.
+const AdminKeyEnvName = "TELLIOT_API_ADMIN_KEY"
+
+// adminKeyID is the ID of the key in AdminKeyEnvName.
+const adminKeyID = "admin"
+
+// The paths that are always served without a key so that the orchestrators can probe the instance
+// and the external tooling can read the API document.
+var publicPaths = map[string]bool{"/healthz": true, "/readyz": true, OpenAPIPath: true}
+
+// The POST endpoints of the query API which only read.
+var readPosts = map[string]bool{
+	"/api/v1/query":           true,
+	"/api/v1/query_range":     true,
+	"/api/v1/query_exemplars": true,
+	"/api/v1/labels":          true,
+	"/api/v1/series":          true,
+	"/api/v1/read":            true,
+}
+
+type AuthConfig struct {
+	Enabled bool
+	// KeysPath is the file with the issued keys.
+	// Only the hashes of the keys are stored.
+	KeysPath string
+	// RateLimit is the default max requests per second of a key, 0 for no limit.
+	RateLimit float64
+	// Burst is the max requests of a key at once.
+	Burst int
+}
+
+// APIKey is an issued key without the key itself.
+type APIKey struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Scope string `json:"scope"`
+	// RateLimit overrides the default rate limit when set.
+	RateLimit float64    `json:"rateLimit,omitempty"`
+	Created   time.Time  `json:"created"`
+	Revoked   *time.Time `json:"revoked,omitempty"`
+	hash      string
+}
+
+// storedKey is the record of a key in the keys file.
+type storedKey struct {
+	APIKey
+	Hash string `json:"hash"`
+}
+
+// IssueRequest is the body of the request to issue a key.
+type IssueRequest struct {
+	Name      string  `json:"name"`
+	Scope     string  `json:"scope"`
+	RateLimit float64 `json:"rateLimit,omitempty"`
+}
+
+// IssueResponse has the new key which is shown only once.
+type IssueResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type keyCtx struct{}
+
+// KeyFromContext returns the key of an authenticated request.
+func KeyFromContext(ctx context.Context) (APIKey, bool) {
+	key, ok := ctx.Value(keyCtx{}).(APIKey)
+	return key, ok
+}
+
+// Keys authenticates the API requests with the issued keys
+// and limits the request rate of each key
+// so that a single instance can be shared by several operators.
+// It is safe for concurrent use.
+type Keys struct {
+	logger log.Logger
+	cfg    AuthConfig
+
+	mtx      sync.Mutex
+	keys     []*APIKey
+	limiters map[string]*rate.Limiter
+
+	requests *prometheus.CounterVec
+	rejects  *prometheus.CounterVec
+}
+
+// NewKeys loads the issued keys. The admin key is optional.
+func NewKeys(logger log.Logger, cfg AuthConfig, adminKey string) (*Keys, error) {
+	self := &Keys{
+		logger:   log.With(logger, "component", "apiKeys"),
+		cfg:      cfg,
+		limiters: make(map[string]*rate.Limiter),
+		requests: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "key_requests_total",
+			Help:      "The total number of the API requests per key",
+		}, []string{"key"}),
+		rejects: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "telliot",
+			Subsystem: ComponentName,
+			Name:      "key_rejects_total",
+			Help:      "The total number of the API requests rejected as unauthorized, forbidden or over the rate limit",
+		}, []string{"reason"}),
+	}
+	if adminKey != "" {
+		self.keys = append(self.keys, &APIKey{ID: adminKeyID, Name: adminKeyID, Scope: ScopeAdmin, hash: hash(adminKey)})
+	}
+
+	keys, err := loadKeys(cfg.KeysPath)
+	if err != nil {
+		return nil, err
+	}
+	self.keys = append(self.keys, keys...)
+	return self, nil
+}
+
+// loadKeys reads the issued keys from the keys file.
+func loadKeys(path string) ([]*APIKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "reading the keys file")
+	}
+	var stored []storedKey
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, errors.Wrap(err, "decoding the keys file")
+	}
+	var keys []*APIKey
+	for _, s := range stored {
+		key := s.APIKey
+		key.hash = s.Hash
+		keys = append(keys, &key)
+	}
+	return keys, nil
+}
+
+// Issue creates a key and returns it with its record.
+// The key is never stored so it can't be shown again.
+func (self *Keys) Issue(name, scope string, rateLimit float64) (string, APIKey, error) {
+	if _, ok := scopeLevels[scope]; !ok {
+		return "", APIKey{}, errors.Errorf("invalid scope:%v", scope)
+	}
+	if name == "" {
+		return "", APIKey{}, errors.New("the key needs a name")
+	}
+	if rateLimit < 0 {
+		return "", APIKey{}, errors.Errorf("invalid rate limit:%v", rateLimit)
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", APIKey{}, errors.Wrap(err, "generating the key")
+	}
+	secret := hex.EncodeToString(b)
+
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	key := &APIKey{
+		ID:        hash(secret)[:12],
+		Name:      name,
+		Scope:     scope,
+		RateLimit: rateLimit,
+		Created:   time.Now(),
+		hash:      hash(secret),
+	}
+	self.keys = append(self.keys, key)
+	if err := self.save(); err != nil {
+		self.keys = self.keys[:len(self.keys)-1]
+		return "", APIKey{}, err
+	}
+	level.Info(self.logger).Log("msg", "issued key", "id", key.ID, "name", name, "scope", scope)
+	return secret, *key, nil
+}
+
+// Revoke disables a key immediately.
+func (self *Keys) Revoke(id string) (APIKey, error) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	if id == adminKeyID {
+		return APIKey{}, errors.Errorf("the admin key can be changed only in %v", AdminKeyEnvName)
+	}
+	for _, key := range self.keys {
+		if key.ID != id {
+			continue
+		}
+		if key.Revoked != nil {
+			return APIKey{}, errors.Errorf("key:%v is already revoked", id)
+		}
+		now := time.Now()
+		key.Revoked = &now
+		if err := self.save(); err != nil {
+			key.Revoked = nil
+			return APIKey{}, err
+		}
+		delete(self.limiters, id)
+		level.Info(self.logger).Log("msg", "revoked key", "id", id, "name", key.Name)
+		return *key, nil
+	}
+	return APIKey{}, errors.Errorf("key:%v not found", id)
+}
+
+// List returns the issued keys without the admin key.
+func (self *Keys) List() []APIKey {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	keys := []APIKey{}
+	for _, key := range self.keys {
+		if key.ID != adminKeyID {
+			keys = append(keys, *key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Created.Before(keys[j].Created) })
+	return keys
+}
+
+// save writes all keys to the keys file atomically.
+// It should be called with the lock held.
+func (self *Keys) save() error {
+	stored := []storedKey{}
+	for _, key := range self.keys {
+		if key.ID != adminKeyID {
+			stored = append(stored, storedKey{APIKey: *key, Hash: key.hash})
+		}
+	}
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "encoding the keys")
+	}
+	return errors.Wrap(fsutil.WriteAtomic(self.cfg.KeysPath, data, 0600), "writing the keys file")
+}
+
+// authenticate returns the key of the request or the status code to reject it with.
+func (self *Keys) authenticate(r *http.Request) (APIKey, int, error) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return APIKey{}, http.StatusUnauthorized, errors.New("missing API key")
+	}
+	h := hash(strings.TrimSpace(strings.TrimPrefix(auth, "Bearer ")))
+
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	var key *APIKey
+	for _, k := range self.keys {
+		if subtle.ConstantTimeCompare([]byte(k.hash), []byte(h)) == 1 {
+			key = k
+			break
+		}
+	}
+	if key == nil || key.Revoked != nil {
+		return APIKey{}, http.StatusUnauthorized, errors.New("invalid API key")
+	}
+	if scopeLevels[key.Scope] < scopeLevels[requiredScope(r)] {
+		return *key, http.StatusForbidden, errors.Errorf("the request needs the %v scope", requiredScope(r))
+	}
+
+	limiter, ok := self.limiters[key.ID]
+	if !ok {
+		limit := rate.Limit(self.cfg.RateLimit)
+		if key.RateLimit > 0 {
+			limit = rate.Limit(key.RateLimit)
+		}
+		if limit == 0 {
+			limit = rate.Inf
+		}
+		burst := self.cfg.Burst
+		if burst < 1 {
+			burst = 1
+		}
+		limiter = rate.NewLimiter(limit, burst)
+		self.limiters[key.ID] = limiter
+	}
+	if !limiter.Allow() {
+		return *key, http.StatusTooManyRequests, errors.New("rate limit exceeded")
+	}
+	return *key, http.StatusOK, nil
+}
+
+// Wrap rejects the requests without a valid key with the needed scope
+// and the requests over the rate limit of the key.
+func (self *Keys) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if publicPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		key, code, err := self.authenticate(r)
+		if err != nil {
+			switch code {
+			case http.StatusUnauthorized:
+				self.rejects.With(prometheus.Labels{"reason": "unauthorized"}).Inc()
+			case http.StatusForbidden:
+				self.rejects.With(prometheus.Labels{"reason": "forbidden"}).Inc()
+			default:
+				self.rejects.With(prometheus.Labels{"reason": "rate_limited"}).Inc()
+			}
+			level.Debug(self.logger).Log("msg", "rejected request", "path", r.URL.Path, "key", key.ID, "code", code, "err", err)
+			_ = WriteJSON(w, code, nil, err)
+			return
+		}
+		self.requests.With(prometheus.Labels{"key": key.Name}).Inc()
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), keyCtx{}, key)))
+	})
+}
+
+func (self *Keys) serveList(w http.ResponseWriter, r *http.Request) {
+	if err := WriteJSON(w, http.StatusOK, self.List(), nil); err != nil {
+		level.Error(self.logger).Log("msg", "encoding keys response", "err", err)
+	}
+}
+
+func (self *Keys) serveIssue(w http.ResponseWriter, r *http.Request) {
+	var resp *IssueResponse
+	code, err := func() (int, error) {
+		var req IssueRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return http.StatusBadRequest, errors.Wrap(err, "decoding the request")
+		}
+		secret, key, err := self.Issue(req.Name, req.Scope, req.RateLimit)
+		if err != nil {
+			return http.StatusBadRequest, err
+		}
+		resp = &IssueResponse{APIKey: key, Key: secret}
+		return http.StatusOK, nil
+	}()
+	if err := WriteJSON(w, code, resp, err); err != nil {
+		level.Error(self.logger).Log("msg", "encoding issued key response", "err", err)
+	}
+}
+
+func (self *Keys) serveRevoke(w http.ResponseWriter, r *http.Request) {
+	key, err := self.Revoke(r.URL.Query().Get("id"))
+	code := http.StatusOK
+	if err != nil {
+		code = http.StatusBadRequest
+	}
+	if err := WriteJSON(w, code, key, err); err != nil {
+		level.Error(self.logger).Log("msg", "encoding revoked key response", "err", err)
+	}
+}
+
+// requiredScope of a request by its path and method.
+func requiredScope(r *http.Request) string {
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/api/v1/keys"), strings.HasPrefix(r.URL.Path, "/api/v1/runtime"), strings.HasPrefix(r.URL.Path, "/debug/"):
+		return ScopeAdmin
+	// The approvals release the spends held for the approvers.
+	case r.URL.Path == "/api/v1/approvals/approve" && r.Method == http.MethodPost:
+		return ScopeAdmin
+	case r.Method == http.MethodGet, r.Method == http.MethodHead, readPosts[r.URL.Path]:
+		return ScopeRead
+	default:
+		return ScopeControl
+	}
+}
+
+// SetAPIKey adds the key from APIKeyEnvName to a request to another instance.
+func SetAPIKey(r *http.Request) {
+	if key := os.Getenv(APIKeyEnvName); key != "" {
+		r.Header.Set("Authorization", "Bearer "+key)
+	}
+}
+
+func hash(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:])
+}
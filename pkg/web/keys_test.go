@@ -0,0 +1,88 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package web
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// TestKeys ensures that the requests need a key with the scope of the endpoint,
+// that the keys are rate limited and that a revoked key is rejected immediately.
+func TestKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keys")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	cfg := AuthConfig{Enabled: true, KeysPath: filepath.Join(dir, "apikeys.json"), RateLimit: 0.1, Burst: 2}
+	keys, err := NewKeys(logging.NewLogger(), cfg, "adminSecret")
+	testutil.Ok(t, err)
+	handler := keys.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, ok := KeyFromContext(r.Context())
+		testutil.Assert(t, ok || publicPaths[r.URL.Path], "authenticated request should have the key")
+		w.Write([]byte(key.Name))
+	}))
+	do := func(method, path, key string) int {
+		req := httptest.NewRequest(method, path, nil)
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	_, _, err = keys.Issue("ops", "root", 0)
+	testutil.NotOk(t, err)
+	reader, readKey, err := keys.Issue("dashboards", ScopeRead, 0)
+	testutil.Ok(t, err)
+	control, _, err := keys.Issue("ops", ScopeControl, 100)
+	testutil.Ok(t, err)
+
+	testutil.Equals(t, http.StatusOK, do(http.MethodGet, "/healthz", ""))
+	testutil.Equals(t, http.StatusUnauthorized, do(http.MethodGet, "/metrics", ""))
+	testutil.Equals(t, http.StatusUnauthorized, do(http.MethodGet, "/metrics", "invalid"))
+
+	testutil.Equals(t, http.StatusOK, do(http.MethodGet, "/api/v1/races", reader))
+	testutil.Equals(t, http.StatusOK, do(http.MethodPost, "/api/v1/query", reader))
+	// Over the burst.
+	testutil.Equals(t, http.StatusTooManyRequests, do(http.MethodGet, "/api/v1/races", reader))
+
+	testutil.Equals(t, http.StatusForbidden, do(http.MethodPost, "/api/v1/transactor/pending/bump", reader))
+	testutil.Equals(t, http.StatusOK, do(http.MethodPost, "/api/v1/transactor/pending/bump", control))
+	testutil.Equals(t, http.StatusForbidden, do(http.MethodPost, "/api/v1/keys", control))
+	testutil.Equals(t, http.StatusForbidden, do(http.MethodGet, "/debug/pprof/", control))
+	testutil.Equals(t, http.StatusForbidden, do(http.MethodPut, "/api/v1/runtime", control))
+	testutil.Equals(t, http.StatusForbidden, do(http.MethodPost, "/api/v1/approvals/approve", control))
+	testutil.Equals(t, http.StatusOK, do(http.MethodPost, "/api/v1/keys", "adminSecret"))
+
+	_, err = keys.Revoke(adminKeyID)
+	testutil.NotOk(t, err)
+	_, err = keys.Revoke(readKey.ID)
+	testutil.Ok(t, err)
+	_, err = keys.Revoke(readKey.ID)
+	testutil.NotOk(t, err)
+	testutil.Equals(t, http.StatusUnauthorized, do(http.MethodGet, "/api/v1/races", reader))
+
+	// Only the hashes are stored and the revocation is persisted.
+	data, err := ioutil.ReadFile(cfg.KeysPath)
+	testutil.Ok(t, err)
+	testutil.Assert(t, !strings.Contains(string(data), reader) && !strings.Contains(string(data), control), "the keys file shouldn't have the keys")
+	loaded, err := loadKeys(cfg.KeysPath)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 2, len(loaded))
+	testutil.Equals(t, readKey.ID, loaded[0].ID)
+	testutil.Assert(t, loaded[0].Revoked != nil, "the revocation should be persisted")
+	testutil.Equals(t, hash(control), loaded[1].hash)
+
+	testutil.Equals(t, 2, len(keys.List()))
+}
@@ -0,0 +1,47 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/tellor-io/telliot/pkg/logging"
+)
+
+// logLevels lists the current log level of all components.
+func (self *Web) logLevels(w http.ResponseWriter, r *http.Request) {
+	self.writeLogLevels(w, http.StatusOK, "success", "")
+}
+
+// setLogLevel changes the log level of a single component without a restart.
+// For example: curl -X PUT 'localhost:9090/api/v1/loglevel?component=disputeTracker&level=debug'
+// An empty level restores the level from the config.
+func (self *Web) setLogLevel(w http.ResponseWriter, r *http.Request) {
+	component := r.URL.Query().Get("component")
+	lvl := r.URL.Query().Get("level")
+	if err := logging.SetLevel(component, lvl); err != nil {
+		self.writeLogLevels(w, http.StatusBadRequest, "error", err.Error())
+		return
+	}
+	level.Info(self.logger).Log("msg", "log level changed", "name", component, "level", lvl)
+	self.writeLogLevels(w, http.StatusOK, "success", "")
+}
+
+func (self *Web) writeLogLevels(w http.ResponseWriter, code int, status, errMsg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(struct {
+		Status string                   `json:"status"`
+		Data   []logging.ComponentLevel `json:"data,omitempty"`
+		Error  string                   `json:"error,omitempty"`
+	}{
+		Status: status,
+		Data:   logging.Levels(),
+		Error:  errMsg,
+	}); err != nil {
+		level.Error(self.logger).Log("msg", "encoding log levels response", "err", err)
+	}
+}
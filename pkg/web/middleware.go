@@ -0,0 +1,206 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package web
+
+import (
+	"compress/gzip"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// basePath serves the handler at the prefix so that a reverse proxy can expose the API at a sub-path.
+// The requests without the prefix are served as well for the proxies that strip it.
+func basePath(prefix string, next http.Handler) http.Handler {
+	prefix = "/" + strings.Trim(prefix, "/")
+	if prefix == "/" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == prefix {
+			http.Redirect(w, r, prefix+"/", http.StatusMovedPermanently)
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, prefix+"/") {
+			r2 := r.Clone(r.Context())
+			r2.URL.Path = strings.TrimPrefix(r.URL.Path, prefix)
+			r2.URL.RawPath = ""
+			r = r2
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// parseProxies parses the addresses and CIDRs of the trusted proxies.
+func parseProxies(proxies []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, p := range proxies {
+		if !strings.Contains(p, "/") {
+			ip := net.ParseIP(p)
+			if ip == nil {
+				return nil, errors.Errorf("invalid trusted proxy:%v", p)
+			}
+			bits := 32
+			if ip.To4() == nil {
+				bits = 128
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid trusted proxy:%v", p)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// forwardedFor sets the remote address of the requests from the trusted proxies
+// to the client address in the X-Forwarded-For header,
+// which is the last address that isn't a trusted proxy.
+// The header of the other requests is ignored as the client can set it to anything.
+func forwardedFor(trusted []*net.IPNet, next http.Handler) http.Handler {
+	if len(trusted) == 0 {
+		return next
+	}
+	isTrusted := func(addr string) bool {
+		ip := net.ParseIP(strings.TrimSpace(addr))
+		if ip == nil {
+			return false
+		}
+		for _, n := range trusted {
+			if n.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		fwd := r.Header.Values("X-Forwarded-For")
+		if !isTrusted(host) || len(fwd) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		addrs := strings.Split(strings.Join(fwd, ","), ",")
+		client := ""
+		for i := len(addrs) - 1; i >= 0; i-- {
+			client = strings.TrimSpace(addrs[i])
+			if !isTrusted(client) {
+				break
+			}
+		}
+		if net.ParseIP(client) != nil {
+			r2 := r.Clone(r.Context())
+			r2.RemoteAddr = net.JoinHostPort(client, "0")
+			r = r2
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// cors allows the browsers on the origins to call the API.
+// The preflight requests are answered without calling the handler
+// as they never have the API key.
+func cors(origins []string, next http.Handler) http.Handler {
+	if len(origins) == 0 {
+		return next
+	}
+	allowed := make(map[string]bool)
+	for _, o := range origins {
+		allowed[strings.TrimSuffix(o, "/")] = true
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		w.Header().Add("Vary", "Origin")
+		if origin == "" || !(allowed["*"] || allowed[origin]) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+			w.Header().Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+var gzipWriters = sync.Pool{New: func() interface{} { return gzip.NewWriter(nil) }}
+
+// compress gzips the responses for the clients that accept it.
+// The Accept-Encoding header is removed so that the handlers
+// which compress on their own don't compress twice.
+func compress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		r.Header.Del("Accept-Encoding")
+		w.Header().Add("Vary", "Accept-Encoding")
+		gw := &gzipWriter{ResponseWriter: w}
+		defer gw.close()
+		next.ServeHTTP(gw, r)
+	})
+}
+
+// gzipWriter compresses the body once the handler writes the header
+// unless the response has no body or is already encoded.
+type gzipWriter struct {
+	http.ResponseWriter
+	gz          *gzip.Writer
+	wroteHeader bool
+}
+
+func (self *gzipWriter) WriteHeader(code int) {
+	if self.wroteHeader {
+		return
+	}
+	self.wroteHeader = true
+	if code != http.StatusNoContent && code != http.StatusNotModified && self.Header().Get("Content-Encoding") == "" {
+		self.Header().Set("Content-Encoding", "gzip")
+		self.Header().Del("Content-Length")
+		self.gz = gzipWriters.Get().(*gzip.Writer)
+		self.gz.Reset(self.ResponseWriter)
+	}
+	self.ResponseWriter.WriteHeader(code)
+}
+
+func (self *gzipWriter) Write(b []byte) (int, error) {
+	if !self.wroteHeader {
+		self.WriteHeader(http.StatusOK)
+	}
+	if self.gz == nil {
+		return self.ResponseWriter.Write(b)
+	}
+	return self.gz.Write(b)
+}
+
+func (self *gzipWriter) Flush() {
+	if self.gz != nil {
+		self.gz.Flush()
+	}
+	if f, ok := self.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (self *gzipWriter) close() {
+	if self.gz == nil {
+		return
+	}
+	self.gz.Close()
+	gzipWriters.Put(self.gz)
+}
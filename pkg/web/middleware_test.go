@@ -0,0 +1,125 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package web
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// TestBasePath ensures that the paths with and without the prefix are served.
+func TestBasePath(t *testing.T) {
+	var path string
+	handler := basePath("/telliot/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+	}))
+	for req, exp := range map[string]string{
+		"/telliot/api/v1/races": "/api/v1/races",
+		"/api/v1/races":         "/api/v1/races",
+		"/telliotx/metrics":     "/telliotx/metrics",
+	} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, req, nil))
+		testutil.Equals(t, exp, path)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/telliot", nil))
+	testutil.Equals(t, http.StatusMovedPermanently, rec.Code)
+	testutil.Equals(t, "/telliot/", rec.Header().Get("Location"))
+}
+
+// TestForwardedFor ensures that the client address is taken
+// only from the header set by the trusted proxies.
+func TestForwardedFor(t *testing.T) {
+	trusted, err := parseProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	testutil.Ok(t, err)
+	_, err = parseProxies([]string{"proxy"})
+	testutil.NotOk(t, err)
+
+	var remote string
+	handler := forwardedFor(trusted, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remote = r.RemoteAddr
+	}))
+	for _, c := range []struct {
+		remote, fwd, exp string
+	}{
+		{"10.0.0.2:1234", "1.1.1.1, 2.2.2.2, 10.0.0.3", "2.2.2.2:0"},
+		{"192.168.1.1:1234", "2.2.2.2", "2.2.2.2:0"},
+		// Untrusted proxies can't spoof the address.
+		{"3.3.3.3:1234", "2.2.2.2", "3.3.3.3:1234"},
+		{"10.0.0.2:1234", "", "10.0.0.2:1234"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = c.remote
+		if c.fwd != "" {
+			req.Header.Set("X-Forwarded-For", c.fwd)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		testutil.Equals(t, c.exp, remote)
+	}
+}
+
+// TestCORS ensures that the preflight requests of the allowed origins are answered
+// without calling the handler.
+func TestCORS(t *testing.T) {
+	called := false
+	handler := cors([]string{"https://dash.example/"}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/v1/races", nil)
+	req.Header.Set("Origin", "https://dash.example")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	testutil.Equals(t, http.StatusNoContent, rec.Code)
+	testutil.Equals(t, "https://dash.example", rec.Header().Get("Access-Control-Allow-Origin"))
+	testutil.Assert(t, !called, "the preflight shouldn't reach the handler")
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/races", nil)
+	req.Header.Set("Origin", "https://other.example")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	testutil.Equals(t, "", rec.Header().Get("Access-Control-Allow-Origin"))
+	testutil.Assert(t, called, "the request should reach the handler")
+}
+
+// TestCompress ensures that the responses are compressed once
+// and that the responses without a body are not.
+func TestCompress(t *testing.T) {
+	handler := compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		testutil.Equals(t, "", r.Header.Get("Accept-Encoding"))
+		if r.URL.Path == "/empty" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Write([]byte("telliot"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	testutil.Equals(t, "gzip", rec.Header().Get("Content-Encoding"))
+	gz, err := gzip.NewReader(rec.Body)
+	testutil.Ok(t, err)
+	body, err := ioutil.ReadAll(gz)
+	testutil.Ok(t, err)
+	testutil.Equals(t, "telliot", string(body))
+
+	req = httptest.NewRequest(http.MethodGet, "/empty", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	testutil.Equals(t, "", rec.Header().Get("Content-Encoding"))
+	testutil.Equals(t, 0, rec.Body.Len())
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	testutil.Equals(t, "telliot", rec.Body.String())
+}
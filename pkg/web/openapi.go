@@ -0,0 +1,270 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package web
+
+import (
+	"encoding"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/tellor-io/telliot/pkg/format"
+)
+
+// OpenAPIPath serves the OpenAPI document of all registered endpoints.
+const OpenAPIPath = "/openapi.json"
+
+// Operation describes an endpoint in the OpenAPI document.
+type Operation struct {
+	Summary string
+	// Params are the query parameters.
+	Params []Param
+	// Request is a value of the request body type, nil when the request has no body.
+	Request interface{}
+	// Response is a value of the response data type, nil when the response has no data.
+	Response interface{}
+	// Raw responses are not in the status envelope of the JSON API.
+	Raw bool
+}
+
+// Param describes a query parameter of an operation.
+type Param struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+// document records the operation of a registered endpoint.
+func (self *Web) document(method, path string, op Operation) {
+	if self.ops[path] == nil {
+		self.ops[path] = make(map[string]Operation)
+	}
+	self.ops[path][strings.ToLower(method)] = op
+}
+
+// OpenAPI generates the OpenAPI document from the registered operations.
+// The schemas are built from the Go types of the requests and responses
+// so the document can't get out of sync with the handlers.
+func (self *Web) OpenAPI() map[string]interface{} {
+	defs := make(map[string]interface{})
+	paths := make(map[string]interface{})
+	for p, methods := range self.ops {
+		item := make(map[string]interface{})
+		for method, op := range methods {
+			item[method] = operationSpec(defs, op)
+		}
+		paths[p] = item
+	}
+
+	server := "/" + strings.Trim(self.cfg.BasePath, "/")
+	components := map[string]interface{}{"schemas": defs}
+	doc := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "Telliot API",
+			"version": "v1",
+		},
+		"servers":    []interface{}{map[string]interface{}{"url": server}},
+		"paths":      paths,
+		"components": components,
+	}
+	if self.keys != nil {
+		components["securitySchemes"] = map[string]interface{}{
+			"apiKey": map[string]interface{}{"type": "http", "scheme": "bearer"},
+		}
+		doc["security"] = []interface{}{map[string]interface{}{"apiKey": []string{}}}
+	}
+	return doc
+}
+
+func (self *Web) serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(self.OpenAPI()); err != nil {
+		level.Error(self.logger).Log("msg", "encoding openapi response", "err", err)
+	}
+}
+
+func operationSpec(defs map[string]interface{}, op Operation) map[string]interface{} {
+	spec := map[string]interface{}{"summary": op.Summary}
+
+	if len(op.Params) > 0 {
+		var params []interface{}
+		for _, p := range op.Params {
+			params = append(params, map[string]interface{}{
+				"name":        p.Name,
+				"in":          "query",
+				"description": p.Description,
+				"required":    p.Required,
+				"schema":      map[string]interface{}{"type": "string"},
+			})
+		}
+		spec["parameters"] = params
+	}
+
+	if op.Request != nil {
+		spec["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  jsonContent(schemaOf(defs, reflect.TypeOf(op.Request))),
+		}
+	}
+
+	var data map[string]interface{}
+	if op.Response != nil {
+		data = schemaOf(defs, reflect.TypeOf(op.Response))
+	}
+	if op.Raw {
+		if data == nil {
+			data = map[string]interface{}{"type": "string"}
+		}
+		spec["responses"] = map[string]interface{}{
+			"default": map[string]interface{}{"description": "The response.", "content": jsonContent(data)},
+		}
+		return spec
+	}
+	spec["responses"] = map[string]interface{}{
+		"200":     map[string]interface{}{"description": "Success.", "content": jsonContent(envelope(data))},
+		"default": map[string]interface{}{"description": "Error.", "content": jsonContent(envelope(nil))},
+	}
+	return spec
+}
+
+func jsonContent(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+}
+
+// envelope is the schema of the status envelope written by WriteJSON.
+func envelope(data map[string]interface{}) map[string]interface{} {
+	props := map[string]interface{}{
+		"status": map[string]interface{}{"type": "string", "enum": []string{"success", "error"}},
+		"error":  map[string]interface{}{"type": "string"},
+	}
+	if data != nil {
+		props["data"] = data
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": props,
+		"required":   []string{"status"},
+	}
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	bigIntType        = reflect.TypeOf(big.Int{})
+	durationType      = reflect.TypeOf(format.Duration{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// schemaOf returns the JSON schema of the type as encoded by encoding/json.
+// The named structs are added to the defs and referenced.
+func schemaOf(defs map[string]interface{}, t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == bigIntType:
+		return map[string]interface{}{"type": "integer"}
+	case t == durationType:
+		return map[string]interface{}{"type": "string", "example": "1m30s"}
+	case implements(t, jsonMarshalerType):
+		// Custom encoding without a known schema.
+		return map[string]interface{}{}
+	case implements(t, textMarshalerType):
+		return map[string]interface{}{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": schemaOf(defs, t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaOf(defs, t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return structSchema(defs, t)
+		}
+		name := path.Base(t.PkgPath()) + "." + t.Name()
+		if _, ok := defs[name]; !ok {
+			// Set before the fields to stop at recursive types.
+			defs[name] = map[string]interface{}{}
+			defs[name] = structSchema(defs, t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func structSchema(defs map[string]interface{}, t reflect.Type) map[string]interface{} {
+	props := make(map[string]interface{})
+	var required []string
+	var addFields func(t reflect.Type)
+	addFields = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, opts := tag, ""
+			if i := strings.Index(tag, ","); i >= 0 {
+				name, opts = tag[:i], tag[i+1:]
+			}
+			ft := f.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+				addFields(ft)
+				continue
+			}
+			if f.PkgPath != "" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			if strings.Contains(opts, "string") {
+				props[name] = map[string]interface{}{"type": "string"}
+			} else {
+				props[name] = schemaOf(defs, f.Type)
+			}
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+	}
+	addFields(t)
+
+	schema := map[string]interface{}{"type": "object", "properties": props}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PtrTo(t).Implements(iface)
+}
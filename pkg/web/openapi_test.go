@@ -0,0 +1,74 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+type testItem struct {
+	Name    string    `json:"name"`
+	Value   *big.Int  `json:"value"`
+	Time    time.Time `json:"time"`
+	Note    string    `json:"note,omitempty"`
+	Next    *testItem `json:"next,omitempty"`
+	Ignored string    `json:"-"`
+}
+
+// TestOpenAPI ensures that the registered handlers are in the document
+// with the schemas of their Go types.
+func TestOpenAPI(t *testing.T) {
+	srv, err := New(logging.NewLogger(), context.Background(), nil, Config{LogLevel: "info", BasePath: "/telliot"})
+	testutil.Ok(t, err)
+	srv.Handle("/api/v1/items", http.NotFoundHandler(), Operation{
+		Summary:  "List the items.",
+		Params:   []Param{{Name: "name", Required: true}},
+		Response: []testItem{},
+	})
+
+	rec := httptest.NewRecorder()
+	srv.srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/telliot"+OpenAPIPath, nil))
+	testutil.Equals(t, http.StatusOK, rec.Code)
+
+	var doc struct {
+		Servers []struct {
+			URL string `json:"url"`
+		} `json:"servers"`
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]map[string]interface{} `json:"properties"`
+				Required   []string                          `json:"required"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	testutil.Ok(t, json.NewDecoder(rec.Body).Decode(&doc))
+	testutil.Equals(t, "/telliot", doc.Servers[0].URL)
+
+	for _, p := range []string{"/healthz", "/readyz", "/api/v1/items"} {
+		_, ok := doc.Paths[p]["get"]
+		testutil.Assert(t, ok, "missing path:%v", p)
+	}
+	_, ok := doc.Paths["/api/v1/loglevel"]["put"]
+	testutil.Assert(t, ok, "missing the loglevel put")
+	_, ok = doc.Paths["/api/v1/keys"]
+	testutil.Assert(t, !ok, "the keys endpoints should be documented only with auth")
+
+	item, ok := doc.Components.Schemas["web.testItem"]
+	testutil.Assert(t, ok, "missing the item schema")
+	testutil.Equals(t, []string{"name", "time", "value"}, item.Required)
+	testutil.Equals(t, 5, len(item.Properties))
+	testutil.Equals(t, "integer", item.Properties["value"]["type"])
+	testutil.Equals(t, "date-time", item.Properties["time"]["format"])
+	testutil.Equals(t, "#/components/schemas/web.testItem", item.Properties["next"]["$ref"])
+}
@@ -0,0 +1,53 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+func TestParseProxy(t *testing.T) {
+	for raw, exp := range map[string]string{
+		"":                           "",
+		ProxyDirect:                  "",
+		ProxyTor:                     torAddr,
+		"socks5://user:pass@h:1080":  "socks5://user:pass@h:1080",
+		"http://proxy.example:3128":  "http://proxy.example:3128",
+		"https://proxy.example:3128": "https://proxy.example:3128",
+	} {
+		u, err := ParseProxy(raw)
+		testutil.Ok(t, err, raw)
+		if exp == "" {
+			testutil.Assert(t, u == nil, "expected no proxy for:%v", raw)
+			continue
+		}
+		testutil.Equals(t, exp, u.String())
+	}
+
+	for _, raw := range []string{"ftp://proxy.example", "proxy.example:3128", "socks5://"} {
+		_, err := ParseProxy(raw)
+		testutil.NotOk(t, err, raw)
+	}
+}
+
+func TestFetcherProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer proxy.Close()
+
+	u, err := ParseProxy(proxy.URL)
+	testutil.Ok(t, err)
+	data, err := NewFetcher(RetryConfig{MaxAttempts: 1}, u, false, nil).Fetch(context.Background(), "http://api.example/ticker")
+	testutil.Ok(t, err)
+	testutil.Equals(t, "ok", string(data))
+	testutil.Equals(t, "http://api.example/ticker", proxied)
+}
@@ -0,0 +1,78 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// TestQuery ensures that the range queries of a dashboard are evaluated against the DB
+// and that the query limits are applied.
+func TestQuery(t *testing.T) {
+	dir, err := ioutil.TempDir("", "query")
+	testutil.Ok(t, err)
+	defer os.RemoveAll(dir)
+	tsDB, err := tsdb.Open(dir, nil, nil, tsdb.DefaultOptions())
+	testutil.Ok(t, err)
+	defer tsDB.Close()
+
+	start := time.Now().Add(-time.Hour).Truncate(time.Minute)
+	app := tsDB.Appender(context.Background())
+	for i := 0; i < 60; i++ {
+		_, err := app.Append(0, labels.FromStrings("__name__", "trb_price", "source", "test"), timestamp.FromTime(start.Add(time.Duration(i)*time.Minute)), float64(i))
+		testutil.Ok(t, err)
+	}
+	testutil.Ok(t, app.Commit())
+
+	cfg := Config{
+		LogLevel: "info",
+		Query: QueryConfig{
+			Timeout:       format.Duration{Duration: 10 * time.Second},
+			MaxSamples:    100,
+			LookbackDelta: format.Duration{Duration: 5 * time.Minute},
+		},
+	}
+	srv, err := New(logging.NewLogger(), context.Background(), tsDB, cfg)
+	testutil.Ok(t, err)
+	do := func(path string) (int, map[string]interface{}) {
+		rec := httptest.NewRecorder()
+		srv.srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var resp map[string]interface{}
+		testutil.Ok(t, json.NewDecoder(rec.Body).Decode(&resp))
+		return rec.Code, resp
+	}
+
+	code, resp := do(fmt.Sprintf("/api/v1/query_range?query=trb_price&start=%d&end=%d&step=10m", start.Unix(), start.Add(time.Hour).Unix()))
+	testutil.Equals(t, http.StatusOK, code)
+	result := resp["data"].(map[string]interface{})["result"].([]interface{})
+	testutil.Equals(t, 1, len(result))
+	testutil.Equals(t, 7, len(result[0].(map[string]interface{})["values"].([]interface{})))
+
+	// Over the max samples.
+	code, resp = do(fmt.Sprintf("/api/v1/query_range?query=trb_price&start=%d&end=%d&step=30s", start.Unix(), start.Add(time.Hour).Unix()))
+	testutil.Equals(t, http.StatusUnprocessableEntity, code)
+	testutil.Equals(t, "execution", resp["errorType"])
+
+	code, resp = do("/api/v1/status/buildinfo")
+	testutil.Equals(t, http.StatusOK, code)
+	testutil.Assert(t, resp["data"].(map[string]interface{})["version"] != "", "the buildinfo should have a version")
+
+	code, _ = do("/api/v1/metadata")
+	testutil.Equals(t, http.StatusOK, code)
+}
@@ -0,0 +1,131 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package web
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tellor-io/telliot/pkg/errclass"
+	"github.com/tellor-io/telliot/pkg/format"
+)
+
+// ErrCircuitOpen is returned without fetching while the circuit breaker of a source is open.
+var ErrCircuitOpen = errclass.New(errclass.Retryable, "circuit breaker open")
+
+// RetryConfig is the retry policy of a http source.
+type RetryConfig struct {
+	// MaxAttempts is the number of requests in a single fetch before giving up.
+	MaxAttempts int
+	// BackoffMin is the wait after the first failed request which doubles
+	// after every next failed request up to BackoffMax.
+	BackoffMin format.Duration
+	BackoffMax format.Duration
+	// Jitter is the fraction of the backoff that is randomized
+	// to avoid retrying all sources at the same time, between 0 and 1.
+	Jitter float64
+	// BreakerThreshold is the number of consecutive failed fetches
+	// after which the source is not fetched for the BreakerCooldown, 0 disables the breaker.
+	BreakerThreshold int
+	BreakerCooldown  format.Duration
+}
+
+// DefaultRetry is used by the fetches without their own retry policy.
+var DefaultRetry = RetryConfig{
+	MaxAttempts: 5,
+	BackoffMin:  format.Duration{Duration: time.Second},
+	BackoffMax:  format.Duration{Duration: 30 * time.Second},
+	Jitter:      0.2,
+}
+
+// Override returns the policy with the non zero fields of the override.
+func (self RetryConfig) Override(o *RetryConfig) RetryConfig {
+	if o == nil {
+		return self
+	}
+	if o.MaxAttempts != 0 {
+		self.MaxAttempts = o.MaxAttempts
+	}
+	if o.BackoffMin.Duration != 0 {
+		self.BackoffMin = o.BackoffMin
+	}
+	if o.BackoffMax.Duration != 0 {
+		self.BackoffMax = o.BackoffMax
+	}
+	if o.Jitter != 0 {
+		self.Jitter = o.Jitter
+	}
+	if o.BreakerThreshold != 0 {
+		self.BreakerThreshold = o.BreakerThreshold
+	}
+	if o.BreakerCooldown.Duration != 0 {
+		self.BreakerCooldown = o.BreakerCooldown
+	}
+	return self
+}
+
+func (self RetryConfig) Validate() error {
+	if self.MaxAttempts <= 0 {
+		return errors.Errorf("max attempts should be more than 0:%v", self.MaxAttempts)
+	}
+	if self.BackoffMax.Duration < self.BackoffMin.Duration {
+		return errors.Errorf("max backoff:%v lower than the min backoff:%v", self.BackoffMax, self.BackoffMin)
+	}
+	if self.Jitter < 0 || self.Jitter > 1 {
+		return errors.Errorf("jitter should be between 0 and 1:%v", self.Jitter)
+	}
+	if self.BreakerThreshold > 0 && self.BreakerCooldown.Duration <= 0 {
+		return errors.New("breaker cooldown should be more than 0 when the breaker is enabled")
+	}
+	return nil
+}
+
+// Backoff returns the wait after the given failed attempt starting from 1.
+func (self RetryConfig) Backoff(attempt int) time.Duration {
+	backoff := self.BackoffMin.Duration
+	for i := 1; i < attempt && backoff < self.BackoffMax.Duration; i++ {
+		backoff *= 2
+	}
+	if backoff > self.BackoffMax.Duration {
+		backoff = self.BackoffMax.Duration
+	}
+	if self.Jitter > 0 {
+		backoff -= time.Duration(rand.Float64() * self.Jitter * float64(backoff))
+	}
+	return backoff
+}
+
+// breaker stops fetching a source after too many consecutive failures
+// and lets a single fetch through after the cooldown to check if it recovered.
+type breaker struct {
+	mtx       sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+}
+
+func (self *breaker) allow(now time.Time) bool {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	return self.threshold <= 0 || now.After(self.openUntil)
+}
+
+// done records the result of a fetch and returns true when the breaker opened.
+func (self *breaker) done(now time.Time, ok bool) bool {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	if ok {
+		self.failures = 0
+		return false
+	}
+	self.failures++
+	if self.threshold <= 0 || self.failures < self.threshold {
+		return false
+	}
+	self.openUntil = now.Add(self.cooldown)
+	return true
+}
@@ -0,0 +1,57 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package web
+
+import (
+	"net/http"
+	"runtime"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/tellor-io/telliot/pkg/tuning"
+)
+
+// RuntimeStatus is the resource usage of the process with the runtime settings.
+type RuntimeStatus struct {
+	NumCPU       int              `json:"numCPU"`
+	NumGoroutine int              `json:"numGoroutine"`
+	HeapAlloc    uint64           `json:"heapAlloc"`
+	HeapSys      uint64           `json:"heapSys"`
+	NumGC        uint32           `json:"numGC"`
+	Settings     []tuning.Setting `json:"settings"`
+}
+
+// runtimeStatus lists the runtime settings.
+func (self *Web) runtimeStatus(w http.ResponseWriter, r *http.Request) {
+	self.writeRuntime(w, http.StatusOK, nil)
+}
+
+// setRuntime changes a runtime setting without a restart.
+// For example: curl -X PUT 'localhost:9090/api/v1/runtime?name=miner.workers&value=2'
+// An empty value restores the value from the config.
+func (self *Web) setRuntime(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	value := r.URL.Query().Get("value")
+	if err := tuning.Set(name, value); err != nil {
+		self.writeRuntime(w, http.StatusBadRequest, err)
+		return
+	}
+	level.Info(self.logger).Log("msg", "runtime setting changed", "name", name, "value", value)
+	self.writeRuntime(w, http.StatusOK, nil)
+}
+
+func (self *Web) writeRuntime(w http.ResponseWriter, code int, err error) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	status := RuntimeStatus{
+		NumCPU:       runtime.NumCPU(),
+		NumGoroutine: runtime.NumGoroutine(),
+		HeapAlloc:    mem.HeapAlloc,
+		HeapSys:      mem.HeapSys,
+		NumGC:        mem.NumGC,
+		Settings:     tuning.Settings(),
+	}
+	if err := WriteJSON(w, code, status, err); err != nil {
+		level.Error(self.logger).Log("msg", "encoding runtime response", "err", err)
+	}
+}
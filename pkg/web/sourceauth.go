@@ -0,0 +1,307 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package web
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	gohash "hash"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// tokenMargin is how long before its expiry a token is refreshed.
+const tokenMargin = 30 * time.Second
+
+// SourceAuthConfig is the authentication of a http source for the providers that require signed requests or tokens.
+// All values can use env variables like ${API_SECRET} and
+// the headers, params and the HMAC payload are text/template templates executed with:
+// .Method, .Path, .Query, .Timestamp (unix seconds), .TimestampMs, .Nonce, .Token and .Signature.
+// For example: {"Headers": {"X-Signature": "{{.Signature}}", "X-Timestamp": "{{.Timestamp}}"}}.
+type SourceAuthConfig struct {
+	// Headers are added to every request.
+	Headers map[string]string
+	// Params are added to the query of every request before it is signed.
+	Params map[string]string
+	HMAC   *HMACConfig
+	Token  *TokenConfig
+}
+
+// HMACConfig signs every request with a HMAC of its payload.
+type HMACConfig struct {
+	Secret string
+	// Hash is sha1, sha256 or sha512, sha256 by default.
+	Hash string
+	// Payload is the template of the signed message, {{.Timestamp}}{{.Method}}{{.Path}}{{.Query}} by default.
+	Payload string
+	// Encoding of the signature is hex or base64, hex by default.
+	Encoding string
+	// Param adds the signature as the last query param with this name
+	// for the providers that expect it in the url.
+	Param string
+}
+
+// TokenConfig gets an OAuth2 access token with the client credentials grant.
+// The token is refreshed before it expires and after a 401 response.
+type TokenConfig struct {
+	URL          string
+	ClientID     string
+	ClientSecret string
+	Scope        string
+}
+
+// SourceAuth authenticates the requests of a single http source.
+// It is safe for concurrent use.
+type SourceAuth struct {
+	cfg     SourceAuthConfig
+	headers map[string]*template.Template
+	params  map[string]*template.Template
+	payload *template.Template
+	newHash func() gohash.Hash
+	now     func() time.Time
+
+	mtx     sync.Mutex
+	token   string
+	expires time.Time
+}
+
+type authData struct {
+	Method      string
+	Path        string
+	Query       string
+	Timestamp   int64
+	TimestampMs int64
+	Nonce       string
+	Token       string
+	Signature   string
+}
+
+// NewSourceAuth validates the config and expands its env variables.
+func NewSourceAuth(cfg SourceAuthConfig) (*SourceAuth, error) {
+	var err error
+	expand := func(s string) string {
+		return os.Expand(s, func(key string) string {
+			if os.Getenv(key) == "" {
+				err = errors.Errorf("missing required env variable in auth config:%v", key)
+			}
+			return os.Getenv(key)
+		})
+	}
+	self := &SourceAuth{
+		headers: make(map[string]*template.Template),
+		params:  make(map[string]*template.Template),
+		now:     time.Now,
+	}
+	for name, value := range cfg.Headers {
+		if self.headers[name], err = template.New(name).Parse(expand(value)); err != nil {
+			return nil, errors.Wrapf(err, "parse header template:%v", name)
+		}
+	}
+	for name, value := range cfg.Params {
+		if self.params[name], err = template.New(name).Parse(expand(value)); err != nil {
+			return nil, errors.Wrapf(err, "parse param template:%v", name)
+		}
+	}
+	if cfg.HMAC != nil {
+		h := *cfg.HMAC
+		cfg.HMAC = &h
+		h.Secret = expand(h.Secret)
+		if h.Secret == "" {
+			return nil, errors.New("missing HMAC secret")
+		}
+		switch h.Hash {
+		case "", "sha256":
+			self.newHash = sha256.New
+		case "sha512":
+			self.newHash = sha512.New
+		case "sha1":
+			self.newHash = sha1.New
+		default:
+			return nil, errors.Errorf("unsupported HMAC hash:%v, should be sha1, sha256 or sha512", h.Hash)
+		}
+		if h.Encoding != "" && h.Encoding != "hex" && h.Encoding != "base64" {
+			return nil, errors.Errorf("unsupported HMAC encoding:%v, should be hex or base64", h.Encoding)
+		}
+		payload := h.Payload
+		if payload == "" {
+			payload = "{{.Timestamp}}{{.Method}}{{.Path}}{{.Query}}"
+		}
+		if self.payload, err = template.New("payload").Parse(expand(payload)); err != nil {
+			return nil, errors.Wrap(err, "parse HMAC payload template")
+		}
+	}
+	if cfg.Token != nil {
+		t := *cfg.Token
+		cfg.Token = &t
+		t.URL = expand(t.URL)
+		t.ClientID = expand(t.ClientID)
+		t.ClientSecret = expand(t.ClientSecret)
+		if t.URL == "" {
+			return nil, errors.New("missing token url")
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	self.cfg = cfg
+	return self, nil
+}
+
+// apply adds the token, the params, the signature and the headers to the request.
+func (self *SourceAuth) apply(ctx context.Context, client *http.Client, req *http.Request) error {
+	now := self.now()
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return errors.Wrap(err, "generate nonce")
+	}
+	data := authData{
+		Method:      req.Method,
+		Timestamp:   now.Unix(),
+		TimestampMs: now.UnixNano() / int64(time.Millisecond),
+		Nonce:       hex.EncodeToString(nonce),
+	}
+	if self.cfg.Token != nil {
+		token, err := self.getToken(ctx, client)
+		if err != nil {
+			return err
+		}
+		data.Token = token
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	// The params are appended sorted by name so that the signed query is the same as the sent one.
+	query := req.URL.RawQuery
+	for _, name := range sortedKeys(self.params) {
+		value, err := execute(self.params[name], data)
+		if err != nil {
+			return err
+		}
+		query = joinQuery(query, url.QueryEscape(name)+"="+url.QueryEscape(value))
+	}
+	data.Path = req.URL.EscapedPath()
+	data.Query = query
+
+	if self.payload != nil {
+		payload, err := execute(self.payload, data)
+		if err != nil {
+			return err
+		}
+		mac := hmac.New(self.newHash, []byte(self.cfg.HMAC.Secret))
+		_, _ = mac.Write([]byte(payload))
+		if self.cfg.HMAC.Encoding == "base64" {
+			data.Signature = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+		} else {
+			data.Signature = hex.EncodeToString(mac.Sum(nil))
+		}
+		if self.cfg.HMAC.Param != "" {
+			query = joinQuery(query, url.QueryEscape(self.cfg.HMAC.Param)+"="+url.QueryEscape(data.Signature))
+		}
+	}
+	req.URL.RawQuery = query
+
+	for name, tmpl := range self.headers {
+		value, err := execute(tmpl, data)
+		if err != nil {
+			return err
+		}
+		req.Header.Set(name, value)
+	}
+	return nil
+}
+
+// invalidate drops the token so that the next request gets a new one.
+func (self *SourceAuth) invalidate() {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	self.token = ""
+}
+
+func (self *SourceAuth) getToken(ctx context.Context, client *http.Client) (string, error) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	if self.token != "" && (self.expires.IsZero() || self.now().Before(self.expires.Add(-tokenMargin))) {
+		return self.token, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if self.cfg.Token.Scope != "" {
+		form.Set("scope", self.cfg.Token.Scope)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, self.cfg.Token.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", errors.Wrap(err, "creating token request")
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(self.cfg.Token.ClientID), url.QueryEscape(self.cfg.Token.ClientSecret))
+	r, err := client.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "requesting token")
+	}
+	defer r.Body.Close()
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return "", errors.Wrap(err, "read token response body")
+	}
+	if r.StatusCode/100 != 2 {
+		return "", errors.Errorf("token response status code not OK code:%v, payload:%v", r.StatusCode, string(body))
+	}
+	resp := struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}{}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", errors.Wrap(err, "parse token response")
+	}
+	if resp.AccessToken == "" {
+		return "", errors.New("token response without an access token")
+	}
+	self.token = resp.AccessToken
+	// A token without an expiry is kept until a 401 response.
+	self.expires = time.Time{}
+	if resp.ExpiresIn > 0 {
+		self.expires = self.now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	}
+	return self.token, nil
+}
+
+func execute(tmpl *template.Template, data authData) (string, error) {
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", errors.Wrapf(err, "execute auth template:%v", tmpl.Name())
+	}
+	return b.String(), nil
+}
+
+func joinQuery(query, param string) string {
+	if query == "" {
+		return param
+	}
+	return query + "&" + param
+}
+
+func sortedKeys(m map[string]*template.Template) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,103 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package web
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+func TestSourceAuthHMAC(t *testing.T) {
+	testutil.Ok(t, os.Setenv("TEST_API_SECRET", "secret"))
+	defer os.Unsetenv("TEST_API_SECRET")
+
+	var query, signature string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		signature = r.Header.Get("X-Signature")
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	auth, err := NewSourceAuth(SourceAuthConfig{
+		Headers: map[string]string{"X-Signature": "{{.Signature}}"},
+		Params:  map[string]string{"timestamp": "{{.TimestampMs}}"},
+		HMAC:    &HMACConfig{Secret: "${TEST_API_SECRET}", Payload: "{{.Method}}{{.Path}}?{{.Query}}", Param: "signature"},
+	})
+	testutil.Ok(t, err)
+	auth.now = func() time.Time { return time.Unix(1600000000, 0) }
+
+	fetcher := NewFetcher(DefaultRetry, nil, false, nil)
+	fetcher.SetAuth(auth)
+	_, err = fetcher.Fetch(context.Background(), srv.URL+"/price?symbol=ETHUSD")
+	testutil.Ok(t, err)
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	_, _ = mac.Write([]byte("GET/price?symbol=ETHUSD&timestamp=1600000000000"))
+	expected := hex.EncodeToString(mac.Sum(nil))
+	testutil.Equals(t, expected, signature)
+	testutil.Equals(t, "symbol=ETHUSD&timestamp=1600000000000&signature="+expected, query)
+
+	_, err = NewSourceAuth(SourceAuthConfig{HMAC: &HMACConfig{Secret: "${TEST_MISSING_SECRET}"}})
+	testutil.NotOk(t, err)
+}
+
+func TestSourceAuthToken(t *testing.T) {
+	var tokens int
+	var revoked bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			id, secret, _ := r.BasicAuth()
+			testutil.Equals(t, "id", id)
+			testutil.Equals(t, "secret", secret)
+			tokens++
+			fmt.Fprintf(w, `{"access_token":"token%d","expires_in":60}`, tokens)
+			return
+		}
+		if revoked && r.Header.Get("Authorization") == "Bearer token1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer srv.Close()
+
+	auth, err := NewSourceAuth(SourceAuthConfig{Token: &TokenConfig{URL: srv.URL + "/token", ClientID: "id", ClientSecret: "secret"}})
+	testutil.Ok(t, err)
+	now := time.Now()
+	auth.now = func() time.Time { return now }
+
+	cfg := RetryConfig{MaxAttempts: 2, BackoffMin: format.Duration{Duration: time.Millisecond}, BackoffMax: format.Duration{Duration: time.Millisecond}}
+	fetcher := NewFetcher(cfg, nil, false, nil)
+	fetcher.SetAuth(auth)
+	fetch := func() string {
+		data, err := fetcher.Fetch(context.Background(), srv.URL)
+		testutil.Ok(t, err)
+		return string(data)
+	}
+
+	testutil.Equals(t, "Bearer token1", fetch())
+	testutil.Equals(t, "Bearer token1", fetch())
+	testutil.Equals(t, 1, tokens)
+
+	// A revoked token is refreshed on the retry.
+	revoked = true
+	testutil.Equals(t, "Bearer token2", fetch())
+
+	// The token is refreshed before it expires.
+	now = now.Add(45 * time.Second)
+	testutil.Equals(t, "Bearer token3", fetch())
+	testutil.Equals(t, 3, tokens)
+}
@@ -6,8 +6,10 @@ package web
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/pprof"
+	"os"
 	"strings"
 	"time"
 
@@ -18,18 +20,48 @@ import (
 	"github.com/prometheus/common/route"
 	"github.com/prometheus/prometheus/promql"
 	"github.com/prometheus/prometheus/storage"
+	"github.com/tellor-io/telliot/pkg/db"
 	"github.com/tellor-io/telliot/pkg/format"
+	"github.com/tellor-io/telliot/pkg/health"
 	"github.com/tellor-io/telliot/pkg/logging"
 	"github.com/tellor-io/telliot/pkg/web/api"
 )
 
 const ComponentName = "web"
 
+// shutdownTimeout is how long stopping waits for the requests in progress.
+const shutdownTimeout = 5 * time.Second
+
 type Config struct {
-	LogLevel    string
+	LogLevel string
+	// ListenHost is the address of the API, empty for all interfaces.
+	// Without the auth it should be a loopback address because the API can control the components.
 	ListenHost  string
 	ListenPort  uint
 	ReadTimeout format.Duration
+	// Auth requires an API key for all requests except the health checks.
+	Auth AuthConfig
+	// BasePath is the path prefix when served behind a reverse proxy at a sub-path e.g. /telliot.
+	BasePath string
+	// TrustedProxies are the addresses or CIDRs of the reverse proxies
+	// whose X-Forwarded-For header is used for the client address.
+	TrustedProxies []string
+	// CORSOrigins are the origins allowed to call the API from a browser, * for any.
+	CORSOrigins []string
+	// Gzip compresses the responses for the clients that accept it.
+	Gzip bool
+	// Query limits the PromQL queries of the query API.
+	Query QueryConfig
+}
+
+// QueryConfig limits the PromQL queries against the DB
+// e.g. the range queries of the Grafana dashboards.
+type QueryConfig struct {
+	Timeout format.Duration
+	// MaxSamples is the maximum number of samples loaded by a single query.
+	MaxSamples int
+	// LookbackDelta is how far back an instant vector selector looks for the latest sample.
+	LookbackDelta format.Duration
 }
 
 type Web struct {
@@ -38,6 +70,14 @@ type Web struct {
 	ctx    context.Context
 	stop   context.CancelFunc
 	srv    *http.Server
+	router *route.Router
+	// listener is served instead of listening on the configured address when set.
+	listener net.Listener
+
+	healthReporters []health.Reporter
+	audit           *db.Audit
+	keys            *Keys
+	ops             map[string]map[string]Operation
 }
 
 func New(logger log.Logger, ctx context.Context, tsDB storage.SampleAndChunkQueryable, cfg Config) (*Web, error) {
@@ -47,26 +87,60 @@ func New(logger log.Logger, ctx context.Context, tsDB storage.SampleAndChunkQuer
 	}
 	router := route.New()
 
-	router.Get("/debug/*subpath", serveDebug)
-	router.Post("/debug/*subpath", serveDebug)
+	// The debug endpoints and the runtime settings can stop or slow down the process
+	// so are only served to local clients or with the auth.
+	protected := cfg.Auth.Enabled || isLoopback(cfg.ListenHost)
+	if protected {
+		router.Get("/debug/*subpath", serveDebug)
+		router.Post("/debug/*subpath", serveDebug)
+	}
 
 	router.Get("/metrics", promhttp.Handler().ServeHTTP)
 
-	opts := promql.EngineOpts{
-		Logger:               logger,
-		Reg:                  nil,
-		MaxSamples:           100000,
-		Timeout:              10 * time.Second,
-		EnableAtModifier:     true,
-		EnableNegativeOffset: true,
+	// The roles that don't use a DB serve only the health, metrics and their own handlers.
+	if tsDB != nil {
+		opts := promql.EngineOpts{
+			Logger:               logger,
+			Reg:                  nil,
+			MaxSamples:           cfg.Query.MaxSamples,
+			Timeout:              cfg.Query.Timeout.Duration,
+			LookbackDelta:        cfg.Query.LookbackDelta.Duration,
+			EnableAtModifier:     true,
+			EnableNegativeOffset: true,
+		}
+		engine := promql.NewEngine(opts)
+
+		api := api.New(logger, ctx, engine, tsDB)
+		api.Register(router.WithPrefix("/api/v1"))
 	}
-	engine := promql.NewEngine(opts)
 
-	api := api.New(logger, ctx, engine, tsDB)
-	api.Register(router.WithPrefix("/api/v1"))
+	if !cfg.Auth.Enabled && !isLoopback(cfg.ListenHost) {
+		level.Warn(logger).Log("msg", "the API has no auth and listens on a non loopback address, any client that can reach it can control the components", "host", cfg.ListenHost)
+	}
+
+	var keys *Keys
+	var handler http.Handler = router
+	if cfg.Auth.Enabled {
+		keys, err = NewKeys(logger, cfg.Auth, os.Getenv(AdminKeyEnvName))
+		if err != nil {
+			return nil, errors.Wrap(err, "loading API keys")
+		}
+		handler = keys.Wrap(router)
+	}
+	if cfg.Gzip {
+		handler = compress(handler)
+	}
+	// The preflight requests never have the API key so are answered before the auth.
+	handler = cors(cfg.CORSOrigins, handler)
+	proxies, err := parseProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	handler = forwardedFor(proxies, handler)
+	handler = basePath(cfg.BasePath, handler)
 
 	mux := http.NewServeMux()
-	mux.Handle("/", router)
+	mux.Handle("/", handler)
 
 	srv := &http.Server{
 		Handler:     mux,
@@ -76,17 +150,172 @@ func New(logger log.Logger, ctx context.Context, tsDB storage.SampleAndChunkQuer
 
 	ctx, stop := context.WithCancel(ctx)
 
-	return &Web{
+	web := &Web{
 		logger: log.With(logger, "component", ComponentName),
 		cfg:    cfg,
 		ctx:    ctx,
 		stop:   stop,
 		srv:    srv,
-	}, nil
+		router: router,
+		keys:   keys,
+		ops:    make(map[string]map[string]Operation),
+	}
+
+	router.Get(OpenAPIPath, web.serveOpenAPI)
+	web.Handle("/healthz", http.HandlerFunc(web.healthz), Operation{
+		Summary:  "Liveness probe which fails when a critical component is degraded.",
+		Response: healthReport{},
+		Raw:      true,
+	})
+	web.Handle("/readyz", http.HandlerFunc(web.readyz), Operation{
+		Summary:  "Readiness probe which fails when a critical component is not ready.",
+		Response: healthReport{},
+		Raw:      true,
+	})
+	web.Handle("/api/v1/loglevel", http.HandlerFunc(web.logLevels), Operation{
+		Summary:  "List the log level of all components.",
+		Response: []logging.ComponentLevel{},
+	})
+	router.Put("/api/v1/loglevel", web.audited(web.setLogLevel))
+	web.document(http.MethodPut, "/api/v1/loglevel", Operation{
+		Summary: "Change the log level of a component without a restart.",
+		Params: []Param{
+			{Name: "component", Description: "The component name.", Required: true},
+			{Name: "level", Description: "The new level, empty restores the level from the config."},
+		},
+		Response: []logging.ComponentLevel{},
+	})
+	web.Handle("/api/v1/runtime", http.HandlerFunc(web.runtimeStatus), Operation{
+		Summary:  "Show the resource usage and the runtime settings.",
+		Response: RuntimeStatus{},
+	})
+	if protected {
+		router.Put("/api/v1/runtime", web.audited(web.setRuntime))
+		web.document(http.MethodPut, "/api/v1/runtime", Operation{
+			Summary: "Change a runtime setting like GOMAXPROCS, the mining workers or a tracker interval without a restart.",
+			Params: []Param{
+				{Name: "name", Description: "The setting name.", Required: true},
+				{Name: "value", Description: "The new value, empty restores the value from the config."},
+			},
+			Response: RuntimeStatus{},
+		})
+	} else {
+		level.Warn(logger).Log("msg", "the debug endpoints and the runtime settings are disabled because the API has no auth")
+	}
+	if tsDB != nil {
+		for _, method := range []string{http.MethodGet, http.MethodPost} {
+			web.document(method, "/api/v1/query", Operation{
+				Summary: "Evaluate a PromQL instant query against the local DB.",
+				Params: []Param{
+					{Name: "query", Description: "The PromQL expression.", Required: true},
+					{Name: "time", Description: "The evaluation unix timestamp, defaults to now."},
+				},
+				Response: map[string]interface{}{},
+			})
+			web.document(method, "/api/v1/query_range", Operation{
+				Summary: "Evaluate a PromQL range query against the local DB.",
+				Params: []Param{
+					{Name: "query", Description: "The PromQL expression.", Required: true},
+					{Name: "start", Description: "The start unix timestamp.", Required: true},
+					{Name: "end", Description: "The end unix timestamp.", Required: true},
+					{Name: "step", Description: "The resolution step e.g. 15s.", Required: true},
+				},
+				Response: map[string]interface{}{},
+			})
+			web.document(method, "/api/v1/series", Operation{
+				Summary: "List the series that match the selectors.",
+				Params: []Param{
+					{Name: "match[]", Description: "A series selector, repeated for several.", Required: true},
+					{Name: "start", Description: "The start unix timestamp."},
+					{Name: "end", Description: "The end unix timestamp."},
+				},
+				Response: []map[string]string{},
+			})
+			web.document(method, "/api/v1/labels", Operation{
+				Summary:  "List the label names.",
+				Response: []string{},
+			})
+		}
+	}
+	if keys != nil {
+		web.Handle("/api/v1/keys", http.HandlerFunc(keys.serveList), Operation{
+			Summary:  "List the API keys without their secrets.",
+			Response: []APIKey{},
+		})
+		web.HandlePost("/api/v1/keys", http.HandlerFunc(keys.serveIssue), Operation{
+			Summary:  "Issue an API key. The key is in the response only.",
+			Request:  IssueRequest{},
+			Response: IssueResponse{},
+		})
+		web.HandlePost("/api/v1/keys/revoke", http.HandlerFunc(keys.serveRevoke), Operation{
+			Summary:  "Revoke an API key.",
+			Params:   []Param{{Name: "id", Description: "The key ID.", Required: true}},
+			Response: APIKey{},
+		})
+	}
 
+	return web, nil
+
+}
+
+// AuthEnabled returns true when all requests except the health checks require an API key.
+// The handlers that change the state of the components or sign transactions
+// should only be registered when it is true.
+func (self *Web) AuthEnabled() bool {
+	return self.keys != nil
+}
+
+// Protected returns true when the API requires a key or accepts only local connections.
+func (self *Web) Protected() bool {
+	return self.AuthEnabled() || isLoopback(self.cfg.ListenHost)
+}
+
+// isLoopback returns true when the listen host accepts only local connections.
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Handle registers an additional GET handler
+// and adds the operation to the OpenAPI document.
+// It should be called before starting the server.
+func (self *Web) Handle(path string, handler http.Handler, op Operation) {
+	self.router.Get(path, handler.ServeHTTP)
+	self.document(http.MethodGet, path, op)
+}
+
+// HandlePost registers an additional POST handler
+// and adds the operation to the OpenAPI document.
+// The requests are recorded in the audit log when set.
+// It should be called before starting the server.
+func (self *Web) HandlePost(path string, handler http.Handler, op Operation) {
+	self.router.Post(path, self.audited(handler.ServeHTTP))
+	self.document(http.MethodPost, path, op)
+}
+
+// Addr is the configured listen address.
+func (self *Web) Addr() string {
+	return self.srv.Addr
+}
+
+// SetListener serves on the listener instead of listening on the configured address
+// e.g. the listener inherited from the process before an upgrade.
+// It should be called before starting the server.
+func (self *Web) SetListener(listener net.Listener) {
+	self.listener = listener
 }
 
 func (self *Web) Start() error {
+	if self.listener != nil {
+		level.Info(self.logger).Log("msg", "starting", "addr", self.listener.Addr())
+		if err := self.srv.Serve(self.listener); err != http.ErrServerClosed {
+			return errors.Wrapf(err, "Serve")
+		}
+		return nil
+	}
 	level.Info(self.logger).Log("msg", "starting", "addr", self.srv.Addr)
 	if err := self.srv.ListenAndServe(); err != http.ErrServerClosed {
 		return errors.Wrapf(err, "ListenAndServe")
@@ -94,10 +323,17 @@ func (self *Web) Start() error {
 	return nil
 }
 
+// Stop waits for the requests in progress up to shutdownTimeout
+// so that they are not dropped when the process is stopped for an upgrade.
 func (self *Web) Stop() {
 	self.stop()
-	if err := self.srv.Close(); err != nil {
-		level.Error(self.logger).Log("msg", "closing srv", "err", err)
+	ctx, cncl := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cncl()
+	if err := self.srv.Shutdown(ctx); err != nil {
+		level.Error(self.logger).Log("msg", "shutting down srv", "err", err)
+		if err := self.srv.Close(); err != nil {
+			level.Error(self.logger).Log("msg", "closing srv", "err", err)
+		}
 	}
 }
 
@@ -106,7 +342,9 @@ func serveDebug(w http.ResponseWriter, req *http.Request) {
 	subpath := route.Param(ctx, "subpath")
 
 	if subpath == "/pprof" {
-		http.Redirect(w, req, req.URL.Path+"/", http.StatusMovedPermanently)
+		// Relative so that it works behind a reverse proxy at a sub-path.
+		w.Header().Set("Location", "pprof/")
+		w.WriteHeader(http.StatusMovedPermanently)
 		return
 	}
 
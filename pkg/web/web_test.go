@@ -0,0 +1,39 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tellor-io/telliot/pkg/logging"
+	"github.com/tellor-io/telliot/pkg/testutil"
+)
+
+// TestProtectedRoutes ensures that the debug endpoints and the runtime settings
+// aren't served without the auth on a non loopback address.
+func TestProtectedRoutes(t *testing.T) {
+	for host, exp := range map[string]bool{
+		"":          false,
+		"0.0.0.0":   false,
+		"10.0.0.1":  false,
+		"127.0.0.1": true,
+		"::1":       true,
+		"localhost": true,
+	} {
+		srv, err := New(logging.NewLogger(), context.Background(), nil, Config{LogLevel: "info", ListenHost: host})
+		testutil.Ok(t, err)
+		testutil.Assert(t, !srv.AuthEnabled(), "auth enabled without the config")
+
+		rec := httptest.NewRecorder()
+		srv.srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/cmdline", nil))
+		testutil.Equals(t, exp, rec.Code == http.StatusOK, "debug endpoint on host:%v", host)
+
+		rec = httptest.NewRecorder()
+		srv.srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/v1/runtime?name=unknown", nil))
+		testutil.Equals(t, exp, rec.Code != http.StatusNotFound && rec.Code != http.StatusMethodNotAllowed, "runtime settings on host:%v code:%v", host, rec.Code)
+	}
+}